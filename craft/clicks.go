@@ -0,0 +1,133 @@
+package craft
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Container click modes and buttons
+const (
+	ModePickup    = 0 // Plain click, picks up or puts down
+	ModeQuickMove = 1 // Shift click
+	ButtonLeft    = 0
+	ButtonRight   = 1 // With ModePickup: puts down a single item
+)
+
+// Stack is the item stack in a slot, the zero value being an empty slot
+type Stack struct {
+	Item  string
+	Count int
+}
+
+// Layout describes the slots of a window with a crafting grid
+type Layout struct {
+	Result    int // Slot of the crafting output
+	GridStart int // First slot of the grid, the grid is row by row
+	GridWidth int // 2 for the player inventory, 3 for a crafting table
+	InvStart  int // First window slot holding the player's items
+	InvEnd    int // Slot after the last one holding the player's items
+}
+
+var (
+	// InventoryLayout is the player inventory window with its 2x2 grid
+	InventoryLayout = Layout{Result: 0, GridStart: 1, GridWidth: 2, InvStart: 9, InvEnd: 45}
+	// TableLayout is the crafting table window
+	TableLayout = Layout{Result: 0, GridStart: 1, GridWidth: 3, InvStart: 10, InvEnd: 46}
+)
+
+// Click is one container click with what the client predicts it changes
+type Click struct {
+	Slot    int
+	Button  int
+	Mode    int
+	Changed map[int]Stack // Slots the click changes and their new contents
+	Cursor  Stack         // Stack on the cursor after the click
+}
+
+// errGridNotEmpty is returned when items left in the grid would mix with a recipe
+var errGridNotEmpty = errors.New("crafting grid is not empty")
+
+// Clicks returns the clicks that place the ingredients of a step in the grid, one cell at a time,
+// and shift-click the result into the inventory. slots holds the window contents by slot and is
+// updated with the predicted result, except for where the crafted items land.
+func (l Layout) Clicks(step Step, slots []Stack) ([]Click, error) {
+	if len(slots) < max(l.InvEnd, l.GridStart+l.GridWidth*l.GridWidth) {
+		return nil, fmt.Errorf("window has %d slots, too few for the layout", len(slots))
+	}
+
+	// Map the 3x3 frame of the step to grid slots
+	var order []string
+	cells := make(map[string][]int)
+	for i, item := range step.Grid {
+		if item == "" {
+			continue
+		}
+		x, y := i%3, i/3
+		if x >= l.GridWidth || y >= l.GridWidth {
+			return nil, fmt.Errorf("%s needs a 3x3 grid", step.Recipe.Result)
+		}
+		slot := l.GridStart + y*l.GridWidth + x
+		if slots[slot].Count > 0 {
+			return nil, errGridNotEmpty
+		}
+		if cells[item] == nil {
+			order = append(order, item)
+		}
+		cells[item] = append(cells[item], slot)
+	}
+
+	var clicks []Click
+	for _, item := range order {
+		var cursor Stack
+		origin := -1
+		for _, cell := range cells[item] {
+			for range step.Times {
+				if cursor.Count == 0 {
+					origin = l.find(slots, item)
+					if origin < 0 {
+						return nil, &MissingError{Item: item, Count: 1}
+					}
+					cursor, slots[origin] = slots[origin], Stack{}
+					clicks = append(clicks, Click{Slot: origin, Button: ButtonLeft, Mode: ModePickup,
+						Changed: map[int]Stack{origin: {}}, Cursor: cursor})
+				}
+
+				slots[cell] = Stack{Item: item, Count: slots[cell].Count + 1}
+				cursor.Count--
+				if cursor.Count == 0 {
+					cursor = Stack{}
+				}
+				clicks = append(clicks, Click{Slot: cell, Button: ButtonRight, Mode: ModePickup,
+					Changed: map[int]Stack{cell: slots[cell]}, Cursor: cursor})
+			}
+		}
+		// Put the rest back where it came from
+		if cursor.Count > 0 {
+			slots[origin], cursor = cursor, Stack{}
+			clicks = append(clicks, Click{Slot: origin, Button: ButtonLeft, Mode: ModePickup,
+				Changed: map[int]Stack{origin: slots[origin]}, Cursor: cursor})
+		}
+	}
+
+	// Shift-clicking the output crafts until the grid runs out
+	used := make(map[int]Stack)
+	for i := range l.GridWidth * l.GridWidth {
+		if s := slots[l.GridStart+i]; s.Count > 0 {
+			slots[l.GridStart+i] = Stack{}
+			used[l.GridStart+i] = Stack{}
+		}
+	}
+	clicks = append(clicks, Click{Slot: l.Result, Button: ButtonLeft, Mode: ModeQuickMove, Changed: used})
+	return clicks, nil
+}
+
+// find returns the inventory slot holding the smallest stack of an item, -1 when there is none
+func (l Layout) find(slots []Stack, item string) int {
+	best := -1
+	for i := l.InvStart; i < l.InvEnd; i++ {
+		if slots[i].Item == item && slots[i].Count > 0 && (best < 0 || slots[i].Count < slots[best].Count) {
+			best = i
+		}
+	}
+	return best
+}
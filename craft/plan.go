@@ -0,0 +1,127 @@
+package craft
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+const (
+	maxDepth = 4  // Levels of intermediate items (logs to planks to sticks...) a plan may craft
+	maxBatch = 64 // Crafts per step, a grid cell holds at most a stack
+)
+
+// Step is one crafting operation: a recipe crafted Times times with the item chosen for every cell.
+// Grid is row by row in a 3x3 frame, empty strings being empty cells.
+type Step struct {
+	Recipe Recipe
+	Times  int
+	Grid   [9]string
+}
+
+// MissingError reports an ingredient neither held nor craftable from what is held
+type MissingError struct {
+	Item  string
+	Count int
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("missing %d %s", e.Count, e.Item)
+}
+
+// Plan works out the steps that craft count of an item from the items held, crafting
+// missing ingredients first when possible. have maps item names to counts and is not modified.
+func (b *Book) Plan(item string, count int, have map[string]int) ([]Step, error) {
+	item = Namespaced(item)
+	if len(b.Find(item)) == 0 {
+		return nil, fmt.Errorf("no recipe for %s", item)
+	}
+	// Only the crafted count counts, the target item already held is not used up
+	stock := maps.Clone(have)
+	if stock == nil {
+		stock = make(map[string]int)
+	}
+	stock[item] = 0
+	return b.resolve(item, count, stock, 0)
+}
+
+// resolve takes count of item out of stock, crafting what is missing.
+// stock is updated with everything used and produced.
+func (b *Book) resolve(item string, count int, stock map[string]int, depth int) ([]Step, error) {
+	missing := count - stock[item]
+	if missing <= 0 {
+		stock[item] -= count
+		return nil, nil
+	}
+	recipes := b.Find(item)
+	if len(recipes) == 0 || depth >= maxDepth {
+		return nil, &MissingError{Item: item, Count: missing}
+	}
+
+	var firstErr error
+	for _, r := range recipes {
+		trial := maps.Clone(stock)
+		steps, err := b.craftWith(r, (missing+r.Count-1)/r.Count, trial, depth)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		trial[item] -= count
+		maps.Copy(stock, trial)
+		return steps, nil
+	}
+	// An ingredient that can't be crafted either is what is missing, not what it is crafted from
+	if depth > 0 {
+		return nil, &MissingError{Item: item, Count: missing}
+	}
+	return nil, firstErr
+}
+
+// craftWith plans crafting a recipe times times, adding the result to stock
+func (b *Book) craftWith(r Recipe, times int, stock map[string]int, depth int) ([]Step, error) {
+	var steps []Step
+	for times > 0 {
+		batch := min(times, maxBatch)
+		step := Step{Recipe: r, Times: batch}
+		for i, alternatives := range r.cells(3) {
+			if alternatives == nil {
+				continue
+			}
+			pre, chosen, err := b.choose(alternatives, batch, stock, depth)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, pre...)
+			step.Grid[i] = chosen
+		}
+		steps = append(steps, step)
+		stock[r.Result] += batch * r.Count
+		times -= batch
+	}
+	return steps, nil
+}
+
+// choose picks the alternative to fill a cell with count items, preferring what is held
+// in the largest amount, and crafts it when nothing is held in sufficient amount
+func (b *Book) choose(alternatives []string, count int, stock map[string]int, depth int) ([]Step, string, error) {
+	sorted := slices.Clone(alternatives)
+	slices.SortStableFunc(sorted, func(x, y string) int { return cmp.Compare(stock[y], stock[x]) })
+
+	var firstErr error
+	for _, alt := range sorted {
+		trial := maps.Clone(stock)
+		steps, err := b.resolve(alt, count, trial, depth+1)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		maps.Copy(stock, trial)
+		return steps, alt, nil
+	}
+	return nil, "", firstErr
+}
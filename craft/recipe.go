@@ -0,0 +1,156 @@
+// Package craft knows crafting recipes and works out the container clicks
+// that craft an item in the player inventory grid or at a crafting table.
+package craft
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:embed recipes.json
+var bundledRecipes []byte
+
+// Recipe is a crafting recipe. Shaped recipes have a pattern whose characters are
+// looked up in Key, a space being an empty cell. Shapeless recipes list their ingredients.
+// Every ingredient is a list of items any of which will do.
+type Recipe struct {
+	Result      string              `json:"result"`
+	Count       int                 `json:"count"`
+	Pattern     []string            `json:"pattern,omitempty"`
+	Key         map[string][]string `json:"key,omitempty"`
+	Ingredients [][]string          `json:"ingredients,omitempty"`
+}
+
+// Size returns the width and height of the grid area the recipe needs
+func (r Recipe) Size() (width, height int) {
+	if len(r.Pattern) == 0 {
+		n := len(r.Ingredients)
+		if n <= 1 {
+			return n, n
+		}
+		if n <= 4 {
+			return 2, 2
+		}
+		return 3, 3
+	}
+	for _, row := range r.Pattern {
+		width = max(width, len(row))
+	}
+	return width, len(r.Pattern)
+}
+
+// FitsInventory reports whether the recipe can be crafted in the 2x2 grid of the player inventory
+func (r Recipe) FitsInventory() bool {
+	w, h := r.Size()
+	return w <= 2 && h <= 2
+}
+
+// cells returns the ingredient alternatives of every cell of the recipe in a grid of the given width,
+// row by row, nil for empty cells
+func (r Recipe) cells(gridWidth int) [][]string {
+	cells := make([][]string, gridWidth*gridWidth)
+	if len(r.Pattern) == 0 {
+		for i, ing := range r.Ingredients {
+			cells[i] = ing
+		}
+		return cells
+	}
+	for y, row := range r.Pattern {
+		for x, c := range row {
+			if c != ' ' {
+				cells[y*gridWidth+x] = r.Key[string(c)]
+			}
+		}
+	}
+	return cells
+}
+
+// Book is a set of recipes by result item
+type Book struct {
+	recipes map[string][]Recipe
+}
+
+// Load parses recipes from JSON, a list of Recipe objects
+func Load(data []byte) (*Book, error) {
+	var recipes []Recipe
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		return nil, fmt.Errorf("failed to parse recipes: %w", err)
+	}
+
+	b := &Book{recipes: make(map[string][]Recipe)}
+	for i, r := range recipes {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("recipe %d (%s): %w", i, r.Result, err)
+		}
+		b.recipes[r.Result] = append(b.recipes[r.Result], r)
+	}
+	return b, nil
+}
+
+// validate checks the recipe is well formed
+func (r Recipe) validate() error {
+	if r.Result == "" || r.Count <= 0 {
+		return fmt.Errorf("missing result")
+	}
+	if (len(r.Pattern) == 0) == (len(r.Ingredients) == 0) {
+		return fmt.Errorf("needs either a pattern or ingredients")
+	}
+	if w, h := r.Size(); w > 3 || h > 3 || len(r.Ingredients) > 9 {
+		return fmt.Errorf("does not fit a 3x3 grid")
+	}
+	for _, row := range r.Pattern {
+		for _, c := range row {
+			if c != ' ' && len(r.Key[string(c)]) == 0 {
+				return fmt.Errorf("no key for %q", c)
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultBook *Book
+)
+
+// Default returns the recipes bundled with the package: tools, sticks, planks,
+// crafting tables, chests, furnaces, torches and a few iron items
+func Default() *Book {
+	defaultOnce.Do(func() {
+		b, err := Load(bundledRecipes)
+		if err != nil {
+			panic(err)
+		}
+		defaultBook = b
+	})
+	return defaultBook
+}
+
+// Find returns the recipes crafting an item, "minecraft:" may be left out
+func (b *Book) Find(item string) []Recipe {
+	return b.recipes[Namespaced(item)]
+}
+
+// Filter returns the recipes of the book that keep accepts
+func (b *Book) Filter(keep func(Recipe) bool) *Book {
+	kept := &Book{recipes: make(map[string][]Recipe)}
+	for result, recipes := range b.recipes {
+		for _, r := range recipes {
+			if keep(r) {
+				kept.recipes[result] = append(kept.recipes[result], r)
+			}
+		}
+	}
+	return kept
+}
+
+// Namespaced adds the minecraft namespace to an item name that has none
+func Namespaced(item string) string {
+	if strings.Contains(item, ":") {
+		return item
+	}
+	return "minecraft:" + item
+}
@@ -1,9 +1,31 @@
 module github.com/coolguycoder/Minecraft-Miner
 
-go 1.24
+go 1.24.0
 
-require github.com/Tnze/go-mc v1.20.3-0.20241224032005-539b4a3a7f03
+require (
+	github.com/Tnze/go-mc v1.20.3-0.20241224032005-539b4a3a7f03
+	github.com/jackc/pgx/v5 v5.8.0
+	github.com/yuin/gopher-lua v1.1.2
+	modernc.org/sqlite v1.40.1
+)
 
-require github.com/google/uuid v1.3.0 // indirect
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/iancoleman/strcase v0.2.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/sync v0.17.0 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+	golang.org/x/text v0.29.0 // indirect
+	modernc.org/libc v1.66.10 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+)
 
 replace github.com/Tnze/go-mc => ./go-mc-local
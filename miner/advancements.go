@@ -0,0 +1,225 @@
+package miner
+
+import (
+	"bytes"
+	"cmp"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// advancementItems are the advancements earned by obtaining an item, which confirm the bot
+// really got it
+var advancementItems = map[string]string{
+	"minecraft:story/mine_stone":              "minecraft:cobblestone",
+	"minecraft:story/smelt_iron":              "minecraft:iron_ingot",
+	"minecraft:story/mine_diamond":            "minecraft:diamond",
+	"minecraft:nether/obtain_ancient_debris":  "minecraft:ancient_debris",
+	"minecraft:nether/obtain_crying_obsidian": "minecraft:crying_obsidian",
+	"minecraft:story/form_obsidian":           "minecraft:obsidian",
+}
+
+// advancement is one advancement the server told the bot about
+type advancement struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title,omitempty"` // Unset for hidden advancements like recipes
+	Description  string     `json:"description,omitempty"`
+	Earned       *time.Time `json:"earned,omitempty"`
+	requirements [][]string
+	criteria     map[string]bool // Criteria met so far
+}
+
+// done reports whether every requirement has a criterion met
+func (a *advancement) done() bool {
+	for _, group := range a.requirements {
+		if !slices.ContainsFunc(group, func(c string) bool { return a.criteria[c] }) {
+			return false
+		}
+	}
+	return len(a.requirements) > 0
+}
+
+// advancementTracker keeps the advancements of the bot and which are earned
+type advancementTracker struct {
+	mu    sync.Mutex
+	known map[string]*advancement
+}
+
+// registerAdvancementHandler follows the advancement packets
+func (b *Bot) registerAdvancementHandler() {
+	b.advancements.known = make(map[string]*advancement)
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundUpdateAdvancements, F: b.onAdvancements})
+}
+
+// onAdvancements applies an advancement update and announces advancements the bot just
+// earned. The first update after joining lists those earned before, which are not announced.
+func (b *Bot) onAdvancements(p pk.Packet) error {
+	var (
+		reset, hasParent, hasDisplay pk.Boolean
+		count, frame                 pk.VarInt
+		id, parent, background       pk.Identifier
+		title, description           chat.Message
+		flags                        pk.Int
+		x, y                         pk.Float
+		telemetry                    pk.Boolean
+	)
+	r := bytes.NewReader(p.Data)
+	if _, err := (pk.Tuple{&reset, &count}).ReadFrom(r); err != nil {
+		return err
+	}
+
+	t := &b.advancements
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if reset {
+		t.known = make(map[string]*advancement)
+	}
+	for range int(count) {
+		if _, err := (pk.Tuple{&id, &hasParent}).ReadFrom(r); err != nil {
+			return err
+		}
+		if hasParent {
+			if _, err := parent.ReadFrom(r); err != nil {
+				return err
+			}
+		}
+		a := &advancement{ID: string(id), criteria: make(map[string]bool)}
+		if _, err := hasDisplay.ReadFrom(r); err != nil {
+			return err
+		}
+		if hasDisplay {
+			if _, err := (pk.Tuple{&title, &description}).ReadFrom(r); err != nil {
+				return err
+			}
+			if _, _, err := readItemDetails(r); err != nil {
+				return err // Icon
+			}
+			if _, err := (pk.Tuple{&frame, &flags}).ReadFrom(r); err != nil {
+				return err
+			}
+			if flags&0x01 != 0 {
+				if _, err := background.ReadFrom(r); err != nil {
+					return err
+				}
+			}
+			if _, err := (pk.Tuple{&x, &y}).ReadFrom(r); err != nil {
+				return err
+			}
+			a.Title, a.Description = title.ClearString(), description.ClearString()
+		}
+		var groups pk.VarInt
+		if _, err := groups.ReadFrom(r); err != nil {
+			return err
+		}
+		for range int(groups) {
+			var criteria []pk.String
+			if _, err := pk.Array(&criteria).ReadFrom(r); err != nil {
+				return err
+			}
+			group := make([]string, len(criteria))
+			for i, c := range criteria {
+				group[i] = string(c)
+			}
+			a.requirements = append(a.requirements, group)
+		}
+		if _, err := telemetry.ReadFrom(r); err != nil {
+			return err
+		}
+		if old, ok := t.known[a.ID]; ok {
+			a.criteria, a.Earned = old.criteria, old.Earned
+		}
+		t.known[a.ID] = a
+	}
+
+	var removed []pk.Identifier
+	if _, err := pk.Array(&removed).ReadFrom(r); err != nil {
+		return err
+	}
+	for _, id := range removed {
+		delete(t.known, string(id))
+	}
+
+	if _, err := count.ReadFrom(r); err != nil {
+		return err
+	}
+	for range int(count) {
+		var entries pk.VarInt
+		if _, err := (pk.Tuple{&id, &entries}).ReadFrom(r); err != nil {
+			return err
+		}
+		a, ok := t.known[string(id)]
+		for range int(entries) {
+			var (
+				criterion pk.Identifier
+				achieved  pk.Boolean
+				date      pk.Long
+			)
+			if _, err := (pk.Tuple{&criterion, &achieved}).ReadFrom(r); err != nil {
+				return err
+			}
+			if achieved {
+				if _, err := date.ReadFrom(r); err != nil {
+					return err
+				}
+			}
+			if ok {
+				a.criteria[string(criterion)] = bool(achieved)
+				if bool(achieved) && a.Earned == nil && a.done() {
+					earned := time.UnixMilli(int64(date))
+					a.Earned = &earned
+					if !reset {
+						b.announceAdvancement(*a)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// announceAdvancement logs and emits an advancement the bot just earned, checking the item it
+// stands for is in the inventory
+func (b *Bot) announceAdvancement(a advancement) {
+	if a.Title == "" {
+		return // Recipe unlocks and other hidden advancements
+	}
+	data := map[string]any{"id": a.ID, "title": a.Title, "description": a.Description}
+	b.log.Printf("🏆 Earned the advancement [%s]", a.Title)
+	if item, ok := advancementItems[a.ID]; ok {
+		data["item"] = item
+		if b.inventoryCounts()[item] > 0 {
+			b.log.Printf("✅ [%s] confirms %s was obtained", a.Title, item)
+		} else {
+			b.log.Printf("⚠️ [%s] says %s was obtained, but the inventory has none", a.Title, item)
+		}
+	}
+	b.events.emit(eventAdvancement, data)
+}
+
+// earned returns the advancements earned so far, most recent first
+func (t *advancementTracker) earned() []advancement {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := []advancement{}
+	for _, a := range t.known {
+		if a.Earned != nil && a.Title != "" {
+			list = append(list, *a)
+		}
+	}
+	slices.SortFunc(list, func(a, c advancement) int {
+		return cmp.Or(c.Earned.Compare(*a.Earned), strings.Compare(a.ID, c.ID))
+	})
+	return list
+}
+
+// handleAdvancementsRequest returns the advancements the bot has earned
+func (b *Bot) handleAdvancementsRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.advancements.earned())
+}
@@ -0,0 +1,264 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	helmetSlot         = 5 // Inventory slots of the armor, the chestplate's is chestSlot
+	leggingsSlot       = 7
+	bootsSlot          = 8
+	armorCheckInterval = 5 * time.Second
+	rearmRetry         = 10 * time.Minute // Least time between two trips home for the same piece
+	rearmTaskName      = "rearm"
+)
+
+// armorPieces are the item suffixes of the armor pieces, from head to feet
+var armorPieces = []string{"helmet", "chestplate", "leggings", "boots"}
+
+// armorSlots are the inventory slots of the pieces, in the order of armorPieces
+var armorSlots = [4]int{helmetSlot, chestSlot, leggingsSlot, bootsSlot}
+
+// armorPoints are the armor points of each piece by material, in the order of armorPieces
+var armorPoints = map[string][4]int{
+	"leather":   {1, 3, 2, 1},
+	"golden":    {2, 5, 3, 1},
+	"chainmail": {2, 5, 4, 1},
+	"iron":      {2, 6, 5, 2},
+	"turtle":    {2, 0, 0, 0},
+	"diamond":   {3, 8, 6, 3},
+	"netherite": {3, 8, 6, 3},
+}
+
+// armorToughness is the toughness of the pieces of a material
+var armorToughness = map[string]int{"diamond": 2, "netherite": 3}
+
+// ArmorConfig keeps the bot in the best armor it has
+type ArmorConfig struct {
+	Enabled       bool `json:"enabled"`
+	MinDurability int  `json:"min_durability"` // Durability left at which a piece is swapped for a spare
+}
+
+// defaultArmor returns the armor policy
+func defaultArmor() ArmorConfig {
+	return ArmorConfig{Enabled: true, MinDurability: 10}
+}
+
+// armorWatch remembers the trips home for spare armor
+type armorWatch struct {
+	mu        sync.Mutex
+	rearmedAt [4]time.Time // Last trip home for each piece
+}
+
+// armorPiece returns the material and the piece, an index of armorPieces, of an armor item
+func armorPiece(name string) (material string, piece int, ok bool) {
+	name = strings.TrimPrefix(name, "minecraft:")
+	for i, p := range armorPieces {
+		if m, found := strings.CutSuffix(name, "_"+p); found {
+			if points, known := armorPoints[m]; known && points[i] > 0 {
+				return m, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// armorScore ranks an armor piece: armor points first, then toughness and the Protection
+// enchantment. It is -1 for items that aren't armor.
+func armorScore(d itemDetails) int {
+	material, piece, ok := armorPiece(d.Item)
+	if !ok {
+		return -1
+	}
+	return armorPoints[material][piece]*100 + armorToughness[material]*10 + d.Enchantments["protection"]
+}
+
+// armorLeft returns the durability left of an armor piece. ok is false for pieces that don't
+// wear out.
+func armorLeft(d itemDetails) (left int, ok bool) {
+	if d.Unbreakable {
+		return 0, false
+	}
+	if _, _, isArmor := armorPiece(d.Item); !isArmor {
+		return 0, false
+	}
+	maxDamage := d.MaxDamage
+	if maxDamage <= 0 {
+		it, _ := registry.ItemInfo(d.Item)
+		maxDamage = it.MaxDamage
+	}
+	if maxDamage <= 0 {
+		return 0, false
+	}
+	return max(maxDamage-d.Damage, 0), true
+}
+
+// slotItem returns the details of the item in a player inventory slot, just its name when the
+// components weren't read. ok is false for an empty slot.
+func (b *Bot) slotItem(slot int) (itemDetails, bool) {
+	s := b.screens.Inventory.Slots[slot]
+	if s.Count <= 0 {
+		return itemDetails{}, false
+	}
+	if d, ok := b.itemDetailsAt(slot); ok {
+		return d, true
+	}
+	return itemDetails{Item: itemName(int32(s.ID))}, true
+}
+
+// watchArmorEvery checks the armor periodically until ctx is done
+func (b *Bot) watchArmorEvery(ctx context.Context) {
+	if !b.cfg.Armor.Enabled {
+		return
+	}
+	ticker := time.NewTicker(armorCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.checkArmor()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkArmor puts on the best piece of the inventory for every armor slot, swapping pieces
+// about to break for a spare, or fetching one from the home chest when the inventory has
+// none. An elytra stays on while the bot flies.
+func (b *Bot) checkArmor() {
+	if b.screens == nil {
+		return
+	}
+	b.windowMu.Lock()
+	busy := b.window.ID != 0
+	b.windowMu.Unlock()
+	if busy {
+		return // Clicks go to the open container
+	}
+	current, _ := b.tasks.snapshot()
+	flying := current != nil && current.Name == flyTaskName
+
+	minLeft := b.cfg.Armor.MinDurability
+	for piece, slot := range armorSlots {
+		wornScore, wearingOut := -1, false
+		worn, wearing := b.slotItem(slot)
+		if wearing && worn.Item == elytraItem && flying {
+			continue
+		}
+		if wearing && worn.Item != elytraItem {
+			wornScore = armorScore(worn)
+			left, wears := armorLeft(worn)
+			wearingOut = wears && left < minLeft
+		}
+
+		from, bestScore := -1, wornScore
+		if wearingOut {
+			bestScore = -1 // Anything beats a piece about to break
+		}
+		for i := inventoryStart; i < offhandSlot; i++ {
+			d, ok := b.slotItem(i)
+			if !ok || int32(i) == b.miningItem {
+				continue
+			}
+			if _, p, isArmor := armorPiece(d.Item); !isArmor || p != piece {
+				continue
+			}
+			if left, wears := armorLeft(d); wears && left < minLeft {
+				continue
+			}
+			if score := armorScore(d); score > bestScore {
+				from, bestScore = i, score
+			}
+		}
+
+		switch {
+		case from >= 0:
+			if err := b.wearArmor(from, slot); err != nil {
+				b.log.Printf("⚠️ %v", err)
+				return
+			}
+		case wearingOut:
+			b.rearm(piece, worn)
+		}
+	}
+}
+
+// wearArmor swaps the piece in an inventory slot with the one worn in an armor slot by
+// picking it up, putting it down on the armor slot and the piece taken off back
+func (b *Bot) wearArmor(from, to int) error {
+	slots := &b.screens.Inventory.Slots
+	piece, worn := craftStack(slots[from]), craftStack(slots[to])
+	pieceDetails, _ := b.itemDetailsAt(from)
+	wornDetails, wornOK := b.itemDetailsAt(to)
+
+	clicks := []craft.Click{
+		{Slot: from, Button: craft.ButtonLeft, Mode: craft.ModePickup, Changed: map[int]craft.Stack{from: {}}, Cursor: piece},
+		{Slot: to, Button: craft.ButtonLeft, Mode: craft.ModePickup, Changed: map[int]craft.Stack{to: piece}, Cursor: worn},
+	}
+	if worn.Count > 0 {
+		clicks = append(clicks, craft.Click{Slot: from, Button: craft.ButtonLeft, Mode: craft.ModePickup, Changed: map[int]craft.Stack{from: worn}})
+	}
+	for _, c := range clicks {
+		if err := b.sendClick(0, c); err != nil {
+			return fmt.Errorf("failed to put on %s: %w", piece.Item, err)
+		}
+	}
+	b.setSlotDetails(to, pieceDetails, pieceDetails.Item != "")
+	b.setSlotDetails(from, wornDetails, wornOK && worn.Count > 0)
+
+	if worn.Count > 0 {
+		b.log.Printf("🪖 Put on %s instead of %s", piece.Item, worn.Item)
+	} else {
+		b.log.Printf("🪖 Put on %s", piece.Item)
+	}
+	return nil
+}
+
+// rearm goes to the home chest for a spare of a piece about to break, ahead of every other
+// task, at most once per rearmRetry
+func (b *Bot) rearm(piece int, worn itemDetails) {
+	b.armor.mu.Lock()
+	recent := time.Since(b.armor.rearmedAt[piece]) < rearmRetry
+	if !recent {
+		b.armor.rearmedAt[piece] = time.Now()
+	}
+	b.armor.mu.Unlock()
+	if recent {
+		return
+	}
+
+	left, _ := armorLeft(worn)
+	home := b.cfg.HomeChest
+	if home == nil {
+		b.log.Printf("⚠️ %s has %d durability left and there is no spare", worn.Item, left)
+		return
+	}
+	b.log.Printf("🏠 %s has %d durability left, fetching a spare from the home chest at (%d, %d, %d)", worn.Item, left, home.X, home.Y, home.Z)
+	b.enqueueUrgentTask(rearmTaskName, func(ctx context.Context) error {
+		if err := b.approachBlock(ctx, *home); err != nil {
+			return fmt.Errorf("failed to reach the home chest: %w", err)
+		}
+		err := b.takeFromChest(ctx, *home, func(name string) int {
+			if _, p, ok := armorPiece(name); !ok || p != piece {
+				return -1
+			}
+			return armorScore(itemDetails{Item: name})
+		})
+		if err != nil {
+			return fmt.Errorf("no spare %s in the home chest: %w", armorPieces[piece], err)
+		}
+		if err := sleepCtx(ctx, craftSyncDelay); err != nil {
+			return err
+		}
+		b.checkArmor()
+		return nil
+	})
+}
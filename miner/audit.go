@@ -0,0 +1,164 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	auditFileMode    = 0o600
+	auditDefaultFile = "audit.log"
+
+	auditSourceChat = "chat"
+	auditSourceHTTP = "http"
+
+	auditAccepted = "accepted" // Chat command handed to its handler
+	auditDenied   = "denied"   // Owner command from another player, or a request without the API token
+	auditUnknown  = "unknown"  // Chat line starting with a command the bot doesn't have
+	auditLimited  = "limited"  // Visitor command over the rate limit
+	auditReply    = "reply"    // What the bot answered a chat command with
+	auditOK       = "ok"       // HTTP request answered with a 2xx or 3xx status
+	auditFailed   = "failed"   // HTTP request answered with an error status
+)
+
+// ownerCommands are the chat commands only the owner, or the backup owner standing in, may use
+var ownerCommands = map[string]bool{
+	"sell":     true,
+	"handsoff": true,
+	"resume":   true,
+	"setwp":    true,
+	"delwp":    true,
+	"protect":  true,
+	"deliver":  true,
+	"script":   true,
+}
+
+// auditEntry is a line of the audit log: who asked the bot to do what, through which way in,
+// and how it went
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Bot       string    `json:"bot"`
+	Source    string    `json:"source"`         // "chat" or "http"
+	Principal string    `json:"principal"`      // Player name, or "api_token" and "anonymous" for HTTP
+	UUID      string    `json:"uuid,omitempty"` // Account of the player, when the tab list has them
+	Remote    string    `json:"remote,omitempty"`
+	Action    string    `json:"action"` // Chat line or HTTP method and path
+	Outcome   string    `json:"outcome"`
+	Status    int       `json:"status,omitempty"` // HTTP status
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// auditLog appends entries to the audit file, one JSON object per line. Bots sharing the file
+// share the auditLog.
+type auditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// openAuditLog opens the audit file for appending, creating it readable by the owner only
+func openAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, auditFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %w", err)
+	}
+	return &auditLog{path: path, file: f}, nil
+}
+
+// write appends an entry. A nil auditLog, for bots without audit_log, drops it.
+func (a *auditLog) write(e auditEntry) {
+	if a == nil {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("❌ Failed to encode an audit entry: %v", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		log.Printf("❌ Failed to write to the audit log %s: %v", a.path, err)
+	}
+}
+
+// Close closes the audit file
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// auditChat records a chat command and whether it was let through
+func (b *Bot) auditChat(line chatLine, outcome string) {
+	e := auditEntry{
+		Time:      time.Now(),
+		Bot:       b.cfg.Username,
+		Source:    auditSourceChat,
+		Principal: line.Sender,
+		Action:    line.Text,
+		Outcome:   outcome,
+	}
+	if p, ok := b.scoreboard.resolvePlayer(line.Sender); ok {
+		e.UUID = p.UUID
+	}
+	b.audit.write(e)
+}
+
+// auditReplies records the answers to chat commands, the outcome of what they asked for
+func (b *Bot) auditReplies(r botReply) {
+	if r.Command == "" {
+		return
+	}
+	b.audit.write(auditEntry{
+		Time:      time.Now(),
+		Bot:       b.cfg.Username,
+		Source:    auditSourceChat,
+		Principal: r.To,
+		Action:    "!" + r.Command,
+		Outcome:   auditReply,
+		Detail:    r.Text,
+	})
+}
+
+// statusRecorder keeps the status an HTTP handler answers with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// auditHTTP records a control request, who made it and how it was answered
+func (b *Bot) auditHTTP(r *http.Request, principal string, status int) {
+	outcome := auditOK
+	switch {
+	case status == http.StatusUnauthorized:
+		outcome = auditDenied
+	case status >= http.StatusBadRequest:
+		outcome = auditFailed
+	}
+	action := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		action += "?" + r.URL.RawQuery
+	}
+	b.audit.write(auditEntry{
+		Time:      time.Now(),
+		Bot:       b.cfg.Username,
+		Source:    auditSourceHTTP,
+		Principal: principal,
+		Remote:    r.RemoteAddr,
+		Action:    action,
+		Outcome:   outcome,
+		Status:    status,
+	})
+}
@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	blockLogDepth = 8 // Changes kept per block, the oldest dropped first
+	blockLogShown = 3 // Changes !whobroke lists
+)
+
+// whoBrokeCommand matches "!whobroke <x> <y> <z>"
+var whoBrokeCommand = regexp.MustCompile(`(?i)!whobroke\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+// unloggedBlocks change on their own all the time: fluids flowing and fire spreading
+var unloggedBlocks = map[string]bool{
+	"minecraft:water":         true,
+	"minecraft:lava":          true,
+	"minecraft:fire":          true,
+	"minecraft:soul_fire":     true,
+	"minecraft:bubble_column": true,
+}
+
+// blockChange is a change of a block the bot saw, with the player that probably made it
+type blockChange struct {
+	Time     time.Time `json:"time"`
+	Before   string    `json:"before"`
+	After    string    `json:"after"`
+	Player   string    `json:"player,omitempty"`   // Player closest to the block, empty when none was in view
+	Distance float64   `json:"distance,omitempty"` // How far the player was from it
+}
+
+// logBlockChange records a block changing from one block to another in the state database,
+// blaming the closest player within activityBlame, or the bot itself when it is closer. Only
+// changes of the block name count, not of its properties like a crop growing, and fluids
+// and fire are left out.
+func (b *Bot) logBlockChange(pos blockPos, before, after block.StateID) {
+	from, to := blockName(before), blockName(after)
+	if from == to || unloggedBlocks[from] || unloggedBlocks[to] || b.db == nil {
+		return
+	}
+	c := blockChange{Time: time.Now(), Before: from, After: to}
+
+	cx, cy, cz := float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5
+	if e, ok := b.entities.nearest(cx, cy, cz, activityBlame, func(e trackedEntity) bool {
+		return entityName(e.Type) == "player"
+	}); ok {
+		c.Player = b.entities.playerName(e.UUID)
+		c.Distance = math.Sqrt((e.X-cx)*(e.X-cx) + (e.Y-cy)*(e.Y-cy) + (e.Z-cz)*(e.Z-cz))
+	}
+	x, y, z := b.currentPosition()
+	if own := math.Sqrt((x-cx)*(x-cx) + (y+eyeHeight-cy)*(y+eyeHeight-cy) + (z-cz)*(z-cz)); own <= miningReach && (c.Player == "" || own < c.Distance) {
+		c.Player, c.Distance = b.cfg.Username, own
+	}
+	c.Distance = math.Round(c.Distance*10) / 10
+
+	key := b.minedKey(pos)
+	var changes []blockChange
+	if _, err := b.db.Get(bucketBlockLog, key, &changes); err != nil {
+		b.log.Printf("⚠️ %v", err)
+	}
+	changes = append(changes, c)
+	if len(changes) > blockLogDepth {
+		changes = changes[len(changes)-blockLogDepth:]
+	}
+	if err := b.db.Put(bucketBlockLog, key, changes); err != nil {
+		b.log.Printf("⚠️ Failed to record the change at (%d, %d, %d): %v", pos.X, pos.Y, pos.Z, err)
+	}
+}
+
+// handleWhoBrokeCommand answers "!whobroke <x> <y> <z>" with the last changes of the block
+// and who was closest each time
+func (b *Bot) handleWhoBrokeCommand(msg string) {
+	m := whoBrokeCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !whobroke <x> <y> <z>")
+		return
+	}
+	var pos blockPos
+	for i, v := range []*int{&pos.X, &pos.Y, &pos.Z} {
+		*v, _ = strconv.Atoi(m[i+1])
+	}
+
+	var changes []blockChange
+	if _, err := b.db.Get(bucketBlockLog, b.minedKey(pos), &changes); err != nil {
+		b.reply(msg, fmt.Sprintf("Can't read the block log: %v", err))
+		return
+	}
+	if len(changes) == 0 {
+		b.reply(msg, fmt.Sprintf("I haven't seen (%d, %d, %d) change", pos.X, pos.Y, pos.Z))
+		return
+	}
+
+	var parts []string
+	for i := len(changes) - 1; i >= 0 && len(parts) < blockLogShown; i-- {
+		c := changes[i]
+		who := "nobody in view"
+		if c.Player != "" {
+			who = fmt.Sprintf("%s %.0f blocks away", c.Player, c.Distance)
+		}
+		parts = append(parts, fmt.Sprintf("%s → %s %s ago, %s", strings.TrimPrefix(c.Before, "minecraft:"), strings.TrimPrefix(c.After, "minecraft:"),
+			time.Since(c.Time).Round(time.Second), who))
+	}
+	b.reply(msg, fmt.Sprintf("(%d, %d, %d): %s", pos.X, pos.Y, pos.Z, strings.Join(parts, "; ")))
+}
@@ -0,0 +1,376 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/bot/basic"
+	"github.com/Tnze/go-mc/bot/playerlist"
+	"github.com/Tnze/go-mc/bot/screen"
+	"github.com/Tnze/go-mc/data/packetid"
+
+	"github.com/coolguycoder/Minecraft-Miner/store"
+	"github.com/coolguycoder/Minecraft-Miner/world"
+)
+
+// Bot is one player connection with its own config, task queue and state.
+// Several bots can run side by side in one process.
+type Bot struct {
+	cfg    Config
+	log    *log.Logger
+	client *bot.Client
+	player *basic.Player
+
+	screens  *screen.Manager // Player inventory and open containers
+	world    *worldModel     // Chunks the server has sent us
+	entities *entityTracker  // Entities in view and online player names
+	players  *playerlist.PlayerList
+	tasks    *taskQueue
+	events   *eventHub
+	budget   *actionBudget
+	shaper   *packetShaper // Holds back packets sent faster than the packet limits
+	swarm    *swarm        // Bots of this process working together
+	chests   *chestIndex   // Containers opened by the bots of this process
+	db       store.Backend // State kept across restarts, shared by the bots using the same file
+	audit    *auditLog     // Who sent which command, nil without audit_log
+
+	replayAddr     string // Replay server every join connects to while replaying a recording
+	replayProtocol int32  // Protocol of the recording being replayed
+	serverProtocol int32  // Protocol the bot speaks with its server, negotiated on joining
+
+	stopping       atomic.Bool
+	transferring   atomic.Bool                     // Set from a transfer until the bot joined the server it names
+	reconfig       atomic.Pointer[reconfiguration] // Queues of the connection, replaced on every join
+	sprinting      atomic.Bool
+	minedFirst     bool
+	spawned        chan struct{} // Closed once the server placed the bot for the first time
+	spawnOnce      sync.Once
+	miningItem     int32             // Current slot holding mining item
+	itemDurability int               // Item durability (default: 100)
+	miningTicks    int               // Counter for mining simulation ticks
+	sequence       atomic.Int32      // Block action sequence acknowledged by the server
+	opLevel        atomic.Int32      // Permission level the server granted the bot
+	containerState atomic.Int32      // State ID of the last container update
+	shieldRaised   bool              // Whether the shield is up, only touched by the running task
+	mineConfirm    chan bool         // Answers to the question whether to use a worn tool
+	screenOpened   chan openedScreen // Containers the server opened, for the task waiting on one
+
+	windowMu   sync.Mutex // Guards the open window and the block used to open it
+	window     openWindow
+	usedBlock  blockPos
+	usedAt     time.Time
+	indexTimer *time.Timer // Indexes the open container once its contents arrived
+
+	digMu sync.Mutex // Guards dig
+	dig   *activeDig // Block being broken, nil when idle
+
+	stateMu     sync.RWMutex // Guards the tracked player position and health
+	x, y, z     float64
+	yaw         float32
+	pitch       float32
+	health      float32
+	food        int32
+	xp          experience
+	effects     map[string]activeEffect // Status effects of the bot by name
+	heldSlot    int32                   // Selected hotbar slot (0-8)
+	slotDetails map[int]itemDetails     // Damage and enchantments of the player inventory slots that have been read
+	loan        *toolLoan               // Tool a player handed over with !mine, until it is given back
+	knockback   *velocity               // Motion the server applied to the bot that was not resolved yet
+	rotatedAt   time.Time               // When the bot last sent a rotation different from the one before
+	meleeHitAt  time.Time               // When a mob last hit the bot in melee
+	lastScan    *scanResult             // Ores found by the last !scan
+	vehicle     int32                   // Entity the bot rides while riding is set
+	riding      bool
+
+	chat         chatLog
+	advancements advancementTracker
+	recipes      recipeUnlocks
+	tablist      tabList
+	scoreboard   scoreboard
+	hud          hud
+	visitors     visitorLimit
+	sounds       soundWatch
+	damage       damageWatch
+	personality  personality
+	typist       typist
+	armor        armorWatch
+	rules        *ruleSet // Rules of the rules file, loaded at startup
+	clock        world.Clock
+	tps          tickRate
+	session      sessionStats
+	bed          bedRest
+	traders      traderWatch
+	raid         raidWatch
+	economy      economy
+	owner        ownerWatch
+	freeze       freezeGate
+	inventory    inventoryTracker
+	loot         lootLedger
+	drift        driftCheck
+	ores         oreStats
+	travel       travelStats
+	maps         mapWatch
+	channels     channelHub
+	data         serverData
+	plugins      pluginHost
+}
+
+// newBot creates a bot charging its actions to budget and registers its packet handlers
+func newBot(c Config, budget *actionBudget) *Bot {
+	b := &Bot{
+		cfg:            c,
+		log:            log.New(logOutput(c), fmt.Sprintf("[%s] ", c.Username), log.LstdFlags|log.Lmsgprefix),
+		client:         bot.NewClient(),
+		tasks:          newTaskQueue(),
+		events:         newEventHub(),
+		budget:         budget,
+		shaper:         newPacketShaper(c.PacketLimits),
+		miningItem:     -1,
+		itemDurability: 100,
+		slotDetails:    make(map[int]itemDetails),
+		effects:        make(map[string]activeEffect),
+		mineConfirm:    make(chan bool, 1),
+		screenOpened:   make(chan openedScreen, 1),
+		spawned:        make(chan struct{}),
+	}
+	b.client.Auth.Name = c.Username
+
+	// Create event listeners
+	events := basic.EventsListener{
+		GameStart:    b.onGameStart,
+		Disconnect:   b.onDisconnect,
+		HealthChange: b.onHealthChange,
+		Death:        b.onDeath,
+		Teleported:   b.onTeleported,
+	}
+
+	// Create player with event handlers, reporting the configured client information
+	settings, _ := c.ClientInfo.settings() // Checked when the config was loaded
+	b.player = basic.NewPlayer(b.client, settings, events)
+
+	// Track inventory, loaded chunks and entities
+	b.screens = screen.NewManager(b.client, screen.EventsListener{
+		Open:    b.onScreenOpen,
+		SetSlot: b.onInventorySlot,
+		Close:   b.onScreenClose,
+	})
+	b.world = newWorldModel()
+	b.registerWorldHandlers()
+	b.entities = newEntityTracker()
+	b.registerEntityHandlers()
+	b.registerDriftCheck()
+	b.registerTransferHandler()
+	b.registerReconfigureHandler()
+	b.registerCookieHandlers()
+	b.registerChannelHandlers()
+	b.registerForgeHandlers()
+	b.registerServerDataHandlers()
+	b.registerTabListHandler()
+	b.registerScoreboardHandlers()
+	b.registerHUDHandlers()
+	b.registerSoundHandlers()
+	b.registerDamageHandler()
+	b.registerTotemHandler()
+	b.registerAdvancementHandler()
+	b.registerRecipeHandler()
+	b.registerBedHandler()
+	b.registerTraderHandlers()
+	b.registerRaidHandlers()
+	b.registerClockHandlers()
+	b.registerTeleportHandler()
+	b.registerExperienceHandlers()
+	b.registerEconomyHandler()
+	b.registerEffectHandlers()
+	b.registerMapHandlers()
+
+	// Aggregate ore yield per strategy and region, rate task routes, keep the mined-block history,
+	// count the output contributors share and the blocks each task mined, audit the replies to commands
+	// remark on milestones and apply the automation rules
+	subscribeTo(b.events, b.recordOreGains)
+	subscribeTo(b.events, b.startRoute)
+	subscribeTo(b.events, b.finishRoute)
+	subscribeTo(b.events, b.recordMined)
+	subscribeTo(b.events, b.recordOutput)
+	subscribeTo(b.events, b.tasks.countMined)
+	subscribeTo(b.events, b.auditReplies)
+	subscribeTo(b.events, b.announceToolBroke)
+	subscribeTo(b.events, b.countMinedBlock)
+	subscribeTo(b.events, b.countCollected)
+	subscribeTo(b.events, b.countToolBroke)
+	b.events.subscribe(b.remarkOnMilestones)
+	b.events.subscribe(b.applyRules)
+
+	// Add custom packet handlers for chat messages, the held item, knockback, permissions, damage, container state,
+	// item details and vehicles
+	b.client.Events.AddListener(
+		bot.PacketHandler{
+			ID: packetid.ClientboundSystemChat,
+			F:  b.handleChatPacket,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundPlayerChat,
+			F:  b.handleChatPacket,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundDisguisedChat,
+			F:  b.handleChatPacket,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundSetCarriedItem,
+			F:  b.onHeldSlot,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundSetEntityMotion,
+			F:  b.onEntityMotion,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundEntityEvent,
+			F:  b.onEntityEvent,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundDamageEvent,
+			F:  b.onDamageEvent,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundContainerSetContent,
+			F:  b.onContainerState,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundContainerSetSlot,
+			F:  b.onContainerState,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundContainerSetContent,
+			F:  b.onContentDetails,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundContainerSetSlot,
+			F:  b.onSlotDetails,
+		},
+		bot.PacketHandler{
+			ID: packetid.ClientboundSetPassengers,
+			F:  b.onSetPassengers,
+		},
+	)
+	return b
+}
+
+// run connects the bot and blocks until it leaves the game
+func (b *Bot) run(ctx context.Context) error {
+	if b.cfg.HTTPAddr != "" {
+		b.startHTTPServer(b.cfg.HTTPAddr)
+	}
+	b.warnOwner()
+
+	// A bot that retired stays away
+	if err := b.checkRetired(); err != nil {
+		return err
+	}
+
+	// Join server
+	if err := b.join(b.cfg.Server); err != nil {
+		return err
+	}
+	b.startSession()
+
+	// Run queued tasks (mining, commands) one at a time
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go b.runTasks(ctx)
+	go b.saveStatsEvery(ctx)
+	go b.saveWorldCacheEvery(ctx)
+	defer func() {
+		// A bot told to stop saved its world cache and session report already
+		if !b.stopping.Load() {
+			if err := b.saveWorldCache(); err != nil {
+				b.log.Printf("⚠️ Failed to save the world cache: %v", err)
+			}
+			if err := b.writeSessionReport(); err != nil {
+				b.log.Printf("⚠️ Failed to write the session report: %v", err)
+			}
+		}
+	}()
+	go b.watchOwnerEvery(ctx)
+	go b.watchArmorEvery(ctx)
+	go b.tickPlugins(ctx)
+
+	for {
+		err := b.client.HandleGame()
+		var transfer *transferError
+		if errors.As(err, &transfer) && !b.stopping.Load() {
+			if err := b.transfer(transfer.addr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && !b.stopping.Load() {
+			if problems := b.drift.mismatches(); len(problems) > 0 {
+				b.log.Printf("❌ The error likely comes from the version data mismatch: %s", strings.Join(problems, "; "))
+			}
+			return fmt.Errorf("game ended with error: %w", err)
+		}
+		return nil
+	}
+}
+
+// join connects to the server at addr
+func (b *Bot) join(addr string) error {
+	b.log.Printf("Connecting to server %s as %s (Minecraft Java Edition %s, Protocol %d)...", addr, b.cfg.Username, version, protocolVersion)
+	if b.cfg.Proxy != "" && b.replayAddr == "" {
+		if u, err := parseProxy(b.cfg.Proxy); err == nil {
+			b.log.Printf("🧦 Going through the %s proxy %s", u.Scheme, u.Host)
+		}
+	}
+	codec, err := b.negotiateProtocol(addr)
+	if err != nil {
+		return fmt.Errorf("failed to join server: %w", err)
+	}
+	b.serverProtocol = codec.protocol
+	opts := bot.JoinOptions{MCDialer: tagDialer{MCDialer: codec.dialer(b.dialer()), b: b}}
+	codec.joinOptions(&opts)
+	b.configQueues(&opts, codec)
+	opts.QueueWrite = newShapedQueue(opts.QueueWrite, b.shaper)
+	if err := b.client.JoinServerWithOptions(addr, opts); err != nil {
+		if codec.modded != "" {
+			return fmt.Errorf("failed to join server running %s as a %s client: %w", codec.modded, codec.client(), err)
+		}
+		return fmt.Errorf("failed to join server: %w", err)
+	}
+	b.log.Println("✓ Successfully connected to server!")
+	return nil
+}
+
+// stop disconnects the bot, making run return
+func (b *Bot) stop() {
+	b.stopping.Store(true)
+	b.saveStats()
+	if err := b.saveWorldCache(); err != nil {
+		b.log.Printf("⚠️ Failed to save the world cache: %v", err)
+	}
+	if err := b.writeSessionReport(); err != nil {
+		b.log.Printf("⚠️ Failed to write the session report: %v", err)
+	}
+	if b.client.Conn != nil {
+		// Send the replies still being typed, like the goodbye of !stop, before leaving
+		if b.flushReplies() {
+			time.Sleep(flushGrace)
+		}
+		// Leave no half-broken block behind on the server
+		if err := b.CancelDig(); err != nil {
+			b.log.Printf("⚠️ Failed to cancel dig: %v", err)
+		}
+		b.client.Conn.Close()
+	}
+}
+
+// connected reports whether the bot is in game
+func (b *Bot) connected() bool {
+	return b.client.Conn != nil && !b.stopping.Load()
+}
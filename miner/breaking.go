@@ -0,0 +1,360 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	digMaxAttempts    = 4                       // Breaks tried before giving up on a block
+	digBackoffTicks   = 2                       // Extra ticks after the first rollback, doubled on each retry
+	digConfirmTimeout = 1500 * time.Millisecond // Wait for the server to confirm or roll back a break
+)
+
+// errBreakRejected is returned when the server keeps rolling back a break
+var errBreakRejected = errors.New("server rejected the break")
+
+// Tool harvest tiers, matching the vanilla tool materials
+const (
+	tierNone      = registry.TierNone // Block drops without the right tool
+	tierWood      = registry.TierWood // Wooden and golden tools
+	tierStone     = registry.TierStone
+	tierIron      = registry.TierIron
+	tierDiamond   = registry.TierDiamond
+	tierNetherite = registry.TierNetherite
+)
+
+// toolTiers maps tool material prefixes to their tier and mining speed
+var toolTiers = map[string]struct {
+	tier  int
+	speed float32
+}{
+	"wooden":    {tierWood, 2},
+	"stone":     {tierStone, 4},
+	"iron":      {tierIron, 6},
+	"diamond":   {tierDiamond, 8},
+	"netherite": {tierNetherite, 9},
+	"golden":    {tierWood, 12},
+}
+
+// parseTool splits an item name like minecraft:iron_pickaxe into its kind and material.
+// ok is false for items that are not tools.
+func parseTool(item string) (kind string, tier int, speed float32, ok bool) {
+	material, kind, found := strings.Cut(strings.TrimPrefix(item, "minecraft:"), "_")
+	if !found {
+		return "", 0, 0, false
+	}
+	t, known := toolTiers[material]
+	if !known {
+		return "", 0, 0, false
+	}
+	switch kind {
+	case "pickaxe", "shovel", "axe", "hoe":
+		return kind, t.tier, t.speed, true
+	}
+	return "", 0, 0, false
+}
+
+// breakTicks predicts how many ticks the server needs to see before it accepts breaking
+// a block with the given tool, using the same per-tick progress as the vanilla client.
+// effects is what status effects multiply the mining speed by.
+// 0 means the block breaks instantly, ok is false for unbreakable blocks.
+func breakTicks(info registry.Block, tool string, effects float32) (ticks int, ok bool) {
+	if info.Hardness < 0 {
+		return 0, false
+	}
+	if info.Hardness == 0 {
+		return 0, true
+	}
+
+	speed, canHarvest := float32(1), info.Tier == tierNone
+	if kind, tier, toolSpeed, isTool := parseTool(tool); isTool && kind == info.Tool {
+		speed = toolSpeed
+		canHarvest = canHarvest || tier >= info.Tier
+	}
+
+	speed *= effects
+
+	divisor := float32(100)
+	if canHarvest {
+		divisor = 30
+	}
+	perTick := speed / info.Hardness / divisor
+	if perTick >= 1 {
+		return 0, true
+	}
+
+	// Accumulate in float32 like the client does, so rounding matches
+	var progress float32
+	for progress < 1 {
+		progress += perTick
+		ticks++
+	}
+	return ticks, true
+}
+
+// heldTool returns the item name of the mining item, or empty when mining by hand
+func (b *Bot) heldTool() string {
+	if b.miningItem < 0 || b.screens == nil || int(b.miningItem) >= len(b.screens.Inventory.Slots) {
+		return ""
+	}
+	s := b.screens.Inventory.Slots[b.miningItem]
+	if s.Count <= 0 {
+		return ""
+	}
+	return itemName(int32(s.ID))
+}
+
+// digPlan is a block to break with its break time worked out in advance
+type digPlan struct {
+	Pos    blockPos
+	Name   string
+	Tool   string
+	Ticks  int
+	Loaded bool // Whether the chunk was loaded when the plan was made
+	Known  bool // Whether the break time comes from the block's hardness
+}
+
+// planDig predicts how long the block at pos takes to break with the held tool.
+// It fails for air and unbreakable blocks.
+func (b *Bot) planDig(pos blockPos) (digPlan, error) {
+	state, loaded := b.world.blockAt(pos)
+	if loaded && block.IsAir(state) {
+		return digPlan{}, fmt.Errorf("no block at (%d, %d, %d)", pos.X, pos.Y, pos.Z)
+	}
+
+	plan := digPlan{Pos: pos, Name: blockName(state), Tool: b.heldTool(), Loaded: loaded}
+	if !loaded {
+		// Zones still apply where the block isn't known
+		plan.Name = ""
+	}
+	if err := b.checkProtected(pos, plan.Name); err != nil {
+		return digPlan{}, err
+	}
+	info, known := b.blockInfo(plan.Name)
+	if known {
+		plan.Ticks, plan.Known = breakTicks(info, plan.Tool, b.breakSpeedFactor())
+	}
+	if !loaded || !plan.Known {
+		if loaded && known && info.Hardness < 0 {
+			return digPlan{}, fmt.Errorf("%s at (%d, %d, %d) is unbreakable", plan.Name, pos.X, pos.Y, pos.Z)
+		}
+		plan.Ticks = miningTickCount
+	}
+	return plan, nil
+}
+
+// digBlock breaks the block at pos. It holds the dig for as many ticks as the server
+// is predicted to need, then waits for the server to confirm the block is gone.
+// When the server rolls the block back the dig is retried with extra ticks.
+func (b *Bot) digBlock(ctx context.Context, pos blockPos) error {
+	plan, err := b.planDig(pos)
+	if err != nil {
+		return err
+	}
+	return b.digPlanned(ctx, plan, nil)
+}
+
+// digPlanned breaks a planned block like digBlock.
+// onTick, when set, runs after every mining tick with the number of ticks left.
+func (b *Bot) digPlanned(ctx context.Context, plan digPlan, onTick func(remaining int) error) error {
+	pos, name, ticks := plan.Pos, plan.Name, plan.Ticks
+	if err := b.drinkBeforeDig(ctx, pos); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		b.log.Printf("⚠️ Mining without the potion: %v", err)
+	}
+	b.holdTotemAt(pos)
+	switch {
+	case !plan.Loaded:
+		b.log.Printf("⚠️ Chunk at (%d, %d, %d) not loaded, using the default mining time", pos.X, pos.Y, pos.Z)
+	case !plan.Known:
+		b.log.Printf("⚠️ No hardness known for %s, using the default mining time", name)
+	default:
+		b.log.Printf("⛏️ Breaking %s with %s: predicted %d ticks", name, toolLabel(plan.Tool), ticks)
+	}
+
+	if err := b.hesitate(ctx); err != nil {
+		return err
+	}
+	b.highlightTarget(pos)
+	backoff := digBackoffTicks
+	for attempt := 1; attempt <= digMaxAttempts; attempt++ {
+		broken, err := b.digOnce(ctx, pos, ticks, onTick)
+		if err != nil || broken {
+			return err
+		}
+
+		b.log.Printf("↩️ Server rolled back %s at (%d, %d, %d) after %d ticks (attempt %d/%d)",
+			name, pos.X, pos.Y, pos.Z, ticks, attempt, digMaxAttempts)
+		ticks += backoff
+		backoff *= 2
+	}
+	return fmt.Errorf("%w: %s at (%d, %d, %d) after %d attempts", errBreakRejected, name, pos.X, pos.Y, pos.Z, digMaxAttempts)
+}
+
+// digOnce sends one start/finish dig for pos and reports whether the server broke the block
+func (b *Bot) digOnce(ctx context.Context, pos blockPos, ticks int, onTick func(remaining int) error) (broken bool, err error) {
+	updates, stop := b.world.watch(pos)
+	defer stop()
+
+	if err := b.startDig(ctx, pos); err != nil {
+		return false, fmt.Errorf("error starting to dig: %w", err)
+	}
+
+	// Blocks breaking instantly are done with the start packet alone
+	if ticks == 0 {
+		b.clearDig()
+	} else {
+		if err := b.simulateMining(ctx, ticks, onTick); err != nil {
+			if cerr := b.CancelDig(); cerr != nil {
+				b.log.Printf("⚠️ Failed to cancel dig: %v", cerr)
+			}
+			return false, err
+		}
+		if err := b.finishDig(); err != nil {
+			return false, fmt.Errorf("error finishing dig: %w", err)
+		}
+	}
+
+	timeout := time.NewTimer(digConfirmTimeout)
+	defer timeout.Stop()
+	select {
+	case state := <-updates:
+		// The server resends the old block when it rejects the break
+		return block.IsAir(state), nil
+	case <-timeout.C:
+		// No update seen, trust the world model if the chunk is loaded
+		state, loaded := b.world.blockAt(pos)
+		return !loaded || block.IsAir(state), nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Block faces as sent in player actions
+const (
+	faceBottom byte = iota
+	faceTop
+	faceNorth
+	faceSouth
+	faceWest
+	faceEast
+)
+
+// activeDig is a dig the server was told about that is neither finished nor cancelled
+type activeDig struct {
+	Pos  blockPos
+	Face byte
+}
+
+// digFace returns the face of the block at pos that points towards the bot's eyes
+func (b *Bot) digFace(pos blockPos) byte {
+	x, y, z := b.currentPosition()
+	dx := x - (float64(pos.X) + 0.5)
+	dy := y + eyeHeight - (float64(pos.Y) + 0.5)
+	dz := z - (float64(pos.Z) + 0.5)
+
+	switch ax, ay, az := math.Abs(dx), math.Abs(dy), math.Abs(dz); {
+	case ay >= ax && ay >= az && dy > 0:
+		return faceTop
+	case ay >= ax && ay >= az:
+		return faceBottom
+	case ax >= az && dx > 0:
+		return faceEast
+	case ax >= az:
+		return faceWest
+	case dz > 0:
+		return faceSouth
+	default:
+		return faceNorth
+	}
+}
+
+// startDig tells the server the bot starts breaking the block at pos.
+// A dig still running on another block is cancelled first, as the vanilla client does when the target changes.
+// The bot aims at a face it can see at least a tick before the start packet, strict servers check both.
+func (b *Bot) startDig(ctx context.Context, pos blockPos) error {
+	b.digMu.Lock()
+	previous := b.dig
+	b.digMu.Unlock()
+	if previous != nil && previous.Pos != pos {
+		b.log.Printf("🎯 Target changed from (%d, %d, %d) to (%d, %d, %d)",
+			previous.Pos.X, previous.Pos.Y, previous.Pos.Z, pos.X, pos.Y, pos.Z)
+		if err := b.CancelDig(); err != nil {
+			return err
+		}
+	}
+
+	if err := b.waitThaw(ctx); err != nil {
+		return err
+	}
+	// Whatever planned the dig, nothing protected gets broken
+	name := ""
+	if state, loaded := b.world.blockAt(pos); loaded {
+		name = blockName(state)
+	}
+	if err := b.checkProtected(pos, name); err != nil {
+		return err
+	}
+	face, ok := b.visibleFace(pos)
+	if !ok {
+		return fmt.Errorf("%w to (%d, %d, %d)", errNoLineOfSight, pos.X, pos.Y, pos.Z)
+	}
+	if err := b.aimAt(ctx, pos, face); err != nil {
+		return err
+	}
+	if err := b.sendDigging(0, pos.X, pos.Y, pos.Z, face); err != nil { // Status 0 = start digging
+		return err
+	}
+
+	b.digMu.Lock()
+	b.dig = &activeDig{Pos: pos, Face: face}
+	b.digMu.Unlock()
+	return nil
+}
+
+// finishDig tells the server the bot is done breaking the active block
+func (b *Bot) finishDig() error {
+	d := b.clearDig()
+	if d == nil {
+		return errors.New("no dig in progress")
+	}
+	return b.sendDigging(2, d.Pos.X, d.Pos.Y, d.Pos.Z, d.Face) // Status 2 = finish digging
+}
+
+// CancelDig aborts the block the bot is breaking, if any, so the server
+// resets its break progress. It is safe to call when no dig is running.
+func (b *Bot) CancelDig() error {
+	d := b.clearDig()
+	if d == nil {
+		return nil
+	}
+	b.log.Printf("✋ Cancelled dig at (%d, %d, %d)", d.Pos.X, d.Pos.Y, d.Pos.Z)
+	return b.sendDigging(1, d.Pos.X, d.Pos.Y, d.Pos.Z, d.Face) // Status 1 = cancel digging
+}
+
+// clearDig forgets the active dig and returns it
+func (b *Bot) clearDig() *activeDig {
+	b.digMu.Lock()
+	defer b.digMu.Unlock()
+	d := b.dig
+	b.dig = nil
+	return d
+}
+
+// toolLabel names a tool for logs
+func toolLabel(tool string) string {
+	if tool == "" {
+		return "bare hands"
+	}
+	return tool
+}
@@ -0,0 +1,225 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+const (
+	bridgeCost         = 4.0             // Path cost of a step that first places a block to stand on
+	bridgeLean         = 0.75            // How far past the middle of its block the bot sneaks to place
+	bridgePlaceTimeout = 2 * time.Second // Time the server has to confirm a placed block
+	inputSneak         = 0x20            // Sneak flag of the player input packet
+)
+
+// defaultScaffolding are the blocks bridges are built from unless scaffolding says otherwise
+var defaultScaffolding = []string{"minecraft:cobblestone", "minecraft:cobbled_deepslate", "minecraft:netherrack", "minecraft:dirt"}
+
+// errNoScaffolding is returned when a bridge needs a block the inventory doesn't have
+var errNoScaffolding = errors.New("no scaffolding blocks left")
+
+// replaceableBlocks give way to a placed block
+var replaceableBlocks = map[string]bool{
+	"minecraft:water":       true,
+	"minecraft:lava":        true,
+	"minecraft:short_grass": true,
+	"minecraft:tall_grass":  true,
+	"minecraft:fern":        true,
+	"minecraft:large_fern":  true,
+	"minecraft:dead_bush":   true,
+	"minecraft:vine":        true,
+}
+
+// replaceable reports whether a block can be placed at pos
+func (w *worldModel) replaceable(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	return ok && (block.IsAir(state) || replaceableBlocks[blockName(state)])
+}
+
+// scaffoldingCount returns the scaffolding blocks in the inventory
+func (b *Bot) scaffoldingCount() int {
+	counts := b.inventoryCounts()
+	n := 0
+	for _, name := range b.cfg.Scaffolding {
+		n += counts[name]
+	}
+	return n
+}
+
+// holdScaffolding moves scaffolding into scaffold_slot unless it holds some already and
+// selects that slot
+func (b *Bot) holdScaffolding() error {
+	if b.cfg.ScaffoldSlot < 0 || b.cfg.ScaffoldSlot >= hotbarSize {
+		return fmt.Errorf("scaffold_slot %d is not a hotbar slot (0-%d)", b.cfg.ScaffoldSlot, hotbarSize-1)
+	}
+	ok, err := b.holdItem(b.cfg.Scaffolding, b.cfg.ScaffoldSlot)
+	if err == nil && !ok {
+		return errNoScaffolding
+	}
+	return err
+}
+
+// holdItem moves one of items into hotbar slot unless it holds one already and selects that
+// slot. It reports false when the inventory has none of them.
+func (b *Bot) holdItem(items []string, slot int) (bool, error) {
+	return b.holdMatching(func(_ int, item string) bool { return slices.Contains(items, item) }, slot)
+}
+
+// holdMatching is holdItem for the items match accepts, given their inventory slot and name
+func (b *Bot) holdMatching(match func(slot int, item string) bool, slot int) (bool, error) {
+	hotbar := hotbarStart + slot
+	inv := &b.screens.Inventory.Slots
+	if inv[hotbar].Count > 0 && match(hotbar, itemName(int32(inv[hotbar].ID))) {
+		return true, b.selectHotbarSlot(int32(slot))
+	}
+
+	from := -1
+	for _, s := range b.inventorySnapshot() {
+		if s.Slot >= inventoryStart && s.Slot < offhandSlot && match(s.Slot, s.Item) {
+			from = s.Slot
+			break
+		}
+	}
+	if from < 0 {
+		return false, nil
+	}
+	if int32(hotbar) == b.miningItem {
+		return false, fmt.Errorf("hotbar slot %d holds the mining tool", slot)
+	}
+	if err := b.sendClick(0, craft.Click{Slot: from, Button: slot, Mode: clickModeSwap,
+		Changed: map[int]craft.Stack{from: craftStack(inv[hotbar]), hotbar: craftStack(inv[from])}}); err != nil {
+		return false, fmt.Errorf("failed to move %s to the hotbar: %w", itemName(int32(inv[from].ID)), err)
+	}
+	other, ok := b.itemDetailsAt(hotbar)
+	moved, _ := b.itemDetailsAt(from)
+	b.setSlotDetails(from, other, ok)
+	b.setSlotDetails(hotbar, moved, moved.Item != "")
+	return true, b.selectHotbarSlot(int32(slot))
+}
+
+// sendSneak presses or releases the sneak key
+func (b *Bot) sendSneak(on bool) error {
+	var flags pk.UnsignedByte
+	if on {
+		flags = inputSneak
+	}
+	return b.client.Conn.WritePacket(pk.Marshal(packetid.ServerboundPlayerInput, flags))
+}
+
+// sendUseItemOn right-clicks the face of a block with the held item, at cursor within the block
+func (b *Bot) sendUseItemOn(pos blockPos, face byte, cx, cy, cz float32) error {
+	position := int64(pos.X&positionXZMask)<<38 | int64(pos.Z&positionXZMask)<<12 | int64(pos.Y&positionYMask)
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundUseItemOn,
+		pk.VarInt(0), // Main hand
+		pk.Long(position),
+		pk.VarInt(face),
+		pk.Float(cx), pk.Float(cy), pk.Float(cz), // Cursor position on the face
+		pk.Boolean(false), // Head inside the block
+		pk.Boolean(false), // Hit the world border
+		pk.VarInt(b.sequence.Add(1)),
+	))
+}
+
+// stepTo walks from one path position to the next, first bridging the gap under it when the
+// path crosses one
+func (b *Bot) stepTo(ctx context.Context, from, to blockPos) error {
+	floor := blockPos{X: to.X, Y: to.Y - 1, Z: to.Z}
+	dx, dz := to.X-from.X, to.Z-from.Z
+	if to.Y == from.Y && dx*dx+dz*dz == 1 && !b.world.solid(floor) && b.world.replaceable(floor) {
+		if err := b.bridgeTo(ctx, from, to); err != nil {
+			return fmt.Errorf("failed to bridge to (%d, %d, %d): %w", to.X, to.Y, to.Z, err)
+		}
+	}
+	ox, oz := b.pathWobble(dx, dz)
+	return b.walkTo(ctx, float64(to.X)+0.5+ox, float64(to.Y), float64(to.Z)+0.5+oz)
+}
+
+// bridgeTo places a scaffolding block under to, the next block along from, like a player
+// does: sneaking out to the edge and placing against the side of the block it stands on
+func (b *Bot) bridgeTo(ctx context.Context, from, to blockPos) error {
+	dx, dz := to.X-from.X, to.Z-from.Z
+	support := blockPos{X: from.X, Y: from.Y - 1, Z: from.Z}
+	target := blockPos{X: to.X, Y: to.Y - 1, Z: to.Z}
+	var face byte
+	switch {
+	case dx > 0:
+		face = faceEast
+	case dx < 0:
+		face = faceWest
+	case dz > 0:
+		face = faceSouth
+	default:
+		face = faceNorth
+	}
+
+	b.stateMu.RLock()
+	held := b.heldSlot
+	b.stateMu.RUnlock()
+	if err := b.holdScaffolding(); err != nil {
+		return err
+	}
+	defer b.selectHotbarSlot(held)
+
+	if err := b.sendSneak(true); err != nil {
+		return err
+	}
+	defer b.sendSneak(false)
+	edgeX, edgeZ := float64(from.X)+0.5+float64(dx)*bridgeLean, float64(from.Z)+0.5+float64(dz)*bridgeLean
+	if err := b.walkWithin(ctx, edgeX, float64(from.Y), edgeZ, arriveRadius/5); err != nil {
+		return err
+	}
+
+	if err := b.aimAt(ctx, support, face); err != nil {
+		return err
+	}
+	if err := b.budget.spend(ctx, actionPlace); err != nil {
+		return err
+	}
+	updates, stop := b.world.watch(target)
+	defer stop()
+	fx, fy, fz := faceCenter(support, face)
+	cx, cy, cz := fx-float64(support.X), fy-float64(support.Y), fz-float64(support.Z)
+	if err := b.sendUseItemOn(support, face, float32(cx), float32(cy), float32(cz)); err != nil {
+		return err
+	}
+	if err := b.sendArmSwing(); err != nil {
+		return err
+	}
+
+	if err := b.waitPlaced(ctx, updates, target); err != nil {
+		return err
+	}
+	b.log.Printf("🧱 Placed scaffolding at (%d, %d, %d)", target.X, target.Y, target.Z)
+	return nil
+}
+
+// waitPlaced waits for the server to confirm a block placed at pos, updates watching pos
+func (b *Bot) waitPlaced(ctx context.Context, updates <-chan block.StateID, pos blockPos) error {
+	if b.world.solid(pos) {
+		return nil
+	}
+	timeout := time.NewTimer(bridgePlaceTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-updates:
+			if b.world.solid(pos) {
+				return nil
+			}
+		case <-timeout.C:
+			return errors.New("the server didn't place the block")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package miner
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Kinds of actions charged against the budget
+const (
+	actionBreak    = "break"
+	actionPlace    = "place"
+	actionInteract = "interact"
+)
+
+// actionBudget is a token bucket limiting world-changing actions per second
+type actionBudget struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second, 0 means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+	spent  map[string]int
+	waited time.Duration
+}
+
+func newActionBudget() *actionBudget {
+	return &actionBudget{spent: make(map[string]int)}
+}
+
+// configure sets the budget from the config, applying the profile of the server when present
+func (a *actionBudget) configure(c Config, logger *log.Logger) {
+	rate, burst := c.ActionsPerSecond, c.ActionBurst
+	if p, ok := c.Profiles[c.Server]; ok {
+		logger.Printf("📐 Using server profile for %s", c.Server)
+		if p.ActionsPerSecond != nil {
+			rate = *p.ActionsPerSecond
+		}
+		if p.ActionBurst != nil {
+			burst = *p.ActionBurst
+		}
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rate = rate
+	a.burst = float64(burst)
+	a.tokens = float64(burst)
+	a.last = time.Now()
+
+	if rate > 0 {
+		logger.Printf("📐 Action budget: %.2f actions/s, burst %d", rate, burst)
+	}
+}
+
+// spend takes one token for an action, waiting until one is available
+func (a *actionBudget) spend(ctx context.Context, kind string) error {
+	start := time.Now()
+	for {
+		wait := a.take(kind)
+		if wait == 0 {
+			if waited := time.Since(start); waited > tickDuration {
+				a.mu.Lock()
+				a.waited += waited
+				a.mu.Unlock()
+			}
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// take consumes a token and returns zero, or returns how long until one is available
+func (a *actionBudget) take(kind string) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.rate > 0 {
+		now := time.Now()
+		a.tokens = min(a.burst, a.tokens+now.Sub(a.last).Seconds()*a.rate)
+		a.last = now
+		if a.tokens < 1 {
+			return time.Duration((1 - a.tokens) / a.rate * float64(time.Second))
+		}
+		a.tokens--
+	}
+	a.spent[kind]++
+	return 0
+}
+
+// snapshot returns the actions spent by kind and the total time spent waiting for the budget
+func (a *actionBudget) snapshot() (spent map[string]int, waited time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	spent = make(map[string]int, len(a.spent))
+	for k, v := range a.spent {
+		spent[k] = v
+	}
+	return spent, a.waited
+}
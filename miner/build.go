@@ -0,0 +1,398 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+
+	"github.com/coolguycoder/Minecraft-Miner/schematic"
+)
+
+const (
+	buildTaskName       = "build"
+	buildDefaultDir     = "schematics"
+	buildMaterialWait   = 5 * time.Minute  // Time the bot waits for missing materials before giving up
+	buildMaterialPoll   = 5 * time.Second  // How often the inventory is checked while waiting
+	buildMaxBlocks      = 100000           // Largest schematic the bot takes on
+	buildMaterialsShown = 5                // Missing materials listed in one chat message
+	buildStepBack       = 2.5              // Distance the bot keeps from a block it stands in to place it
+	buildPlaceRetries   = 1                // Passes over the blocks of a layer that failed to place
+	buildReportEvery    = 20 * time.Second // Least time between two progress reports in chat
+)
+
+// buildCommand matches "!build <file> <x> <y> <z>", the file name without a directory
+var buildCommand = regexp.MustCompile(`(?i)!build\s+([\w.-]+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+// buildExtensions are the schematic formats tried for a file name without an extension
+var buildExtensions = []string{".schem", ".litematic"}
+
+// wallItemSuffixes are the blocks placed against a wall whose item is the standing variant,
+// like minecraft:oak_wall_sign from minecraft:oak_sign
+var wallItemSuffixes = []string{"torch", "sign", "banner", "head", "skull", "fan"}
+
+// buildItems are the items of the blocks not named like them
+var buildItems = map[string]string{
+	"minecraft:redstone_wire":    "minecraft:redstone",
+	"minecraft:tripwire":         "minecraft:string",
+	"minecraft:water":            "minecraft:water_bucket",
+	"minecraft:lava":             "minecraft:lava_bucket",
+	"minecraft:wheat":            "minecraft:wheat_seeds",
+	"minecraft:potatoes":         "minecraft:potato",
+	"minecraft:carrots":          "minecraft:carrot",
+	"minecraft:beetroots":        "minecraft:beetroot_seeds",
+	"minecraft:cocoa":            "minecraft:cocoa_beans",
+	"minecraft:sweet_berry_bush": "minecraft:sweet_berries",
+	"minecraft:bamboo_sapling":   "minecraft:bamboo",
+	"minecraft:cave_vines":       "minecraft:glow_berries",
+	"minecraft:cave_vines_plant": "minecraft:glow_berries",
+	"minecraft:tall_seagrass":    "minecraft:seagrass",
+}
+
+// errNoSupport is returned for a block with no neighbour to place it against
+var errNoSupport = errors.New("nothing to place it against")
+
+// blockItem returns the item that places a block, false for blocks no item places
+func blockItem(name string) (string, bool) {
+	item := name
+	if it, ok := buildItems[name]; ok {
+		item = it
+	} else if strings.Contains(name, "wall_") {
+		for _, suffix := range wallItemSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				item = strings.Replace(name, "wall_", "", 1)
+				break
+			}
+		}
+	}
+	_, ok := itemID(item)
+	return item, ok
+}
+
+// schematicPath returns the file of a schematic name in the schematics directory, trying the
+// known extensions when it has none
+func (b *Bot) schematicPath(name string) (string, error) {
+	path := filepath.Join(b.cfg.SchematicsDir, filepath.Base(name))
+	if filepath.Ext(name) != "" {
+		return path, nil
+	}
+	for _, ext := range buildExtensions {
+		if _, err := os.Stat(path + ext); err == nil {
+			return path + ext, nil
+		}
+	}
+	return "", fmt.Errorf("no %s.schem or %s.litematic in %s", name, name, b.cfg.SchematicsDir)
+}
+
+// loadSchematic reads a schematic of the schematics directory
+func (b *Bot) loadSchematic(name string) (*schematic.Schematic, error) {
+	path, err := b.schematicPath(name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := schematic.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Blocks) > buildMaxBlocks {
+		return nil, fmt.Errorf("%s has %d blocks, more than the %d the bot builds", name, len(s.Blocks), buildMaxBlocks)
+	}
+	return s, nil
+}
+
+// handleBuildCommand queues building the schematic of "!build <file> <x> <y> <z>" with its
+// lowest corner at the coordinates
+func (b *Bot) handleBuildCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := buildCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !build <file> <x> <y> <z>")
+		return
+	}
+	var origin blockPos
+	for i, v := range []*int{&origin.X, &origin.Y, &origin.Z} {
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			b.reply(msg, fmt.Sprintf("%s is not a coordinate", m[i+2]))
+			return
+		}
+		*v = n
+	}
+	name := m[1]
+	s, err := b.loadSchematic(name)
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Can't build %s: %v", name, err))
+		return
+	}
+
+	b.queueBuild(name, origin, senderOf(msg))
+	text := fmt.Sprintf("Building %s at (%d, %d, %d): %d blocks in %d layers", name, origin.X, origin.Y, origin.Z, len(s.Blocks), s.Height)
+	if missing := b.missingMaterials(s.Blocks, origin); len(missing) > 0 {
+		text += ". Still need " + describeMaterials(missing)
+	}
+	b.reply(msg, text)
+}
+
+// queueBuild queues building a schematic with its lowest corner at origin and reports the
+// outcome to player
+func (b *Bot) queueBuild(name string, origin blockPos, player string) *task {
+	spec := &taskSpec{Kind: buildTaskName, Pos: &origin, Item: name, Player: player}
+	return b.enqueueResumableTask(fmt.Sprintf("%s %s", buildTaskName, name), exposureSurface, spec, func(ctx context.Context) error {
+		s, err := b.loadSchematic(name)
+		if err == nil {
+			err = b.buildSchematic(ctx, s, origin, player)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			b.replyTo(buildTaskName, player, fmt.Sprintf("Stopped building %s: %v", name, err))
+		}
+		return err
+	})
+}
+
+// buildSchematic places the blocks of a schematic layer by layer from the bottom, skipping
+// those the world has already. Blocks that fail to place are tried again at the end of their
+// layer, then left out. Every block placed is checked against the world.
+func (b *Bot) buildSchematic(ctx context.Context, s *schematic.Schematic, origin blockPos, player string) error {
+	if b.cfg.ScaffoldSlot < 0 || b.cfg.ScaffoldSlot >= hotbarSize {
+		return fmt.Errorf("scaffold_slot %d is not a hotbar slot (0-%d)", b.cfg.ScaffoldSlot, hotbarSize-1)
+	}
+	b.stateMu.RLock()
+	held := b.heldSlot
+	b.stateMu.RUnlock()
+	defer b.selectHotbarSlot(held)
+	if err := b.sendSneak(true); err != nil { // Clicks on chests and doors place instead of opening them
+		return err
+	}
+	defer b.sendSneak(false)
+
+	var placed, present int
+	var failed []string
+	reported := time.Now()
+	for start := 0; start < len(s.Blocks); {
+		y := s.Blocks[start].Y
+		end := start
+		for end < len(s.Blocks) && s.Blocks[end].Y == y {
+			end++
+		}
+		layer := s.Blocks[start:end]
+
+		for pass := 0; pass <= buildPlaceRetries && len(layer) > 0; pass++ {
+			var retry []schematic.Block
+			for i, blk := range layer {
+				pos := blockPos{X: origin.X + blk.X, Y: origin.Y + blk.Y, Z: origin.Z + blk.Z}
+				if state, ok := b.world.blockAt(pos); ok && blockName(state) == blk.Name {
+					if pass == 0 {
+						present++
+					}
+					continue
+				}
+				item, ok := blockItem(blk.Name)
+				if !ok {
+					failed = append(failed, fmt.Sprintf("%s at (%d, %d, %d): no item places it", blk.Name, pos.X, pos.Y, pos.Z))
+					continue
+				}
+				if b.inventoryCounts()[item] == 0 {
+					rest := append(slices.Clone(layer[i:]), s.Blocks[end:]...)
+					if err := b.waitForMaterials(ctx, item, rest, origin, player); err != nil {
+						return err
+					}
+				}
+
+				err := b.placeBlock(ctx, pos, item)
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+				if err == nil {
+					if state, ok := b.world.blockAt(pos); !ok || blockName(state) != blk.Name {
+						err = fmt.Errorf("the world has %s there", blockName(state))
+					}
+				}
+				if err != nil {
+					if pass < buildPlaceRetries {
+						retry = append(retry, blk)
+					} else {
+						failed = append(failed, fmt.Sprintf("%s at (%d, %d, %d): %v", blk.Name, pos.X, pos.Y, pos.Z, err))
+					}
+					continue
+				}
+				placed++
+				if time.Since(reported) >= buildReportEvery {
+					reported = time.Now()
+					b.replyTo(buildTaskName, player, fmt.Sprintf("Building layer %d of %d, %d blocks placed", y+1, s.Height, placed))
+				}
+			}
+			layer = retry
+		}
+		b.log.Printf("🏗️ Finished layer %d of %d", y+1, s.Height)
+		start = end
+	}
+
+	for _, f := range failed {
+		b.log.Printf("⚠️ Couldn't place %s", f)
+	}
+	text := fmt.Sprintf("Finished building: %d blocks placed, %d already there", placed, present)
+	if len(failed) > 0 {
+		text += fmt.Sprintf(", %d left out, like %s", len(failed), failed[0])
+	}
+	b.log.Printf("🏗️ %s", text)
+	b.replyTo(buildTaskName, player, text)
+	return nil
+}
+
+// placeBlock places the block of an item at pos against one of its solid neighbours,
+// stepping out of the way first when the bot stands in it
+func (b *Bot) placeBlock(ctx context.Context, pos blockPos, item string) error {
+	if !b.world.replaceable(pos) {
+		state, _ := b.world.blockAt(pos)
+		return fmt.Errorf("%s is in the way", blockName(state))
+	}
+	support, face, ok := b.placementSupport(pos)
+	if !ok {
+		return errNoSupport
+	}
+
+	if feet := b.feetBlock(); feet == pos || (blockPos{X: feet.X, Y: feet.Y + 1, Z: feet.Z}) == pos {
+		x, y, z := b.currentPosition()
+		dx, dz := x-(float64(pos.X)+0.5), z-(float64(pos.Z)+0.5)
+		if dx == 0 && dz == 0 {
+			dx = 1
+		}
+		d := math.Max(math.Abs(dx), math.Abs(dz))
+		if err := b.walkTo(ctx, x+dx/d*buildStepBack, y, z+dz/d*buildStepBack); err != nil {
+			return err
+		}
+	}
+	if err := b.approachBlock(ctx, pos); err != nil {
+		return err
+	}
+	if ok, err := b.holdItem([]string{item}, b.cfg.ScaffoldSlot); err != nil || !ok {
+		if err == nil {
+			err = fmt.Errorf("no %s left", item)
+		}
+		return err
+	}
+
+	if err := b.aimAt(ctx, support, face); err != nil {
+		return err
+	}
+	if err := b.budget.spend(ctx, actionPlace); err != nil {
+		return err
+	}
+	updates, stop := b.world.watch(pos)
+	defer stop()
+	fx, fy, fz := faceCenter(support, face)
+	cx, cy, cz := fx-float64(support.X), fy-float64(support.Y), fz-float64(support.Z)
+	if err := b.sendUseItemOn(support, face, float32(cx), float32(cy), float32(cz)); err != nil {
+		return err
+	}
+	if err := b.sendArmSwing(); err != nil {
+		return err
+	}
+	return b.waitBlockChanged(ctx, updates, pos)
+}
+
+// placementSupport returns a solid neighbour of pos and its face pointing to pos, the block
+// below first
+func (b *Bot) placementSupport(pos blockPos) (blockPos, byte, bool) {
+	for _, face := range []byte{faceTop, faceNorth, faceSouth, faceWest, faceEast, faceBottom} {
+		n := faceNormal(face)
+		support := blockPos{X: pos.X - n.X, Y: pos.Y - n.Y, Z: pos.Z - n.Z}
+		if b.world.solid(support) {
+			return support, face, true
+		}
+	}
+	return blockPos{}, 0, false
+}
+
+// waitBlockChanged waits for the server to put a block at pos, updates watching pos. Unlike
+// waitPlaced it accepts blocks that aren't solid, like torches.
+func (b *Bot) waitBlockChanged(ctx context.Context, updates <-chan block.StateID, pos blockPos) error {
+	if !b.world.replaceable(pos) {
+		return nil
+	}
+	timeout := time.NewTimer(bridgePlaceTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-updates:
+			if !b.world.replaceable(pos) {
+				return nil
+			}
+		case <-timeout.C:
+			return errors.New("the server didn't place the block")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// missingMaterials counts the items the blocks not yet in the world at origin need beyond
+// those in the inventory
+func (b *Bot) missingMaterials(blocks []schematic.Block, origin blockPos) map[string]int {
+	need := make(map[string]int)
+	for _, blk := range blocks {
+		pos := blockPos{X: origin.X + blk.X, Y: origin.Y + blk.Y, Z: origin.Z + blk.Z}
+		if state, ok := b.world.blockAt(pos); ok && blockName(state) == blk.Name {
+			continue
+		}
+		if item, ok := blockItem(blk.Name); ok {
+			need[item]++
+		}
+	}
+	for item, n := range b.inventoryCounts() {
+		if need[item] > 0 {
+			need[item] -= n
+		}
+	}
+	for item, n := range need {
+		if n <= 0 {
+			delete(need, item)
+		}
+	}
+	return need
+}
+
+// describeMaterials lists the most needed materials, like "64 stone, 12 glass and 3 more"
+func describeMaterials(need map[string]int) string {
+	items := slices.SortedFunc(maps.Keys(need), func(a, c string) int { return need[c] - need[a] })
+	parts := make([]string, 0, buildMaterialsShown)
+	for _, item := range items[:min(len(items), buildMaterialsShown)] {
+		parts = append(parts, fmt.Sprintf("%d %s", need[item], strings.TrimPrefix(item, "minecraft:")))
+	}
+	text := strings.Join(parts, ", ")
+	if more := len(items) - len(parts); more > 0 {
+		text += fmt.Sprintf(" and %d more", more)
+	}
+	return text
+}
+
+// waitForMaterials asks player in chat for the materials the rest of the build needs and
+// waits up to buildMaterialWait for item to turn up in the inventory
+func (b *Bot) waitForMaterials(ctx context.Context, item string, rest []schematic.Block, origin blockPos, player string) error {
+	text := describeMaterials(b.missingMaterials(rest, origin))
+	b.log.Printf("🏗️ Out of %s, waiting for %s", item, text)
+	b.replyTo(buildTaskName, player, fmt.Sprintf("Out of %s, I need %s to finish", strings.TrimPrefix(item, "minecraft:"), text))
+
+	deadline := time.Now().Add(buildMaterialWait)
+	for b.inventoryCounts()[item] == 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no %s after waiting %s", item, buildMaterialWait)
+		}
+		if err := sleepCtx(ctx, buildMaterialPoll); err != nil {
+			return err
+		}
+	}
+	return nil
+}
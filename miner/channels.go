@@ -0,0 +1,166 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	channelBrand      = "minecraft:brand"
+	channelRegister   = "minecraft:register"   // Channels the sender listens on, separated by zero bytes
+	channelUnregister = "minecraft:unregister" // Channels the sender stopped listening on
+	maxPayloadSize    = 32767                  // Largest custom payload a server accepts from a client
+)
+
+// channelHub is the plugin channels of a bot: the handlers listening on them and the channels
+// the server listens on
+type channelHub struct {
+	mu       sync.Mutex
+	handlers map[string][]func(data []byte)
+	server   map[string]bool // Channels the server registered
+	brand    string          // What the server calls itself, empty until it said
+}
+
+// registerChannelHandlers passes the custom payloads of the server to the handlers of their
+// channels. Handlers only hear the play state: go-mc drops the payloads of the configuration
+// state, where servers since 1.20.2 send their brand.
+func (b *Bot) registerChannelHandlers() {
+	b.channels.handlers = make(map[string][]func(data []byte))
+	b.channels.server = make(map[string]bool)
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundCustomPayload, F: b.onCustomPayload},
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onChannelsReset},
+	)
+}
+
+// onChannelsReset forgets the channels of the server the bot left and tells the new one
+// which channels the bot listens on, which Bukkit servers only send payloads on
+func (b *Bot) onChannelsReset(pk.Packet) error {
+	b.channels.mu.Lock()
+	clear(b.channels.server)
+	b.channels.brand = ""
+	listening := slices.Sorted(maps.Keys(b.channels.handlers))
+	b.channels.mu.Unlock()
+	if len(listening) == 0 {
+		return nil
+	}
+	return b.sendPayload(channelRegister, []byte(strings.Join(listening, "\x00")))
+}
+
+// onCustomPayload keeps track of the brand and channels of the server and passes the payload
+// to the handlers of its channel
+func (b *Bot) onCustomPayload(p pk.Packet) error {
+	var (
+		channel pk.Identifier
+		data    pk.PluginMessageData
+	)
+	if err := p.Scan(&channel, &data); err != nil {
+		return fmt.Errorf("failed to parse custom payload: %w", err)
+	}
+
+	b.channels.mu.Lock()
+	switch channel {
+	case channelBrand:
+		var brand pk.String
+		if _, err := brand.ReadFrom(bytes.NewReader(data)); err == nil && string(brand) != b.channels.brand {
+			b.channels.brand = string(brand)
+			b.log.Printf("🏷️ Server brand: %s", brand)
+		}
+	case channelRegister, channelUnregister:
+		for _, name := range strings.Split(string(data), "\x00") {
+			if name != "" {
+				b.channels.server[name] = channel == channelRegister
+			}
+		}
+	}
+	handlers := slices.Clone(b.channels.handlers[string(channel)])
+	b.channels.mu.Unlock()
+
+	for _, h := range handlers {
+		h(data)
+	}
+	return nil
+}
+
+// checkChannel returns an error for a channel name that isn't a namespaced identifier
+func checkChannel(channel string) error {
+	namespace, path, ok := strings.Cut(channel, ":")
+	if !ok || namespace == "" || path == "" {
+		return fmt.Errorf("channel %q is not of the form namespace:path", channel)
+	}
+	return nil
+}
+
+// sendPayload sends a custom payload on a channel
+func (b *Bot) sendPayload(channel string, data []byte) error {
+	if len(data) > maxPayloadSize {
+		return fmt.Errorf("payload for %s is %d bytes, servers take at most %d", channel, len(data), maxPayloadSize)
+	}
+	if b.client.Conn == nil {
+		return errors.New("not connected")
+	}
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundCustomPayload,
+		pk.Identifier(channel),
+		pk.PluginMessageData(data),
+	))
+}
+
+// HandleChannel calls fn with the data of every custom payload the server sends on a plugin
+// channel, like "myplugin:jobs" or "minecraft:brand". fn runs while the bot handles packets
+// and must return quickly. The first handler of a channel registers it with the server, so
+// Bukkit plugins send on it. Payloads of the configuration state, where servers since 1.20.2
+// send their brand, don't reach the handlers.
+func (m *Miner) HandleChannel(channel string, fn func(data []byte)) error {
+	if err := checkChannel(channel); err != nil {
+		return err
+	}
+	c := &m.bot.channels
+	c.mu.Lock()
+	first := len(c.handlers[channel]) == 0
+	c.handlers[channel] = append(c.handlers[channel], fn)
+	c.mu.Unlock()
+	if first && m.bot.client.Conn != nil {
+		m.bot.sendPayload(channelRegister, []byte(channel)) // Joining again registers it when this fails
+	}
+	return nil
+}
+
+// SendPayload sends a custom payload on a plugin channel, of at most 32767 bytes
+func (m *Miner) SendPayload(channel string, data []byte) error {
+	if err := checkChannel(channel); err != nil {
+		return err
+	}
+	return m.bot.sendPayload(channel, data)
+}
+
+// ServerBrand returns what the server calls itself, like "Paper", once it sent its brand in
+// game. It is empty for servers that only send it while configuring the bot.
+func (m *Miner) ServerBrand() string {
+	m.bot.channels.mu.Lock()
+	defer m.bot.channels.mu.Unlock()
+	return m.bot.channels.brand
+}
+
+// ServerChannels returns the plugin channels the server registered, sorted
+func (m *Miner) ServerChannels() []string {
+	m.bot.channels.mu.Lock()
+	defer m.bot.channels.mu.Unlock()
+	var names []string
+	for name, ok := range m.bot.channels.server {
+		if ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,129 @@
+package miner
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	chatHistorySize = 50               // Number of chat messages kept for the dashboard
+	chatEchoWindow  = 10 * time.Second // How long a message the bot sent is recognised when it comes back
+	chatDupWindow   = 2 * time.Second  // A line arriving again this soon is a duplicate
+)
+
+// chatFormats match the chat lines that name their sender: vanilla chat, vanilla whispers
+// and the "[name -> me]" whispers of common server plugins
+var chatFormats = []*regexp.Regexp{
+	regexp.MustCompile(`^<(\w{3,16})> (.*)$`),
+	regexp.MustCompile(`^(\w{3,16}) whispers to you: (.*)$`),
+	regexp.MustCompile(`^\[(\w{3,16}) -> me\] (.*)$`),
+}
+
+// chatLine is a chat line split into sender and text
+type chatLine struct {
+	Sender  string // Player who wrote the line, empty for server messages
+	Text    string // The line without the sender prefix
+	Command string // Lowercase command word without the "!", empty unless the text starts with one
+}
+
+// parseChatLine works out who wrote a chat line and which command it gives. Only player
+// lines starting with "!" are commands, so a command quoted mid-sentence or announced by
+// the server gives none.
+func parseChatLine(raw string) chatLine {
+	line := chatLine{Text: raw}
+	for _, f := range chatFormats {
+		if m := f.FindStringSubmatch(raw); m != nil {
+			line.Sender, line.Text = m[1], m[2]
+			break
+		}
+	}
+	if line.Sender == "" {
+		return line
+	}
+	if word, _, _ := strings.Cut(strings.TrimSpace(line.Text), " "); len(word) > 1 && word[0] == '!' {
+		line.Command = strings.ToLower(word[1:])
+	}
+	return line
+}
+
+// chatEntry is a received chat message
+type chatEntry struct {
+	Time   time.Time `json:"time"`
+	Sender string    `json:"sender,omitempty"`
+	Text   string    `json:"text"`
+}
+
+// chatLog keeps the most recent chat messages, and the lines received and sent lately to
+// drop duplicates and echoes
+type chatLog struct {
+	mu       sync.Mutex
+	history  []chatEntry
+	received map[string]time.Time // Raw lines by arrival
+	sent     map[string]time.Time // Messages the bot sent by sending time
+}
+
+// record appends a message to the recent chat history and returns the new entry
+func (l *chatLog) record(text, sender string) chatEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := chatEntry{Time: time.Now(), Sender: sender, Text: text}
+	l.history = append(l.history, e)
+	if len(l.history) > chatHistorySize {
+		l.history = l.history[len(l.history)-chatHistorySize:]
+	}
+	return e
+}
+
+// recent returns a copy of the recent chat history, oldest first
+func (l *chatLog) recent() []chatEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]chatEntry{}, l.history...)
+}
+
+// duplicate reports whether the same line arrived within chatDupWindow, as when a server
+// sends one message in two packets, and remembers it
+func (l *chatLog) duplicate(raw string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	expire(l.received, now, chatDupWindow)
+	if _, ok := l.received[raw]; ok {
+		return true
+	}
+	if l.received == nil {
+		l.received = make(map[string]time.Time)
+	}
+	l.received[raw] = now
+	return false
+}
+
+// noteSent remembers a message the bot sent
+func (l *chatLog) noteSent(text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sent == nil {
+		l.sent = make(map[string]time.Time)
+	}
+	l.sent[text] = time.Now()
+}
+
+// echo reports whether text is a message the bot sent within chatEchoWindow
+func (l *chatLog) echo(text string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expire(l.sent, time.Now(), chatEchoWindow)
+	_, ok := l.sent[text]
+	return ok
+}
+
+// expire drops the entries older than window
+func expire(m map[string]time.Time, now time.Time, window time.Duration) {
+	for k, t := range m {
+		if now.Sub(t) > window {
+			delete(m, k)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package miner
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot/screen"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+	"github.com/coolguycoder/Minecraft-Miner/store"
+)
+
+const (
+	chestIndexDelay = 100 * time.Millisecond // Batches the slot updates of one container refresh
+	enderChest      = "minecraft:ender_chest"
+	findMaxReplies  = 3 // Chests named in a !find answer
+)
+
+// storageMenus maps the menu types that store items to the number of container slots
+// before the player inventory part of the window
+var storageMenus = map[int32]int{
+	0: 9, 1: 18, 2: 27, 3: 36, 4: 45, 5: 54, // Chests, barrels and ender chests
+	6:  9,  // Dispensers and droppers
+	16: 5,  // Hoppers
+	20: 27, // Shulker boxes
+}
+
+// findCommand matches "!find <item>"
+var findCommand = regexp.MustCompile(`(?i)!find\s+([a-z_:]+)`)
+
+// indexedChest is what the bot saw in a container the last time it opened it
+type indexedChest struct {
+	Pos   blockPos       `json:"pos"`
+	Block string         `json:"block"`
+	Owner string         `json:"owner,omitempty"` // Player whose ender chest this is, its contents follow the player
+	Items map[string]int `json:"items"`
+	Seen  time.Time      `json:"seen"`
+}
+
+// chestMatch is a container holding an item searched for
+type chestMatch struct {
+	Item  string       `json:"item"`
+	Count int          `json:"count"`
+	Chest indexedChest `json:"chest"`
+}
+
+// chestIndex records the contents of every container opened by the bots of the process,
+// persisted to the state database
+type chestIndex struct {
+	mu     sync.Mutex
+	db     store.Backend
+	chests map[string]indexedChest
+}
+
+// loadChestIndex reads the index from the state database. Unreadable entries are skipped.
+func loadChestIndex(db store.Backend) *chestIndex {
+	idx := &chestIndex{db: db, chests: make(map[string]indexedChest)}
+	err := db.Each(bucketChests, func(key string, value json.RawMessage) error {
+		var c indexedChest
+		if err := json.Unmarshal(value, &c); err != nil {
+			log.Printf("⚠️ Skipping chest %s in the chest index: %v", key, err)
+			return nil
+		}
+		idx.chests[key] = c
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to read the chest index: %v", err)
+	}
+	return idx
+}
+
+// key identifies a container: its position, or its owner for ender chests
+func (c indexedChest) key() string {
+	if c.Owner != "" {
+		return "ender_chest:" + c.Owner
+	}
+	return fmt.Sprintf("%d,%d,%d", c.Pos.X, c.Pos.Y, c.Pos.Z)
+}
+
+// record stores the contents of a container
+func (idx *chestIndex) record(c indexedChest) {
+	idx.mu.Lock()
+	idx.chests[c.key()] = c
+	idx.mu.Unlock()
+	if err := idx.db.Put(bucketChests, c.key(), c); err != nil {
+		log.Printf("❌ Failed to save the chest index: %v", err)
+	}
+}
+
+// find returns the containers holding an item, most first. An item that no container
+// holds under its exact name matches every item containing it, "log" finding all logs.
+func (idx *chestIndex) find(item string) []chestMatch {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	match := func(name string) bool { return name == craft.Namespaced(item) }
+	var matches []chestMatch
+	for range 2 {
+		for _, c := range idx.chests {
+			for name, n := range c.Items {
+				if match(name) {
+					matches = append(matches, chestMatch{Item: name, Count: n, Chest: c})
+				}
+			}
+		}
+		if len(matches) > 0 {
+			break
+		}
+		partial := strings.TrimPrefix(strings.ToLower(item), "minecraft:")
+		match = func(name string) bool { return strings.Contains(strings.TrimPrefix(name, "minecraft:"), partial) }
+	}
+	slices.SortFunc(matches, func(a, b chestMatch) int { return cmp.Compare(b.Count, a.Count) })
+	return matches
+}
+
+// openWindow is the container the bot has open and where it stands
+type openWindow struct {
+	ID    int
+	Type  int32
+	Pos   blockPos
+	Block string
+	Known bool // Whether the bot opened it by using a block, so its position is known
+}
+
+// noteUsedBlock remembers the block the bot is about to use, so the window it opens can be located
+func (b *Bot) noteUsedBlock(pos blockPos) {
+	b.windowMu.Lock()
+	defer b.windowMu.Unlock()
+	b.usedBlock, b.usedAt = pos, time.Now()
+}
+
+// trackWindow notes the container the server opened, located at the block used last
+func (b *Bot) trackWindow(id int, typ int32) {
+	b.windowMu.Lock()
+	defer b.windowMu.Unlock()
+	b.window = openWindow{ID: id, Type: typ}
+	if !b.usedAt.IsZero() && time.Since(b.usedAt) < screenOpenTimeout {
+		state, _ := b.world.blockAt(b.usedBlock)
+		b.window.Pos, b.window.Block, b.window.Known = b.usedBlock, blockName(state), true
+	}
+	b.usedAt = time.Time{}
+}
+
+// onScreenClose forgets the container the server closed
+func (b *Bot) onScreenClose(id int) error {
+	b.windowMu.Lock()
+	defer b.windowMu.Unlock()
+	if b.window.ID == id {
+		b.window = openWindow{}
+	}
+	return nil
+}
+
+// onContainerSlot indexes the open container once a batch of slot updates is over
+func (b *Bot) onContainerSlot(id int) {
+	b.windowMu.Lock()
+	defer b.windowMu.Unlock()
+	if b.chests == nil || b.window.ID != id || !b.window.Known {
+		return
+	}
+	if _, ok := storageMenus[b.window.Type]; !ok {
+		return
+	}
+	if b.indexTimer == nil {
+		b.indexTimer = time.AfterFunc(chestIndexDelay, b.indexWindow)
+	} else {
+		b.indexTimer.Reset(chestIndexDelay)
+	}
+}
+
+// indexWindow records the contents of the open container in the chest index
+func (b *Bot) indexWindow() {
+	b.windowMu.Lock()
+	w := b.window
+	b.windowMu.Unlock()
+	size, ok := storageMenus[w.Type]
+	if !w.Known || !ok {
+		return
+	}
+
+	c, ok := b.screens.Screens[w.ID].(*screen.Chest)
+	if !ok {
+		return
+	}
+	entry := indexedChest{Pos: w.Pos, Block: w.Block, Items: make(map[string]int), Seen: time.Now()}
+	if w.Block == enderChest {
+		entry.Owner = b.cfg.Username
+	}
+	for _, s := range c.Slots[:min(size, len(c.Slots))] {
+		if s.Count > 0 {
+			entry.Items[itemName(int32(s.ID))] += int(s.Count)
+		}
+	}
+	b.chests.record(entry)
+}
+
+// describeChest names a container for chat
+func (b *Bot) describeChest(c indexedChest) string {
+	if c.Owner == b.cfg.Username {
+		return "my ender chest"
+	}
+	if c.Owner != "" {
+		return c.Owner + "'s ender chest"
+	}
+	return fmt.Sprintf("the %s at (%d, %d, %d)", strings.TrimPrefix(c.Block, "minecraft:"), c.Pos.X, c.Pos.Y, c.Pos.Z)
+}
+
+// handleFindCommand answers "!find <item>" with the chests holding the item
+func (b *Bot) handleFindCommand(msg string) {
+	m := findCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !find <item>")
+		return
+	}
+	matches := b.chests.find(m[1])
+	if len(matches) == 0 {
+		b.reply(msg, fmt.Sprintf("I haven't seen any %s in a chest", m[1]))
+		return
+	}
+
+	var parts []string
+	for _, c := range matches[:min(findMaxReplies, len(matches))] {
+		parts = append(parts, fmt.Sprintf("%d %s in %s", c.Count, strings.TrimPrefix(c.Item, "minecraft:"), b.describeChest(c.Chest)))
+	}
+	reply := strings.Join(parts, ", ")
+	if more := len(matches) - findMaxReplies; more > 0 {
+		reply += fmt.Sprintf(" and %d more", more)
+	}
+	b.reply(msg, reply)
+}
+
+// handleChestsRequest returns the indexed containers holding ?item=, or every indexed container
+func (b *Bot) handleChestsRequest(w http.ResponseWriter, r *http.Request) {
+	if item := r.URL.Query().Get("item"); item != "" {
+		matches := b.chests.find(item)
+		if matches == nil {
+			matches = []chestMatch{}
+		}
+		writeJSON(w, http.StatusOK, matches)
+		return
+	}
+	b.chests.mu.Lock()
+	chests := make([]indexedChest, 0, len(b.chests.chests))
+	for _, c := range b.chests.chests {
+		chests = append(chests, c)
+	}
+	b.chests.mu.Unlock()
+	writeJSON(w, http.StatusOK, chests)
+}
@@ -0,0 +1,79 @@
+package miner
+
+import (
+	"fmt"
+
+	"github.com/Tnze/go-mc/bot/basic"
+)
+
+// Client information values as sent to the server
+var (
+	chatVisibilities = map[string]int{"full": 0, "system": 1, "hidden": 2}
+	mainHands        = map[string]int{"left": 0, "right": 1}
+	skinParts        = map[string]uint8{
+		"cape":            1,
+		"jacket":          basic.Jacket,
+		"left_sleeve":     basic.LeftSleeve,
+		"right_sleeve":    basic.RightSleeve,
+		"left_pants_leg":  basic.LeftPantsLeg,
+		"right_pants_leg": basic.RightPantsLeg,
+		"hat":             basic.Hat,
+	}
+)
+
+// ClientInfo is what the bot tells the server about its client when it joins. Unset fields
+// keep go-mc's defaults.
+type ClientInfo struct {
+	Locale         string   `json:"locale,omitempty"`          // Like "en_us"
+	ViewDistance   int      `json:"view_distance,omitempty"`   // Chunks, 2 to 32
+	ChatVisibility string   `json:"chat_visibility,omitempty"` // "full", "system" or "hidden"
+	ChatColors     *bool    `json:"chat_colors,omitempty"`
+	SkinParts      []string `json:"skin_parts,omitempty"` // Shown parts, like "hat" and "jacket"
+	MainHand       string   `json:"main_hand,omitempty"`  // "left" or "right"
+	Brand          string   `json:"brand,omitempty"`      // Client brand, "vanilla" by default
+}
+
+// settings applies the client information to go-mc's defaults
+func (c ClientInfo) settings() (basic.Settings, error) {
+	s := basic.DefaultSettings
+	if c.Locale != "" {
+		s.Locale = c.Locale
+	}
+	if c.ViewDistance != 0 {
+		if c.ViewDistance < 2 || c.ViewDistance > 32 {
+			return s, fmt.Errorf("view_distance %d is not between 2 and 32", c.ViewDistance)
+		}
+		s.ViewDistance = c.ViewDistance
+	}
+	if c.ChatVisibility != "" {
+		mode, ok := chatVisibilities[c.ChatVisibility]
+		if !ok {
+			return s, fmt.Errorf("chat_visibility %q is not full, system or hidden", c.ChatVisibility)
+		}
+		s.ChatMode = mode
+	}
+	if c.ChatColors != nil {
+		s.ChatColors = *c.ChatColors
+	}
+	if c.SkinParts != nil {
+		s.DisplayedSkinParts = 0
+		for _, part := range c.SkinParts {
+			bit, ok := skinParts[part]
+			if !ok {
+				return s, fmt.Errorf("%q is not a skin part", part)
+			}
+			s.DisplayedSkinParts |= bit
+		}
+	}
+	if c.MainHand != "" {
+		hand, ok := mainHands[c.MainHand]
+		if !ok {
+			return s, fmt.Errorf("main_hand %q is not left or right", c.MainHand)
+		}
+		s.MainHand = hand
+	}
+	if c.Brand != "" {
+		s.Brand = c.Brand
+	}
+	return s, nil
+}
@@ -0,0 +1,283 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/data/entity"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	hotbarStart    = 36  // Inventory slot of the first hotbar slot
+	hotbarSize     = 9   // Slots in the hotbar
+	attackReach    = 3.0 // Survival entity interaction range
+	unarmedSpeed   = 4.0 // Attack speed attribute of an empty hand
+	critMinCharge  = 0.9 // Attack strength a critical hit needs
+	critLeadTicks  = 6   // Ticks from the start of a jump until the bot is falling
+	sweepReach     = 2.0 // Distance from the target within which a sword sweep hits other entities
+	ticksPerSecond = 20
+)
+
+// attackSpeeds is the attack speed attribute (attacks per second) of weapons by item kind.
+// Axes and hoes depend on the material, keyed "kind/material".
+var attackSpeeds = map[string]float64{
+	"sword":         1.6,
+	"pickaxe":       1.2,
+	"shovel":        1.0,
+	"trident":       1.1,
+	"mace":          0.6,
+	"axe/wooden":    0.8,
+	"axe/stone":     0.8,
+	"axe/iron":      0.9,
+	"axe/golden":    1.0,
+	"axe/diamond":   1.0,
+	"axe/netherite": 1.0,
+	"hoe/wooden":    1.0,
+	"hoe/golden":    1.0,
+	"hoe/stone":     2.0,
+	"hoe/iron":      3.0,
+	"hoe/diamond":   4.0,
+	"hoe/netherite": 4.0,
+}
+
+// neutralMobs are hostile-typed mobs that only fight back when provoked, the bot leaves them alone
+var neutralMobs = map[string]bool{
+	"enderman":         true,
+	"piglin":           true,
+	"zombified_piglin": true,
+	"warden":           true,
+}
+
+// extraHostileMobs attack on sight but are not typed hostile in the entity data
+var extraHostileMobs = map[string]bool{
+	"slime":      true,
+	"magma_cube": true,
+	"phantom":    true,
+	"hoglin":     true,
+	"ghast":      true,
+	"shulker":    true,
+}
+
+// entityType returns the entity data of a type ID, nil when unknown
+func entityType(typ int32) *entity.Entity {
+	return entity.ByID[entity.ID(typ)]
+}
+
+// isHostile reports whether an entity type attacks players on sight
+func isHostile(typ int32) bool {
+	e := entityType(typ)
+	if e == nil {
+		return false
+	}
+	return (e.Type == "hostile" && !neutralMobs[e.Name]) || extraHostileMobs[e.Name]
+}
+
+// entityName names an entity type for logs
+func entityName(typ int32) string {
+	if e := entityType(typ); e != nil {
+		return e.Name
+	}
+	return fmt.Sprintf("entity type %d", typ)
+}
+
+// onHeldSlot tracks the hotbar slot the server selected for us
+func (b *Bot) onHeldSlot(p pk.Packet) error {
+	var slot pk.VarInt
+	if err := p.Scan(&slot); err != nil {
+		return err
+	}
+	b.stateMu.Lock()
+	b.heldSlot = int32(slot)
+	b.stateMu.Unlock()
+	return nil
+}
+
+// selectHotbarSlot switches the held item to a hotbar slot (0-8)
+func (b *Bot) selectHotbarSlot(slot int32) error {
+	b.stateMu.RLock()
+	current := b.heldSlot
+	b.stateMu.RUnlock()
+	if current == slot {
+		return nil
+	}
+
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundSetCarriedItem,
+		pk.Short(slot),
+	)); err != nil {
+		return fmt.Errorf("failed to select hotbar slot: %w", err)
+	}
+	b.stateMu.Lock()
+	b.heldSlot = slot
+	b.stateMu.Unlock()
+	return nil
+}
+
+// heldItem returns the item name in the selected hotbar slot, empty when the hand is empty
+func (b *Bot) heldItem() string {
+	b.stateMu.RLock()
+	slot := hotbarStart + int(b.heldSlot)
+	b.stateMu.RUnlock()
+	if b.screens == nil {
+		return ""
+	}
+	s := b.screens.Inventory.Slots[slot]
+	if s.Count <= 0 {
+		return ""
+	}
+	return itemName(int32(s.ID))
+}
+
+// weaponKind splits an item name like "minecraft:iron_sword" into material and kind
+func weaponKind(name string) (material, kind string) {
+	name = strings.TrimPrefix(name, "minecraft:")
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// selectWeapon holds the best sword of the hotbar, or the best axe without a sword,
+// and returns its name, empty when fighting bare-handed
+func (b *Bot) selectWeapon() (string, error) {
+	if b.screens == nil {
+		return "", nil
+	}
+
+	best, bestScore := -1, -1
+	var bestName string
+	for i := range hotbarSize {
+		s := b.screens.Inventory.Slots[hotbarStart+i]
+		if s.Count <= 0 {
+			continue
+		}
+		name := itemName(int32(s.ID))
+		material, kind := weaponKind(name)
+		score := toolTiers[material].tier
+		switch kind {
+		case "sword":
+			score += 10 // Any sword beats any axe, it hits more often
+		case "axe":
+		default:
+			continue
+		}
+		if score > bestScore {
+			best, bestScore, bestName = i, score, name
+		}
+	}
+	if best < 0 {
+		return "", nil
+	}
+	return bestName, b.selectHotbarSlot(int32(best))
+}
+
+// attackSpeed returns the attack speed attribute of a weapon, the empty hand's for non-weapons
+func attackSpeed(weapon string) float64 {
+	material, kind := weaponKind(weapon)
+	if speed, ok := attackSpeeds[kind]; ok {
+		return speed
+	}
+	if speed, ok := attackSpeeds[kind+"/"+material]; ok {
+		return speed
+	}
+	return unarmedSpeed
+}
+
+// attackCooldown returns how long the attack strength of a weapon takes to recharge fully
+func attackCooldown(weapon string) time.Duration {
+	return time.Duration(float64(ticksPerSecond) / attackSpeed(weapon) * float64(tickDuration))
+}
+
+// attackCharge returns the attack strength (0 to 1) of a weapon some time after the last attack
+func attackCharge(weapon string, since time.Duration) float64 {
+	return min(1, float64(since)/float64(attackCooldown(weapon)))
+}
+
+// sendAttack attacks an entity and swings the arm like the vanilla client
+func (b *Bot) sendAttack(id int32) error {
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundInteract,
+		pk.VarInt(id),
+		pk.VarInt(1),      // Type 1 = attack
+		pk.Boolean(false), // Sneaking
+	)); err != nil {
+		return fmt.Errorf("failed to attack: %w", err)
+	}
+	return b.sendArmSwing()
+}
+
+// sendInteract right-clicks an entity with the main hand
+func (b *Bot) sendInteract(id int32) error {
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundInteract,
+		pk.VarInt(id),
+		pk.VarInt(0),      // Type 0 = interact
+		pk.VarInt(0),      // Main hand
+		pk.Boolean(false), // Sneaking
+	)); err != nil {
+		return fmt.Errorf("failed to interact: %w", err)
+	}
+	return nil
+}
+
+// petNear reports whether a named or tamed entity is close enough to a target for a sweep
+// attack on it to hit the pet too
+func (b *Bot) petNear(target trackedEntity) bool {
+	_, found := b.entities.nearest(target.X, target.Y, target.Z, sweepReach, func(e trackedEntity) bool {
+		return e.ID != target.ID && e.pet()
+	})
+	return found
+}
+
+// lookAtEntity turns the bot towards the middle of an entity
+func (b *Bot) lookAtEntity(e trackedEntity) error {
+	height := 1.8
+	if t := entityType(e.Type); t != nil {
+		height = t.Height
+	}
+	x, y, z := b.currentPosition()
+	yaw, pitch := lookAngles(x, y+eyeHeight, z, e.X, e.Y+height/2, e.Z)
+	if err := b.sendRotation(yaw, pitch, true); err != nil {
+		return err
+	}
+	b.setPosition(x, y, z, yaw, pitch)
+	return nil
+}
+
+// critAttack jumps and hits the entity on the way down for a critical hit.
+// It reports whether the hit landed, it does not when the entity left reach during the jump.
+func (b *Bot) critAttack(ctx context.Context, e trackedEntity, weapon string, lastAttack time.Time) (hit bool, err error) {
+	err = b.jump(ctx, func() (bool, error) {
+		if attackCharge(weapon, time.Since(lastAttack)) < 1 {
+			return false, nil
+		}
+		now, ok := b.entities.entity(e.ID)
+		if !ok || !b.inAttackReach(now) {
+			return true, nil
+		}
+		if err := b.lookAtEntity(now); err != nil {
+			return true, err
+		}
+		if err := b.budget.spend(ctx, actionInteract); err != nil {
+			return true, err
+		}
+		if err := b.sendAttack(now.ID); err != nil {
+			return true, err
+		}
+		hit = true
+		return true, nil
+	})
+	return hit, err
+}
+
+// inAttackReach reports whether an entity is close enough to hit
+func (b *Bot) inAttackReach(e trackedEntity) bool {
+	x, y, z := b.currentPosition()
+	dx, dy, dz := e.X-x, e.Y-y, e.Z-z
+	return math.Sqrt(dx*dx+dy*dy+dz*dz) <= attackReach
+}
@@ -0,0 +1,441 @@
+package miner
+
+import (
+	"bytes"
+	"cmp"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sync/atomic"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/nbt"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/registry"
+)
+
+// bridgeDialer connects with another dialer and bridges the login and configuration of a
+// server of before 1.20.5 to go-mc's
+type bridgeDialer struct {
+	mcnet.MCDialer
+	c *protocolCodec
+}
+
+func (d bridgeDialer) DialMCContext(ctx context.Context, addr string) (*mcnet.Conn, error) {
+	conn, err := d.MCDialer.DialMCContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &configBridge{
+		Conn:          conn.Socket,
+		c:             d.c,
+		buf:           make([]byte, 32<<10),
+		loginID:       d.c.serverID(packetid.ClientboundLogin),
+		startConfigID: d.c.serverID(packetid.ClientboundStartConfiguration),
+		ackConfigID:   -1,
+	}
+	if id, ok := d.c.serverbound[int32(packetid.ServerboundConfigurationAcknowledged)]; ok {
+		b.ackConfigID = id
+	}
+	b.threshold.Store(-1)
+	conn.Socket, conn.Reader, conn.Writer = b, b, b
+	return conn, nil
+}
+
+// bridgeState is what a connection of the bridge is in
+type bridgeState int
+
+const (
+	bridgeLogin bridgeState = iota
+	bridgeConfig
+	bridgePlay
+)
+
+// configBridge rewrites the packets go-mc reads and writes below it, for servers whose login
+// and configuration differ from go-mc's. Servers of before 1.20.2 have no configuration, so
+// the bridge makes one up from the registries their login carries. Those of 1.20.2 to 1.20.4
+// number their configuration packets differently and send the registries in one packet. The
+// bot joins offline, so the socket isn't encrypted, and go-mc writes a packet at once.
+type configBridge struct {
+	net.Conn
+	c         *protocolCodec
+	threshold atomic.Int64 // Compression threshold, -1 while uncompressed
+
+	// The server IDs of the packets switching between configuration and play, -1 for none
+	loginID, startConfigID, ackConfigID int32
+
+	read bridgeState
+	buf  []byte
+	in   []byte       // Read from the socket, not translated yet
+	out  bytes.Buffer // Translated, not read by go-mc yet
+	held []pk.Packet  // Play packets before the login of a server without configuration
+
+	write      bridgeState
+	handshaken bool
+}
+
+// configless reports whether the server has no configuration, being of before 1.20.2
+func (b *configBridge) configless() bool {
+	return b.c.protocol < configPhaseProtocol
+}
+
+func (b *configBridge) Read(p []byte) (int, error) {
+	for b.out.Len() == 0 {
+		n, err := b.Conn.Read(b.buf)
+		if n > 0 {
+			if err := b.feed(b.buf[:n]); err != nil {
+				return 0, err
+			}
+		}
+		if err != nil && b.out.Len() == 0 {
+			return 0, err
+		}
+	}
+	return b.out.Read(p)
+}
+
+// feed translates the packets that are complete once data arrived
+func (b *configBridge) feed(data []byte) error {
+	if b.read == bridgePlay && b.configless() {
+		b.out.Write(data)
+		return nil
+	}
+	b.in = append(b.in, data...)
+	for {
+		length, n := binary.Uvarint(b.in)
+		if n == 0 {
+			return nil
+		}
+		if n < 0 || length > pk.MaxDataLength {
+			return errors.New("invalid packet length")
+		}
+		end := n + int(length)
+		if len(b.in) < end {
+			return nil
+		}
+		frame := b.in[:end]
+		b.in = b.in[end:]
+		if err := b.readFrame(frame); err != nil {
+			return err
+		}
+		if b.read == bridgePlay && b.configless() {
+			b.out.Write(b.in)
+			b.in = nil
+			return nil
+		}
+	}
+}
+
+// readFrame translates a packet the server sent, framed as on the socket
+func (b *configBridge) readFrame(frame []byte) error {
+	if b.read == bridgePlay {
+		id, err := b.peekID(frame)
+		if err != nil {
+			return err
+		}
+		if id == b.startConfigID {
+			b.read = bridgeConfig
+		}
+		b.out.Write(frame)
+		return nil
+	}
+
+	var p pk.Packet
+	if err := p.UnPack(bytes.NewReader(frame), int(b.threshold.Load())); err != nil {
+		return err
+	}
+	if b.read == bridgeLogin {
+		b.out.Write(frame)
+		switch packetid.ClientboundPacketID(p.ID) {
+		case packetid.ClientboundLoginLoginCompression:
+			var threshold pk.VarInt
+			if err := p.Scan(&threshold); err != nil {
+				return err
+			}
+			b.threshold.Store(int64(threshold))
+		case packetid.ClientboundLoginGameProfile:
+			b.read = bridgeConfig
+		}
+		return nil
+	}
+
+	if b.configless() {
+		if p.ID != b.loginID {
+			b.held = append(b.held, pk.Packet{ID: p.ID, Data: slices.Clone(p.Data)})
+			return nil
+		}
+		return b.configureFromLogin(p)
+	}
+	id, ok := b.c.config.clientbound[p.ID]
+	if !ok {
+		return nil
+	}
+	p.ID = id
+	switch packetid.ClientboundPacketID(id) {
+	case packetid.ClientboundConfigRegistryData:
+		var codec registryCodec
+		d := nbt.NewDecoder(bytes.NewReader(p.Data))
+		d.NetworkFormat(true)
+		if _, err := d.Decode(&codec); err != nil {
+			return err
+		}
+		return b.emitRegistries(codec)
+	case packetid.ClientboundConfigDisconnect:
+		if b.c.protocol < 765 {
+			if err := chatFields(chatText)(b.c, &p); err != nil {
+				return err
+			}
+		}
+	case packetid.ClientboundConfigFinishConfiguration:
+		b.read = bridgePlay
+	}
+	return b.emit(p)
+}
+
+// registryCodec is the registries of a server of before 1.20.5, all in one NBT compound
+type registryCodec map[string]struct {
+	Entries []registryEntry `nbt:"value"`
+}
+
+// registryEntry is an entry of a registry of before 1.20.5
+type registryEntry struct {
+	Name    string         `nbt:"name"`
+	ID      int32          `nbt:"id"`
+	Element nbt.RawMessage `nbt:"element"`
+}
+
+// configureFromLogin makes up the configuration of a server of before 1.20.2 from the
+// registries of its login, then passes the login on
+func (b *configBridge) configureFromLogin(login pk.Packet) error {
+	r := bytes.NewReader(login.Data)
+	var dimensions []pk.Identifier
+	if _, err := (pk.Tuple{new(pk.Int), new(pk.Boolean), new(pk.UnsignedByte), new(pk.Byte), pk.Array(&dimensions)}).ReadFrom(r); err != nil {
+		return err
+	}
+	var codec registryCodec
+	if _, err := nbt.NewDecoder(r).Decode(&codec); err != nil {
+		return err
+	}
+	if err := b.emitRegistries(codec); err != nil {
+		return err
+	}
+	if err := b.emit(pk.Marshal(packetid.ClientboundConfigFinishConfiguration)); err != nil {
+		return err
+	}
+	for _, p := range b.held {
+		if err := b.emit(p); err != nil {
+			return err
+		}
+	}
+	b.held, b.read = nil, bridgePlay
+	return b.emit(login)
+}
+
+// emitRegistries passes the registries go-mc knows on as a registry data packet each, and
+// notes the dimension types, chat types and biomes for the codec
+func (b *configBridge) emitRegistries(codec registryCodec) error {
+	known := registry.NewNetworkCodec()
+	for _, name := range slices.Sorted(maps.Keys(codec)) {
+		if known.Registry(name) == nil {
+			continue
+		}
+		entries := codec[name].Entries
+		slices.SortFunc(entries, func(a, b registryEntry) int { return cmp.Compare(a.ID, b.ID) })
+		if err := b.noteRegistry(name, entries); err != nil {
+			return fmt.Errorf("failed to read the registry %s: %w", name, err)
+		}
+		var data bytes.Buffer
+		pk.Identifier(name).WriteTo(&data)
+		pk.VarInt(len(entries)).WriteTo(&data)
+		for _, e := range entries {
+			pk.Identifier(e.Name).WriteTo(&data)
+			pk.Boolean(true).WriteTo(&data)
+			if name == "minecraft:dimension_type" && b.c.protocol < 755 {
+				e.Element = withWorldHeight(e.Element)
+			}
+			if _, err := pk.NBT(e.Element).WriteTo(&data); err != nil {
+				return err
+			}
+		}
+		if err := b.emit(pk.Packet{ID: int32(packetid.ClientboundConfigRegistryData), Data: data.Bytes()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// noteRegistry notes what the codec needs of a registry, its entries in order
+func (b *configBridge) noteRegistry(name string, entries []registryEntry) error {
+	switch name {
+	case "minecraft:dimension_type":
+		ids := make(map[string]int32, len(entries))
+		types := make([]dimensionType, len(entries))
+		for i, e := range entries {
+			ids[e.Name] = int32(i)
+			types[i].name = e.Name
+			if err := e.Element.Unmarshal(&types[i].element); err != nil {
+				return err
+			}
+		}
+		b.c.dimensions.Store(&ids)
+		b.c.dimensionTypes.Store(&types)
+	case "minecraft:chat_type":
+		types := make(map[int32]chatType, len(entries))
+		for _, e := range entries {
+			// 1.19 puts the decoration of chat in a compound of its own
+			var element struct {
+				Chat struct {
+					Key        string   `nbt:"translation_key"`
+					Parameters []string `nbt:"parameters"`
+					Decoration struct {
+						Key        string   `nbt:"translation_key"`
+						Parameters []string `nbt:"parameters"`
+					} `nbt:"decoration"`
+				} `nbt:"chat"`
+			}
+			if err := e.Element.Unmarshal(&element); err != nil {
+				return err
+			}
+			t := chatType{name: e.Name, key: element.Chat.Key, parameters: element.Chat.Parameters}
+			if t.key == "" {
+				t.key, t.parameters = element.Chat.Decoration.Key, element.Chat.Decoration.Parameters
+			}
+			types[e.ID] = t
+		}
+		b.c.chatTypes.Store(&types)
+	case "minecraft:worldgen/biome":
+		ids := make(map[int32]int32, len(entries))
+		for i, e := range entries {
+			ids[e.ID] = int32(i)
+		}
+		b.c.biomes.Store(&ids)
+	}
+	return nil
+}
+
+// withWorldHeight adds the bottom and height of 1.17 to a dimension type of 1.16, whose worlds
+// span 0 to 256
+func withWorldHeight(element nbt.RawMessage) nbt.RawMessage {
+	if element.Type != nbt.TagCompound || len(element.Data) == 0 {
+		return element
+	}
+	var data bytes.Buffer
+	data.Write(element.Data[:len(element.Data)-1]) // Before the end of the compound
+	for _, f := range []struct {
+		name  string
+		value int32
+	}{{"min_y", 0}, {"height", 256}} {
+		data.WriteByte(nbt.TagInt)
+		binary.Write(&data, binary.BigEndian, uint16(len(f.name)))
+		data.WriteString(f.name)
+		binary.Write(&data, binary.BigEndian, f.value)
+	}
+	data.WriteByte(nbt.TagEnd)
+	return nbt.RawMessage{Type: nbt.TagCompound, Data: data.Bytes()}
+}
+
+// emit passes a packet on to go-mc
+func (b *configBridge) emit(p pk.Packet) error {
+	return p.Pack(&b.out, int(b.threshold.Load()))
+}
+
+// peekID returns the ID of the packet framed as on the socket, without reading past it
+func (b *configBridge) peekID(frame []byte) (int32, error) {
+	r := bytes.NewReader(frame)
+	var length, size, id pk.VarInt
+	if _, err := length.ReadFrom(r); err != nil {
+		return 0, err
+	}
+	if b.threshold.Load() >= 0 {
+		if _, err := size.ReadFrom(r); err != nil {
+			return 0, err
+		}
+		if size != 0 {
+			z, err := zlib.NewReader(r)
+			if err != nil {
+				return 0, err
+			}
+			defer z.Close()
+			_, err = id.ReadFrom(z)
+			return int32(id), err
+		}
+	}
+	_, err := id.ReadFrom(r)
+	return int32(id), err
+}
+
+func (b *configBridge) Write(data []byte) (int, error) {
+	if !b.handshaken {
+		b.handshaken = true
+		return b.Conn.Write(data)
+	}
+	if b.write == bridgePlay {
+		if !b.configless() {
+			if id, err := b.peekID(data); err == nil && id == b.ackConfigID {
+				b.write = bridgeConfig
+			}
+		}
+		return b.Conn.Write(data)
+	}
+
+	threshold := int(b.threshold.Load())
+	var p pk.Packet
+	if err := p.UnPack(bytes.NewReader(data), threshold); err != nil {
+		return 0, err
+	}
+	if !b.writePacket(&p) {
+		return len(data), nil
+	}
+	if err := p.Pack(b.Conn, threshold); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// loginHello turns the bot's login start into that of a server of before 1.20.2. The UUID was
+// optional from 1.19.1 and left out before, and 1.19 to 1.19.2 asked for a signature key.
+func (b *configBridge) loginHello(e *packetEditor) {
+	var uuid pk.UUID
+	e.keep(new(pk.String))
+	e.skip(&uuid)
+	switch p := b.c.protocol; {
+	case p >= 761:
+		e.write(pk.Boolean(true), uuid)
+	case p == 760:
+		e.write(pk.Boolean(false), pk.Boolean(true), uuid)
+	case p == 759:
+		e.write(pk.Boolean(false))
+	}
+}
+
+// writePacket translates a packet of the login or configuration go-mc writes, false to drop it
+func (b *configBridge) writePacket(p *pk.Packet) bool {
+	if b.write == bridgeLogin {
+		switch packetid.ServerboundPacketID(p.ID) {
+		case packetid.ServerboundLoginHello:
+			if b.configless() {
+				edit(p, b.loginHello)
+			}
+		case packetid.ServerboundLoginLoginAcknowledged:
+			b.write = bridgeConfig
+			return !b.configless()
+		}
+		return true
+	}
+
+	if packetid.ServerboundPacketID(p.ID) == packetid.ServerboundConfigFinishConfiguration {
+		b.write = bridgePlay
+	}
+	if b.configless() {
+		return false
+	}
+	id, ok := b.c.config.serverbound[p.ID]
+	p.ID = id
+	return ok
+}
@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/coolguycoder/Minecraft-Miner/world"
+)
+
+// Constraints of task_constraints
+const (
+	constraintDay       = "day"        // Only by day, with time left before nightfall
+	constraintNoRain    = "no_rain"    // Not while it rains, snows or thunders
+	constraintNoThunder = "no_thunder" // Not during thunderstorms
+)
+
+// taskConstraints report whether a constraint lets a task run now, and why not
+var taskConstraints = map[string]func(b *Bot) (ok bool, why string){
+	constraintDay: func(b *Bot) (bool, string) {
+		safe, _ := b.surfaceSafe()
+		return safe, "it is night"
+	},
+	constraintNoRain: func(b *Bot) (bool, string) {
+		return b.clock.Weather() == world.Clear, "it is raining"
+	},
+	constraintNoThunder: func(b *Bot) (bool, string) {
+		return b.clock.Weather() != world.Thunderstorm, "there is a thunderstorm"
+	},
+}
+
+// defaultTaskConstraints returns the default task_constraints, which keep surface tasks out of
+// thunderstorms
+func defaultTaskConstraints() map[string][]string {
+	return map[string][]string{exposureSurface.String(): {constraintNoThunder}}
+}
+
+// checkTaskConstraints checks the names of task_constraints
+func checkTaskConstraints(constraints map[string][]string) error {
+	for key, names := range constraints {
+		for _, name := range names {
+			if _, ok := taskConstraints[name]; !ok {
+				return fmt.Errorf("%s: unknown constraint %q", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// constraintsFor returns the constraints of a task: those of its exposure and those of its
+// kind, or of its name for tasks that aren't resumed
+func (b *Bot) constraintsFor(name string, e exposure, spec *taskSpec) []string {
+	kind := name
+	if spec != nil {
+		kind = spec.Kind
+	}
+	names := slices.Clone(b.cfg.TaskConstraints[e.String()])
+	for _, c := range b.cfg.TaskConstraints[kind] {
+		if !slices.Contains(names, c) {
+			names = append(names, c)
+		}
+	}
+	return names
+}
+
+// constraintsMet reports whether every constraint of a task lets it run now, and why not
+func (b *Bot) constraintsMet(t *task) (bool, string) {
+	for _, name := range t.Constraints {
+		if ok, why := taskConstraints[name](b); !ok {
+			return false, why
+		}
+	}
+	return true, ""
+}
+
+// enforceConstraints stops the running task when one of its constraints no longer lets it
+// run, queueing it again to start over once they do
+func (b *Bot) enforceConstraints() {
+	var why string
+	stopped := b.tasks.requeueCurrent(func(t *task) bool {
+		var ok bool
+		ok, why = b.constraintsMet(t)
+		return !ok
+	})
+	if stopped != nil {
+		b.log.Printf("⏸️ Stopped %s because %s, task #%d starts again once it may", stopped.Name, why, stopped.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	bucketCookies = "cookies" // Cookies servers stored on each bot, keyed by username
+	cookieMaxSize = 5 * 1024  // Largest cookie the vanilla client keeps
+)
+
+// registerCookieHandlers answers the cookie packets of the play state. go-mc handles those
+// of the login and configuration states with the same cookie jar.
+func (b *Bot) registerCookieHandlers() {
+	b.client.Cookies = make(map[string][]byte)
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundStoreCookie, F: b.onStoreCookie},
+		bot.PacketHandler{ID: packetid.ClientboundCookieRequest, F: b.onCookieRequest},
+	)
+}
+
+// onStoreCookie keeps a cookie the server sets
+func (b *Bot) onStoreCookie(p pk.Packet) error {
+	var (
+		key     pk.Identifier
+		payload pk.ByteArray
+	)
+	if err := p.Scan(&key, &payload); err != nil {
+		return fmt.Errorf("failed to parse stored cookie: %w", err)
+	}
+	if len(payload) > cookieMaxSize {
+		b.log.Printf("⚠️ Ignoring the %d byte cookie %s, cookies are at most %d bytes", len(payload), key, cookieMaxSize)
+		return nil
+	}
+	b.log.Printf("🍪 Server stored cookie %s (%d bytes)", key, len(payload))
+	b.client.Cookies[string(key)] = []byte(payload)
+	b.saveCookies()
+	return nil
+}
+
+// onCookieRequest sends the server the cookie it asks for, or none when there is none
+func (b *Bot) onCookieRequest(p pk.Packet) error {
+	var key pk.Identifier
+	if err := p.Scan(&key); err != nil {
+		return fmt.Errorf("failed to parse cookie request: %w", err)
+	}
+	cookie, ok := b.client.Cookies[string(key)]
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundCookieResponse,
+		key,
+		pk.OptionEncoder[pk.ByteArray]{Has: pk.Boolean(ok), Val: pk.ByteArray(cookie)},
+	))
+}
+
+// loadCookies restores the cookies servers stored on the bot before it restarted
+func (b *Bot) loadCookies() {
+	cookies := make(map[string][]byte)
+	if _, err := b.db.Get(bucketCookies, b.cfg.Username, &cookies); err != nil {
+		b.log.Printf("⚠️ Starting without cookies: %v", err)
+		return
+	}
+	maps.Copy(b.client.Cookies, cookies)
+}
+
+// saveCookies writes the cookie jar to the state database, including the cookies go-mc
+// stored while joining
+func (b *Bot) saveCookies() {
+	if err := b.db.Put(bucketCookies, b.cfg.Username, b.client.Cookies); err != nil {
+		b.log.Printf("⚠️ Failed to save cookies: %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/bot/screen"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/inventory"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+const (
+	craftingTable      = "minecraft:crafting_table"
+	craftingMenu       = 12                     // Menu type of the crafting table window
+	windowSlots        = 90                     // Slots kept for windows go-mc does not size, enough for any menu
+	screenOpenTimeout  = 2 * time.Second        // How long the server has to open a container
+	craftSyncDelay     = 250 * time.Millisecond // Time for the server to send the crafted items before the next step
+	craftMaxCount      = 256
+	craftTaskName      = "craft"
+	playerInventoryLen = 36 // Main inventory and hotbar slots every window ends with
+)
+
+// craftCommand matches "!craft [count] <item>"
+var craftCommand = regexp.MustCompile(`(?i)!craft\s+(?:(\d+)\s+)?([a-z_:]+)`)
+
+// openedScreen is a container the server opened for the bot
+type openedScreen struct {
+	ID   int
+	Type int32
+}
+
+// onScreenOpen makes sure the screen manager keeps the slots of every window and tells
+// a waiting task about it. go-mc only creates chests and sizes them without the player's slots.
+func (b *Bot) onScreenOpen(id int, typ int32, title chat.Message) error {
+	c, ok := b.screens.Screens[id].(*screen.Chest)
+	switch {
+	case !ok:
+		b.screens.Screens[id] = &screen.Chest{Type: inventory.InventoryID(typ), Title: title, Slots: make([]screen.Slot, windowSlots)}
+	case len(c.Slots) < c.Rows*9+playerInventoryLen:
+		c.Slots = append(c.Slots, make([]screen.Slot, playerInventoryLen)...)
+	}
+
+	b.trackWindow(id, typ)
+
+	select {
+	case b.screenOpened <- openedScreen{ID: id, Type: typ}:
+	default:
+	}
+	return nil
+}
+
+// craftStack converts a slot for crafting plans
+func craftStack(s screen.Slot) craft.Stack {
+	if s.Count <= 0 {
+		return craft.Stack{}
+	}
+	return craft.Stack{Item: itemName(int32(s.ID)), Count: int(s.Count)}
+}
+
+// encodeStack writes a predicted stack in the container click slot format
+func encodeStack(s craft.Stack) pk.Tuple {
+	id, ok := itemID(s.Item)
+	if s.Count <= 0 || !ok {
+		return pk.Tuple{pk.VarInt(0)}
+	}
+	return pk.Tuple{pk.VarInt(s.Count), pk.VarInt(id), pk.VarInt(0), pk.VarInt(0)}
+}
+
+// sendClick sends a container click with the predicted changes
+func (b *Bot) sendClick(window int, c craft.Click) error {
+	changed := pk.Tuple{pk.VarInt(len(c.Changed))}
+	for slot, s := range c.Changed {
+		changed = append(changed, pk.Short(slot), encodeStack(s))
+	}
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundContainerClick,
+		pk.VarInt(window),
+		pk.VarInt(b.containerState.Load()),
+		pk.Short(c.Slot),
+		pk.Byte(c.Button),
+		pk.VarInt(c.Mode),
+		changed,
+		encodeStack(c.Cursor),
+	)); err != nil {
+		return err
+	}
+	b.applyClick(window, c)
+	return nil
+}
+
+// closeWindow tells the server the bot closed a container, indexing its final contents
+func (b *Bot) closeWindow(id int) error {
+	b.windowMu.Lock()
+	pending := b.indexTimer != nil && b.indexTimer.Stop()
+	b.windowMu.Unlock()
+	if pending {
+		b.indexWindow()
+	}
+	b.onScreenClose(id)
+	delete(b.screens.Screens, id)
+	return b.client.Conn.WritePacket(pk.Marshal(packetid.ServerboundContainerClose, pk.VarInt(id)))
+}
+
+// findNearbyBlock returns the closest block of a kind within radius of the bot's feet
+func (b *Bot) findNearbyBlock(name string, radius int) (blockPos, bool) {
+	feet := b.feetBlock()
+	best, found := blockPos{}, false
+	for dx := -radius; dx <= radius; dx++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dz := -radius; dz <= radius; dz++ {
+				pos := blockPos{X: feet.X + dx, Y: feet.Y + dy, Z: feet.Z + dz}
+				state, ok := b.world.blockAt(pos)
+				if !ok || block.IsAir(state) || blockName(state) != name {
+					continue
+				}
+				if !found || distance(feet, pos) < distance(feet, best) {
+					best, found = pos, true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// useBlock right-clicks a block, opening it if it is a container
+func (b *Bot) useBlock(ctx context.Context, pos blockPos) error {
+	face, ok := b.visibleFace(pos)
+	if !ok {
+		return fmt.Errorf("%w to (%d, %d, %d)", errNoLineOfSight, pos.X, pos.Y, pos.Z)
+	}
+	if err := b.aimAt(ctx, pos, face); err != nil {
+		return err
+	}
+	if err := b.budget.spend(ctx, actionInteract); err != nil {
+		return err
+	}
+
+	b.noteUsedBlock(pos)
+	return b.sendUseItemOn(pos, face, 0.5, 0.5, 0.5)
+}
+
+// openContainer right-clicks the block at pos and waits for the server to open a window
+// accept agrees to, returning the window
+func (b *Bot) openContainer(ctx context.Context, pos blockPos, accept func(typ int32) bool) (openedScreen, error) {
+	// Forget windows opened before
+	select {
+	case <-b.screenOpened:
+	default:
+	}
+	if err := b.useBlock(ctx, pos); err != nil {
+		return openedScreen{}, err
+	}
+
+	timeout := time.NewTimer(screenOpenTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case s := <-b.screenOpened:
+			if accept(s.Type) {
+				return s, nil
+			}
+		case <-timeout.C:
+			return openedScreen{}, errors.New("no window opened")
+		case <-ctx.Done():
+			return openedScreen{}, ctx.Err()
+		}
+	}
+}
+
+// openCraftingTable opens a crafting table within reach and returns its window ID
+func (b *Bot) openCraftingTable(ctx context.Context) (int, error) {
+	pos, ok := b.findNearbyBlock(craftingTable, int(math.Floor(miningReach)))
+	if !ok {
+		return 0, errors.New("no crafting table within reach")
+	}
+	s, err := b.openContainer(ctx, pos, func(typ int32) bool { return typ == craftingMenu })
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the crafting table: %w", err)
+	}
+	return s.ID, nil
+}
+
+// windowStacks returns the contents of a window by slot
+func (b *Bot) windowStacks(window int) []craft.Stack {
+	var slots []screen.Slot
+	if window == 0 {
+		slots = b.screens.Inventory.Slots[:]
+	} else if c, ok := b.screens.Screens[window].(*screen.Chest); ok {
+		slots = c.Slots
+	}
+	stacks := make([]craft.Stack, len(slots))
+	for i, s := range slots {
+		stacks[i] = craftStack(s)
+	}
+	return stacks
+}
+
+// craftItem crafts count of an item from the inventory, crafting missing intermediate items
+// like planks and sticks on the way. Recipes larger than 2x2 need a crafting table within reach.
+func (b *Bot) craftItem(ctx context.Context, item string, count int) error {
+	if b.screens == nil {
+		return errors.New("inventory not tracked")
+	}
+	steps, err := b.craftBook().Plan(item, count, b.inventoryCounts())
+	if err != nil {
+		return err
+	}
+
+	table := -1
+	defer func() {
+		if table >= 0 {
+			if err := b.closeWindow(table); err != nil {
+				b.log.Printf("⚠️ Failed to close the crafting table: %v", err)
+			}
+		}
+	}()
+
+	for _, step := range steps {
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+		window, layout := 0, craft.InventoryLayout
+		if !step.Recipe.FitsInventory() {
+			if table < 0 {
+				if table, err = b.openCraftingTable(ctx); err != nil {
+					table = -1
+					return err
+				}
+				// Let the window contents arrive
+				if err := sleepCtx(ctx, craftSyncDelay); err != nil {
+					return err
+				}
+			}
+			window, layout = table, craft.TableLayout
+		}
+
+		clicks, err := layout.Clicks(step, b.windowStacks(window))
+		if err != nil {
+			return fmt.Errorf("failed to craft %s: %w", step.Recipe.Result, err)
+		}
+		for _, c := range clicks {
+			if err := b.sendClick(window, c); err != nil {
+				return fmt.Errorf("failed to click: %w", err)
+			}
+		}
+		b.log.Printf("🔨 Crafted %d %s", step.Times*step.Recipe.Count, step.Recipe.Result)
+
+		// The next step needs what this one made
+		if err := sleepCtx(ctx, craftSyncDelay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleepCtx waits for d or until ctx is cancelled
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleCraftCommand queues crafting from "!craft [count] <item>"
+func (b *Bot) handleCraftCommand(msg string) {
+	m := craftCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !craft [count] <item>")
+		return
+	}
+	count, item := 1, craft.Namespaced(strings.ToLower(m[2]))
+	if m[1] != "" {
+		count, _ = strconv.Atoi(m[1])
+		count = min(max(count, 1), craftMaxCount)
+	}
+	if len(craft.Default().Find(item)) == 0 {
+		b.reply(msg, fmt.Sprintf("I don't know how to craft %s", item))
+		return
+	}
+	if len(b.craftBook().Find(item)) == 0 {
+		b.reply(msg, fmt.Sprintf("The server hasn't unlocked the recipe for %s yet", item))
+		return
+	}
+
+	b.queueCraft(item, count, senderOf(msg))
+}
+
+// queueCraft queues crafting count of an item and reports the outcome in chat
+func (b *Bot) queueCraft(item string, count int, player string) *task {
+	spec := &taskSpec{Kind: craftTaskName, Item: item, Count: count, Player: player}
+	return b.enqueueResumableTask(fmt.Sprintf("%s %d %s", craftTaskName, count, item), exposureAny, spec, func(ctx context.Context) error {
+		err := b.craftItem(ctx, item, count)
+		var missing *craft.MissingError
+		switch {
+		case errors.As(err, &missing):
+			b.replyTo(craftTaskName, player, fmt.Sprintf("Can't craft %s, %v", item, err))
+		case err == nil:
+			b.replyTo(craftTaskName, player, fmt.Sprintf("Crafted %d %s", count, item))
+		}
+		return err
+	})
+}
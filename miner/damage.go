@@ -0,0 +1,190 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Kinds of damage the bot tells apart
+const (
+	damageFire   = "fire"
+	damageLava   = "lava"
+	damageFall   = "fall"
+	damageMob    = "mob"
+	damagePlayer = "player"
+	damageOther  = "other"
+)
+
+const (
+	waterBucket     = "minecraft:water_bucket"
+	emptyBucket     = "minecraft:bucket"
+	bucketSlot      = 6                // Hotbar slot the water bucket is used from, shared with potions
+	douseWait       = 20               // Ticks the water stays before the bot scoops it up again
+	douseCooldown   = 5 * time.Second  // Least time between two buckets of water
+	attackerMemory  = 10 * time.Second // How long a mob that hit the bot stays the one to fight back
+	playerFleeDist  = 16.0             // Blocks the bot runs from a player hitting it
+	douseTaskName   = "douse"
+	attackedCommand = "attacked" // command_replies key of attack reports
+)
+
+// damageMessages are the message IDs of the damage types by kind
+var damageMessages = map[string]string{
+	"inFire":   damageFire,
+	"onFire":   damageFire,
+	"hotFloor": damageFire,
+	"lava":     damageLava,
+	"fall":     damageFall,
+	"mob":      damageMob,
+	"player":   damagePlayer,
+}
+
+// damageWatch remembers who last hit the bot and when it last poured water
+type damageWatch struct {
+	mu         sync.Mutex
+	attacker   int32 // Entity ID of the mob that hit the bot last
+	attackedAt time.Time
+	dousedAt   time.Time
+}
+
+// registerDamageHandler reads what hurt the bot from damage events
+func (b *Bot) registerDamageHandler() {
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundDamageEvent, F: b.onDamageSource})
+}
+
+// onDamageSource works out what hurt the bot and responds: water against fire and lava,
+// guard mode against mobs, running away and telling the owner about players
+func (b *Bot) onDamageSource(p pk.Packet) error {
+	var id, sourceType, cause, direct pk.VarInt
+	if err := p.Scan(&id, &sourceType, &cause, &direct); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID {
+		return nil
+	}
+
+	kind := damageOther
+	message := "unknown"
+	if t := b.client.Registries.DamageType.GetByID(int32(sourceType)); t != nil {
+		message = t.MessageID
+		if k, ok := damageMessages[t.MessageID]; ok {
+			kind = k
+		}
+	}
+	// Cause IDs are sent plus one, 0 means none. Arrows and tridents name who shot them.
+	var attacker trackedEntity
+	hasAttacker := false
+	if cause != 0 {
+		attacker, hasAttacker = b.entities.entity(int32(cause) - 1)
+	}
+	name := ""
+	if hasAttacker {
+		if entityName(attacker.Type) == "player" {
+			kind = damagePlayer
+			name = b.entities.playerName(attacker.UUID)
+		} else {
+			kind = damageMob
+			name = entityName(attacker.Type)
+		}
+	}
+
+	if name != "" {
+		b.log.Printf("🩸 Hurt by %s (%s)", name, message)
+	} else {
+		b.log.Printf("🩸 Hurt by %s", message)
+	}
+	b.events.emit(eventDamaged, map[string]string{"kind": kind, "type": message, "attacker": name})
+
+	switch kind {
+	case damageFire, damageLava:
+		b.douse()
+	case damageMob:
+		b.damage.mu.Lock()
+		b.damage.attacker, b.damage.attackedAt = attacker.ID, time.Now()
+		b.damage.mu.Unlock()
+	case damagePlayer:
+		if name == "" || strings.EqualFold(name, b.actingOwner()) || (b.swarm != nil && b.swarm.has(name)) {
+			return nil // Friendly fire, or a player the tab list doesn't know
+		}
+		pos := b.feetBlock()
+		go b.replyTo(attackedCommand, b.actingOwner(), fmt.Sprintf("%s attacked me at (%d, %d, %d), running away", name, pos.X, pos.Y, pos.Z))
+		b.flee(name+" attacks", attacker.X, attacker.Z, playerFleeDist)
+	}
+	return nil
+}
+
+// recentAttacker returns the mob that hit the bot within attackerMemory, while it is within
+// radius of the bot
+func (b *Bot) recentAttacker(radius float64) (trackedEntity, bool) {
+	b.damage.mu.Lock()
+	id, at := b.damage.attacker, b.damage.attackedAt
+	b.damage.mu.Unlock()
+	if at.IsZero() || time.Since(at) > attackerMemory {
+		return trackedEntity{}, false
+	}
+	e, ok := b.entities.entity(id)
+	if !ok || e.pet() {
+		return trackedEntity{}, false
+	}
+	x, y, z := b.currentPosition()
+	if math.Sqrt((e.X-x)*(e.X-x)+(e.Y-y)*(e.Y-y)+(e.Z-z)*(e.Z-z)) > radius {
+		return trackedEntity{}, false
+	}
+	return e, true
+}
+
+// douse pours a water bucket at the bot's feet ahead of every other task to put out fire or
+// lava, and scoops the water up again. Water boils away in the nether.
+func (b *Bot) douse() {
+	if b.world.hot() || b.inventoryCounts()[waterBucket] == 0 {
+		return
+	}
+	b.damage.mu.Lock()
+	recent := time.Since(b.damage.dousedAt) < douseCooldown
+	if !recent {
+		b.damage.dousedAt = time.Now()
+	}
+	b.damage.mu.Unlock()
+	if recent {
+		return
+	}
+
+	b.log.Println("💧 Burning, pouring water")
+	b.enqueueUrgentTask(douseTaskName, func(ctx context.Context) error {
+		b.stateMu.RLock()
+		held, yaw := b.heldSlot, b.yaw
+		b.stateMu.RUnlock()
+		defer b.selectHotbarSlot(held)
+
+		ok, err := b.holdItem([]string{waterBucket}, bucketSlot)
+		if err != nil || !ok {
+			return err
+		}
+		// Looking straight down the water lands in the block the bot stands in
+		x, y, z := b.currentPosition()
+		if err := b.sendRotation(yaw, 90, true); err != nil {
+			return err
+		}
+		b.setPosition(x, y, z, yaw, 90)
+		if err := b.sendUseItem(); err != nil {
+			return fmt.Errorf("failed to pour water: %w", err)
+		}
+		if err := sleepCtx(ctx, douseWait*tickDuration); err != nil {
+			return err
+		}
+		if b.heldItem() != emptyBucket {
+			return nil // The server didn't take the water
+		}
+		if err := b.sendUseItem(); err != nil {
+			return fmt.Errorf("failed to scoop the water up: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,239 @@
+package miner
+
+import (
+	"slices"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/world"
+)
+
+const (
+	duskMargin    = 1200 // Surface tasks don't start within a minute of nightfall
+	skyScanHeight = 64   // Blocks above a position checked for cover
+
+	// Game events of the weather, their value is the new level
+	gameEventRainLevel    = 7
+	gameEventThunderLevel = 8
+
+	// Daylight schedule modes of the config
+	daylightAuto   = "auto"   // Only while no iron or better sword is in the hotbar
+	daylightAlways = "always" // Regardless of weapons
+	daylightOff    = "off"
+)
+
+// exposure says whether a task works under the open sky or underground
+type exposure int
+
+const (
+	exposureAny exposure = iota
+	exposureSurface
+	exposureUnderground
+)
+
+func (e exposure) String() string {
+	switch e {
+	case exposureSurface:
+		return "surface"
+	case exposureUnderground:
+		return "underground"
+	}
+	return "any"
+}
+
+// registerClockHandlers follows the time and weather updates of the server, and its tick rate
+func (b *Bot) registerClockHandlers() {
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundSetTime, F: b.onSetTime},
+		bot.PacketHandler{ID: packetid.ClientboundGameEvent, F: b.onWeather},
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onWeatherReset},
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onTickRateReset},
+		bot.PacketHandler{ID: packetid.ClientboundRespawn, F: b.onWeatherReset},
+	)
+}
+
+// onSetTime records the time of day, waking the task queue when it becomes
+// safe or unsafe to be on the surface
+func (b *Bot) onSetTime(p pk.Packet) error {
+	var (
+		worldAge, dayTime pk.Long
+		ticking           pk.Boolean
+	)
+	if err := p.Scan(&worldAge, &dayTime, &ticking); err != nil {
+		return err
+	}
+
+	b.tps.add(int64(worldAge))
+	wasSafe, wasKnown := b.surfaceSafe()
+	b.clock.SetTime(int64(dayTime), bool(ticking))
+
+	if safe, _ := b.surfaceSafe(); !wasKnown || safe != wasSafe {
+		if safe {
+			b.log.Println("🌅 Daylight, surface tasks may run")
+		} else {
+			b.log.Println("🌙 Nightfall, surface tasks wait for the morning")
+		}
+		b.enforceConstraints()
+		b.tasks.notify()
+	}
+	return nil
+}
+
+// onWeather records rain and thunder level changes, waking the task queue when the weather
+// turns
+func (b *Bot) onWeather(p pk.Packet) error {
+	var (
+		event pk.UnsignedByte
+		value pk.Float
+	)
+	if err := p.Scan(&event, &value); err != nil {
+		return err
+	}
+	before := b.clock.Weather()
+	switch event {
+	case gameEventRainLevel:
+		b.clock.SetRainLevel(float32(value))
+	case gameEventThunderLevel:
+		b.clock.SetThunderLevel(float32(value))
+	default:
+		return nil
+	}
+	if after := b.clock.Weather(); after != before {
+		b.log.Printf("🌦️ The weather turned from %s to %s", before, after)
+		b.enforceConstraints()
+		b.tasks.notify()
+	}
+	return nil
+}
+
+// onWeatherReset forgets the weather when joining or changing dimension, the server sends it again
+func (b *Bot) onWeatherReset(pk.Packet) error {
+	b.clock.ClearWeather()
+	return nil
+}
+
+// timeOfDay returns the current time of day in ticks (0 is sunrise, 6000 noon),
+// extrapolated from the last time update
+func (b *Bot) timeOfDay() (ticks int64, known bool) {
+	return b.clock.TimeOfDay()
+}
+
+// surfaceSafe reports whether it is day with enough time left for a surface task
+func (b *Bot) surfaceSafe() (safe, known bool) {
+	t, known := b.timeOfDay()
+	if !known {
+		return true, false
+	}
+	return t < world.NightStart-duskMargin || t >= world.NightEnd, true
+}
+
+// daylightScheduling reports whether tasks are ordered by the time of day
+func (b *Bot) daylightScheduling() bool {
+	switch b.cfg.DaylightSchedule {
+	case daylightOff:
+		return false
+	case daylightAlways:
+		return true
+	}
+	return !b.wellArmed()
+}
+
+// wellArmed reports whether the hotbar holds an iron or better sword
+func (b *Bot) wellArmed() bool {
+	if b.screens == nil {
+		return false
+	}
+	for i := range hotbarSize {
+		s := b.screens.Inventory.Slots[hotbarStart+i]
+		if s.Count <= 0 {
+			continue
+		}
+		material, kind := weaponKind(itemName(int32(s.ID)))
+		if kind == "sword" && toolTiers[material].tier >= tierIron {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleTask returns the index of the pending task to run next, -1 to wait.
+// Tasks whose constraints don't let them run now are skipped. By day surface tasks
+// go first, by night underground ones, and surface tasks are held back until the
+// morning or while a raid threatens. Otherwise tasks run in order.
+func (b *Bot) scheduleTask(pending []*task) int {
+	if b.switching() {
+		return -1 // Tasks start once the bot is in game on the server it moves to
+	}
+	runnable := func(t *task) bool {
+		ok, _ := b.constraintsMet(t)
+		return ok
+	}
+	safe, known := b.surfaceSafe()
+	raid := b.raidThreat()
+	if raid {
+		safe, known = false, true // Pillagers around keep the bot underground at any time of day
+	}
+	if !known || (!raid && !b.daylightScheduling()) {
+		return slices.IndexFunc(pending, runnable)
+	}
+
+	preferred := exposureUnderground
+	if safe {
+		preferred = exposureSurface
+	}
+	first := -1
+	for i, t := range pending {
+		if !runnable(t) {
+			continue
+		}
+		if t.Exposure == preferred {
+			return i
+		}
+		if first < 0 && (safe || t.Exposure != exposureSurface) {
+			first = i
+		}
+	}
+	return first
+}
+
+// skyExposed reports whether nothing but air is above pos in the loaded chunk,
+// known is false when the chunk is not loaded
+func (w *worldModel) skyExposed(pos blockPos) (exposed, known bool) {
+	if _, ok := w.blockAt(pos); !ok {
+		return false, false
+	}
+	for y := pos.Y + 1; y <= pos.Y+skyScanHeight; y++ {
+		state, ok := w.blockAt(blockPos{X: pos.X, Y: y, Z: pos.Z})
+		if !ok {
+			break // Above the top of the world
+		}
+		if !block.IsAir(state) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// regionExposure returns whether a box is open to the sky, checking its top
+// corners and center. A box with unknown columns may be either.
+func (b *Bot) regionExposure(lo, hi blockPos) exposure {
+	cx, cz := (lo.X+hi.X)/2, (lo.Z+hi.Z)/2
+	columns := [][2]int{{lo.X, lo.Z}, {lo.X, hi.Z}, {hi.X, lo.Z}, {hi.X, hi.Z}, {cx, cz}}
+	covered := 0
+	for _, c := range columns {
+		exposed, known := b.world.skyExposed(blockPos{X: c[0], Y: hi.Y, Z: c[1]})
+		if exposed {
+			return exposureSurface
+		}
+		if known {
+			covered++
+		}
+	}
+	if covered == len(columns) {
+		return exposureUnderground
+	}
+	return exposureAny
+}
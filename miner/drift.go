@@ -0,0 +1,212 @@
+package miner
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	driftSamples     = 3                // Packets of each kind checked
+	driftReportDelay = 10 * time.Second // Time after joining the packets have to arrive before the verdict
+	driftPriority    = 100              // Runs before the handlers that would fail on a bad packet
+)
+
+// driftCheck verifies the first packets of a few kinds decode exactly as the bot expects, so
+// a server speaking a different protocol version shows up as such rather than as odd
+// failures mid-mine
+type driftCheck struct {
+	mu       sync.Mutex
+	checked  map[string]int    // Packets checked by kind
+	problems map[string]string // First problem by kind
+	reported bool
+}
+
+// registerDriftCheck adds the packet listeners of the self-check
+func (b *Bot) registerDriftCheck() {
+	b.drift.checked = make(map[string]int)
+	b.drift.problems = make(map[string]string)
+	b.client.Events.AddListener(
+		bot.PacketHandler{Priority: driftPriority, ID: packetid.ClientboundPlayerPosition, F: b.checkTeleport},
+		bot.PacketHandler{Priority: driftPriority, ID: packetid.ClientboundLevelChunkWithLight, F: b.checkChunk},
+		bot.PacketHandler{Priority: driftPriority, ID: packetid.ClientboundSystemChat, F: b.checkChat},
+	)
+}
+
+// decodeExact decodes the fields from a packet and fails unless they use all of it
+func decodeExact(p pk.Packet, fields ...pk.FieldDecoder) error {
+	r := bytes.NewReader(p.Data)
+	for _, f := range fields {
+		if _, err := f.ReadFrom(r); err != nil {
+			return err
+		}
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%d byte(s) left over", r.Len())
+	}
+	return nil
+}
+
+// sample reports whether another packet of a kind should be checked
+func (d *driftCheck) sample(kind string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.checked[kind] >= driftSamples {
+		return false
+	}
+	d.checked[kind]++
+	return true
+}
+
+// fail records the first problem of a kind and reports whether it is new
+func (d *driftCheck) fail(kind, problem string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.problems[kind]; ok {
+		return false
+	}
+	d.problems[kind] = problem
+	return true
+}
+
+// mismatches returns the problems found so far, by kind
+func (d *driftCheck) mismatches() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]string, 0, len(d.problems))
+	for kind, problem := range d.problems {
+		list = append(list, kind+": "+problem)
+	}
+	slices.Sort(list)
+	return list
+}
+
+// mismatch records a problem and logs it the first time
+func (b *Bot) mismatch(kind, format string, args ...any) {
+	problem := fmt.Sprintf(format, args...)
+	if b.drift.fail(kind, problem) {
+		b.log.Printf("❌ Version data mismatch in %s: %s", kind, problem)
+	}
+}
+
+// checkTeleport decodes the player position packet with the layout go-mc reads it with
+func (b *Bot) checkTeleport(p pk.Packet) error {
+	if !b.drift.sample("teleport") {
+		return nil
+	}
+	var (
+		x, y, z    pk.Double
+		yaw, pitch pk.Float
+		flags      pk.Byte
+		teleportID pk.VarInt
+	)
+	if err := decodeExact(p, &x, &y, &z, &yaw, &pitch, &flags, &teleportID); err != nil {
+		b.mismatch("teleport", "packet doesn't match the x, y, z, yaw, pitch, flags, id layout: %v", err)
+		return nil
+	}
+	for _, v := range []float64{float64(x), float64(y), float64(z)} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > worldBorder+1 {
+			b.mismatch("teleport", "decoded an impossible position (%g, %g, %g)", x, y, z)
+			return nil
+		}
+	}
+	if flags&0x10 == 0 && math.Abs(float64(pitch)) > 90 {
+		// An absolute pitch beyond straight up or down
+		b.mismatch("teleport", "decoded an impossible pitch %g", pitch)
+	}
+	return nil
+}
+
+// checkChunk decodes a chunk and looks up its block states
+func (b *Bot) checkChunk(p pk.Packet) error {
+	if !b.drift.sample("chunk") {
+		return nil
+	}
+	dim := b.client.Registries.DimensionType.GetByID(b.player.DimensionType)
+	if dim == nil {
+		b.mismatch("registry", "dimension type %d is not in the registry the server sent", b.player.DimensionType)
+		return nil
+	}
+	var pos level.ChunkPos
+	chunk := level.EmptyChunk(int(dim.Height) / 16)
+	if err := decodeExact(p, &pos, chunk); err != nil {
+		b.mismatch("chunk", "chunk data doesn't decode: %v", err)
+		return nil
+	}
+	for s := range chunk.Sections {
+		for i := range 16 * 16 * 16 {
+			if state := chunk.Sections[s].GetBlock(i); int(state) >= len(block.StateList) {
+				b.mismatch("registry", "block state %d is beyond the %d states go-mc knows", state, len(block.StateList))
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// checkChat decodes a system chat message
+func (b *Bot) checkChat(p pk.Packet) error {
+	if !b.drift.sample("chat") {
+		return nil
+	}
+	var (
+		msg     chat.Message
+		overlay pk.Boolean
+	)
+	if err := decodeExact(p, &msg, &overlay); err != nil {
+		b.mismatch("chat", "system chat doesn't decode as a message and an overlay flag: %v", err)
+	}
+	return nil
+}
+
+// checkItemIDs looks up the items of the inventory
+func (b *Bot) checkItemIDs() {
+	for _, s := range b.inventorySnapshot() {
+		if strings.HasPrefix(s.Item, "unknown:") {
+			b.mismatch("registry", "item %s in slot %d is not an item go-mc knows", strings.TrimPrefix(s.Item, "unknown:"), s.Slot)
+			return
+		}
+	}
+}
+
+// reportDrift logs the verdict of the self-check once the first packets had time to arrive
+func (b *Bot) reportDrift() {
+	b.drift.mu.Lock()
+	if b.drift.reported {
+		b.drift.mu.Unlock()
+		return
+	}
+	b.drift.reported = true
+	var unchecked []string
+	for _, kind := range []string{"teleport", "chunk", "chat"} {
+		if b.drift.checked[kind] == 0 {
+			unchecked = append(unchecked, kind)
+		}
+	}
+	b.drift.mu.Unlock()
+	b.checkItemIDs()
+
+	if problems := b.drift.mismatches(); len(problems) > 0 {
+		b.log.Printf("❌ Version data mismatch: the server doesn't speak protocol %d (Minecraft %s data) the way the bot expects: %s. Check the server version, fix-protocol.sh and tools/datagen",
+			protocolVersion, registry.Version, strings.Join(problems, "; "))
+		return
+	}
+	if len(unchecked) > 0 {
+		b.log.Printf("✅ Protocol self-check passed, no %s packet to check yet", strings.Join(unchecked, " or "))
+		return
+	}
+	b.log.Println("✅ Protocol self-check passed: teleport, chunk and chat packets decode and IDs resolve")
+}
@@ -0,0 +1,308 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	economyAnswerWait = 5 * time.Second         // How long the server has to answer an economy command
+	sellCommandGap    = 1500 * time.Millisecond // Pause between sell commands, against chat spam kicks
+	sellCooldown      = time.Minute             // Least time between sales started on their own
+)
+
+// errSelling is a sale asked for while another runs
+var errSelling = errors.New("already selling")
+
+// EconomyConfig runs the commands of a server economy plugin like EssentialsX and reads their answers
+type EconomyConfig struct {
+	Enabled bool `json:"enabled"`
+	// Balance and Sell are the commands asking for the balance and selling an item, {item} being
+	// the item without the minecraft: prefix and {count} how many the bot has
+	Balance string `json:"balance"`
+	Sell    string `json:"sell"`
+	// Balances and Sales match the answers to them; the first group is the amount
+	Balances []string `json:"balances"`
+	Sales    []string `json:"sales"`
+	// Prices are what one of an item sells for, to value the inventory. Only items with a price are sold.
+	Prices map[string]float64 `json:"prices,omitempty"`
+	// SellAt is the inventory value at which the bot sells on its own, 0 for never
+	SellAt float64 `json:"sell_at"`
+}
+
+// defaultEconomy returns the commands and answers of EssentialsX
+func defaultEconomy() EconomyConfig {
+	return EconomyConfig{
+		Balance:  "balance",
+		Sell:     "sell {item}",
+		Balances: []string{`^Balance: \$?([\d,.]+)`},
+		Sales:    []string{`^Sold for \$?([\d,.]+)`},
+	}
+}
+
+// economyPatterns are the compiled answers
+type economyPatterns struct {
+	balances, sales []*regexp.Regexp
+}
+
+// compile compiles the answers
+func (c EconomyConfig) compile() (economyPatterns, error) {
+	var p economyPatterns
+	for _, list := range []struct {
+		exprs []string
+		into  *[]*regexp.Regexp
+	}{{c.Balances, &p.balances}, {c.Sales, &p.sales}} {
+		for _, expr := range list.exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return p, err
+			}
+			if re.NumSubexp() < 1 {
+				return p, fmt.Errorf("pattern %q has no group for the amount", expr)
+			}
+			*list.into = append(*list.into, re)
+		}
+	}
+	return p, nil
+}
+
+// economy is the balance of the bot and the money it earned selling
+type economy struct {
+	mu       sync.Mutex
+	patterns economyPatterns
+	balance  *float64 // Last balance the server reported, nil before the first
+	earned   float64  // Money made selling, carried over between runs
+	selling  bool
+	soldAt   time.Time    // When the last sale started
+	balances chan float64 // Balances the server answers with
+}
+
+// economyState is the economy in GET /stats
+type economyState struct {
+	Balance *float64 `json:"balance,omitempty"`
+	Earned  float64  `json:"earned"`
+}
+
+// parseAmount reads numbers like "19.98", "1,024" or "$5"
+func parseAmount(text string) (float64, bool) {
+	text = strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(text), ",", ""), "$")
+	v, err := strconv.ParseFloat(text, 64)
+	return v, err == nil
+}
+
+// registerEconomyHandler reads the answers to economy commands when enabled
+func (b *Bot) registerEconomyHandler() {
+	if !b.cfg.Economy.Enabled {
+		return
+	}
+	b.economy.patterns, _ = b.cfg.Economy.compile() // Checked when the config was loaded
+	b.economy.balances = make(chan float64, 1)
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundSystemChat, F: b.onEconomyMessage})
+	b.events.subscribe(b.sellWhenValuable)
+}
+
+// onEconomyMessage records the balances and sales the server reports. Only system messages
+// count, players can't fake them in chat.
+func (b *Bot) onEconomyMessage(p pk.Packet) error {
+	var msg chat.Message
+	if err := p.Scan(&msg); err != nil {
+		return nil // handleChatPacket reports it
+	}
+	text := legacyFormatting.ReplaceAllString(msg.ClearString(), "")
+	if amount, ok := matchAmount(text, b.economy.patterns.balances); ok {
+		b.economy.mu.Lock()
+		b.economy.balance = &amount
+		b.economy.mu.Unlock()
+		select {
+		case b.economy.balances <- amount:
+		default:
+		}
+		return nil
+	}
+	if amount, ok := matchAmount(text, b.economy.patterns.sales); ok {
+		b.economy.mu.Lock()
+		b.economy.earned += amount
+		earned := b.economy.earned
+		b.economy.mu.Unlock()
+		b.log.Printf("💰 Sold for $%.2f, $%.2f earned in total", amount, earned)
+		b.events.emit(eventItemsSold, map[string]float64{"amount": amount, "earned": earned})
+	}
+	return nil
+}
+
+// matchAmount returns the amount of the first pattern matching text
+func matchAmount(text string, patterns []*regexp.Regexp) (float64, bool) {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return parseAmount(m[1])
+		}
+	}
+	return 0, false
+}
+
+// snapshot returns the balance and the money earned
+func (e *economy) snapshot() economyState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return economyState{Balance: e.balance, Earned: e.earned}
+}
+
+// restore carries over the money earned in a previous run
+func (e *economy) restore(earned float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.earned += earned
+}
+
+// inventoryValue is what the priced items of the inventory sell for
+func (b *Bot) inventoryValue() float64 {
+	value := 0.0
+	for item, count := range b.inventoryCounts() {
+		value += b.itemPrice(item) * float64(count)
+	}
+	return value
+}
+
+// itemPrice is the price of one item, set with or without the minecraft: prefix
+func (b *Bot) itemPrice(item string) float64 {
+	if price, ok := b.cfg.Economy.Prices[item]; ok {
+		return price
+	}
+	return b.cfg.Economy.Prices[strings.TrimPrefix(item, "minecraft:")]
+}
+
+// sellWhenValuable sells the priced items once the inventory is worth sell_at, at most once
+// per sellCooldown in case the server refuses some of them
+func (b *Bot) sellWhenValuable(e botEvent) {
+	if e.Type != eventInventoryChanged || b.cfg.Economy.SellAt <= 0 {
+		return
+	}
+	b.economy.mu.Lock()
+	busy := b.economy.selling || time.Since(b.economy.soldAt) < sellCooldown
+	b.economy.mu.Unlock()
+	if busy {
+		return
+	}
+	if value := b.inventoryValue(); value >= b.cfg.Economy.SellAt {
+		go func() {
+			b.log.Printf("💰 The inventory is worth $%.2f, selling", value)
+			if _, err := b.sellInventory(); err != nil && !errors.Is(err, errSelling) {
+				b.log.Printf("❌ Failed to sell: %v", err)
+			}
+		}()
+	}
+}
+
+// sellInventory runs the sell command for every priced item in the inventory and returns
+// the money the sales brought in. One sale runs at a time.
+func (b *Bot) sellInventory() (float64, error) {
+	b.economy.mu.Lock()
+	if b.economy.selling {
+		b.economy.mu.Unlock()
+		return 0, errSelling
+	}
+	b.economy.selling = true
+	b.economy.soldAt = time.Now()
+	before := b.economy.earned
+	b.economy.mu.Unlock()
+	defer func() {
+		b.economy.mu.Lock()
+		b.economy.selling = false
+		b.economy.mu.Unlock()
+	}()
+
+	counts := b.inventoryCounts()
+	sold := 0
+	for _, item := range slices.Sorted(maps.Keys(counts)) {
+		if b.itemPrice(item) <= 0 {
+			continue
+		}
+		if sold > 0 {
+			time.Sleep(sellCommandGap)
+		}
+		command := strings.NewReplacer(
+			"{item}", strings.TrimPrefix(item, "minecraft:"),
+			"{count}", strconv.Itoa(counts[item]),
+		).Replace(b.cfg.Economy.Sell)
+		if err := b.sendCommand(command); err != nil {
+			return 0, fmt.Errorf("failed to sell %s: %w", item, err)
+		}
+		sold++
+		if !strings.Contains(b.cfg.Economy.Sell, "{item}") {
+			break // One command sells everything
+		}
+	}
+	if sold == 0 {
+		return 0, nil
+	}
+	time.Sleep(economyAnswerWait) // Let the last answers come in
+	if _, err := b.fetchBalance(); err != nil {
+		b.log.Printf("⚠️ No balance after selling: %v", err)
+	}
+
+	b.economy.mu.Lock()
+	defer b.economy.mu.Unlock()
+	return b.economy.earned - before, nil
+}
+
+// fetchBalance runs the balance command and waits for the answer
+func (b *Bot) fetchBalance() (float64, error) {
+	select {
+	case <-b.economy.balances: // Drop an answer nobody waited for
+	default:
+	}
+	if err := b.sendCommand(b.cfg.Economy.Balance); err != nil {
+		return 0, err
+	}
+	select {
+	case balance := <-b.economy.balances:
+		return balance, nil
+	case <-time.After(economyAnswerWait):
+		return 0, fmt.Errorf("no answer to /%s", b.cfg.Economy.Balance)
+	}
+}
+
+// handleBalanceCommand replies with the balance of the bot and the money it earned
+func (b *Bot) handleBalanceCommand(msg string) {
+	if !b.cfg.Economy.Enabled {
+		b.reply(msg, "The economy is not enabled")
+		return
+	}
+	balance, err := b.fetchBalance()
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Failed to get the balance: %v", err))
+		return
+	}
+	b.reply(msg, fmt.Sprintf("Balance: $%.2f, earned $%.2f selling, inventory worth $%.2f",
+		balance, b.economy.snapshot().Earned, b.inventoryValue()))
+}
+
+// handleSellCommand sells the priced items of the inventory now
+func (b *Bot) handleSellCommand(msg string) {
+	if !b.cfg.Economy.Enabled {
+		b.reply(msg, "The economy is not enabled")
+		return
+	}
+	if b.inventoryValue() == 0 {
+		b.reply(msg, "Nothing to sell")
+		return
+	}
+	earned, err := b.sellInventory()
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Failed to sell: %v", err))
+		return
+	}
+	b.reply(msg, fmt.Sprintf("Sold for $%.2f", earned))
+}
@@ -0,0 +1,290 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	potionItem      = "minecraft:potion"
+	potionSlot      = 6               // Hotbar slot potions are drunk from
+	drinkTicks      = 32              // Ticks it takes to drink a potion
+	drinkEffectWait = 2 * time.Second // How long after drinking the effect has to show up
+
+	potionWhenLava   = "lava"   // Before mining a block lava touches
+	potionWhenMining = "mining" // Before mining any block
+)
+
+// errNoPotion is a potion to drink missing from the inventory
+var errNoPotion = errors.New("no such potion in the inventory")
+
+// drinkCommand is !drink <potion>
+var drinkCommand = regexp.MustCompile(`(?i)!drink\s+(?:minecraft:)?([a-z_]+)`)
+
+// PotionRule drinks a potion from the inventory when its situation comes up and its effect
+// is not active yet
+type PotionRule struct {
+	Potion string `json:"potion"` // Potion type like "fire_resistance", long_ and strong_ ones count too
+	When   string `json:"when"`   // "lava" or "mining"
+}
+
+// potionEffects are the effects of the potions not named after them
+var potionEffects = map[string]string{
+	"swiftness": "speed",
+	"leaping":   "jump_boost",
+	"healing":   "instant_health",
+	"harming":   "instant_damage",
+}
+
+// activeEffect is a status effect on the bot
+type activeEffect struct {
+	Amplifier int       `json:"amplifier"`          // 0 for level I
+	Until     time.Time `json:"until,omitempty"`    // Zero for effects without an end
+	Infinite  bool      `json:"infinite,omitempty"` // Beacons and commands give effects that never run out
+}
+
+// checkPotionRules checks the situations of the potions config
+func checkPotionRules(rules []PotionRule) error {
+	for _, r := range rules {
+		if r.When != potionWhenLava && r.When != potionWhenMining {
+			return fmt.Errorf("%s: unknown situation %q", r.Potion, r.When)
+		}
+		if potionEffect(r.Potion) == "" {
+			return fmt.Errorf("unknown potion %q", r.Potion)
+		}
+	}
+	return nil
+}
+
+// potionEffect returns the effect a potion type gives, empty for unknown potions
+func potionEffect(potion string) string {
+	potion = strings.TrimPrefix(potion, "minecraft:")
+	for _, p := range registryid.Potion {
+		if strings.TrimPrefix(p, "minecraft:") != potion {
+			continue
+		}
+		base := strings.TrimPrefix(strings.TrimPrefix(potion, "long_"), "strong_")
+		if effect, ok := potionEffects[base]; ok {
+			return effect
+		}
+		return base
+	}
+	return ""
+}
+
+// registerEffectHandlers follows the status effects of the bot
+func (b *Bot) registerEffectHandlers() {
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundUpdateMobEffect, F: b.onUpdateEffect},
+		bot.PacketHandler{ID: packetid.ClientboundRemoveMobEffect, F: b.onRemoveEffect},
+		bot.PacketHandler{ID: packetid.ClientboundRespawn, F: b.onEffectsReset},
+	)
+}
+
+// onUpdateEffect records an effect the bot got
+func (b *Bot) onUpdateEffect(p pk.Packet) error {
+	var (
+		id, effect, amplifier, duration pk.VarInt
+		flags                           pk.Byte
+	)
+	if err := p.Scan(&id, &effect, &amplifier, &duration, &flags); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID {
+		return nil
+	}
+	name := effectName(int32(effect))
+	e := activeEffect{Amplifier: int(amplifier), Infinite: duration < 0}
+	if !e.Infinite {
+		e.Until = time.Now().Add(time.Duration(duration) * tickDuration)
+	}
+	b.stateMu.Lock()
+	_, had := b.effects[name]
+	b.effects[name] = e
+	b.stateMu.Unlock()
+	if !had {
+		b.log.Printf("🧪 Got %s %d", name, amplifier+1)
+	}
+	return nil
+}
+
+// onRemoveEffect forgets an effect that wore off or was taken away
+func (b *Bot) onRemoveEffect(p pk.Packet) error {
+	var id, effect pk.VarInt
+	if err := p.Scan(&id, &effect); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID {
+		return nil
+	}
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	delete(b.effects, effectName(int32(effect)))
+	return nil
+}
+
+// onEffectsReset forgets every effect on respawn, the server sends those kept across
+// dimensions again
+func (b *Bot) onEffectsReset(pk.Packet) error {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	clear(b.effects)
+	return nil
+}
+
+// effectName returns the name of an effect by registry ID, without the minecraft: prefix
+func effectName(id int32) string {
+	if id < 0 || int(id) >= len(registryid.MobEffect) {
+		return fmt.Sprintf("unknown_%d", id)
+	}
+	return strings.TrimPrefix(registryid.MobEffect[id], "minecraft:")
+}
+
+// effect returns an active effect of the bot
+func (b *Bot) effect(name string) (activeEffect, bool) {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	e, ok := b.effects[name]
+	if ok && !e.Infinite && time.Now().After(e.Until) {
+		return e, false // Ran out, the removal is on its way
+	}
+	return e, ok
+}
+
+// activeEffects returns the effects of the bot by name
+func (b *Bot) activeEffects() map[string]activeEffect {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return maps.Clone(b.effects)
+}
+
+// breakSpeedFactor is what Haste, Conduit Power and Mining Fatigue multiply the mining speed
+// by, as the vanilla client works it out
+func (b *Bot) breakSpeedFactor() float32 {
+	factor := float32(1)
+	haste, hasHaste := b.effect("haste")
+	if conduit, ok := b.effect("conduit_power"); ok && (!hasHaste || conduit.Amplifier > haste.Amplifier) {
+		haste, hasHaste = conduit, true
+	}
+	if hasHaste {
+		factor *= 1 + float32(haste.Amplifier+1)*0.2
+	}
+	if fatigue, ok := b.effect("mining_fatigue"); ok {
+		switch fatigue.Amplifier {
+		case 0:
+			factor *= 0.3
+		case 1:
+			factor *= 0.09
+		case 2:
+			factor *= 0.0027
+		default:
+			factor *= 8.1e-4
+		}
+	}
+	return factor
+}
+
+// drinkBeforeDig drinks the potions whose situation the dig at pos is, unless their effect is on
+// or the inventory has none left
+func (b *Bot) drinkBeforeDig(ctx context.Context, pos blockPos) error {
+	for _, r := range b.cfg.Potions {
+		if r.When == potionWhenLava && !b.world.nextToLava(pos) {
+			continue
+		}
+		if _, ok := b.effect(potionEffect(r.Potion)); ok {
+			continue
+		}
+		if err := b.drinkPotion(ctx, r.Potion); err != nil && !errors.Is(err, errNoPotion) {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextToLava reports whether lava touches pos
+func (w *worldModel) nextToLava(pos blockPos) bool {
+	for _, d := range []blockPos{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}} {
+		state, ok := w.blockAt(blockPos{X: pos.X + d.X, Y: pos.Y + d.Y, Z: pos.Z + d.Z})
+		if ok && isLava(state) {
+			return true
+		}
+	}
+	return false
+}
+
+// drinkPotion drinks a potion of the given type, or its long or strong kind, from the inventory
+// and waits for its effect, then takes the item held before back in hand
+func (b *Bot) drinkPotion(ctx context.Context, potion string) error {
+	potion = strings.TrimPrefix(potion, "minecraft:")
+	b.stateMu.RLock()
+	held := b.heldSlot
+	b.stateMu.RUnlock()
+	defer b.selectHotbarSlot(held)
+
+	ok, err := b.holdMatching(func(slot int, item string) bool {
+		d, _ := b.itemDetailsAt(slot)
+		return item == potionItem && strings.TrimPrefix(strings.TrimPrefix(d.Potion, "long_"), "strong_") ==
+			strings.TrimPrefix(strings.TrimPrefix(potion, "long_"), "strong_")
+	}, potionSlot)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", errNoPotion, potion)
+	}
+
+	b.log.Printf("🧪 Drinking a potion of %s", potion)
+	if err := b.sendUseItem(); err != nil {
+		return fmt.Errorf("failed to drink: %w", err)
+	}
+	if err := sleepCtx(ctx, drinkTicks*tickDuration); err != nil {
+		return err
+	}
+	effect := potionEffect(potion)
+	if strings.HasPrefix(effect, "instant_") {
+		return nil // Over as soon as it is drunk
+	}
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	deadline := time.After(drinkEffectWait)
+	for {
+		if _, ok := b.effect(effect); ok {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("no %s effect after drinking", effect)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleDrinkCommand drinks a potion from the inventory, like !drink fire_resistance
+func (b *Bot) handleDrinkCommand(msg string) {
+	m := drinkCommand.FindStringSubmatch(msg)
+	if m == nil || potionEffect(m[1]) == "" {
+		b.reply(msg, "Usage: !drink <potion>, like !drink fire_resistance")
+		return
+	}
+	potion := strings.ToLower(m[1])
+	b.enqueueTask("drink "+potion, func(ctx context.Context) error {
+		if err := b.drinkPotion(ctx, potion); err != nil {
+			b.reply(msg, fmt.Sprintf("Failed to drink: %v", err))
+			return err
+		}
+		b.reply(msg, "Drank a potion of "+potion)
+		return nil
+	})
+}
@@ -0,0 +1,343 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	elytraItem          = "minecraft:elytra"
+	rocketItem          = "minecraft:firework_rocket"
+	chestSlot           = 6           // Inventory slot of the chestplate
+	flightSlot          = 7           // Hotbar slot the elytra and rockets are held in
+	startFallFlying     = 8           // Player command action deploying the elytra
+	elytraMinDurability = 10          // Durability left below which the bot won't take off
+	glideRatio          = 6.0         // Blocks an elytra glides forward per block of height, with margin
+	glidePitch          = 8.0         // Degrees below the horizon of the flattest glide
+	climbPitch          = -30.0       // Degrees above the horizon the bot climbs at behind a rocket
+	rocketTicks         = 25          // Ticks a firework rocket of flight duration 1 pushes the flyer
+	landingDistance     = 12.0        // Horizontal blocks from the target where the bot dives to land
+	maxFlightTicks      = 20 * 60 * 5 // Five minutes in the air at most
+	maxLaunchPillar     = 32          // Most blocks the bot pillars up to glide without rockets
+	flyTaskName         = "fly"
+)
+
+// flyCommand matches "!fly <x> <y> <z>"
+var flyCommand = regexp.MustCompile(`(?i)!fly\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+var (
+	errNoElytra = errors.New("no usable elytra") // The inventory has no elytra left to fly with
+	errTookOff  = errors.New("took off")         // Ends the take-off jump once the elytra is out
+)
+
+// lookVector returns the direction of a yaw and pitch in degrees
+func lookVector(yaw, pitch float64) velocity {
+	y, p := yaw*math.Pi/180, pitch*math.Pi/180
+	return velocity{X: -math.Sin(y) * math.Cos(p), Y: -math.Sin(p), Z: math.Cos(y) * math.Cos(p)}
+}
+
+// glide applies one tick of vanilla elytra flight to v for a flyer looking along yaw and pitch,
+// boosted by a firework rocket when boost is set
+func glide(v *velocity, yaw, pitch float64, boost bool) {
+	look := lookVector(yaw, pitch)
+	rad := pitch * math.Pi / 180
+	horizontal := math.Hypot(look.X, look.Z)
+	speed := math.Hypot(v.X, v.Z)
+	lift := math.Cos(rad) * math.Cos(rad)
+
+	v.Y += gravity * (-1 + lift*0.75)
+	if v.Y < 0 && horizontal > 0 {
+		// Falling turns into forward speed
+		a := v.Y * -0.1 * lift
+		v.X += look.X * a / horizontal
+		v.Y += a
+		v.Z += look.Z * a / horizontal
+	}
+	if rad < 0 && horizontal > 0 {
+		// Pulling up trades forward speed for height
+		a := speed * -math.Sin(rad) * 0.04
+		v.X -= look.X * a / horizontal
+		v.Y += a * 3.2
+		v.Z -= look.Z * a / horizontal
+	}
+	if horizontal > 0 {
+		v.X += (look.X/horizontal*speed - v.X) * 0.1
+		v.Z += (look.Z/horizontal*speed - v.Z) * 0.1
+	}
+	v.X, v.Y, v.Z = v.X*0.99, v.Y*0.98, v.Z*0.99
+
+	if boost {
+		v.X += look.X*0.1 + (look.X*1.5-v.X)*0.5
+		v.Y += look.Y*0.1 + (look.Y*1.5-v.Y)*0.5
+		v.Z += look.Z*0.1 + (look.Z*1.5-v.Z)*0.5
+	}
+}
+
+// equipElytra puts an elytra on unless the bot wears one, by using it from the hotbar like a
+// player does. The chestplate it replaces goes to the hotbar.
+func (b *Bot) equipElytra(ctx context.Context) error {
+	if s := b.screens.Inventory.Slots[chestSlot]; s.Count > 0 && itemName(int32(s.ID)) == elytraItem {
+		if d, ok := b.itemDetailsAt(chestSlot); ok && d.MaxDamage-d.Damage < elytraMinDurability {
+			return fmt.Errorf("%w, the one worn has %d durability left", errNoElytra, d.MaxDamage-d.Damage)
+		}
+		return nil
+	}
+	ok, err := b.holdItem([]string{elytraItem}, flightSlot)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNoElytra
+	}
+	if d, ok := b.itemDetailsAt(hotbarStart + flightSlot); ok && d.MaxDamage-d.Damage < elytraMinDurability {
+		return fmt.Errorf("%w, the one carried has %d durability left", errNoElytra, d.MaxDamage-d.Damage)
+	}
+	if err := b.sendUseItem(); err != nil {
+		return fmt.Errorf("failed to put the elytra on: %w", err)
+	}
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	for range 20 {
+		if s := b.screens.Inventory.Slots[chestSlot]; s.Count > 0 && itemName(int32(s.ID)) == elytraItem {
+			b.log.Println("🪽 Put the elytra on")
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.New("the server didn't equip the elytra")
+}
+
+// sendUseItem right-clicks the air with the item in the main hand
+func (b *Bot) sendUseItem() error {
+	b.stateMu.RLock()
+	yaw, pitch := b.yaw, b.pitch
+	b.stateMu.RUnlock()
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundUseItem,
+		pk.VarInt(0), // Main hand
+		pk.VarInt(b.sequence.Add(1)),
+		pk.Float(yaw),
+		pk.Float(pitch),
+	))
+}
+
+// sendStartFlying deploys the worn elytra, the bot must be in the air
+func (b *Bot) sendStartFlying() error {
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundPlayerCommand,
+		pk.VarInt(b.player.EID),
+		pk.VarInt(startFallFlying),
+		pk.VarInt(0), // Jump boost, for horses only
+	))
+}
+
+// fireRocket uses a firework rocket from the flight slot. It reports false when none is left.
+func (b *Bot) fireRocket(ctx context.Context) (bool, error) {
+	ok, err := b.holdItem([]string{rocketItem}, flightSlot)
+	if !ok || err != nil {
+		return false, err
+	}
+	if err := b.budget.spend(ctx, actionInteract); err != nil {
+		return false, err
+	}
+	return true, b.sendUseItem()
+}
+
+// flyTo flies with an elytra to the block at goal: it jumps, deploys the elytra at the top of
+// the jump and glides there, boosting with firework rockets when it runs low. Without rockets it
+// first pillars up high enough to glide the whole way.
+func (b *Bot) flyTo(ctx context.Context, goal blockPos) error {
+	if err := b.equipElytra(ctx); err != nil {
+		return err
+	}
+	start := b.feetBlock()
+	flat := math.Hypot(float64(goal.X-start.X), float64(goal.Z-start.Z))
+	rockets := b.inventoryCounts()[rocketItem]
+	if need := goal.Y + int(math.Ceil(flat/glideRatio)); rockets == 0 && need > start.Y {
+		if climb := need - start.Y; climb > maxLaunchPillar || b.scaffoldingCount() < climb {
+			return fmt.Errorf("no rockets, and gliding there needs a launch %d blocks higher", climb)
+		}
+		if err := b.pillarUp(ctx, need); err != nil {
+			return fmt.Errorf("failed to climb to the launch height: %w", err)
+		}
+	}
+	if !b.canJump() {
+		return errors.New("no room to jump for the take-off")
+	}
+
+	// Deploy the elytra at the top of the jump and leave the jump there
+	err := b.jump(ctx, func() (bool, error) {
+		if err := b.sendStartFlying(); err != nil {
+			return true, err
+		}
+		return true, errTookOff
+	})
+	if err == nil {
+		return errors.New("jumped too low to deploy the elytra")
+	}
+	if !errors.Is(err, errTookOff) {
+		return err
+	}
+	b.log.Printf("🪽 Taking off for (%d, %d, %d), %.0f blocks away with %d rocket(s)", goal.X, goal.Y, goal.Z, flat, rockets)
+	return b.glideTo(ctx, goal)
+}
+
+// glideTo flies the deployed elytra to goal until the bot lands
+func (b *Bot) glideTo(ctx context.Context, goal blockPos) error {
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+
+	x, y, z := b.currentPosition()
+	var v velocity
+	boost := 0
+	tx, tz := float64(goal.X)+0.5, float64(goal.Z)+0.5
+	for range maxFlightTicks {
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+		dx, dz := tx-x, tz-z
+		flat := math.Hypot(dx, dz)
+		height := y - float64(goal.Y)
+		yaw, _ := lookAngles(x, y, z, tx, y, tz)
+
+		// Aim the glide at the target, diving once close and climbing behind a rocket when low
+		pitch := math.Max(glidePitch, math.Atan2(height, flat)*180/math.Pi)
+		switch {
+		case flat < landingDistance:
+			pitch = math.Min(math.Max(pitch, 20), 60)
+		case height < flat/glideRatio && boost == 0:
+			fired, err := b.fireRocket(ctx)
+			if err != nil {
+				return err
+			}
+			if fired {
+				boost = rocketTicks
+			}
+		}
+		if boost > 0 && height < flat/glideRatio {
+			pitch = climbPitch
+		}
+		glide(&v, float64(yaw), pitch, boost > 0)
+		boost = max(boost-1, 0)
+
+		nx, ny, nz := x+v.X, y+v.Y, z+v.Z
+		feet := blockPos{X: int(math.Floor(nx)), Y: int(math.Floor(ny)), Z: int(math.Floor(nz))}
+		landed := false
+		switch {
+		case b.world.solid(feet) && v.Y < 0 && b.world.passable(blockPos{X: feet.X, Y: feet.Y + 1, Z: feet.Z}):
+			ny, landed = float64(feet.Y+1), true
+		case !b.world.passable(feet) || !b.world.passable(blockPos{X: feet.X, Y: feet.Y + 1, Z: feet.Z}):
+			// Flew into a wall, drop from where the bot was
+			b.log.Printf("💥 Hit a wall at (%d, %d, %d) while flying", feet.X, feet.Y, feet.Z)
+			if err := b.dropToGround(ctx, x, y, z); err != nil {
+				return err
+			}
+			return nil
+		}
+		if err := b.sendPositionRotation(nx, ny, nz, yaw, float32(pitch), landed); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.setPosition(nx, ny, nz, yaw, float32(pitch))
+		x, y, z = nx, ny, nz
+		if landed {
+			b.log.Printf("🛬 Landed at (%d, %d, %d), %.0f blocks from the target", feet.X, feet.Y+1, feet.Z, flat)
+			return nil
+		}
+
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("still in the air after %s", time.Duration(maxFlightTicks)*tickDuration)
+}
+
+// dropToGround lets the bot fall straight down from x, y, z until it lands
+func (b *Bot) dropToGround(ctx context.Context, x, y, z float64) error {
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	v := &velocity{}
+	for range maxSettleTicks * 10 {
+		var onGround bool
+		x, y, z, onGround = b.world.step(x, y, z, v)
+		if err := b.sendPosition(x, y, z, onGround); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.stateMu.RLock()
+		yaw, pitch := b.yaw, b.pitch
+		b.stateMu.RUnlock()
+		b.setPosition(x, y, z, yaw, pitch)
+		if onGround {
+			return nil
+		}
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.New("fell for too long")
+}
+
+// queueFly queues flying to pos and walking the rest of the way, reporting to player
+func (b *Bot) queueFly(pos blockPos, player string) *task {
+	spec := &taskSpec{Kind: flyTaskName, Pos: &pos, Player: player}
+	name := fmt.Sprintf("%s %d %d %d", flyTaskName, pos.X, pos.Y, pos.Z)
+	return b.enqueueResumableTask(name, exposureSurface, spec, func(ctx context.Context) error {
+		err := b.flyTo(ctx, pos)
+		if err == nil && distance(b.feetBlock(), pos) > 1 {
+			err = b.navigate(ctx, pos, b.mobAwareness(b.cfg.AvoidMobs), nil)
+		}
+		switch {
+		case err == nil:
+			b.replyTo(flyTaskName, player, fmt.Sprintf("Flew to (%d, %d, %d)", pos.X, pos.Y, pos.Z))
+		case !errors.Is(err, context.Canceled):
+			b.replyTo(flyTaskName, player, fmt.Sprintf("Can't fly to (%d, %d, %d): %v", pos.X, pos.Y, pos.Z, err))
+		}
+		return err
+	})
+}
+
+// handleFlyCommand queues flying to the coordinates of "!fly <x> <y> <z>"
+func (b *Bot) handleFlyCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := flyCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !fly <x> <y> <z>")
+		return
+	}
+	var pos blockPos
+	for i, v := range []*int{&pos.X, &pos.Y, &pos.Z} {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			b.reply(msg, fmt.Sprintf("%s is not a coordinate", m[i+1]))
+			return
+		}
+		*v = n
+	}
+	if err := b.validateGoal(pos); err != nil {
+		b.reply(msg, fmt.Sprintf("Can't fly there: %v", err))
+		return
+	}
+
+	b.queueFly(pos, senderOf(msg))
+	b.reply(msg, fmt.Sprintf("Flying to (%d, %d, %d), %.0f blocks away", pos.X, pos.Y, pos.Z, distance(b.feetBlock(), pos)))
+}
@@ -0,0 +1,303 @@
+package miner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/bot/playerlist"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Entity data of names and owners
+const (
+	customNameIndex = 2  // Optional custom name of every entity
+	tamedFlagsIndex = 17 // Flags of tameable animals and horses
+)
+
+const (
+	playerNearbyRadius = 16.0 // Players coming this close to the bot are reported
+	playerNearbyMargin = 8.0  // Blocks further a reported player has to go before being reported again
+)
+
+// tamedFlags is the flag marking a tamed animal, by the animals that can be tamed
+var tamedFlags = map[string]byte{
+	"wolf": 0x04, "cat": 0x04, "parrot": 0x04,
+	"horse": 0x02, "donkey": 0x02, "mule": 0x02, "llama": 0x02, "trader_llama": 0x02,
+	"camel": 0x02, "skeleton_horse": 0x02, "zombie_horse": 0x02,
+}
+
+// trackedEntity is an entity the server told us about
+type trackedEntity struct {
+	ID      int32
+	UUID    pk.UUID
+	Type    int32
+	X, Y, Z float64
+	Named   bool // Has a custom name, like a name-tagged mob
+	Tamed   bool
+}
+
+// pet reports whether the entity belongs to someone: it was named or tamed
+func (e trackedEntity) pet() bool {
+	return e.Named || e.Tamed
+}
+
+// entityTracker follows the position of the entities around the bot and the names of online players.
+// The packet loop writes to it while tasks read from it.
+type entityTracker struct {
+	mu       sync.RWMutex
+	entities map[int32]*trackedEntity
+	names    map[pk.UUID]string // Player names from the tab list
+	near     map[int32]bool     // Players reported near the bot that didn't leave since
+}
+
+func newEntityTracker() *entityTracker {
+	return &entityTracker{
+		entities: make(map[int32]*trackedEntity),
+		names:    make(map[pk.UUID]string),
+		near:     make(map[int32]bool),
+	}
+}
+
+// registerEntityHandlers makes the entity tracker follow spawn, move and player list packets
+func (b *Bot) registerEntityHandlers() {
+	b.players = playerlist.New(b.client)
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onEntitiesReset},
+		bot.PacketHandler{ID: packetid.ClientboundRespawn, F: b.onEntitiesReset},
+		bot.PacketHandler{ID: packetid.ClientboundAddEntity, F: b.onAddEntity},
+		bot.PacketHandler{ID: packetid.ClientboundRemoveEntities, F: b.onRemoveEntities},
+		bot.PacketHandler{ID: packetid.ClientboundMoveEntityPos, F: b.onMoveEntity},
+		bot.PacketHandler{ID: packetid.ClientboundMoveEntityPosRot, F: b.onMoveEntity},
+		bot.PacketHandler{ID: packetid.ClientboundTeleportEntity, F: b.onTeleportEntity},
+		bot.PacketHandler{ID: packetid.ClientboundSetEntityData, F: b.onEntityData},
+		// The player list handlers run first (priority 64), copy the names they parsed
+		bot.PacketHandler{ID: packetid.ClientboundPlayerInfoUpdate, F: b.onPlayerInfo},
+		bot.PacketHandler{ID: packetid.ClientboundPlayerInfoRemove, F: b.onPlayerInfo},
+	)
+}
+
+// onEntitiesReset forgets all entities when joining or changing dimension
+func (b *Bot) onEntitiesReset(pk.Packet) error {
+	b.entities.mu.Lock()
+	defer b.entities.mu.Unlock()
+	b.entities.entities = make(map[int32]*trackedEntity)
+	b.entities.near = make(map[int32]bool)
+	return nil
+}
+
+// onAddEntity starts tracking a spawned entity
+func (b *Bot) onAddEntity(p pk.Packet) error {
+	var (
+		id      pk.VarInt
+		uuid    pk.UUID
+		typ     pk.VarInt
+		x, y, z pk.Double
+	)
+	if err := p.Scan(&id, &uuid, &typ, &x, &y, &z); err != nil {
+		return err
+	}
+
+	b.entities.mu.Lock()
+	b.entities.entities[int32(id)] = &trackedEntity{ID: int32(id), UUID: uuid, Type: int32(typ), X: float64(x), Y: float64(y), Z: float64(z)}
+	b.entities.mu.Unlock()
+	b.checkPlayerNearby(int32(id))
+	return nil
+}
+
+// onRemoveEntities stops tracking despawned entities
+func (b *Bot) onRemoveEntities(p pk.Packet) error {
+	var ids []pk.VarInt
+	if err := p.Scan(pk.Array(&ids)); err != nil {
+		return err
+	}
+
+	b.entities.mu.Lock()
+	defer b.entities.mu.Unlock()
+	for _, id := range ids {
+		delete(b.entities.entities, int32(id))
+		delete(b.entities.near, int32(id))
+	}
+	return nil
+}
+
+// onEntityData follows whether entities were given a custom name or tamed
+func (b *Bot) onEntityData(p pk.Packet) error {
+	r := bytes.NewReader(p.Data)
+	var id pk.VarInt
+	if _, err := id.ReadFrom(r); err != nil {
+		return err
+	}
+	b.entities.mu.Lock()
+	defer b.entities.mu.Unlock()
+	e, ok := b.entities.entities[int32(id)]
+	if !ok {
+		return nil
+	}
+	tamedFlag := tamedFlags[entityName(e.Type)]
+	readEntityData(r, func(index uint8, typ int32, r io.Reader) (bool, error) {
+		var (
+			set   pk.Boolean
+			flags pk.Byte
+			raw   nbt.RawMessage
+		)
+		switch {
+		case index == customNameIndex && typ == metaOptionalText:
+			if _, err := set.ReadFrom(r); err != nil || !set {
+				e.Named = false
+				return true, err
+			}
+			_, err := pk.NBT(&raw).ReadFrom(r)
+			e.Named = err == nil
+			return true, err
+		case index == tamedFlagsIndex && typ == metaByte && tamedFlag != 0:
+			_, err := flags.ReadFrom(r)
+			e.Tamed = byte(flags)&tamedFlag != 0
+			return true, err
+		}
+		return false, nil
+	})
+	return nil
+}
+
+// onMoveEntity applies a relative entity move, deltas are in 1/4096 of a block
+func (b *Bot) onMoveEntity(p pk.Packet) error {
+	var (
+		id         pk.VarInt
+		dx, dy, dz pk.Short
+	)
+	if err := p.Scan(&id, &dx, &dy, &dz); err != nil {
+		return err
+	}
+
+	b.entities.mu.Lock()
+	if e, ok := b.entities.entities[int32(id)]; ok {
+		e.X += float64(dx) / 4096
+		e.Y += float64(dy) / 4096
+		e.Z += float64(dz) / 4096
+	}
+	b.entities.mu.Unlock()
+	b.checkPlayerNearby(int32(id))
+	return nil
+}
+
+// onTeleportEntity sets the absolute position of an entity that moved too far for a relative move
+func (b *Bot) onTeleportEntity(p pk.Packet) error {
+	var (
+		id      pk.VarInt
+		x, y, z pk.Double
+	)
+	if err := p.Scan(&id, &x, &y, &z); err != nil {
+		return err
+	}
+
+	b.entities.mu.Lock()
+	if e, ok := b.entities.entities[int32(id)]; ok {
+		e.X, e.Y, e.Z = float64(x), float64(y), float64(z)
+	}
+	b.entities.mu.Unlock()
+	b.checkPlayerNearby(int32(id))
+	return nil
+}
+
+// checkPlayerNearby emits a player_nearby event when a player entity came within
+// playerNearbyRadius of the bot. The bots of the swarm are left out.
+func (b *Bot) checkPlayerNearby(id int32) {
+	e, ok := b.entities.entity(id)
+	if !ok || entityName(e.Type) != "player" {
+		return
+	}
+	x, y, z := b.currentPosition()
+	dist := math.Hypot(math.Hypot(e.X-x, e.Y-y), e.Z-z)
+
+	t := b.entities
+	t.mu.Lock()
+	arrived := !t.near[id] && dist <= playerNearbyRadius
+	if arrived {
+		t.near[id] = true
+	} else if dist > playerNearbyRadius+playerNearbyMargin {
+		delete(t.near, id)
+	}
+	t.mu.Unlock()
+	if !arrived {
+		return
+	}
+
+	name := t.playerName(e.UUID)
+	if name != "" && b.swarm != nil && b.swarm.has(name) {
+		return
+	}
+	pos := blockPos{X: int(math.Floor(e.X)), Y: int(math.Floor(e.Y)), Z: int(math.Floor(e.Z))}
+	who := name
+	if who == "" {
+		who = fmt.Sprintf("An unknown player (entity %d)", id)
+	}
+	b.log.Printf("👀 %s came within %.0f blocks, at (%d, %d, %d)", who, dist, pos.X, pos.Y, pos.Z)
+	b.events.emit(eventPlayerNearby, PlayerNearby{Player: name, Pos: BlockPos(pos), Distance: dist})
+}
+
+// onPlayerInfo copies the player names out of the player list.
+// It runs in the packet loop, the only place the player list may be read.
+func (b *Bot) onPlayerInfo(pk.Packet) error {
+	names := make(map[pk.UUID]string, len(b.players.PlayerInfos))
+	for id, info := range b.players.PlayerInfos {
+		names[pk.UUID(id)] = info.Name
+	}
+	b.noteOwnerPresence(names)
+	b.copyPlayerList()
+
+	b.entities.mu.Lock()
+	defer b.entities.mu.Unlock()
+	b.entities.names = names
+	return nil
+}
+
+// playerPosition returns the position of the named player if their entity is in view
+func (t *entityTracker) playerPosition(name string) (x, y, z float64, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, e := range t.entities {
+		if strings.EqualFold(t.names[e.UUID], name) {
+			return e.X, e.Y, e.Z, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// playerName returns the name of an online player by UUID
+func (t *entityTracker) playerName(id pk.UUID) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.names[id]
+}
+
+// nearest returns the closest entity within radius of a point that matches the filter
+func (t *entityTracker) nearest(x, y, z, radius float64, match func(trackedEntity) bool) (found trackedEntity, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	best := radius * radius
+	for _, e := range t.entities {
+		dx, dy, dz := e.X-x, e.Y-y, e.Z-z
+		if d := dx*dx + dy*dy + dz*dz; d <= best && match(*e) {
+			found, ok, best = *e, true, d
+		}
+	}
+	return found, ok
+}
+
+// entity returns the current state of an entity by ID
+func (t *entityTracker) entity(id int32) (trackedEntity, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, ok := t.entities[id]
+	if !ok {
+		return trackedEntity{}, false
+	}
+	return *e, true
+}
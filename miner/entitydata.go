@@ -0,0 +1,122 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const entityDataEnd = 0xff // Index that ends the entity data
+
+// Entity data serializer types of protocol 768 this bot can skip over
+const (
+	metaByte = iota
+	metaVarInt
+	metaVarLong
+	metaFloat
+	metaString
+	metaText
+	metaOptionalText
+	metaItem
+	metaBoolean
+	metaRotations
+	metaPosition
+	metaOptionalPosition
+	metaDirection
+	metaOptionalUUID
+	metaBlockState
+	metaOptionalBlockState
+	metaNBT
+	metaParticle
+	metaParticles
+	metaVillagerData
+	metaOptionalVarInt
+	metaPose
+)
+
+// errUnreadableData stops reading entity data at a value the bot can't skip
+var errUnreadableData = errors.New("unsupported entity data type")
+
+// skipEntityData reads over one entity data value
+func skipEntityData(r io.Reader, typ int32) error {
+	var (
+		v    pk.VarInt
+		l    pk.VarLong
+		f    pk.Float
+		s    pk.String
+		set  pk.Boolean
+		pos  pk.Position
+		uuid pk.UUID
+		raw  nbt.RawMessage
+	)
+	var err error
+	switch typ {
+	case metaByte:
+		_, err = new(pk.Byte).ReadFrom(r)
+	case metaVarInt, metaDirection, metaBlockState, metaOptionalBlockState, metaOptionalVarInt, metaPose:
+		_, err = v.ReadFrom(r)
+	case metaVarLong:
+		_, err = l.ReadFrom(r)
+	case metaFloat:
+		_, err = f.ReadFrom(r)
+	case metaString:
+		_, err = s.ReadFrom(r)
+	case metaText, metaNBT:
+		_, err = pk.NBT(&raw).ReadFrom(r)
+	case metaOptionalText:
+		if _, err = set.ReadFrom(r); err == nil && set {
+			_, err = pk.NBT(&raw).ReadFrom(r)
+		}
+	case metaItem:
+		_, _, err = readItemDetails(r)
+	case metaBoolean:
+		_, err = set.ReadFrom(r)
+	case metaRotations:
+		_, err = (pk.Tuple{&f, &f, &f}).ReadFrom(r)
+	case metaPosition:
+		_, err = pos.ReadFrom(r)
+	case metaOptionalPosition:
+		if _, err = set.ReadFrom(r); err == nil && set {
+			_, err = pos.ReadFrom(r)
+		}
+	case metaOptionalUUID:
+		if _, err = set.ReadFrom(r); err == nil && set {
+			_, err = uuid.ReadFrom(r)
+		}
+	case metaVillagerData:
+		_, err = (pk.Tuple{&v, &v, &v}).ReadFrom(r)
+	default:
+		return fmt.Errorf("%w %d", errUnreadableData, typ)
+	}
+	return err
+}
+
+// readEntityData reads the entries of entity data, which lists the changed values in index
+// order. read gets each entry and reports whether it read the value, the others are skipped.
+// Reading stops at the end, at an error or at a value the bot can't skip.
+func readEntityData(r io.Reader, read func(index uint8, typ int32, r io.Reader) (bool, error)) error {
+	for {
+		var (
+			index pk.UnsignedByte
+			typ   pk.VarInt
+		)
+		if _, err := index.ReadFrom(r); err != nil || index == entityDataEnd {
+			return err
+		}
+		if _, err := typ.ReadFrom(r); err != nil {
+			return err
+		}
+		done, err := read(uint8(index), int32(typ), r)
+		if err != nil {
+			return err
+		}
+		if !done {
+			if err := skipEntityData(r, int32(typ)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package miner
+
+import (
+	"sync"
+	"time"
+)
+
+// eventHistorySize is the number of events kept for the HTTP API
+const eventHistorySize = 100
+
+// eventSubscriberBuffer is how many events a slow subscriber may lag behind before events are dropped
+const eventSubscriberBuffer = 64
+
+// Event types emitted by the bot
+const (
+	eventInventoryChanged = "inventory_changed"
+	eventBlockMined       = "block_mined"
+	eventChatReceived     = "chat_received"
+	eventHealthChanged    = "health_changed"
+	eventTaskStarted      = "task_started"
+	eventTaskFinished     = "task_finished"
+	eventRouteInefficient = "route_inefficient"
+	eventReply            = "reply" // Every answer to a command, whichever way it went out in game
+	eventDimensionChanged = "dimension_changed"
+	eventServerTelemetry  = "server_telemetry" // Fields read from the player list, when one changed
+	eventAdvancement      = "advancement"      // An advancement the bot just earned
+	eventTraderSpotted    = "trader_spotted"   // A wandering trader or unemployed villager came near
+	eventTradeOffers      = "trade_offers"     // Offers of a villager or wandering trader the bot inspected
+	eventRaidWarning      = "raid_warning"     // Pillagers or a raid near the bot working on the surface
+	eventTeleportRequest  = "teleport_request" // A tpa request the bot answered
+	eventItemsSold        = "items_sold"       // A sale the economy plugin confirmed
+	eventOwnerChanged     = "owner_changed"    // The backup owner took over, or the owner came back
+	eventRetired          = "retired"          // The bot left its server for good, its owner gone too long
+	eventBossBar          = "boss_bar"         // A boss bar was shown, retitled or removed
+	eventTitle            = "title"            // A new title, subtitle or action bar message
+	eventDangerHeard      = "danger_heard"     // A creeper hissed or TNT was lit close to the bot, which runs
+	eventPlayerActivity   = "player_activity"  // Blocks broken or placed near the bot by someone else
+	eventDamaged          = "damaged"          // What hurt the bot: fire, lava, a fall, a mob or a player
+	eventRemark           = "remark"           // Something the bot said in chat about a milestone
+	eventRuleFired        = "rule_fired"       // A rule of the rules file went off
+	eventTotemPopped      = "totem_popped"     // A totem of undying saved the bot
+	eventMapRendered      = "map_rendered"     // A map the bot holds was written to a PNG file
+	eventToolBroke        = "tool_broke"       // The tool the bot mines with wore out
+	eventPlayerNearby     = "player_nearby"    // Another player came close to the bot
+)
+
+// BlockMined is the data of block_mined events: a block the bot broke
+type BlockMined struct {
+	BlockPos
+	Block string `json:"block,omitempty"` // Empty when the bot didn't know the block
+}
+
+// ToolBroke is the data of tool_broke events
+type ToolBroke struct {
+	Item string `json:"item,omitempty"`
+	Slot int    `json:"slot"` // Inventory slot the tool was in
+}
+
+// PlayerNearby is the data of player_nearby events: a player that came within
+// playerNearbyRadius of the bot, reported again only after leaving that distance
+type PlayerNearby struct {
+	Player   string   `json:"player"` // Empty when the player isn't in the tab list
+	Pos      BlockPos `json:"pos"`
+	Distance float64  `json:"distance"`
+}
+
+// TaskFinished is the data of task_finished events: how a task ended
+type TaskFinished struct {
+	Task        TaskInfo   `json:"task"`
+	Status      string     `json:"status"` // finished, cancelled or failed
+	Finished    *time.Time `json:"finished,omitempty"`
+	BlocksMined int        `json:"blocks_mined"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// TaskInfo is a task of the queue, the data of task_started events
+type TaskInfo struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Created     time.Time  `json:"created"`
+	Started     *time.Time `json:"started,omitempty"`
+	Exposure    string     `json:"exposure,omitempty"` // surface or underground, empty for either
+	Constraints []string   `json:"constraints,omitempty"`
+}
+
+// Event is something that happened to a bot, as the event stream sends it
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Data any       `json:"data,omitempty"` // BlockMined, ToolBroke, PlayerNearby, TaskInfo, TaskFinished or the JSON of the event stream
+}
+
+// exportEvent returns an event with its data as the public types give it
+func exportEvent(e botEvent) Event {
+	return Event{Time: e.Time, Type: e.Type, Data: exportEventData(e.Data)}
+}
+
+// exportEventData returns the data of an event as a public type, for the data kept in
+// internal types
+func exportEventData(data any) any {
+	switch d := data.(type) {
+	case taskInfo:
+		return TaskInfo(d)
+	case taskResult:
+		return TaskFinished{Task: TaskInfo(d.Task), Status: d.Status, Finished: d.Finished, BlocksMined: d.BlocksMined, Error: d.Error}
+	}
+	return data
+}
+
+// botEvent is a structured notification about something that happened in game
+type botEvent struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Data any       `json:"data,omitempty"`
+}
+
+// eventHub distributes the events of one bot
+type eventHub struct {
+	mu          sync.Mutex
+	history     []botEvent
+	handlers    []func(botEvent)
+	subscribers map[chan botEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan botEvent]struct{})}
+}
+
+// subscribe registers a handler called for every emitted event
+func (h *eventHub) subscribe(handler func(botEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers = append(h.handlers, handler)
+}
+
+// subscribeTo registers a handler called with the data of every emitted event carrying a T,
+// so subscribers of typed events neither check event types nor assert their data
+func subscribeTo[T any](h *eventHub, handler func(T)) {
+	h.subscribe(func(e botEvent) {
+		if data, ok := e.Data.(T); ok {
+			handler(data)
+		}
+	})
+}
+
+// emit records an event and passes it to the registered handlers
+func (h *eventHub) emit(typ string, data any) {
+	e := botEvent{Time: time.Now(), Type: typ, Data: data}
+
+	h.mu.Lock()
+	h.history = append(h.history, e)
+	if len(h.history) > eventHistorySize {
+		h.history = h.history[len(h.history)-eventHistorySize:]
+	}
+	handlers := append([]func(botEvent){}, h.handlers...)
+	for ch := range h.subscribers {
+		select {
+		case ch <- e:
+		default: // Never block the game loop on a slow subscriber
+		}
+	}
+	h.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
+
+// stream returns a channel receiving every emitted event
+// and a function that ends the subscription
+func (h *eventHub) stream() (<-chan botEvent, func()) {
+	ch := make(chan botEvent, eventSubscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+}
+
+// recent returns a copy of the recent events, oldest first
+func (h *eventHub) recent() []botEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]botEvent{}, h.history...)
+}
@@ -0,0 +1,180 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Tnze/go-mc/level"
+)
+
+const (
+	exploreTaskName   = "explore"
+	exploreMaxRadius  = 256              // Widest exploration, its chunks are all kept in memory
+	exploreStep       = 64               // Blocks between the stops of an exploration, covered by a view distance of 2
+	exploreReach      = 24               // Blocks from a stop that count as having reached it
+	exploreLegTimeout = 3 * time.Minute  // Longest the bot tries to reach one stop
+	exploreReport     = 30 * time.Second // Time between progress reports
+)
+
+// exploreCommand matches "!explore <radius>"
+var exploreCommand = regexp.MustCompile(`(?i)!explore\s+(\d+)`)
+
+// handleExploreCommand queues walking a spiral around the bot for "!explore <radius>"
+func (b *Bot) handleExploreCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := exploreCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !explore <radius>")
+		return
+	}
+	radius, err := strconv.Atoi(m[1])
+	if err != nil || radius < exploreStep || radius > exploreMaxRadius {
+		b.reply(msg, fmt.Sprintf("The radius must be between %d and %d", exploreStep, exploreMaxRadius))
+		return
+	}
+	center := b.feetBlock()
+	b.queueExplore(center, radius, senderOf(msg))
+	b.reply(msg, fmt.Sprintf("Exploring %d blocks around (%d, %d)", radius, center.X, center.Z))
+}
+
+// queueExplore queues exploring the chunks within radius of center and reports the outcome
+// to player
+func (b *Bot) queueExplore(center blockPos, radius int, player string) *task {
+	spec := &taskSpec{Kind: exploreTaskName, Pos: &center, Radius: float64(radius), Player: player}
+	name := fmt.Sprintf("%s %d %d", exploreTaskName, center.X, center.Z)
+	return b.enqueueResumableTask(name, exposureSurface, spec, func(ctx context.Context) error {
+		err := b.explore(ctx, center, radius, player)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			b.replyTo(exploreTaskName, player, fmt.Sprintf("Stopped exploring: %v", err))
+		}
+		return err
+	})
+}
+
+// exploreSpiral returns the stops of a square spiral from center out to radius, exploreStep
+// apart
+func exploreSpiral(center blockPos, radius int) []blockPos {
+	stops := []blockPos{center}
+	for ring := 1; ring*exploreStep <= radius; ring++ {
+		// Clockwise from the north-west corner, each side ending where the next starts
+		for side := range 4 {
+			for i := range 2 * ring {
+				dx, dz := -ring+i, -ring
+				switch side {
+				case 1:
+					dx, dz = ring, -ring+i
+				case 2:
+					dx, dz = ring-i, ring
+				case 3:
+					dx, dz = -ring, ring-i
+				}
+				stops = append(stops, blockPos{X: center.X + dx*exploreStep, Y: center.Y, Z: center.Z + dz*exploreStep})
+			}
+		}
+	}
+	return stops
+}
+
+// explore walks to every stop of a spiral around center whose chunk the world model doesn't
+// have yet, keeping the chunks that load meanwhile after the server unloads them. Stops the
+// bot can't reach are skipped.
+func (b *Bot) explore(ctx context.Context, center blockPos, radius int, player string) error {
+	b.world.keepChunks(true)
+	defer b.world.keepChunks(false)
+
+	stops := exploreSpiral(center, radius)
+	reached, skipped := 0, 0
+	lastReport := time.Now()
+	for i, stop := range stops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if b.world.hasChunk(stop) {
+			continue
+		}
+		stop.Y = b.feetBlock().Y // The terrain there is unknown yet, keep to the bot's height
+		if err := b.reachStop(ctx, stop); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			b.log.Printf("🧭 Skipping exploration stop (%d, %d): %v", stop.X, stop.Z, err)
+			skipped++
+		} else {
+			reached++
+		}
+		if time.Since(lastReport) >= exploreReport {
+			lastReport = time.Now()
+			b.replyTo(exploreTaskName, player, fmt.Sprintf("Explored %d of %d stops, %d chunks known", i+1, len(stops), b.world.loadedChunks()))
+		}
+	}
+
+	chunks := b.world.loadedChunks()
+	text := fmt.Sprintf("Explored %d blocks around (%d, %d): %d chunks known", radius, center.X, center.Z, chunks)
+	if skipped > 0 {
+		text += fmt.Sprintf(", %d stops out of reach", skipped)
+	}
+	if err := b.saveWorldCache(); err != nil {
+		b.log.Printf("⚠️ Failed to save the explored chunks: %v", err)
+	}
+	b.log.Printf("🧭 %s (%d stops walked to)", text, reached)
+	b.replyTo(exploreTaskName, player, text)
+	return nil
+}
+
+// reachStop walks towards an exploration stop until the bot is within exploreReach of it
+func (b *Bot) reachStop(ctx context.Context, stop blockPos) error {
+	ctx, cancel := context.WithTimeout(ctx, exploreLegTimeout)
+	defer cancel()
+	go func() {
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+				if pos := b.feetBlock(); horizontalDistance(pos, stop) <= exploreReach {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	err := b.navigate(ctx, stop, b.mobAwareness(0), nil)
+	if horizontalDistance(b.feetBlock(), stop) <= exploreReach {
+		return nil
+	}
+	return err
+}
+
+// horizontalDistance returns the distance between two blocks, ignoring height
+func horizontalDistance(a, b blockPos) float64 {
+	return distance(blockPos{X: a.X, Z: a.Z}, blockPos{X: b.X, Z: b.Z})
+}
+
+// hasChunk reports whether the world model has the chunk of a block
+func (w *worldModel) hasChunk(pos blockPos) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.columns[level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)}]
+	return ok
+}
+
+// keepChunks makes the chunks loaded now and those loading until keeping is turned off again
+// stay after the server unloads them
+func (w *worldModel) keepChunks(on bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keeping = on
+	if on {
+		for pos := range w.columns {
+			w.kept[pos] = true
+		}
+	}
+}
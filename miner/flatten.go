@@ -0,0 +1,158 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	flattenTaskName  = "flatten"
+	flattenMaxArea   = 64 * 64 // Most columns one !flatten clears
+	flattenMaxHeight = 64      // Blocks above the target height that are cleared
+	flattenMaxDepth  = 8       // Deepest hole below the target height that is filled
+)
+
+// flattenCommand matches "!flatten x1 z1 x2 z2 <y> [fill]"
+var flattenCommand = regexp.MustCompile(`(?i)!flatten\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)(?:\s+(fill))?`)
+
+// flattenFill are the blocks holes are filled with, the first the inventory has
+var flattenFill = []string{"minecraft:dirt", "minecraft:cobblestone"}
+
+// handleFlattenCommand queues clearing the rectangle of "!flatten x1 z1 x2 z2 <y> [fill]"
+// down to height y
+func (b *Bot) handleFlattenCommand(msg string) {
+	m := flattenCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !flatten x1 z1 x2 z2 <y> [fill]")
+		return
+	}
+	var v [5]int
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	y := v[4]
+	region := regionFrom([6]int{v[0], y, v[1], v[2], y, v[3]})
+	if area := (region.Max.X - region.Min.X + 1) * (region.Max.Z - region.Min.Z + 1); area > flattenMaxArea {
+		b.reply(msg, fmt.Sprintf("That's %d columns, the most I flatten at once is %d", area, flattenMaxArea))
+		return
+	}
+	fill := m[6] != ""
+
+	b.queueFlatten(region, fill, senderOf(msg))
+	text := fmt.Sprintf("Flattening x %d..%d, z %d..%d down to y=%d", region.Min.X, region.Max.X, region.Min.Z, region.Max.Z, y)
+	if fill {
+		text += ", filling holes"
+	}
+	b.reply(msg, text)
+}
+
+// queueFlatten queues flattening a region, whose Min.Y is the height it is cleared down to,
+// and reports the outcome to player
+func (b *Bot) queueFlatten(region quarryRegion, fill bool, player string) *task {
+	spec := &taskSpec{Kind: flattenTaskName, Region: &region, Fill: fill, Player: player}
+	return b.enqueueResumableTask(flattenTaskName, exposureSurface, spec, func(ctx context.Context) error {
+		err := b.flatten(ctx, region, fill, player)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			b.replyTo(flattenTaskName, player, fmt.Sprintf("Stopped flattening: %v", err))
+		}
+		return err
+	})
+}
+
+// flatten mines every block above the height of the region, top layer first, then fills the
+// holes below that height up to it when fill is set
+func (b *Bot) flatten(ctx context.Context, region quarryRegion, fill bool, player string) error {
+	y := region.Min.Y
+	top := b.highestBlock(region, y+flattenMaxHeight)
+	mined := 0
+	for layer := top; layer > y; layer-- {
+		line := snakeLayer(region.Min.X, region.Max.X, region.Min.Z, region.Max.Z, layer)
+		n, err := b.mineLine(ctx, line, func(pos blockPos) bool { return !b.quarryable(pos) })
+		mined += n
+		if err != nil {
+			return err
+		}
+	}
+
+	filled := 0
+	if fill {
+		var err error
+		if filled, err = b.fillHoles(ctx, region, player); err != nil {
+			return err
+		}
+	}
+	text := fmt.Sprintf("Flattened down to y=%d: %d blocks mined", y, mined)
+	if fill {
+		text += fmt.Sprintf(", %d placed", filled)
+	}
+	b.log.Printf("🚜 %s", text)
+	b.replyTo(flattenTaskName, player, text)
+	return nil
+}
+
+// highestBlock returns the height of the highest block above the region in the loaded world,
+// up to limit. It is the region's height when nothing is above it.
+func (b *Bot) highestBlock(region quarryRegion, limit int) int {
+	top := region.Min.Y
+	for x := region.Min.X; x <= region.Max.X; x++ {
+		for z := region.Min.Z; z <= region.Max.Z; z++ {
+			for y := limit; y > top; y-- {
+				if state, ok := b.world.blockAt(blockPos{X: x, Y: y, Z: z}); ok && !block.IsAir(state) {
+					top = y
+					break
+				}
+			}
+		}
+	}
+	return top
+}
+
+// fillHoles fills every column of the region whose block at the region's height is missing,
+// from the bottom of the hole up, with blocks of flattenFill. Holes deeper than
+// flattenMaxDepth are only filled that deep.
+func (b *Bot) fillHoles(ctx context.Context, region quarryRegion, player string) (int, error) {
+	y := region.Min.Y
+	b.stateMu.RLock()
+	held := b.heldSlot
+	b.stateMu.RUnlock()
+	defer b.selectHotbarSlot(held)
+
+	filled := 0
+	for _, surface := range snakeLayer(region.Min.X, region.Max.X, region.Min.Z, region.Max.Z, y) {
+		if !b.world.replaceable(surface) {
+			continue
+		}
+		bottom := surface
+		for bottom.Y > y-flattenMaxDepth && b.world.replaceable(blockPos{X: bottom.X, Y: bottom.Y - 1, Z: bottom.Z}) {
+			bottom.Y--
+		}
+		for pos := bottom; pos.Y <= y; pos.Y++ {
+			item := ""
+			counts := b.inventoryCounts()
+			for _, name := range flattenFill {
+				if counts[name] > 0 {
+					item = name
+					break
+				}
+			}
+			if item == "" {
+				b.replyTo(flattenTaskName, player, fmt.Sprintf("Out of dirt and cobblestone after filling %d blocks, bring me more and flatten again", filled))
+				return filled, errors.New("nothing left to fill holes with")
+			}
+			if err := b.placeBlock(ctx, pos, item); err != nil {
+				if ctx.Err() != nil {
+					return filled, ctx.Err()
+				}
+				b.log.Printf("⚠️ Can't fill (%d, %d, %d): %v", pos.X, pos.Y, pos.Z, err)
+				break
+			}
+			filled++
+		}
+	}
+	return filled, nil
+}
@@ -0,0 +1,142 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	followDistance = 3.0                    // Blocks the bot keeps from the followed player
+	followReplan   = 2.0                    // Blocks the player may move before the path is planned again
+	followPoll     = 500 * time.Millisecond // How often an idle follower checks the player position
+	followTaskName = "follow"
+)
+
+// followCommand matches "!follow <player>" with an optional "avoid <blocks>" distance from hostile mobs
+var followCommand = regexp.MustCompile(`(?i)!follow\s+(\w{3,16})(?:\s+avoid\s+(\d+))?`)
+
+// follow keeps the bot within followDistance of the named player until ctx is cancelled
+func (b *Bot) follow(ctx context.Context, name string, avoidRadius float64) error {
+	ticker := time.NewTicker(followPoll)
+	defer ticker.Stop()
+
+	visible, searched := true, false
+	var lastSeen blockPos
+	var lostAt time.Time
+	for {
+		px, py, pz, ok := b.entities.playerPosition(name)
+		if ok != visible {
+			if ok {
+				b.log.Printf("👀 %s is back in view", name)
+			} else {
+				b.log.Printf("👀 Lost sight of %s, waiting for them to come back", name)
+				lostAt = time.Now()
+			}
+			visible, searched = ok, false
+		}
+
+		if ok {
+			goal := blockPos{X: int(math.Floor(px)), Y: int(math.Floor(py)), Z: int(math.Floor(pz))}
+			lastSeen = goal
+			if err := b.approachPlayer(ctx, name, goal, avoidRadius); err != nil && !errors.Is(err, errNoPath) {
+				return err
+			}
+		} else if !searched && time.Since(lostAt) > lostPlayerWait {
+			// Look around where they were last seen once, then keep waiting
+			searched = true
+			err := b.spiralSearch(ctx, lastSeen, searchMaxRadius, func() bool {
+				_, _, _, ok := b.entities.playerPosition(name)
+				return ok
+			})
+			if errors.Is(err, errNotFound) {
+				b.log.Printf("👀 %s is nowhere near (%d, %d, %d)", name, lastSeen.X, lastSeen.Y, lastSeen.Z)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// approachPlayer walks towards the player standing at goal, giving up on the path
+// as soon as the player moved far enough for it to be outdated
+func (b *Bot) approachPlayer(ctx context.Context, name string, goal blockPos, avoidRadius float64) error {
+	path, err := b.world.findPath(b.feetBlock(), goal, followDistance, b.mobAwareness(avoidRadius))
+	if err != nil {
+		b.log.Printf("🧭 No path to %s at (%d, %d, %d): %v", name, goal.X, goal.Y, goal.Z, err)
+		return err
+	}
+	if len(path) == 0 {
+		return nil
+	}
+
+	return b.followPath(ctx, path, func() bool {
+		x, y, z, ok := b.entities.playerPosition(name)
+		if !ok {
+			return true
+		}
+		dx, dy, dz := x-(float64(goal.X)+0.5), y-float64(goal.Y), z-(float64(goal.Z)+0.5)
+		return math.Sqrt(dx*dx+dy*dy+dz*dz) > followReplan
+	})
+}
+
+// handleFollowCommand starts following the player named in "!follow <player> [avoid <blocks>]"
+func (b *Bot) handleFollowCommand(msg string) {
+	m := followCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !follow <player> [avoid <blocks>]")
+		return
+	}
+	name, avoid := m[1], b.cfg.AvoidMobs
+	if strings.EqualFold(name, b.cfg.Username) {
+		return
+	}
+	if m[2] != "" {
+		n, _ := strconv.Atoi(m[2])
+		avoid = float64(n)
+	}
+
+	b.stopMode()
+	b.queueFollow(name, avoid)
+	b.reply(msg, fmt.Sprintf("Following %s, say !stay to stop", name))
+}
+
+// queueFollow queues following a player, keeping avoid blocks from hostile mobs
+func (b *Bot) queueFollow(name string, avoid float64) *task {
+	spec := &taskSpec{Kind: followTaskName, Player: name, Avoid: avoid}
+	return b.enqueueResumableTask(fmt.Sprintf("%s %s", followTaskName, name), exposureAny, spec, func(ctx context.Context) error {
+		return b.follow(ctx, name, avoid)
+	})
+}
+
+// handleStayCommand stops following or guarding
+func (b *Bot) handleStayCommand(msg string) {
+	if b.stopMode() {
+		b.reply(msg, "Staying here")
+	}
+}
+
+// stopMode cancels a running follow or guard task and reports whether there was one
+func (b *Bot) stopMode() bool {
+	current, _ := b.tasks.snapshot()
+	if current == nil {
+		return false
+	}
+	for _, mode := range []string{followTaskName, guardTaskName} {
+		if strings.HasPrefix(current.Name, mode+" ") {
+			return b.tasks.cancelCurrent()
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package miner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	forgeAuto = "auto" // forge_handshake choosing the handshake after the ping
+
+	channelLoginWrapper   = "fml:loginwrapper" // Login queries of Forge before 1.20.2, wrapping a message of another channel
+	channelFMLHandshake   = "fml:handshake"    // The messages of the FML handshake
+	channelForgeHandshake = "forge:handshake"  // The same since 1.20.2, in the configuration
+
+	fmlModList      = 1  // The server's mods, channels and registries
+	fmlModListReply = 2  // The client's
+	fmlAcknowledge  = 99 // The client took any other message
+)
+
+// forgeMarkers is what each handshake of forge_handshake appends to the server address of the
+// handshake, telling Forge servers the client speaks it
+var forgeMarkers = map[string]string{
+	"fml2":  "\x00FML2\x00",
+	"fml3":  "\x00FML3\x00",
+	"forge": "\x00FORGE",
+}
+
+// forgeData is what Forge adds to the server list ping: its network version and the mods and
+// channels of the server. Forge 1.19 and newer packs the lists into Packed instead when they
+// are long, in a binary form the bot doesn't read.
+type forgeData struct {
+	NetworkVersion int `json:"fmlNetworkVersion"`
+	Mods           []struct {
+		ID      string `json:"modId"`
+		Version string `json:"modmarker"`
+	} `json:"mods"`
+	Channels []struct {
+		Name     string `json:"res"`
+		Version  string `json:"version"`
+		Required bool   `json:"required"`
+	} `json:"channels"`
+	Truncated bool   `json:"truncated"`
+	Packed    string `json:"d"`
+}
+
+// String describes the loader and its mods for logs, like "Forge (network version 3, 12 mods)"
+func (f *forgeData) String() string {
+	switch {
+	case f.Packed != "":
+		return fmt.Sprintf("Forge (network version %d, mod list packed)", f.NetworkVersion)
+	case f.Truncated:
+		return fmt.Sprintf("Forge (network version %d, %d+ mods)", f.NetworkVersion, len(f.Mods))
+	}
+	return fmt.Sprintf("Forge (network version %d, %d mods)", f.NetworkVersion, len(f.Mods))
+}
+
+// handshake returns the forge_handshake of the network version of the server
+func (f *forgeData) handshake() string {
+	switch {
+	case f.NetworkVersion <= 2:
+		return "fml2"
+	case f.NetworkVersion == 3:
+		return "fml3"
+	}
+	return "forge"
+}
+
+// requiredChannels returns the channels the server only lets clients in with, as name@version
+func (f *forgeData) requiredChannels() []string {
+	var names []string
+	for _, c := range f.Channels {
+		if c.Required {
+			names = append(names, strings.TrimSuffix(c.Name+"@"+c.Version, "@"))
+		}
+	}
+	return names
+}
+
+// forgeMarker returns the marker the handshake of the bot carries for a server whose ping has
+// the Forge data f, nil when it has none, or empty to join as a vanilla client
+func (b *Bot) forgeMarker(f *forgeData) string {
+	switch h := b.cfg.ForgeHandshake; {
+	case h != forgeAuto:
+		return forgeMarkers[h]
+	case f != nil:
+		return forgeMarkers[f.handshake()]
+	}
+	return ""
+}
+
+// registerForgeHandlers answers the FML handshake of the login, which Forge servers before
+// 1.20.2 start with the clients whose handshake carries their marker
+func (b *Bot) registerForgeHandlers() {
+	b.client.LoginPlugin[channelLoginWrapper] = b.onLoginWrapper
+}
+
+// onLoginWrapper answers a login query of Forge, a message of another channel wrapped
+func (b *Bot) onLoginWrapper(data []byte) ([]byte, error) {
+	var (
+		channel pk.Identifier
+		msg     pk.ByteArray
+	)
+	if _, err := (pk.Tuple{&channel, &msg}).ReadFrom(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse FML login query: %w", err)
+	}
+	var reply []byte
+	if channel == channelFMLHandshake {
+		var err error
+		if reply, err = b.fmlReply(msg); err != nil {
+			return nil, err
+		}
+	} else {
+		b.log.Printf("⚠️ Can't answer the FML login query on %s, the mod behind it may refuse the bot", channel)
+	}
+	var buf bytes.Buffer
+	if _, err := (pk.Tuple{channel, pk.ByteArray(reply)}).WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// forgeReply returns the answer to a custom payload of the FML handshake in the configuration,
+// which Forge servers send since 1.20.2, false for other payloads
+func (b *Bot) forgeReply(p pk.Packet) (pk.Packet, bool) {
+	var (
+		channel pk.Identifier
+		data    pk.PluginMessageData
+	)
+	if err := p.Scan(&channel, &data); err != nil || (channel != channelFMLHandshake && channel != channelForgeHandshake) {
+		return pk.Packet{}, false
+	}
+	reply, err := b.fmlReply(data)
+	if err != nil {
+		b.log.Printf("⚠️ Failed to answer the FML handshake: %v", err)
+		return pk.Packet{}, false
+	}
+	return pk.Marshal(packetid.ServerboundConfigCustomPayload, channel, pk.PluginMessageData(reply)), true
+}
+
+// fmlReply answers a message of the FML handshake: the mod list of the server with the same
+// mods, channels and registries, which the bot claims to have, and the rest with an
+// acknowledgement
+func (b *Bot) fmlReply(msg []byte) ([]byte, error) {
+	r := bytes.NewReader(msg)
+	var kind pk.VarInt
+	if _, err := kind.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("failed to parse FML message: %w", err)
+	}
+	var reply bytes.Buffer
+	if kind != fmlModList {
+		pk.VarInt(fmlAcknowledge).WriteTo(&reply)
+		return reply.Bytes(), nil
+	}
+
+	var (
+		mods       []pk.String
+		channels   []fmlEntry
+		registries []pk.Identifier
+	)
+	if _, err := (pk.Tuple{pk.Array(&mods), pk.Array(&channels), pk.Array(&registries)}).ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("failed to parse FML mod list: %w", err)
+	}
+	markers := make([]fmlEntry, len(registries))
+	for i, name := range registries {
+		markers[i].Name = name // The marker is left empty, like a client without mappings
+	}
+	b.log.Printf("🧩 Claiming the %d mods and %d channels of the server in the FML handshake", len(mods), len(channels))
+	if _, err := (pk.Tuple{pk.VarInt(fmlModListReply), pk.Array(mods), pk.Array(channels), pk.Array(markers)}).WriteTo(&reply); err != nil {
+		return nil, err
+	}
+	return reply.Bytes(), nil
+}
+
+// fmlEntry is a channel of the FML mod list with its version, or a registry with its marker
+type fmlEntry struct {
+	Name    pk.Identifier
+	Version pk.String
+}
+
+func (e *fmlEntry) ReadFrom(r io.Reader) (int64, error) {
+	return pk.Tuple{&e.Name, &e.Version}.ReadFrom(r)
+}
+
+func (e fmlEntry) WriteTo(w io.Writer) (int64, error) {
+	return pk.Tuple{e.Name, e.Version}.WriteTo(w)
+}
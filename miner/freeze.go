@@ -0,0 +1,173 @@
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	freezeDefault = 30 * time.Second // Hands-off time when none is given
+	freezeMax     = 10 * time.Minute
+)
+
+// handsOffCommand matches "!handsoff" with an optional number of seconds
+var handsOffCommand = regexp.MustCompile(`(?i)!handsoff(?:\s+(\d+))?`)
+
+// freezeGate pauses every bot action while a human works in the same area
+type freezeGate struct {
+	mu    sync.Mutex
+	until time.Time
+	thaw  chan struct{} // Closed and replaced when the freeze ends early
+}
+
+// freezeState is the serializable view of the freeze gate
+type freezeState struct {
+	Frozen bool      `json:"frozen"`
+	Until  time.Time `json:"until,omitzero"`
+}
+
+// freezeFor pauses all actions for d, extending a running freeze if that ends sooner
+func (b *Bot) freezeFor(d time.Duration) time.Time {
+	f := &b.freeze
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if until := time.Now().Add(d); until.After(f.until) {
+		f.until = until
+	}
+	b.log.Printf("🧊 Hands off until %s", f.until.Format(time.TimeOnly))
+	return f.until
+}
+
+// unfreeze ends a freeze early and reports whether one was running
+func (b *Bot) unfreeze() bool {
+	f := &b.freeze
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !time.Now().Before(f.until) {
+		return false
+	}
+	f.until = time.Time{}
+	if f.thaw != nil {
+		close(f.thaw)
+		f.thaw = nil
+	}
+	b.log.Println("▶️ Resuming")
+	return true
+}
+
+// frozen returns the freeze state
+func (b *Bot) frozen() freezeState {
+	f := &b.freeze
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !time.Now().Before(f.until) {
+		return freezeState{}
+	}
+	return freezeState{Frozen: true, Until: f.until}
+}
+
+// waitThaw blocks while the bot is frozen. Every action loop calls it before acting.
+func (b *Bot) waitThaw(ctx context.Context) error {
+	for {
+		f := &b.freeze
+		f.mu.Lock()
+		wait := time.Until(f.until)
+		if wait <= 0 {
+			f.mu.Unlock()
+			return nil
+		}
+		if f.thaw == nil {
+			f.thaw = make(chan struct{})
+		}
+		thaw := f.thaw
+		f.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-thaw:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		timer.Stop()
+	}
+}
+
+// handleHandsOffCommand freezes the bot from "!handsoff [seconds]"
+func (b *Bot) handleHandsOffCommand(msg string) {
+	d := freezeDefault
+	if m := handsOffCommand.FindStringSubmatch(msg); m != nil && m[1] != "" {
+		s, _ := strconv.Atoi(m[1])
+		d = min(time.Duration(s)*time.Second, freezeMax)
+	}
+	until := b.freezeFor(d)
+	b.reply(msg, fmt.Sprintf("Hands off until %s, say !resume to continue earlier", until.Format(time.TimeOnly)))
+}
+
+// handleResumeCommand ends a freeze early
+func (b *Bot) handleResumeCommand(msg string) {
+	if b.unfreeze() {
+		b.reply(msg, "Resuming")
+	}
+}
+
+// fromOwner reports whether a chat line was written by the configured owner, or the backup
+// owner standing in for them. Without an owner nobody may use the owner commands, with an
+// owner of ownerEveryone everyone may.
+func (b *Bot) fromOwner(msg string) bool {
+	switch b.cfg.Owner {
+	case "":
+		return false
+	case ownerEveryone:
+		return true
+	}
+	return strings.EqualFold(senderOf(msg), b.actingOwner())
+}
+
+// warnOwner logs at startup who may use the owner commands when that isn't a single player
+func (b *Bot) warnOwner() {
+	switch b.cfg.Owner {
+	case "":
+		b.log.Printf("⚠️ No owner set, owner commands like !handsoff are refused. Set owner to a player, or to %q to let everyone use them", ownerEveryone)
+	case ownerEveryone:
+		b.log.Printf("⚠️ owner is %q, everyone may use the owner commands like !handsoff", ownerEveryone)
+	}
+}
+
+// handleFreezeRequest freezes the bot for {"seconds": n}, or the default without a body
+func (b *Bot) handleFreezeRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Seconds int `json:"seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+	d := freezeDefault
+	if req.Seconds > 0 {
+		d = min(time.Duration(req.Seconds)*time.Second, freezeMax)
+	}
+
+	b.log.Printf("🌐 Freezing for %s from API", d)
+	b.freezeFor(d)
+	writeJSON(w, http.StatusOK, b.frozen())
+}
+
+// handleUnfreezeRequest ends a freeze early
+func (b *Bot) handleUnfreezeRequest(w http.ResponseWriter, r *http.Request) {
+	if !b.unfreeze() {
+		writeError(w, http.StatusNotFound, errors.New("not frozen"))
+		return
+	}
+	writeJSON(w, http.StatusOK, b.frozen())
+}
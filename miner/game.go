@@ -0,0 +1,497 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	version         = registry.Version  // Minecraft Java Edition version
+	protocolVersion = registry.Protocol // Its protocol
+	serverAddr      = "100.94.216.120:25565"
+	username        = "MINER"
+
+	// Timing constants
+	worldLoadDelay  = 2 * time.Second        // Wait time for world to load after joining
+	spawnTimeout    = 30 * time.Second       // Longest wait for the server to place the bot after joining
+	basicMiningTime = 1 * time.Second        // Time to mine a block with bare hands
+	itemMiningTime  = 500 * time.Millisecond // Time to mine a block with a tool
+	tickDuration    = 50 * time.Millisecond  // Minecraft tick duration (20 ticks per second)
+	miningTickCount = 40                     // Ticks to mine a block whose break time is unknown (40 ticks = 2 seconds)
+	miningLogTicks  = 20                     // Ticks between mining progress logs
+
+	// Minecraft protocol position encoding constants
+	// Position is encoded as: X (26 bits) << 38 | Z (26 bits) << 12 | Y (12 bits)
+	positionXZMask = 0x3FFFFFF // 26-bit mask for X and Z coordinates
+	positionYMask  = 0xFFF     // 12-bit mask for Y coordinate
+)
+
+// onGameStart is called when the player joins the game
+func (b *Bot) onGameStart() error {
+	b.log.Println("🎮 Game started! Bot is now in the game.")
+
+	// Keep the cookies set while joining for the next login
+	b.saveCookies()
+
+	// Check the first packets decode as expected once they had time to arrive
+	time.AfterFunc(driftReportDelay, b.reportDrift)
+
+	if !b.minedFirst {
+		b.minedFirst = true
+		go b.startFirstTask()
+	}
+	return nil
+}
+
+// startFirstTask picks up the task left unfinished by the last run, or mines the cobblestone
+// block directly in front, once the server placed the bot and the world had time to load.
+// Tasks started before the first teleport would work from the origin.
+func (b *Bot) startFirstTask() {
+	select {
+	case <-b.spawned:
+	case <-time.After(spawnTimeout):
+		b.log.Printf("⚠️ The server didn't place the bot within %s, starting anyway", spawnTimeout)
+	}
+	time.Sleep(worldLoadDelay)
+
+	if !b.resumeTask() {
+		b.enqueueTask("mine block in front", b.mineBlockInFront)
+	}
+}
+
+// onDisconnect is called when disconnected from the server
+func (b *Bot) onDisconnect(reason chat.Message) error {
+	b.log.Printf("👋 Disconnected: %s", reason.String())
+	return nil
+}
+
+// onHealthChange handles health updates
+func (b *Bot) onHealthChange(health float32, food int32, foodSaturation float32) error {
+	b.log.Printf("❤️ Health: %.1f, Food: %d, Saturation: %.1f", health, food, foodSaturation)
+
+	b.stateMu.Lock()
+	b.health = health
+	b.food = food
+	b.stateMu.Unlock()
+
+	b.events.emit(eventHealthChanged, healthState{Health: health, Food: food, Saturation: foodSaturation})
+	return nil
+}
+
+// onDeath is called when the player dies
+func (b *Bot) onDeath() error {
+	b.log.Println("💀 Player died!")
+	b.countDeath()
+	// A dig does not survive death, let the server drop it too
+	if err := b.CancelDig(); err != nil {
+		b.log.Printf("⚠️ Failed to cancel dig: %v", err)
+	}
+	// Go back for the dropped items once respawned
+	if len(b.inventoryCounts()) > 0 {
+		b.queueRecover(b.feetBlock())
+	}
+	// Respawn the player
+	return b.player.Respawn()
+}
+
+// onTeleported is called when the player is teleported
+func (b *Bot) onTeleported(x, y, z float64, yaw, pitch float32, flags byte, teleportID int32) error {
+	b.log.Printf("📍 Teleported to: X=%.2f, Y=%.2f, Z=%.2f, Yaw=%.2f, Pitch=%.2f", x, y, z, yaw, pitch)
+
+	// Update tracked position
+	b.stateMu.Lock()
+	b.x = x
+	b.y = y
+	b.z = z
+	b.yaw = yaw
+	b.pitch = pitch
+	b.stateMu.Unlock()
+	b.spawnOnce.Do(func() { close(b.spawned) })
+
+	// Confirm teleportation
+	return b.player.AcceptTeleportation(pk.VarInt(teleportID))
+}
+
+// handleChatPacket processes incoming chat messages
+func (b *Bot) handleChatPacket(p pk.Packet) error {
+	var msg chat.Message
+
+	// Try to decode the chat message
+	if err := p.Scan(&msg); err != nil {
+		return fmt.Errorf("failed to parse chat message: %w", err)
+	}
+
+	msgText := msg.String()
+	if b.chat.duplicate(msgText) {
+		return nil
+	}
+	msgText = b.canonicalSender(msgText)
+	line := parseChatLine(msgText)
+	b.log.Printf("💬 Chat message: %s", msgText)
+	b.events.emit(eventChatReceived, b.chat.record(msgText, line.Sender))
+	b.pluginChat(line)
+
+	// Only commands other players start their line with count, never the bot's own coming back
+	if line.Command == "" || strings.EqualFold(line.Sender, b.cfg.Username) || b.chat.echo(line.Text) {
+		return nil
+	}
+	if (ownerCommands[line.Command] || b.pluginOwnerCommand(line.Command)) && !b.fromOwner(msgText) {
+		b.auditChat(line, auditDenied)
+		return nil
+	}
+	if outcome, ok := b.admitVisitor(line); !ok {
+		b.log.Printf("🚷 Ignored !%s from %s (%s)", line.Command, line.Sender, outcome)
+		b.auditChat(line, outcome)
+		return nil
+	}
+	outcome := auditAccepted
+	if !b.runCommand(line.Command, msgText) {
+		outcome = auditUnknown
+	}
+	b.auditChat(line, outcome)
+
+	return nil
+}
+
+// runCommand hands the chat line msgText to the handler of command, reporting false for a
+// command the bot doesn't have
+func (b *Bot) runCommand(command, msgText string) bool {
+	switch command {
+	case "me":
+		b.log.Println("📥 Received !me command")
+		go b.handleMeCommand(msgText)
+	case "mineblock":
+		b.log.Println("📥 Received !mineblock command")
+		go b.handleMineBlockCommand(msgText)
+	case "mine":
+		b.log.Println("📥 Received !mine command")
+		go b.handleMineCommand(msgText)
+	case "stop":
+		b.log.Println("📥 Received !stop command")
+		go b.handleStopCommand(msgText)
+	case "dump":
+		b.log.Println("📥 Received !dump command")
+		go b.handleDumpCommand(msgText)
+	case "stats":
+		b.log.Println("📥 Received !stats command")
+		go b.handleStatsCommand(msgText)
+	case "status":
+		b.log.Println("📥 Received !status command")
+		go b.handleStatusCommand(msgText)
+	case "pos":
+		b.log.Println("📥 Received !pos command")
+		go b.handlePosCommand(msgText)
+	case "online":
+		b.log.Println("📥 Received !online command")
+		go b.handleOnlineCommand(msgText)
+	case "tps":
+		b.log.Println("📥 Received !tps command")
+		go b.handleTPSCommand(msgText)
+	case "balance":
+		b.log.Println("📥 Received !balance command")
+		go b.handleBalanceCommand(msgText)
+	case "sell":
+		b.log.Println("📥 Received !sell command")
+		go b.handleSellCommand(msgText)
+	case "drink":
+		b.log.Println("📥 Received !drink command")
+		go b.handleDrinkCommand(msgText)
+	case "follow":
+		b.log.Println("📥 Received !follow command")
+		go b.handleFollowCommand(msgText)
+	case "guard":
+		b.log.Println("📥 Received !guard command")
+		go b.handleGuardCommand(msgText)
+	case "trades":
+		b.log.Println("📥 Received !trades command")
+		go b.handleTradesCommand(msgText)
+	case "handsoff":
+		b.log.Println("📥 Received !handsoff command")
+		go b.handleHandsOffCommand(msgText)
+	case "resume":
+		b.log.Println("📥 Received !resume command")
+		go b.handleResumeCommand(msgText)
+	case "stay":
+		b.log.Println("📥 Received !stay command")
+		go b.handleStayCommand(msgText)
+	case "quarry":
+		b.log.Println("📥 Received !quarry command")
+		go b.handleQuarryCommand(msgText)
+	case "find":
+		b.log.Println("📥 Received !find command")
+		go b.handleFindCommand(msgText)
+	case "craft":
+		b.log.Println("📥 Received !craft command")
+		go b.handleCraftCommand(msgText)
+	case "giveback":
+		b.log.Println("📥 Received !giveback command")
+		go b.handleGivebackCommand(msgText)
+	case "setwp":
+		b.log.Println("📥 Received !setwp command")
+		go b.handleSetWpCommand(msgText)
+	case "delwp":
+		b.log.Println("📥 Received !delwp command")
+		go b.handleDelWpCommand(msgText)
+	case "listwp":
+		b.log.Println("📥 Received !listwp command")
+		go b.handleListWpCommand(msgText)
+	case "goto":
+		b.log.Println("📥 Received !goto command")
+		go b.handleGotoCommand(msgText)
+	case "fly":
+		b.log.Println("📥 Received !fly command")
+		go b.handleFlyCommand(msgText)
+	case "wp":
+		b.log.Println("📥 Received !wp command")
+		go b.handleWpCommand(msgText)
+	case "protect":
+		b.log.Println("📥 Received !protect command")
+		go b.handleProtectCommand(msgText)
+	case "scan":
+		b.log.Println("📥 Received !scan command")
+		go b.handleScanCommand(msgText)
+	case "contribute":
+		b.log.Println("📥 Received !contribute command")
+		go b.handleContributeCommand(msgText)
+	case "shares":
+		b.log.Println("📥 Received !shares command")
+		go b.handleSharesCommand(msgText)
+	case "claim":
+		b.log.Println("📥 Received !claim command")
+		go b.handleClaimCommand(msgText)
+	case "deliver":
+		b.log.Println("📥 Received !deliver command")
+		go b.handleDeliverCommand(msgText)
+	case "flatten":
+		b.log.Println("📥 Received !flatten command")
+		go b.handleFlattenCommand(msgText)
+	case "explore":
+		b.log.Println("📥 Received !explore command")
+		go b.handleExploreCommand(msgText)
+	case "render":
+		b.log.Println("📥 Received !render command")
+		go b.handleRenderCommand(msgText)
+	case "whobroke":
+		b.log.Println("📥 Received !whobroke command")
+		go b.handleWhoBrokeCommand(msgText)
+	case "build":
+		b.log.Println("📥 Received !build command")
+		go b.handleBuildCommand(msgText)
+	case "script":
+		b.log.Println("📥 Received !script command")
+		go b.handleScriptCommand(msgText)
+	default:
+		return b.runPluginCommand(command, msgText)
+	}
+	return true
+}
+
+// mineBlockInFront mines the cobblestone block directly in front of the bot
+func (b *Bot) mineBlockInFront(ctx context.Context) error {
+	b.log.Println("⛏️ Mining cobblestone block in front...")
+
+	// Use tracked player position (from teleported event)
+	// Calculate block position in front (1 block forward based on yaw)
+	// Assuming the bot is facing a specific direction, let's just mine the block at feet level + 0
+	b.stateMu.RLock()
+	blockX := int(math.Floor(b.x))
+	blockY := int(math.Floor(b.y))
+	blockZ := int(math.Floor(b.z + 1)) // Block in front
+	b.stateMu.RUnlock()
+
+	b.log.Printf("🎯 Attempting to mine block at position: (%d, %d, %d)", blockX, blockY, blockZ)
+
+	if err := b.budget.spend(ctx, actionBreak); err != nil {
+		return err
+	}
+
+	// Dig for as long as the server needs to accept the break
+	if err := b.digBlock(ctx, blockPos{X: blockX, Y: blockY, Z: blockZ}); err != nil {
+		return err
+	}
+
+	// Reduce durability if using an item
+	if b.miningItem >= 0 {
+		b.itemDurability -= 5
+		b.log.Printf("🔧 Item durability: %d", b.itemDurability)
+		if b.itemDurability <= 0 {
+			b.events.emit(eventToolBroke, ToolBroke{Item: b.heldTool(), Slot: int(b.miningItem)})
+			b.itemDurability = 100 // Reset for next item
+		}
+	}
+
+	b.events.emit(eventBlockMined, BlockMined{BlockPos: BlockPos{X: blockX, Y: blockY, Z: blockZ}})
+	b.log.Println("✓ Successfully mined the block!")
+	return nil
+}
+
+// sendDigging sends a player digging packet
+func (b *Bot) sendDigging(status int32, x, y, z int, face byte) error {
+	// Encode position as per Minecraft protocol
+	position := int64(x&positionXZMask)<<38 | int64(z&positionXZMask)<<12 | int64(y&positionYMask)
+
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundPlayerAction,
+		pk.VarInt(status),
+		pk.Long(position),
+		pk.Byte(face),
+		pk.VarInt(b.sequence.Add(1)), // Sequence, acknowledged by the server once processed
+	))
+}
+
+// sendArmSwing sends an arm swing animation packet
+func (b *Bot) sendArmSwing() error {
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundSwing,
+		pk.VarInt(0), // Main hand
+	))
+}
+
+// simulateMining simulates realistic mining for the given number of ticks, swinging the arm
+// every tick like the vanilla client does while breaking a block.
+// onTick, when set, runs after every tick with the number of ticks left.
+// It returns early with the context error if the task is cancelled, without swinging again.
+func (b *Bot) simulateMining(ctx context.Context, ticks int, onTick func(remaining int) error) error {
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+
+	b.miningTicks = 0
+	for b.miningTicks < ticks {
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+		err := b.sendArmSwing()
+		if err != nil {
+			b.log.Printf("⚠️ Error sending arm swing: %v", err)
+		}
+
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// Both may be ready at once, a cancelled dig must not count another tick
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.miningTicks++
+		if onTick != nil {
+			if err := onTick(ticks - b.miningTicks); err != nil {
+				return err
+			}
+		}
+
+		// Show progress every 20 ticks
+		if b.miningTicks%miningLogTicks == 0 {
+			b.log.Printf("⛏️ Mining progress: %d/%d ticks", b.miningTicks, ticks)
+		}
+	}
+	return nil
+}
+
+// handleMeCommand moves the bot to the player who issued the command
+func (b *Bot) handleMeCommand(msg string) {
+	b.log.Println("🏃 Executing !me command...")
+
+	b.reply(msg, "Moving to you!")
+
+	// Note: Full implementation would require:
+	// 1. Parse the sender's username from the chat message
+	// 2. Track other players' positions from spawn entity packets
+	// 3. Calculate path to player using pathfinding
+	// 4. Send player position packets to move
+	// 5. Look at player by calculating yaw/pitch
+
+	b.log.Println("✓ !me command acknowledged (requires player position tracking and pathfinding)")
+}
+
+// handleStopCommand gracefully stops the bot, leaving any other bots of the process running
+func (b *Bot) handleStopCommand(msg string) {
+	b.log.Println("🛑 Executing !stop command...")
+
+	b.reply(msg, "Goodbye!")
+
+	time.Sleep(1 * time.Second)
+
+	b.log.Println("👋 Bot stopped gracefully")
+	b.stop()
+}
+
+// sendChatMessage sends a chat message to the server
+func (b *Bot) sendChatMessage(message string) {
+	if b.client.Conn == nil {
+		b.log.Println("⚠️ Cannot send chat message: not connected")
+		return
+	}
+	b.chat.noteSent(message)
+
+	// The unsigned chat message of the protocol go-mc speaks, the codec of the server's
+	// protocol changes it for other releases
+	err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundChat,
+		pk.String(message),
+		pk.Long(time.Now().UnixMilli()), // Timestamp
+		pk.Long(0),                      // Salt
+		pk.Boolean(false),               // Has signature
+		pk.VarInt(0),                    // Message Count
+		pk.NewFixedBitSet(20),           // Acknowledged
+	))
+	if err != nil {
+		b.log.Printf("❌ Failed to send chat message: %v", err)
+	}
+}
+
+// mineWithItem mines a block using the current held item
+func (b *Bot) mineWithItem(ctx context.Context, x, y, z int) error {
+	b.log.Printf("⛏️ Mining block at (%d, %d, %d) with item...", x, y, z)
+
+	plan, err := b.planDig(blockPos{X: x, Y: y, Z: z})
+	if err != nil {
+		return err
+	}
+	return b.minePlanned(ctx, plan, nil)
+}
+
+// minePlanned breaks a planned block with the held item and wears the item down.
+// onTick is passed on to the dig.
+func (b *Bot) minePlanned(ctx context.Context, plan digPlan, onTick func(remaining int) error) error {
+	if err := b.budget.spend(ctx, actionBreak); err != nil {
+		return err
+	}
+
+	// Dig for as long as the server needs to accept the break
+	if err := b.digPlanned(ctx, plan, onTick); err != nil {
+		return err
+	}
+
+	b.events.emit(eventBlockMined, BlockMined{BlockPos: BlockPos(plan.Pos), Block: plan.Name})
+
+	// Reduce durability after mining (5 per 40 ticks)
+	b.itemDurability -= 5
+	b.log.Printf("🔧 Item durability: %d", b.itemDurability)
+
+	if b.itemDurability <= 0 {
+		b.events.emit(eventToolBroke, ToolBroke{Item: b.heldTool(), Slot: int(b.miningItem)})
+		b.itemDurability = 100 // Reset for next item
+		b.miningItem = -1      // No longer holding a mining item
+	}
+
+	b.log.Println("✓ Mining action completed")
+	return nil
+}
+
+// announceToolBroke tells the chat the mining tool broke
+func (b *Bot) announceToolBroke(ToolBroke) {
+	b.log.Println("💥 IT BROKEEEEE")
+	b.sendChatMessage("IT BROKEEEEE")
+}
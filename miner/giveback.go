@@ -0,0 +1,224 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+const (
+	givebackReach    = 2.0 // Blocks from the player the bot stops at, close enough for drops to land at their feet
+	clickModeThrow   = 4   // Container click mode of the drop key
+	throwOne         = 0   // Drop key button throwing a single item
+	throwStack       = 1   // Drop key button throwing the whole stack, as with control held
+	dropStackStatus  = 3   // Player action dropping the held stack
+	givebackTaskName = "give back"
+)
+
+// givebackCommand matches "!giveback" with an optional "all" to hand over the loot too
+var givebackCommand = regexp.MustCompile(`(?i)!giveback(?:\s+(all))?\b`)
+
+// toolLoan is a tool a player handed to the bot with !mine
+type toolLoan struct {
+	Lender string
+	Item   string
+	Before map[string]int // Inventory when the tool arrived, what was gained since is loot
+	Points float64        // Contribution the tool was credited with, 0 when the lender gets no share
+}
+
+// lendTool remembers who handed over the tool in a slot and credits them with it
+func (b *Bot) lendTool(lender string, slot int) {
+	loan := &toolLoan{Lender: lender, Item: b.toolSlots()[slot], Before: b.inventoryCounts()}
+	if lender != "" && b.mayContribute(lender) {
+		d, ok := b.itemDetailsAt(slot)
+		if !ok {
+			d = itemDetails{Item: loan.Item}
+		}
+		loan.Points = toolPoints(d)
+		b.credit(lender, loan.Points)
+	}
+	b.stateMu.Lock()
+	b.loan = loan
+	b.stateMu.Unlock()
+}
+
+// currentLoan returns the tool the bot borrowed, nil when it has none
+func (b *Bot) currentLoan() *toolLoan {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.loan
+}
+
+// lootSince returns the items gained since before, tools aside
+func (b *Bot) lootSince(before map[string]int) map[string]int {
+	loot := make(map[string]int)
+	for item, n := range b.inventoryCounts() {
+		if gained := n - before[item]; gained > 0 && !isTool(item) {
+			loot[item] = gained
+		}
+	}
+	return loot
+}
+
+// faceRequester walks up to a player and looks at their feet
+func (b *Bot) faceRequester(ctx context.Context, name string) error {
+	px, py, pz, ok := b.entities.playerPosition(name)
+	if !ok {
+		return fmt.Errorf("%s is not in view", name)
+	}
+	goal := blockPos{X: int(math.Floor(px)), Y: int(math.Floor(py)), Z: int(math.Floor(pz))}
+	err := b.walkPath(ctx, goal, givebackReach, b.mobAwareness(b.cfg.AvoidMobs))
+	if errors.Is(err, errNoPath) {
+		err = b.walkWithin(ctx, px, py, pz, givebackReach)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The player may have moved while the bot walked
+	if nx, ny, nz, ok := b.entities.playerPosition(name); ok {
+		px, py, pz = nx, ny, nz
+	}
+	x, y, z := b.currentPosition()
+	yaw, pitch := lookAngles(x, y+eyeHeight, z, px, py, pz)
+	if err := b.sendRotation(yaw, pitch, true); err != nil {
+		return fmt.Errorf("failed to turn to %s: %w", name, err)
+	}
+	b.setPosition(x, y, z, yaw, pitch)
+	return nil
+}
+
+// dropTool drops the stack in the tool's slot, taking it in hand first
+func (b *Bot) dropTool(slot int) error {
+	if err := b.equipTool(slot); err != nil {
+		return err
+	}
+	if err := b.sendDigging(dropStackStatus, 0, 0, 0, faceBottom); err != nil {
+		return fmt.Errorf("failed to drop the tool: %w", err)
+	}
+	b.applyClick(0, craft.Click{Changed: map[int]craft.Stack{int(b.miningItem): {}}})
+	b.setSlotDetails(int(b.miningItem), itemDetails{}, false)
+	b.miningItem = -1
+	b.itemDurability = 100
+	return nil
+}
+
+// throwItems drops count of an item from the inventory, whole stacks first
+func (b *Bot) throwItems(item string, count int) error {
+	for _, s := range b.inventorySnapshot() {
+		if count <= 0 {
+			return nil
+		}
+		if s.Item != item || s.Slot < inventoryStart || s.Slot >= offhandSlot {
+			continue
+		}
+		if s.Count <= count {
+			click := craft.Click{Slot: s.Slot, Button: throwStack, Mode: clickModeThrow, Changed: map[int]craft.Stack{s.Slot: {}}}
+			if err := b.sendClick(0, click); err != nil {
+				return err
+			}
+			count -= s.Count
+			continue
+		}
+		for left := s.Count; left > s.Count-count; left-- {
+			click := craft.Click{Slot: s.Slot, Button: throwOne, Mode: clickModeThrow,
+				Changed: map[int]craft.Stack{s.Slot: {Item: item, Count: left - 1}}}
+			if err := b.sendClick(0, click); err != nil {
+				return err
+			}
+		}
+		count = 0
+	}
+	return nil
+}
+
+// giveBack walks to the requester and drops the borrowed tool, or the held tool when nothing
+// was borrowed, at their feet. With loot it also drops what was mined since the tool arrived.
+func (b *Bot) giveBack(ctx context.Context, requester string, loot bool) error {
+	loan := b.currentLoan()
+	slot, tools := int(b.miningItem), b.toolSlots()
+	if loan != nil && tools[slot] != loan.Item {
+		// The borrowed tool was swapped out of the hand since
+		for s, item := range tools {
+			if item == loan.Item {
+				slot = s
+				break
+			}
+		}
+	}
+	tool := tools[slot]
+	if tool == "" {
+		b.replyTo("giveback", requester, "I'm not holding a tool to give back")
+		return nil
+	}
+
+	if err := b.faceRequester(ctx, requester); err != nil {
+		b.replyTo("giveback", requester, fmt.Sprintf("Can't get to you: %v", err))
+		return err
+	}
+	if loan != nil && loan.Points > 0 && tool == loan.Item {
+		// The lender keeps credit for the part of the tool that was used up
+		left := float64(b.expectedDurability(slot) / durabilityPerBlock)
+		b.credit(loan.Lender, -min(left, loan.Points))
+	}
+	if err := b.dropTool(slot); err != nil {
+		return err
+	}
+	reply := fmt.Sprintf("Here's your %s back", itemDetails{Item: tool}.displayName())
+
+	if loot {
+		var items map[string]int
+		if loan != nil {
+			items = b.lootSince(loan.Before)
+		} else {
+			// Without a loan to date it from, the ore is the loot
+			items = make(map[string]int)
+			for item, n := range b.inventoryCounts() {
+				if isOreItem(item) {
+					items[item] = n
+				}
+			}
+		}
+		total := 0
+		for item, n := range items {
+			if err := b.throwItems(item, n); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", item, err)
+			}
+			total += n
+		}
+		if total > 0 {
+			reply += fmt.Sprintf(" with %d item(s) of loot", total)
+		}
+	}
+
+	b.stateMu.Lock()
+	b.loan = nil
+	b.stateMu.Unlock()
+	b.replyTo("giveback", requester, reply)
+	return nil
+}
+
+// handleGivebackCommand queues returning the tool on "!giveback [all]". The owner and
+// the player who lent the tool may ask for it.
+func (b *Bot) handleGivebackCommand(msg string) {
+	sender := senderOf(msg)
+	if sender == "" || b.fromSelf(msg) {
+		return
+	}
+	loan := b.currentLoan()
+	if !b.fromOwner(msg) && (loan == nil || !strings.EqualFold(loan.Lender, sender)) {
+		return
+	}
+	m := givebackCommand.FindStringSubmatch(msg)
+	loot := m != nil && m[1] != ""
+
+	b.log.Printf("🤝 Giving the tool back to %s", sender)
+	b.enqueueTask(givebackTaskName, func(ctx context.Context) error {
+		return b.giveBack(ctx, sender, loot)
+	})
+}
@@ -0,0 +1,235 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	guardRadius        = 16.0 // Default distance from the guard point within which mobs are attacked
+	guardMaxRadius     = 48.0
+	guardRetreatHealth = 8  // Health at or below which the bot backs off (4 hearts)
+	guardResumeHealth  = 14 // Health the bot waits for before fighting again
+	guardRetreatDist   = 8  // Blocks the bot tries to put between itself and a mob when retreating
+	guardPoll          = 100 * time.Millisecond
+	guardTaskName      = "guard"
+)
+
+// guardCommand matches "!guard" with an optional radius
+var guardCommand = regexp.MustCompile(`(?i)!guard(?:\s+(\d+))?`)
+
+// guard attacks hostile mobs that come within radius of center until ctx is cancelled. Named
+// and tamed mobs are left alone.
+func (b *Bot) guard(ctx context.Context, center blockPos, radius float64) error {
+	ticker := time.NewTicker(guardPoll)
+	defer ticker.Stop()
+
+	weapon, err := b.selectWeapon()
+	if err != nil {
+		return err
+	}
+	b.log.Printf("🛡️ Guarding (%d, %d, %d) within %.0f blocks with %s", center.X, center.Y, center.Z, radius, toolLabel(weapon))
+	if _, err := b.equipTotem(); err != nil {
+		b.log.Printf("⚠️ %v", err)
+	}
+
+	defer func() {
+		if err := b.lowerShield(); err != nil {
+			b.log.Printf("⚠️ %v", err)
+		}
+	}()
+
+	var lastAttack time.Time
+	retreating := false
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+
+		// Land wherever a hit pushed the bot before doing anything else
+		if knocked, err := b.resolveKnockback(ctx); err != nil {
+			return err
+		} else if knocked {
+			continue
+		}
+
+		b.stateMu.RLock()
+		health := b.health
+		b.stateMu.RUnlock()
+
+		// Whatever just hit the bot comes first, even from outside the guarded area
+		mob, found := b.recentAttacker(radius)
+		if !found {
+			mob, found = b.entities.nearest(float64(center.X)+0.5, float64(center.Y), float64(center.Z)+0.5, radius,
+				func(e trackedEntity) bool { return isHostile(e.Type) && !e.pet() })
+		}
+
+		switch {
+		case health <= guardRetreatHealth && !retreating:
+			b.log.Printf("🏃 Health low (%.1f), retreating", health)
+			retreating = true
+			fallthrough
+		case retreating:
+			if health >= guardResumeHealth {
+				b.log.Printf("🛡️ Health back to %.1f, guarding again", health)
+				retreating = false
+				continue
+			}
+			if err := b.lowerShield(); err != nil {
+				return err
+			}
+			if found {
+				if err := b.retreatFrom(ctx, mob); err != nil && !errors.Is(err, errNoPath) {
+					return err
+				}
+			}
+
+		case !found:
+			// Nothing to fight, go back to the post
+			if err := b.lowerShield(); err != nil {
+				return err
+			}
+			if distance(b.feetBlock(), center) > 2 {
+				if err := b.walkPath(ctx, center, 1, b.mobAwareness(0)); err != nil && !errors.Is(err, errNoPath) {
+					return err
+				}
+			}
+
+		default:
+			if !b.inAttackReach(mob) {
+				if err := b.lowerShield(); err != nil {
+					return err
+				}
+				if err := b.chaseEntity(ctx, mob); err != nil && !errors.Is(err, errNoPath) {
+					return err
+				}
+				continue
+			}
+
+			if err := b.lookAtEntity(mob); err != nil {
+				return err
+			}
+			// Start jumping early enough to be falling, and land a critical hit, when the weapon is charged
+			wait := attackCooldown(weapon) - time.Since(lastAttack)
+			crit := b.canJump() && wait <= critLeadTicks*tickDuration
+			// Attacks before the cooldown is over deal only a fraction of the damage, block meanwhile
+			if wait > 0 && !crit {
+				if err := b.blockBetweenAttacks(wait); err != nil {
+					return err
+				}
+				continue
+			}
+			if !crit && b.petNear(mob) {
+				// A sweep from the ground would hit the pet too, only critical hits don't sweep
+				if err := b.blockBetweenAttacks(tickDuration); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := b.lowerShield(); err != nil {
+				return err
+			}
+
+			if crit {
+				hit, err := b.critAttack(ctx, mob, weapon, lastAttack)
+				if err != nil {
+					return err
+				}
+				if hit {
+					lastAttack = time.Now()
+					b.log.Printf("💢 Critical hit on %s #%d", entityName(mob.Type), mob.ID)
+				}
+				continue
+			}
+			if err := b.budget.spend(ctx, actionInteract); err != nil {
+				return err
+			}
+			if err := b.sendAttack(mob.ID); err != nil {
+				return err
+			}
+			lastAttack = time.Now()
+			b.log.Printf("⚔️ Attacked %s #%d", entityName(mob.Type), mob.ID)
+		}
+	}
+}
+
+// chaseEntity walks towards an entity until it is within attack reach or moved away from the planned spot
+func (b *Bot) chaseEntity(ctx context.Context, e trackedEntity) error {
+	goal := blockPos{X: int(math.Floor(e.X)), Y: int(math.Floor(e.Y)), Z: int(math.Floor(e.Z))}
+	path, err := b.world.findPath(b.feetBlock(), goal, attackReach-0.5, pathOptions{})
+	if err != nil {
+		return err
+	}
+	return b.followPath(ctx, path, func() bool {
+		now, ok := b.entities.entity(e.ID)
+		if !ok {
+			return true
+		}
+		dx, dy, dz := now.X-e.X, now.Y-e.Y, now.Z-e.Z
+		return math.Sqrt(dx*dx+dy*dy+dz*dz) > followReplan
+	})
+}
+
+// retreatFrom walks away from an entity
+func (b *Bot) retreatFrom(ctx context.Context, e trackedEntity) error {
+	return b.walkAway(ctx, e.X, e.Z, guardRetreatDist)
+}
+
+// walkAway walks dist blocks away from a point, or as far as a path goes
+func (b *Bot) walkAway(ctx context.Context, fromX, fromZ, dist float64) error {
+	x, y, z := b.currentPosition()
+	dx, dz := x-fromX, z-fromZ
+	length := math.Hypot(dx, dz)
+	if length < 0.1 {
+		dx, dz, length = 1, 0, 1
+	}
+	goal := blockPos{
+		X: int(math.Floor(x + dx/length*dist)),
+		Y: int(math.Floor(y)),
+		Z: int(math.Floor(z + dz/length*dist)),
+	}
+	return b.walkPath(ctx, goal, 2, b.mobAwareness(0))
+}
+
+// walkPath pathfinds to within reach of goal and walks there
+func (b *Bot) walkPath(ctx context.Context, goal blockPos, reach float64, opts pathOptions) error {
+	path, err := b.world.findPath(b.feetBlock(), goal, reach, opts)
+	if err != nil {
+		return err
+	}
+	return b.followPath(ctx, path, nil)
+}
+
+// handleGuardCommand starts guarding the current position, "!guard [radius]"
+func (b *Bot) handleGuardCommand(msg string) {
+	radius := guardRadius
+	if m := guardCommand.FindStringSubmatch(msg); m != nil && m[1] != "" {
+		r, _ := strconv.Atoi(m[1])
+		radius = math.Min(math.Max(float64(r), attackReach), guardMaxRadius)
+	}
+	center := b.feetBlock()
+
+	b.stopMode()
+	b.queueGuard(center, radius)
+	b.reply(msg, fmt.Sprintf("Guarding this spot within %.0f blocks, say !stay to stop", radius))
+}
+
+// queueGuard queues guarding center within radius
+func (b *Bot) queueGuard(center blockPos, radius float64) *task {
+	spec := &taskSpec{Kind: guardTaskName, Pos: &center, Radius: radius}
+	name := fmt.Sprintf("%s %d %d %d", guardTaskName, center.X, center.Y, center.Z)
+	return b.enqueueResumableTask(name, exposureAny, spec, func(ctx context.Context) error {
+		return b.guard(ctx, center, radius)
+	})
+}
@@ -0,0 +1,211 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	handoffTimeout    = time.Minute // How long !mine waits for a tool
+	handoffPoll       = 250 * time.Millisecond
+	handoffSearchWait = 10 * time.Second // Time without a tool before searching around the thrower
+	handoffSearchSize = 16               // Radius searched around the thrower for the tool
+	confirmTimeout    = 30 * time.Second // How long a low-durability tool waits for a yes
+	lowToolBlocks     = 32               // Tools expected to break sooner are only used when confirmed
+	lowToolFraction   = 0.1              // Same for tools with less of their durability left
+	handoffTaskName   = "mine handoff"
+)
+
+// mineCommand matches "!mine" with an optional answer to a confirmation
+var mineCommand = regexp.MustCompile(`(?i)!mine(?:\s+(yes|no))?\b`)
+
+// senderOf returns the player who wrote a chat line, empty for server messages
+func senderOf(msg string) string {
+	return parseChatLine(msg).Sender
+}
+
+// fromSelf reports whether a chat line is the bot's own message coming back
+func (b *Bot) fromSelf(msg string) bool {
+	return strings.EqualFold(senderOf(msg), b.cfg.Username)
+}
+
+// isTool reports whether an item is a mining tool
+func isTool(name string) bool {
+	switch _, kind := weaponKind(name); kind {
+	case "pickaxe", "axe", "shovel", "hoe":
+		return true
+	}
+	return name == "minecraft:shears"
+}
+
+// toolSlots returns the inventory slots holding tools and what they hold
+func (b *Bot) toolSlots() map[int]string {
+	tools := make(map[int]string)
+	for _, s := range b.inventorySnapshot() {
+		if s.Slot >= inventoryStart && s.Slot < offhandSlot && b.itemIsTool(s.Item) {
+			tools[s.Slot] = s.Item
+		}
+	}
+	return tools
+}
+
+// awaitTool picks up items thrown to the bot until a new tool is in the inventory and
+// returns its slot. When nothing arrives for a while it searches around the thrower.
+func (b *Bot) awaitTool(ctx context.Context, thrower string) (int, error) {
+	before := b.toolSlots()
+	start := time.Now()
+	searched := false
+	ticker := time.NewTicker(handoffPoll)
+	defer ticker.Stop()
+	for {
+		for slot, item := range b.toolSlots() {
+			if before[slot] != item {
+				return slot, nil
+			}
+		}
+		if time.Since(start) > handoffTimeout {
+			return -1, fmt.Errorf("no tool arrived within %s", handoffTimeout)
+		}
+
+		if _, ok := b.nearestItem(itemLookRadius); ok {
+			if _, err := b.collectItems(ctx); err != nil {
+				return -1, err
+			}
+		} else if !searched && thrower != "" && time.Since(start) > handoffSearchWait {
+			// The tool may have fallen short, bounced off a wall or landed in water
+			searched = true
+			if x, y, z, ok := b.entities.playerPosition(thrower); ok {
+				center := blockPos{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))}
+				err := b.spiralSearch(ctx, center, handoffSearchSize, func() bool {
+					_, ok := b.nearestItem(itemLookRadius)
+					return ok
+				})
+				if err != nil && ctx.Err() != nil {
+					return -1, ctx.Err()
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+}
+
+// confirmTool asks whether to use a tool that is about to break and waits for the answer
+func (b *Bot) confirmTool(ctx context.Context, player, question string) (bool, error) {
+	// Drop answers given before the question
+	select {
+	case <-b.mineConfirm:
+	default:
+	}
+	b.replyTo("mine", player, question+" Use it anyway? (yes/no with !mine)")
+
+	timeout := time.NewTimer(confirmTimeout)
+	defer timeout.Stop()
+	select {
+	case yes := <-b.mineConfirm:
+		return yes, nil
+	case <-timeout.C:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// describeTool announces a tool with its enchantments and how long it will last,
+// and reports whether it is so worn it needs a confirmation
+func describeTool(d itemDetails) (announce string, worn bool) {
+	name := d.displayName()
+	article := "a"
+	if strings.ContainsRune("AEIOU", rune(name[0])) {
+		article = "an"
+	}
+	announce = fmt.Sprintf("Got %s %s", article, name)
+
+	blocks, wears := d.blocksLeft()
+	switch {
+	case !wears:
+		announce += ", it won't break"
+	case !d.Complete:
+		announce += fmt.Sprintf(", good for up to %d blocks", blocks)
+	default:
+		announce += fmt.Sprintf(", good for about %d blocks", blocks)
+		left := float64(d.MaxDamage-d.Damage) / float64(d.MaxDamage)
+		worn = blocks < lowToolBlocks || left < lowToolFraction
+	}
+	return announce, worn
+}
+
+// expectedDurability returns the durability model value for the tool in a slot: what
+// minePlanned counts down by durabilityPerBlock per block, 100 when the tool is unknown
+func (b *Bot) expectedDurability(slot int) int {
+	d, ok := b.itemDetailsAt(slot)
+	if !ok || !d.Complete {
+		return 100
+	}
+	if blocks, wears := d.blocksLeft(); wears {
+		return max(blocks, 1) * durabilityPerBlock
+	}
+	return math.MaxInt32
+}
+
+// handoff waits for a thrown tool, announces it and takes it in hand
+func (b *Bot) handoff(ctx context.Context, thrower string) error {
+	slot, err := b.awaitTool(ctx, thrower)
+	if err != nil {
+		b.replyTo("mine", thrower, "Didn't get a tool")
+		return err
+	}
+	b.lendTool(thrower, slot)
+
+	d, ok := b.itemDetailsAt(slot)
+	if !ok {
+		d = itemDetails{Item: b.toolSlots()[slot]}
+	}
+	announce, worn := describeTool(d)
+	if worn {
+		use, err := b.confirmTool(ctx, thrower, announce+", but it's almost broken.")
+		if err != nil {
+			return err
+		}
+		if !use {
+			b.replyTo("mine", thrower, "OK, I'll keep it but won't mine with it")
+			return nil
+		}
+	} else {
+		b.replyTo("mine", thrower, announce)
+	}
+	return b.equipTool(slot)
+}
+
+// handleMineCommand starts waiting for a tool on "!mine", or answers the pending
+// confirmation on "!mine yes" and "!mine no"
+func (b *Bot) handleMineCommand(msg string) {
+	// The bot's own question echoes back with the answers in it
+	if b.fromSelf(msg) {
+		return
+	}
+	sender := senderOf(msg)
+	m := mineCommand.FindStringSubmatch(msg)
+	if m != nil && m[1] != "" {
+		select {
+		case b.mineConfirm <- strings.EqualFold(m[1], "yes"):
+		default:
+		}
+		return
+	}
+
+	b.log.Println("⛏️ Executing !mine command...")
+	b.reply(msg, "Ready to mine! Throw me a tool!")
+	b.enqueueTask(handoffTaskName, func(ctx context.Context) error {
+		b.log.Println("⏳ Waiting for item to be thrown...")
+		return b.handoff(ctx, sender)
+	})
+}
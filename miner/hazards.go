@@ -0,0 +1,108 @@
+package miner
+
+import (
+	"math"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+// lavaShoreCost is the extra path cost of a step next to lava in a hot dimension
+const lavaShoreCost = 8
+
+// mobHazard is how much a path should stay away from one kind of hostile mob
+type mobHazard struct {
+	Radius float64 // Distance within which the mob makes a path more expensive
+	Cost   float64 // Extra cost of a step right next to the mob, fading out towards the radius
+}
+
+// defaultHazard applies to hostile mobs without an entry in mobHazards
+var defaultHazard = mobHazard{Radius: 4, Cost: 4}
+
+// petHazard keeps paths from walking through named and tamed animals
+var petHazard = mobHazard{Radius: 2, Cost: 6}
+
+// mobHazards weighs the mobs that are most dangerous to walk past
+var mobHazards = map[string]mobHazard{
+	"creeper":  {Radius: 6, Cost: 30}, // Explodes, walking past is never worth it
+	"skeleton": {Radius: 10, Cost: 10},
+	"stray":    {Radius: 10, Cost: 10},
+	"bogged":   {Radius: 10, Cost: 10},
+	"witch":    {Radius: 8, Cost: 8},
+	"blaze":    {Radius: 10, Cost: 10},
+	"pillager": {Radius: 10, Cost: 10},
+	"spider":   {Radius: 5, Cost: 6},
+}
+
+// pathHazard is a tracked mob a path search plans around
+type pathHazard struct {
+	X, Y, Z float64
+	mobHazard
+}
+
+// pathOptions tunes a path search. The zero value plans the shortest path.
+type pathOptions struct {
+	Hazards     []pathHazard
+	AvoidRadius float64 // Refuse steps within this distance of a hazard, 0 to only add costs
+	Bridges     int     // Scaffolding blocks the path may place to cross gaps
+}
+
+// penalty returns the extra cost of stepping onto pos and whether the step is allowed.
+// A hazard the start is already too close to only adds costs, so the bot can walk away from it.
+func (o pathOptions) penalty(start, pos blockPos) (cost float64, allowed bool) {
+	px, py, pz := float64(pos.X)+0.5, float64(pos.Y), float64(pos.Z)+0.5
+	sx, sy, sz := float64(start.X)+0.5, float64(start.Y), float64(start.Z)+0.5
+	for _, h := range o.Hazards {
+		d := math.Sqrt((h.X-px)*(h.X-px) + (h.Y-py)*(h.Y-py) + (h.Z-pz)*(h.Z-pz))
+		if o.AvoidRadius > 0 && d < o.AvoidRadius {
+			ds := math.Sqrt((h.X-sx)*(h.X-sx) + (h.Y-sy)*(h.Y-sy) + (h.Z-sz)*(h.Z-sz))
+			if ds >= o.AvoidRadius {
+				return 0, false
+			}
+		}
+		if d < h.Radius {
+			cost += h.Cost * (1 - d/h.Radius)
+		}
+	}
+	return cost, true
+}
+
+// mobAwareness returns path options that weigh every tracked hostile mob,
+// refusing to pass within avoidRadius of them when it is above 0, and go around pets.
+// Paths may bridge gaps with the scaffolding the bot carries.
+func (b *Bot) mobAwareness(avoidRadius float64) pathOptions {
+	opts := pathOptions{AvoidRadius: avoidRadius, Bridges: b.scaffoldingCount()}
+	b.entities.mu.RLock()
+	defer b.entities.mu.RUnlock()
+	for _, e := range b.entities.entities {
+		if !isHostile(e.Type) {
+			if e.pet() {
+				opts.Hazards = append(opts.Hazards, pathHazard{X: e.X, Y: e.Y, Z: e.Z, mobHazard: petHazard})
+			}
+			continue
+		}
+		h, ok := mobHazards[entityName(e.Type)]
+		if !ok {
+			h = defaultHazard
+		}
+		opts.Hazards = append(opts.Hazards, pathHazard{X: e.X, Y: e.Y, Z: e.Z, mobHazard: h})
+	}
+	return opts
+}
+
+// nearLava reports whether lava touches the bot standing at pos or the block under it
+func (w *worldModel) nearLava(pos blockPos) bool {
+	for dy := -2; dy <= 1; dy++ {
+		for _, d := range []blockPos{{}, {X: 1}, {X: -1}, {Z: 1}, {Z: -1}} {
+			state, ok := w.blockAt(blockPos{X: pos.X + d.X, Y: pos.Y + dy, Z: pos.Z + d.Z})
+			if ok && isLava(state) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isLava reports whether a block state is lava
+func isLava(state block.StateID) bool {
+	return blockName(state) == "minecraft:lava"
+}
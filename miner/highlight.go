@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	opLevelStatusBase = 24 // Entity event status of permission level 0, levels 1-4 follow
+	particleOpLevel   = 2  // Permission level /particle needs
+	highlightPathMax  = 32 // Path blocks marked at most, the rest of a long path is left out
+)
+
+// Particles used for highlighting, in the 1.20.5+ particle argument syntax
+const (
+	targetParticle = "minecraft:dust{color:[1.0,0.2,0.2],scale:1.5}"
+	pathParticle   = "minecraft:dust{color:[0.2,0.6,1.0],scale:1.0}"
+)
+
+// onEntityEvent picks the bot's permission level out of the entity events sent to it
+func (b *Bot) onEntityEvent(p pk.Packet) error {
+	var (
+		id     pk.Int
+		status pk.Byte
+	)
+	if err := p.Scan(&id, &status); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID || status < opLevelStatusBase || status > opLevelStatusBase+4 {
+		return nil
+	}
+
+	level := int32(status - opLevelStatusBase)
+	if previous := b.opLevel.Swap(level); previous != level && b.cfg.DebugParticles {
+		if level >= particleOpLevel {
+			b.log.Printf("✨ Permission level %d, highlighting targets with particles", level)
+		} else {
+			b.log.Printf("⚠️ Permission level %d, debug particles need level %d", level, particleOpLevel)
+		}
+	}
+	return nil
+}
+
+// sendCommand runs a command as the bot, without the leading slash
+func (b *Bot) sendCommand(command string) error {
+	if b.client.Conn == nil {
+		return errors.New("not connected")
+	}
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundChatCommand,
+		pk.String(command),
+	))
+}
+
+// highlighting reports whether debug particles are enabled and allowed
+func (b *Bot) highlighting() bool {
+	return b.cfg.DebugParticles && b.opLevel.Load() >= particleOpLevel
+}
+
+// spawnParticles shows count particles spread over a box of the given half size around a point
+func (b *Bot) spawnParticles(particle string, x, y, z, spread float64, count int) {
+	cmd := fmt.Sprintf("particle %s %.2f %.2f %.2f %.2f %.2f %.2f 0 %d force", particle, x, y, z, spread, spread, spread, count)
+	if err := b.sendCommand(cmd); err != nil {
+		b.log.Printf("⚠️ Failed to send particle command: %v", err)
+	}
+}
+
+// highlightTarget fills the block about to be broken with particles
+func (b *Bot) highlightTarget(pos blockPos) {
+	if !b.highlighting() {
+		return
+	}
+	b.spawnParticles(targetParticle, float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5, 0.35, 24)
+}
+
+// highlightPath marks the blocks of a planned path at foot level
+func (b *Bot) highlightPath(path []blockPos) {
+	if !b.highlighting() {
+		return
+	}
+	for _, p := range path[:min(len(path), highlightPathMax)] {
+		b.spawnParticles(pathParticle, float64(p.X)+0.5, float64(p.Y)+0.1, float64(p.Z)+0.5, 0, 2)
+	}
+}
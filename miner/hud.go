@@ -0,0 +1,315 @@
+package miner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Boss bar actions
+const (
+	bossBarAdd    = 0
+	bossBarRemove = 1
+	bossBarHealth = 2
+	bossBarTitle  = 3
+	bossBarStyle  = 4
+	bossBarFlags  = 5
+)
+
+const (
+	titleFadeIn    = 10 // Ticks titles fade in, stay and fade out for until the server says otherwise
+	titleStay      = 70
+	titleFadeOut   = 20
+	actionBarShown = 3 * time.Second // How long the client shows an action bar message
+)
+
+// bossBarColors name the boss bar colors by ID
+var bossBarColors = []string{"pink", "blue", "red", "green", "yellow", "purple", "white"}
+
+// bossBar is a boss bar the server shows
+type bossBar struct {
+	Title  string    `json:"title"`
+	Health float32   `json:"health"` // From 0 to 1
+	Color  string    `json:"color"`
+	Since  time.Time `json:"since"`
+}
+
+// shownText is a title, subtitle or action bar message and when it goes away
+type shownText struct {
+	Text  string    `json:"text"`
+	Until time.Time `json:"until"`
+}
+
+// hud keeps what the server shows on screen outside chat: boss bars, titles and the action bar
+type hud struct {
+	mu        sync.Mutex
+	pauses    []*regexp.Regexp // pause_on_hud, compiled
+	bars      map[pk.UUID]*bossBar
+	title     shownText
+	subtitle  shownText
+	actionBar shownText
+	timing    [3]int32 // Fade in, stay and fade out ticks of titles
+
+	pausedUntil time.Time // End of the freeze the boss bars and titles started
+}
+
+// hudState is what is on screen in GET /state
+type hudState struct {
+	BossBars  []bossBar `json:"boss_bars"`
+	Title     string    `json:"title,omitempty"`
+	Subtitle  string    `json:"subtitle,omitempty"`
+	ActionBar string    `json:"action_bar,omitempty"`
+}
+
+// compileHUDPauses compiles the pause_on_hud patterns
+func compileHUDPauses(exprs []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// registerHUDHandlers follows the boss bars, titles and action bar
+func (b *Bot) registerHUDHandlers() {
+	b.hud.pauses, _ = compileHUDPauses(b.cfg.PauseOnHUD) // Checked when the config was loaded
+	b.hud.bars = make(map[pk.UUID]*bossBar)
+	b.hud.timing = [3]int32{titleFadeIn, titleStay, titleFadeOut}
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundBossEvent, F: b.onBossBar},
+		bot.PacketHandler{ID: packetid.ClientboundSetTitleText, F: b.onTitle},
+		bot.PacketHandler{ID: packetid.ClientboundSetSubtitleText, F: b.onSubtitle},
+		bot.PacketHandler{ID: packetid.ClientboundSetActionBarText, F: b.onActionBar},
+		bot.PacketHandler{ID: packetid.ClientboundSetTitlesAnimation, F: b.onTitleTiming},
+		bot.PacketHandler{ID: packetid.ClientboundClearTitles, F: b.onClearTitles},
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onHUDReset},
+	)
+}
+
+// onHUDReset drops the boss bars of the server or dimension the bot left
+func (b *Bot) onHUDReset(pk.Packet) error {
+	b.hud.mu.Lock()
+	defer b.hud.mu.Unlock()
+	clear(b.hud.bars)
+	return nil
+}
+
+// onBossBar adds, updates or removes a boss bar
+func (b *Bot) onBossBar(p pk.Packet) error {
+	var (
+		id              pk.UUID
+		action          pk.VarInt
+		title           chat.Message
+		health          pk.Float
+		color, division pk.VarInt
+		flags           pk.UnsignedByte
+	)
+	if err := p.Scan(&id, &action); err != nil {
+		return err
+	}
+	var err error
+	switch action {
+	case bossBarAdd:
+		err = p.Scan(&id, &action, &title, &health, &color, &division, &flags)
+	case bossBarHealth:
+		err = p.Scan(&id, &action, &health)
+	case bossBarTitle:
+		err = p.Scan(&id, &action, &title)
+	case bossBarStyle:
+		err = p.Scan(&id, &action, &color, &division)
+	}
+	if err != nil {
+		return err
+	}
+
+	b.hud.mu.Lock()
+	bar, ok := b.hud.bars[id]
+	switch {
+	case action == bossBarAdd:
+		bar = &bossBar{Title: plainText(title), Health: float32(health), Color: bossBarColor(int32(color)), Since: time.Now()}
+		b.hud.bars[id] = bar
+	case !ok:
+		b.hud.mu.Unlock()
+		return nil
+	case action == bossBarRemove:
+		delete(b.hud.bars, id)
+	case action == bossBarHealth:
+		bar.Health = float32(health)
+	case action == bossBarTitle:
+		bar.Title = plainText(title)
+	case action == bossBarStyle:
+		bar.Color = bossBarColor(int32(color))
+	}
+	shown := *bar
+	b.hud.mu.Unlock()
+
+	switch action {
+	case bossBarAdd, bossBarTitle:
+		b.log.Printf("📊 Boss bar: %s", shown.Title)
+		b.events.emit(eventBossBar, map[string]any{"action": "shown", "title": shown.Title, "color": shown.Color})
+	case bossBarRemove:
+		b.log.Printf("📊 Boss bar gone: %s", shown.Title)
+		b.events.emit(eventBossBar, map[string]any{"action": "removed", "title": shown.Title})
+		b.endHUDPause()
+		return nil
+	}
+	// Bars stay up without a word from the server, so every update keeps the bot paused
+	b.pauseForHUD("boss bar", shown.Title, freezeMax)
+	return nil
+}
+
+// bossBarColor names a boss bar color
+func bossBarColor(id int32) string {
+	if id < 0 || int(id) >= len(bossBarColors) {
+		return fmt.Sprintf("unknown_%d", id)
+	}
+	return bossBarColors[id]
+}
+
+// onTitle records a title shown in the middle of the screen
+func (b *Bot) onTitle(p pk.Packet) error {
+	return b.showText(p, "title", &b.hud.title)
+}
+
+// onSubtitle records a subtitle shown under the title
+func (b *Bot) onSubtitle(p pk.Packet) error {
+	return b.showText(p, "subtitle", &b.hud.subtitle)
+}
+
+// onActionBar records a message shown above the hotbar. Servers resend them every second or so
+// to keep them up, only changes are logged.
+func (b *Bot) onActionBar(p pk.Packet) error {
+	return b.showText(p, "action_bar", &b.hud.actionBar)
+}
+
+// showText keeps a title, subtitle or action bar message until it fades, and logs and emits it
+// when it is new
+func (b *Bot) showText(p pk.Packet, kind string, into *shownText) error {
+	var msg chat.Message
+	if err := p.Scan(&msg); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(plainText(msg))
+
+	b.hud.mu.Lock()
+	shown := actionBarShown
+	if kind != "action_bar" {
+		shown = time.Duration(b.hud.timing[0]+b.hud.timing[1]+b.hud.timing[2]) * tickDuration
+	}
+	fresh := into.Text != text || time.Now().After(into.Until)
+	*into = shownText{Text: text, Until: time.Now().Add(shown)}
+	b.hud.mu.Unlock()
+
+	if text == "" {
+		return nil
+	}
+	b.pauseForHUD(strings.ReplaceAll(kind, "_", " "), text, shown)
+	if !fresh {
+		return nil
+	}
+	b.log.Printf("🪧 Shown as %s: %s", strings.ReplaceAll(kind, "_", " "), text)
+	b.events.emit(eventTitle, map[string]string{"kind": kind, "text": text})
+	return nil
+}
+
+// onTitleTiming records how long the next titles stay up
+func (b *Bot) onTitleTiming(p pk.Packet) error {
+	var fadeIn, stay, fadeOut pk.Int
+	if err := p.Scan(&fadeIn, &stay, &fadeOut); err != nil {
+		return err
+	}
+	b.hud.mu.Lock()
+	defer b.hud.mu.Unlock()
+	b.hud.timing = [3]int32{int32(fadeIn), int32(stay), int32(fadeOut)}
+	return nil
+}
+
+// onClearTitles takes the title and subtitle down, and resets their timing when asked to
+func (b *Bot) onClearTitles(p pk.Packet) error {
+	var reset pk.Boolean
+	if err := p.Scan(&reset); err != nil {
+		return err
+	}
+	b.hud.mu.Lock()
+	defer b.hud.mu.Unlock()
+	b.hud.title, b.hud.subtitle = shownText{}, shownText{}
+	if reset {
+		b.hud.timing = [3]int32{titleFadeIn, titleStay, titleFadeOut}
+	}
+	return nil
+}
+
+// pauseForHUD freezes every action for d when text matches a pause_on_hud pattern, so the bot
+// holds still through something the server announces, like a boss fight or an event countdown
+func (b *Bot) pauseForHUD(kind, text string, d time.Duration) {
+	if !b.hud.pausesFor(text) {
+		return
+	}
+	f := b.frozen()
+	if f.Frozen && time.Until(f.Until) > d/2 {
+		return // Paused long enough already, updates come many times a second
+	}
+	if !f.Frozen {
+		b.log.Printf("⏸️ The %s %q pauses the bot", kind, text)
+	}
+	until := b.freezeFor(d)
+	b.hud.mu.Lock()
+	b.hud.pausedUntil = until
+	b.hud.mu.Unlock()
+}
+
+// pausesFor reports whether text matches a pause_on_hud pattern
+func (h *hud) pausesFor(text string) bool {
+	return slices.ContainsFunc(h.pauses, func(re *regexp.Regexp) bool { return re.MatchString(text) })
+}
+
+// endHUDPause ends the pause a boss bar started once no bar that pauses is left, unless
+// someone froze the bot for longer in the meantime
+func (b *Bot) endHUDPause() {
+	b.hud.mu.Lock()
+	for _, bar := range b.hud.bars {
+		if b.hud.pausesFor(bar.Title) {
+			b.hud.mu.Unlock()
+			return
+		}
+	}
+	ours := !b.hud.pausedUntil.IsZero() && b.frozen().Until.Equal(b.hud.pausedUntil)
+	b.hud.pausedUntil = time.Time{}
+	b.hud.mu.Unlock()
+	if ours {
+		b.unfreeze()
+	}
+}
+
+// snapshot returns what is on screen
+func (h *hud) snapshot() hudState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	s := hudState{BossBars: []bossBar{}}
+	for _, bar := range h.bars {
+		s.BossBars = append(s.BossBars, *bar)
+	}
+	slices.SortFunc(s.BossBars, func(a, b bossBar) int { return a.Since.Compare(b.Since) })
+	for _, t := range []struct {
+		shown shownText
+		into  *string
+	}{{h.title, &s.Title}, {h.subtitle, &s.Subtitle}, {h.actionBar, &s.ActionBar}} {
+		if now.Before(t.shown.Until) {
+			*t.into = t.shown.Text
+		}
+	}
+	return s
+}
@@ -0,0 +1,165 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	maxLookJitter = 10  // Degrees, more misses the face of a block nearby
+	maxPathWobble = 0.2 // Blocks, more brushes the walls of a one block wide tunnel
+	maxTypingTime = 8 * time.Second
+	flushTimeout  = 2 * time.Second        // Longest a leaving bot waits for the replies being typed
+	flushGrace    = 500 * time.Millisecond // Time the flushed replies get to go out before the connection closes
+)
+
+// HumanizeConfig adds human-like noise to what the bot does, for servers whose anti-bot
+// plugins look for machine-perfect aim and timing
+type HumanizeConfig struct {
+	Enabled      bool    `json:"enabled"`
+	LookJitter   float64 `json:"look_jitter"`   // Degrees the aim misses the middle of a block face by at most
+	MineDelayMS  int     `json:"mine_delay_ms"` // Longest random wait before starting to break a block
+	PauseChance  float64 `json:"pause_chance"`  // Chance of a break before breaking a block, 0 to 1
+	PauseSeconds int     `json:"pause_seconds"` // Longest break
+	PathWobble   float64 `json:"path_wobble"`   // Blocks a walk strays sideways from the middle of a path at most
+	TypingCPS    float64 `json:"typing_cps"`    // Characters per second chat replies are typed at, 0 to answer at once
+}
+
+// defaultHumanize returns the noise of a calm player
+func defaultHumanize() HumanizeConfig {
+	return HumanizeConfig{LookJitter: 1.5, MineDelayMS: 250, PauseChance: 0.01, PauseSeconds: 8, PathWobble: 0.15, TypingCPS: 6}
+}
+
+// check checks the amounts of noise
+func (c HumanizeConfig) check() error {
+	if c.LookJitter < 0 || c.LookJitter > maxLookJitter {
+		return errors.New("look_jitter must be between 0 and 10 degrees")
+	}
+	if c.MineDelayMS < 0 || c.PauseSeconds < 0 || c.TypingCPS < 0 {
+		return errors.New("mine_delay_ms, pause_seconds and typing_cps can't be negative")
+	}
+	if c.PauseChance < 0 || c.PauseChance > 1 {
+		return errors.New("pause_chance must be between 0 and 1")
+	}
+	if c.PathWobble < 0 || c.PathWobble > maxPathWobble {
+		return errors.New("path_wobble must be between 0 and 0.2 blocks")
+	}
+	return nil
+}
+
+// typist sends the chat replies of a bot one after the other, each once it was typed
+type typist struct {
+	mu    sync.Mutex
+	last  chan struct{} // Closed once the reply before was sent
+	hurry chan struct{} // Closed once the bot is leaving, to send the replies without typing
+}
+
+// hurried returns the channel closed once the replies are flushed. t.mu must be held.
+func (t *typist) hurried() chan struct{} {
+	if t.hurry == nil {
+		t.hurry = make(chan struct{})
+	}
+	return t.hurry
+}
+
+// lookJitter returns random offsets to add to an aim's yaw and pitch
+func (b *Bot) lookJitter() (yaw, pitch float32) {
+	h := b.cfg.Humanize
+	if !h.Enabled || h.LookJitter == 0 {
+		return 0, 0
+	}
+	return float32((rand.Float64()*2 - 1) * h.LookJitter), float32((rand.Float64()*2 - 1) * h.LookJitter)
+}
+
+// hesitate waits a moment before the bot starts breaking a block, now and then long enough
+// to pass for a break
+func (b *Bot) hesitate(ctx context.Context) error {
+	h := b.cfg.Humanize
+	if !h.Enabled {
+		return nil
+	}
+	if h.PauseSeconds > 0 && rand.Float64() < h.PauseChance {
+		pause := time.Second + rand.N(time.Duration(h.PauseSeconds)*time.Second)
+		b.log.Printf("☕ Taking a %s break", pause.Round(100*time.Millisecond))
+		if err := sleepCtx(ctx, pause); err != nil {
+			return err
+		}
+	}
+	if h.MineDelayMS > 0 {
+		return sleepCtx(ctx, rand.N(time.Duration(h.MineDelayMS)*time.Millisecond))
+	}
+	return nil
+}
+
+// pathWobble returns a random sideways offset for a step of a path along dx, dz, none for
+// diagonal steps that pass close to corners
+func (b *Bot) pathWobble(dx, dz int) (ox, oz float64) {
+	h := b.cfg.Humanize
+	if !h.Enabled || h.PathWobble == 0 || dx*dx+dz*dz != 1 {
+		return 0, 0
+	}
+	off := (rand.Float64()*2 - 1) * h.PathWobble
+	return float64(dz) * off, float64(dx) * off
+}
+
+// typeReply calls send once the bot took the time to type text, after the replies before it
+// went out. It doesn't block the caller.
+func (b *Bot) typeReply(text string, send func()) {
+	h := b.cfg.Humanize
+	if !h.Enabled || h.TypingCPS == 0 {
+		send()
+		return
+	}
+	// A little thinking first, then the typing at an uneven speed
+	typing := time.Duration((0.5 + rand.Float64() + float64(len(text))/h.TypingCPS*(0.8+0.4*rand.Float64())) * float64(time.Second))
+	typing = min(typing, maxTypingTime)
+
+	b.typist.mu.Lock()
+	prev, done, hurry := b.typist.last, make(chan struct{}), b.typist.hurried()
+	b.typist.last = done
+	b.typist.mu.Unlock()
+	go func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		timer := time.NewTimer(typing)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-hurry:
+		}
+		send()
+	}()
+}
+
+// flushReplies sends the replies still being typed at once, and those asked for later without
+// typing, for a bot about to leave. It waits until they were sent and reports whether there
+// were any.
+func (b *Bot) flushReplies() bool {
+	b.typist.mu.Lock()
+	last, hurry := b.typist.last, b.typist.hurried()
+	select {
+	case <-hurry:
+	default:
+		close(hurry)
+	}
+	b.typist.mu.Unlock()
+	if last == nil {
+		return false
+	}
+	select {
+	case <-last:
+		return false
+	default:
+	}
+	select {
+	case <-last:
+	case <-time.After(flushTimeout):
+		b.log.Println("⚠️ Gave up waiting for the replies being typed")
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package miner
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/data/item"
+)
+
+// inventoryDiffDelay batches the slot updates of one container refresh into a single diff
+const inventoryDiffDelay = 100 * time.Millisecond
+
+// inventoryTracker debounces slot updates into inventory diffs
+type inventoryTracker struct {
+	mu     sync.Mutex
+	totals map[string]int // Item counts at the last diff
+	timer  *time.Timer
+}
+
+// itemStack is the serializable view of an inventory slot
+type itemStack struct {
+	Slot  int    `json:"slot"`
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// itemDelta is a change in the total count of one item
+type itemDelta struct {
+	Item  string `json:"item"`
+	Delta int    `json:"delta"`
+}
+
+// inventoryChange is the data of an inventory_changed event
+type inventoryChange struct {
+	Changes []itemDelta `json:"changes"`
+}
+
+// itemName returns the namespaced name of an item ID
+func itemName(id int32) string {
+	if it, ok := item.ByID[item.ID(id)]; ok {
+		return "minecraft:" + it.Name
+	}
+	return fmt.Sprintf("unknown:%d", id)
+}
+
+var (
+	itemIDsOnce sync.Once
+	itemIDs     map[string]int32
+)
+
+// itemID returns the ID of a namespaced item name
+func itemID(name string) (int32, bool) {
+	itemIDsOnce.Do(func() {
+		itemIDs = make(map[string]int32, len(item.ByID))
+		for id, it := range item.ByID {
+			itemIDs["minecraft:"+it.Name] = int32(id)
+		}
+	})
+	id, ok := itemIDs[name]
+	return id, ok
+}
+
+// inventorySnapshot returns every non-empty slot of the player inventory
+func (b *Bot) inventorySnapshot() []itemStack {
+	stacks := []itemStack{}
+	if b.screens == nil {
+		return stacks
+	}
+	for i, s := range b.screens.Inventory.Slots {
+		if s.Count <= 0 {
+			continue
+		}
+		stacks = append(stacks, itemStack{
+			Slot:  i,
+			Item:  itemName(int32(s.ID)),
+			Count: int(s.Count),
+		})
+	}
+	return stacks
+}
+
+// inventoryCounts sums the player inventory by item, ignoring the crafting output slot
+func (b *Bot) inventoryCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, s := range b.inventorySnapshot() {
+		if s.Slot == 0 {
+			continue
+		}
+		counts[s.Item] += s.Count
+	}
+	return counts
+}
+
+// onInventorySlot is called by the screen manager whenever a slot changes
+func (b *Bot) onInventorySlot(id, index int) error {
+	// Container 0 is the player inventory, -2 is the direct inventory update
+	if id != 0 && id != -2 {
+		b.onContainerSlot(id)
+		return nil
+	}
+
+	inv := &b.inventory
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.timer == nil {
+		inv.timer = time.AfterFunc(inventoryDiffDelay, b.diffInventory)
+	} else {
+		inv.timer.Reset(inventoryDiffDelay)
+	}
+	return nil
+}
+
+// diffInventory compares the inventory with the previous snapshot and emits the changes
+func (b *Bot) diffInventory() {
+	counts := b.inventoryCounts()
+
+	b.inventory.mu.Lock()
+	previous := b.inventory.totals
+	b.inventory.totals = counts
+	b.inventory.mu.Unlock()
+
+	// The first full inventory after joining is the baseline, not a gain
+	if previous == nil {
+		return
+	}
+
+	var changes []itemDelta
+	for name, n := range counts {
+		if d := n - previous[name]; d != 0 {
+			changes = append(changes, itemDelta{Item: name, Delta: d})
+		}
+	}
+	for name, n := range previous {
+		if _, ok := counts[name]; !ok {
+			changes = append(changes, itemDelta{Item: name, Delta: -n})
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Item < changes[j].Item })
+
+	for _, c := range changes {
+		if c.Delta > 0 {
+			b.log.Printf("🎒 Gained %d %s", c.Delta, c.Item)
+		} else {
+			b.log.Printf("🎒 Lost %d %s", -c.Delta, c.Item)
+		}
+	}
+	b.events.emit(eventInventoryChanged, inventoryChange{Changes: changes})
+}
@@ -0,0 +1,290 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Tnze/go-mc/data/registryid"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+// Item data component types of protocol 768 this bot can read. The slot data is a list of
+// components without lengths, so a slot is only read up to the first other component.
+const (
+	componentCustomData   = 0
+	componentMaxStackSize = 1
+	componentMaxDamage    = 2
+	componentDamage       = 3
+	componentUnbreakable  = 4
+	componentCustomName   = 5
+	componentItemName     = 6
+	componentItemModel    = 7
+	componentLore         = 8
+	componentRarity       = 9
+	componentEnchantments = 10
+	componentRepairCost   = 17
+	componentMapColor     = 35
+	componentMapID        = 36
+	componentMapDecor     = 37 // map_decorations
+	componentPotion       = 41 // potion_contents
+)
+
+// enchantmentNames lists the enchantment registry in the order vanilla servers send it,
+// which is alphabetical
+var enchantmentNames = []string{
+	"aqua_affinity", "bane_of_arthropods", "binding_curse", "blast_protection", "breach", "channeling",
+	"density", "depth_strider", "efficiency", "feather_falling", "fire_aspect", "fire_protection", "flame",
+	"fortune", "frost_walker", "impaling", "infinity", "knockback", "looting", "loyalty", "luck_of_the_sea",
+	"lure", "mending", "multishot", "piercing", "power", "projectile_protection", "protection", "punch",
+	"quick_charge", "respiration", "riptide", "sharpness", "silk_touch", "smite", "soul_speed",
+	"sweeping_edge", "swift_sneak", "thorns", "unbreaking", "vanishing_curse", "wind_burst",
+}
+
+// itemDetails is what the components of an item stack tell about it
+type itemDetails struct {
+	Item         string         `json:"item"`
+	Damage       int            `json:"damage"`
+	MaxDamage    int            `json:"max_damage,omitempty"` // 0 for items that don't wear out
+	Unbreakable  bool           `json:"unbreakable,omitempty"`
+	Enchantments map[string]int `json:"enchantments,omitempty"`
+	Potion       string         `json:"potion,omitempty"` // Potion type of potions and tipped arrows, like "fire_resistance"
+	MapID        *int32         `json:"map_id,omitempty"` // Map of a filled map
+	Complete     bool           `json:"complete"`         // False when a component the bot can't read cut the details short
+}
+
+// readItemDetails reads slot data, returning ok false for an empty slot.
+// An error means the data could not be read to the end of the slot.
+func readItemDetails(r io.Reader) (d itemDetails, ok bool, err error) {
+	var count, id, added, removed pk.VarInt
+	if _, err := count.ReadFrom(r); err != nil || count <= 0 {
+		return d, false, err
+	}
+	if _, err := (pk.Tuple{&id, &added, &removed}).ReadFrom(r); err != nil {
+		return d, false, err
+	}
+	d.Item = itemName(int32(id))
+	if it, ok := registry.ItemInfo(d.Item); ok {
+		d.MaxDamage = it.MaxDamage
+	}
+
+	for range int(added) {
+		var typ pk.VarInt
+		if _, err := typ.ReadFrom(r); err != nil {
+			return d, true, err
+		}
+		if err := d.readComponent(r, int32(typ)); err != nil {
+			return d, true, err
+		}
+	}
+	for range int(removed) {
+		var typ pk.VarInt
+		if _, err := typ.ReadFrom(r); err != nil {
+			return d, true, err
+		}
+	}
+	d.Complete = true
+	return d, true, nil
+}
+
+// readComponent reads the data of one component into d
+func (d *itemDetails) readComponent(r io.Reader, typ int32) error {
+	var (
+		v   pk.VarInt
+		b   pk.Boolean
+		raw nbt.RawMessage
+		s   pk.String
+	)
+	switch typ {
+	case componentMaxDamage:
+		_, err := v.ReadFrom(r)
+		d.MaxDamage = int(v)
+		return err
+	case componentDamage:
+		_, err := v.ReadFrom(r)
+		d.Damage = int(v)
+		return err
+	case componentMaxStackSize, componentRarity, componentRepairCost:
+		_, err := v.ReadFrom(r)
+		return err
+	case componentMapID:
+		_, err := v.ReadFrom(r)
+		id := int32(v)
+		d.MapID = &id
+		return err
+	case componentMapColor:
+		var c pk.Int
+		_, err := c.ReadFrom(r)
+		return err
+	case componentUnbreakable:
+		d.Unbreakable = true
+		_, err := b.ReadFrom(r) // Shown in the tooltip
+		return err
+	case componentCustomData, componentCustomName, componentItemName, componentMapDecor:
+		_, err := pk.NBT(&raw).ReadFrom(r)
+		return err
+	case componentItemModel:
+		_, err := s.ReadFrom(r)
+		return err
+	case componentLore:
+		if _, err := v.ReadFrom(r); err != nil {
+			return err
+		}
+		for range int(v) {
+			if _, err := pk.NBT(&raw).ReadFrom(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case componentEnchantments:
+		if _, err := v.ReadFrom(r); err != nil {
+			return err
+		}
+		d.Enchantments = make(map[string]int, int(v))
+		for range int(v) {
+			var ench, level pk.VarInt
+			if _, err := (pk.Tuple{&ench, &level}).ReadFrom(r); err != nil {
+				return err
+			}
+			name := fmt.Sprintf("unknown_%d", ench)
+			if int(ench) >= 0 && int(ench) < len(enchantmentNames) {
+				name = enchantmentNames[ench]
+			}
+			d.Enchantments[name] = int(level)
+		}
+		_, err := b.ReadFrom(r) // Shown in the tooltip
+		return err
+	case componentPotion:
+		var (
+			potion pk.Option[pk.VarInt, *pk.VarInt]
+			color  pk.Option[pk.Int, *pk.Int]
+			name   pk.Option[pk.String, *pk.String]
+		)
+		if _, err := (pk.Tuple{&potion, &color, &v}).ReadFrom(r); err != nil {
+			return err
+		}
+		if potion.Has && int(potion.Val) >= 0 && int(potion.Val) < len(registryid.Potion) {
+			d.Potion = strings.TrimPrefix(registryid.Potion[potion.Val], "minecraft:")
+		}
+		if v > 0 {
+			return errors.New("unsupported custom potion effects")
+		}
+		_, err := name.ReadFrom(r)
+		return err
+	}
+	return fmt.Errorf("unsupported item component %d", typ)
+}
+
+// onSlotDetails keeps the details of player inventory slots from slot updates.
+// It never fails the packet, go-mc's own handler reads the slots too.
+func (b *Bot) onSlotDetails(p pk.Packet) error {
+	var (
+		window, state pk.VarInt
+		slot          pk.Short
+	)
+	r := bytes.NewReader(p.Data)
+	if _, err := (pk.Tuple{&window, &state, &slot}).ReadFrom(r); err != nil || window != 0 {
+		return nil
+	}
+	d, ok, _ := readItemDetails(r)
+	b.setSlotDetails(int(slot), d, ok)
+	return nil
+}
+
+// onContentDetails keeps the details of the player inventory from full inventory updates,
+// up to the first slot it can't read
+func (b *Bot) onContentDetails(p pk.Packet) error {
+	var window, state, count pk.VarInt
+	r := bytes.NewReader(p.Data)
+	if _, err := (pk.Tuple{&window, &state, &count}).ReadFrom(r); err != nil || window != 0 {
+		return nil
+	}
+	for i := range int(count) {
+		d, ok, err := readItemDetails(r)
+		b.setSlotDetails(i, d, ok)
+		if err != nil {
+			// The rest of the packet can't be located, forget what is no longer known
+			for j := i + 1; j < int(count); j++ {
+				b.setSlotDetails(j, itemDetails{}, false)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// setSlotDetails stores or clears the details of a player inventory slot
+func (b *Bot) setSlotDetails(slot int, d itemDetails, ok bool) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if !ok {
+		delete(b.slotDetails, slot)
+		return
+	}
+	b.slotDetails[slot] = d
+}
+
+// itemDetailsAt returns the details of the item in a player inventory slot
+func (b *Bot) itemDetailsAt(slot int) (itemDetails, bool) {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	d, ok := b.slotDetails[slot]
+	return d, ok
+}
+
+// blocksLeft estimates how many blocks a tool mines before breaking. Unbreaking makes each
+// use only wear the tool with a chance of 1/(level+1). ok is false for items that don't wear out.
+func (d itemDetails) blocksLeft() (blocks int, ok bool) {
+	if d.Unbreakable || d.MaxDamage <= 0 {
+		return 0, false
+	}
+	return max(d.MaxDamage-d.Damage, 0) * (d.Enchantments["unbreaking"] + 1), true
+}
+
+// displayName returns a name like "Iron Pickaxe, Efficiency II" for chat
+func (d itemDetails) displayName() string {
+	words := strings.Split(strings.TrimPrefix(d.Item, "minecraft:"), "_")
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	name := strings.Join(words, " ")
+
+	// Enchantments in registry order, so the same tool always reads the same
+	for _, e := range enchantmentNames {
+		if level, ok := d.Enchantments[e]; ok {
+			name += ", " + enchantmentLabel(e, level)
+		}
+	}
+	return name
+}
+
+// singleLevelEnchantments have no level shown after their name
+var singleLevelEnchantments = map[string]bool{
+	"aqua_affinity": true, "binding_curse": true, "channeling": true, "flame": true, "infinity": true,
+	"mending": true, "multishot": true, "silk_touch": true, "vanishing_curse": true,
+}
+
+// romanNumerals are the levels shown in tooltips
+var romanNumerals = []string{"", "I", "II", "III", "IV", "V"}
+
+// enchantmentLabel returns "Efficiency II" for efficiency level 2
+func enchantmentLabel(name string, level int) string {
+	words := strings.Split(name, "_")
+	for i, w := range words {
+		if w != "of" && w != "the" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	label := strings.Join(words, " ")
+	switch {
+	case singleLevelEnchantments[name] && level == 1:
+		return label
+	case level > 0 && level < len(romanNumerals):
+		return label + " " + romanNumerals[level]
+	}
+	return fmt.Sprintf("%s %d", label, level)
+}
@@ -0,0 +1,938 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// packetRewrite changes a packet from one layout to another, or into another packet of the
+// bot's by setting its go-mc ID
+type packetRewrite func(c *protocolCodec, p *pk.Packet) error
+
+// packetAnswer answers a packet of the server the bot has no counterpart of, sending the
+// server what it expects back
+type packetAnswer func(c *protocolCodec, p pk.Packet) error
+
+// errUntranslated is returned by rewrites of the packets whose layout the codec doesn't
+// translate, which are dropped
+var errUntranslated = errors.New("layout not translated")
+
+// layoutChange is a change to the layout of the packets the bot uses, made by a protocol, with
+// the rewrites bridging it by packet name. Servers on either side of the change get its
+// rewrites: a change newer than the bot's protocol applies to the servers speaking it or
+// newer, an older one to the servers from before it. fromServer rewrites a packet of the
+// server's side into the bot's and toServer the other way round.
+type layoutChange struct {
+	protocol   int32
+	fromServer map[string]packetRewrite
+	toServer   map[string]packetRewrite
+	renamed    map[string]string       // Server packets named differently on the bot's side
+	answered   map[string]packetAnswer // Server packets without a counterpart the codec answers
+}
+
+// layoutChanges are the layout changes the codec bridges, by protocol
+var layoutChanges = []layoutChange{
+	{
+		// 1.16.5 -> 1.17: chunks are always whole, the player's position tells whether it
+		// left its vehicle, map icons are optional and clicks say what they changed instead
+		// of being numbered and confirmed
+		protocol: 755,
+		fromServer: map[string]packetRewrite{
+			"level_chunk":           chunkBitSet,
+			"player_position":       appendBytes(0),
+			"map_item_data":         mapIconsOptional,
+			"container_set_content": noteContainerContent,
+			"container_set_slot":    noteContainerSlot,
+			"update_tags":           untranslated,
+		},
+		toServer: map[string]packetRewrite{
+			"client_information": dropLastBoolean,
+			"container_click":    clickWithAction,
+		},
+		answered: map[string]packetAnswer{"container_ack": answerRejectedClick},
+	},
+	{
+		// 1.17 -> 1.17.1: containers number their states and entities are removed in lists
+		// again
+		protocol: 756,
+		fromServer: map[string]packetRewrite{
+			"container_set_content": contentStateID,
+			"container_set_slot":    slotStateID,
+			"remove_entity":         entityAsList,
+		},
+		toServer: map[string]packetRewrite{"container_click": dropStateID},
+		renamed:  map[string]string{"remove_entity": "remove_entities"},
+	},
+	{
+		// 1.17.1 -> 1.18: chunks carry their light and biomes per section, and the login tells
+		// the simulation distance
+		protocol: 757,
+		fromServer: map[string]packetRewrite{
+			"level_chunk": chunkWithLight,
+			"login":       loginSimulationDistance,
+		},
+		toServer: map[string]packetRewrite{"client_information": dropLastBoolean},
+		renamed:  map[string]string{"level_chunk": "level_chunk_with_light"},
+	},
+	{
+		// 1.18.2 -> 1.19: dimension types are named, chat comes from players or the system,
+		// block actions are numbered, mobs are spawned like other entities and sounds seeded
+		protocol: 759,
+		fromServer: map[string]packetRewrite{
+			"login":             loginDimensionName,
+			"respawn":           respawnDimensionName,
+			"chat":              chatAsSystem,
+			"add_entity":        addEntityHeadYaw,
+			"add_mob":           addMobAsEntity,
+			"update_mob_effect": mobEffectVarInt,
+			"remove_mob_effect": removeMobEffectVarInt,
+			"sound":             appendBytes(0, 0, 0, 0, 0, 0, 0, 0),
+			"sound_entity":      appendBytes(0, 0, 0, 0, 0, 0, 0, 0),
+			"block_break_ack":   breakAckAsBlockUpdate,
+			"player_info":       playerInfoSignatureData,
+		},
+		toServer: map[string]packetRewrite{
+			"chat":          chatMessageOnly,
+			"chat_command":  commandAsChat,
+			"player_action": dropDigSequence,
+			"use_item_on":   dropUseOnSequence,
+			"use_item":      dropUseSequence,
+		},
+		renamed: map[string]string{
+			"chat":            "system_chat",
+			"add_mob":         "add_entity",
+			"block_break_ack": "block_update",
+		},
+	},
+	{
+		// 1.19 -> 1.19.1: system messages say whether they show above the hotbar and player
+		// chat is signed in a chain
+		protocol: 760,
+		fromServer: map[string]packetRewrite{
+			"system_chat": systemChatOverlay,
+			"player_chat": unchainedChatAsSystem,
+		},
+		toServer: map[string]packetRewrite{
+			"chat":         dropLastSeen,
+			"chat_command": dropLastSeen,
+		},
+		renamed: map[string]string{"player_chat": "system_chat"},
+	},
+	{
+		// 1.19.2 -> 1.19.3: player chat and the player list take new layouts, sounds may be
+		// sent inline and chat acknowledges the messages seen by their offsets
+		protocol: 761,
+		fromServer: map[string]packetRewrite{
+			"player_chat":  chainedChatAsSystem,
+			"player_info":  playerInfoAsUpdate,
+			"sound":        soundHolder,
+			"sound_entity": soundHolder,
+		},
+		toServer: map[string]packetRewrite{
+			"chat":         unsignedLastSeen,
+			"chat_command": unsignedLastSeen,
+			"chat_ack":     untranslated,
+		},
+		renamed: map[string]string{
+			"player_chat": "system_chat",
+			"player_info": "player_info_update",
+		},
+	},
+	{
+		// 1.19.3 -> 1.19.4: the player's position no longer tells whether it left its vehicle
+		protocol:   762,
+		fromServer: map[string]packetRewrite{"player_position": dropDismount},
+	},
+	{
+		// 1.19.4 -> 1.20: chunks no longer say whether to trust their light edges, and the
+		// login and respawn tell the portal cooldown
+		protocol: 763,
+		fromServer: map[string]packetRewrite{
+			"level_chunk_with_light": dropTrustEdges,
+			"section_blocks_update":  dropSuppressLight,
+			"login":                  appendBytes(0),
+			"respawn":                appendBytes(0),
+		},
+	},
+	{
+		// 1.20 -> 1.20.2: NBT loses its root name, players are spawned like other entities and
+		// the registries move from the login into the configuration
+		protocol: 764,
+		fromServer: map[string]packetRewrite{
+			"login":                  loginSinceConfiguration,
+			"respawn":                respawnDataKeptLast,
+			"level_chunk_with_light": chunkNamelessNBT,
+			"add_player":             addPlayerAsEntity,
+		},
+		renamed: map[string]string{"add_player": "add_entity"},
+	},
+	{
+		// 1.20.2 -> 1.20.3: text components are sent as NBT instead of JSON, and scores are
+		// reset by a packet of their own
+		protocol: 765,
+		fromServer: map[string]packetRewrite{
+			"system_chat":         chatFields(chatText, keepBoolean),
+			"disguised_chat":      chatFields(chatText, keepVarInt, chatText, chatOptional),
+			"player_chat":         playerChatNBT,
+			"disconnect":          chatFields(chatText),
+			"set_action_bar_text": chatFields(chatText),
+			"set_title_text":      chatFields(chatText),
+			"set_subtitle_text":   chatFields(chatText),
+			"tab_list":            chatFields(chatText, chatText),
+			"open_screen":         chatFields(keepVarInt, keepVarInt, chatText),
+			"player_info_update":  playerInfoNBT,
+			"boss_event":          bossEventNBT,
+			"set_objective":       objectiveNBT,
+			"set_player_team":     teamNBT,
+			"set_score":           scoreSinceReset,
+			"map_item_data":       mapIconsNBT,
+		},
+	},
+	{
+		// 1.20.3 -> 1.20.5: dimension types are numbered, item stacks carry components instead
+		// of NBT, mob effects count from 0 and unsigned commands get a packet of their own
+		protocol: 766,
+		fromServer: map[string]packetRewrite{
+			"login":                 loginDimensionIndex,
+			"respawn":               respawnDimensionIndex,
+			"container_set_content": containerContentSlots,
+			"container_set_slot":    containerSlotSlot,
+			"update_mob_effect":     mobEffectSinceComponents,
+			"remove_mob_effect":     removeMobEffectFromZero,
+			"set_entity_data":       untranslated,
+			"merchant_offers":       untranslated,
+			"update_advancements":   untranslated,
+		},
+		toServer: map[string]packetRewrite{
+			"chat_command":    signedChatCommand,
+			"container_click": containerClickSlots,
+		},
+	},
+	{
+		// 1.20.5 -> 1.21: using an item tells where the player looks
+		protocol: 767,
+		toServer: map[string]packetRewrite{"use_item": dropUseItemRotation},
+	},
+	{
+		// 1.21.1 -> 1.21.2: the time tells whether it advances, a click on a block whether it
+		// hit the world border, and the player input carries the keys the player holds
+		protocol: 768,
+		fromServer: map[string]packetRewrite{
+			"set_time": timeTicking,
+			"login":    recordEntityID,
+		},
+		toServer: map[string]packetRewrite{
+			"use_item_on":  dropWorldBorderHit,
+			"player_input": steerVehicle,
+		},
+	},
+	{
+		// 1.21.4 -> 1.21.5: player chat is numbered and chat messages checksum the messages
+		// they acknowledge
+		protocol:   770,
+		fromServer: map[string]packetRewrite{"player_chat": dropVarInt},
+		toServer:   map[string]packetRewrite{"chat": appendBytes(0)},
+	},
+}
+
+// layoutChangesFor returns the layout changes between protocol and the bot's, ordered from the
+// server's layout to the bot's
+func layoutChangesFor(protocol int32) []layoutChange {
+	var older, newer []layoutChange
+	for _, change := range layoutChanges {
+		switch {
+		case change.protocol > protocolVersion && protocol >= change.protocol:
+			newer = append(newer, change)
+		case change.protocol <= protocolVersion && protocol < change.protocol:
+			older = append(older, change)
+		}
+	}
+	// Newer servers' packets go back through the changes, newest first
+	for i, j := 0, len(newer)-1; i < j; i, j = i+1, j-1 {
+		newer[i], newer[j] = newer[j], newer[i]
+	}
+	return append(older, newer...)
+}
+
+// packetEditor copies the fields of a packet one by one, changing some of them on the way.
+// The first error stops it and done returns it.
+type packetEditor struct {
+	data []byte
+	r    *bytes.Reader
+	out  bytes.Buffer
+	err  error
+}
+
+func newPacketEditor(data []byte) *packetEditor {
+	return &packetEditor{data: data, r: bytes.NewReader(data)}
+}
+
+// keep copies fields as they are, reading them into the fields given
+func (e *packetEditor) keep(fields ...pk.Field) {
+	for _, f := range fields {
+		if e.err != nil {
+			return
+		}
+		if _, e.err = f.ReadFrom(e.r); e.err == nil {
+			_, e.err = f.WriteTo(&e.out)
+		}
+	}
+}
+
+// keepBytes copies n bytes
+func (e *packetEditor) keepBytes(n int) {
+	if e.err == nil {
+		_, e.err = io.CopyN(&e.out, e.r, int64(n))
+	}
+}
+
+// skip reads fields and leaves them out
+func (e *packetEditor) skip(fields ...pk.FieldDecoder) {
+	for _, f := range fields {
+		if e.err == nil {
+			_, e.err = f.ReadFrom(e.r)
+		}
+	}
+}
+
+// write adds fields
+func (e *packetEditor) write(fields ...pk.FieldEncoder) {
+	for _, f := range fields {
+		if e.err == nil {
+			_, e.err = f.WriteTo(&e.out)
+		}
+	}
+}
+
+// chat turns a text component from JSON into NBT
+func (e *packetEditor) chat() {
+	var text pk.String
+	e.skip(&text)
+	if e.err != nil {
+		return
+	}
+	var msg chat.Message
+	if err := json.Unmarshal([]byte(text), &msg); err != nil {
+		e.err = fmt.Errorf("invalid text component: %w", err)
+		return
+	}
+	// go-mc writes a text component as a named compound within the tag, so the bot writes
+	// the tag and the compound's contents alone
+	var named bytes.Buffer
+	if e.err = msg.MarshalNBT(&named); e.err != nil {
+		return
+	}
+	e.out.WriteByte(nbt.TagCompound)
+	e.out.Write(named.Bytes()[3:]) // After the tag and the empty name
+}
+
+// optionalChat turns a text component that may be left out from JSON into NBT
+func (e *packetEditor) optionalChat() {
+	var has pk.Boolean
+	if e.keep(&has); has {
+		e.chat()
+	}
+}
+
+// readNBT reads NBT, whose root is named when sent before 1.20.2
+func (e *packetEditor) readNBT(named bool) (raw nbt.RawMessage) {
+	if e.err != nil {
+		return raw
+	}
+	d := nbt.NewDecoder(e.r)
+	d.NetworkFormat(!named)
+	if _, err := d.Decode(&raw); err != nil && !errors.Is(err, nbt.ErrEND) {
+		e.err = err
+	}
+	return raw
+}
+
+// namedNBT turns NBT whose root is named into the nameless form of 1.20.2
+func (e *packetEditor) namedNBT() {
+	e.write(pk.NBT(e.readNBT(true)))
+}
+
+// keepNamedNBT copies NBT whose root is named, returning it
+func (e *packetEditor) keepNamedNBT() nbt.RawMessage {
+	start := len(e.data) - e.r.Len()
+	raw := e.readNBT(true)
+	if e.err == nil {
+		e.out.Write(e.data[start : len(e.data)-e.r.Len()])
+	}
+	return raw
+}
+
+// writeNamedNBT writes NBT with an empty root name, as sent before 1.20.2
+func (e *packetEditor) writeNamedNBT(v any) {
+	if e.err == nil {
+		e.err = nbt.NewEncoder(&e.out).Encode(v, "")
+	}
+}
+
+// done returns the packet with what is left of it copied
+func (e *packetEditor) done() ([]byte, error) {
+	if e.err == nil {
+		_, e.err = e.r.WriteTo(&e.out)
+	}
+	return e.out.Bytes(), e.err
+}
+
+// cut returns the packet without what is left of it
+func (e *packetEditor) cut() ([]byte, error) {
+	return e.out.Bytes(), e.err
+}
+
+// edit runs f on an editor of the packet's data and keeps what it made of it
+func edit(p *pk.Packet, f func(e *packetEditor)) error {
+	e := newPacketEditor(p.Data)
+	f(e)
+	data, err := e.done()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// untranslated is the rewrite of the packets whose layout the codec doesn't translate
+func untranslated(*protocolCodec, *pk.Packet) error {
+	return errUntranslated
+}
+
+// dropVarInt removes the VarInt a packet starts with
+func dropVarInt(_ *protocolCodec, p *pk.Packet) error {
+	var v pk.VarInt
+	r := bytes.NewReader(p.Data)
+	if _, err := v.ReadFrom(r); err != nil {
+		return err
+	}
+	p.Data = p.Data[len(p.Data)-r.Len():]
+	return nil
+}
+
+// appendBytes returns a rewrite appending bytes to a packet
+func appendBytes(v ...byte) packetRewrite {
+	return func(_ *protocolCodec, p *pk.Packet) error {
+		p.Data = append(p.Data[:len(p.Data):len(p.Data)], v...)
+		return nil
+	}
+}
+
+// fieldEdit edits a field of a packet
+type fieldEdit func(e *packetEditor)
+
+func keepBoolean(e *packetEditor)  { e.keep(new(pk.Boolean)) }
+func keepVarInt(e *packetEditor)   { e.keep(new(pk.VarInt)) }
+func keepUUID(e *packetEditor)     { e.keep(new(pk.UUID)) }
+func chatText(e *packetEditor)     { e.chat() }
+func chatOptional(e *packetEditor) { e.optionalChat() }
+
+// chatFields returns a rewrite editing the fields a packet starts with, keeping the rest
+func chatFields(fields ...fieldEdit) packetRewrite {
+	return func(_ *protocolCodec, p *pk.Packet) error {
+		return edit(p, func(e *packetEditor) {
+			for _, f := range fields {
+				f(e)
+			}
+		})
+	}
+}
+
+// dropTrustEdges removes the flag telling whether to trust the light on the edges of a chunk
+func dropTrustEdges(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.Int), new(pk.Int))
+		e.keepNamedNBT()
+		e.keep(new(pk.ByteArray), &n)
+		for range max(n, 0) {
+			e.keep(new(pk.Byte), new(pk.Short), new(pk.VarInt))
+			e.keepNamedNBT()
+		}
+		e.skip(new(pk.Boolean))
+	})
+}
+
+// dropSuppressLight removes the flag of a section update telling to keep the light as it is
+func dropSuppressLight(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Long))
+		e.skip(new(pk.Boolean))
+	})
+}
+
+// loginSinceConfiguration turns the login of 1.20 into that of 1.20.2, leaving out the
+// registries sent in the configuration since
+func loginSinceConfiguration(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			eid                          pk.Int
+			hardcore                     pk.Boolean
+			gamemode                     pk.UnsignedByte
+			previous                     pk.Byte
+			dimensions                   []pk.Identifier
+			dimensionType, dimension     pk.Identifier
+			seed                         pk.Long
+			maxPlayers, view, simulation pk.VarInt
+			reducedDebug, respawnScreen  pk.Boolean
+			debug, flat                  pk.Boolean
+		)
+		e.skip(&eid, &hardcore, &gamemode, &previous, pk.Array(&dimensions))
+		e.readNBT(true) // The registries
+		e.skip(&dimensionType, &dimension, &seed, &maxPlayers, &view, &simulation, &reducedDebug, &respawnScreen, &debug, &flat)
+		e.write(eid, hardcore, pk.Array(dimensions), maxPlayers, view, simulation, reducedDebug, respawnScreen,
+			pk.Boolean(false), // Limited crafting
+			dimensionType, dimension, seed, gamemode, previous, debug, flat)
+	})
+}
+
+// respawnDataKeptLast moves the flags of the data a respawn keeps to the end, after the death
+// location and portal cooldown
+func respawnDataKeptLast(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var (
+		kept pk.Byte
+		died pk.Boolean
+	)
+	e.keep(new(pk.Identifier), new(pk.Identifier), new(pk.Long), new(pk.UnsignedByte), new(pk.Byte), new(pk.Boolean), new(pk.Boolean))
+	e.skip(&kept)
+	if e.keep(&died); died {
+		e.keep(new(pk.Identifier), new(pk.Position))
+	}
+	e.keep(new(pk.VarInt))
+	e.write(kept)
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// chunkNamelessNBT drops the root names of the NBT of a chunk
+func chunkNamelessNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.Int), new(pk.Int))
+		e.namedNBT()
+		e.keep(new(pk.ByteArray), &n)
+		for range max(n, 0) {
+			e.keep(new(pk.Byte), new(pk.Short), new(pk.VarInt))
+			e.namedNBT()
+		}
+	})
+}
+
+// addPlayerAsEntity turns the spawn of a player into that of an entity of the player type
+func addPlayerAsEntity(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var yaw, pitch pk.Angle
+		e.keep(new(pk.VarInt), new(pk.UUID))
+		e.write(pk.VarInt(c.playerType()))
+		e.keep(new(pk.Double), new(pk.Double), new(pk.Double))
+		e.skip(&yaw, &pitch)
+		e.write(pitch, yaw, yaw, pk.VarInt(0), pk.Short(0), pk.Short(0), pk.Short(0))
+	})
+}
+
+// playerChatNBT turns the text components of a player chat message into NBT
+func playerChatNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			signed   pk.Boolean
+			previous pk.VarInt
+			filter   pk.VarInt
+		)
+		e.keep(new(pk.UUID), new(pk.VarInt))
+		if e.keep(&signed); signed {
+			e.keepBytes(256)
+		}
+		e.keep(new(pk.String), new(pk.Long), new(pk.Long), &previous)
+		for range max(previous, 0) {
+			var id pk.VarInt
+			if e.keep(&id); id == 0 {
+				e.keepBytes(256)
+			}
+		}
+		e.optionalChat()
+		if e.keep(&filter); filter == 2 {
+			e.keep(new(pk.BitSet))
+		}
+		e.keep(new(pk.VarInt))
+		e.chat()
+		e.optionalChat()
+	})
+}
+
+// Actions of a player info update, in the order of their fields
+const (
+	infoAddPlayer = 1 << iota
+	infoInitChat
+	infoGameMode
+	infoListed
+	infoLatency
+	infoDisplayName
+)
+
+// playerInfoNBT turns the display names of a player info update into NBT
+func playerInfoNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			actions pk.Byte
+			n       pk.VarInt
+		)
+		e.keep(&actions, &n)
+		for range max(n, 0) {
+			e.keep(new(pk.UUID))
+			if actions&infoAddPlayer != 0 {
+				var properties pk.VarInt
+				e.keep(new(pk.String), &properties)
+				for range max(properties, 0) {
+					var signed pk.Boolean
+					if e.keep(new(pk.String), new(pk.String), &signed); signed {
+						e.keep(new(pk.String))
+					}
+				}
+			}
+			if actions&infoInitChat != 0 {
+				var has pk.Boolean
+				if e.keep(&has); has {
+					e.keep(new(pk.UUID), new(pk.Long), new(pk.ByteArray), new(pk.ByteArray))
+				}
+			}
+			if actions&infoGameMode != 0 {
+				e.keep(new(pk.VarInt))
+			}
+			if actions&infoListed != 0 {
+				e.keep(new(pk.Boolean))
+			}
+			if actions&infoLatency != 0 {
+				e.keep(new(pk.VarInt))
+			}
+			if actions&infoDisplayName != 0 {
+				e.optionalChat()
+			}
+		}
+	})
+}
+
+// Boss bar actions carrying a title
+const (
+	bossAdd         = 0
+	bossUpdateTitle = 3
+)
+
+// bossEventNBT turns the title of a boss bar into NBT
+func bossEventNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var action pk.VarInt
+		e.keep(new(pk.UUID), &action)
+		if action == bossAdd || action == bossUpdateTitle {
+			e.chat()
+		}
+	})
+}
+
+// objectiveNBT turns the name of a scoreboard objective into NBT, without a number format
+func objectiveNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var mode pk.Byte
+		e.keep(new(pk.String), &mode)
+		if mode == 0 || mode == 2 { // Created or updated
+			e.chat()
+			e.keep(new(pk.VarInt))
+			e.write(pk.Boolean(false))
+		}
+	})
+}
+
+// teamNBT turns the names, prefixes and suffixes of a team into NBT
+func teamNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var mode pk.Byte
+		e.keep(new(pk.String), &mode)
+		if mode == 0 || mode == 2 { // Created or updated
+			e.chat()
+			e.keep(new(pk.Byte), new(pk.String), new(pk.String), new(pk.VarInt))
+			e.chat()
+			e.chat()
+		}
+	})
+}
+
+// scoreSinceReset turns a score update into that of 1.20.3, and a removal into a reset
+func scoreSinceReset(_ *protocolCodec, p *pk.Packet) error {
+	var (
+		entity, objective pk.String
+		action, value     pk.VarInt
+	)
+	r := bytes.NewReader(p.Data)
+	if _, err := (pk.Tuple{&entity, &action, &objective}).ReadFrom(r); err != nil {
+		return err
+	}
+	if action == 1 { // Removed
+		p.ID = int32(packetid.ClientboundResetScore)
+		p.Data = pk.Marshal(0, entity, pk.OptionEncoder[pk.String]{Has: objective != "", Val: objective}).Data
+		return nil
+	}
+	if _, err := value.ReadFrom(r); err != nil {
+		return err
+	}
+	p.Data = pk.Marshal(0, entity, objective, value, pk.Boolean(false), pk.Boolean(false)).Data
+	return nil
+}
+
+// mapIconsNBT turns the names of the icons of a map into NBT
+func mapIconsNBT(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			icons pk.Boolean
+			n     pk.VarInt
+		)
+		e.keep(new(pk.VarInt), new(pk.Byte), new(pk.Boolean))
+		if e.keep(&icons); !icons {
+			return
+		}
+		e.keep(&n)
+		for range max(n, 0) {
+			e.keep(new(pk.VarInt), new(pk.Byte), new(pk.Byte), new(pk.Byte))
+			e.optionalChat()
+		}
+	})
+}
+
+// loginDimensionIndex numbers the dimension type of the login by the registry of dimension
+// types
+func loginDimensionIndex(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Int), new(pk.Boolean), pk.Array(new([]pk.Identifier)))
+		e.keep(new(pk.VarInt), new(pk.VarInt), new(pk.VarInt), new(pk.Boolean), new(pk.Boolean), new(pk.Boolean))
+		c.dimensionIndex(e)
+	})
+}
+
+// respawnDimensionIndex numbers the dimension type of a respawn by the registry of dimension
+// types
+func respawnDimensionIndex(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, c.dimensionIndex)
+}
+
+// dimensionIndex turns the name of a dimension type into its index in the registry
+func (c *protocolCodec) dimensionIndex(e *packetEditor) {
+	var name pk.Identifier
+	if e.skip(&name); e.err != nil {
+		return
+	}
+	var types map[string]int32
+	if p := c.dimensions.Load(); p != nil {
+		types = *p
+	}
+	id, ok := types[string(name)]
+	if !ok {
+		e.err = fmt.Errorf("unknown dimension type %s", name)
+		return
+	}
+	e.write(pk.VarInt(id))
+}
+
+// oldSlot turns an item stack of before 1.20.5 into one without components. Its NBT is lost.
+func (c *protocolCodec) oldSlot(e *packetEditor) {
+	var (
+		present pk.Boolean
+		id      pk.VarInt
+		count   pk.Byte
+	)
+	if e.skip(&present); !present {
+		e.write(pk.VarInt(0))
+		return
+	}
+	e.skip(&id, &count)
+	e.readNBT(c.protocol < 764)
+	if count <= 0 {
+		e.write(pk.VarInt(0))
+		return
+	}
+	e.write(pk.VarInt(count), id, pk.VarInt(0), pk.VarInt(0))
+}
+
+// containerContentSlots turns the item stacks of a container's content into ones without
+// components
+func containerContentSlots(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.UnsignedByte), new(pk.VarInt), &n)
+		for range max(n, 0) + 1 { // And the carried stack
+			c.oldSlot(e)
+		}
+	})
+}
+
+// containerSlotSlot turns the item stack of a container slot into one without components
+func containerSlotSlot(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Byte), new(pk.VarInt), new(pk.Short))
+		c.oldSlot(e)
+	})
+}
+
+// mobEffectSinceComponents turns a mob effect of before 1.20.5, numbered from 1, into one of
+// 1.20.5
+func mobEffectSinceComponents(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var (
+		effect    pk.VarInt
+		amplifier pk.Byte
+	)
+	e.keep(new(pk.VarInt))
+	e.skip(&effect, &amplifier)
+	e.write(effect-1, pk.VarInt(amplifier))
+	e.keep(new(pk.VarInt), new(pk.Byte))
+	data, err := e.cut() // Without the factor data
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// removeMobEffectFromZero numbers a removed mob effect from 0
+func removeMobEffectFromZero(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var effect pk.VarInt
+		e.keep(new(pk.VarInt))
+		e.skip(&effect)
+		e.write(effect - 1)
+	})
+}
+
+// signedChatCommand turns an unsigned command into the command of before 1.20.5, without
+// argument signatures
+func signedChatCommand(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.String))
+		e.write(pk.Long(time.Now().UnixMilli()), pk.Long(0), pk.VarInt(0), pk.VarInt(0), pk.NewFixedBitSet(20))
+	})
+}
+
+// newSlot turns an item stack without components into one of before 1.20.5
+func newSlot(e *packetEditor) {
+	var count, id pk.VarInt
+	if e.skip(&count); count <= 0 {
+		e.write(pk.Boolean(false))
+		return
+	}
+	e.skip(&id, new(pk.VarInt), new(pk.VarInt))
+	e.write(pk.Boolean(true), id, pk.Byte(count), pk.Byte(nbt.TagEnd))
+}
+
+// containerClickSlots turns the item stacks of a click into ones of before 1.20.5
+func containerClickSlots(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.VarInt), new(pk.VarInt), new(pk.Short), new(pk.Byte), new(pk.VarInt), &n)
+		for range max(n, 0) {
+			e.keep(new(pk.Short))
+			newSlot(e)
+		}
+		newSlot(e)
+	})
+}
+
+// dropUseItemRotation removes the rotation using an item ends with
+func dropUseItemRotation(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.VarInt), new(pk.VarInt))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// timeTicking turns the time of before 1.21.2, negative while it stands still, into the time
+// and whether it advances
+func timeTicking(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var dayTime pk.Long
+		e.keep(new(pk.Long))
+		e.skip(&dayTime)
+		e.write(max(dayTime, -dayTime), pk.Boolean(dayTime >= 0))
+	})
+}
+
+// recordEntityID notes the entity ID of the bot, which the packets to older servers need
+func recordEntityID(c *protocolCodec, p *pk.Packet) error {
+	var eid pk.Int
+	if _, err := eid.ReadFrom(bytes.NewReader(p.Data)); err != nil {
+		return err
+	}
+	c.eid.Store(int32(eid))
+	return nil
+}
+
+// dropWorldBorderHit removes the flag telling whether a click on a block hit the world border
+func dropWorldBorderHit(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.VarInt), new(pk.Position), new(pk.VarInt), new(pk.Float), new(pk.Float), new(pk.Float), new(pk.Boolean))
+		e.skip(new(pk.Boolean))
+	})
+}
+
+// Keys of the player input of 1.21.2
+const (
+	inputBackward = 0x02
+	inputLeft     = 0x04
+	inputRight    = 0x08
+	inputJump     = 0x10
+)
+
+// Player commands pressing and releasing the sneak key
+const (
+	pressShift   = 0
+	releaseShift = 1
+)
+
+// steerVehicle turns the keys the player holds into the vehicle steering of before 1.21.2.
+// The sneak key was a player command then, so pressing or releasing it becomes one.
+func steerVehicle(c *protocolCodec, p *pk.Packet) error {
+	var keys pk.UnsignedByte
+	if _, err := keys.ReadFrom(bytes.NewReader(p.Data)); err != nil {
+		return err
+	}
+	sneak := keys&inputSneak != 0
+	if c.sneaking.Swap(sneak) != sneak {
+		action := releaseShift
+		if sneak {
+			action = pressShift
+		}
+		p.ID = int32(packetid.ServerboundPlayerCommand)
+		p.Data = pk.Marshal(0, pk.VarInt(c.eid.Load()), pk.VarInt(action), pk.VarInt(0)).Data
+		return nil
+	}
+	axis := func(positive, negative pk.UnsignedByte) pk.Float {
+		var v pk.Float
+		if keys&positive != 0 {
+			v++
+		}
+		if keys&negative != 0 {
+			v--
+		}
+		return v
+	}
+	var flags pk.UnsignedByte
+	if keys&inputJump != 0 {
+		flags |= 0x01
+	}
+	p.Data = pk.Marshal(0, axis(inputLeft, inputRight), axis(inputForward, inputBackward), flags).Data
+	return nil
+}
@@ -0,0 +1,400 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	bucketLoot       = "loot"           // Contributions and mined output of each bot, keyed by username
+	contributeWait   = 30 * time.Second // How long !contribute collects thrown items
+	contributeSettle = 5 * time.Second  // Quiet time after the last item before the collection ends
+	contributeTask   = "contribute"
+	deliverTaskName  = "deliver shares"
+)
+
+// lootLedger tracks what players contributed to a bot and what it mined, so the output
+// can be split in proportion to the contributions
+type lootLedger struct {
+	mu        sync.Mutex
+	Points    map[string]float64        `json:"points"`    // Contribution of each player
+	Output    map[string]int            `json:"output"`    // Items gained while mining
+	Delivered map[string]map[string]int `json:"delivered"` // Items handed to each player so far
+}
+
+// contributionPoints values contributed items: a tool counts as the blocks it mines before
+// breaking, any other item as one point
+func contributionPoints(item string, count int) float64 {
+	if it, ok := registry.ItemInfo(item); ok && isTool(item) && it.MaxDamage > 0 {
+		return float64(count * it.MaxDamage)
+	}
+	return float64(count)
+}
+
+// isMiningTask reports whether a task gains items by mining
+func isMiningTask(name string) bool {
+	return name == quarryTaskName || name == "mine block in front" ||
+		strings.HasPrefix(name, mineTaskName+" ") && name != handoffTaskName
+}
+
+// toolPoints values a handed-over tool by the blocks it mines before breaking
+func toolPoints(d itemDetails) float64 {
+	if blocks, wears := d.blocksLeft(); wears && d.Complete {
+		return float64(blocks)
+	}
+	return contributionPoints(d.Item, 1)
+}
+
+// mayContribute reports whether a player's contributions count
+func (b *Bot) mayContribute(player string) bool {
+	if len(b.cfg.Contributors) == 0 || strings.EqualFold(player, b.cfg.Owner) {
+		return true
+	}
+	return slices.ContainsFunc(b.cfg.Contributors, func(c string) bool { return strings.EqualFold(c, player) })
+}
+
+// loadLoot restores the ledger saved by the last run
+func (b *Bot) loadLoot() {
+	l := &b.loot
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := b.db.Get(bucketLoot, b.cfg.Username, l); err != nil {
+		b.log.Printf("⚠️ Starting a fresh loot ledger: %v", err)
+	}
+	if l.Points == nil {
+		l.Points = make(map[string]float64)
+	}
+	if l.Output == nil {
+		l.Output = make(map[string]int)
+	}
+	if l.Delivered == nil {
+		l.Delivered = make(map[string]map[string]int)
+	}
+}
+
+// saveLoot writes the ledger to the state database. The caller must hold the ledger lock.
+func (b *Bot) saveLoot() {
+	if err := b.db.Put(bucketLoot, b.cfg.Username, &b.loot); err != nil {
+		b.log.Printf("⚠️ Failed to save the loot ledger: %v", err)
+	}
+}
+
+// credit adds points to a player's contribution, removing it once nothing is left
+func (b *Bot) credit(player string, points float64) {
+	l := &b.loot
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Points[player] = max(l.Points[player]+points, 0)
+	b.saveLoot()
+	b.log.Printf("🤲 %s: %+.0f point(s), %.0f in total", player, points, l.Points[player])
+}
+
+// recordOutput adds the items gained while mining to the output to split
+func (b *Bot) recordOutput(change inventoryChange) {
+	if !isMiningTask(b.currentStrategy()) {
+		return
+	}
+	l := &b.loot
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	gained := false
+	for _, c := range change.Changes {
+		if c.Delta > 0 && !isTool(c.Item) {
+			l.Output[c.Item] += c.Delta
+			gained = true
+		}
+	}
+	if gained {
+		b.saveLoot()
+	}
+}
+
+// shares returns what each contributor is owed of the output, not counting what they got already
+func (b *Bot) shares() (owed map[string]map[string]int, percent map[string]float64) {
+	l := &b.loot
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	total := 0.0
+	for _, p := range l.Points {
+		total += p
+	}
+	owed, percent = make(map[string]map[string]int), make(map[string]float64)
+	if total == 0 {
+		return owed, percent
+	}
+	for player, p := range l.Points {
+		if p == 0 {
+			continue
+		}
+		percent[player] = p / total * 100
+		owed[player] = make(map[string]int)
+		for item, n := range l.Output {
+			// Rounding down leaves the odd items with the bot rather than promising them twice
+			if due := int(math.Floor(float64(n)*p/total)) - l.Delivered[player][item]; due > 0 {
+				owed[player][item] = due
+			}
+		}
+	}
+	return owed, percent
+}
+
+// markDelivered records items handed to a player
+func (b *Bot) markDelivered(player string, items map[string]int) {
+	l := &b.loot
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Delivered[player] == nil {
+		l.Delivered[player] = make(map[string]int)
+	}
+	for item, n := range items {
+		l.Delivered[player][item] += n
+	}
+	b.saveLoot()
+}
+
+// available limits a share to what the inventory holds
+func (b *Bot) available(share map[string]int) map[string]int {
+	counts := b.inventoryCounts()
+	items := make(map[string]int)
+	for item, n := range share {
+		if n = min(n, counts[item]); n > 0 {
+			items[item] = n
+		}
+	}
+	return items
+}
+
+// countItems sums the items of a share
+func countItems(items map[string]int) int {
+	total := 0
+	for _, n := range items {
+		total += n
+	}
+	return total
+}
+
+// collectContribution picks up what a player throws and credits them with it
+func (b *Bot) collectContribution(ctx context.Context, player string) error {
+	before := b.inventoryCounts()
+	deadline := time.Now().Add(contributeWait)
+	lastGain := time.Time{}
+	for time.Now().Before(deadline) && (lastGain.IsZero() || time.Since(lastGain) < contributeSettle) {
+		n, err := b.collectItems(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			lastGain = time.Now()
+		}
+		if err := sleepCtx(ctx, handoffPoll); err != nil {
+			return err
+		}
+	}
+
+	points := 0.0
+	for item, n := range b.inventoryCounts() {
+		if gained := n - before[item]; gained > 0 {
+			points += contributionPoints(item, gained)
+		}
+	}
+	if points == 0 {
+		b.replyTo("contribute", player, "Nothing arrived, throw the items after asking")
+		return nil
+	}
+	b.credit(player, points)
+	b.replyTo("contribute", player, fmt.Sprintf("Thanks %s, that's %.0f point(s) towards your share", player, points))
+	return nil
+}
+
+// claimShare walks to a player and drops their share at their feet
+func (b *Bot) claimShare(ctx context.Context, player string) error {
+	owed, _ := b.shares()
+	items := b.available(owed[player])
+	if len(items) == 0 {
+		b.replyTo("claim", player, fmt.Sprintf("Nothing to hand you yet, %s", player))
+		return nil
+	}
+	if err := b.faceRequester(ctx, player); err != nil {
+		b.replyTo("claim", player, fmt.Sprintf("Can't get to you: %v", err))
+		return err
+	}
+	for item, n := range items {
+		if err := b.throwItems(item, n); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", item, err)
+		}
+	}
+	b.markDelivered(player, items)
+	b.replyTo("claim", player, fmt.Sprintf("Here's your share, %d item(s)", countItems(items)))
+	return nil
+}
+
+// depositItems puts items from the inventory into the chest at pos
+func (b *Bot) depositItems(ctx context.Context, pos blockPos, items map[string]int) error {
+	s, err := b.openContainer(ctx, pos, func(typ int32) bool { return typ < chestMenus })
+	if err != nil {
+		return fmt.Errorf("failed to open the chest: %w", err)
+	}
+	defer func() {
+		if err := b.closeWindow(s.ID); err != nil {
+			b.log.Printf("⚠️ Failed to close the chest: %v", err)
+		}
+	}()
+	if err := sleepCtx(ctx, craftSyncDelay); err != nil {
+		return err
+	}
+
+	size := int(s.Type+1) * 9
+	stacks := b.windowStacks(s.ID)
+	if len(stacks) < size+playerInventoryLen {
+		return errors.New("chest window is too small")
+	}
+	for item, n := range items {
+		for j := size; j < size+playerInventoryLen && n > 0; j++ {
+			st := stacks[j]
+			if st.Item != item || st.Count <= 0 {
+				continue
+			}
+			if st.Count <= n {
+				if err := b.sendClick(s.ID, craft.Click{Slot: j, Button: craft.ButtonLeft, Mode: craft.ModeQuickMove,
+					Changed: map[int]craft.Stack{j: {}}}); err != nil {
+					return err
+				}
+				n -= st.Count
+				continue
+			}
+
+			// Part of a stack: pick it up, place items one by one in an empty chest slot, put the
+			// rest back. Quick moves fill the chest from the front, so the slot is taken from the back.
+			empty := -1
+			for k := size - 1; k >= 0 && empty < 0; k-- {
+				if stacks[k].Count <= 0 {
+					empty = k
+				}
+			}
+			if empty < 0 {
+				return errors.New("the chest is full")
+			}
+			clicks := []craft.Click{{Slot: j, Button: craft.ButtonLeft, Mode: craft.ModePickup,
+				Changed: map[int]craft.Stack{j: {}}, Cursor: st}}
+			for k := 1; k <= n; k++ {
+				clicks = append(clicks, craft.Click{Slot: empty, Button: craft.ButtonRight, Mode: craft.ModePickup,
+					Changed: map[int]craft.Stack{empty: {Item: item, Count: k}}, Cursor: craft.Stack{Item: item, Count: st.Count - k}})
+			}
+			clicks = append(clicks, craft.Click{Slot: j, Button: craft.ButtonLeft, Mode: craft.ModePickup,
+				Changed: map[int]craft.Stack{j: {Item: item, Count: st.Count - n}}})
+			for _, c := range clicks {
+				if err := b.sendClick(s.ID, c); err != nil {
+					return err
+				}
+			}
+			stacks[empty] = craft.Stack{Item: item, Count: n}
+			n = 0
+		}
+	}
+	return nil
+}
+
+// deliverShares takes every contributor with a share chest their share
+func (b *Bot) deliverShares(ctx context.Context) error {
+	owed, _ := b.shares()
+	delivered := 0
+	for _, player := range slices.Sorted(maps.Keys(owed)) {
+		pos, ok := b.shareChest(player)
+		items := b.available(owed[player])
+		if !ok || len(items) == 0 {
+			continue
+		}
+		b.log.Printf("📦 Delivering %d item(s) to %s's chest at (%d, %d, %d)", countItems(items), player, pos.X, pos.Y, pos.Z)
+		if err := b.approachBlock(ctx, pos); err != nil {
+			return fmt.Errorf("failed to reach %s's chest: %w", player, err)
+		}
+		before := b.inventoryCounts()
+		err := b.depositItems(ctx, pos, items)
+		// Whatever left the inventory is in the chest, even when the chest filled up halfway
+		after, moved := b.inventoryCounts(), make(map[string]int)
+		for item, n := range items {
+			if m := min(n, before[item]-after[item]); m > 0 {
+				moved[item] = m
+			}
+		}
+		b.markDelivered(player, moved)
+		if err != nil {
+			return fmt.Errorf("failed to fill %s's chest: %w", player, err)
+		}
+		delivered++
+	}
+	b.sendChatMessage(fmt.Sprintf("Delivered %d share(s)", delivered))
+	return nil
+}
+
+// shareChest returns the chest a player's share is delivered to
+func (b *Bot) shareChest(player string) (blockPos, bool) {
+	for name, pos := range b.cfg.ShareChests {
+		if strings.EqualFold(name, player) {
+			return pos, true
+		}
+	}
+	return blockPos{}, false
+}
+
+// handleContributeCommand collects the items the sender throws next and credits them
+func (b *Bot) handleContributeCommand(msg string) {
+	player := senderOf(msg)
+	if player == "" || b.fromSelf(msg) || !b.mayContribute(player) {
+		return
+	}
+	b.reply(msg, fmt.Sprintf("Throw me what you're putting in, %s", player))
+	b.enqueueTask(contributeTask+" "+player, func(ctx context.Context) error {
+		return b.collectContribution(ctx, player)
+	})
+}
+
+// handleSharesCommand reports how the output is split
+func (b *Bot) handleSharesCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	owed, percent := b.shares()
+	if len(percent) == 0 {
+		b.reply(msg, "Nobody has contributed yet")
+		return
+	}
+	var parts []string
+	for _, player := range slices.Sorted(maps.Keys(percent)) {
+		parts = append(parts, fmt.Sprintf("%s %.0f%% (%d owed)", player, percent[player], countItems(owed[player])))
+	}
+	b.reply(msg, "Shares: "+strings.Join(parts, ", "))
+}
+
+// handleClaimCommand queues dropping the sender's share at their feet
+func (b *Bot) handleClaimCommand(msg string) {
+	player := senderOf(msg)
+	if player == "" || b.fromSelf(msg) || !b.mayContribute(player) {
+		return
+	}
+	b.enqueueTask("claim "+player, func(ctx context.Context) error {
+		return b.claimShare(ctx, player)
+	})
+}
+
+// handleDeliverCommand queues taking every share to its owner's chest
+func (b *Bot) handleDeliverCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	if len(b.cfg.ShareChests) == 0 {
+		b.reply(msg, "No share chests are configured")
+		return
+	}
+	b.enqueueTask(deliverTaskName, b.deliverShares)
+}
@@ -0,0 +1,278 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	mapSize        = 128             // Pixels along each side of a map
+	mapSaveDelay   = 5 * time.Second // Updates of a held map are written to its PNG at most this often
+	mapsDefaultDir = "maps"
+)
+
+// mapBaseColors are the base colors of the map palette by ID, 0 being transparent
+var mapBaseColors = []uint32{
+	0x000000, 0x7FB238, 0xF7E9A3, 0xC7C7C7, 0xFF0000, 0xA0A0FF, 0xA7A7A7, 0x007C00,
+	0xFFFFFF, 0xA4A8B8, 0x976D4D, 0x707070, 0x4040FF, 0x8F7748, 0xFFFCF5, 0xD87F33,
+	0xB24CD8, 0x6699D8, 0xE5E533, 0x7FCC19, 0xF27FA5, 0x4C4C4C, 0x999999, 0x4C7F99,
+	0x7F3FB2, 0x334CB2, 0x664C33, 0x667F33, 0x993333, 0x191919, 0xFAEE4D, 0x5CDBD5,
+	0x4A80FF, 0x00D93A, 0x815631, 0x700200, 0xD1B1A1, 0x9F5224, 0x95576C, 0x706C8A,
+	0xBA8524, 0x677535, 0xA04D4E, 0x392923, 0x876B62, 0x575C5C, 0x7A4958, 0x4C3E5C,
+	0x4C3223, 0x4C522A, 0x8E3C2E, 0x251610, 0xBD3031, 0x943F61, 0x5C191D, 0x167E86,
+	0x3A8E8C, 0x562C3E, 0x14B485, 0x646464, 0xD8AF93, 0x7FA796,
+}
+
+// mapShades are the brightness of the four shades of every base color, out of 255
+var mapShades = [4]uint32{180, 220, 255, 135}
+
+// mapColor returns the color of a map pixel: its base color in the upper six bits and its
+// shade in the lower two
+func mapColor(c byte) color.RGBA {
+	base := int(c >> 2)
+	if base == 0 || base >= len(mapBaseColors) {
+		return color.RGBA{}
+	}
+	rgb, shade := mapBaseColors[base], mapShades[c&3]
+	return color.RGBA{
+		R: uint8((rgb >> 16 & 0xFF) * shade / 255),
+		G: uint8((rgb >> 8 & 0xFF) * shade / 255),
+		B: uint8((rgb & 0xFF) * shade / 255),
+		A: 0xFF,
+	}
+}
+
+// mapIcon is a marker drawn on a map, like a player, a banner or an explorer map target
+type mapIcon struct {
+	Type      int    `json:"type"`
+	X         int    `json:"x"` // From -128 to 127 across the map
+	Z         int    `json:"z"`
+	Direction int    `json:"direction"` // 0 to 15, in steps of 22.5°
+	Name      string `json:"name,omitempty"`
+}
+
+// mapItem is what the bot knows of a filled map from the map data the server sent
+type mapItem struct {
+	ID      int32     `json:"id"`
+	Scale   int       `json:"scale"` // Each pixel covers 2^scale blocks along each side
+	Locked  bool      `json:"locked"`
+	Icons   []mapIcon `json:"icons"`
+	Updated time.Time `json:"updated"`
+	colors  [mapSize * mapSize]byte
+}
+
+// image renders the map pixels
+func (m *mapItem) image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, mapSize, mapSize))
+	for i, c := range m.colors {
+		img.SetRGBA(i%mapSize, i/mapSize, mapColor(c))
+	}
+	return img
+}
+
+// mapWatch keeps the maps the server sent data of since the bot joined
+type mapWatch struct {
+	mu      sync.Mutex
+	maps    map[int32]*mapItem
+	pending map[int32]bool // Maps waiting to be written to their PNG
+}
+
+// registerMapHandlers reads the map data the server sends for the maps in the inventory
+func (b *Bot) registerMapHandlers() {
+	b.maps.maps = make(map[int32]*mapItem)
+	b.maps.pending = make(map[int32]bool)
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundMapItemData, F: b.onMapData},
+	)
+}
+
+// onMapData applies an update of the icons and pixels of a map. Pixels come as a rectangle
+// of the map that changed.
+func (b *Bot) onMapData(p pk.Packet) error {
+	var (
+		id           pk.VarInt
+		scale        pk.Byte
+		locked       pk.Boolean
+		hasIcons     pk.Boolean
+		columns      pk.UnsignedByte
+		rows, x0, z0 pk.UnsignedByte
+		data         pk.ByteArray
+	)
+	r := bytes.NewReader(p.Data)
+	if _, err := (pk.Tuple{&id, &scale, &locked, &hasIcons}).ReadFrom(r); err != nil {
+		return err
+	}
+	var icons []mapIcon
+	if hasIcons {
+		var err error
+		if icons, err = readMapIcons(r); err != nil {
+			return err
+		}
+	}
+	if _, err := columns.ReadFrom(r); err != nil {
+		return err
+	}
+	if columns > 0 {
+		if _, err := (pk.Tuple{&rows, &x0, &z0, &data}).ReadFrom(r); err != nil {
+			return err
+		}
+		if int(x0)+int(columns) > mapSize || int(z0)+int(rows) > mapSize || len(data) < int(columns)*int(rows) {
+			return fmt.Errorf("map %d update of %dx%d at %d, %d doesn't fit", id, columns, rows, x0, z0)
+		}
+	}
+
+	b.maps.mu.Lock()
+	m := b.maps.maps[int32(id)]
+	if m == nil {
+		m = &mapItem{ID: int32(id), Icons: []mapIcon{}}
+		b.maps.maps[int32(id)] = m
+	}
+	m.Scale, m.Locked, m.Updated = int(scale), bool(locked), time.Now()
+	if hasIcons {
+		m.Icons = icons
+	}
+	for z := range int(rows) {
+		for x := range int(columns) {
+			m.colors[(int(z0)+z)*mapSize+int(x0)+x] = data[z*int(columns)+x]
+		}
+	}
+	save := b.cfg.MapsDir != "" && !b.maps.pending[m.ID] && slices.Contains(b.heldMaps(), m.ID)
+	if save {
+		b.maps.pending[m.ID] = true
+	}
+	b.maps.mu.Unlock()
+
+	if save {
+		time.AfterFunc(mapSaveDelay, func() { b.saveMap(int32(id)) })
+	}
+	return nil
+}
+
+// readMapIcons reads the icons of a map data packet
+func readMapIcons(r io.Reader) ([]mapIcon, error) {
+	var count pk.VarInt
+	if _, err := count.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	icons := make([]mapIcon, 0, max(int(count), 0))
+	for range int(count) {
+		var (
+			typ     pk.VarInt
+			x, z    pk.Byte
+			dir     pk.Byte
+			hasName pk.Boolean
+			name    chat.Message
+		)
+		if _, err := (pk.Tuple{&typ, &x, &z, &dir, &hasName}).ReadFrom(r); err != nil {
+			return nil, err
+		}
+		if hasName {
+			if _, err := name.ReadFrom(r); err != nil {
+				return nil, err
+			}
+		}
+		icons = append(icons, mapIcon{Type: int(typ), X: int(x), Z: int(z), Direction: int(dir), Name: name.ClearString()})
+	}
+	return icons, nil
+}
+
+// heldMaps returns the IDs of the filled maps in the main hand and the offhand
+func (b *Bot) heldMaps() []int32 {
+	b.stateMu.RLock()
+	slot := hotbarStart + int(b.heldSlot)
+	b.stateMu.RUnlock()
+	var ids []int32
+	for _, s := range []int{slot, offhandSlot} {
+		if d, ok := b.itemDetailsAt(s); ok && d.Item == "minecraft:filled_map" && d.MapID != nil {
+			ids = append(ids, *d.MapID)
+		}
+	}
+	return ids
+}
+
+// saveMap writes a map to its PNG file in maps_dir
+func (b *Bot) saveMap(id int32) {
+	b.maps.mu.Lock()
+	delete(b.maps.pending, id)
+	m := b.maps.maps[id]
+	var img *image.RGBA
+	if m != nil {
+		img = m.image()
+	}
+	b.maps.mu.Unlock()
+	if img == nil {
+		return
+	}
+
+	path := filepath.Join(b.cfg.MapsDir, fmt.Sprintf("map_%d.png", id))
+	if err := writePNG(path, img); err != nil {
+		b.log.Printf("⚠️ Failed to save map %d: %v", id, err)
+		return
+	}
+	b.events.emit(eventMapRendered, map[string]any{"id": id, "file": path})
+}
+
+// writePNG writes an image to a PNG file, creating its directory
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// handleMapsRequest lists the maps the bot has data of, most recently updated first
+func (b *Bot) handleMapsRequest(w http.ResponseWriter, r *http.Request) {
+	b.maps.mu.Lock()
+	maps := make([]mapItem, 0, len(b.maps.maps))
+	for _, m := range b.maps.maps {
+		c := *m
+		c.Icons = slices.Clone(m.Icons)
+		maps = append(maps, c)
+	}
+	b.maps.mu.Unlock()
+	slices.SortFunc(maps, func(a, b mapItem) int { return b.Updated.Compare(a.Updated) })
+	writeJSON(w, http.StatusOK, maps)
+}
+
+// handleMapImageRequest renders a map as a PNG image
+func (b *Bot) handleMapImageRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(r.PathValue("file"), ".png"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid map ID"))
+		return
+	}
+	b.maps.mu.Lock()
+	var img *image.RGBA
+	if m := b.maps.maps[int32(id)]; m != nil {
+		img = m.image()
+	}
+	b.maps.mu.Unlock()
+	if img == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no data of map %d", id))
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
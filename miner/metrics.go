@@ -0,0 +1,89 @@
+package miner
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// handleMetricsRequest exposes bot metrics in the Prometheus text format
+func (b *Bot) handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	rates := b.ores.rates()
+	fmt.Fprintln(w, "# HELP miner_ore_per_hour Rolling ore yield per hour by strategy and region.")
+	fmt.Fprintln(w, "# TYPE miner_ore_per_hour gauge")
+	for _, r := range rates {
+		fmt.Fprintf(w, "miner_ore_per_hour{strategy=%q,region=%q} %g\n", r.Strategy, r.Region, r.PerHour)
+	}
+	fmt.Fprintln(w, "# HELP miner_ore_total Ore items gained by strategy and region.")
+	fmt.Fprintln(w, "# TYPE miner_ore_total counter")
+	for _, r := range rates {
+		fmt.Fprintf(w, "miner_ore_total{strategy=%q,region=%q} %d\n", r.Strategy, r.Region, r.Total)
+	}
+
+	traveled, routes, flagged := b.travel.snapshot()
+	fmt.Fprintln(w, "# HELP miner_travel_blocks_total Blocks moved by the bot, teleports excluded.")
+	fmt.Fprintln(w, "# TYPE miner_travel_blocks_total counter")
+	fmt.Fprintf(w, "miner_travel_blocks_total %g\n", traveled)
+	fmt.Fprintln(w, "# HELP miner_route_efficiency Straight-line over traveled distance of the last rated route.")
+	fmt.Fprintln(w, "# TYPE miner_route_efficiency gauge")
+	if len(routes) > 0 {
+		fmt.Fprintf(w, "miner_route_efficiency %g\n", routes[len(routes)-1].Efficiency)
+	}
+	fmt.Fprintln(w, "# HELP miner_routes_flagged_total Routes flagged as inefficient.")
+	fmt.Fprintln(w, "# TYPE miner_routes_flagged_total counter")
+	fmt.Fprintf(w, "miner_routes_flagged_total %d\n", flagged)
+
+	spent, waited := b.budget.snapshot()
+	fmt.Fprintln(w, "# HELP miner_actions_total Actions charged against the action budget by kind.")
+	fmt.Fprintln(w, "# TYPE miner_actions_total counter")
+	for kind, n := range spent {
+		fmt.Fprintf(w, "miner_actions_total{kind=%q} %d\n", kind, n)
+	}
+	fmt.Fprintln(w, "# HELP miner_action_budget_wait_seconds_total Time spent waiting for the action budget.")
+	fmt.Fprintln(w, "# TYPE miner_action_budget_wait_seconds_total counter")
+	fmt.Fprintf(w, "miner_action_budget_wait_seconds_total %g\n", waited.Seconds())
+
+	delayed, held, replaced := b.shaper.snapshot()
+	fmt.Fprintln(w, "# HELP miner_packets_delayed_total Packets held back by the packet limits by kind.")
+	fmt.Fprintln(w, "# TYPE miner_packets_delayed_total counter")
+	for kind, n := range delayed {
+		fmt.Fprintf(w, "miner_packets_delayed_total{kind=%q} %d\n", kind, n)
+	}
+	fmt.Fprintln(w, "# HELP miner_packet_delay_seconds_total Time packets were held back by the packet limits.")
+	fmt.Fprintln(w, "# TYPE miner_packet_delay_seconds_total counter")
+	fmt.Fprintf(w, "miner_packet_delay_seconds_total %g\n", held.Seconds())
+	fmt.Fprintln(w, "# HELP miner_packets_replaced_total Movement packets replaced by newer ones while held back by the packet limits.")
+	fmt.Fprintln(w, "# TYPE miner_packets_replaced_total counter")
+	fmt.Fprintf(w, "miner_packets_replaced_total %d\n", replaced)
+
+	money := b.economy.snapshot()
+	fmt.Fprintln(w, "# HELP miner_money_earned_total Money the economy plugin paid for items the bot sold.")
+	fmt.Fprintln(w, "# TYPE miner_money_earned_total counter")
+	fmt.Fprintf(w, "miner_money_earned_total %g\n", money.Earned)
+	if money.Balance != nil {
+		fmt.Fprintln(w, "# HELP miner_balance Last balance the economy plugin reported.")
+		fmt.Fprintln(w, "# TYPE miner_balance gauge")
+		fmt.Fprintf(w, "miner_balance %g\n", *money.Balance)
+	}
+
+	if tps, ok := b.tps.tps(); ok {
+		fmt.Fprintln(w, "# HELP miner_server_tps Ticks per second the server runs at, estimated from its time updates.")
+		fmt.Fprintln(w, "# TYPE miner_server_tps gauge")
+		fmt.Fprintf(w, "miner_server_tps %g\n", tps)
+	}
+	if ping, ok := b.ping(); ok {
+		fmt.Fprintln(w, "# HELP miner_ping_seconds Latency of the bot as the server measured it with keep-alives.")
+		fmt.Fprintln(w, "# TYPE miner_ping_seconds gauge")
+		fmt.Fprintf(w, "miner_ping_seconds %g\n", ping.Seconds())
+	}
+
+	values := b.tablist.numericFields()
+	fmt.Fprintln(w, "# HELP miner_server_value Numeric fields read from the player list header and footer.")
+	fmt.Fprintln(w, "# TYPE miner_server_value gauge")
+	for _, field := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(w, "miner_server_value{field=%q} %g\n", field, values[field])
+	}
+}
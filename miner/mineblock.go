@@ -0,0 +1,183 @@
+package miner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	mineBlockRadius   = 64 // Blocks around the bot searched for targets
+	mineBlockMaxCount = 64 // Most blocks one !mineblock takes on
+	mineBlockTaskName = "mineblock"
+)
+
+// mineBlockCommand matches "!mineblock <block_name> [count]"
+var mineBlockCommand = regexp.MustCompile(`(?i)!mineblock\s+([a-z0-9_:]+)(?:\s+(\d+))?`)
+
+// blockNames is the set of block names go-mc knows
+var blockNames = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	for _, s := range block.StateList {
+		names[s.ID()] = true
+	}
+	return names
+})
+
+// nearestBlocks returns up to count loaded blocks named name, closest to from first
+func (b *Bot) nearestBlocks(from blockPos, name string, count int) []blockPos {
+	var found []blockPos
+	b.world.eachBlock(from, mineBlockRadius, func(pos blockPos, state block.StateID) {
+		if blockName(state) == name && b.checkProtected(pos, name) == nil {
+			found = append(found, pos)
+		}
+	})
+	slices.SortFunc(found, func(a, c blockPos) int {
+		return cmp.Compare(distance(from, a), distance(from, c))
+	})
+	return found[:min(len(found), count)]
+}
+
+// visitOrder orders targets for a short walk from start: nearest neighbour first, then
+// 2-opt reversals while they shorten the route
+func visitOrder(start blockPos, targets []blockPos) []blockPos {
+	route := make([]blockPos, 0, len(targets))
+	left := slices.Clone(targets)
+	at := start
+	for len(left) > 0 {
+		next := 0
+		for i, p := range left {
+			if distance(at, p) < distance(at, left[next]) {
+				next = i
+			}
+		}
+		at = left[next]
+		route = append(route, at)
+		left = slices.Delete(left, next, next+1)
+	}
+
+	// Reversing route[i..j] swaps the legs into i and out of j for shorter ones
+	stop := func(i int) blockPos {
+		if i < 0 {
+			return start
+		}
+		return route[i]
+	}
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(route)-1; i++ {
+			for j := i + 1; j < len(route); j++ {
+				before := distance(stop(i-1), route[i])
+				after := distance(stop(i-1), route[j])
+				if j+1 < len(route) {
+					before += distance(route[j], route[j+1])
+					after += distance(route[i], route[j+1])
+				}
+				if after < before-1e-9 {
+					slices.Reverse(route[i : j+1])
+					improved = true
+				}
+			}
+		}
+	}
+	return route
+}
+
+// mineBlocks visits and mines up to count blocks named name, then reports what was collected
+func (b *Bot) mineBlocks(ctx context.Context, name string, count int, player string) error {
+	start := b.feetBlock()
+	targets := b.nearestBlocks(start, name, count)
+	if len(targets) == 0 {
+		b.replyTo(mineBlockTaskName, player, fmt.Sprintf("No %s loaded within %d blocks", strings.TrimPrefix(name, "minecraft:"), mineBlockRadius))
+		return nil
+	}
+	route := visitOrder(start, targets)
+	b.log.Printf("🎯 Mining %d %s", len(route), name)
+
+	before := b.inventoryCounts()
+	mined := 0
+	for _, pos := range route {
+		if state, ok := b.world.blockAt(pos); !ok || blockName(state) != name {
+			continue // Broken by someone else meanwhile
+		}
+		err := b.walkPath(ctx, pos, miningReach-1, b.mobAwareness(b.cfg.AvoidMobs))
+		if errors.Is(err, errNoPath) {
+			err = b.approachBlock(ctx, pos)
+		}
+		if err != nil {
+			return err
+		}
+		if err := b.mineWithItem(ctx, pos.X, pos.Y, pos.Z); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			b.log.Printf("⚠️ Skipping the %s at (%d, %d, %d): %v", name, pos.X, pos.Y, pos.Z, err)
+			continue
+		}
+		mined++
+		if _, err := b.collectItems(ctx); err != nil {
+			return err
+		}
+	}
+
+	gained := b.lootSince(before)
+	var parts []string
+	for _, item := range slices.Sorted(maps.Keys(gained)) {
+		parts = append(parts, fmt.Sprintf("%s x%d", strings.TrimPrefix(item, "minecraft:"), gained[item]))
+	}
+	reply := fmt.Sprintf("Mined %d of %d %s", mined, len(route), strings.TrimPrefix(name, "minecraft:"))
+	if len(parts) > 0 {
+		reply += ", collected " + strings.Join(parts, ", ")
+	}
+	b.replyTo(mineBlockTaskName, player, reply)
+	return nil
+}
+
+// queueMineBlocks queues mining count blocks named name
+func (b *Bot) queueMineBlocks(name string, count int, player string) *task {
+	spec := &taskSpec{Kind: mineBlockTaskName, Item: name, Count: count, Player: player}
+	return b.enqueueResumableTask(fmt.Sprintf("%s %s x%d", mineTaskName, name, count), exposureAny, spec, func(ctx context.Context) error {
+		return b.mineBlocks(ctx, name, count, player)
+	})
+}
+
+// handleMineBlockCommand queues mining the blocks of "!mineblock <block_name> [count]"
+func (b *Bot) handleMineBlockCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := mineBlockCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !mineblock <block_name> [count]")
+		return
+	}
+	name := strings.ToLower(m[1])
+	if !strings.Contains(name, ":") {
+		name = "minecraft:" + name
+	}
+	if !blockNames()[name] {
+		b.reply(msg, fmt.Sprintf("%s is not a block I know", name))
+		return
+	}
+	count := 1
+	if m[2] != "" {
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n < 1 || n > mineBlockMaxCount {
+			b.reply(msg, fmt.Sprintf("Count must be between 1 and %d", mineBlockMaxCount))
+			return
+		}
+		count = n
+	}
+
+	b.queueMineBlocks(name, count, senderOf(msg))
+	b.reply(msg, fmt.Sprintf("Looking for %d %s", count, strings.TrimPrefix(name, "minecraft:")))
+}
@@ -0,0 +1,356 @@
+// Package miner is a Minecraft bot that mines, quarries, builds and guards on its own, for
+// use from other Go programs as well as from the minecraft-bot command:
+//
+//	m := miner.New(cfg)
+//	m.OnEvent(func(e miner.Event) { log.Println(e.Type) })
+//	m.Enqueue(miner.Quarry(0, 60, 0, 31, 50, 31))
+//	err := m.Run(ctx)
+//
+// A Swarm runs several bots in one process, sharing their state databases and quarries.
+package miner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+	"github.com/coolguycoder/Minecraft-Miner/store"
+)
+
+// BlockPos is the position of a block
+type BlockPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// DefaultConfig returns the built-in configuration
+func DefaultConfig() Config {
+	return defaultConfig()
+}
+
+// LoadConfigs reads a config file and returns the config of every bot it runs, one unless
+// it lists bots
+func LoadConfigs(path string) ([]Config, error) {
+	return loadConfigs(path)
+}
+
+var versionsOnce sync.Once
+
+// logVersions logs the Minecraft version the bots speak, once per process
+func logVersions() {
+	versionsOnce.Do(func() {
+		log.Printf("📦 Minecraft Java Edition version: %s (Protocol %d)", version, protocolVersion)
+		log.Printf("📚 Block and item data of Minecraft %s", registry.Version)
+		if bot.ProtocolVersion != protocolVersion {
+			log.Printf("⚠️ go-mc speaks protocol %d but the bundled data is for protocol %d, regenerate it with tools/datagen", bot.ProtocolVersion, protocolVersion)
+		}
+	})
+}
+
+// Miner is a bot, ready to join its server with Run
+type Miner struct {
+	bot   *Bot
+	swarm *Swarm
+}
+
+// New returns a bot for a config, on its own. Its state database, audit log and rules are
+// opened when it runs.
+func New(cfg Config) *Miner {
+	return NewSwarm([]Config{cfg}).Miners[0]
+}
+
+// OnEvent calls fn with every event of the bot, in order, from a goroutine of its own
+func (m *Miner) OnEvent(fn func(Event)) {
+	m.bot.events.subscribe(func(e botEvent) { fn(exportEvent(e)) })
+}
+
+// Subscribe calls fn with the data of every event of the bot carrying a T, in order, from a
+// goroutine of the bot. The typed events are BlockMined, ToolBroke, PlayerNearby and
+// TaskFinished:
+//
+//	miner.Subscribe(m, func(e miner.ToolBroke) { log.Printf("%s broke", e.Item) })
+func Subscribe[T any](m *Miner, fn func(T)) {
+	m.bot.events.subscribe(func(e botEvent) {
+		if data, ok := exportEventData(e.Data).(T); ok {
+			fn(data)
+		}
+	})
+}
+
+// Enqueue adds a job to the end of the bot's task queue and returns the ID of its task. A
+// quarry is shared by the swarm and has no task ID of its own.
+func (m *Miner) Enqueue(j Job) (int64, error) {
+	s := j.spec
+	if s.Kind == gotoTaskName || s.Kind == followTaskName {
+		s.Avoid = m.bot.cfg.AvoidMobs
+	}
+	t, err := m.bot.queueSpec(s)
+	if err != nil || t == nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+// Run joins the server and plays until the bot stops or leaves. A bot made with New opens
+// its files first and closes them when it is done.
+func (m *Miner) Run(ctx context.Context) error {
+	if len(m.swarm.Miners) > 1 {
+		return errors.New("the bot is part of a swarm, run the swarm")
+	}
+	return m.swarm.Run(ctx)
+}
+
+// Stop leaves the server
+func (m *Miner) Stop() {
+	m.bot.stop()
+}
+
+// Username returns the name the bot plays as
+func (m *Miner) Username() string {
+	return m.bot.cfg.Username
+}
+
+// Logger returns the log of the bot, whose lines start with its name
+func (m *Miner) Logger() *log.Logger {
+	return m.bot.log
+}
+
+// Position returns where the bot stands
+func (m *Miner) Position() (x, y, z float64) {
+	m.bot.stateMu.RLock()
+	defer m.bot.stateMu.RUnlock()
+	return m.bot.x, m.bot.y, m.bot.z
+}
+
+// Say sends a chat message
+func (m *Miner) Say(text string) {
+	m.bot.sendChatMessage(text)
+}
+
+// Reply answers the command of a chat line the way command_replies sets for it
+func (m *Miner) Reply(line ChatLine, text string) {
+	m.bot.replyTo(line.Command, line.Sender, text)
+}
+
+// WritePacket sends a packet to the server
+func (m *Miner) WritePacket(p pk.Packet) error {
+	if m.bot.client.Conn == nil {
+		return errors.New("not connected")
+	}
+	return m.bot.client.Conn.WritePacket(p)
+}
+
+// Swarm is the bots of one process. Bots with the same state_db share the database and the
+// chest index, and quarries are split between the bots joining the same server.
+type Swarm struct {
+	Miners []*Miner
+
+	mu     sync.Mutex
+	opened bool
+	dbs    map[string]store.Backend
+	audits map[string]*auditLog
+}
+
+// NewSwarm returns the bots of configs, each config with its own username and http_addr. The
+// bots of a server share one action budget, set by the first of them, and quarry together.
+func NewSwarm(configs []Config) *Swarm {
+	s := &Swarm{}
+	budgets := make(map[string]*actionBudget)
+	quarries := make(map[string]*swarm)
+	for _, c := range configs {
+		budget, shared := budgets[c.Server]
+		if !shared {
+			budget = newActionBudget()
+			budgets[c.Server] = budget
+			quarries[c.Server] = newSwarm()
+		}
+		b := newBot(c, budget)
+		if !shared {
+			budget.configure(c, b.log)
+		}
+		quarries[c.Server].join(b)
+		m := &Miner{bot: b, swarm: s}
+		b.loadPlugins(m)
+		s.Miners = append(s.Miners, m)
+	}
+	return s
+}
+
+// open opens the state databases, audit logs and rules of the bots and loads what they
+// saved
+func (s *Swarm) open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opened {
+		return nil
+	}
+	s.dbs = make(map[string]store.Backend)
+	s.audits = make(map[string]*auditLog)
+	indexes := make(map[string]*chestIndex)
+	for _, m := range s.Miners {
+		b, c := m.bot, m.bot.cfg
+		if s.dbs[c.StateDB] == nil {
+			db, err := store.Connect(c.StateDB)
+			if err != nil {
+				s.closeLocked()
+				return errors.Join(errors.New("failed to open the state database"), err)
+			}
+			s.dbs[c.StateDB] = db
+			indexes[c.StateDB] = loadChestIndex(db)
+		}
+		b.db = s.dbs[c.StateDB]
+		b.chests = indexes[c.StateDB]
+		if c.AuditLog != "" && s.audits[c.AuditLog] == nil {
+			a, err := openAuditLog(c.AuditLog)
+			if err != nil {
+				s.closeLocked()
+				return err
+			}
+			s.audits[c.AuditLog] = a
+		}
+		b.audit = s.audits[c.AuditLog]
+		rules, err := loadRules(c.RulesFile)
+		if err != nil {
+			s.closeLocked()
+			return err
+		}
+		b.rules = rules
+		if err := b.initPlugins(); err != nil {
+			s.closeLocked()
+			return err
+		}
+		b.loadStats()
+		b.loadLoot()
+		b.loadCookies()
+		b.loadOwnerPresence()
+	}
+	s.opened = true
+	return nil
+}
+
+// Run opens the files of the bots, runs every bot until all of them left the game and
+// closes the files again
+func (s *Swarm) Run(ctx context.Context) error {
+	logVersions()
+	if err := s.open(); err != nil {
+		return err
+	}
+	defer s.Close()
+	if len(s.Miners) > 1 {
+		log.Printf("🐝 Running %d bots", len(s.Miners))
+	}
+
+	var wg sync.WaitGroup
+	for _, m := range s.Miners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.bot.run(ctx); err != nil {
+				m.bot.log.Printf("❌ %v", err)
+			}
+			m.bot.swarm.leave(m.bot)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Stop makes every bot leave the server
+func (s *Swarm) Stop() {
+	for _, m := range s.Miners {
+		m.bot.stop()
+	}
+}
+
+// Close flushes and closes the state databases and audit logs. Closing twice does nothing.
+func (s *Swarm) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+// closeLocked closes the files of the swarm. The caller must hold the lock.
+func (s *Swarm) closeLocked() {
+	for path, db := range s.dbs {
+		if err := db.Close(); err != nil {
+			log.Printf("❌ Failed to close %s: %v", path, err)
+		}
+	}
+	for path, a := range s.audits {
+		if err := a.Close(); err != nil {
+			log.Printf("❌ Failed to close %s: %v", path, err)
+		}
+	}
+	s.dbs, s.audits = nil, nil
+	s.opened = false
+}
+
+// Job is a task a bot can be given with Enqueue. A bot that stops while doing it picks it
+// up again when it starts over.
+type Job struct {
+	spec taskSpec
+}
+
+// Quarry digs out the box between two corners with every bot of the swarm
+func Quarry(x1, y1, z1, x2, y2, z2 int) Job {
+	region := regionFrom([6]int{x1, y1, z1, x2, y2, z2})
+	return Job{taskSpec{Kind: quarryTaskName, Region: &region}}
+}
+
+// Goto walks to a block
+func Goto(x, y, z int) Job {
+	return Job{taskSpec{Kind: gotoTaskName, Pos: &blockPos{X: x, Y: y, Z: z}}}
+}
+
+// Mine mines a block
+func Mine(x, y, z int) Job {
+	return Job{taskSpec{Kind: mineTaskName, Pos: &blockPos{X: x, Y: y, Z: z}}}
+}
+
+// MineBlocks finds and mines count blocks of a kind, like "minecraft:oak_log"
+func MineBlocks(block string, count int) Job {
+	return Job{taskSpec{Kind: mineBlockTaskName, Item: block, Count: count}}
+}
+
+// Follow follows a player around
+func Follow(player string) Job {
+	return Job{taskSpec{Kind: followTaskName, Player: player}}
+}
+
+// Guard fights the hostile mobs within radius of a block
+func Guard(x, y, z int, radius float64) Job {
+	return Job{taskSpec{Kind: guardTaskName, Pos: &blockPos{X: x, Y: y, Z: z}, Radius: radius}}
+}
+
+// Craft crafts count of an item, like "minecraft:stone_pickaxe"
+func Craft(item string, count int) Job {
+	return Job{taskSpec{Kind: craftTaskName, Item: item, Count: count}}
+}
+
+// Fly flies to a block with an elytra
+func Fly(x, y, z int) Job {
+	return Job{taskSpec{Kind: flyTaskName, Pos: &blockPos{X: x, Y: y, Z: z}}}
+}
+
+// Explore walks a spiral out to radius blocks around a block, keeping the chunks that load
+func Explore(x, y, z, radius int) Job {
+	return Job{taskSpec{Kind: exploreTaskName, Pos: &blockPos{X: x, Y: y, Z: z}, Radius: float64(radius)}}
+}
+
+// Build builds a schematic of the schematics directory with its lowest corner at a block
+func Build(file string, x, y, z int) Job {
+	return Job{taskSpec{Kind: buildTaskName, Item: file, Pos: &blockPos{X: x, Y: y, Z: z}}}
+}
+
+// Flatten mines every block above height y in a rectangle, filling the holes below it when
+// fill is set
+func Flatten(x1, z1, x2, z2, y int, fill bool) Job {
+	region := regionFrom([6]int{x1, y, z1, x2, y, z2})
+	return Job{taskSpec{Kind: flattenTaskName, Region: &region, Fill: fill}}
+}
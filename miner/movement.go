@@ -0,0 +1,162 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	walkSpeed     = 4.317 // Vanilla walking speed in blocks per second
+	sprintSpeed   = 5.612 // Vanilla sprinting speed in blocks per second
+	startSprint   = 3     // PlayerCommand actions
+	stopSprint    = 4
+	arriveRadius  = 0.25 // Distance at which a walk target counts as reached
+	arriveSlack   = 1e-6 // Rounding error tolerated on top of a radius, steps short of it never get there
+	miningReach   = 4.5  // Survival block interaction range
+	eyeHeight     = 1.62 // Player eye height above the feet
+	progressEvery = 50   // Blocks between walk progress logs
+)
+
+// sendPosition sends a player position packet
+func (b *Bot) sendPosition(x, y, z float64, onGround bool) error {
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundMovePlayerPos,
+		pk.Double(x),
+		pk.Double(y),
+		pk.Double(z),
+		pk.Boolean(onGround),
+	))
+}
+
+// sendPositionRotation sends a player position and rotation packet
+func (b *Bot) sendPositionRotation(x, y, z float64, yaw, pitch float32, onGround bool) error {
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundMovePlayerPosRot,
+		pk.Double(x),
+		pk.Double(y),
+		pk.Double(z),
+		pk.Float(yaw),
+		pk.Float(pitch),
+		pk.Boolean(onGround),
+	))
+}
+
+// sendRotation sends a player rotation packet
+func (b *Bot) sendRotation(yaw, pitch float32, onGround bool) error {
+	return b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundMovePlayerRot,
+		pk.Float(yaw),
+		pk.Float(pitch),
+		pk.Boolean(onGround),
+	))
+}
+
+// currentPosition returns the tracked player position
+func (b *Bot) currentPosition() (x, y, z float64) {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.x, b.y, b.z
+}
+
+// setPosition updates the tracked player position and rotation after the bot moved itself
+func (b *Bot) setPosition(x, y, z float64, yaw, pitch float32) {
+	b.stateMu.Lock()
+	dx, dy, dz := x-b.x, y-b.y, z-b.z
+	b.x, b.y, b.z = x, y, z
+	if yaw != b.yaw || pitch != b.pitch {
+		b.rotatedAt = time.Now()
+	}
+	b.yaw, b.pitch = yaw, pitch
+	b.stateMu.Unlock()
+
+	b.travel.add(dx, dy, dz)
+	b.travel.visit(blockPos{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))})
+}
+
+// lookAngles returns the yaw and pitch to look from one point to another
+func lookAngles(fromX, fromY, fromZ, toX, toY, toZ float64) (yaw, pitch float32) {
+	dx, dy, dz := toX-fromX, toY-fromY, toZ-fromZ
+	horizontal := math.Sqrt(dx*dx + dz*dz)
+	yaw = float32(-math.Atan2(dx, dz) * 180 / math.Pi)
+	pitch = float32(-math.Atan2(dy, horizontal) * 180 / math.Pi)
+	return yaw, pitch
+}
+
+// sprint starts or stops sprinting, walks go at sprinting speed meanwhile
+func (b *Bot) sprint(on bool) error {
+	action := stopSprint
+	if on {
+		action = startSprint
+	}
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundPlayerCommand,
+		pk.VarInt(b.player.EID),
+		pk.VarInt(action),
+		pk.VarInt(0),
+	)); err != nil {
+		return err
+	}
+	b.sprinting.Store(on)
+	return nil
+}
+
+// walkTo moves the bot in a straight line to the target at walking speed
+func (b *Bot) walkTo(ctx context.Context, x, y, z float64) error {
+	return b.walkWithin(ctx, x, y, z, arriveRadius)
+}
+
+// walkWithin moves the bot in a straight line towards the target until it is within radius
+func (b *Bot) walkWithin(ctx context.Context, x, y, z, radius float64) error {
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+
+	speed := walkSpeed
+	if b.sprinting.Load() {
+		speed = sprintSpeed
+	}
+	step := speed * tickDuration.Seconds()
+	walked, nextReport := 0.0, float64(progressEvery)
+	for {
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+		px, py, pz := b.currentPosition()
+		dx, dy, dz := x-px, y-py, z-pz
+		dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+		if dist <= radius+arriveSlack {
+			return nil
+		}
+
+		move := math.Min(step, dist-radius)
+		nx, ny, nz := px+dx/dist*move, py+dy/dist*move, pz+dz/dist*move
+		yaw, _ := lookAngles(px, py, pz, x, y, z)
+		if err := b.sendPositionRotation(nx, ny, nz, yaw, 0, true); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.setPosition(nx, ny, nz, yaw, 0)
+
+		walked += move
+		if walked >= nextReport {
+			b.log.Printf("🚶 Walked %.0f blocks, %.0f to go", walked, dist-move)
+			nextReport += progressEvery
+		}
+
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// approachBlock walks until the block is within mining reach of the bot's eyes
+func (b *Bot) approachBlock(ctx context.Context, pos blockPos) error {
+	cx, cy, cz := float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5
+	return b.walkWithin(ctx, cx, cy-eyeHeight, cz, miningReach-1)
+}
@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	gotoTimeout  = 10 * time.Minute // Longest an announced goto may take before it gives up
+	worldBorder  = 29_999_984       // Farthest block from the origin a player can reach
+	legMinGain   = 1.0              // Blocks a leg must bring the bot closer to the goal to keep going
+	gotoTaskName = "goto"
+)
+
+// gotoCommand matches "!goto <x> <y> <z>"
+var gotoCommand = regexp.MustCompile(`(?i)!goto\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+// worldHeight returns the lowest and highest block heights of the dimension the bot is in
+func (b *Bot) worldHeight() (minY, maxY int, ok bool) {
+	dim := b.client.Registries.DimensionType.GetByID(b.player.DimensionType)
+	if dim == nil {
+		return 0, 0, false
+	}
+	return int(dim.MinY), int(dim.MinY) + int(dim.Height) - 1, true
+}
+
+// validateGoal checks that a position is inside the world
+func (b *Bot) validateGoal(pos blockPos) error {
+	if pos.X < -worldBorder || pos.X > worldBorder || pos.Z < -worldBorder || pos.Z > worldBorder {
+		return fmt.Errorf("(%d, %d, %d) is beyond the world border", pos.X, pos.Y, pos.Z)
+	}
+	if minY, maxY, ok := b.worldHeight(); ok && (pos.Y < minY || pos.Y > maxY) {
+		return fmt.Errorf("y %d is outside the world, which spans %d to %d", pos.Y, minY, maxY)
+	}
+	return nil
+}
+
+// navigate walks to goal in legs, after riding a boat or minecart when water or rails lead
+// towards a far goal. Each leg follows a path through the loaded world, or when
+// the goal is out of reach the path to the explored position closest to it, so the chunks
+// further on load before the next leg. progress, when set, is called every progressEvery
+// blocks. When a leg brings the bot no closer it pillars up or digs a staircase down towards
+// the height of goal, and fails with a pathError once that doesn't help either.
+func (b *Bot) navigate(ctx context.Context, goal blockPos, opts pathOptions, progress func(walked, left float64)) error {
+	if _, err := b.fastTravel(ctx, goal); err != nil {
+		return err
+	}
+	walked, nextReport := 0.0, float64(progressEvery)
+	for {
+		start := b.feetBlock()
+		path, err := b.world.findPath(start, goal, arriveRadius, opts)
+		var stuck *pathError
+		if errors.As(err, &stuck) {
+			if distance(start, goal)-distance(stuck.Closest, goal) < legMinGain {
+				// No path up or down there, climb or dig to its height instead
+				moved, err := b.changeElevation(ctx, goal)
+				if err != nil {
+					return fmt.Errorf("%w, and changing height failed: %w", stuck, err)
+				}
+				if !moved {
+					return stuck
+				}
+				continue
+			}
+			path = stuck.Partial
+		} else if err != nil {
+			return err
+		}
+
+		b.highlightPath(path)
+		prev := start
+		for _, p := range path {
+			if err := b.stepTo(ctx, prev, p); err != nil {
+				return err
+			}
+			walked += distance(prev, p)
+			prev = p
+			if progress != nil && walked >= nextReport {
+				progress(walked, distance(p, goal))
+				nextReport += progressEvery
+			}
+		}
+		if stuck == nil {
+			return nil
+		}
+	}
+}
+
+// queueGoto queues walking to pos, keeping avoid blocks from hostile mobs. Announced gotos
+// report their progress and failure to player.
+func (b *Bot) queueGoto(pos blockPos, avoid float64, announce bool, player string) *task {
+	spec := &taskSpec{Kind: gotoTaskName, Pos: &pos, Avoid: avoid, Announce: announce, Player: player}
+	name := fmt.Sprintf("%s %d %d %d", gotoTaskName, pos.X, pos.Y, pos.Z)
+	return b.enqueueResumableTask(name, b.regionExposure(pos, pos), spec, func(ctx context.Context) error {
+		if !announce {
+			err := b.navigate(ctx, pos, b.mobAwareness(avoid), nil)
+			if errors.Is(err, errNoPath) && avoid == 0 {
+				// Outside the loaded world, walk straight there as before
+				return b.walkTo(ctx, float64(pos.X)+0.5, float64(pos.Y), float64(pos.Z)+0.5)
+			}
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, gotoTimeout)
+		defer cancel()
+		err := b.navigate(ctx, pos, b.mobAwareness(avoid), func(walked, left float64) {
+			b.replyTo(gotoTaskName, player, fmt.Sprintf("Walked %.0f blocks, %.0f to go", walked, left))
+		})
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s, %.0f blocks away", gotoTimeout, distance(b.feetBlock(), pos))
+		}
+		switch {
+		case err == nil:
+			b.replyTo(gotoTaskName, player, fmt.Sprintf("Arrived at (%d, %d, %d)", pos.X, pos.Y, pos.Z))
+		case !errors.Is(err, context.Canceled):
+			b.replyTo(gotoTaskName, player, fmt.Sprintf("Can't reach (%d, %d, %d): %v", pos.X, pos.Y, pos.Z, err))
+		}
+		return err
+	})
+}
+
+// handleGotoCommand queues walking to the coordinates of "!goto <x> <y> <z>"
+func (b *Bot) handleGotoCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := gotoCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !goto <x> <y> <z>")
+		return
+	}
+	var pos blockPos
+	for i, v := range []*int{&pos.X, &pos.Y, &pos.Z} {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			b.reply(msg, fmt.Sprintf("%s is not a coordinate", m[i+1]))
+			return
+		}
+		*v = n
+	}
+	if err := b.validateGoal(pos); err != nil {
+		b.reply(msg, fmt.Sprintf("Can't go there: %v", err))
+		return
+	}
+
+	b.queueGoto(pos, b.cfg.AvoidMobs, true, senderOf(msg))
+	b.reply(msg, fmt.Sprintf("Heading to (%d, %d, %d), %.0f blocks away", pos.X, pos.Y, pos.Z, distance(b.feetBlock(), pos)))
+}
@@ -0,0 +1,861 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"sync"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level/biome"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// The rewrites of the layout changes from 1.16.2 to 1.19.4, for the servers of before them
+
+// dimensionType is an entry of the dimension types a server of before 1.20.5 sends in its
+// login, for the dimension types of before 1.19 sent whole
+type dimensionType struct {
+	name    string
+	element map[string]any
+}
+
+// chatType is how a chat type of 1.19 to 1.19.2 decorates the messages of players
+type chatType struct {
+	name       string
+	key        string   // Translation key of the decoration, empty for messages shown as they are
+	parameters []string // What the translation is given, like sender and content
+}
+
+// Chat types of vanilla 1.19 the chat of before 1.19 becomes, and the position of a chat
+// message of before 1.19 above the hotbar
+const (
+	chatTypeSystem       = 1
+	chatTypeGameInfo     = 2
+	chatPositionGameInfo = 2
+)
+
+// Actions of a player info of before 1.19.3
+const (
+	playerInfoAdd = iota
+	playerInfoGameMode
+	playerInfoLatency
+	playerInfoDisplayName
+	playerInfoRemove
+)
+
+// sectionBiomes is the number of biomes of a chunk section, one for each 4x4x4 blocks
+const sectionBiomes = 4 * 4 * 4
+
+// chunkBitSet turns a chunk of 1.16 into one of 1.17, whose sections are masked by a bit set.
+// The sections updated apart from a whole chunk can't be merged with it and fail.
+func chunkBitSet(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			x, z pk.Int
+			full pk.Boolean
+			mask pk.VarInt
+		)
+		if e.skip(&x, &z, &full, &mask); e.err == nil && !full {
+			e.err = fmt.Errorf("chunk %d,%d carries some of its sections alone, which the bot can't merge", x, z)
+		}
+		e.write(x, z, pk.BitSet{int64(uint32(mask))})
+	})
+}
+
+// chunkWithLight turns a chunk of 1.17 into one of 1.18: every section is sent, air ones
+// included, with its biomes, the block entities say where they are and the light is left out
+func chunkWithLight(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			mask   pk.BitSet
+			biomes []pk.VarInt
+			data   pk.ByteArray
+			n      pk.VarInt
+		)
+		e.keep(new(pk.Int), new(pk.Int))
+		e.skip(&mask)
+		e.keepNamedNBT() // Heightmaps
+		if e.skip(pk.Array(&biomes), &data); e.err != nil {
+			return
+		}
+		sections, err := c.sectionsWithBiomes(mask, biomes, data)
+		if err != nil {
+			e.err = err
+			return
+		}
+		e.write(pk.ByteArray(sections))
+		e.skip(&n)
+		e.write(n)
+		for range max(n, 0) {
+			var entity struct {
+				ID string `nbt:"id"`
+				X  int32  `nbt:"x"`
+				Y  int32  `nbt:"y"`
+				Z  int32  `nbt:"z"`
+			}
+			raw := e.readNBT(true)
+			if e.err != nil {
+				return
+			}
+			if e.err = raw.Unmarshal(&entity); e.err != nil {
+				return
+			}
+			e.write(pk.UnsignedByte((entity.X&15)<<4|entity.Z&15), pk.Short(entity.Y), pk.VarInt(c.blockEntityType(entity.ID)))
+			e.writeNamedNBT(raw)
+		}
+		// No light, which comes in packets of its own
+		e.write(pk.Boolean(true), pk.BitSet(nil), pk.BitSet(nil), pk.BitSet(nil), pk.BitSet(nil), pk.VarInt(0), pk.VarInt(0))
+	})
+}
+
+// sectionsWithBiomes turns the sections of a chunk of 1.17, those in the mask alone, into all
+// the sections of 1.18 with their biomes
+func (c *protocolCodec) sectionsWithBiomes(mask pk.BitSet, biomes []pk.VarInt, data []byte) ([]byte, error) {
+	var index map[int32]int32
+	if p := c.biomes.Load(); p != nil {
+		index = *p
+	}
+	e := newPacketEditor(data)
+	for i := range len(biomes) / sectionBiomes {
+		if i < mask.Len() && mask.Get(i) {
+			e.keep(new(pk.Short)) // Non-air blocks
+			keepContainer(e, maxStatePaletteBits)
+		} else {
+			e.write(pk.Short(0), pk.UnsignedByte(0), pk.VarInt(0), pk.VarInt(0)) // All air
+		}
+		section := make([]uint64, sectionBiomes)
+		for j, id := range biomes[i*sectionBiomes : (i+1)*sectionBiomes] {
+			if v, ok := index[int32(id)]; ok {
+				id = pk.VarInt(v)
+			}
+			section[j] = uint64(max(id, 0))
+		}
+		writeBiomes(e, section)
+	}
+	return e.cut()
+}
+
+// writeBiomes writes the biomes of a section as a paletted container
+func writeBiomes(e *packetEditor, section []uint64) {
+	var palette []pk.VarInt
+	entries := make([]uint64, len(section))
+	for i, id := range section {
+		j := 0
+		for j < len(palette) && uint64(palette[j]) != id {
+			j++
+		}
+		if j == len(palette) {
+			palette = append(palette, pk.VarInt(id))
+		}
+		entries[i] = uint64(j)
+	}
+	switch width := bits.Len(uint(len(palette) - 1)); {
+	case len(palette) == 1:
+		e.write(pk.UnsignedByte(0), palette[0], pk.VarInt(0))
+	case width <= maxBiomePaletteBits:
+		e.write(pk.UnsignedByte(width), pk.Array(palette), pk.Array(packEntries(entries, width)))
+	default:
+		for i, id := range section {
+			if id >= 1<<biome.BitsPerBiome { // Beyond the bot's biomes
+				section[i] = 0
+			}
+		}
+		e.write(pk.UnsignedByte(biome.BitsPerBiome), pk.Array(packEntries(section, biome.BitsPerBiome)))
+	}
+}
+
+// loginSimulationDistance adds the simulation distance of 1.18 to the login, the view distance
+func loginSimulationDistance(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var view pk.VarInt
+		e.keep(new(pk.Int), new(pk.Boolean), new(pk.UnsignedByte), new(pk.Byte), pk.Array(new([]pk.Identifier)))
+		e.keepNamedNBT() // The registries
+		e.keepNamedNBT() // The dimension type
+		e.keep(new(pk.Identifier), new(pk.Long), new(pk.VarInt), &view)
+		e.write(view)
+	})
+}
+
+// loginDimensionName names the dimension type of the login, sent whole before 1.19, and adds
+// the death location of 1.19
+func loginDimensionName(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Int), new(pk.Boolean), new(pk.UnsignedByte), new(pk.Byte), pk.Array(new([]pk.Identifier)))
+		e.keepNamedNBT() // The registries
+		c.dimensionTypeName(e)
+		e.keep(new(pk.Identifier), new(pk.Long), new(pk.VarInt), new(pk.VarInt), new(pk.VarInt))
+		e.keep(new(pk.Boolean), new(pk.Boolean), new(pk.Boolean), new(pk.Boolean))
+		e.write(pk.Boolean(false)) // No death location
+	})
+}
+
+// respawnDimensionName names the dimension type of a respawn, sent whole before 1.19, and adds
+// the death location of 1.19
+func respawnDimensionName(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		c.dimensionTypeName(e)
+		e.keep(new(pk.Identifier), new(pk.Long), new(pk.UnsignedByte), new(pk.Byte), new(pk.Boolean), new(pk.Boolean), new(pk.Boolean))
+		e.write(pk.Boolean(false))
+	})
+}
+
+// dimensionTypeName turns a dimension type sent whole into its name in the registry. Plugins
+// may send one the registry lacks, which goes by the registry's first of the same height.
+func (c *protocolCodec) dimensionTypeName(e *packetEditor) {
+	raw := e.readNBT(true)
+	if e.err != nil {
+		return
+	}
+	var element map[string]any
+	if e.err = raw.Unmarshal(&element); e.err != nil {
+		return
+	}
+	var types []dimensionType
+	if p := c.dimensionTypes.Load(); p != nil {
+		types = *p
+	}
+	for _, t := range types {
+		if reflect.DeepEqual(t.element, element) {
+			e.write(pk.Identifier(t.name))
+			return
+		}
+	}
+	for _, t := range types {
+		if t.element["effects"] == element["effects"] && t.element["min_y"] == element["min_y"] && t.element["height"] == element["height"] {
+			e.write(pk.Identifier(t.name))
+			return
+		}
+	}
+	e.err = errors.New("the dimension type is none of the registry's")
+}
+
+// chatAsSystem turns a chat message of before 1.19 into a system message, which is what the
+// bot reads of them
+func chatAsSystem(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var position pk.Byte
+	e.keep(new(pk.String))
+	e.skip(&position)
+	kind := pk.VarInt(chatTypeSystem)
+	if position == chatPositionGameInfo {
+		kind = chatTypeGameInfo
+	}
+	e.write(kind)
+	data, err := e.cut() // Without the sender
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// systemChatOverlay turns the chat type of a system message of 1.19 into whether it shows above
+// the hotbar
+func systemChatOverlay(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var kind pk.VarInt
+		e.keep(new(pk.String))
+		e.skip(&kind)
+		e.write(pk.Boolean(c.gameInfo(int32(kind))))
+	})
+}
+
+// gameInfo reports whether the chat type id shows above the hotbar
+func (c *protocolCodec) gameInfo(id int32) bool {
+	if p := c.chatTypes.Load(); p != nil {
+		return (*p)[id].name == "minecraft:game_info"
+	}
+	return id == chatTypeGameInfo
+}
+
+// unchainedChatAsSystem turns a player chat message of 1.19 into the system message it shows as,
+// decorated by its chat type. The bot doesn't check signatures.
+func unchainedChatAsSystem(c *protocolCodec, p *pk.Packet) error {
+	var (
+		signed, unsigned, sender, team pk.String
+		hasUnsigned, hasTeam           pk.Boolean
+		kind                           pk.VarInt
+	)
+	e := newPacketEditor(p.Data)
+	e.skip(&signed)
+	if e.skip(&hasUnsigned); hasUnsigned {
+		e.skip(&unsigned)
+	} else {
+		unsigned = signed
+	}
+	e.skip(&kind, new(pk.UUID), &sender)
+	if e.skip(&hasTeam); hasTeam {
+		e.skip(&team)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	text, err := c.decorate(int32(kind), map[string]string{"sender": string(sender), "content": string(unsigned), "team_name": string(team)})
+	if err != nil {
+		return err
+	}
+	p.Data = pk.Marshal(0, pk.String(text), pk.Boolean(false)).Data
+	return nil
+}
+
+// chainedChatAsSystem turns a player chat message of 1.19.1 and 1.19.2 into the system message
+// it shows as, decorated by its chat type
+func chainedChatAsSystem(c *protocolCodec, p *pk.Packet) error {
+	var (
+		hasPrevious, hasFormatted, hasUnsigned, hasTarget pk.Boolean
+		plain, formatted, unsigned, name, target          pk.String
+		previous, filter, kind                            pk.VarInt
+	)
+	e := newPacketEditor(p.Data)
+	if e.skip(&hasPrevious); hasPrevious {
+		e.skip(new(pk.ByteArray))
+	}
+	e.skip(new(pk.UUID), new(pk.ByteArray), &plain)
+	if e.skip(&hasFormatted); hasFormatted {
+		e.skip(&formatted)
+	}
+	e.skip(new(pk.Long), new(pk.Long), &previous)
+	for range max(previous, 0) {
+		e.skip(new(pk.UUID), new(pk.ByteArray))
+	}
+	if e.skip(&hasUnsigned); hasUnsigned {
+		e.skip(&unsigned)
+	}
+	if e.skip(&filter); filter == 2 { // Partially filtered
+		e.skip(new(pk.BitSet))
+	}
+	e.skip(&kind, &name)
+	if e.skip(&hasTarget); hasTarget {
+		e.skip(&target)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	content := string(unsigned)
+	if !hasUnsigned && hasFormatted {
+		content = string(formatted)
+	} else if !hasUnsigned {
+		text, err := json.Marshal(struct {
+			Text string `json:"text"`
+		}{string(plain)})
+		if err != nil {
+			return err
+		}
+		content = string(text)
+	}
+	text, err := c.decorate(int32(kind), map[string]string{"sender": string(name), "content": content, "target": string(target)})
+	if err != nil {
+		return err
+	}
+	p.Data = pk.Marshal(0, pk.String(text), pk.Boolean(false)).Data
+	return nil
+}
+
+// decorate returns a message of the chat type id as the text component the client shows, given
+// the text components of its parameters in JSON
+func (c *protocolCodec) decorate(id int32, params map[string]string) (string, error) {
+	var t chatType
+	if p := c.chatTypes.Load(); p != nil {
+		t = (*p)[id]
+	}
+	if t.key == "" {
+		return params["content"], nil
+	}
+	with := make([]json.RawMessage, len(t.parameters))
+	for i, name := range t.parameters {
+		if with[i] = json.RawMessage(params[name]); len(with[i]) == 0 {
+			with[i] = json.RawMessage(`""`)
+		}
+	}
+	text, err := json.Marshal(struct {
+		Translate string            `json:"translate"`
+		With      []json.RawMessage `json:"with"`
+	}{t.key, with})
+	return string(text), err
+}
+
+// addEntityHeadYaw turns the spawn of an entity of before 1.19 into one with its head yaw, its
+// data a VarInt
+func addEntityHeadYaw(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			yaw  pk.Angle
+			data pk.Int
+		)
+		e.keep(new(pk.VarInt), new(pk.UUID), new(pk.VarInt), new(pk.Double), new(pk.Double), new(pk.Double), new(pk.Angle), &yaw)
+		e.skip(&data)
+		e.write(yaw, pk.VarInt(data))
+	})
+}
+
+// addMobAsEntity turns the spawn of a mob of before 1.19 into that of an entity
+func addMobAsEntity(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var yaw, pitch, head pk.Angle
+		e.keep(new(pk.VarInt), new(pk.UUID), new(pk.VarInt), new(pk.Double), new(pk.Double), new(pk.Double))
+		e.skip(&yaw, &pitch, &head)
+		e.write(pitch, yaw, head, pk.VarInt(0))
+	})
+}
+
+// mobEffectVarInt turns the effect of a mob effect of before 1.19 into a VarInt, without the
+// factor data of 1.19
+func mobEffectVarInt(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var effect pk.Byte
+		e.keep(new(pk.VarInt))
+		e.skip(&effect)
+		e.write(pk.VarInt(effect))
+		e.keep(new(pk.Byte), new(pk.VarInt), new(pk.Byte))
+		e.write(pk.Boolean(false))
+	})
+}
+
+// removeMobEffectVarInt turns the effect of a removed mob effect of before 1.19 into a VarInt
+func removeMobEffectVarInt(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var effect pk.Byte
+		e.keep(new(pk.VarInt))
+		e.skip(&effect)
+		e.write(pk.VarInt(effect))
+	})
+}
+
+// breakAckAsBlockUpdate turns the acknowledgement of a block action of before 1.19, which
+// states the block, into a block update
+func breakAckAsBlockUpdate(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.Position), new(pk.VarInt))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// keepProperties copies the properties of a player's profile
+func keepProperties(e *packetEditor) {
+	var n pk.VarInt
+	e.keep(&n)
+	for range max(n, 0) {
+		var signed pk.Boolean
+		if e.keep(new(pk.String), new(pk.String), &signed); signed {
+			e.keep(new(pk.String))
+		}
+	}
+}
+
+// keepOptionalString copies a string that may be left out
+func keepOptionalString(e *packetEditor) {
+	var has pk.Boolean
+	if e.keep(&has); has {
+		e.keep(new(pk.String))
+	}
+}
+
+// playerInfoSignatureData adds the empty signature data of 1.19 to the players a player info adds
+func playerInfoSignatureData(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var action, n pk.VarInt
+		if e.keep(&action, &n); action != playerInfoAdd {
+			return
+		}
+		for range max(n, 0) {
+			e.keep(new(pk.UUID), new(pk.String))
+			keepProperties(e)
+			e.keep(new(pk.VarInt), new(pk.VarInt))
+			keepOptionalString(e)
+			e.write(pk.Boolean(false))
+		}
+	})
+}
+
+// playerInfoAsUpdate turns a player info of before 1.19.3 into the player info update of
+// 1.19.3, or the removal of players into a packet of its own
+func playerInfoAsUpdate(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var action, n pk.VarInt
+	if e.skip(&action, &n); e.err != nil {
+		return e.err
+	}
+	if action == playerInfoRemove {
+		players := make([]pk.UUID, max(n, 0))
+		for i := range players {
+			e.skip(&players[i])
+		}
+		if e.err != nil {
+			return e.err
+		}
+		p.ID = int32(packetid.ClientboundPlayerInfoRemove)
+		p.Data = pk.Marshal(0, pk.Array(players)).Data
+		return nil
+	}
+	actions, ok := map[pk.VarInt]pk.Byte{
+		playerInfoAdd:         infoAddPlayer | infoGameMode | infoListed | infoLatency | infoDisplayName,
+		playerInfoGameMode:    infoGameMode,
+		playerInfoLatency:     infoLatency,
+		playerInfoDisplayName: infoDisplayName,
+	}[action]
+	if !ok {
+		return fmt.Errorf("unknown player info action %d", action)
+	}
+	e.write(actions, n)
+	for range max(n, 0) {
+		e.keep(new(pk.UUID))
+		switch action {
+		case playerInfoAdd:
+			var (
+				gameMode, latency pk.VarInt
+				signed            pk.Boolean
+			)
+			e.keep(new(pk.String))
+			keepProperties(e)
+			e.skip(&gameMode, &latency)
+			e.write(gameMode, pk.Boolean(true), latency)
+			keepOptionalString(e)
+			if e.skip(&signed); signed {
+				e.skip(new(pk.Long), new(pk.ByteArray), new(pk.ByteArray))
+			}
+		case playerInfoGameMode, playerInfoLatency:
+			e.keep(new(pk.VarInt))
+		case playerInfoDisplayName:
+			keepOptionalString(e)
+		}
+	}
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// soundHolder turns the sound of a sound packet into the registry ID plus one of 1.19.3, where
+// 0 is an inline sound
+func soundHolder(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var id pk.VarInt
+		e.skip(&id)
+		e.write(id + 1)
+	})
+}
+
+// entityAsList turns the removal of an entity of 1.17 into a list of one
+func entityAsList(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.write(pk.VarInt(1))
+	})
+}
+
+// dropDismount removes the flag of the player's position telling it to leave its vehicle
+func dropDismount(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.Double), new(pk.Double), new(pk.Double), new(pk.Float), new(pk.Float), new(pk.Byte), new(pk.VarInt))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// mapIconsOptional turns the map data of 1.16 into that of 1.17, whose icons may be left out,
+// dropping the flag telling whether it tracks positions
+func mapIconsOptional(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.VarInt), new(pk.Byte))
+		e.skip(new(pk.Boolean))
+		e.keep(new(pk.Boolean))
+		e.write(pk.Boolean(true))
+	})
+}
+
+// oldClicks keeps what the clicks of a server of before 1.17 carry besides the bot's clicks:
+// the item stack the clicked slot held, as the server sent it, and the number of the click the
+// server confirms
+type oldClicks struct {
+	mu     sync.Mutex
+	stacks map[slotKey][]byte // Item stacks as sent, by window and slot
+	action int16
+}
+
+// slotKey is a slot of a window
+type slotKey struct {
+	window int32
+	slot   int16
+}
+
+// emptyOldSlot is an empty item stack of before 1.20.5
+var emptyOldSlot = []byte{0}
+
+// setAll notes the item stacks of all the slots of a window
+func (o *oldClicks) setAll(window int32, stacks [][]byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for k := range o.stacks {
+		if k.window == window {
+			delete(o.stacks, k)
+		}
+	}
+	for i, stack := range stacks {
+		o.setLocked(window, int16(i), stack)
+	}
+}
+
+// set notes the item stack of a slot
+func (o *oldClicks) set(window int32, slot int16, stack []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.setLocked(window, slot, stack)
+}
+
+func (o *oldClicks) setLocked(window int32, slot int16, stack []byte) {
+	if o.stacks == nil {
+		o.stacks = make(map[slotKey][]byte)
+	}
+	o.stacks[slotKey{window, slot}] = stack
+}
+
+// click returns the item stack the click of mode on a slot reports and the click's number, and
+// notes the item stacks it changed
+func (o *oldClicks) click(window int32, slot int16, mode int32, changed map[int16][]byte) ([]byte, int16) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	clicked := emptyOldSlot
+	switch mode {
+	case 0, 1, 3, clickModeThrow: // Picking up, moving, cloning and throwing report the stack
+		if stack, ok := o.stacks[slotKey{window, slot}]; ok {
+			clicked = stack
+		}
+	}
+	for s, stack := range changed {
+		o.setLocked(window, s, stack)
+	}
+	o.action++
+	return clicked, o.action
+}
+
+// skipOldSlot reads an item stack of before 1.20.5, returning it as sent
+func (e *packetEditor) skipOldSlot() []byte {
+	start := len(e.data) - e.r.Len()
+	var present pk.Boolean
+	if e.skip(&present); present {
+		e.skip(new(pk.VarInt), new(pk.Byte))
+		e.readNBT(true)
+	}
+	if e.err != nil {
+		return nil
+	}
+	return bytes.Clone(e.data[start : len(e.data)-e.r.Len()])
+}
+
+// noteContainerContent notes the item stacks of a container of 1.16, which its clicks carry
+func noteContainerContent(c *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var (
+		window pk.UnsignedByte
+		n      pk.Short
+	)
+	e.skip(&window, &n)
+	stacks := make([][]byte, max(n, 0))
+	for i := range stacks {
+		stacks[i] = e.skipOldSlot()
+	}
+	if e.err != nil {
+		return e.err
+	}
+	c.clicks.setAll(int32(window), stacks)
+	return nil
+}
+
+// noteContainerSlot notes the item stack of a container slot of 1.16
+func noteContainerSlot(c *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var (
+		window pk.Byte
+		slot   pk.Short
+	)
+	e.skip(&window, &slot)
+	stack := e.skipOldSlot()
+	if e.err != nil {
+		return e.err
+	}
+	c.clicks.set(int32(window), int16(slot), stack)
+	return nil
+}
+
+// contentStateID turns the content of a container of 1.17 into that of 1.17.1, numbered by
+// its state and with the stack the player carries, none
+func contentStateID(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.Short
+		e.keep(new(pk.UnsignedByte))
+		e.skip(&n)
+		e.write(pk.VarInt(0), pk.VarInt(n))
+		e.keepBytes(e.r.Len())
+		e.write(pk.Boolean(false))
+	})
+}
+
+// slotStateID adds the state number of 1.17.1 to a container slot
+func slotStateID(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Byte))
+		e.write(pk.VarInt(0))
+	})
+}
+
+// dropStateID removes the state number of a click, which servers of 1.17 don't know
+func dropStateID(_ *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.VarInt))
+		e.skip(new(pk.VarInt))
+	})
+}
+
+// clickWithAction turns a click of 1.17, saying what it changed, into one of 1.16 carrying its
+// number and the stack it clicked, which the server confirms by that number
+func clickWithAction(c *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	var (
+		window, mode, n pk.VarInt
+		slot            pk.Short
+		button          pk.Byte
+	)
+	e.skip(&window, &slot, &button, &mode, &n)
+	changed := make(map[int16][]byte, max(n, 0))
+	for range max(n, 0) {
+		var s pk.Short
+		e.skip(&s)
+		changed[int16(s)] = e.skipOldSlot()
+	}
+	if e.err != nil {
+		return e.err
+	}
+	clicked, action := c.clicks.click(int32(window), int16(slot), int32(mode), changed)
+	var data bytes.Buffer
+	if _, err := (pk.Tuple{pk.UnsignedByte(window), slot, button, pk.Short(action), mode}).WriteTo(&data); err != nil {
+		return err
+	}
+	data.Write(clicked)
+	p.Data = data.Bytes()
+	return nil
+}
+
+// answerRejectedClick confirms a click of 1.16 the server rejected, which it waits for before
+// taking the next ones
+func answerRejectedClick(c *protocolCodec, p pk.Packet) error {
+	var (
+		window   pk.Byte
+		action   pk.Short
+		accepted pk.Boolean
+	)
+	if err := p.Scan(&window, &action, &accepted); err != nil {
+		return err
+	}
+	if accepted {
+		return nil
+	}
+	return c.reply("container_ack", window, action, pk.Boolean(true))
+}
+
+// dropLastBoolean removes the flag a packet ends with
+func dropLastBoolean(_ *protocolCodec, p *pk.Packet) error {
+	if len(p.Data) == 0 {
+		return errors.New("empty packet")
+	}
+	p.Data = p.Data[:len(p.Data)-1]
+	return nil
+}
+
+// chatMessageOnly leaves a chat message with its text alone, as sent before 1.19
+func chatMessageOnly(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.String))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// commandAsChat turns a command into the chat message starting with a slash it was sent as
+// before 1.19
+func commandAsChat(_ *protocolCodec, p *pk.Packet) error {
+	var command pk.String
+	if _, err := command.ReadFrom(bytes.NewReader(p.Data)); err != nil {
+		return err
+	}
+	p.ID = int32(packetid.ServerboundChat)
+	p.Data = pk.Marshal(0, "/"+command).Data
+	return nil
+}
+
+// dropLastSeen turns a chat message or command of 1.19.1 into one of 1.19, without the messages
+// it acknowledges. The bot signs neither.
+func dropLastSeen(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.String), new(pk.Long), new(pk.Long), new(pk.ByteArray), new(pk.Boolean))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// unsignedLastSeen turns a chat message or command of 1.19.3 into an unsigned one of 1.19.1,
+// acknowledging no messages
+func unsignedLastSeen(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.String), new(pk.Long), new(pk.Long))
+	e.write(pk.VarInt(0), pk.Boolean(false), pk.VarInt(0), pk.Boolean(false))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// dropDigSequence removes the sequence number of 1.19 from a player action
+func dropDigSequence(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.VarInt), new(pk.Position), new(pk.Byte))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// dropUseOnSequence removes the sequence number of 1.19 from using an item on a block
+func dropUseOnSequence(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.VarInt), new(pk.Position), new(pk.VarInt), new(pk.Float), new(pk.Float), new(pk.Float), new(pk.Boolean))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// dropUseSequence removes the sequence number of 1.19 from using an item
+func dropUseSequence(_ *protocolCodec, p *pk.Packet) error {
+	e := newPacketEditor(p.Data)
+	e.keep(new(pk.VarInt))
+	data, err := e.cut()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
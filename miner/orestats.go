@@ -0,0 +1,198 @@
+package miner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const oreRateWindow = time.Hour // Rolling window for ore-per-hour rates
+
+// oreItems are the items counted as ore yield
+var oreItems = map[string]bool{
+	"minecraft:coal":           true,
+	"minecraft:raw_iron":       true,
+	"minecraft:raw_copper":     true,
+	"minecraft:raw_gold":       true,
+	"minecraft:gold_nugget":    true,
+	"minecraft:redstone":       true,
+	"minecraft:lapis_lazuli":   true,
+	"minecraft:diamond":        true,
+	"minecraft:emerald":        true,
+	"minecraft:quartz":         true,
+	"minecraft:ancient_debris": true,
+}
+
+// oreKey identifies one aggregation bucket
+type oreKey struct {
+	Strategy string
+	Region   string
+}
+
+type oreSample struct {
+	Time  time.Time
+	Count int
+}
+
+// oreBucket holds the yield samples of one strategy and region
+type oreBucket struct {
+	Since   time.Time
+	Total   int
+	Samples []oreSample
+}
+
+// oreRate is the serializable view of a bucket
+type oreRate struct {
+	Strategy string  `json:"strategy"`
+	Region   string  `json:"region"`
+	Total    int     `json:"total"`
+	PerHour  float64 `json:"per_hour"`
+}
+
+// oreStats aggregates the ore yield of one bot
+type oreStats struct {
+	mu      sync.Mutex
+	buckets map[oreKey]*oreBucket
+}
+
+// isOreItem reports whether an item counts as ore yield
+func isOreItem(name string) bool {
+	return oreItems[name] || strings.HasSuffix(name, "_ore")
+}
+
+// currentStrategy names the strategy the gains are attributed to: the kind of the running task
+func (b *Bot) currentStrategy() string {
+	if kind := b.tasks.currentKind(); kind != "" {
+		return kind
+	}
+	return "idle"
+}
+
+// currentRegion names the chunk the gains are attributed to: the quarry chunk the bot claimed,
+// or else the one it stands in
+func (b *Bot) currentRegion() string {
+	c, ok := chunkPos{}, false
+	if b.swarm != nil {
+		c, ok = b.swarm.activeChunk(b)
+	}
+	if !ok {
+		b.stateMu.RLock()
+		c = chunkPos{X: int(math.Floor(b.x / 16)), Z: int(math.Floor(b.z / 16))}
+		b.stateMu.RUnlock()
+	}
+	return fmt.Sprintf("chunk %d,%d", c.X, c.Z)
+}
+
+// recordOreGains aggregates ore gains from inventory_changed events
+func (b *Bot) recordOreGains(change inventoryChange) {
+	gained := 0
+	for _, c := range change.Changes {
+		if c.Delta > 0 && isOreItem(c.Item) {
+			gained += c.Delta
+		}
+	}
+	if gained == 0 {
+		return
+	}
+
+	key := oreKey{Strategy: b.currentStrategy(), Region: b.currentRegion()}
+	b.ores.add(key, time.Now(), gained)
+}
+
+// add records a yield sample in the bucket of key and drops those that left the window
+func (o *oreStats) add(key oreKey, t time.Time, count int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.buckets == nil {
+		o.buckets = make(map[oreKey]*oreBucket)
+	}
+	bucket, ok := o.buckets[key]
+	if !ok {
+		bucket = &oreBucket{Since: t}
+		o.buckets[key] = bucket
+	}
+	bucket.Total += count
+	bucket.Samples = append(bucket.Samples, oreSample{Time: t, Count: count})
+	bucket.trim(t)
+}
+
+// trim drops the samples that left the window at now
+func (b *oreBucket) trim(now time.Time) {
+	i := 0
+	for i < len(b.Samples) && now.Sub(b.Samples[i].Time) > oreRateWindow {
+		i++
+	}
+	b.Samples = b.Samples[i:]
+}
+
+// restore carries over the totals of a previous run. Their rates start again from zero.
+func (o *oreStats) restore(rates []oreRate) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.buckets == nil {
+		o.buckets = make(map[oreKey]*oreBucket)
+	}
+	for _, r := range rates {
+		key := oreKey{Strategy: r.Strategy, Region: r.Region}
+		if o.buckets[key] == nil {
+			o.buckets[key] = &oreBucket{Since: time.Now()}
+		}
+		o.buckets[key].Total += r.Total
+	}
+}
+
+// rates returns the rolling rate of every bucket, highest first
+func (o *oreStats) rates() []oreRate {
+	now := time.Now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	rates := []oreRate{}
+	for key, b := range o.buckets {
+		b.trim(now)
+
+		inWindow := 0
+		for _, s := range b.Samples {
+			inWindow += s.Count
+		}
+		// Buckets younger than the window are rated over their lifetime
+		span := min(now.Sub(b.Since), oreRateWindow)
+		if span < time.Minute {
+			span = time.Minute
+		}
+
+		rates = append(rates, oreRate{
+			Strategy: key.Strategy,
+			Region:   key.Region,
+			Total:    b.Total,
+			PerHour:  float64(inWindow) / span.Hours(),
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].PerHour > rates[j].PerHour })
+	return rates
+}
+
+// handleStatsCommand replies with the session's statistics and the best ore rates, and the money
+// earned when the economy is enabled
+func (b *Bot) handleStatsCommand(msg string) {
+	b.reply(msg, b.sessionSummary())
+	var earned string
+	if b.cfg.Economy.Enabled {
+		earned = fmt.Sprintf(", $%.2f earned selling", b.economy.snapshot().Earned)
+	}
+	rates := b.ores.rates()
+	if len(rates) == 0 {
+		b.reply(msg, "No ore mined yet"+earned)
+		return
+	}
+
+	parts := make([]string, 0, 3)
+	for _, r := range rates[:min(3, len(rates))] {
+		parts = append(parts, fmt.Sprintf("%s @ %s: %.1f/h (%d)", r.Strategy, r.Region, r.PerHour, r.Total))
+	}
+	b.reply(msg, "Ore rates: "+strings.Join(parts, ", ")+earned)
+}
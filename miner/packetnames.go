@@ -0,0 +1,66 @@
+package miner
+
+// playClientbound names the play packets the server sends, in the order of their go-mc IDs. The
+// names are those of the vanilla packets report without the minecraft: namespace.
+var playClientbound = []string{
+	"bundle_delimiter", "add_entity", "add_experience_orb", "animate", "award_stats",
+	"block_changed_ack", "block_destruction", "block_entity_data", "block_event", "block_update",
+	"boss_event", "change_difficulty", "chunk_batch_finished", "chunk_batch_start", "chunks_biomes",
+	"clear_titles", "command_suggestions", "commands", "container_close", "container_set_content",
+	"container_set_data", "container_set_slot", "cookie_request", "cooldown",
+	"custom_chat_completions", "custom_payload", "damage_event", "debug_sample", "delete_chat",
+	"disconnect", "disguised_chat", "entity_event", "explode", "forget_level_chunk", "game_event",
+	"horse_screen_open", "hurt_animation", "initialize_border", "keep_alive",
+	"level_chunk_with_light", "level_event", "level_particles", "light_update", "login",
+	"map_item_data", "merchant_offers", "move_entity_pos", "move_entity_pos_rot", "move_entity_rot",
+	"move_vehicle", "open_book", "open_screen", "open_sign_editor", "ping", "pong_response",
+	"place_ghost_recipe", "player_abilities", "player_chat", "player_combat_end",
+	"player_combat_enter", "player_combat_kill", "player_info_remove", "player_info_update",
+	"player_look_at", "player_position", "recipe", "remove_entities", "remove_mob_effect",
+	"reset_score", "resource_pack_pop", "resource_pack_push", "respawn", "rotate_head",
+	"section_blocks_update", "select_advancements_tab", "server_data", "set_action_bar_text",
+	"set_border_center", "set_border_lerp_size", "set_border_size", "set_border_warning_delay",
+	"set_border_warning_distance", "set_camera", "set_carried_item", "set_chunk_cache_center",
+	"set_chunk_cache_radius", "set_default_spawn_position", "set_display_objective",
+	"set_entity_data", "set_entity_link", "set_entity_motion", "set_equipment", "set_experience",
+	"set_health", "set_objective", "set_passengers", "set_player_team", "set_score",
+	"set_simulation_distance", "set_subtitle_text", "set_time", "set_title_text",
+	"set_titles_animation", "sound_entity", "sound", "start_configuration", "stop_sound",
+	"store_cookie", "system_chat", "tab_list", "tag_query", "take_item_entity", "teleport_entity",
+	"ticking_state", "ticking_step", "transfer", "update_advancements", "update_attributes",
+	"update_mob_effect", "update_recipes", "update_tags", "projectile_power", "custom_report_details",
+	"server_links",
+}
+
+// playServerbound names the play packets the bot sends, in the order of their go-mc IDs
+var playServerbound = []string{
+	"accept_teleportation", "block_entity_tag_query", "change_difficulty", "chat_ack", "chat_command",
+	"chat_command_signed", "chat", "chat_session_update", "chunk_batch_received", "client_command",
+	"client_information", "command_suggestion", "configuration_acknowledged",
+	"container_button_click", "container_click", "container_close", "container_slot_state_changed",
+	"cookie_response", "custom_payload", "debug_sample_subscription", "edit_book", "entity_tag_query",
+	"interact", "jigsaw_generate", "keep_alive", "lock_difficulty", "move_player_pos",
+	"move_player_pos_rot", "move_player_rot", "move_player_status_only", "move_vehicle",
+	"paddle_boat", "pick_item", "ping_request", "place_recipe", "player_abilities", "player_action",
+	"player_command", "player_input", "pong", "recipe_book_change_settings",
+	"recipe_book_seen_recipe", "rename_item", "resource_pack", "seen_advancements", "select_trade",
+	"set_beacon", "set_carried_item", "set_command_block", "set_command_minecart",
+	"set_creative_mode_slot", "set_jigsaw_block", "set_structure_block", "sign_update", "swing",
+	"teleport_to_entity", "use_item_on", "use_item",
+}
+
+// configClientbound names the configuration packets the server sends, in the order of their
+// go-mc IDs
+var configClientbound = []string{
+	"cookie_request", "custom_payload", "disconnect", "finish_configuration", "keep_alive", "ping",
+	"reset_chat", "registry_data", "resource_pack_pop", "resource_pack_push", "store_cookie",
+	"transfer", "update_enabled_features", "update_tags", "select_known_packs",
+	"custom_report_details", "server_links",
+}
+
+// configServerbound names the configuration packets the bot sends, in the order of their go-mc
+// IDs
+var configServerbound = []string{
+	"client_information", "cookie_response", "custom_payload", "finish_configuration",
+	"keep_alive", "pong", "resource_pack", "select_known_packs",
+}
@@ -0,0 +1,280 @@
+package miner
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	pathMaxNodes = 20000 // Nodes expanded before a search gives up
+	pathMaxDrop  = 3     // Deepest fall a path may take without fall damage
+)
+
+// errNoPath is returned when the goal cannot be reached through the loaded world
+var errNoPath = errors.New("no path found")
+
+// pathError is errNoPath with how far the search got
+type pathError struct {
+	Closest blockPos   // Explored position closest to the goal
+	Partial []blockPos // Path to Closest
+	Reason  string     // Why the search ended early, empty when it ran out of places to go
+}
+
+func (e *pathError) Error() string {
+	msg := fmt.Sprintf("no path found, blocked at (%d, %d, %d)", e.Closest.X, e.Closest.Y, e.Closest.Z)
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
+
+// Is makes a pathError match errNoPath
+func (e *pathError) Is(target error) bool {
+	return target == errNoPath
+}
+
+// passableBlocks can be walked through in addition to air
+var passableBlocks = map[string]bool{
+	"minecraft:short_grass":   true,
+	"minecraft:tall_grass":    true,
+	"minecraft:fern":          true,
+	"minecraft:large_fern":    true,
+	"minecraft:dead_bush":     true,
+	"minecraft:torch":         true,
+	"minecraft:wall_torch":    true,
+	"minecraft:snow":          true,
+	"minecraft:dandelion":     true,
+	"minecraft:poppy":         true,
+	"minecraft:vine":          true,
+	"minecraft:rail":          true,
+	"minecraft:redstone_wire": true,
+	"minecraft:sugar_cane":    true,
+}
+
+// passable reports whether the bot can stand inside the block at pos.
+// Unloaded chunks count as blocked so paths stay in the known world.
+func (w *worldModel) passable(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	if !ok {
+		return false
+	}
+	if block.IsAir(state) {
+		return true
+	}
+	name := blockName(state)
+	return passableBlocks[name] || strings.HasSuffix(name, "_sapling") || strings.HasSuffix(name, "_carpet")
+}
+
+// solid reports whether the block at pos can be stood on
+func (w *worldModel) solid(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	if !ok || block.IsAir(state) {
+		return false
+	}
+	switch name := blockName(state); name {
+	case "minecraft:water", "minecraft:lava", "minecraft:magma_block", "minecraft:cactus":
+		return false
+	default:
+		return !passableBlocks[name] || name == "minecraft:snow"
+	}
+}
+
+// standable reports whether the bot fits at pos with ground under its feet
+func (w *worldModel) standable(pos blockPos) bool {
+	return w.passable(pos) &&
+		w.passable(blockPos{X: pos.X, Y: pos.Y + 1, Z: pos.Z}) &&
+		w.solid(blockPos{X: pos.X, Y: pos.Y - 1, Z: pos.Z})
+}
+
+// pathNode is a position in the A* open set
+type pathNode struct {
+	pos    blockPos
+	cost   float64 // Cost from the start
+	score  float64 // cost + heuristic
+	placed int     // Scaffolding blocks the path placed so far
+	index  int
+}
+
+type pathQueue []*pathNode
+
+func (q pathQueue) Len() int           { return len(q) }
+func (q pathQueue) Less(i, j int) bool { return q[i].score < q[j].score }
+func (q pathQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *pathQueue) Push(x any) {
+	n := x.(*pathNode)
+	n.index = len(*q)
+	*q = append(*q, n)
+}
+func (q *pathQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// distance is the straight-line distance between two block positions
+func distance(a, b blockPos) float64 {
+	dx, dy, dz := float64(a.X-b.X), float64(a.Y-b.Y), float64(a.Z-b.Z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// neighbors returns the positions reachable in one step from pos and what each step costs.
+// Steps are walking to an adjacent or diagonal block, stepping up one block, or dropping up to pathMaxDrop.
+// With bridge, a straight step may also cross a gap on a block placed under it.
+func (w *worldModel) neighbors(pos blockPos, bridge bool) (next []blockPos, costs []float64) {
+	head := blockPos{X: pos.X, Y: pos.Y + 2, Z: pos.Z}
+	for dx := -1; dx <= 1; dx++ {
+		for dz := -1; dz <= 1; dz++ {
+			if dx == 0 && dz == 0 {
+				continue
+			}
+			step := 1.0
+			if dx != 0 && dz != 0 {
+				// No cutting corners through walls
+				if !w.passable(blockPos{X: pos.X + dx, Y: pos.Y, Z: pos.Z}) || !w.passable(blockPos{X: pos.X + dx, Y: pos.Y + 1, Z: pos.Z}) ||
+					!w.passable(blockPos{X: pos.X, Y: pos.Y, Z: pos.Z + dz}) || !w.passable(blockPos{X: pos.X, Y: pos.Y + 1, Z: pos.Z + dz}) {
+					continue
+				}
+				step = math.Sqrt2
+			}
+
+			flat := blockPos{X: pos.X + dx, Y: pos.Y, Z: pos.Z + dz}
+			switch {
+			case w.standable(flat):
+				next, costs = append(next, flat), append(costs, step)
+			case w.passable(head) && w.standable(blockPos{X: flat.X, Y: flat.Y + 1, Z: flat.Z}):
+				next, costs = append(next, blockPos{X: flat.X, Y: flat.Y + 1, Z: flat.Z}), append(costs, step+0.5)
+			case w.passable(flat) && w.passable(blockPos{X: flat.X, Y: flat.Y + 1, Z: flat.Z}):
+				for drop := 1; drop <= pathMaxDrop; drop++ {
+					below := blockPos{X: flat.X, Y: flat.Y - drop, Z: flat.Z}
+					if w.standable(below) {
+						next, costs = append(next, below), append(costs, step+0.5*float64(drop))
+						break
+					}
+					if !w.passable(below) {
+						break
+					}
+				}
+				if bridge && (dx == 0 || dz == 0) && w.replaceable(blockPos{X: flat.X, Y: flat.Y - 1, Z: flat.Z}) {
+					next, costs = append(next, flat), append(costs, step+bridgeCost)
+				}
+			}
+		}
+	}
+	return next, costs
+}
+
+// findPath searches a walkable path from start to any position within reach of goal.
+// The returned path excludes start and ends at the first position close enough.
+// opts adds costs around hazards and can rule out steps near them. In hot dimensions paths keep
+// off lava shores and never bridge next to lava.
+func (w *worldModel) findPath(start, goal blockPos, reach float64, opts pathOptions) ([]blockPos, error) {
+	if distance(start, goal) <= reach {
+		return nil, nil
+	}
+
+	open := &pathQueue{}
+	nodes := map[blockPos]*pathNode{start: {pos: start, score: distance(start, goal)}}
+	from := make(map[blockPos]blockPos)
+	closed := make(map[blockPos]bool)
+	heap.Push(open, nodes[start])
+
+	// The path to the closest position explored tells how far the goal is out of reach
+	closest := start
+	hot := w.hot()
+	stuck := func(reason string) error {
+		return &pathError{Closest: closest, Partial: tracePath(from, start, closest), Reason: reason}
+	}
+
+	for expanded := 0; open.Len() > 0; expanded++ {
+		if expanded >= pathMaxNodes {
+			return nil, stuck(fmt.Sprintf("gave up after %d nodes", pathMaxNodes))
+		}
+		current := heap.Pop(open).(*pathNode)
+		if distance(current.pos, goal) <= reach {
+			return tracePath(from, start, current.pos), nil
+		}
+		closed[current.pos] = true
+		if distance(current.pos, goal) < distance(closest, goal) {
+			closest = current.pos
+		}
+
+		next, costs := w.neighbors(current.pos, current.placed < opts.Bridges)
+		for i, p := range next {
+			if closed[p] {
+				continue
+			}
+			placed := current.placed
+			bridged := !w.standable(p)
+			if bridged {
+				placed++ // Crossed on a placed block
+			}
+			extra, allowed := opts.penalty(start, p)
+			if !allowed {
+				continue
+			}
+			if hot && w.nearLava(p) {
+				if bridged {
+					continue // No bridging over the lava oceans of the nether
+				}
+				extra += lavaShoreCost
+			}
+			cost := current.cost + costs[i] + extra
+			if n, ok := nodes[p]; ok {
+				if cost >= n.cost {
+					continue
+				}
+				n.cost, n.score, n.placed = cost, cost+distance(p, goal), placed
+				heap.Fix(open, n.index)
+			} else {
+				n := &pathNode{pos: p, cost: cost, score: cost + distance(p, goal), placed: placed}
+				nodes[p] = n
+				heap.Push(open, n)
+			}
+			from[p] = current.pos
+		}
+	}
+	return nil, stuck("")
+}
+
+// tracePath follows the search links back from end and returns the path from start, excluding start
+func tracePath(from map[blockPos]blockPos, start, end blockPos) []blockPos {
+	var path []blockPos
+	for p := end; p != start; p = from[p] {
+		path = append(path, p)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// feetBlock returns the block the bot is standing in
+func (b *Bot) feetBlock() blockPos {
+	x, y, z := b.currentPosition()
+	return blockPos{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))}
+}
+
+// followPath walks along a path block by block, stopping early when stop returns true
+func (b *Bot) followPath(ctx context.Context, path []blockPos, stop func() bool) error {
+	b.highlightPath(path)
+	prev := b.feetBlock()
+	for _, p := range path {
+		if stop != nil && stop() {
+			return nil
+		}
+		if err := b.stepTo(ctx, prev, p); err != nil {
+			return err
+		}
+		prev = p
+	}
+	return nil
+}
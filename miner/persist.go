@@ -0,0 +1,210 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Buckets of the state database
+const (
+	bucketMined     = "mined"     // Blocks broken by the bots, keyed by server, dimension unless the overworld, and position
+	bucketChests    = "chests"    // Container contents, see chestIndex
+	bucketStats     = "stats"     // Statistics of each bot, keyed by username
+	bucketTasks     = "tasks"     // Task each bot was running, keyed by username
+	bucketWaypoints = "waypoints" // Named places, keyed by server and name
+	bucketBlockLog  = "blocklog"  // Block changes the bots saw, keyed like bucketMined
+)
+
+const statsSaveInterval = time.Minute // Statistics lost at most in a crash
+
+// minedBlock is an entry of the mined-block history
+type minedBlock struct {
+	Bot  string    `json:"bot"`
+	Time time.Time `json:"time"`
+}
+
+// savedStats are the statistics of a bot carried over restarts
+type savedStats struct {
+	Mined     int       `json:"mined"`
+	Ores      []oreRate `json:"ores,omitempty"`
+	Traveled  float64   `json:"traveled"`
+	Earned    float64   `json:"earned,omitempty"` // Money made selling
+	Week      time.Time `json:"week"`             // Monday the blocks of the week are counted from
+	WeekMined int       `json:"week_mined"`
+	Saved     time.Time `json:"saved"`
+}
+
+// taskSpec is what it takes to queue a task again after a restart
+type taskSpec struct {
+	Kind     string        `json:"kind"`
+	Pos      *blockPos     `json:"pos,omitempty"`
+	Region   *quarryRegion `json:"region,omitempty"`
+	Player   string        `json:"player,omitempty"`
+	Item     string        `json:"item,omitempty"`
+	Count    int           `json:"count,omitempty"`
+	Radius   float64       `json:"radius,omitempty"`   // Guard and explore radius
+	Avoid    float64       `json:"avoid,omitempty"`    // Distance kept from hostile mobs
+	Announce bool          `json:"announce,omitempty"` // Whether progress is reported in chat
+	Fill     bool          `json:"fill,omitempty"`     // Whether a flatten fills holes
+}
+
+// savedTask is the task a bot was running when it stopped
+type savedTask struct {
+	Name    string    `json:"name"`
+	Spec    taskSpec  `json:"spec"`
+	Started time.Time `json:"started"`
+}
+
+// minedKey identifies a block position in the current dimension on the server of the bot
+func (b *Bot) minedKey(pos blockPos) string {
+	if dim := b.world.currentDimension(); dim != "" && dim != overworld {
+		return fmt.Sprintf("%s/%s/%d,%d,%d", b.cfg.Server, dim, pos.X, pos.Y, pos.Z)
+	}
+	return fmt.Sprintf("%s/%d,%d,%d", b.cfg.Server, pos.X, pos.Y, pos.Z)
+}
+
+// recordMined adds the blocks the bot breaks to the mined-block history
+func (b *Bot) recordMined(e BlockMined) {
+	if err := b.db.Put(bucketMined, b.minedKey(blockPos(e.BlockPos)), minedBlock{Bot: b.cfg.Username, Time: time.Now()}); err != nil {
+		b.log.Printf("⚠️ Failed to record the mined block: %v", err)
+	}
+}
+
+// minedBefore reports whether a bot of this server broke the block at pos
+func (b *Bot) minedBefore(pos blockPos) bool {
+	return b.db.Has(bucketMined, b.minedKey(pos))
+}
+
+// loadStats restores the statistics saved by the last run
+func (b *Bot) loadStats() {
+	var s savedStats
+	ok, err := b.db.Get(bucketStats, b.cfg.Username, &s)
+	if err != nil {
+		b.log.Printf("⚠️ Starting with fresh statistics: %v", err)
+	}
+	if !ok || err != nil {
+		return
+	}
+	b.swarm.restoreMined(b.cfg.Username, s.Mined)
+	b.ores.restore(s.Ores)
+	b.travel.restore(s.Traveled)
+	b.economy.restore(s.Earned)
+	b.personality.restore(s.Week, s.WeekMined)
+	b.log.Printf("📈 Restored statistics: %d blocks mined, %.0f blocks traveled", s.Mined, s.Traveled)
+}
+
+// saveStats writes the statistics of the bot to the state database
+func (b *Bot) saveStats() {
+	traveled, _, _ := b.travel.snapshot()
+	week, weekMined := b.personality.snapshot()
+	s := savedStats{
+		Mined:     b.swarm.minedBy(b.cfg.Username),
+		Ores:      b.ores.rates(),
+		Traveled:  traveled,
+		Earned:    b.economy.snapshot().Earned,
+		Week:      week,
+		WeekMined: weekMined,
+		Saved:     time.Now(),
+	}
+	if err := b.db.Put(bucketStats, b.cfg.Username, s); err != nil {
+		b.log.Printf("⚠️ Failed to save statistics: %v", err)
+	}
+}
+
+// saveStatsEvery saves the statistics periodically until ctx is done
+func (b *Bot) saveStatsEvery(ctx context.Context) {
+	ticker := time.NewTicker(statsSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.saveStats()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// saveTask records the task that started running, or forgets the last one
+// when the new task can't be resumed
+func (b *Bot) saveTask(t *task) {
+	var err error
+	if t.Spec == nil {
+		err = b.db.Delete(bucketTasks, b.cfg.Username)
+	} else {
+		err = b.db.Put(bucketTasks, b.cfg.Username, savedTask{Name: t.Name, Spec: *t.Spec, Started: t.Started})
+	}
+	if err != nil {
+		b.log.Printf("⚠️ Failed to save the running task: %v", err)
+	}
+}
+
+// forgetTask drops the saved task once it is over
+func (b *Bot) forgetTask() {
+	if err := b.db.Delete(bucketTasks, b.cfg.Username); err != nil {
+		b.log.Printf("⚠️ Failed to forget the finished task: %v", err)
+	}
+}
+
+// resumeTask queues the task the bot was running when it last stopped and reports whether it did
+func (b *Bot) resumeTask() bool {
+	var saved savedTask
+	ok, err := b.db.Get(bucketTasks, b.cfg.Username, &saved)
+	if !ok {
+		return false
+	}
+	if err == nil {
+		b.log.Printf("🔄 Resuming %s, started %s", saved.Name, saved.Started.Format(time.DateTime))
+		_, err = b.queueSpec(saved.Spec)
+	}
+	if err != nil {
+		b.log.Printf("⚠️ Can't resume %s: %v", saved.Name, err)
+		b.forgetTask()
+		return false
+	}
+	return true
+}
+
+// queueSpec queues the task a spec describes
+func (b *Bot) queueSpec(s taskSpec) (*task, error) {
+	needPos := s.Kind == mineTaskName || s.Kind == gotoTaskName || s.Kind == guardTaskName || s.Kind == recoverTaskName || s.Kind == flyTaskName || s.Kind == buildTaskName || s.Kind == exploreTaskName
+	if needPos && s.Pos == nil {
+		return nil, fmt.Errorf("%s task without a position", s.Kind)
+	}
+	switch s.Kind {
+	case quarryTaskName:
+		if s.Region == nil {
+			return nil, errors.New("quarry task without a region")
+		}
+		_, _, err := b.swarm.startQuarry(*s.Region)
+		return nil, err
+	case flattenTaskName:
+		if s.Region == nil {
+			return nil, errors.New("flatten task without a region")
+		}
+		return b.queueFlatten(*s.Region, s.Fill, s.Player), nil
+	case mineTaskName:
+		return b.queueMine(*s.Pos), nil
+	case gotoTaskName:
+		return b.queueGoto(*s.Pos, s.Avoid, s.Announce, s.Player), nil
+	case followTaskName:
+		return b.queueFollow(s.Player, s.Avoid), nil
+	case guardTaskName:
+		return b.queueGuard(*s.Pos, s.Radius), nil
+	case mineBlockTaskName:
+		return b.queueMineBlocks(s.Item, s.Count, s.Player), nil
+	case craftTaskName:
+		return b.queueCraft(s.Item, s.Count, s.Player), nil
+	case recoverTaskName:
+		return b.queueRecover(*s.Pos), nil
+	case flyTaskName:
+		return b.queueFly(*s.Pos, s.Player), nil
+	case buildTaskName:
+		return b.queueBuild(s.Item, *s.Pos, s.Player), nil
+	case exploreTaskName:
+		return b.queueExplore(*s.Pos, int(s.Radius), s.Player), nil
+	}
+	return nil, fmt.Errorf("unknown task kind %q", s.Kind)
+}
@@ -0,0 +1,199 @@
+package miner
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	firstMilestone = 1000 // Smallest count the bot remarks on
+	hoursPerDay    = 24
+)
+
+// milestoneSteps are the round numbers within a power of ten the bot remarks on
+var milestoneSteps = []float64{1, 2, 5}
+
+// Remarks by milestone, one picked at random. %s is the number or the advancement.
+var (
+	weekRemarks = []string{
+		"just hit %s blocks this week!",
+		"%s blocks mined this week, not bad",
+		"that's %s blocks this week. My pickaxe needs a holiday",
+	}
+	totalRemarks = []string{
+		"%s blocks mined since I started!",
+		"block number %s, and counting",
+	}
+	earnedRemarks = []string{
+		"just passed $%s earned selling ores!",
+		"$%s made so far, the mine pays",
+	}
+	advancementRemarks = []string{
+		"got [%s]!",
+		"[%s], finally",
+	}
+)
+
+// PersonalityConfig lets the bot remark on its milestones in chat now and then, apart from
+// the answers to commands
+type PersonalityConfig struct {
+	Enabled    bool `json:"enabled"`
+	Every      int  `json:"every_minutes"` // Least minutes between two remarks, milestones in between pass silently
+	QuietFrom  int  `json:"quiet_from"`    // Hour of the day (0-23, local time) the bot stops remarking at
+	QuietUntil int  `json:"quiet_until"`   // Hour it starts again, the same as quiet_from for no quiet hours
+}
+
+// defaultPersonality returns the remark frequency and quiet hours
+func defaultPersonality() PersonalityConfig {
+	return PersonalityConfig{Every: 30, QuietFrom: 22, QuietUntil: 8}
+}
+
+// check checks the frequency and quiet hours
+func (c PersonalityConfig) check() error {
+	if c.Every < 1 {
+		return fmt.Errorf("every_minutes must be at least 1")
+	}
+	if c.QuietFrom < 0 || c.QuietFrom >= hoursPerDay || c.QuietUntil < 0 || c.QuietUntil >= hoursPerDay {
+		return fmt.Errorf("quiet_from and quiet_until are hours from 0 to 23")
+	}
+	return nil
+}
+
+// quiet reports whether t falls in the quiet hours, which may span midnight
+func (c PersonalityConfig) quiet(t time.Time) bool {
+	h := t.Hour()
+	switch {
+	case c.QuietFrom == c.QuietUntil:
+		return false
+	case c.QuietFrom < c.QuietUntil:
+		return h >= c.QuietFrom && h < c.QuietUntil
+	default:
+		return h >= c.QuietFrom || h < c.QuietUntil
+	}
+}
+
+// personality counts the blocks of the week and remembers the last remark
+type personality struct {
+	mu        sync.Mutex
+	week      time.Time // Monday the counted week started on
+	weekMined int
+	remarked  time.Time
+}
+
+// weekStart returns midnight of the Monday of the week of t
+func weekStart(t time.Time) time.Time {
+	days := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -days).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// countMined counts a block mined at t and returns the blocks of the week so far
+func (p *personality) countMined(t time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if week := weekStart(t); !week.Equal(p.week) {
+		p.week, p.weekMined = week, 0
+	}
+	p.weekMined++
+	return p.weekMined
+}
+
+// restore carries over the blocks of the week mined in a previous run, unless a new week began
+func (p *personality) restore(week time.Time, mined int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !week.Equal(weekStart(time.Now())) {
+		return
+	}
+	p.week, p.weekMined = week, mined
+}
+
+// snapshot returns the week counted and its blocks
+func (p *personality) snapshot() (time.Time, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.week, p.weekMined
+}
+
+// milestoneCrossed returns the largest round number, 1, 2 or 5 times a power of ten from
+// firstMilestone on, that going from before to after passed. 0 means none.
+func milestoneCrossed(before, after float64) float64 {
+	var crossed float64
+	for p := float64(firstMilestone); p <= after; p *= 10 {
+		for _, f := range milestoneSteps {
+			if m := p * f; m > before && m <= after {
+				crossed = m
+			}
+		}
+	}
+	return crossed
+}
+
+// groupThousands writes n with commas between the thousands, like 10,000
+func groupThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// remarkOnMilestones comments in chat on the blocks mined this week and in total, the money
+// earned selling and the advancements earned
+func (b *Bot) remarkOnMilestones(e botEvent) {
+	switch e.Type {
+	case eventBlockMined:
+		week := b.personality.countMined(e.Time)
+		if m := milestoneCrossed(float64(week-1), float64(week)); m > 0 {
+			b.remark(weekRemarks, groupThousands(int64(m)))
+			return
+		}
+		total := b.swarm.minedBy(b.cfg.Username)
+		if m := milestoneCrossed(float64(total-1), float64(total)); m >= 10*firstMilestone {
+			b.remark(totalRemarks, groupThousands(int64(m)))
+		}
+	case eventItemsSold:
+		sale, ok := e.Data.(map[string]float64)
+		if !ok {
+			return
+		}
+		if m := milestoneCrossed(sale["earned"]-sale["amount"], sale["earned"]); m > 0 {
+			b.remark(earnedRemarks, groupThousands(int64(m)))
+		}
+	case eventAdvancement:
+		data, ok := e.Data.(map[string]any)
+		if !ok {
+			return
+		}
+		if title, ok := data["title"].(string); ok {
+			b.remark(advancementRemarks, title)
+		}
+	}
+}
+
+// remark says one of remarks in public chat, unless the personality is off, it's the quiet
+// hours or the last remark was less than every_minutes ago
+func (b *Bot) remark(remarks []string, what string) {
+	c := b.cfg.Personality
+	now := time.Now()
+	if !c.Enabled || c.quiet(now) {
+		return
+	}
+	b.personality.mu.Lock()
+	recent := now.Sub(b.personality.remarked) < time.Duration(c.Every)*time.Minute
+	if !recent {
+		b.personality.remarked = now
+	}
+	b.personality.mu.Unlock()
+	if recent {
+		return
+	}
+
+	text := fmt.Sprintf(remarks[rand.IntN(len(remarks))], what)
+	b.log.Printf("💬 Remark: %s", text)
+	b.events.emit(eventRemark, text)
+	go b.sendChatMessage(text)
+}
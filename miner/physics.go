@@ -0,0 +1,185 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Vanilla player movement constants, per tick
+const (
+	gravity        = 0.08
+	verticalDrag   = 0.98
+	airDrag        = 0.91
+	groundDrag     = 0.91 * 0.6 // Air drag times the slipperiness of ordinary blocks
+	jumpVelocity   = 0.42
+	motionUnit     = 8000.0 // Entity motion packets are in 1/8000 of a block per tick
+	maxSettleTicks = 60     // Ticks a knockback is simulated at most before giving up
+	settledSpeed   = 0.005  // Horizontal speed below which a grounded player stops sliding
+)
+
+// velocity is a motion in blocks per tick
+type velocity struct {
+	X, Y, Z float64
+}
+
+// onEntityMotion records the knockback the server applies to the bot.
+// Motion packets of other entities are ignored.
+func (b *Bot) onEntityMotion(p pk.Packet) error {
+	var (
+		id         pk.VarInt
+		vx, vy, vz pk.Short
+	)
+	if err := p.Scan(&id, &vx, &vy, &vz); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID {
+		return nil
+	}
+
+	b.stateMu.Lock()
+	b.knockback = &velocity{X: float64(vx) / motionUnit, Y: float64(vy) / motionUnit, Z: float64(vz) / motionUnit}
+	b.stateMu.Unlock()
+	return nil
+}
+
+// takeKnockback returns and clears the pending knockback, nil when there is none
+func (b *Bot) takeKnockback() *velocity {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	v := b.knockback
+	b.knockback = nil
+	return v
+}
+
+// resolveKnockback moves the bot along a pending knockback until it lands and stops sliding,
+// so the position the bot reports matches where the server pushed it.
+// It reports whether there was a knockback to resolve.
+func (b *Bot) resolveKnockback(ctx context.Context) (bool, error) {
+	v := b.takeKnockback()
+	if v == nil {
+		return false, nil
+	}
+	x, y, z := b.currentPosition()
+	b.log.Printf("💥 Knocked back (%.2f, %.2f, %.2f) blocks/tick", v.X, v.Y, v.Z)
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	for range maxSettleTicks {
+		var onGround bool
+		x, y, z, onGround = b.world.step(x, y, z, v)
+		if err := b.sendPosition(x, y, z, onGround); err != nil {
+			return true, fmt.Errorf("failed to send position: %w", err)
+		}
+		b.stateMu.RLock()
+		yaw, pitch := b.yaw, b.pitch
+		b.stateMu.RUnlock()
+		b.setPosition(x, y, z, yaw, pitch)
+
+		if onGround && math.Hypot(v.X, v.Z) < settledSpeed {
+			break
+		}
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+	x, y, z = b.currentPosition()
+	b.log.Printf("📍 Resynced after knockback at (%.2f, %.2f, %.2f)", x, y, z)
+	return true, nil
+}
+
+// step moves a player at x, y, z by one tick of v, stopping at blocks, and applies gravity and drag to v.
+// It reports whether the player ends the tick on the ground.
+func (w *worldModel) step(x, y, z float64, v *velocity) (nx, ny, nz float64, onGround bool) {
+	nx, ny, nz = x+v.X, y, z+v.Z
+	feet := blockPos{X: int(math.Floor(nx)), Y: int(math.Floor(y)), Z: int(math.Floor(nz))}
+	if !w.passable(feet) || !w.passable(blockPos{X: feet.X, Y: feet.Y + 1, Z: feet.Z}) {
+		// Walked into a wall, lose the horizontal motion
+		nx, nz = x, z
+		v.X, v.Z = 0, 0
+	}
+
+	ny = y + v.Y
+	column := blockPos{X: int(math.Floor(nx)), Y: int(math.Floor(ny)), Z: int(math.Floor(nz))}
+	switch {
+	case v.Y <= 0 && w.solid(column):
+		// Landed on top of the block
+		ny, v.Y, onGround = float64(column.Y+1), 0, true
+	case v.Y <= 0 && ny == math.Floor(ny) && w.solid(blockPos{X: column.X, Y: column.Y - 1, Z: column.Z}):
+		// Still standing on the block below
+		v.Y, onGround = 0, true
+	case v.Y > 0 && !w.passable(blockPos{X: column.X, Y: int(math.Floor(ny + 1.8)), Z: column.Z}):
+		// Bumped the head
+		ny, v.Y = y, 0
+	}
+
+	if !onGround {
+		v.Y = (v.Y - gravity) * verticalDrag
+	}
+	drag := airDrag
+	if onGround {
+		drag = groundDrag
+	}
+	v.X *= drag
+	v.Z *= drag
+	return nx, ny, nz, onGround
+}
+
+// canJump reports whether there is room above the bot for a full jump
+func (b *Bot) canJump() bool {
+	feet := b.feetBlock()
+	return b.world.solid(blockPos{X: feet.X, Y: feet.Y - 1, Z: feet.Z}) &&
+		b.world.passable(blockPos{X: feet.X, Y: feet.Y + 2, Z: feet.Z}) &&
+		b.world.passable(blockPos{X: feet.X, Y: feet.Y + 3, Z: feet.Z})
+}
+
+// jump jumps in place. Every tick the bot is falling, falling is called until it reports done.
+func (b *Bot) jump(ctx context.Context, falling func() (done bool, err error)) error {
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+
+	x, ground, z := b.currentPosition()
+	y, vy, done := ground, jumpVelocity, false
+	for {
+		previous := y
+		y += vy
+		vy = (vy - gravity) * verticalDrag
+		landed := y <= ground
+		if landed {
+			y = ground
+		}
+		if err := b.sendPosition(x, y, z, landed); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.stateMu.Lock()
+		b.y = y
+		b.stateMu.Unlock()
+		if landed {
+			return nil
+		}
+
+		// Vanilla counts the player as falling once a move went downwards
+		if !done && falling != nil && y < previous {
+			var err error
+			if done, err = falling(); err != nil {
+				return err
+			}
+		}
+
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			b.stateMu.Lock()
+			b.y = ground
+			b.stateMu.Unlock()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	pipelineDepth = 8   // Targets planned ahead of the block being broken
+	reachMargin   = 0.5 // Distance kept from the edge of mining reach while stepping during a dig
+)
+
+// mineLine breaks a sequence of blocks in order, skipping the ones skip rejects.
+// The next pipelineDepth targets are planned ahead so skipped blocks cost no ticks.
+// While a block breaks the bot steps towards the next target as far as it can without
+// leaving reach, and it aims at the next target before finishing the current one.
+func (b *Bot) mineLine(ctx context.Context, targets []blockPos, skip func(blockPos) bool) (mined int, err error) {
+	start := time.Now()
+	var ahead []digPlan
+	for len(targets) > 0 || len(ahead) > 0 {
+		// Top up the look-ahead window
+		for len(ahead) < pipelineDepth && len(targets) > 0 {
+			pos := targets[0]
+			targets = targets[1:]
+			if skip != nil && skip(pos) {
+				continue
+			}
+			plan, err := b.planDig(pos)
+			if err != nil {
+				b.log.Printf("⚠️ Skipping (%d, %d, %d): %v", pos.X, pos.Y, pos.Z, err)
+				continue
+			}
+			ahead = append(ahead, plan)
+		}
+		if len(ahead) == 0 {
+			break
+		}
+
+		plan := ahead[0]
+		ahead = ahead[1:]
+		// The block may have fallen or been mined by someone else since it was planned
+		if state, loaded := b.world.blockAt(plan.Pos); loaded && block.IsAir(state) {
+			continue
+		}
+		if b.toolWorn() {
+			err := b.replaceTool(ctx)
+			if ctx.Err() != nil {
+				return mined, ctx.Err()
+			}
+			if err != nil {
+				b.log.Printf("⚠️ Can't replace the tool: %v", err)
+				b.sendChatMessage("My pickaxe is about to break and I have no replacement")
+			} else {
+				// Plan again with the new tool, starting at the block the swap interrupted
+				pending := []blockPos{plan.Pos}
+				for _, p := range ahead {
+					pending = append(pending, p.Pos)
+				}
+				targets = append(pending, targets...)
+				ahead = nil
+				b.log.Printf("🔁 Resuming at (%d, %d, %d)", plan.Pos.X, plan.Pos.Y, plan.Pos.Z)
+				continue
+			}
+		}
+		if b.mendingNeeded() {
+			if err := b.collectXP(ctx); err != nil {
+				return mined, err
+			}
+		}
+		if err := b.approachBlock(ctx, plan.Pos); err != nil {
+			return mined, err
+		}
+
+		var onTick func(int) error
+		if len(ahead) > 0 {
+			onTick = b.leadTowards(plan.Pos, ahead[0].Pos)
+		}
+		err := b.minePlanned(ctx, plan, onTick)
+		if errors.Is(err, errBreakRejected) || errors.Is(err, errNoLineOfSight) || errors.Is(err, errProtected) {
+			b.log.Printf("⚠️ Skipping (%d, %d, %d): %v", plan.Pos.X, plan.Pos.Y, plan.Pos.Z, err)
+			continue
+		}
+		if err != nil {
+			return mined, err
+		}
+		mined++
+	}
+
+	if mined > 0 {
+		elapsed := time.Since(start)
+		b.log.Printf("🚇 Mined %d blocks in %s (%s per block)", mined, elapsed.Round(time.Millisecond), (elapsed / time.Duration(mined)).Round(time.Millisecond))
+	}
+	return mined, nil
+}
+
+// leadTowards returns a mining tick hook for breaking cur while next is the following target.
+// Each tick it steps towards where next can be reached, as long as cur stays in reach,
+// and on the last tick it turns towards the face of next it will dig so the following dig starts aimed.
+func (b *Bot) leadTowards(cur, next blockPos) func(remaining int) error {
+	cx, cy, cz := float64(cur.X)+0.5, float64(cur.Y)+0.5, float64(cur.Z)+0.5
+	nx, ny, nz := float64(next.X)+0.5, float64(next.Y)+0.5, float64(next.Z)+0.5
+	step := walkSpeed * tickDuration.Seconds()
+
+	return func(remaining int) error {
+		x, y, z := b.currentPosition()
+		if remaining == 0 {
+			// The current block still hides the next one it is behind, leave aiming to the dig then
+			face, ok := b.visibleFace(next)
+			if !ok {
+				return nil
+			}
+			fx, fy, fz := faceCenter(next, face)
+			yaw, pitch := lookAngles(x, y+eyeHeight, z, fx, fy, fz)
+			if err := b.sendRotation(yaw, pitch, true); err != nil {
+				return fmt.Errorf("failed to aim at the next block: %w", err)
+			}
+			b.setPosition(x, y, z, yaw, pitch)
+			return nil
+		}
+
+		// Same goal as approachBlock would walk to for the next target
+		dx, dy, dz := nx-x, ny-eyeHeight-y, nz-z
+		dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+		move := math.Min(step, dist-(miningReach-1))
+		if move <= 0 {
+			return nil
+		}
+		px, py, pz := x+dx/dist*move, y+dy/dist*move, z+dz/dist*move
+		ex, ey, ez := cx-px, cy-(py+eyeHeight), cz-pz
+		if math.Sqrt(ex*ex+ey*ey+ez*ez) > miningReach-reachMargin {
+			return nil
+		}
+
+		// Keep looking at the block being broken while moving
+		yaw, pitch := lookAngles(px, py+eyeHeight, pz, cx, cy, cz)
+		if err := b.sendPositionRotation(px, py, pz, yaw, pitch, true); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.setPosition(px, py, pz, yaw, pitch)
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Plugin is a feature living in a module of its own, like farming or a Discord bridge, that
+// hooks into the bots. A plugin package registers itself with Register from an init function,
+// so importing it for its side effects is enough to add it to the bots:
+//
+//	import _ "example.com/miner-farming"
+type Plugin interface {
+	// Init is called when the bot's files are opened, before it joins. An error keeps the
+	// bots from running.
+	Init(m *Miner) error
+	// OnTick is called every game tick while the bot is in game
+	OnTick(m *Miner)
+	// OnPacket is called with every packet the server sends, before the bot handles it. The
+	// packet is only valid during the call.
+	OnPacket(m *Miner, p pk.Packet)
+	// OnChat is called with every chat line the bot receives, the bot's own included
+	OnChat(m *Miner, line ChatLine)
+	// Commands returns the chat commands the plugin adds
+	Commands() []Command
+}
+
+// ChatLine is a chat line as the bot reads it: who wrote it, its text and the command it
+// starts with
+type ChatLine struct {
+	Sender  string // Player who wrote the line, empty for server messages
+	Text    string // The line without the sender prefix
+	Command string // Lowercase command word without the "!", empty unless the text starts with one
+}
+
+// Command is a chat command a plugin adds. The bot's own commands take precedence over
+// plugin commands of the same name.
+type Command struct {
+	Name  string                        // The lowercase word after the "!"
+	Owner bool                          // Only the owner, or the backup owner standing in, may use it
+	Run   func(m *Miner, line ChatLine) // Called from a goroutine of its own
+}
+
+var (
+	pluginsMu       sync.Mutex
+	pluginFactories = map[string]func() Plugin{}
+)
+
+// Register makes a plugin available to the bots under a name. Every bot gets an instance of
+// its own from newPlugin. Registering a name twice panics.
+func Register(name string, newPlugin func() Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := pluginFactories[name]; ok {
+		panic(fmt.Sprintf("miner: plugin %q registered twice", name))
+	}
+	pluginFactories[name] = newPlugin
+}
+
+// Plugins returns the names of the registered plugins, sorted
+func Plugins() []string {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	return slices.Sorted(maps.Keys(pluginFactories))
+}
+
+// loadedPlugin is a plugin instance of one bot
+type loadedPlugin struct {
+	name   string
+	plugin Plugin
+}
+
+// pluginCommand is a command with the plugin that added it
+type pluginCommand struct {
+	plugin string
+	Command
+}
+
+// pluginHost is the plugins of a bot and the commands they added
+type pluginHost struct {
+	miner    *Miner
+	loaded   []loadedPlugin
+	commands map[string]pluginCommand
+}
+
+// checkPlugins returns an error for a plugin name nothing registered
+func checkPlugins(names []string) error {
+	known := Plugins()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("no plugin %q is built in, registered are %v", name, known)
+		}
+	}
+	return nil
+}
+
+// loadPlugins creates the bot's instances of the plugins the config names, or of every
+// registered plugin when it names none, and hooks them into the packets of the bot
+func (b *Bot) loadPlugins(m *Miner) {
+	names := b.cfg.Plugins
+	if len(names) == 0 {
+		names = Plugins()
+	}
+	h := &b.plugins
+	h.miner = m
+	h.commands = make(map[string]pluginCommand)
+	for _, name := range names {
+		pluginsMu.Lock()
+		newPlugin := pluginFactories[name]
+		pluginsMu.Unlock()
+		if newPlugin == nil {
+			b.log.Printf("⚠️ Skipping plugin %s: it is not built in", name)
+			continue
+		}
+		p := newPlugin()
+		for _, c := range p.Commands() {
+			if other, ok := h.commands[c.Name]; ok {
+				b.log.Printf("⚠️ Plugin %s adds !%s, which plugin %s added already", name, c.Name, other.plugin)
+				continue
+			}
+			h.commands[c.Name] = pluginCommand{plugin: name, Command: c}
+		}
+		h.loaded = append(h.loaded, loadedPlugin{name: name, plugin: p})
+	}
+	if len(h.loaded) == 0 {
+		return
+	}
+	b.client.Events.AddGeneric(bot.PacketHandler{F: func(p pk.Packet) error {
+		for _, l := range h.loaded {
+			l.plugin.OnPacket(m, p)
+		}
+		return nil
+	}})
+}
+
+// initPlugins initializes the plugins of the bot
+func (b *Bot) initPlugins() error {
+	for _, l := range b.plugins.loaded {
+		if err := l.plugin.Init(b.plugins.miner); err != nil {
+			return fmt.Errorf("failed to start plugin %s: %w", l.name, err)
+		}
+		b.log.Printf("🧩 Loaded plugin %s", l.name)
+	}
+	return nil
+}
+
+// tickPlugins calls the plugins every game tick until ctx is done
+func (b *Bot) tickPlugins(ctx context.Context) {
+	if len(b.plugins.loaded) == 0 {
+		return
+	}
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, l := range b.plugins.loaded {
+				l.plugin.OnTick(b.plugins.miner)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pluginChat passes a chat line to the plugins
+func (b *Bot) pluginChat(line chatLine) {
+	for _, l := range b.plugins.loaded {
+		l.plugin.OnChat(b.plugins.miner, ChatLine(line))
+	}
+}
+
+// pluginOwnerCommand reports whether a plugin added command for the owner only
+func (b *Bot) pluginOwnerCommand(command string) bool {
+	return b.plugins.commands[command].Owner
+}
+
+// runPluginCommand runs the plugin command a chat message gives and reports whether a plugin
+// has it
+func (b *Bot) runPluginCommand(command, msg string) bool {
+	c, ok := b.plugins.commands[command]
+	if !ok {
+		return false
+	}
+	b.log.Printf("📥 Received !%s command for plugin %s", command, c.plugin)
+	go c.Run(b.plugins.miner, ChatLine(parseChatLine(msg)))
+	return true
+}
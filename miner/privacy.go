@@ -0,0 +1,184 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Privacy modes
+const (
+	privacyOff    = ""
+	privacyRound  = "round"  // X and Z rounded to the nearest multiple of the grid
+	privacyOffset = "offset" // X and Z shifted by a fixed offset only the owner knows
+)
+
+// coordinateTriple matches coordinates in text, like "(12, 64, -30)" or "12 64 -30"
+var coordinateTriple = regexp.MustCompile(`(-?\d+(?:\.\d+)?)(,\s*|\s+)(-?\d+(?:\.\d+)?)(,\s*|\s+)(-?\d+(?:\.\d+)?)\b`)
+
+// PrivacyConfig hides where the bot is from chat, the HTTP API without the token and, when
+// Logs is set, the log. The bot keeps the exact coordinates for itself.
+type PrivacyConfig struct {
+	Mode    string `json:"mode"`
+	Grid    int    `json:"grid"` // Blocks coordinates are rounded to
+	OffsetX int    `json:"offset_x"`
+	OffsetZ int    `json:"offset_z"`
+	Logs    bool   `json:"logs"`
+}
+
+// defaultPrivacy returns the privacy settings, off
+func defaultPrivacy() PrivacyConfig {
+	return PrivacyConfig{Grid: 100}
+}
+
+// check checks the privacy mode
+func (c PrivacyConfig) check() error {
+	switch c.Mode {
+	case privacyOff:
+	case privacyRound:
+		if c.Grid < 2 {
+			return fmt.Errorf("grid must be at least 2")
+		}
+	case privacyOffset:
+		if c.OffsetX == 0 && c.OffsetZ == 0 {
+			return fmt.Errorf("offset_x and offset_z are both 0")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q, expected round or offset", c.Mode)
+	}
+	return nil
+}
+
+// coord hides an X or Z coordinate, offset being the shift of its axis
+func (c PrivacyConfig) coord(v float64, offset int) float64 {
+	switch c.Mode {
+	case privacyRound:
+		return math.Round(v/float64(c.Grid))*float64(c.Grid) + 0 // No "-0"
+	case privacyOffset:
+		return v + float64(offset)
+	}
+	return v
+}
+
+// text hides the X and Z of the coordinates in text, keeping their format
+func (c PrivacyConfig) text(s string) string {
+	if c.Mode == privacyOff {
+		return s
+	}
+	return coordinateTriple.ReplaceAllStringFunc(s, func(triple string) string {
+		m := coordinateTriple.FindStringSubmatch(triple)
+		return c.number(m[1], c.OffsetX) + m[2] + m[3] + m[4] + c.number(m[5], c.OffsetZ)
+	})
+}
+
+// number hides a coordinate written as a number, with as many decimals as it had
+func (c PrivacyConfig) number(s string, offset int) string {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	decimals := 0
+	if _, frac, ok := strings.Cut(s, "."); ok {
+		decimals = len(frac)
+	}
+	return strconv.FormatFloat(c.coord(v, offset), 'f', decimals, 64)
+}
+
+// value hides the coordinates of a decoded JSON value: the "x" and "z" of every object and
+// the coordinates in every string
+func (c PrivacyConfig) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		x, xOK := v["x"].(float64)
+		z, zOK := v["z"].(float64)
+		for k, field := range v {
+			v[k] = c.value(field)
+		}
+		if xOK && zOK {
+			v["x"], v["z"] = c.coord(x, c.OffsetX), c.coord(z, c.OffsetZ)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = c.value(item)
+		}
+	case string:
+		return c.text(v)
+	}
+	return v
+}
+
+// json hides the coordinates of a JSON document
+func (c PrivacyConfig) json(data []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(c.value(v))
+}
+
+// privateWriter hides the coordinates of what is written through it, for the log
+type privateWriter struct {
+	w io.Writer
+	c PrivacyConfig
+}
+
+// Write hides the coordinates of p and writes it
+func (p privateWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, p.c.text(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// bufferedResponse holds a response back to rewrite it
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *bufferedResponse) Header() http.Header         { return r.header }
+func (r *bufferedResponse) WriteHeader(status int)      { r.status = status }
+func (r *bufferedResponse) Write(b []byte) (int, error) { return r.body.Write(b) }
+
+// publicRead hides the coordinates in the JSON answers of a read endpoint from clients without
+// the API token. Without an API token every client is public.
+func (b *Bot) publicRead(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := b.cfg.Privacy
+		if c.Mode == privacyOff || b.hasToken(r) {
+			next(w, r)
+			return
+		}
+		buf := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next(buf, r)
+		body := buf.body.Bytes()
+		if strings.HasPrefix(buf.header.Get("Content-Type"), "application/json") {
+			hidden, err := c.json(body)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to hide coordinates: %w", err))
+				return
+			}
+			body = append(hidden, '\n')
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	}
+}
+
+// logOutput returns where the log of a bot goes, hiding coordinates when the privacy settings
+// cover the log
+func logOutput(c Config) io.Writer {
+	if c.Privacy.Mode == privacyOff || !c.Privacy.Logs {
+		return os.Stderr
+	}
+	return privateWriter{w: os.Stderr, c: c.Privacy}
+}
@@ -0,0 +1,285 @@
+package miner
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coolguycoder/Minecraft-Miner/store"
+)
+
+const (
+	profileVersion  = 1
+	profileManifest = "profile.json" // First entry of a profile archive
+)
+
+// Kinds of the files of a profile, named after the config fields holding their path
+const (
+	profileConfig  = "config"
+	profileStateDB = "state_db" // Waypoints, loot claims, statistics, task state and the rest of the database
+	profileSecrets = "secrets_file"
+	profileRules   = "rules_file"
+)
+
+// profileFile is a file of a bot profile
+type profileFile struct {
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`  // Where it was exported from
+	Entry string `json:"entry"` // Name in the archive
+}
+
+// profileIndex is the manifest of a profile archive, listing its files
+type profileIndex struct {
+	Version  int           `json:"version"`
+	Exported time.Time     `json:"exported"`
+	Files    []profileFile `json:"files"`
+}
+
+// profileFiles lists the files of the bots a config runs besides the config itself: state
+// databases kept in a file, secrets files and rules files, each once, in the order of the bots
+func profileFiles(base Config) ([]profileFile, error) {
+	configs := []Config{base}
+	if len(base.Bots) > 0 {
+		configs = nil
+		for i, raw := range base.Bots {
+			c := base
+			if err := json.Unmarshal(raw, &c); err != nil {
+				return nil, fmt.Errorf("failed to parse bot %d: %w", i, err)
+			}
+			configs = append(configs, c)
+		}
+	}
+
+	var files []profileFile
+	seen := make(map[string]bool)
+	for _, kind := range []string{profileStateDB, profileSecrets, profileRules} {
+		n := 0
+		for _, c := range configs {
+			db, _ := store.LocalFile(c.StateDB) // A database on a server stays where it is
+			path := map[string]string{profileStateDB: db, profileSecrets: c.SecretsFile, profileRules: c.RulesFile}[kind]
+			if path == "" || seen[kind+"\x00"+path] {
+				continue
+			}
+			seen[kind+"\x00"+path] = true
+			files = append(files, profileFile{Kind: kind, Path: path, Entry: fmt.Sprintf("%s/%d-%s", kind, n, filepath.Base(path))})
+			n++
+		}
+	}
+	return files, nil
+}
+
+// ExportProfile bundles the config file and the state database, secrets and rules of every
+// bot it runs into a gzipped tar archive, for import on another machine. Files that don't
+// exist are left out.
+func ExportProfile(configPath, archive string) error {
+	c, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	files, err := profileFiles(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configPath, err)
+	}
+	files = append([]profileFile{{Kind: profileConfig, Path: configPath, Entry: profileConfig + "/" + filepath.Base(configPath)}}, files...)
+
+	var present []profileFile
+	for _, f := range files {
+		if _, err := os.Stat(f.Path); errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "⏭️ No %s at %s, left out\n", f.Kind, f.Path)
+			continue
+		} else if err != nil {
+			return err
+		}
+		present = append(present, f)
+	}
+
+	out, err := os.OpenFile(archive, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create the archive: %w", err)
+	}
+	if err := writeProfile(out, present); err != nil {
+		out.Close()
+		os.Remove(archive)
+		return fmt.Errorf("failed to write %s: %w", archive, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", archive, err)
+	}
+	fmt.Fprintf(os.Stderr, "📦 Exported %d file(s) to %s\n", len(present), archive)
+	return nil
+}
+
+// writeProfile writes the manifest and the files of a profile archive
+func writeProfile(w io.Writer, files []profileFile) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	manifest, err := json.MarshalIndent(profileIndex{Version: profileVersion, Exported: time.Now(), Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: profileManifest, Mode: 0o644, Size: int64(len(manifest)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		// A record the bot is appending meanwhile may be cut short, opening the database drops it
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: f.Entry, Mode: int64(info.Mode().Perm()), Size: int64(len(data)), ModTime: info.ModTime()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ImportProfile restores an exported profile: the config to configPath and the other files
+// where the imported config puts them. It refuses to overwrite any file.
+func ImportProfile(configPath, archive string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	index, entries, err := readProfile(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archive, err)
+	}
+
+	// The other files go where the imported config, or the one already there, says
+	c, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	var files []profileFile
+	for _, pf := range index.Files {
+		if pf.Kind != profileConfig {
+			continue
+		}
+		files = append(files, profileFile{Kind: profileConfig, Path: configPath, Entry: pf.Entry})
+		c = defaultConfig()
+		if err := json.Unmarshal(entries[pf.Entry].data, &c); err != nil {
+			return fmt.Errorf("failed to parse the imported config: %w", err)
+		}
+	}
+	targets, err := profileFiles(c)
+	if err != nil {
+		return fmt.Errorf("failed to parse the config: %w", err)
+	}
+
+	// Files of a kind are matched in the order the bots use them
+	byKind := make(map[string][]profileFile)
+	for _, t := range targets {
+		byKind[t.Kind] = append(byKind[t.Kind], t)
+	}
+	used := make(map[string]int)
+	for _, pf := range index.Files {
+		if pf.Kind == profileConfig {
+			continue
+		}
+		i := used[pf.Kind]
+		if i >= len(byKind[pf.Kind]) {
+			return fmt.Errorf("the config has no place for %s %s", pf.Kind, pf.Path)
+		}
+		used[pf.Kind]++
+		files = append(files, profileFile{Kind: pf.Kind, Path: byKind[pf.Kind][i].Path, Entry: pf.Entry})
+	}
+
+	for _, t := range files {
+		if _, err := os.Stat(t.Path); err == nil {
+			return fmt.Errorf("%s exists already, move it away to import the profile", t.Path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	for _, t := range files {
+		e := entries[t.Entry]
+		if dir := filepath.Dir(t.Path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(t.Path, e.data, e.mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", t.Path, err)
+		}
+		fmt.Fprintf(os.Stderr, "📥 Restored %s to %s\n", t.Kind, t.Path)
+	}
+	fmt.Fprintf(os.Stderr, "📦 Imported %d file(s), exported %s\n", len(files), index.Exported.Format(time.DateTime))
+	return nil
+}
+
+// profileEntry is a file read from a profile archive
+type profileEntry struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// readProfile reads the manifest and the files of a profile archive, checking every file
+// the manifest lists is there
+func readProfile(r io.Reader) (profileIndex, map[string]profileEntry, error) {
+	var index profileIndex
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return index, nil, err
+	}
+	tr := tar.NewReader(zr)
+	entries := make(map[string]profileEntry)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return index, nil, err
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return index, nil, err
+		}
+		if hdr.Name == profileManifest {
+			if err := json.Unmarshal(buf.Bytes(), &index); err != nil {
+				return index, nil, fmt.Errorf("bad manifest: %w", err)
+			}
+			continue
+		}
+		entries[hdr.Name] = profileEntry{data: buf.Bytes(), mode: fs.FileMode(hdr.Mode).Perm()}
+	}
+
+	if index.Version == 0 {
+		return index, nil, errors.New("not a profile archive, it has no manifest")
+	}
+	if index.Version > profileVersion {
+		return index, nil, fmt.Errorf("profile version %d is newer than this bot supports (%d)", index.Version, profileVersion)
+	}
+	for _, f := range index.Files {
+		if _, ok := entries[f.Entry]; !ok {
+			return index, nil, fmt.Errorf("%s is missing", f.Entry)
+		}
+	}
+	return index, entries, nil
+}
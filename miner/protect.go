@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bucketZones      = "zones" // Protection zones set with !protect, keyed by server and corners
+	listZoneMaxNames = 5       // Zones described in a bare !protect answer
+)
+
+// protectCommand matches "!protect x1 y1 z1 x2 y2 z2"
+var protectCommand = regexp.MustCompile(`(?i)!protect\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+// errProtected is returned when mining would break a protected block
+var errProtected = errors.New("protected")
+
+// defaultProtectedBlocks are the functional and valuable blocks no mining breaks unless
+// protected_blocks says otherwise
+var defaultProtectedBlocks = []string{
+	"minecraft:spawner", "minecraft:chest", "minecraft:trapped_chest", "minecraft:barrel",
+	"minecraft:ender_chest", "minecraft:*shulker_box", "minecraft:furnace", "minecraft:blast_furnace",
+	"minecraft:smoker", "minecraft:crafting_table", "minecraft:enchanting_table", "minecraft:*anvil",
+	"minecraft:beacon", "minecraft:brewing_stand", "minecraft:hopper", "minecraft:*_bed",
+	"minecraft:respawn_anchor", "minecraft:lodestone", "minecraft:end_portal_frame",
+}
+
+// protectedZone is a cuboid set with !protect
+type protectedZone struct {
+	Region quarryRegion `json:"region"`
+	SetBy  string       `json:"set_by,omitempty"`
+	Set    time.Time    `json:"set"`
+}
+
+// regionFrom builds the cuboid spanned by two corners given as six coordinates
+func regionFrom(v [6]int) quarryRegion {
+	return quarryRegion{
+		Min: blockPos{X: min(v[0], v[3]), Y: min(v[1], v[4]), Z: min(v[2], v[5])},
+		Max: blockPos{X: max(v[0], v[3]), Y: max(v[1], v[4]), Z: max(v[2], v[5])},
+	}
+}
+
+// contains reports whether pos is inside the region
+func (r quarryRegion) contains(pos blockPos) bool {
+	return pos.X >= r.Min.X && pos.X <= r.Max.X &&
+		pos.Y >= r.Min.Y && pos.Y <= r.Max.Y &&
+		pos.Z >= r.Min.Z && pos.Z <= r.Max.Z
+}
+
+// String formats the region like "(1, 2, 3)..(4, 5, 6)"
+func (r quarryRegion) String() string {
+	return fmt.Sprintf("(%d, %d, %d)..(%d, %d, %d)", r.Min.X, r.Min.Y, r.Min.Z, r.Max.X, r.Max.Y, r.Max.Z)
+}
+
+// zoneKey identifies a zone on the server of the bot
+func (b *Bot) zoneKey(r quarryRegion) string {
+	return fmt.Sprintf("%s/%d,%d,%d/%d,%d,%d", b.cfg.Server, r.Min.X, r.Min.Y, r.Min.Z, r.Max.X, r.Max.Y, r.Max.Z)
+}
+
+// protectedZones returns the zones of the config and those set with !protect on this server
+func (b *Bot) protectedZones() []quarryRegion {
+	zones := append([]quarryRegion(nil), b.cfg.ProtectedZones...)
+	prefix := b.cfg.Server + "/"
+	err := b.db.Each(bucketZones, func(key string, value json.RawMessage) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		var z protectedZone
+		if err := json.Unmarshal(value, &z); err != nil {
+			b.log.Printf("⚠️ Skipping unreadable zone %s: %v", key, err)
+			return nil
+		}
+		zones = append(zones, z.Region)
+		return nil
+	})
+	if err != nil {
+		b.log.Printf("⚠️ Failed to read protection zones: %v", err)
+	}
+	return zones
+}
+
+// protectedBlock reports whether a block name matches protected_blocks
+func (b *Bot) protectedBlock(name string) bool {
+	for _, pattern := range b.cfg.ProtectedBlocks {
+		if !strings.Contains(pattern, ":") {
+			pattern = "minecraft:" + pattern
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProtected fails with errProtected when the block at pos must not be broken.
+// name is empty when the block is not known.
+func (b *Bot) checkProtected(pos blockPos, name string) error {
+	if b.protectedBlock(name) {
+		return fmt.Errorf("%s at (%d, %d, %d) is %w", name, pos.X, pos.Y, pos.Z, errProtected)
+	}
+	for _, z := range b.protectedZones() {
+		if z.contains(pos) {
+			return fmt.Errorf("(%d, %d, %d) in zone %s is %w", pos.X, pos.Y, pos.Z, z, errProtected)
+		}
+	}
+	return nil
+}
+
+// handleProtectCommand adds the zone of "!protect x1 y1 z1 x2 y2 z2", or lists the zones
+func (b *Bot) handleProtectCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := protectCommand.FindStringSubmatch(msg)
+	if m == nil {
+		zones := b.protectedZones()
+		if len(zones) == 0 {
+			b.reply(msg, "No protected zones. Usage: !protect x1 y1 z1 x2 y2 z2")
+			return
+		}
+		parts := make([]string, 0, min(len(zones), listZoneMaxNames))
+		for _, z := range zones[:min(len(zones), listZoneMaxNames)] {
+			parts = append(parts, z.String())
+		}
+		reply := "Protected: " + strings.Join(parts, ", ")
+		if more := len(zones) - listZoneMaxNames; more > 0 {
+			reply += fmt.Sprintf(" and %d more", more)
+		}
+		b.reply(msg, reply)
+		return
+	}
+
+	var v [6]int
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	zone := protectedZone{Region: regionFrom(v), SetBy: senderOf(msg), Set: time.Now()}
+	if err := b.db.Put(bucketZones, b.zoneKey(zone.Region), zone); err != nil {
+		b.log.Printf("❌ Failed to save the protection zone: %v", err)
+		b.reply(msg, "Couldn't save the zone")
+		return
+	}
+	b.log.Printf("🛡️ Protecting %s", zone.Region)
+	b.reply(msg, fmt.Sprintf("Won't mine anything in %s", zone.Region))
+}
@@ -0,0 +1,541 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/net/queue"
+)
+
+const (
+	protocolsDefaultDir = "protocols"
+	pingTimeout         = 10 * time.Second // Longest the version check before joining may take
+
+	// Oldest protocol the bot joins, of 1.16.2
+	minProtocol = 751
+	minRelease  = "1.16.2"
+
+	configPhaseProtocol = 764 // First protocol with a configuration phase, of 1.20.2
+	configProtocol      = 766 // First protocol whose login and configuration go-mc speaks, of 1.20.5
+)
+
+// protocolCodec translates the play packets between the protocol the bot speaks and the one
+// of its server: their IDs, after the packets report of the server's release, the layouts
+// that changed and, with the server's block and registry reports, the numbers of blocks and
+// items
+type protocolCodec struct {
+	protocol int32  // The server's
+	release  string // What the server calls its version
+	modded   string // The mod loader of the server and its mods, empty for none
+	forge    string // Marker of the FML handshake the bot's handshake carries, empty for none
+	log      *log.Logger
+
+	clientbound    map[int32]int32                    // go-mc ID of every server packet ID, nil when they are the same
+	serverNames    map[int32]string                   // Names of the server packet IDs, for logs
+	serverbound    map[int32]int32                    // Server ID of every go-mc packet ID, nil when they are the same
+	serverboundIDs map[string]int32                   // Server IDs of the server's own packets by name, for answers
+	fromServer     map[int32][]packetRewrite          // Rewrites of the server's packets by server ID, in order
+	toServer       map[int32][]packetRewrite          // Rewrites of the bot's packets by go-mc ID, in order
+	answers        map[int32]packetAnswer             // Answers to server packets the bot has no counterpart of, by server ID
+	replies        queue.Queue[pk.Packet]             // Packets to the server as they are, for the answers
+	config         *configIDs                         // Configuration packet IDs of 1.20.2 to 1.20.4, nil for others
+	ids            *idRemap                           // Numbers of the server's blocks and items, nil to keep them
+	dimensions     atomic.Pointer[map[string]int32]   // Dimension type indexes by name, for older servers
+	dimensionTypes atomic.Pointer[[]dimensionType]    // Dimension types, for servers of before 1.19 sending them whole
+	chatTypes      atomic.Pointer[map[int32]chatType] // Chat types by ID, for servers of 1.19 to 1.19.2 decorating chat apart
+	biomes         atomic.Pointer[map[int32]int32]    // Biome indexes by ID, for servers of before 1.18 numbering them with gaps
+	clicks         oldClicks                          // Stacks of the containers and click numbers, for servers of before 1.17
+	eid            atomic.Int32                       // The bot's entity ID, for the packets of older servers naming it
+	sneaking       atomic.Bool                        // Whether the bot sneaks, for older servers taking it apart from steering
+	dropped        sync.Map                           // Names of the packets reported dropped
+}
+
+// newProtocolCodec returns the codec for a server speaking protocol, translating packet IDs
+// with report, or keeping them for a nil report, and renumbering blocks and items with ids
+// unless it is nil
+func newProtocolCodec(protocol int32, release string, report *packetReport, ids *idRemap, logger *log.Logger) (*protocolCodec, error) {
+	c := &protocolCodec{
+		protocol:   protocol,
+		release:    release,
+		log:        logger,
+		ids:        ids,
+		fromServer: make(map[int32][]packetRewrite),
+		toServer:   make(map[int32][]packetRewrite),
+		answers:    make(map[int32]packetAnswer),
+	}
+	if report == nil {
+		return c, nil
+	}
+	clientbound, serverbound := (*report)["play"]["clientbound"], (*report)["play"]["serverbound"]
+	if len(clientbound) == 0 || len(serverbound) == 0 {
+		return nil, errors.New("the packets report has no play packets both ways")
+	}
+	changes := layoutChangesFor(protocol)
+
+	// The server's packets go through the changes from its layout to the bot's, then get the
+	// bot's numbers and go-mc's ID
+	native := make(map[string]int32, len(playClientbound))
+	for id, name := range playClientbound {
+		native[name] = int32(id)
+	}
+	c.clientbound = make(map[int32]int32, len(clientbound))
+	c.serverNames = make(map[int32]string, len(clientbound))
+	for full, p := range clientbound {
+		c.serverNames[p.ID] = full
+		name := strings.TrimPrefix(full, "minecraft:")
+		var (
+			rewrites []packetRewrite
+			answer   packetAnswer
+		)
+		for _, change := range changes {
+			if rw, ok := change.fromServer[name]; ok {
+				rewrites = append(rewrites, rw)
+			}
+			if a, ok := change.answered[name]; ok {
+				answer = a
+			}
+			if renamed, ok := change.renamed[name]; ok {
+				name = renamed
+			}
+		}
+		if rw, ok := remapFromServer[name]; ok && ids != nil {
+			rewrites = append(rewrites, rw)
+		}
+		if id, ok := native[name]; ok {
+			c.clientbound[p.ID] = id
+			if len(rewrites) > 0 {
+				c.fromServer[p.ID] = rewrites
+			}
+		} else if answer != nil {
+			c.answers[p.ID] = answer
+		}
+	}
+
+	// The bot's packets the other way round. Those the server lacks still go through the
+	// rewrites, which may turn them into another packet.
+	c.serverbound = make(map[int32]int32, len(playServerbound))
+	c.serverboundIDs = make(map[string]int32, len(serverbound))
+	for full, p := range serverbound {
+		c.serverboundIDs[strings.TrimPrefix(full, "minecraft:")] = p.ID
+	}
+	for id, name := range playServerbound {
+		if p, ok := serverbound["minecraft:"+name]; ok {
+			c.serverbound[int32(id)] = p.ID
+		}
+		var rewrites []packetRewrite
+		if rw, ok := remapToServer[name]; ok && ids != nil {
+			rewrites = append(rewrites, rw)
+		}
+		for _, change := range slices.Backward(changes) {
+			if rw, ok := change.toServer[name]; ok {
+				rewrites = append(rewrites, rw)
+			}
+		}
+		if len(rewrites) > 0 {
+			c.toServer[int32(id)] = rewrites
+		}
+	}
+
+	if protocol >= configPhaseProtocol && protocol < configProtocol {
+		var err error
+		if c.config, err = report.configIDs(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// translates reports whether the codec changes any packet
+func (c *protocolCodec) translates() bool {
+	return c.clientbound != nil || c.serverbound != nil || len(c.fromServer) > 0 || len(c.toServer) > 0
+}
+
+// fromServerPacket turns a packet of the server into the one go-mc knows, false to drop it
+func (c *protocolCodec) fromServerPacket(p *pk.Packet) bool {
+	server := p.ID
+	if c.clientbound != nil {
+		id, ok := c.clientbound[p.ID]
+		if answer, answered := c.answers[p.ID]; !ok && answered {
+			if err := answer(c, *p); err != nil {
+				c.log.Printf("⚠️ Failed to answer a %s packet of the server: %v", c.serverNames[server], err)
+			}
+			return false
+		}
+		if !ok {
+			c.reportDropped(c.serverNames[p.ID], "the bot doesn't know them")
+			return false
+		}
+		p.ID = id
+	}
+	for _, rw := range c.fromServer[server] {
+		id := p.ID
+		err := rw(c, p)
+		if errors.Is(err, errUntranslated) {
+			c.reportDropped(c.serverNames[server], "the codec doesn't translate their layout")
+			return false
+		}
+		if err != nil {
+			c.log.Printf("⚠️ Dropped a %s packet of the server: %v", playClientbound[p.ID], err)
+			return false
+		}
+		if p.ID != id { // Turned into another packet, in the bot's layout already
+			break
+		}
+	}
+	return true
+}
+
+// toServerPacket turns a packet of the bot into the one the server knows, false to drop it
+func (c *protocolCodec) toServerPacket(p *pk.Packet) bool {
+	for _, rw := range c.toServer[p.ID] {
+		id := p.ID
+		err := rw(c, p)
+		if errors.Is(err, errUntranslated) {
+			c.reportDropped("minecraft:"+playServerbound[p.ID], "the codec doesn't translate their layout")
+			return false
+		}
+		if err != nil {
+			c.log.Printf("⚠️ Dropped a %s packet to the server: %v", playServerbound[p.ID], err)
+			return false
+		}
+		if p.ID != id { // Turned into another packet, in the server's layout already
+			break
+		}
+	}
+	if c.serverbound != nil {
+		id, ok := c.serverbound[p.ID]
+		if !ok {
+			c.reportDropped("minecraft:"+playServerbound[p.ID], "the server doesn't know them")
+			return false
+		}
+		p.ID = id
+	}
+	return true
+}
+
+// reply sends the server a packet the bot has no counterpart of, named as in the server's
+// packets report
+func (c *protocolCodec) reply(name string, fields ...pk.FieldEncoder) error {
+	id, ok := c.serverboundIDs[name]
+	if !ok || c.replies == nil {
+		return fmt.Errorf("the server has no %s packet", name)
+	}
+	if !c.replies.Push(pk.Marshal(id, fields...)) {
+		return errors.New("the connection is closed")
+	}
+	return nil
+}
+
+// reportDropped logs the first packet of a kind that is dropped
+func (c *protocolCodec) reportDropped(name, why string) {
+	if _, seen := c.dropped.LoadOrStore(name, true); !seen {
+		c.log.Printf("🔀 Dropping %s packets for %s, %s", name, c.release, why)
+	}
+}
+
+// serverID returns the ID the server gives the packet go-mc knows as id, -1 when the server
+// has no such packet
+func (c *protocolCodec) serverID(id packetid.ClientboundPacketID) int32 {
+	if c.clientbound == nil {
+		return int32(id)
+	}
+	for server, ours := range c.clientbound {
+		if ours == int32(id) {
+			return server
+		}
+	}
+	return -1
+}
+
+// client names what the bot joins as, for logs
+func (c *protocolCodec) client() string {
+	if c.forge != "" {
+		return "Forge"
+	}
+	return "vanilla"
+}
+
+// joinOptions sets up the options of a join to speak the server's protocol
+func (c *protocolCodec) joinOptions(opts *bot.JoinOptions) {
+	if c.protocol != bot.ProtocolVersion || c.forge != "" {
+		opts.MCDialer = handshakeDialer{MCDialer: opts.MCDialer, protocol: c.protocol, marker: c.forge}
+	}
+}
+
+// dialer wraps d to bridge the login and configuration of a server of before 1.20.5, which
+// go-mc doesn't speak
+func (c *protocolCodec) dialer(d mcnet.MCDialer) mcnet.MCDialer {
+	if c.protocol >= configProtocol {
+		return d
+	}
+	return bridgeDialer{MCDialer: d, c: c}
+}
+
+// translated wraps the queues of the play packets read from and written to the server to
+// translate their packets when the server speaks another protocol
+func (c *protocolCodec) translated(read, write queue.Queue[pk.Packet]) (queue.Queue[pk.Packet], queue.Queue[pk.Packet]) {
+	if !c.translates() {
+		return read, write
+	}
+	c.replies = write
+	return translatedQueue{Queue: read, translate: c.fromServerPacket}, translatedQueue{Queue: write, translate: c.toServerPacket}
+}
+
+// translatedQueue is a packet queue translating the packets pushed into it. go-mc pushes the
+// packets it reads into one queue and those the bot writes into another.
+type translatedQueue struct {
+	queue.Queue[pk.Packet]
+	translate func(*pk.Packet) bool
+}
+
+func (q translatedQueue) Push(p pk.Packet) bool {
+	if !q.translate(&p) {
+		return true
+	}
+	return q.Queue.Push(p)
+}
+
+// handshakeDialer connects with another dialer and makes the handshake announce protocol
+// instead of the one go-mc speaks, and the FML handshake with marker
+type handshakeDialer struct {
+	mcnet.MCDialer
+	protocol int32
+	marker   string
+}
+
+func (d handshakeDialer) DialMCContext(ctx context.Context, addr string) (*mcnet.Conn, error) {
+	conn, err := d.MCDialer.DialMCContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	conn.Writer = &handshakeWriter{Writer: conn.Writer, protocol: d.protocol, marker: d.marker}
+	return conn, nil
+}
+
+// handshakeWriter rewrites the protocol of the first packet written, the handshake, and
+// appends the marker to its server address. go-mc writes a packet at once and the handshake
+// uncompressed.
+type handshakeWriter struct {
+	io.Writer
+	protocol int32
+	marker   string
+	done     bool
+}
+
+func (w *handshakeWriter) Write(data []byte) (int, error) {
+	if w.done {
+		return w.Writer.Write(data)
+	}
+	w.done = true
+	var (
+		length, id, protocol pk.VarInt
+		host                 pk.String
+	)
+	r := bytes.NewReader(data)
+	if _, err := (pk.Tuple{&length, &id, &protocol, &host}).ReadFrom(r); err != nil || id != 0 {
+		return w.Writer.Write(data)
+	}
+	var body bytes.Buffer
+	pk.VarInt(w.protocol).WriteTo(&body)
+	(host + pk.String(w.marker)).WriteTo(&body)
+	r.WriteTo(&body)
+	p := pk.Packet{ID: 0, Data: body.Bytes()}
+	if err := p.Pack(w.Writer, -1); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// packetReport is the packets report of the vanilla data generator: the ID of every packet by
+// state, direction and name
+type packetReport map[string]map[string]map[string]struct {
+	ID int32 `json:"protocol_id"`
+}
+
+// loadPacketReport reads a packets report
+func loadPacketReport(path string) (*packetReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r packetReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(r["play"]) == 0 {
+		return nil, fmt.Errorf("%s has no play packets, is it a packets report?", path)
+	}
+	return &r, nil
+}
+
+// configIDs maps the IDs of the configuration packets between the report and go-mc, by name
+func (r packetReport) configIDs() (*configIDs, error) {
+	clientbound, serverbound := r["configuration"]["clientbound"], r["configuration"]["serverbound"]
+	if len(clientbound) == 0 || len(serverbound) == 0 {
+		return nil, errors.New("the packets report has no configuration packets both ways")
+	}
+	ids := &configIDs{
+		clientbound: make(map[int32]int32, len(configClientbound)),
+		serverbound: make(map[int32]int32, len(configServerbound)),
+	}
+	for native, name := range configClientbound {
+		if p, ok := clientbound["minecraft:"+name]; ok {
+			ids.clientbound[p.ID] = int32(native)
+		}
+	}
+	for native, name := range configServerbound {
+		if p, ok := serverbound["minecraft:"+name]; ok {
+			ids.serverbound[int32(native)] = p.ID
+		}
+	}
+	return ids, nil
+}
+
+// configIDs are the IDs of the configuration packets of a server of 1.20.2 to 1.20.4
+type configIDs struct {
+	clientbound map[int32]int32 // go-mc ID of every server packet ID
+	serverbound map[int32]int32 // Server ID of every go-mc packet ID
+}
+
+// serverStatus is what the bot reads of a server list ping response
+type serverStatus struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int32  `json:"protocol"`
+	} `json:"version"`
+	Forge *forgeData `json:"forgeData"`
+}
+
+// pingStatus asks the server at addr for its status, connecting with d. go-mc's ping always
+// connects directly, which would bypass the bot's proxy.
+func pingStatus(d mcnet.MCDialer, addr string) (*serverStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	conn, err := d.DialMCContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.Socket.SetDeadline(deadline)
+
+	host, port := addr, uint64(mcnet.DefaultPort)
+	if h, p, err := net.SplitHostPort(addr); err == nil {
+		host = h
+		if port, err = strconv.ParseUint(p, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid port %q", p)
+		}
+	}
+	if err := conn.WritePacket(pk.Marshal(
+		0, // Handshake
+		pk.VarInt(bot.ProtocolVersion),
+		pk.String(host),
+		pk.UnsignedShort(port),
+		pk.VarInt(1), // Status
+	)); err != nil {
+		return nil, err
+	}
+	if err := conn.WritePacket(pk.Marshal(packetid.ServerboundStatusStatusRequest)); err != nil {
+		return nil, err
+	}
+	var (
+		p    pk.Packet
+		resp pk.String
+	)
+	if err := conn.ReadPacket(&p); err != nil {
+		return nil, err
+	}
+	if err := p.Scan(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read the ping response: %w", err)
+	}
+	var st serverStatus
+	if err := json.Unmarshal([]byte(resp), &st); err != nil {
+		return nil, fmt.Errorf("failed to parse the ping response: %w", err)
+	}
+	return &st, nil
+}
+
+// negotiateProtocol finds out which protocol the server at addr speaks, asking it with a
+// server list ping, and returns the codec for it. A replaying bot takes the protocol of the
+// recording.
+func (b *Bot) negotiateProtocol(addr string) (*protocolCodec, error) {
+	if b.replayAddr != "" {
+		return b.codecFor(b.replayProtocol, "the recording")
+	}
+	st, err := pingStatus(b.netDialer(), addr)
+	if err != nil {
+		b.log.Printf("⚠️ Failed to ask %s for its version, joining with protocol %d: %v", addr, bot.ProtocolVersion, err)
+		c, _ := newProtocolCodec(bot.ProtocolVersion, version, nil, nil, b.log) // Can't fail without a report
+		c.forge = b.forgeMarker(nil)
+		return c, nil
+	}
+	c, err := b.codecFor(st.Version.Protocol, st.Version.Name)
+	if err != nil {
+		return nil, err
+	}
+	c.forge = b.forgeMarker(st.Forge)
+	if st.Forge != nil {
+		c.modded = st.Forge.String()
+		b.log.Printf("🧩 %s runs %s, joining as a %s client", addr, c.modded, c.client())
+		if required := st.Forge.requiredChannels(); len(required) > 0 && c.forge == "" {
+			b.log.Printf("⚠️ Mods of %s need their channels on the client and may keep the bot out: %s", addr, strings.Join(required, ", "))
+		}
+	}
+	return c, nil
+}
+
+// codecFor returns the codec for a server of release speaking protocol, or an error saying
+// why the bot can't join it
+func (b *Bot) codecFor(protocol int32, release string) (*protocolCodec, error) {
+	if protocol == bot.ProtocolVersion || protocol == protocolVersion {
+		return newProtocolCodec(protocol, release, nil, nil, b.log)
+	}
+	if protocol < minProtocol {
+		return nil, fmt.Errorf("%s speaks protocol %d, the bot joins Minecraft %s (protocol %d) and newer", release, protocol, minRelease, minProtocol)
+	}
+	if b.cfg.ProtocolsDir == "" {
+		return nil, fmt.Errorf("%s speaks protocol %d, the bot %d, and protocols_dir is empty", release, protocol, protocolVersion)
+	}
+	path := filepath.Join(b.cfg.ProtocolsDir, fmt.Sprintf("%d.json", protocol))
+	report, err := loadPacketReport(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s speaks protocol %d, the bot %d: put the packets report of %s in %s to translate", release, protocol, protocolVersion, release, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids, missing, err := loadIDRemap(b.cfg.ProtocolsDir, protocol)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.cfg.ProtocolsDir, err)
+	}
+	c, err := newProtocolCodec(protocol, release, report, ids, b.log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	b.log.Printf("🔀 %s speaks protocol %d, translating the packets of protocol %d with %s", release, protocol, protocolVersion, path)
+	if ids == nil {
+		b.log.Printf("⚠️ No blocks and registries reports of %s in %s, blocks and items keep the bot's numbers", release, b.cfg.ProtocolsDir)
+	} else if len(missing) > 0 {
+		b.log.Printf("🔀 %d blocks and items of %s have no counterpart in the bot's release, they read as air: %s", len(missing), release, describeMissing(missing))
+	}
+	return c, nil
+}
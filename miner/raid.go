@@ -0,0 +1,171 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	raidRadius       = 48.0            // Distance from the bot within which illagers count as a threat
+	raidHold         = 5 * time.Minute // Surface tasks wait this long after the last sign of a raid
+	raidRetreatDepth = 12              // Most blocks the bot digs down to get under cover
+	raidTaskName     = "retreat underground"
+	raidBarKey       = "event.minecraft.raid" // Translation key of the raid boss bar titles
+)
+
+// illagers are the mobs of pillager patrols and raids
+var illagers = map[string]bool{
+	"pillager":   true,
+	"vindicator": true,
+	"evoker":     true,
+	"illusioner": true,
+	"ravager":    true,
+}
+
+// raidWatch holds surface work back while pillagers or a raid are around
+type raidWatch struct {
+	mu    sync.Mutex
+	until time.Time   // When surface tasks may run again
+	clear *time.Timer // Wakes the task queue once the threat is over
+}
+
+// registerRaidHandlers watches for illagers near the bot and raid boss bars. The entity
+// handlers run after the entity tracker's.
+func (b *Bot) registerRaidHandlers() {
+	b.client.Events.AddListener(
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundAddEntity, F: b.onIllagerMoved},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundMoveEntityPos, F: b.onIllagerMoved},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundMoveEntityPosRot, F: b.onIllagerMoved},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundTeleportEntity, F: b.onIllagerMoved},
+		bot.PacketHandler{ID: packetid.ClientboundBossEvent, F: b.onRaidBar},
+	)
+}
+
+// onIllagerMoved raises the alarm when an illager comes near the bot while it works on the
+// surface, and keeps it up while illagers stay near
+func (b *Bot) onIllagerMoved(p pk.Packet) error {
+	var id pk.VarInt
+	if err := p.Scan(&id); err != nil {
+		return err
+	}
+	e, ok := b.entities.entity(int32(id))
+	if !ok || !illagers[entityName(e.Type)] {
+		return nil
+	}
+	x, y, z := b.currentPosition()
+	if math.Hypot(math.Hypot(e.X-x, e.Y-y), e.Z-z) > raidRadius {
+		return nil
+	}
+	// Once the alarm is raised, illagers near the bot underground keep it up
+	if !b.raidThreat() && !b.onSurface() {
+		return nil
+	}
+	b.raidAlarm(fmt.Sprintf("A %s is nearby", entityName(e.Type)), "patrol")
+	return nil
+}
+
+// onRaidBar raises the alarm when the server shows a raid boss bar, which it does to players
+// near a raid
+func (b *Bot) onRaidBar(p pk.Packet) error {
+	var (
+		id     pk.UUID
+		action pk.VarInt
+		title  chat.Message
+	)
+	if err := p.Scan(&id, &action); err != nil {
+		return err
+	}
+	if action != bossBarAdd && action != bossBarTitle {
+		return nil
+	}
+	if err := p.Scan(&id, &action, &title); err != nil {
+		return err
+	}
+	if strings.HasPrefix(title.Translate, raidBarKey) {
+		b.raidAlarm("A raid is going on nearby", "raid")
+	}
+	return nil
+}
+
+// onSurface reports whether the bot runs a surface task or stands under the open sky
+func (b *Bot) onSurface() bool {
+	b.tasks.mu.Lock()
+	current := b.tasks.current
+	b.tasks.mu.Unlock()
+	if current != nil && current.Exposure == exposureSurface {
+		return true
+	}
+	exposed, _ := b.world.skyExposed(b.feetBlock())
+	return exposed
+}
+
+// raidThreat reports whether surface tasks are held back for pillagers or a raid
+func (b *Bot) raidThreat() bool {
+	b.raid.mu.Lock()
+	defer b.raid.mu.Unlock()
+	return time.Now().Before(b.raid.until)
+}
+
+// raidAlarm holds surface tasks back for raidHold. The first sign of a threat interrupts a
+// surface task, sends the bot underground and tells the owner.
+func (b *Bot) raidAlarm(reason, source string) {
+	b.raid.mu.Lock()
+	fresh := !time.Now().Before(b.raid.until)
+	b.raid.until = time.Now().Add(raidHold)
+	if b.raid.clear == nil {
+		b.raid.clear = time.AfterFunc(raidHold, b.raidClear)
+	} else {
+		b.raid.clear.Reset(raidHold)
+	}
+	b.raid.mu.Unlock()
+	if !fresh {
+		return
+	}
+
+	pos := b.feetBlock()
+	b.log.Printf("🏴 %s, surface tasks wait until the area is clear", reason)
+	b.events.emit(eventRaidWarning, map[string]any{"source": source, "reason": reason, "pos": pos})
+	go b.replyTo(raidTaskName, b.actingOwner(), fmt.Sprintf("%s at (%d, %d, %d), heading underground", reason, pos.X, pos.Y, pos.Z))
+
+	if t := b.tasks.requeueCurrent(func(t *task) bool { return t.Exposure == exposureSurface }); t != nil {
+		b.log.Printf("⏸️ Stopped the surface task %s, task #%d starts again once the area is clear", t.Name, t.ID)
+	}
+	if exposed, _ := b.world.skyExposed(b.feetBlock()); exposed {
+		b.enqueueExposedTask(raidTaskName, exposureUnderground, b.retreatUnderground)
+	}
+}
+
+// raidClear lets surface tasks run again once no illager or raid was seen for raidHold
+func (b *Bot) raidClear() {
+	if b.raidThreat() {
+		return
+	}
+	b.log.Println("🏳️ No more pillagers around, surface tasks may run")
+	b.tasks.notify()
+}
+
+// retreatUnderground digs a staircase down until the bot is under cover
+func (b *Bot) retreatUnderground(ctx context.Context) error {
+	start := b.feetBlock()
+	dir := horizontalDirs[0]
+	for feet := start; feet.Y > start.Y-raidRetreatDepth; feet = b.feetBlock() {
+		if exposed, known := b.world.skyExposed(feet); known && !exposed {
+			b.log.Printf("🕳️ Under cover at y=%d", feet.Y)
+			return nil
+		}
+		if err := b.staircaseDown(ctx, feet.Y-1, dir); err != nil {
+			return fmt.Errorf("failed to get underground: %w", err)
+		}
+		dir = towards(start, b.feetBlock())
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package miner
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+// Recipe packet actions
+const (
+	recipesInit   = 0
+	recipesAdd    = 1
+	recipesRemove = 2
+)
+
+// recipeUnlocks keeps the recipes the server unlocked for the bot
+type recipeUnlocks struct {
+	mu       sync.RWMutex
+	unlocked map[string]bool // Recipe IDs, like minecraft:iron_pickaxe
+	known    bool            // Whether the server sent the initial list
+}
+
+// registerRecipeHandler follows the recipe unlock packets
+func (b *Bot) registerRecipeHandler() {
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundRecipe, F: b.onRecipes})
+}
+
+// onRecipes applies an unlock packet: the full list after joining, then recipes added or removed
+func (b *Bot) onRecipes(p pk.Packet) error {
+	var (
+		action   pk.VarInt
+		settings [8]pk.Boolean // Open and filtering flags of the four recipe books
+		ids      []pk.Identifier
+	)
+	fields := []pk.FieldDecoder{&action}
+	for i := range settings {
+		fields = append(fields, &settings[i])
+	}
+	fields = append(fields, pk.Array(&ids))
+	if err := p.Scan(fields...); err != nil {
+		return err
+	}
+
+	r := &b.recipes
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch action {
+	case recipesInit:
+		r.unlocked, r.known = make(map[string]bool, len(ids)), true
+		for _, id := range ids {
+			r.unlocked[string(id)] = true
+		}
+		b.log.Printf("📖 %d recipes unlocked", len(ids))
+	case recipesAdd, recipesRemove:
+		if r.unlocked == nil {
+			r.unlocked = make(map[string]bool)
+		}
+		for _, id := range ids {
+			r.unlocked[string(id)] = action == recipesAdd
+		}
+	}
+	return nil
+}
+
+// permits reports whether a recipe is unlocked. Vanilla names recipes after what they make,
+// alternatives like minecraft:stick_from_bamboo_item adding a suffix.
+func (r *recipeUnlocks) permits(recipe craft.Recipe) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.unlocked[recipe.Result] {
+		return true
+	}
+	for id, ok := range r.unlocked {
+		if ok && strings.HasPrefix(id, recipe.Result+"_from_") {
+			return true
+		}
+	}
+	return false
+}
+
+// craftBook returns the recipes the planner may use: all it knows, or with limited_crafting only
+// those the server unlocked once it sent the list
+func (b *Bot) craftBook() *craft.Book {
+	b.recipes.mu.RLock()
+	known := b.recipes.known
+	b.recipes.mu.RUnlock()
+	if !b.cfg.LimitedCrafting || !known {
+		return craft.Default()
+	}
+	return craft.Default().Filter(b.recipes.permits)
+}
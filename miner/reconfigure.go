@@ -0,0 +1,225 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/net/queue"
+)
+
+// reconfiguration follows the configurations a server starts again in game, like Velocity
+// and BungeeCord do when they move a player to another backend server. go-mc only configures
+// the bot while joining, so the packet queues of the connection set the packets of a later
+// configuration aside for reconfigure and hold back what the bot writes meanwhile. The
+// configuration packets are numbered alike from 1.20.5 on, and the bridge of the codec gives
+// those of older servers go-mc's numbers.
+type reconfiguration struct {
+	configuring atomic.Bool // The server sends configuration packets, which go to config
+	holding     atomic.Bool // The bot's play packets are dropped until it finished configuring
+
+	startID int32                  // The server's ID of the packet starting a configuration
+	config  queue.Queue[pk.Packet] // Configuration packets of the server
+	play    queue.Queue[pk.Packet] // Play packets to the server, translated
+	raw     queue.Queue[pk.Packet] // Packets to the server as they are
+}
+
+// configQueues sets up the packet queues of a join to follow later configurations, and to
+// translate play packets with codec
+func (b *Bot) configQueues(opts *bot.JoinOptions, codec *protocolCodec) {
+	r := &reconfiguration{
+		startID: codec.serverID(packetid.ClientboundStartConfiguration),
+		config:  queue.NewLinkedQueue[pk.Packet](),
+		raw:     queue.NewLinkedQueue[pk.Packet](),
+	}
+	var read queue.Queue[pk.Packet]
+	read, r.play = codec.translated(queue.NewLinkedQueue[pk.Packet](), r.raw)
+	opts.QueueRead = configReadQueue{Queue: read, r: r}
+	opts.QueueWrite = configWriteQueue{Queue: r.play, r: r}
+	b.reconfig.Store(r)
+}
+
+// configReadQueue passes the play packets the server sends on to the game and sets those of
+// a configuration aside
+type configReadQueue struct {
+	queue.Queue[pk.Packet]
+	r *reconfiguration
+}
+
+func (q configReadQueue) Push(p pk.Packet) bool {
+	if q.r.configuring.Load() {
+		if p.ID == int32(packetid.ClientboundConfigFinishConfiguration) {
+			q.r.configuring.Store(false)
+		}
+		return q.r.config.Push(p)
+	}
+	if p.ID == q.r.startID {
+		q.r.configuring.Store(true)
+		q.r.holding.Store(true)
+	}
+	return q.Queue.Push(p)
+}
+
+func (q configReadQueue) Close() {
+	q.r.config.Close()
+	q.Queue.Close()
+}
+
+// configWriteQueue drops the play packets the bot writes while the server configures it
+type configWriteQueue struct {
+	queue.Queue[pk.Packet]
+	r *reconfiguration
+}
+
+func (q configWriteQueue) Push(p pk.Packet) bool {
+	if q.r.holding.Load() {
+		return true
+	}
+	return q.Queue.Push(p)
+}
+
+// registerReconfigureHandler configures the bot again when the server asks it to
+func (b *Bot) registerReconfigureHandler() {
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundStartConfiguration, F: b.reconfigure})
+}
+
+// switching reports whether the bot is between two servers, so no task starts
+func (b *Bot) switching() bool {
+	r := b.reconfig.Load()
+	return r != nil && r.holding.Load() || b.transferring.Load()
+}
+
+// reconfigure answers the configuration the server started, blocking the game until it is
+// done. The task that was running starts over once the bot is back in game, on the server
+// the configuration led to.
+func (b *Bot) reconfigure(pk.Packet) error {
+	b.log.Println("🔀 Server is configuring the bot again, like a proxy moving it to another server")
+	if t := b.tasks.requeueCurrent(func(*task) bool { return true }); t != nil {
+		b.log.Printf("⏸️ Stopped %s, task #%d starts again once the bot is back in game", t.Name, t.ID)
+	}
+	r := b.reconfig.Load()
+	if !r.play.Push(pk.Marshal(packetid.ServerboundConfigurationAcknowledged)) {
+		return errors.New("failed to acknowledge the configuration")
+	}
+	for {
+		p, ok := r.config.Pull()
+		if !ok {
+			return errors.New("connection closed while configuring")
+		}
+		done, err := b.onConfigPacket(p)
+		if err != nil {
+			return fmt.Errorf("configuration: %w", err)
+		}
+		if done {
+			break
+		}
+	}
+	r.holding.Store(false)
+	b.tasks.notify()
+	b.log.Println("🔀 Configured, back in game")
+	return nil
+}
+
+// onConfigPacket handles a packet of a configuration the way go-mc does while joining and
+// reports whether it finished the configuration
+func (b *Bot) onConfigPacket(p pk.Packet) (bool, error) {
+	r := b.reconfig.Load()
+	switch packetid.ClientboundPacketID(p.ID) {
+	case packetid.ClientboundConfigFinishConfiguration:
+		r.raw.Push(pk.Marshal(packetid.ServerboundConfigFinishConfiguration))
+		return true, nil
+
+	case packetid.ClientboundConfigKeepAlive:
+		var id pk.Long
+		if err := p.Scan(&id); err != nil {
+			return false, fmt.Errorf("failed to parse keep alive: %w", err)
+		}
+		r.raw.Push(pk.Marshal(packetid.ServerboundConfigKeepAlive, id))
+
+	case packetid.ClientboundConfigPing:
+		var id pk.Int
+		if err := p.Scan(&id); err != nil {
+			return false, fmt.Errorf("failed to parse ping: %w", err)
+		}
+		r.raw.Push(pk.Marshal(packetid.ServerboundConfigPong, id))
+
+	case packetid.ClientboundConfigDisconnect:
+		var reason chat.Message
+		if err := p.Scan(&reason); err != nil {
+			return false, fmt.Errorf("failed to parse disconnect: %w", err)
+		}
+		return false, bot.DisconnectErr(reason)
+
+	case packetid.ClientboundConfigTransfer:
+		var (
+			host pk.String
+			port pk.VarInt
+		)
+		if err := p.Scan(&host, &port); err != nil {
+			return false, fmt.Errorf("failed to parse transfer: %w", err)
+		}
+		addr := net.JoinHostPort(string(host), strconv.Itoa(int(port)))
+		b.log.Printf("🔀 Server transfers the bot to %s", addr)
+		b.transferring.Store(true)
+		return false, &transferError{addr: addr}
+
+	case packetid.ClientboundConfigCookieRequest:
+		var key pk.Identifier
+		if err := p.Scan(&key); err != nil {
+			return false, fmt.Errorf("failed to parse cookie request: %w", err)
+		}
+		cookie, ok := b.client.Cookies[string(key)]
+		r.raw.Push(pk.Marshal(
+			packetid.ServerboundConfigCookieResponse,
+			key,
+			pk.OptionEncoder[pk.ByteArray]{Has: pk.Boolean(ok), Val: pk.ByteArray(cookie)},
+		))
+
+	// Laid out like their packets in game
+	case packetid.ClientboundConfigStoreCookie:
+		return false, b.onStoreCookie(p)
+	case packetid.ClientboundConfigCustomPayload:
+		if reply, ok := b.forgeReply(p); ok {
+			r.raw.Push(reply)
+		}
+		return false, b.onCustomPayload(p)
+
+	case packetid.ClientboundConfigRegistryData:
+		var id pk.Identifier
+		data := bytes.NewReader(p.Data)
+		if _, err := id.ReadFrom(data); err != nil {
+			return false, fmt.Errorf("failed to parse registry data: %w", err)
+		}
+		reg := b.client.Registries.Registry(string(id))
+		if reg == nil {
+			return false, fmt.Errorf("unknown registry %s", id)
+		}
+		if _, err := reg.ReadFrom(data); err != nil {
+			return false, fmt.Errorf("failed to read registry %s: %w", id, err)
+		}
+
+	case packetid.ClientboundConfigUpdateEnabledFeatures:
+		var features []pk.Identifier
+		if err := p.Scan(pk.Array(&features)); err != nil {
+			return false, fmt.Errorf("failed to parse enabled features: %w", err)
+		}
+		b.onFeatures(features)
+	case packetid.ClientboundConfigUpdateTags:
+		return false, b.onTags(p.Data)
+
+	case packetid.ClientboundConfigSelectKnownPacks:
+		var packs []bot.DataPack
+		if err := p.Scan(pk.Array(&packs)); err != nil {
+			return false, fmt.Errorf("failed to parse known packs: %w", err)
+		}
+		r.raw.Push(pk.Marshal(packetid.ServerboundConfigSelectKnownPacks, pk.Array(b.client.ConfigHandler.SelectDataPacks(packs))))
+	}
+	return false, nil
+}
@@ -0,0 +1,331 @@
+package miner
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	recordingMagic   = "MMRC"
+	recordingVersion = 1
+	recordingExt     = ".mcrec"
+	recordingFlush   = time.Second     // Longest a recorded packet waits in memory before it is written out
+	replayLinger     = 5 * time.Second // Longest the replay waits for the bot to hang up at the end
+
+	recordFromServer = 0
+	recordToServer   = 1
+)
+
+// packetRecorder writes the bytes a connection receives and sends, with the time since the
+// connection opened, to a gzipped recording file
+type packetRecorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	buf     *bufio.Writer
+	zw      *gzip.Writer
+	start   time.Time
+	flushed time.Time
+	err     error // First write error, after which nothing more is recorded
+}
+
+// newPacketRecorder creates a recording file for a connection to server speaking protocol: a
+// header of magic, version, server, protocol and start time, then the recorded data
+func newPacketRecorder(path, server string, protocol int32) (*packetRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &packetRecorder{f: f, buf: bufio.NewWriter(f), start: time.Now()}
+	r.zw = gzip.NewWriter(r.buf)
+	r.flushed = r.start
+	_, err = pk.Tuple{
+		pk.String(recordingMagic),
+		pk.VarInt(recordingVersion),
+		pk.String(server),
+		pk.VarInt(protocol),
+		pk.Long(r.start.UnixMilli()),
+	}.WriteTo(r.zw)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// record adds data that went in the direction dir to the recording
+func (r *packetRecorder) record(dir byte, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	now := time.Now()
+	_, r.err = pk.Tuple{
+		pk.Byte(dir),
+		pk.VarLong(now.Sub(r.start).Microseconds()),
+		pk.ByteArray(data),
+	}.WriteTo(r.zw)
+	if r.err == nil && now.Sub(r.flushed) >= recordingFlush {
+		r.flushed = now
+		if r.err = r.zw.Flush(); r.err == nil {
+			r.err = r.buf.Flush()
+		}
+	}
+}
+
+// Close ends the recording and closes its file
+func (r *packetRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.err
+	if err == nil {
+		err = r.zw.Close()
+	}
+	if err == nil {
+		err = r.buf.Flush()
+	}
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	r.f, r.err = nil, errors.New("recording closed")
+	return err
+}
+
+// recordedConn is a connection whose traffic is recorded
+type recordedConn struct {
+	net.Conn
+	rec *packetRecorder
+}
+
+func (c recordedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.rec.record(recordFromServer, p[:n])
+	}
+	return n, err
+}
+
+func (c recordedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.rec.record(recordToServer, p[:n])
+	}
+	return n, err
+}
+
+func (c recordedConn) Close() error {
+	err := c.Conn.Close()
+	c.rec.Close()
+	return err
+}
+
+// recordingDialer connects to servers and records every connection to a file of record_dir.
+// The bytes are recorded as they pass the socket, before compression is undone, so a recording
+// holds the login and configuration too.
+type recordingDialer struct{ b *Bot }
+
+func (d recordingDialer) DialMCContext(ctx context.Context, addr string) (*mcnet.Conn, error) {
+	conn, err := d.b.netDialer().DialMCContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	b := d.b
+	name := fmt.Sprintf("%s_%s_%s%s", b.cfg.Username, unsafeFileChars.ReplaceAllString(addr, "_"), time.Now().Format("20060102-150405"), recordingExt)
+	path := filepath.Join(b.cfg.RecordDir, name)
+	rec, err := newPacketRecorder(path, addr, b.serverProtocol)
+	if err != nil {
+		// Playing matters more than the recording
+		b.log.Printf("⚠️ Not recording the connection: %v", err)
+		return conn, nil
+	}
+	socket := recordedConn{Conn: conn.Socket, rec: rec}
+	conn.Socket, conn.Reader, conn.Writer = socket, socket, socket
+	b.log.Printf("📼 Recording the packets of %s to %s", addr, path)
+	return conn, nil
+}
+
+// replayDialer connects to the replay server whatever the address, so a bot replaying a
+// recording never reaches the real server, not even when the recording transfers it
+type replayDialer string
+
+func (d replayDialer) DialMCContext(ctx context.Context, _ string) (*mcnet.Conn, error) {
+	return mcnet.DefaultDialer.DialMCContext(ctx, string(d))
+}
+
+// dialer returns how the bot connects to its server
+func (b *Bot) dialer() mcnet.MCDialer {
+	switch {
+	case b.replayAddr != "":
+		return replayDialer(b.replayAddr)
+	case b.cfg.RecordDir != "":
+		return recordingDialer{b}
+	}
+	return b.netDialer()
+}
+
+// recordedChunk is data a recorded connection received or sent
+type recordedChunk struct {
+	dir  byte
+	at   time.Duration // Since the connection opened
+	data []byte
+}
+
+// recording reads a recording file chunk by chunk
+type recording struct {
+	f        *os.File
+	zr       *gzip.Reader
+	server   string
+	protocol int32
+	start    time.Time
+}
+
+// openRecording opens a recording file and reads its header
+func openRecording(path string) (*recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a recording: %w", path, err)
+	}
+	var (
+		magic    pk.String
+		ver      pk.VarInt
+		server   pk.String
+		protocol pk.VarInt
+		start    pk.Long
+	)
+	_, err = (pk.Tuple{&magic, &ver, &server, &protocol, &start}).ReadFrom(zr)
+	switch {
+	case err != nil:
+	case magic != recordingMagic:
+		err = errors.New("not a recording")
+	case ver != recordingVersion:
+		err = fmt.Errorf("recording version %d, expected %d", ver, recordingVersion)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &recording{f: f, zr: zr, server: string(server), protocol: int32(protocol), start: time.UnixMilli(int64(start))}, nil
+}
+
+// next returns the next chunk of the recording, io.EOF at its end
+func (r *recording) next() (recordedChunk, error) {
+	var (
+		dir  pk.Byte
+		at   pk.VarLong
+		data pk.ByteArray
+	)
+	if _, err := dir.ReadFrom(r.zr); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF // A recording cut short by a crash ends where its data does
+		}
+		return recordedChunk{}, err
+	}
+	if _, err := (pk.Tuple{&at, &data}).ReadFrom(r.zr); err != nil {
+		return recordedChunk{}, err
+	}
+	return recordedChunk{dir: byte(dir), at: time.Duration(at) * time.Microsecond, data: data}, nil
+}
+
+func (r *recording) Close() error {
+	return r.f.Close()
+}
+
+// Replay runs a bot for cfg against a recording made with record_dir instead of a server:
+// what the server sent is sent again at the recorded pace divided by speed (no waiting for a
+// speed of 0), and what the bot sends is dropped. The bot keeps nothing, it runs without
+// state database, audit log, world cache, maps and recording; its HTTP API stays to watch it.
+func Replay(ctx context.Context, cfg Config, path string, speed float64) error {
+	rec, err := openRecording(path)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	cfg.Server = rec.server
+	cfg.StateDB, cfg.AuditLog, cfg.WorldCacheDir, cfg.MapsDir, cfg.RecordDir = "", "", "", "", ""
+	m := New(cfg)
+	b := m.bot
+	b.replayAddr, b.replayProtocol = ln.Addr().String(), rec.protocol
+	b.log.Printf("📼 Replaying %s, recorded on %s at %s with protocol %d", path, rec.server, rec.start.Format(time.DateTime), rec.protocol)
+
+	go b.serveReplay(ln, rec, speed)
+	return m.Run(ctx)
+}
+
+// serveReplay sends the recorded server side of a connection to the first connection
+// accepted and closes it at the end of the recording
+func (b *Bot) serveReplay(ln net.Listener, rec *recording, speed float64) {
+	conn, err := ln.Accept()
+	ln.Close() // Joining again, like after a transfer, finds nothing to connect to
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	drained := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(drained)
+	}()
+
+	start := time.Now()
+	sent := 0
+	for {
+		c, err := rec.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			b.log.Printf("⚠️ The recording broke off: %v", err)
+			break
+		}
+		if c.dir != recordFromServer {
+			continue
+		}
+		if speed > 0 {
+			time.Sleep(time.Until(start.Add(time.Duration(float64(c.at) / speed))))
+		}
+		if _, err := conn.Write(c.data); err != nil {
+			return // The bot left
+		}
+		sent += len(c.data)
+	}
+	b.log.Printf("📼 Replay done, %d bytes of the server sent", sent)
+
+	// Closing with what the bot sent unread resets the connection, and the bot may not have
+	// read the end yet: only stop sending and give it time to hang up
+	if tc, ok := conn.(*net.TCPConn); ok {
+		tc.CloseWrite()
+	}
+	select {
+	case <-drained:
+	case <-time.After(replayLinger):
+	}
+}
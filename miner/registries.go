@@ -0,0 +1,338 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+// Tags the server sorts blocks by tool and harvest tier, and tools by kind with
+var (
+	mineableTags = map[string]string{
+		"minecraft:mineable/pickaxe": "pickaxe",
+		"minecraft:mineable/axe":     "axe",
+		"minecraft:mineable/shovel":  "shovel",
+		"minecraft:mineable/hoe":     "hoe",
+	}
+	tierTags = map[string]int{
+		"minecraft:needs_stone_tool":   tierStone,
+		"minecraft:needs_iron_tool":    tierIron,
+		"minecraft:needs_diamond_tool": tierDiamond,
+	}
+	toolTags = []string{"minecraft:pickaxes", "minecraft:axes", "minecraft:shovels", "minecraft:hoes"}
+)
+
+// serverData is what the server said about its game while configuring the bot: the feature
+// flags it enabled and the tags of its blocks and items. Servers don't send the numeric IDs of
+// blocks and items, those come from the bundled data of the bot's version, which the tags are
+// resolved with when the server speaks it too.
+type serverData struct {
+	mu       sync.RWMutex
+	features []string
+	tags     map[string]map[string][]string // Registry to tag to the names in it
+	tools    map[string]registry.Block      // Tool and tier of the blocks the tags sort, by name
+	skipped  int32                          // Protocol whose tags weren't resolved, 0 when they were
+}
+
+// registerServerDataHandlers takes the feature flags go-mc reads while joining and the tags
+// servers send again in game after /reload
+func (b *Bot) registerServerDataHandlers() {
+	b.client.ConfigHandler = configHandler{DefaultConfigHandler: bot.NewDefaultConfigHandler(), b: b}
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundUpdateTags, F: func(p pk.Packet) error {
+		return b.onTags(p.Data)
+	}})
+}
+
+// configHandler is the go-mc configuration handler of a bot, passing the feature flags on
+type configHandler struct {
+	*bot.DefaultConfigHandler
+	b *Bot
+}
+
+func (h configHandler) EnableFeature(features []pk.Identifier) {
+	h.b.onFeatures(features)
+}
+
+// onFeatures keeps the feature flags the server enabled and names the experimental ones
+func (b *Bot) onFeatures(features []pk.Identifier) {
+	names := make([]string, len(features))
+	var experimental []string
+	for i, f := range features {
+		names[i] = string(f)
+		if names[i] != "minecraft:vanilla" {
+			experimental = append(experimental, strings.TrimPrefix(names[i], "minecraft:"))
+		}
+	}
+	slices.Sort(names)
+	b.data.mu.Lock()
+	changed := !slices.Equal(b.data.features, names)
+	b.data.features = names
+	b.data.mu.Unlock()
+	if changed && len(experimental) > 0 {
+		b.log.Printf("🧪 Server enabled experimental features: %s", strings.Join(experimental, ", "))
+	}
+}
+
+// onTags reads an update tags packet. The tags of blocks and items replace those of the bundled
+// data, unless the server numbers blocks and items differently than the bot's version does.
+func (b *Bot) onTags(data []byte) error {
+	tags, err := readTags(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse tags: %w", err)
+	}
+	if b.serverProtocol != bot.ProtocolVersion {
+		b.data.mu.Lock()
+		logged := b.data.skipped == b.serverProtocol
+		b.data.skipped = b.serverProtocol
+		b.data.mu.Unlock()
+		if !logged {
+			b.log.Printf("🏷️ Keeping the bundled block and item data, the tags of protocol %d number blocks and items differently", b.serverProtocol)
+		}
+		return nil
+	}
+
+	named := make(map[string]map[string][]string)
+	for reg, names := range map[string][]string{"minecraft:block": registryid.Block, "minecraft:item": registryid.Item} {
+		ids, ok := tags[reg]
+		if !ok {
+			continue
+		}
+		named[reg] = make(map[string][]string, len(ids))
+		for tag, values := range ids {
+			for _, id := range values {
+				if id < 0 || int(id) >= len(names) {
+					return fmt.Errorf("tag %s holds %s %d, the bot knows %d", tag, strings.TrimPrefix(reg, "minecraft:"), id, len(names))
+				}
+				named[reg][tag] = append(named[reg][tag], names[id])
+			}
+		}
+	}
+
+	b.data.mu.Lock()
+	defer b.data.mu.Unlock()
+	b.data.skipped = 0
+	if b.data.tags == nil {
+		b.data.tags = make(map[string]map[string][]string)
+	}
+	for reg, t := range named {
+		b.data.tags[reg] = t
+	}
+	if blocks, ok := named["minecraft:block"]; ok {
+		b.data.tools = blockTools(blocks)
+		b.log.Printf("🏷️ Using the server's tags for %d blocks", len(b.data.tools))
+	}
+	return nil
+}
+
+// readTags reads the tags of an update tags packet as registry to tag to IDs
+func readTags(r *bytes.Reader) (map[string]map[string][]int32, error) {
+	var count pk.VarInt
+	if _, err := count.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	tags := make(map[string]map[string][]int32, count)
+	for range count {
+		var (
+			reg pk.Identifier
+			n   pk.VarInt
+		)
+		if _, err := (pk.Tuple{&reg, &n}).ReadFrom(r); err != nil {
+			return nil, err
+		}
+		tags[string(reg)] = make(map[string][]int32, n)
+		for range n {
+			var (
+				tag pk.Identifier
+				ids []pk.VarInt
+			)
+			if _, err := (pk.Tuple{&tag, pk.Array(&ids)}).ReadFrom(r); err != nil {
+				return nil, err
+			}
+			values := make([]int32, len(ids))
+			for i, id := range ids {
+				values[i] = int32(id)
+			}
+			tags[string(reg)][string(tag)] = values
+		}
+	}
+	return tags, nil
+}
+
+// blockTools returns the tool and harvest tier the block tags give the blocks they sort
+func blockTools(tags map[string][]string) map[string]registry.Block {
+	tools := make(map[string]registry.Block)
+	for tag, tool := range mineableTags {
+		for _, name := range tags[tag] {
+			t := tools[name]
+			t.Tool = tool
+			tools[name] = t
+		}
+	}
+	for tag, tier := range tierTags {
+		for _, name := range tags[tag] {
+			t := tools[name]
+			t.Tier = max(t.Tier, tier)
+			tools[name] = t
+		}
+	}
+	return tools
+}
+
+// blockInfo returns the data of a block like registry.BlockInfo, with the tool and harvest tier
+// the server's tags give it. Which blocks drop nothing without a tool isn't in the tags, so a
+// block that drops anyway keeps doing so.
+func (b *Bot) blockInfo(name string) (registry.Block, bool) {
+	info, ok := registry.BlockInfo(name)
+	if !ok {
+		return info, false
+	}
+	b.data.mu.RLock()
+	defer b.data.mu.RUnlock()
+	if b.data.tools == nil {
+		return info, true
+	}
+	t := b.data.tools[name]
+	info.Tool = t.Tool
+	if info.Tier != tierNone {
+		info.Tier = max(t.Tier, tierWood)
+	}
+	return info, true
+}
+
+// itemIsTool reports whether an item is a tool breaking blocks faster, by the server's item
+// tags when it sent them
+func (b *Bot) itemIsTool(item string) bool {
+	b.data.mu.RLock()
+	defer b.data.mu.RUnlock()
+	items, ok := b.data.tags["minecraft:item"]
+	if !ok || item == "minecraft:shears" {
+		return isTool(item)
+	}
+	for _, tag := range toolTags {
+		if slices.Contains(items[tag], item) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverFeatures returns the feature flags the server enabled, sorted
+func (b *Bot) serverFeatures() []string {
+	b.data.mu.RLock()
+	defer b.data.mu.RUnlock()
+	return slices.Clone(b.data.features)
+}
+
+// tagDialer connects with another dialer and reads the tags of the configuration off the
+// socket, as go-mc reads past them while joining
+type tagDialer struct {
+	mcnet.MCDialer
+	b *Bot
+}
+
+func (d tagDialer) DialMCContext(ctx context.Context, addr string) (*mcnet.Conn, error) {
+	conn, err := d.MCDialer.DialMCContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	tap := &configTap{Conn: conn.Socket, b: d.b, threshold: -1}
+	conn.Socket, conn.Reader = tap, tap
+	return conn, nil
+}
+
+// configTap follows the login and configuration packets a connection reads, hands the tags
+// to the bot and answers the FML handshake. It stops looking once the bot is in game. The bot joins offline, so the
+// socket isn't encrypted.
+type configTap struct {
+	net.Conn
+	b           *Bot
+	threshold   int  // Compression threshold, -1 while uncompressed
+	configuring bool // Past the login
+	done        bool
+	buf         []byte
+}
+
+func (c *configTap) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 && !c.done {
+		c.feed(p[:n])
+	}
+	return n, err
+}
+
+// feed reads the packets that are complete once data arrived
+func (c *configTap) feed(data []byte) {
+	c.buf = append(c.buf, data...)
+	for !c.done {
+		length, n := binary.Uvarint(c.buf)
+		if n == 0 {
+			return
+		}
+		if n < 0 || length > pk.MaxDataLength {
+			c.stop()
+			return
+		}
+		end := n + int(length)
+		if len(c.buf) < end {
+			return
+		}
+		var p pk.Packet
+		if err := p.UnPack(bytes.NewReader(c.buf[:end]), c.threshold); err != nil {
+			c.stop()
+			return
+		}
+		c.buf = c.buf[end:]
+		c.handle(p)
+	}
+}
+
+// handle follows a packet of the login or configuration
+func (c *configTap) handle(p pk.Packet) {
+	if !c.configuring {
+		switch packetid.ClientboundPacketID(p.ID) {
+		case packetid.ClientboundLoginLoginCompression:
+			var threshold pk.VarInt
+			if err := p.Scan(&threshold); err != nil {
+				c.stop()
+				return
+			}
+			c.threshold = int(threshold)
+		case packetid.ClientboundLoginGameProfile:
+			c.configuring = true
+		case packetid.ClientboundLoginLoginDisconnect:
+			c.stop()
+		}
+		return
+	}
+	switch packetid.ClientboundPacketID(p.ID) {
+	case packetid.ClientboundConfigUpdateTags:
+		if err := c.b.onTags(p.Data); err != nil {
+			c.b.log.Printf("⚠️ %v", err)
+		}
+	case packetid.ClientboundConfigCustomPayload:
+		if reply, ok := c.b.forgeReply(p); ok { // go-mc drops the payloads while joining
+			if err := reply.Pack(c.Conn, c.threshold); err != nil {
+				c.b.log.Printf("⚠️ Failed to answer the FML handshake: %v", err)
+			}
+		}
+	case packetid.ClientboundConfigFinishConfiguration, packetid.ClientboundConfigDisconnect:
+		c.stop()
+	}
+}
+
+func (c *configTap) stop() {
+	c.done, c.buf = true, nil
+}
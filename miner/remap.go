@@ -0,0 +1,508 @@
+package miner
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/data/registryid"
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// renamedBlocks and renamedItems name the blocks and items the bot's release renamed, by
+// their older names
+var (
+	renamedBlocks = map[string]string{"minecraft:grass": "minecraft:short_grass"}
+	renamedItems  = map[string]string{"minecraft:grass": "minecraft:short_grass", "minecraft:scute": "minecraft:turtle_scute"}
+)
+
+// remappedRegistries are the registries whose numbers the remap translates, with the bot's
+// entries in the order of their IDs
+var remappedRegistries = map[string][]string{
+	"minecraft:item":              registryid.Item,
+	"minecraft:entity_type":       registryid.EntityType,
+	"minecraft:menu":              registryid.Menu,
+	"minecraft:block_entity_type": registryid.BlockEntityType,
+	"minecraft:sound_event":       registryid.SoundEvent,
+}
+
+// idRemap numbers the blocks and registry entries of a server's release the way the bot does.
+// What the bot's release doesn't have becomes its entry 0, like air.
+type idRemap struct {
+	states     []int32 // The bot's block state of each of the server's
+	registries map[string]idTable
+}
+
+// idTable maps the IDs of a registry both ways
+type idTable struct {
+	toBot    []int32 // By server ID
+	toServer []int32 // By bot ID
+}
+
+// bot returns the bot's ID of the server's entry id
+func (t idTable) bot(id int32) int32 {
+	if id < 0 || int(id) >= len(t.toBot) {
+		return 0
+	}
+	return t.toBot[id]
+}
+
+// server returns the server's ID of the bot's entry id
+func (t idTable) server(id int32) int32 {
+	if id < 0 || int(id) >= len(t.toServer) {
+		return 0
+	}
+	return t.toServer[id]
+}
+
+// blockState returns the bot's block state of the server's state id
+func (m *idRemap) blockState(id int32) int32 {
+	if id < 0 || int(id) >= len(m.states) {
+		return 0
+	}
+	return m.states[id]
+}
+
+// blocksReport is the blocks report of the vanilla data generator: the states of every block
+type blocksReport map[string]struct {
+	States []struct {
+		ID         int32             `json:"id"`
+		Properties map[string]string `json:"properties"`
+	} `json:"states"`
+}
+
+// registriesReport is the registries report of the vanilla data generator: the ID of every
+// entry of every registry
+type registriesReport map[string]struct {
+	Entries map[string]struct {
+		ID int32 `json:"protocol_id"`
+	} `json:"entries"`
+}
+
+// loadIDRemap reads the blocks and registries reports of protocol from dir, the
+// <protocol>.blocks.json and <protocol>.registries.json the data generator of its release
+// writes. It returns nil without them and the names the bot's release lacks.
+func loadIDRemap(dir string, protocol int32) (*idRemap, []string, error) {
+	var (
+		blocks     blocksReport
+		registries registriesReport
+	)
+	for _, report := range []struct {
+		name string
+		v    any
+	}{{"blocks", &blocks}, {"registries", &registries}} {
+		path := filepath.Join(dir, fmt.Sprintf("%d.%s.json", protocol, report.name))
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(data, report.v); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if len(blocks) == 0 || len(registries) == 0 {
+		return nil, nil, errors.New("the blocks or registries report is empty")
+	}
+
+	m := &idRemap{registries: make(map[string]idTable, len(remappedRegistries))}
+	missing := make(map[string]bool)
+	states := botBlockStates()
+	for name, b := range blocks {
+		for _, s := range b.States {
+			if s.ID < 0 {
+				continue
+			}
+			if int(s.ID) >= len(m.states) {
+				m.states = append(m.states, make([]int32, int(s.ID)+1-len(m.states))...)
+			}
+			id, ok := states.find(cmp.Or(renamedBlocks[name], name), s.Properties)
+			if !ok {
+				missing[name] = true
+			}
+			m.states[s.ID] = id
+		}
+	}
+
+	for reg, names := range remappedRegistries {
+		entries := registries[reg].Entries
+		if len(entries) == 0 {
+			return nil, nil, fmt.Errorf("the registries report has no %s", reg)
+		}
+		bot := make(map[string]int32, len(names))
+		for id, name := range names {
+			bot[name] = int32(id)
+		}
+		t := idTable{toServer: make([]int32, len(names))}
+		for name, e := range entries {
+			if e.ID < 0 {
+				continue
+			}
+			if int(e.ID) >= len(t.toBot) {
+				t.toBot = append(t.toBot, make([]int32, int(e.ID)+1-len(t.toBot))...)
+			}
+			renamed := name
+			if reg == "minecraft:item" {
+				renamed = cmp.Or(renamedItems[name], name)
+			}
+			id, ok := bot[renamed]
+			if !ok {
+				missing[name] = true
+				continue
+			}
+			t.toBot[e.ID], t.toServer[id] = id, e.ID
+		}
+		m.registries[reg] = t
+	}
+	return m, slices.Sorted(maps.Keys(missing)), nil
+}
+
+// stateIndex finds the bot's block states by block name and properties
+type stateIndex map[string][]stateProps
+
+type stateProps struct {
+	id    int32
+	props map[string]string
+}
+
+var (
+	botStatesOnce sync.Once
+	botStates     stateIndex
+)
+
+// botBlockStates returns the bot's block states by block name
+func botBlockStates() stateIndex {
+	botStatesOnce.Do(func() {
+		botStates = make(stateIndex)
+		for id, b := range block.StateList {
+			props := make(map[string]string)
+			if data, err := nbt.Marshal(b); err == nil {
+				nbt.Unmarshal(data, &props)
+			}
+			botStates[b.ID()] = append(botStates[b.ID()], stateProps{id: int32(id), props: props})
+		}
+	})
+	return botStates
+}
+
+// find returns the bot's state of the block name with props, or else the one agreeing on the
+// most of them, and whether the bot knows the block
+func (idx stateIndex) find(name string, props map[string]string) (int32, bool) {
+	states, ok := idx[name]
+	if !ok {
+		return 0, false
+	}
+	best, agreed := states[0].id, -1
+	for _, s := range states {
+		n := 0
+		for k, v := range props {
+			if s.props[k] == v {
+				n++
+			}
+		}
+		if n == len(props) && len(s.props) == len(props) {
+			return s.id, true
+		}
+		if n > agreed {
+			best, agreed = s.id, n
+		}
+	}
+	return best, true
+}
+
+// registry returns the table of a remapped registry
+func (m *idRemap) registry(name string) idTable {
+	return m.registries[name]
+}
+
+// playerType returns the entity type of players in the numbers of the server's packets
+func (c *protocolCodec) playerType() int32 {
+	id := int32(slices.Index(registryid.EntityType, "minecraft:player"))
+	if c.ids != nil {
+		return c.ids.registry("minecraft:entity_type").server(id)
+	}
+	return id
+}
+
+// blockEntityType returns the block entity type named name in the numbers of the server's
+// packets
+func (c *protocolCodec) blockEntityType(name string) int32 {
+	id := int32(max(slices.Index(registryid.BlockEntityType, name), 0))
+	if c.ids != nil {
+		return c.ids.registry("minecraft:block_entity_type").server(id)
+	}
+	return id
+}
+
+// remapFromServer and remapToServer renumber the blocks and registry entries in the packets
+// of a server of another release, by the bot's packet names
+var (
+	remapFromServer = map[string]packetRewrite{
+		"level_chunk_with_light": remapChunk,
+		"block_update":           remapBlockUpdate,
+		"section_blocks_update":  remapSectionUpdate,
+		"level_event":            remapLevelEvent,
+		"add_entity":             remapRegistryField("minecraft:entity_type", keepVarInt, keepUUID),
+		"open_screen":            remapRegistryField("minecraft:menu", keepVarInt),
+		"container_set_content":  remapContainerContent,
+		"container_set_slot":     remapContainerSlot,
+		"sound":                  remapSound,
+		"sound_entity":           remapSound,
+	}
+	remapToServer = map[string]packetRewrite{
+		"container_click": remapClick,
+	}
+)
+
+// remapRegistryField returns a rewrite renumbering the entry of registry after the fields
+// skipped
+func remapRegistryField(registry string, skipped ...fieldEdit) packetRewrite {
+	return func(c *protocolCodec, p *pk.Packet) error {
+		return edit(p, func(e *packetEditor) {
+			for _, f := range skipped {
+				f(e)
+			}
+			var id pk.VarInt
+			e.skip(&id)
+			e.write(pk.VarInt(c.ids.registry(registry).bot(int32(id))))
+		})
+	}
+}
+
+// remapSound renumbers the sound of a sound packet, unless it is sent inline
+func remapSound(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var id pk.VarInt
+		if e.skip(&id); id > 0 {
+			id = pk.VarInt(c.ids.registry("minecraft:sound_event").bot(int32(id-1))) + 1
+		}
+		e.write(id)
+	})
+}
+
+// remapBlockUpdate renumbers the state of a changed block
+func remapBlockUpdate(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var state pk.VarInt
+		e.keep(new(pk.Position))
+		e.skip(&state)
+		e.write(pk.VarInt(c.ids.blockState(int32(state))))
+	})
+}
+
+// remapSectionUpdate renumbers the states of the blocks changed in a section, each packed with
+// its position in the low 12 bits
+func remapSectionUpdate(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.Long), &n)
+		for range max(n, 0) {
+			var v pk.VarLong
+			e.skip(&v)
+			state := pk.VarLong(c.ids.blockState(int32(v >> 12)))
+			e.write(state<<12 | v&0xfff)
+		}
+	})
+}
+
+// remapLevelEvent renumbers the state of the block whose breaking a level event shows
+func remapLevelEvent(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var event, data pk.Int
+		e.keep(&event, new(pk.Position))
+		e.skip(&data)
+		if event == blockBreakEvent {
+			data = pk.Int(c.ids.blockState(int32(data)))
+		}
+		e.write(data)
+	})
+}
+
+// remapSlot renumbers the item of a stack, false once a stack has components, which aren't
+// read past
+func (c *protocolCodec) remapSlot(e *packetEditor, table func(idTable, int32) int32) bool {
+	var count, id, added, removed pk.VarInt
+	if e.keep(&count); count <= 0 {
+		return true
+	}
+	e.skip(&id)
+	e.write(pk.VarInt(table(c.ids.registry("minecraft:item"), int32(id))))
+	e.keep(&added, &removed)
+	return added == 0 && removed == 0
+}
+
+// remapContainerContent renumbers the items of a container's content
+func remapContainerContent(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.UnsignedByte), new(pk.VarInt), &n)
+		for range max(n, 0) + 1 { // And the carried stack
+			if !c.remapSlot(e, idTable.bot) {
+				return
+			}
+		}
+	})
+}
+
+// remapContainerSlot renumbers the item of a container slot
+func remapContainerSlot(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		e.keep(new(pk.Byte), new(pk.VarInt), new(pk.Short))
+		c.remapSlot(e, idTable.bot)
+	})
+}
+
+// remapClick renumbers the items of a click into the server's numbers
+func remapClick(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var n pk.VarInt
+		e.keep(new(pk.VarInt), new(pk.VarInt), new(pk.Short), new(pk.Byte), new(pk.VarInt), &n)
+		for range max(n, 0) {
+			e.keep(new(pk.Short))
+			if !c.remapSlot(e, idTable.server) {
+				return
+			}
+		}
+		c.remapSlot(e, idTable.server)
+	})
+}
+
+// remapChunk renumbers the block states and block entities of a chunk
+func remapChunk(c *protocolCodec, p *pk.Packet) error {
+	return edit(p, func(e *packetEditor) {
+		var (
+			data pk.ByteArray
+			n    pk.VarInt
+		)
+		e.keep(new(pk.Int), new(pk.Int), pk.NBTField{V: new(nbt.RawMessage), AllowUnknownFields: true})
+		if e.skip(&data); e.err != nil {
+			return
+		}
+		sections, err := c.remapSections(data)
+		if err != nil {
+			e.err = err
+			return
+		}
+		e.write(pk.ByteArray(sections))
+		e.keep(&n)
+		for range max(n, 0) {
+			var kind pk.VarInt
+			e.keep(new(pk.Byte), new(pk.Short))
+			e.skip(&kind)
+			e.write(pk.VarInt(c.ids.registry("minecraft:block_entity_type").bot(int32(kind))))
+			e.keep(pk.NBTField{V: new(nbt.RawMessage), AllowUnknownFields: true})
+		}
+	})
+}
+
+// Largest bits per entry of the palettes of block states and biomes, beyond which the entries
+// are IDs
+const (
+	maxStatePaletteBits = 8
+	maxBiomePaletteBits = 3
+	sectionEntries      = 16 * 16 * 16
+)
+
+// remapSections renumbers the block states of the sections of a chunk
+func (c *protocolCodec) remapSections(data []byte) ([]byte, error) {
+	e := newPacketEditor(data)
+	for e.err == nil && e.r.Len() > 0 {
+		e.keep(new(pk.Short)) // Non-air blocks
+		c.remapStates(e)
+		keepContainer(e, maxBiomePaletteBits)
+	}
+	return e.cut()
+}
+
+// remapStates renumbers a container of block states. Direct IDs are written with the bits the
+// bot's release takes.
+func (c *protocolCodec) remapStates(e *packetEditor) {
+	var bits pk.UnsignedByte
+	if e.skip(&bits); e.err != nil {
+		return
+	}
+	switch {
+	case bits == 0:
+		var state pk.VarInt
+		e.skip(&state)
+		e.write(bits, pk.VarInt(c.ids.blockState(int32(state))))
+		e.keep(pk.Array(new([]pk.Long)))
+	case bits <= maxStatePaletteBits:
+		var palette []pk.VarInt
+		e.skip(pk.Array(&palette))
+		for i, state := range palette {
+			palette[i] = pk.VarInt(c.ids.blockState(int32(state)))
+		}
+		e.write(bits, pk.Array(palette))
+		e.keep(pk.Array(new([]pk.Long)))
+	default:
+		var longs []pk.Long
+		if e.skip(pk.Array(&longs)); e.err != nil {
+			return
+		}
+		states := unpackEntries(longs, int(bits))
+		for i, state := range states {
+			states[i] = uint64(c.ids.blockState(int32(state)))
+		}
+		e.write(pk.UnsignedByte(block.BitsPerBlock), pk.Array(packEntries(states, block.BitsPerBlock)))
+	}
+}
+
+// keepContainer copies a paletted container
+func keepContainer(e *packetEditor, maxPaletteBits int) {
+	var bits pk.UnsignedByte
+	switch e.keep(&bits); {
+	case bits == 0:
+		e.keep(new(pk.VarInt))
+	case int(bits) <= maxPaletteBits:
+		e.keep(pk.Array(new([]pk.VarInt)))
+	}
+	e.keep(pk.Array(new([]pk.Long)))
+}
+
+// unpackEntries reads the entries of a section packed into longs, bits each, none spanning two
+// longs
+func unpackEntries(longs []pk.Long, bits int) []uint64 {
+	perLong := 64 / bits
+	mask := uint64(1)<<bits - 1
+	entries := make([]uint64, sectionEntries)
+	for i := range entries {
+		if i/perLong >= len(longs) {
+			break
+		}
+		entries[i] = uint64(longs[i/perLong]) >> (i % perLong * bits) & mask
+	}
+	return entries
+}
+
+// packEntries packs entries into longs, bits each
+func packEntries(entries []uint64, bits int) []pk.Long {
+	perLong := 64 / bits
+	longs := make([]pk.Long, (len(entries)+perLong-1)/perLong)
+	for i, v := range entries {
+		longs[i/perLong] |= pk.Long(v << (i % perLong * bits))
+	}
+	return longs
+}
+
+// describeMissing shortens the names the bot's release lacks for a log line
+func describeMissing(names []string) string {
+	const shown = 8
+	s := strings.Join(names[:min(len(names), shown)], ", ")
+	if len(names) > shown {
+		s += fmt.Sprintf(" and %d more", len(names)-shown)
+	}
+	return s
+}
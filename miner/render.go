@@ -0,0 +1,301 @@
+package miner
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	renderDefaultRadius = 128  // Blocks around the bot a render covers without a radius
+	renderMaxRadius     = 512  // Widest render
+	renderMaxScale      = 8    // Most pixels per block along each side
+	renderMaxPixels     = 4096 // Widest image of the HTTP render, in pixels
+	renderNetherCeiling = 8    // Blocks above the bot the surface is looked for under a roof like the nether's
+	renderTrailSize     = 4096 // Blocks of the bot's path kept for renders
+)
+
+// renderCommand matches "!render [radius]"
+var renderCommand = regexp.MustCompile(`(?i)!render(?:\s+(\d+))?`)
+
+// Marker colors of renders
+var (
+	renderUnknown  = color.RGBA{0x10, 0x10, 0x10, 0xFF} // Columns of chunks that aren't loaded
+	renderTrail    = color.RGBA{0xFF, 0xD7, 0x00, 0xFF}
+	renderBot      = color.RGBA{0xFF, 0x20, 0x20, 0xFF}
+	renderWaypoint = color.RGBA{0xFF, 0x40, 0xFF, 0xFF}
+)
+
+// oreColors are the markers of the ores of the last !scan
+var oreColors = map[string]color.RGBA{
+	"ancient_debris": {0x6B, 0x44, 0x3A, 0xFF},
+	"diamond":        {0x4A, 0xED, 0xD9, 0xFF},
+	"emerald":        {0x17, 0xDD, 0x62, 0xFF},
+	"gold":           {0xFC, 0xEE, 0x4B, 0xFF},
+	"nether_gold":    {0xFC, 0xEE, 0x4B, 0xFF},
+	"redstone":       {0xFF, 0x00, 0x00, 0xFF},
+	"lapis":          {0x1F, 0x48, 0xC4, 0xFF},
+	"iron":           {0xD8, 0xAF, 0x93, 0xFF},
+	"copper":         {0xE0, 0x7A, 0x4F, 0xFF},
+	"nether_quartz":  {0xEE, 0xE6, 0xDE, 0xFF},
+	"coal":           {0x2E, 0x2E, 0x2E, 0xFF},
+}
+
+// blockMapColors give blocks their base color of the map palette by the end of their name,
+// checked in order. Blocks matching none are drawn as stone.
+var blockMapColors = []struct {
+	suffix string
+	color  byte
+}{
+	{"grass_block", 1}, {"water", 12}, {"kelp", 12}, {"seagrass", 12}, {"lava", 4}, {"fire", 4},
+	{"packed_ice", 5}, {"ice", 5}, {"snow", 8}, {"snow_block", 8}, {"powder_snow", 8},
+	{"soul_sand", 26}, {"soul_soil", 26}, {"red_sand", 15}, {"sandstone", 2}, {"sand", 2}, {"gravel", 11}, {"clay", 9},
+	{"podzol", 34}, {"mycelium", 24}, {"dirt", 10}, {"farmland", 10}, {"dirt_path", 10}, {"mud", 21},
+	{"leaves", 7}, {"grass", 7}, {"fern", 7}, {"vine", 7}, {"sugar_cane", 7}, {"cactus", 7}, {"lily_pad", 7},
+	{"log", 13}, {"wood", 13}, {"planks", 13}, {"stairs", 13}, {"slab", 13}, {"fence", 13},
+	{"deepslate", 59}, {"tuff", 43}, {"calcite", 36}, {"terracotta", 36},
+	{"netherrack", 35}, {"nether_bricks", 35}, {"crimson_nylium", 52}, {"warped_nylium", 55},
+	{"basalt", 29}, {"blackstone", 29}, {"obsidian", 29},
+	{"end_stone", 2}, {"purpur_block", 16}, {"quartz_block", 14},
+	{"gold_block", 30}, {"diamond_block", 31}, {"lapis_block", 32}, {"emerald_block", 33},
+	{"iron_block", 6}, {"raw_iron_block", 60}, {"glow_lichen", 61}, {"moss_block", 27}, {"moss_carpet", 27},
+}
+
+// blockMapColor returns the map palette base color of a block
+func blockMapColor(name string) byte {
+	name = strings.TrimPrefix(name, "minecraft:")
+	for _, c := range blockMapColors {
+		if strings.HasSuffix(name, c.suffix) {
+			return c.color
+		}
+	}
+	return 11
+}
+
+// surfaceBlock is the top block of a column of the world
+type surfaceBlock struct {
+	name string
+	y    int
+	ok   bool // False when the chunk isn't loaded or the column is empty
+}
+
+// surface returns the top blocks of the size×size columns from minX, minZ, looking from
+// height top down
+func (w *worldModel) surface(minX, minZ, size, top int) []surfaceBlock {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	out := make([]surfaceBlock, size*size)
+	for i := range out {
+		x, z := minX+i%size, minZ+i/size
+		chunk, ok := w.columns[level.ChunkPos{int32(x >> 4), int32(z >> 4)}]
+		if !ok {
+			continue
+		}
+		for s := min(len(chunk.Sections)-1, (top-w.minY)>>4); s >= 0; s-- {
+			sec := &chunk.Sections[s]
+			if sec.BlockCount == 0 {
+				continue
+			}
+			for y := min(15, top-w.minY-s<<4); y >= 0; y-- {
+				state := sec.GetBlock(y<<8 | (z&15)<<4 | x&15)
+				if block.IsAir(state) {
+					continue
+				}
+				out[i] = surfaceBlock{name: blockName(state), y: w.minY + s<<4 + y, ok: true}
+				break
+			}
+			if out[i].ok {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// trailBlocks returns the blocks the bot walked through in the current dimension, oldest first
+func (t *travelStats) trailBlocks() []blockPos {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]blockPos{}, t.trail...)
+}
+
+// visit adds the block the bot stands in to its trail when it moved to another block
+func (t *travelStats) visit(pos blockPos) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.trail); n > 0 && t.trail[n-1] == pos {
+		return
+	}
+	t.trail = append(t.trail, pos)
+	if len(t.trail) > renderTrailSize {
+		t.trail = t.trail[len(t.trail)-renderTrailSize:]
+	}
+}
+
+// resetTrail forgets the trail, which belongs to the dimension the bot left
+func (t *travelStats) resetTrail() {
+	t.mu.Lock()
+	t.trail = nil
+	t.mu.Unlock()
+}
+
+// renderWorld draws the loaded world within radius of the bot from above, a pixel per block
+// scaled by scale: the top blocks shaded by height like a map, the bot's trail, the ores of
+// the last !scan, the waypoints of the server and the bot itself
+func (b *Bot) renderWorld(radius, scale int) *image.RGBA {
+	center := b.feetBlock()
+	size := 2*radius + 1
+	minX, minZ := center.X-radius, center.Z-radius
+	top := b.world.topY()
+	if b.world.currentDimension() == "minecraft:the_nether" {
+		top = center.Y + renderNetherCeiling
+	}
+	columns := b.world.surface(minX, minZ, size, top)
+
+	img := image.NewRGBA(image.Rect(0, 0, size*scale, size*scale))
+	fill := func(x, z int, c color.RGBA) {
+		for py := z * scale; py < (z+1)*scale; py++ {
+			for px := x * scale; px < (x+1)*scale; px++ {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+	mark := func(pos blockPos, c color.RGBA, r int) {
+		x, z := pos.X-minX, pos.Z-minZ
+		for dz := -r; dz <= r; dz++ {
+			for dx := -r; dx <= r; dx++ {
+				if x+dx >= 0 && x+dx < size && z+dz >= 0 && z+dz < size {
+					fill(x+dx, z+dz, c)
+				}
+			}
+		}
+	}
+
+	// Like a map, columns higher than the one to their north are lighter and lower ones darker
+	for i, col := range columns {
+		x, z := i%size, i/size
+		if !col.ok {
+			fill(x, z, renderUnknown)
+			continue
+		}
+		shade := byte(1)
+		if z > 0 && columns[i-size].ok {
+			switch north := columns[i-size].y; {
+			case col.y > north:
+				shade = 2
+			case col.y < north:
+				shade = 0
+			}
+		}
+		fill(x, z, mapColor(blockMapColor(col.name)<<2|shade))
+	}
+
+	for _, pos := range b.travel.trailBlocks() {
+		mark(pos, renderTrail, 0)
+	}
+	b.stateMu.RLock()
+	scan := b.lastScan
+	b.stateMu.RUnlock()
+	if scan != nil {
+		for _, g := range scan.Groups {
+			for _, pos := range g.Positions {
+				mark(pos, oreColors[g.Ore], 0)
+			}
+		}
+	}
+	if b.db != nil {
+		for _, wp := range b.waypoints() {
+			mark(wp.Pos, renderWaypoint, 1)
+		}
+	}
+	mark(center, renderBot, 1)
+	return img
+}
+
+// topY returns the highest block height of the current dimension
+func (w *worldModel) topY() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, chunk := range w.columns {
+		return w.minY + 16*len(chunk.Sections) - 1
+	}
+	return w.minY
+}
+
+// parseRenderRadius reads a render radius, renderDefaultRadius when empty
+func parseRenderRadius(s string) (int, error) {
+	if s == "" {
+		return renderDefaultRadius, nil
+	}
+	r, err := strconv.Atoi(s)
+	if err != nil || r < 1 || r > renderMaxRadius {
+		return 0, fmt.Errorf("radius must be between 1 and %d", renderMaxRadius)
+	}
+	return r, nil
+}
+
+// handleRenderCommand answers "!render [radius]" by writing a render of the loaded world
+// around the bot to maps_dir
+func (b *Bot) handleRenderCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := renderCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !render [radius]")
+		return
+	}
+	radius, err := parseRenderRadius(m[1])
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Can't render: %v", err))
+		return
+	}
+	if b.cfg.MapsDir == "" {
+		b.reply(msg, "Can't render: maps_dir is not set")
+		return
+	}
+
+	img := b.renderWorld(radius, 1)
+	dim := strings.TrimPrefix(b.world.currentDimension(), "minecraft:")
+	path := filepath.Join(b.cfg.MapsDir, fmt.Sprintf("world_%s_%s.png", dim, time.Now().Format("20060102-150405")))
+	if err := writePNG(path, img); err != nil {
+		b.log.Printf("❌ Failed to write the render: %v", err)
+		b.reply(msg, "Can't render: failed to write the image")
+		return
+	}
+	b.log.Printf("🗺️ Rendered %d blocks around the bot to %s", radius, path)
+	b.reply(msg, fmt.Sprintf("Rendered %d blocks around me to %s", radius, path))
+}
+
+// handleWorldImageRequest renders the loaded world around the bot as a PNG image, with
+// ?radius= blocks around it and ?scale= pixels per block
+func (b *Bot) handleWorldImageRequest(w http.ResponseWriter, r *http.Request) {
+	radius, err := parseRenderRadius(r.URL.Query().Get("radius"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	scale := 1
+	if q := r.URL.Query().Get("scale"); q != "" {
+		if scale, err = strconv.Atoi(q); err != nil || scale < 1 || scale > renderMaxScale {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("scale must be between 1 and %d", renderMaxScale))
+			return
+		}
+	}
+	if (2*radius+1)*scale > renderMaxPixels {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the image would be wider than %d pixels, lower the radius or scale", renderMaxPixels))
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, b.renderWorld(radius, scale))
+}
@@ -0,0 +1,81 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Reply modes of reply_mode and command_replies
+const (
+	replyPublic    = "public"    // Public chat
+	replyWhisper   = "whisper"   // /msg to the player who gave the command
+	replyActionBar = "actionbar" // Action bar of that player, needs permission level 2
+	replySilent    = "silent"    // Only the log and the event stream
+)
+
+// botReply is the data of a reply event
+type botReply struct {
+	Command string `json:"command,omitempty"`
+	To      string `json:"to,omitempty"`
+	Mode    string `json:"mode"`
+	Text    string `json:"text"`
+}
+
+// replyMode picks the way to answer a command: its command_replies entry, else reply_mode
+func (b *Bot) replyMode(command string) string {
+	if mode, ok := b.cfg.CommandReplies[command]; ok {
+		return mode
+	}
+	if b.cfg.ReplyMode == "" {
+		return replyPublic
+	}
+	return b.cfg.ReplyMode
+}
+
+// reply answers the command of a chat line
+func (b *Bot) reply(msg, text string) {
+	line := parseChatLine(msg)
+	b.replyTo(line.Command, line.Sender, text)
+}
+
+// replyTo answers command, given by player, the way the config says. Whispers and action bar
+// messages go out publicly when the player is unknown, as for tasks resumed after a restart.
+func (b *Bot) replyTo(command, player, text string) {
+	mode := b.replyMode(command)
+	if player == "" && (mode == replyWhisper || mode == replyActionBar) {
+		mode = replyPublic
+	}
+	// The reply event, and the audit entry with it, comes once the reply was sent
+	sent := botReply{Command: command, To: player, Mode: mode, Text: text}
+	// Only the owner hears exact coordinates, in private
+	if (mode != replyWhisper && mode != replyActionBar) || !strings.EqualFold(player, b.actingOwner()) {
+		text = b.cfg.Privacy.text(text)
+	}
+
+	switch mode {
+	case replySilent:
+		b.log.Printf("🤫 Reply to %s: %s", player, text)
+		b.events.emit(eventReply, sent)
+	case replyWhisper:
+		b.typeReply(text, func() {
+			if err := b.sendCommand(fmt.Sprintf("msg %s %s", player, text)); err != nil {
+				b.log.Printf("❌ Failed to whisper to %s: %v", player, err)
+				return
+			}
+			b.events.emit(eventReply, sent)
+		})
+	case replyActionBar:
+		component, _ := json.Marshal(text)
+		if err := b.sendCommand(fmt.Sprintf("title %s actionbar %s", player, component)); err != nil {
+			b.log.Printf("❌ Failed to show %s the reply: %v", player, err)
+			return
+		}
+		b.events.emit(eventReply, sent)
+	default:
+		b.typeReply(text, func() {
+			b.sendChatMessage(text)
+			b.events.emit(eventReply, sent)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package miner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rulesDefaultFile = "rules.txt"
+
+// Rule actions
+const (
+	ruleRetreat = "retreat" // Dig under cover ahead of every other task
+	rulePause   = "pause"   // Hands off for a duration
+	ruleResume  = "resume"  // End a pause early
+	ruleLogout  = "logout"  // Leave the server, optionally "in" a duration
+	ruleSay     = "say"     // Public chat message
+	ruleCommand = "command" // Server command, without the "/"
+	ruleRun     = "run"     // Chat command of the bot, run as the owner
+)
+
+var (
+	// ruleLine matches "on <trigger> -> <action>", the arrow may also be "→"
+	ruleLine     = regexp.MustCompile(`^on\s+(.+?)\s*(?:->|→)\s*(.+)$`)
+	ruleCompare  = regexp.MustCompile(`^(health|food)\s*(<=|>=|==|!=|<|>)\s*(\d+(?:\.\d+)?)$`)
+	ruleChat     = regexp.MustCompile(`^chat\s+matches\s+/(.*)/$`)
+	ruleEvent    = regexp.MustCompile(`^event\s+([a-z_]+)$`)
+	ruleAction   = regexp.MustCompile(`^(\w+)(?:\s+(.*))?$`)
+	ruleLogoutIn = regexp.MustCompile(`^in\s+(\S+)$`)
+	ruleGroup    = regexp.MustCompile(`\$(\d)`)
+)
+
+// rule is a line of the rules file: an action taken when a trigger goes off
+type rule struct {
+	Line int
+	Text string
+
+	stat  string // "health" or "food" of a comparison, compared with op to value
+	op    string
+	value float64
+	chat  *regexp.Regexp // Pattern chat lines are matched against, groups fill $1 to $9 of the action
+	event string         // Event type
+
+	action string
+	arg    string
+
+	holds bool // Whether the comparison held at the last health update, it fires when it starts to
+}
+
+// ruleSet is the rules of a bot and the logout they scheduled
+type ruleSet struct {
+	mu     sync.Mutex
+	rules  []*rule
+	logout *time.Timer
+}
+
+// loadRules reads a rules file, one rule per line. An empty path or a missing file means no
+// rules.
+func loadRules(path string) (*ruleSet, error) {
+	set := &ruleSet{}
+	if path == "" {
+		return set, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		r, err := parseRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		r.Line = n
+		set.rules = append(set.rules, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return set, nil
+}
+
+// parseRule reads a rule like "on health < 6 -> retreat"
+func parseRule(text string) (*rule, error) {
+	m := ruleLine.FindStringSubmatch(text)
+	if m == nil {
+		return nil, errors.New(`expected "on <trigger> -> <action>"`)
+	}
+	r := &rule{Text: text}
+	trigger, action := m[1], m[2]
+
+	switch {
+	case ruleCompare.MatchString(trigger):
+		c := ruleCompare.FindStringSubmatch(trigger)
+		r.stat, r.op = c[1], c[2]
+		r.value, _ = strconv.ParseFloat(c[3], 64)
+	case ruleChat.MatchString(trigger):
+		re, err := regexp.Compile(ruleChat.FindStringSubmatch(trigger)[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad chat pattern: %w", err)
+		}
+		r.chat = re
+	case ruleEvent.MatchString(trigger):
+		r.event = ruleEvent.FindStringSubmatch(trigger)[1]
+		if r.event == eventRuleFired {
+			return nil, errors.New("rules can't go off on rule_fired")
+		}
+	default:
+		return nil, fmt.Errorf("unknown trigger %q, expected health or food compared with a number, chat matches /pattern/ or event <type>", trigger)
+	}
+
+	a := ruleAction.FindStringSubmatch(action)
+	if a == nil {
+		return nil, fmt.Errorf("bad action %q", action)
+	}
+	r.action, r.arg = strings.ToLower(a[1]), strings.TrimSpace(a[2])
+	fixed := !ruleGroup.MatchString(r.arg) // Arguments with $1 to $9 are only known when the rule fires
+	switch r.action {
+	case ruleRetreat, ruleResume:
+		if r.arg != "" {
+			return nil, fmt.Errorf("%s takes no argument", r.action)
+		}
+	case rulePause:
+		if fixed {
+			if _, err := time.ParseDuration(r.arg); err != nil {
+				return nil, fmt.Errorf("pause needs a duration like 30s: %w", err)
+			}
+		}
+	case ruleLogout:
+		if r.arg != "" && fixed {
+			if _, err := logoutDelay(r.arg); err != nil {
+				return nil, err
+			}
+		}
+	case ruleSay, ruleCommand:
+		if r.arg == "" {
+			return nil, fmt.Errorf("%s needs a text", r.action)
+		}
+	case ruleRun:
+		if !strings.HasPrefix(r.arg, "!") {
+			return nil, errors.New(`run needs a chat command like "!guard 10"`)
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", r.action)
+	}
+	return r, nil
+}
+
+// logoutDelay reads the "in <duration>" of a logout
+func logoutDelay(arg string) (time.Duration, error) {
+	m := ruleLogoutIn.FindStringSubmatch(arg)
+	if m == nil {
+		return 0, errors.New(`logout takes nothing or "in <duration>"`)
+	}
+	d, err := time.ParseDuration(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("logout needs a duration like 5m: %w", err)
+	}
+	return d, nil
+}
+
+// compare reports whether v compares with the rule's value as its operator says
+func (r *rule) compare(v float64) bool {
+	switch r.op {
+	case "<":
+		return v < r.value
+	case "<=":
+		return v <= r.value
+	case ">":
+		return v > r.value
+	case ">=":
+		return v >= r.value
+	case "==":
+		return v == r.value
+	default:
+		return v != r.value
+	}
+}
+
+// firing is a rule that went off, with the groups its chat pattern captured
+type firing struct {
+	rule   *rule
+	groups []string
+}
+
+// applyRules takes the actions of the rules an event sets off. Comparisons go off when they
+// start to hold, not on every health update while they do.
+func (b *Bot) applyRules(e botEvent) {
+	set := b.rules
+	if set == nil {
+		return
+	}
+	var fired []firing
+	set.mu.Lock()
+	for _, r := range set.rules {
+		switch {
+		case r.stat != "":
+			h, ok := e.Data.(healthState)
+			if e.Type != eventHealthChanged || !ok {
+				continue
+			}
+			v := float64(h.Health)
+			if r.stat == "food" {
+				v = float64(h.Food)
+			}
+			holds := r.compare(v)
+			if holds && !r.holds {
+				fired = append(fired, firing{rule: r})
+			}
+			r.holds = holds
+		case r.chat != nil:
+			c, ok := e.Data.(chatEntry)
+			if e.Type != eventChatReceived || !ok || strings.EqualFold(c.Sender, b.cfg.Username) {
+				continue
+			}
+			if m := r.chat.FindStringSubmatch(c.Text); m != nil {
+				fired = append(fired, firing{rule: r, groups: m})
+			}
+		case e.Type == r.event:
+			fired = append(fired, firing{rule: r})
+		}
+	}
+	set.mu.Unlock()
+
+	for _, f := range fired {
+		b.fireRule(f.rule, f.groups)
+	}
+}
+
+// fireRule takes the action of a rule, $1 to $9 in its argument replaced by the groups of
+// the chat pattern
+func (b *Bot) fireRule(r *rule, groups []string) {
+	arg := ruleGroup.ReplaceAllStringFunc(r.arg, func(ref string) string {
+		if i := int(ref[1] - '0'); i < len(groups) {
+			return groups[i]
+		}
+		return ""
+	})
+	b.log.Printf("📜 Rule on line %d goes off: %s %s", r.Line, r.action, arg)
+	b.events.emit(eventRuleFired, map[string]any{"line": r.Line, "rule": r.Text, "action": strings.TrimSpace(r.action + " " + arg)})
+
+	switch r.action {
+	case ruleRetreat:
+		b.enqueueUrgentTask(raidTaskName, b.retreatUnderground)
+	case rulePause:
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			b.log.Printf("⚠️ Rule on line %d: pause needs a duration, got %q", r.Line, arg)
+			return
+		}
+		b.freezeFor(min(d, freezeMax))
+	case ruleResume:
+		b.unfreeze()
+	case ruleLogout:
+		b.scheduleLogout(r, arg)
+	case ruleSay:
+		go b.sendChatMessage(arg)
+	case ruleCommand:
+		go func() {
+			if err := b.sendCommand(strings.TrimPrefix(arg, "/")); err != nil {
+				b.log.Printf("❌ Rule on line %d: failed to send /%s: %v", r.Line, arg, err)
+			}
+		}()
+	case ruleRun:
+		owner := b.actingOwner()
+		if owner == "" {
+			owner = b.cfg.Username
+		}
+		msg := fmt.Sprintf("<%s> %s", owner, arg)
+		if !b.runCommand(parseChatLine(msg).Command, msg) {
+			b.log.Printf("⚠️ Rule on line %d: the bot has no %s command", r.Line, strings.Fields(arg)[0])
+		}
+	}
+}
+
+// scheduleLogout leaves the server now or after the delay of the rule, a later logout rule
+// replacing the time of an earlier one
+func (b *Bot) scheduleLogout(r *rule, arg string) {
+	var d time.Duration
+	if arg != "" {
+		var err error
+		if d, err = logoutDelay(arg); err != nil {
+			b.log.Printf("⚠️ Rule on line %d: %v", r.Line, err)
+			return
+		}
+	}
+	b.log.Printf("👋 Logging out in %s", d)
+	b.rules.mu.Lock()
+	defer b.rules.mu.Unlock()
+	if b.rules.logout != nil {
+		b.rules.logout.Stop()
+	}
+	b.rules.logout = time.AfterFunc(d, b.stop)
+}
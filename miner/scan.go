@@ -0,0 +1,199 @@
+package miner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+)
+
+const (
+	scanDefaultRadius = 32  // Blocks around the bot !scan covers without a radius
+	scanMaxRadius     = 128 // Widest scan, about the view distance of most servers
+	scanMaxReplies    = 8   // Ore groups named in a !scan answer
+)
+
+// scanCommand matches "!scan" with an optional radius
+var scanCommand = regexp.MustCompile(`(?i)!scan(?:\s+(\d+))?`)
+
+// scanOres ranks the ores by value, the best reported first. Deepslate variants count as the plain ore.
+var scanOres = []string{"ancient_debris", "diamond", "emerald", "gold", "nether_gold", "redstone", "lapis", "iron", "copper", "nether_quartz", "coal"}
+
+// oreGroup is the ore of one type found at one height
+type oreGroup struct {
+	Ore       string     `json:"ore"`
+	Y         int        `json:"y"`
+	Count     int        `json:"count"`
+	Positions []blockPos `json:"positions"`
+}
+
+// scanResult is the outcome of an ore scan
+type scanResult struct {
+	Center blockPos   `json:"center"`
+	Radius int        `json:"radius"`
+	Time   time.Time  `json:"time"`
+	Total  int        `json:"total"`
+	Groups []oreGroup `json:"groups"`
+}
+
+// oreType names the ore of a block, like "diamond" for minecraft:deepslate_diamond_ore.
+// ok is false for blocks that are not ores.
+func oreType(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "minecraft:")
+	if name == "ancient_debris" {
+		return name, true
+	}
+	ore, ok := strings.CutSuffix(name, "_ore")
+	return strings.TrimPrefix(ore, "deepslate_"), ok
+}
+
+// eachBlock calls fn with the loaded blocks within radius of center horizontally, at any height.
+// fn must not call back into the world model.
+func (w *worldModel) eachBlock(center blockPos, radius int, fn func(pos blockPos, state block.StateID)) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for cx := (center.X - radius) >> 4; cx <= (center.X+radius)>>4; cx++ {
+		for cz := (center.Z - radius) >> 4; cz <= (center.Z+radius)>>4; cz++ {
+			chunk, ok := w.columns[level.ChunkPos{int32(cx), int32(cz)}]
+			if !ok {
+				continue
+			}
+			for s := range chunk.Sections {
+				sec := &chunk.Sections[s]
+				for i := range 16 * 16 * 16 {
+					pos := blockPos{X: cx<<4 | i&15, Y: w.minY + s<<4 + i>>8, Z: cz<<4 | i>>4&15}
+					if pos.X < center.X-radius || pos.X > center.X+radius || pos.Z < center.Z-radius || pos.Z > center.Z+radius {
+						continue
+					}
+					fn(pos, sec.GetBlock(i))
+				}
+			}
+		}
+	}
+}
+
+// scanOresAround groups the ores of the loaded world within radius of the bot by type and height
+func (b *Bot) scanOresAround(radius int) scanResult {
+	center := b.feetBlock()
+	result := scanResult{Center: center, Radius: radius, Time: time.Now(), Groups: []oreGroup{}}
+	type groupKey struct {
+		ore string
+		y   int
+	}
+	groups := make(map[groupKey]*oreGroup)
+	b.world.eachBlock(center, radius, func(pos blockPos, state block.StateID) {
+		ore, ok := oreType(blockName(state))
+		if !ok {
+			return
+		}
+		g, ok := groups[groupKey{ore, pos.Y}]
+		if !ok {
+			g = &oreGroup{Ore: ore, Y: pos.Y}
+			groups[groupKey{ore, pos.Y}] = g
+		}
+		g.Count++
+		g.Positions = append(g.Positions, pos)
+	})
+	for _, g := range groups {
+		result.Groups = append(result.Groups, *g)
+		result.Total += g.Count
+	}
+	slices.SortFunc(result.Groups, func(a, b oreGroup) int {
+		return cmp.Or(
+			cmp.Compare(oreRank(a.Ore), oreRank(b.Ore)),
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.Y, b.Y),
+		)
+	})
+	return result
+}
+
+// oreRank orders ores by scanOres, unknown ones last
+func oreRank(ore string) int {
+	if i := slices.Index(scanOres, ore); i >= 0 {
+		return i
+	}
+	return len(scanOres)
+}
+
+// summary describes the scan for chat, like "diamond x3 @ y=-58, iron x12 @ y=14"
+func (s scanResult) summary() string {
+	if len(s.Groups) == 0 {
+		return fmt.Sprintf("No ores loaded within %d blocks", s.Radius)
+	}
+	parts := make([]string, 0, min(len(s.Groups), scanMaxReplies))
+	for _, g := range s.Groups[:min(len(s.Groups), scanMaxReplies)] {
+		parts = append(parts, fmt.Sprintf("%s x%d @ y=%d", g.Ore, g.Count, g.Y))
+	}
+	reply := strings.Join(parts, ", ")
+	if more := len(s.Groups) - scanMaxReplies; more > 0 {
+		reply += fmt.Sprintf(" and %d more group(s)", more)
+	}
+	return reply
+}
+
+// parseScanRadius reads a scan radius, scanDefaultRadius when empty
+func parseScanRadius(s string) (int, error) {
+	if s == "" {
+		return scanDefaultRadius, nil
+	}
+	r, err := strconv.Atoi(s)
+	if err != nil || r < 1 || r > scanMaxRadius {
+		return 0, fmt.Errorf("radius must be between 1 and %d", scanMaxRadius)
+	}
+	return r, nil
+}
+
+// handleScanCommand answers "!scan [radius]" with the ores around the bot and keeps the
+// result, coordinates included, for GET /scan
+func (b *Bot) handleScanCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := scanCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !scan [radius]")
+		return
+	}
+	radius, err := parseScanRadius(m[1])
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Can't scan: %v", err))
+		return
+	}
+
+	result := b.scanOresAround(radius)
+	b.stateMu.Lock()
+	b.lastScan = &result
+	b.stateMu.Unlock()
+	b.log.Printf("🔎 Scanned %d blocks around (%d, %d, %d): %d ore(s) in %d group(s)", radius, result.Center.X, result.Center.Y, result.Center.Z, result.Total, len(result.Groups))
+	b.reply(msg, result.summary())
+}
+
+// handleScanRequest returns the last !scan with the ore coordinates, or a fresh scan with ?radius=
+func (b *Bot) handleScanRequest(w http.ResponseWriter, r *http.Request) {
+	if q := r.URL.Query().Get("radius"); q != "" {
+		radius, err := parseScanRadius(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, b.scanOresAround(radius))
+		return
+	}
+	b.stateMu.RLock()
+	last := b.lastScan
+	b.stateMu.RUnlock()
+	if last == nil {
+		writeError(w, http.StatusNotFound, errors.New("no scan yet, use !scan or ?radius="))
+		return
+	}
+	writeJSON(w, http.StatusOK, last)
+}
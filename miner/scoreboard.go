@@ -0,0 +1,401 @@
+package miner
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Modes of the objective and team packets
+const (
+	objectiveCreate = 0
+	objectiveRemove = 1
+	objectiveUpdate = 2
+
+	teamCreate        = 0
+	teamRemove        = 1
+	teamUpdate        = 2
+	teamAddMembers    = 3
+	teamRemoveMembers = 4
+)
+
+// displaySlots name the places an objective can be shown in, by display slot
+var displaySlots = []string{"list", "sidebar", "below_name"}
+
+// decoratedChatFormats are chatFormats for senders whose names carry ranks, nicknames or team
+// prefixes, and canonicalChat the lines they are rewritten to once the player is known
+var (
+	decoratedChatFormats = []*regexp.Regexp{
+		regexp.MustCompile(`^<([^<>]{1,64})> (.*)$`),
+		regexp.MustCompile(`^(.{1,64}?) whispers to you: (.*)$`),
+		regexp.MustCompile(`^\[(.{1,64}?) -> me\] (.*)$`),
+	}
+	canonicalChat = []string{"<%s> %s", "%s whispers to you: %s", "[%s -> me] %s"}
+)
+
+// listedPlayer is a player of the player list
+type listedPlayer struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"` // Nickname or decorated name the list shows instead
+	Team        string `json:"team,omitempty"`
+	Gamemode    int32  `json:"gamemode"`
+	Latency     int32  `json:"latency"` // Milliseconds
+}
+
+// objective is a scoreboard objective and its scores
+type objective struct {
+	Name        string           `json:"name"`
+	DisplayName string           `json:"display_name"`
+	Slots       []string         `json:"slots,omitempty"` // Where it is shown
+	Scores      map[string]int32 `json:"scores"`          // By player or entity name
+}
+
+// team is a scoreboard team
+type team struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Prefix      string   `json:"prefix,omitempty"`
+	Suffix      string   `json:"suffix,omitempty"`
+	Members     []string `json:"members"`
+}
+
+// scoreboard keeps the objectives, scores and teams the server sends and a copy of the player list
+type scoreboard struct {
+	mu         sync.RWMutex
+	objectives map[string]*objective
+	displays   map[int32]string // Objective shown in each display slot
+	teams      map[string]*team
+	players    []listedPlayer
+}
+
+// scoreboardState is the scoreboard in GET /players
+type scoreboardState struct {
+	Players    []listedPlayer `json:"players"`
+	Teams      []team         `json:"teams"`
+	Objectives []objective    `json:"objectives"`
+}
+
+// registerScoreboardHandlers follows the scoreboard of the server
+func (b *Bot) registerScoreboardHandlers() {
+	b.scoreboard.reset()
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundSetObjective, F: b.onSetObjective},
+		bot.PacketHandler{ID: packetid.ClientboundSetDisplayObjective, F: b.onDisplayObjective},
+		bot.PacketHandler{ID: packetid.ClientboundSetScore, F: b.onSetScore},
+		bot.PacketHandler{ID: packetid.ClientboundResetScore, F: b.onResetScore},
+		bot.PacketHandler{ID: packetid.ClientboundSetPlayerTeam, F: b.onSetPlayerTeam},
+		bot.PacketHandler{ID: packetid.ClientboundLogin, F: b.onScoreboardReset},
+	)
+}
+
+// reset forgets everything, for a new server
+func (s *scoreboard) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objectives = make(map[string]*objective)
+	s.displays = make(map[int32]string)
+	s.teams = make(map[string]*team)
+}
+
+// onScoreboardReset forgets the scoreboard of the server the bot left
+func (b *Bot) onScoreboardReset(pk.Packet) error {
+	b.scoreboard.reset()
+	return nil
+}
+
+// plainText returns a text component without formatting
+func plainText(m chat.Message) string {
+	return legacyFormatting.ReplaceAllString(m.ClearString(), "")
+}
+
+// onSetObjective creates, updates or removes an objective
+func (b *Bot) onSetObjective(p pk.Packet) error {
+	var (
+		name pk.String
+		mode pk.Byte
+		text chat.Message
+	)
+	if err := p.Scan(&name, &mode); err != nil {
+		return err
+	}
+	if mode == objectiveCreate || mode == objectiveUpdate {
+		if err := p.Scan(&name, &mode, &text); err != nil {
+			return err
+		}
+	}
+
+	s := &b.scoreboard
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch mode {
+	case objectiveCreate:
+		s.objectives[string(name)] = &objective{Name: string(name), DisplayName: plainText(text), Scores: make(map[string]int32)}
+	case objectiveUpdate:
+		if o, ok := s.objectives[string(name)]; ok {
+			o.DisplayName = plainText(text)
+		}
+	case objectiveRemove:
+		delete(s.objectives, string(name))
+	}
+	return nil
+}
+
+// onDisplayObjective shows an objective in a display slot, or clears the slot
+func (b *Bot) onDisplayObjective(p pk.Packet) error {
+	var (
+		slot pk.VarInt
+		name pk.String
+	)
+	if err := p.Scan(&slot, &name); err != nil {
+		return err
+	}
+	s := &b.scoreboard
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name == "" {
+		delete(s.displays, int32(slot))
+	} else {
+		s.displays[int32(slot)] = string(name)
+	}
+	return nil
+}
+
+// onSetScore records a score. The display name and number format after the value are left out.
+func (b *Bot) onSetScore(p pk.Packet) error {
+	var (
+		holder, name pk.String
+		value        pk.VarInt
+	)
+	if err := p.Scan(&holder, &name, &value); err != nil {
+		return err
+	}
+	s := &b.scoreboard
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.objectives[string(name)]; ok {
+		o.Scores[string(holder)] = int32(value)
+	}
+	return nil
+}
+
+// onResetScore removes the score of a holder from one objective, or from all of them
+func (b *Bot) onResetScore(p pk.Packet) error {
+	var (
+		holder pk.String
+		name   pk.Option[pk.String, *pk.String]
+	)
+	if err := p.Scan(&holder, &name); err != nil {
+		return err
+	}
+	s := &b.scoreboard
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.objectives {
+		if !name.Has || o.Name == string(name.Val) {
+			delete(o.Scores, string(holder))
+		}
+	}
+	return nil
+}
+
+// onSetPlayerTeam creates, updates or removes a team, or changes its members
+func (b *Bot) onSetPlayerTeam(p pk.Packet) error {
+	var (
+		name                 pk.String
+		mode                 pk.Byte
+		display              chat.Message
+		flags                pk.Byte
+		visibility, collides pk.String
+		color                pk.VarInt
+		prefix, suffix       chat.Message
+		members              []pk.String
+	)
+	if err := p.Scan(&name, &mode); err != nil {
+		return err
+	}
+	info := pk.Tuple{&display, &flags, &visibility, &collides, &color, &prefix, &suffix}
+	switch mode {
+	case teamCreate:
+		if err := p.Scan(&name, &mode, info, pk.Array(&members)); err != nil {
+			return err
+		}
+	case teamUpdate:
+		if err := p.Scan(&name, &mode, info); err != nil {
+			return err
+		}
+	case teamAddMembers, teamRemoveMembers:
+		if err := p.Scan(&name, &mode, pk.Array(&members)); err != nil {
+			return err
+		}
+	}
+
+	s := &b.scoreboard
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.teams[string(name)]
+	switch {
+	case mode == teamRemove:
+		delete(s.teams, string(name))
+		return nil
+	case mode == teamCreate:
+		t = &team{Name: string(name)}
+		s.teams[t.Name] = t
+	case !ok:
+		return nil
+	}
+	if mode == teamCreate || mode == teamUpdate {
+		t.DisplayName, t.Prefix, t.Suffix = plainText(display), plainText(prefix), plainText(suffix)
+	}
+	for _, m := range members {
+		if mode == teamRemoveMembers {
+			t.Members = slices.DeleteFunc(t.Members, func(name string) bool { return name == string(m) })
+		} else if !slices.Contains(t.Members, string(m)) {
+			t.Members = append(t.Members, string(m))
+		}
+	}
+	return nil
+}
+
+// copyPlayerList keeps a copy of the player list. It runs in the packet loop, the only place
+// the player list may be read.
+func (b *Bot) copyPlayerList() {
+	players := make([]listedPlayer, 0, len(b.players.PlayerInfos))
+	for id, info := range b.players.PlayerInfos {
+		if !info.Listed {
+			continue // Hidden from the list, like vanished staff
+		}
+		p := listedPlayer{UUID: id.String(), Name: info.Name, Gamemode: info.Gamemode, Latency: info.Latency}
+		if info.DisplayName != nil {
+			p.DisplayName = plainText(*info.DisplayName)
+		}
+		players = append(players, p)
+	}
+	slices.SortFunc(players, func(a, b listedPlayer) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) })
+
+	b.scoreboard.mu.Lock()
+	defer b.scoreboard.mu.Unlock()
+	b.scoreboard.players = players
+}
+
+// teamOf returns the team of a player or entity. s.mu must be held.
+func (s *scoreboard) teamOf(member string) *team {
+	for _, t := range s.teams {
+		if slices.Contains(t.Members, member) {
+			return t
+		}
+	}
+	return nil
+}
+
+// snapshot returns the players, teams and objectives
+func (s *scoreboard) snapshot() scoreboardState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := scoreboardState{Players: slices.Clone(s.players), Teams: []team{}, Objectives: []objective{}}
+	for i, p := range state.Players {
+		if t := s.teamOf(p.Name); t != nil {
+			state.Players[i].Team = t.Name
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.teams)) {
+		t := *s.teams[name]
+		t.Members = slices.Clone(t.Members)
+		state.Teams = append(state.Teams, t)
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.objectives)) {
+		o := *s.objectives[name]
+		o.Scores = maps.Clone(o.Scores)
+		for slot, shown := range s.displays {
+			if shown == name {
+				o.Slots = append(o.Slots, displaySlotName(slot))
+			}
+		}
+		slices.Sort(o.Slots)
+		state.Objectives = append(state.Objectives, o)
+	}
+	return state
+}
+
+// displaySlotName names a display slot, the team sidebars by their color index
+func displaySlotName(slot int32) string {
+	if int(slot) < len(displaySlots) {
+		return displaySlots[slot]
+	}
+	return fmt.Sprintf("sidebar_team_%d", slot-int32(len(displaySlots)))
+}
+
+// resolvePlayer finds the listed player a name in chat stands for: their account name, the
+// display name the list shows, or their name with team prefix and suffix, each matched
+// exactly. A name standing for more than one player resolves to none.
+func (s *scoreboard) resolvePlayer(shown string) (listedPlayer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shown = strings.TrimSpace(shown)
+	var found []listedPlayer
+	for _, p := range s.players {
+		t := s.teamOf(p.Name)
+		if p.Name == shown || (p.DisplayName != "" && p.DisplayName == shown) ||
+			(t != nil && strings.TrimSpace(t.Prefix+p.Name+t.Suffix) == shown) {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return listedPlayer{}, false
+}
+
+// canonicalSender rewrites a chat line whose sender shows with a rank, nickname or team
+// prefix to carry the sender's account name, so commands and permissions see who wrote it
+func (b *Bot) canonicalSender(raw string) string {
+	for i, f := range decoratedChatFormats {
+		m := f.FindStringSubmatch(raw)
+		if m == nil {
+			continue
+		}
+		if p, ok := b.scoreboard.resolvePlayer(m[1]); ok && p.Name != m[1] {
+			return fmt.Sprintf(canonicalChat[i], p.Name, m[2])
+		}
+		return raw
+	}
+	return raw
+}
+
+// handleOnlineCommand lists the players online
+func (b *Bot) handleOnlineCommand(msg string) {
+	s := b.scoreboard.snapshot()
+	if len(s.Players) == 0 {
+		b.reply(msg, "The player list is empty")
+		return
+	}
+	teams := make(map[string]team, len(s.Teams))
+	for _, t := range s.Teams {
+		teams[t.Name] = t
+	}
+	names := make([]string, 0, len(s.Players))
+	for _, p := range s.Players {
+		name := p.Name
+		if t, ok := teams[p.Team]; ok && t.DisplayName != "" && t.DisplayName != t.Name {
+			name += " [" + t.DisplayName + "]"
+		} else if p.Team != "" {
+			name += " [" + p.Team + "]"
+		}
+		names = append(names, name)
+	}
+	b.reply(msg, fmt.Sprintf("%d online: %s", len(names), strings.Join(names, ", ")))
+}
+
+// handlePlayersRequest serves the player list, the teams and the objectives
+func (b *Bot) handlePlayersRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.scoreboard.snapshot())
+}
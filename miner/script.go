@@ -0,0 +1,309 @@
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	scriptTaskName     = "script"
+	scriptsDefaultDir  = "scripts"
+	scriptExt          = ".lua"
+	scriptEventTimeout = 5 * time.Minute // Longest bot.wait_event waits without a timeout of its own
+)
+
+// scriptCommand matches "!script run <name>" and "!script list"
+var scriptCommand = regexp.MustCompile(`(?i)!script\s+(run|list)(?:\s+([\w-]+))?`)
+
+// handleScriptCommand runs a Lua script of scripts_dir as a task for "!script run <name>"
+// and lists the scripts for "!script list"
+func (b *Bot) handleScriptCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := scriptCommand.FindStringSubmatch(msg)
+	if m == nil || (strings.EqualFold(m[1], "run") && m[2] == "") {
+		b.reply(msg, "Usage: !script run <name> | !script list")
+		return
+	}
+	if b.cfg.ScriptsDir == "" {
+		b.reply(msg, "Scripts are off: scripts_dir is not set")
+		return
+	}
+
+	if strings.EqualFold(m[1], "list") {
+		names, err := b.scriptNames()
+		switch {
+		case err != nil:
+			b.log.Printf("❌ Failed to list the scripts: %v", err)
+			b.reply(msg, "Can't list the scripts")
+		case len(names) == 0:
+			b.reply(msg, fmt.Sprintf("No scripts in %s", b.cfg.ScriptsDir))
+		default:
+			b.reply(msg, "Scripts: "+strings.Join(names, ", "))
+		}
+		return
+	}
+
+	name := m[2]
+	path := filepath.Join(b.cfg.ScriptsDir, name+scriptExt)
+	if _, err := os.Stat(path); err != nil {
+		b.reply(msg, fmt.Sprintf("No script %s in %s", name, b.cfg.ScriptsDir))
+		return
+	}
+	player := senderOf(msg)
+	b.enqueueTask(scriptTaskName+" "+name, func(ctx context.Context) error {
+		err := b.runScript(ctx, path, player)
+		switch {
+		case err == nil:
+			b.replyTo(scriptTaskName, player, fmt.Sprintf("Script %s finished", name))
+		case !errors.Is(err, context.Canceled):
+			b.replyTo(scriptTaskName, player, fmt.Sprintf("Script %s failed: %v", name, err))
+		}
+		return err
+	})
+	b.reply(msg, fmt.Sprintf("Queued script %s", name))
+}
+
+// scriptNames returns the names of the scripts in scripts_dir, sorted
+func (b *Bot) scriptNames() ([]string, error) {
+	entries, err := os.ReadDir(b.cfg.ScriptsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), scriptExt); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// runScript runs a Lua script until it ends or ctx is done. Scripts get the base, table,
+// string and math libraries and the bot table, but no access to files or the OS.
+func (b *Bot) runScript(ctx context.Context, path, player string) error {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, unsafe := range []string{"dofile", "loadfile", "load", "loadstring"} {
+		L.SetGlobal(unsafe, lua.LNil)
+	}
+	L.SetContext(ctx)
+	L.SetGlobal("bot", b.scriptBindings(ctx, L, player))
+
+	b.log.Printf("📜 Running script %s", path)
+	err := L.DoFile(path)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var lerr *lua.ApiError
+	if errors.As(err, &lerr) {
+		return errors.New(lua.LVAsString(lerr.Object))
+	}
+	return err
+}
+
+// scriptBindings builds the bot table scripts control the bot through. Actions that can fail
+// in game return true, or nil and the reason.
+func (b *Bot) scriptBindings(ctx context.Context, L *lua.LState, player string) *lua.LTable {
+	result := func(L *lua.LState, err error) int {
+		if err != nil {
+			if ctx.Err() != nil {
+				L.RaiseError("cancelled")
+			}
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+		L.Push(lua.LTrue)
+		return 1
+	}
+	checkPos := func(L *lua.LState) blockPos {
+		return blockPos{X: L.CheckInt(1), Y: L.CheckInt(2), Z: L.CheckInt(3)}
+	}
+
+	return L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"say": func(L *lua.LState) int {
+			b.sendChatMessage(L.CheckString(1))
+			return 0
+		},
+		"reply": func(L *lua.LState) int {
+			b.replyTo(scriptTaskName, player, L.CheckString(1))
+			return 0
+		},
+		"log": func(L *lua.LState) int {
+			b.log.Printf("📜 %s", L.CheckString(1))
+			return 0
+		},
+		"position": func(L *lua.LState) int {
+			b.stateMu.RLock()
+			x, y, z := b.x, b.y, b.z
+			b.stateMu.RUnlock()
+			L.Push(lua.LNumber(x))
+			L.Push(lua.LNumber(y))
+			L.Push(lua.LNumber(z))
+			return 3
+		},
+		"health": func(L *lua.LState) int {
+			b.stateMu.RLock()
+			health, food := b.health, b.food
+			b.stateMu.RUnlock()
+			L.Push(lua.LNumber(health))
+			L.Push(lua.LNumber(food))
+			return 2
+		},
+		"block": func(L *lua.LState) int {
+			state, ok := b.world.blockAt(checkPos(L))
+			if !ok {
+				L.Push(lua.LNil)
+				return 1
+			}
+			L.Push(lua.LString(blockName(state)))
+			return 1
+		},
+		"walk_to": func(L *lua.LState) int {
+			pos := checkPos(L)
+			if err := b.validateGoal(pos); err != nil {
+				return result(L, err)
+			}
+			return result(L, b.navigate(ctx, pos, b.mobAwareness(b.cfg.AvoidMobs), nil))
+		},
+		"mine": func(L *lua.LState) int {
+			pos := checkPos(L)
+			if err := b.approachBlock(ctx, pos); err != nil {
+				return result(L, err)
+			}
+			return result(L, b.mineWithItem(ctx, pos.X, pos.Y, pos.Z))
+		},
+		"craft": func(L *lua.LState) int {
+			return result(L, b.craftItem(ctx, craft.Namespaced(L.CheckString(1)), L.OptInt(2, 1)))
+		},
+		"inventory": func(L *lua.LState) int {
+			t := L.NewTable()
+			for _, s := range b.inventorySnapshot() {
+				stack := L.NewTable()
+				stack.RawSetString("slot", lua.LNumber(s.Slot))
+				stack.RawSetString("item", lua.LString(s.Item))
+				stack.RawSetString("count", lua.LNumber(s.Count))
+				t.Append(stack)
+			}
+			L.Push(t)
+			return 1
+		},
+		"count": func(L *lua.LState) int {
+			L.Push(lua.LNumber(b.inventoryCounts()[craft.Namespaced(L.CheckString(1))]))
+			return 1
+		},
+		"sleep": func(L *lua.LState) int {
+			d := time.Duration(float64(L.CheckNumber(1)) * float64(time.Second))
+			if err := sleepCtx(ctx, d); err != nil {
+				L.RaiseError("cancelled")
+			}
+			return 0
+		},
+		"wait_event": func(L *lua.LState) int {
+			typ := L.CheckString(1)
+			timeout := scriptEventTimeout
+			if L.GetTop() >= 2 {
+				timeout = time.Duration(float64(L.CheckNumber(2)) * float64(time.Second))
+			}
+			e, ok := b.waitEvent(ctx, typ, timeout)
+			if ctx.Err() != nil {
+				L.RaiseError("cancelled")
+			}
+			if !ok {
+				L.Push(lua.LNil)
+				return 1
+			}
+			L.Push(scriptValue(L, e.Data))
+			return 1
+		},
+	})
+}
+
+// waitEvent waits up to timeout for the next event of a type, any type for "*"
+func (b *Bot) waitEvent(ctx context.Context, typ string, timeout time.Duration) (botEvent, bool) {
+	events, stop := b.events.stream()
+	defer stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case e := <-events:
+			if typ == "*" || e.Type == typ {
+				return e, true
+			}
+		case <-timer.C:
+			return botEvent{}, false
+		case <-ctx.Done():
+			return botEvent{}, false
+		}
+	}
+}
+
+// scriptValue converts event data to Lua values the way it is sent as JSON, so scripts see
+// the same fields as WebSocket clients
+func scriptValue(L *lua.LState, data any) lua.LValue {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return lua.LNil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return lua.LNil
+	}
+	var convert func(v any) lua.LValue
+	convert = func(v any) lua.LValue {
+		switch v := v.(type) {
+		case bool:
+			return lua.LBool(v)
+		case float64:
+			return lua.LNumber(v)
+		case string:
+			return lua.LString(v)
+		case []any:
+			t := L.NewTable()
+			for _, e := range v {
+				t.Append(convert(e))
+			}
+			return t
+		case map[string]any:
+			t := L.NewTable()
+			for k, e := range v {
+				t.RawSetString(k, convert(e))
+			}
+			return t
+		}
+		return lua.LNil
+	}
+	return convert(v)
+}
@@ -0,0 +1,164 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	searchRingStep   = 8  // Blocks between search rings and between waypoints on a ring, about what the bot sees around it
+	searchMaxRadius  = 48 // Farthest ring from the expected position
+	searchHeightScan = 8  // Blocks above and below the expected height tried for a place to stand
+	searchReach      = 3.0
+	itemPickupRange  = 1.0 // Distance at which the server hands item entities to the player
+	itemLookRadius   = 16.0
+	lostPlayerWait   = 5 * time.Second // How long a followed player may be out of view before searching
+	recoverTaskName  = "recover drops"
+	itemEntity       = "item"
+)
+
+// errNotFound is returned when a search covered its area without finding its target
+var errNotFound = errors.New("not found")
+
+// spiralWaypoints returns the points of a search around center: center itself, then square rings
+// every searchRingStep blocks out to radius, each with a point every searchRingStep blocks
+// along its edges. Heights are those of center.
+func spiralWaypoints(center blockPos, radius int) []blockPos {
+	points := []blockPos{center}
+	for r := searchRingStep; r <= radius; r += searchRingStep {
+		// Walk the ring clockwise starting from its north-west corner
+		x, z := center.X-r, center.Z-r
+		for _, d := range [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+			for i := 0; i < 2*r; i += searchRingStep {
+				points = append(points, blockPos{X: x, Y: center.Y, Z: z})
+				x += d[0] * searchRingStep
+				z += d[1] * searchRingStep
+			}
+		}
+	}
+	return points
+}
+
+// standingSpot returns the place to stand closest in height to pos in its column
+func (w *worldModel) standingSpot(pos blockPos) (blockPos, bool) {
+	for dy := 0; dy <= searchHeightScan; dy++ {
+		for _, y := range []int{pos.Y - dy, pos.Y + dy} {
+			p := blockPos{X: pos.X, Y: y, Z: pos.Z}
+			if w.standable(p) {
+				return p, true
+			}
+		}
+	}
+	return blockPos{}, false
+}
+
+// spiralSearch walks expanding rings around center until found reports success, the rings
+// reach radius or ctx is cancelled. Waypoints the bot can't reach are skipped.
+func (b *Bot) spiralSearch(ctx context.Context, center blockPos, radius int, found func() bool) error {
+	b.log.Printf("🌀 Searching around (%d, %d, %d) up to %d blocks out", center.X, center.Y, center.Z, radius)
+	for _, wp := range spiralWaypoints(center, radius) {
+		if found() {
+			return nil
+		}
+		if err := b.waitThaw(ctx); err != nil {
+			return err
+		}
+		spot, ok := b.world.standingSpot(wp)
+		if !ok {
+			continue
+		}
+		path, err := b.world.findPath(b.feetBlock(), spot, searchReach, b.mobAwareness(b.cfg.AvoidMobs))
+		if errors.Is(err, errNoPath) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := b.followPath(ctx, path, found); err != nil {
+			return err
+		}
+	}
+	if found() {
+		return nil
+	}
+	return errNotFound
+}
+
+// nearestItem returns the closest item entity within radius of the bot
+func (b *Bot) nearestItem(radius float64) (trackedEntity, bool) {
+	x, y, z := b.currentPosition()
+	return b.entities.nearest(x, y, z, radius, func(e trackedEntity) bool {
+		return entityName(e.Type) == itemEntity
+	})
+}
+
+// collectItems picks up the item entities lying around the bot by walking over them.
+// It returns how many it walked to.
+func (b *Bot) collectItems(ctx context.Context) (int, error) {
+	collected := 0
+	skipped := make(map[int32]bool)
+	for {
+		x, y, z := b.currentPosition()
+		e, ok := b.entities.nearest(x, y, z, itemLookRadius, func(e trackedEntity) bool {
+			return entityName(e.Type) == itemEntity && !skipped[e.ID]
+		})
+		if !ok {
+			return collected, nil
+		}
+		goal := blockPos{X: int(math.Floor(e.X)), Y: int(math.Floor(e.Y)), Z: int(math.Floor(e.Z))}
+		err := b.walkPath(ctx, goal, itemPickupRange, b.mobAwareness(b.cfg.AvoidMobs))
+		if errors.Is(err, errNoPath) {
+			skipped[e.ID] = true
+			continue
+		}
+		if err != nil {
+			return collected, err
+		}
+		// The entity disappears once the server hands the item over
+		skipped[e.ID] = true
+		collected++
+	}
+}
+
+// queueRecover queues going back for the items dropped at death at pos
+func (b *Bot) queueRecover(at blockPos) *task {
+	spec := &taskSpec{Kind: recoverTaskName, Pos: &at}
+	name := fmt.Sprintf("%s %d %d %d", recoverTaskName, at.X, at.Y, at.Z)
+	return b.enqueueResumableTask(name, exposureAny, spec, func(ctx context.Context) error {
+		if len(b.inventoryCounts()) > 0 {
+			return nil // Kept the inventory, nothing was dropped
+		}
+		return b.recoverDrops(ctx, at)
+	})
+}
+
+// recoverDrops walks back to where the bot died and picks up what it dropped,
+// searching around the spot when the items are not there any more
+func (b *Bot) recoverDrops(ctx context.Context, at blockPos) error {
+	b.log.Printf("🎒 Recovering drops at (%d, %d, %d)", at.X, at.Y, at.Z)
+	if err := b.walkPath(ctx, at, searchReach, b.mobAwareness(b.cfg.AvoidMobs)); err != nil && !errors.Is(err, errNoPath) {
+		return err
+	}
+	if _, ok := b.nearestItem(itemLookRadius); !ok {
+		// Items slide down slopes and flow with water
+		err := b.spiralSearch(ctx, at, searchMaxRadius, func() bool {
+			_, ok := b.nearestItem(itemLookRadius)
+			return ok
+		})
+		if errors.Is(err, errNotFound) {
+			b.sendChatMessage(fmt.Sprintf("Couldn't find my things around (%d, %d, %d)", at.X, at.Y, at.Z))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	n, err := b.collectItems(ctx)
+	if n > 0 {
+		b.log.Printf("🎒 Picked up %d dropped stacks", n)
+	}
+	return err
+}
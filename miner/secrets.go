@@ -0,0 +1,258 @@
+package miner
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	secretPrefix       = "secret:"                  // Config values naming a secret instead of holding it
+	secretsEnv         = "MINER_SECRETS_PASSPHRASE" // Environment variable the secrets file is unlocked with
+	secretsVersion     = 1
+	secretsIterations  = 600_000 // PBKDF2-HMAC-SHA256 rounds, OWASP's recommendation
+	secretsSaltSize    = 16
+	secretsKeySize     = 32 // AES-256
+	secretsFileMode    = 0o600
+	secretsDefaultFile = "secrets.enc"
+)
+
+// secretsFile is the encrypted secrets file: a JSON object of names and values sealed with
+// AES-256-GCM under a key derived from the passphrase
+type secretsFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// secretsKey derives the key of the secrets file from the passphrase
+func secretsKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, secretsIterations, secretsKeySize)
+}
+
+// secretsPassphrase returns the passphrase from the environment
+func secretsPassphrase() (string, error) {
+	passphrase := os.Getenv(secretsEnv)
+	if passphrase == "" {
+		return "", fmt.Errorf("%s is not set", secretsEnv)
+	}
+	return passphrase, nil
+}
+
+// loadSecrets decrypts the secrets file. A missing file holds no secrets.
+func loadSecrets(path, passphrase string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+	var f secretsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets %s: %w", path, err)
+	}
+	if f.Version != secretsVersion {
+		return nil, fmt.Errorf("secrets %s: unsupported version %d", path, f.Version)
+	}
+	aead, err := secretsCipher(passphrase, f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets %s, wrong passphrase?", path)
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets %s: %w", path, err)
+	}
+	return secrets, nil
+}
+
+// saveSecrets encrypts the secrets with a fresh salt and nonce and replaces the secrets file
+func saveSecrets(path, passphrase string, secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	f := secretsFile{Version: secretsVersion, Salt: make([]byte, secretsSaltSize)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := secretsCipher(passphrase, f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plain, nil)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write next to the file and rename, so a crash never leaves half a file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(secretsFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	return nil
+}
+
+// secretsCipher returns the AES-GCM cipher of the secrets file
+func secretsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := secretsKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// resolveSecrets replaces the config values like "secret:api_token" with the secret of that
+// name. The secrets file is only opened when a value names a secret.
+func (c *Config) resolveSecrets() error {
+	var secrets map[string]string
+	lookup := func(name string) (string, error) {
+		if secrets == nil {
+			passphrase, err := secretsPassphrase()
+			if err != nil {
+				return "", fmt.Errorf("secret %q: %w", name, err)
+			}
+			if secrets, err = loadSecrets(c.SecretsFile, passphrase); err != nil {
+				return "", err
+			}
+		}
+		value, ok := secrets[name]
+		if !ok {
+			return "", fmt.Errorf("no secret %q in %s", name, c.SecretsFile)
+		}
+		return value, nil
+	}
+	return resolveSecretValues(reflect.ValueOf(c).Elem(), lookup)
+}
+
+// resolveSecretValues resolves the secrets named by the strings in v and the values it holds
+func resolveSecretValues(v reflect.Value, lookup func(name string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		name, ok := strings.CutPrefix(v.String(), secretPrefix)
+		if !ok {
+			return nil
+		}
+		value, err := lookup(name)
+		if err != nil {
+			return err
+		}
+		v.SetString(value)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return resolveSecretValues(v.Elem(), lookup)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := resolveSecretValues(v.Field(i), lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			if err := resolveSecretValues(v.Index(i), lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values can't be set in place, resolve a copy and put it back
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			if err := resolveSecretValues(value, lookup); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	}
+	return nil
+}
+
+// RunSecretsCommand stores, deletes or lists the secrets of the secrets file the config at
+// configPath names. Values to store are read from the first line of stdin, so they stay out
+// of the shell history.
+func RunSecretsCommand(configPath, set, del string, list bool) error {
+	c, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	passphrase, err := secretsPassphrase()
+	if err != nil {
+		return err
+	}
+	secrets, err := loadSecrets(c.SecretsFile, passphrase)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case list:
+		for _, name := range slices.Sorted(maps.Keys(secrets)) {
+			fmt.Println(name)
+		}
+		return nil
+	case del != "":
+		if _, ok := secrets[del]; !ok {
+			return fmt.Errorf("no secret %q in %s", del, c.SecretsFile)
+		}
+		delete(secrets, del)
+	case set != "":
+		fmt.Fprintf(os.Stderr, "Value of %s: ", set)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read the value: %w", err)
+		}
+		secrets[set] = strings.TrimRight(line, "\r\n")
+	}
+	if err := saveSecrets(c.SecretsFile, passphrase, secrets); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🔐 %s holds %d secret(s)\n", c.SecretsFile, len(secrets))
+	return nil
+}
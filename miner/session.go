@@ -0,0 +1,208 @@
+package miner
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sessionsDefaultDir = "sessions"
+
+// sessionStats counts what a bot did since it joined, for !stats and the session report
+type sessionStats struct {
+	mu          sync.Mutex
+	started     time.Time
+	traveledAt  float64        // Distance traveled before the session, restored from the statistics
+	mined       map[string]int // Blocks broken by name
+	collected   map[string]int // Items gained outside containers by name
+	toolsBroken map[string]int
+	deaths      int
+}
+
+// sessionReport is what a bot did in a session, written to session_reports_dir when it stops
+type sessionReport struct {
+	Username    string         `json:"username"`
+	Server      string         `json:"server"`
+	Started     time.Time      `json:"started"`
+	Ended       time.Time      `json:"ended"`
+	Uptime      float64        `json:"uptime_seconds"`
+	Traveled    float64        `json:"traveled"` // Blocks
+	Deaths      int            `json:"deaths"`
+	Mined       map[string]int `json:"mined"`
+	Collected   map[string]int `json:"collected"`
+	ToolsBroken map[string]int `json:"tools_broken"`
+}
+
+// startSession starts counting a session from now
+func (b *Bot) startSession() {
+	traveled, _, _ := b.travel.snapshot()
+	s := &b.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started, s.traveledAt = time.Now(), traveled
+	s.mined = make(map[string]int)
+	s.collected = make(map[string]int)
+	s.toolsBroken = make(map[string]int)
+	s.deaths = 0
+}
+
+// countMinedBlock counts a block the bot broke by its name
+func (b *Bot) countMinedBlock(e BlockMined) {
+	name := e.Block
+	if name == "" {
+		name = "unknown"
+	}
+	b.session.mu.Lock()
+	defer b.session.mu.Unlock()
+	if b.session.mined != nil {
+		b.session.mined[name]++
+	}
+}
+
+// countCollected counts the items the bot gained while no container was open, so taking items
+// out of a chest doesn't count as collecting them
+func (b *Bot) countCollected(change inventoryChange) {
+	b.windowMu.Lock()
+	open := b.window.ID != 0
+	b.windowMu.Unlock()
+	if open {
+		return
+	}
+	b.session.mu.Lock()
+	defer b.session.mu.Unlock()
+	if b.session.collected == nil {
+		return
+	}
+	for _, c := range change.Changes {
+		if c.Delta > 0 {
+			b.session.collected[c.Item] += c.Delta
+		}
+	}
+}
+
+// countToolBroke counts a tool that wore out
+func (b *Bot) countToolBroke(e ToolBroke) {
+	b.session.mu.Lock()
+	defer b.session.mu.Unlock()
+	if b.session.toolsBroken != nil {
+		b.session.toolsBroken[e.Item]++
+	}
+}
+
+// countDeath counts a death of the bot
+func (b *Bot) countDeath() {
+	b.session.mu.Lock()
+	defer b.session.mu.Unlock()
+	b.session.deaths++
+}
+
+// sessionSnapshot returns the report of the session so far
+func (b *Bot) sessionSnapshot() sessionReport {
+	traveled, _, _ := b.travel.snapshot()
+	s := &b.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	return sessionReport{
+		Username:    b.cfg.Username,
+		Server:      b.cfg.Server,
+		Started:     s.started,
+		Ended:       now,
+		Uptime:      now.Sub(s.started).Seconds(),
+		Traveled:    traveled - s.traveledAt,
+		Deaths:      s.deaths,
+		Mined:       maps.Clone(s.mined),
+		Collected:   maps.Clone(s.collected),
+		ToolsBroken: maps.Clone(s.toolsBroken),
+	}
+}
+
+// writeSessionReport writes the report of the session as JSON and CSV to session_reports_dir
+func (b *Bot) writeSessionReport() error {
+	r := b.sessionSnapshot()
+	if b.cfg.SessionReportsDir == "" || r.Started.IsZero() {
+		return nil
+	}
+	if err := os.MkdirAll(b.cfg.SessionReportsDir, 0o755); err != nil {
+		return err
+	}
+	base := filepath.Join(b.cfg.SessionReportsDir, fmt.Sprintf("%s_%s", unsafeFileChars.ReplaceAllString(b.cfg.Username, "_"), r.Started.Format("20060102-150405")))
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".json", data, 0o644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(base + ".csv")
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"stat", "name", "value"})
+	w.Write([]string{"uptime_seconds", "", strconv.FormatFloat(r.Uptime, 'f', 0, 64)})
+	w.Write([]string{"traveled", "", strconv.FormatFloat(r.Traveled, 'f', 1, 64)})
+	w.Write([]string{"deaths", "", strconv.Itoa(r.Deaths)})
+	for _, stat := range []struct {
+		name   string
+		counts map[string]int
+	}{{"mined", r.Mined}, {"collected", r.Collected}, {"tools_broken", r.ToolsBroken}} {
+		for _, name := range slices.Sorted(maps.Keys(stat.counts)) {
+			w.Write([]string{stat.name, name, strconv.Itoa(stat.counts[name])})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	b.log.Printf("📊 Wrote the session report to %s.json and .csv", base)
+	return nil
+}
+
+// topCounts returns the n largest counts as "name count", largest first
+func topCounts(counts map[string]int, n int) []string {
+	names := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+	})
+	top := make([]string, 0, n)
+	for _, name := range names[:min(n, len(names))] {
+		top = append(top, fmt.Sprintf("%s %d", strings.TrimPrefix(name, "minecraft:"), counts[name]))
+	}
+	return top
+}
+
+// sum adds up counts
+func sum(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// sessionSummary returns the session in one chat line
+func (b *Bot) sessionSummary() string {
+	r := b.sessionSnapshot()
+	mined := fmt.Sprintf("%d blocks mined", sum(r.Mined))
+	if top := topCounts(r.Mined, 3); len(top) > 0 {
+		mined += " (" + strings.Join(top, ", ") + ")"
+	}
+	uptime := time.Duration(r.Uptime * float64(time.Second)).Round(time.Minute)
+	return fmt.Sprintf("Up %s: %s, %.0f blocks traveled, %d items collected, %d deaths, %d tools broken",
+		uptime, mined, r.Traveled, sum(r.Collected), r.Deaths, sum(r.ToolsBroken))
+}
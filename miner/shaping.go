@@ -0,0 +1,219 @@
+package miner
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/net/queue"
+)
+
+// Kinds of packets the send limits apply to
+const (
+	packetMovement = "movement"
+	packetSwing    = "swing"
+	packetDigging  = "digging"
+)
+
+// limitedPackets are the packets the send limits apply to, by kind
+var limitedPackets = map[int32]string{
+	int32(packetid.ServerboundMovePlayerPos):        packetMovement,
+	int32(packetid.ServerboundMovePlayerPosRot):     packetMovement,
+	int32(packetid.ServerboundMovePlayerRot):        packetMovement,
+	int32(packetid.ServerboundMovePlayerStatusOnly): packetMovement,
+	int32(packetid.ServerboundMoveVehicle):          packetMovement,
+	int32(packetid.ServerboundSwing):                packetSwing,
+	int32(packetid.ServerboundPlayerAction):         packetDigging,
+}
+
+// PacketLimitConfig caps how many packets of a kind the bot sends per second, so the tasks,
+// handlers and plugins sending in the same tick don't add up to what anti-cheat plugins flag
+// or servers kick for spam. 0 means no cap.
+type PacketLimitConfig struct {
+	Movement float64 `json:"movement"`  // Position, rotation and vehicle move packets
+	Swing    float64 `json:"swing"`     // Arm swings
+	Digging  float64 `json:"digging"`   // Starting, finishing and cancelling digs, and the other player actions
+	Burst    int     `json:"burst"`     // Packets of a kind sent back to back before the cap applies
+	JitterMS int     `json:"jitter_ms"` // Up to this many milliseconds added at random to a packet the cap holds back
+}
+
+// defaultPacketLimits returns caps a little above what the vanilla client sends
+func defaultPacketLimits() PacketLimitConfig {
+	return PacketLimitConfig{Movement: 22, Swing: 22, Digging: 20, Burst: 4}
+}
+
+// check checks the packet limits
+func (c PacketLimitConfig) check() error {
+	if c.Movement < 0 || c.Swing < 0 || c.Digging < 0 {
+		return errors.New("rates can't be negative")
+	}
+	if c.Burst < 1 {
+		return errors.New("burst must be at least 1")
+	}
+	if c.JitterMS < 0 {
+		return errors.New("jitter_ms can't be negative")
+	}
+	return nil
+}
+
+// packetShaper holds back the packets of a kind the bot sends faster than its limit, with a
+// token bucket per kind
+type packetShaper struct {
+	mu       sync.Mutex
+	rates    map[string]float64
+	burst    float64
+	jitter   time.Duration
+	tokens   map[string]float64
+	last     map[string]time.Time
+	delayed  map[string]int // Packets held back, by kind
+	waited   time.Duration
+	replaced int // Movement packets replaced by newer ones while held back
+}
+
+func newPacketShaper(c PacketLimitConfig) *packetShaper {
+	return &packetShaper{
+		rates:   map[string]float64{packetMovement: c.Movement, packetSwing: c.Swing, packetDigging: c.Digging},
+		burst:   float64(c.Burst),
+		jitter:  time.Duration(c.JitterMS) * time.Millisecond,
+		tokens:  make(map[string]float64),
+		last:    make(map[string]time.Time),
+		delayed: make(map[string]int),
+	}
+}
+
+// take consumes a token of kind and returns zero, or returns how long until one is available
+func (s *packetShaper) take(kind string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rate := s.rates[kind]
+	if rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	tokens := min(s.burst, s.tokens[kind]+now.Sub(s.last[kind]).Seconds()*rate) // Full at first
+	s.last[kind] = now
+	if tokens < 1 {
+		s.tokens[kind] = tokens
+		return time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	s.tokens[kind] = tokens - 1
+	return 0
+}
+
+// wait blocks until a packet of kind may be sent
+func (s *packetShaper) wait(kind string) {
+	start, held := time.Now(), false
+	for {
+		wait := s.take(kind)
+		if wait == 0 {
+			break
+		}
+		held = true
+		if s.jitter > 0 {
+			wait += rand.N(s.jitter)
+		}
+		time.Sleep(wait)
+	}
+	if held {
+		s.mu.Lock()
+		s.delayed[kind]++
+		s.waited += time.Since(start)
+		s.mu.Unlock()
+	}
+}
+
+// countReplaced counts a movement packet replaced by a newer one while held back
+func (s *packetShaper) countReplaced() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaced++
+}
+
+// snapshot returns the packets held back by kind, the total time they waited and the movement
+// packets replaced while held back
+func (s *packetShaper) snapshot() (delayed map[string]int, waited time.Duration, replaced int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delayed = make(map[string]int, len(s.delayed))
+	for k, v := range s.delayed {
+		delayed[k] = v
+	}
+	return delayed, s.waited, s.replaced
+}
+
+// shapedQueue is the queue of the packets to the server, holding back those sent faster than
+// the limits. Held back packets, and those sent after them so the order stays, wait in a queue
+// of their own that a goroutine feeds on once the limits let it, so whoever sends a packet,
+// like the packet handler answering the server, never waits. A movement packet held back is
+// replaced by a newer one of the same kind, the server only needs the latest position.
+type shapedQueue struct {
+	queue.Queue[pk.Packet]
+	s *packetShaper
+
+	mu       sync.Mutex
+	held     []pk.Packet
+	draining bool
+	closed   bool
+}
+
+func newShapedQueue(q queue.Queue[pk.Packet], s *packetShaper) *shapedQueue {
+	return &shapedQueue{Queue: q, s: s}
+}
+
+func (q *shapedQueue) Push(p pk.Packet) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	kind, limited := limitedPackets[p.ID]
+	if len(q.held) == 0 && (!limited || q.s.take(kind) == 0) {
+		return q.Queue.Push(p)
+	}
+	if n := len(q.held); kind == packetMovement && n > 1 && q.held[n-1].ID == p.ID {
+		q.held[n-1] = p // The first held packet may be waiting in drain already
+		q.s.countReplaced()
+		return true
+	}
+	q.held = append(q.held, p)
+	if !q.draining {
+		q.draining = true
+		go q.drain()
+	}
+	return true
+}
+
+// drain passes the held packets on in order, each once its limit lets it
+func (q *shapedQueue) drain() {
+	for {
+		q.mu.Lock()
+		if q.closed || len(q.held) == 0 {
+			q.draining = false
+			q.mu.Unlock()
+			return
+		}
+		p := q.held[0]
+		q.mu.Unlock()
+
+		if kind, ok := limitedPackets[p.ID]; ok {
+			q.s.wait(kind)
+		}
+
+		q.mu.Lock()
+		if !q.closed {
+			q.held = q.held[1:]
+			q.Queue.Push(p)
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *shapedQueue) Close() {
+	q.mu.Lock()
+	q.closed, q.held = true, nil
+	q.mu.Unlock()
+	q.Queue.Close()
+}
@@ -0,0 +1,156 @@
+package miner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tnze/go-mc/bot/screen"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	offhandSlot       = 45               // Inventory slot of the offhand
+	offhandButton     = 40               // Container click button swapping a slot with the offhand
+	clickModeSwap     = 2                // Container click mode for number keys and the offhand key
+	shieldRaiseTicks  = 5                // Ticks a raised shield needs before it blocks
+	shieldAlertWindow = 10 * time.Second // How long after a melee hit the bot keeps blocking between attacks
+	shieldItem        = "minecraft:shield"
+)
+
+// onContainerState remembers the state ID of the last container update, container clicks echo it
+func (b *Bot) onContainerState(p pk.Packet) error {
+	var container, state pk.VarInt
+	if err := p.Scan(&container, &state); err != nil {
+		return err
+	}
+	b.containerState.Store(int32(state))
+	return nil
+}
+
+// onDamageEvent notes when a mob hits the bot in melee
+func (b *Bot) onDamageEvent(p pk.Packet) error {
+	var id, sourceType, cause, direct pk.VarInt
+	if err := p.Scan(&id, &sourceType, &cause, &direct); err != nil {
+		return err
+	}
+	// Cause and direct IDs are sent plus one, 0 means none. A melee hit comes straight from its cause,
+	// a projectile is a different direct entity.
+	if int32(id) != b.player.EID || cause == 0 || cause != direct {
+		return nil
+	}
+
+	attacker, ok := b.entities.entity(int32(cause) - 1)
+	if !ok {
+		return nil
+	}
+	b.log.Printf("🩸 Hit by %s #%d", entityName(attacker.Type), attacker.ID)
+	b.stateMu.Lock()
+	b.meleeHitAt = time.Now()
+	b.stateMu.Unlock()
+	return nil
+}
+
+// recentlyHit reports whether a mob hit the bot in melee within the alert window
+func (b *Bot) recentlyHit() bool {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return !b.meleeHitAt.IsZero() && time.Since(b.meleeHitAt) < shieldAlertWindow
+}
+
+// encodeSlot writes an item stack in the container click slot format, components are left out
+func encodeSlot(s screen.Slot) pk.Tuple {
+	if s.Count <= 0 {
+		return pk.Tuple{pk.VarInt(0)}
+	}
+	return pk.Tuple{s.Count, s.ID, pk.VarInt(0), pk.VarInt(0)}
+}
+
+// equipShield moves a shield from the inventory to the offhand, unless it holds a totem of
+// undying. It reports whether the offhand holds a shield afterwards.
+func (b *Bot) equipShield() (bool, error) {
+	if b.screens == nil || b.offhandTotem() {
+		return false, nil
+	}
+	slots := &b.screens.Inventory.Slots
+	if s := slots[offhandSlot]; s.Count > 0 && itemName(int32(s.ID)) == shieldItem {
+		return true, nil
+	}
+
+	from := -1
+	for i := 9; i < offhandSlot; i++ { // Main inventory and hotbar
+		if s := slots[i]; s.Count > 0 && itemName(int32(s.ID)) == shieldItem {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return false, nil
+	}
+
+	// Press the offhand key over the shield, the client predicts the swapped slots
+	shield, offhand := slots[from], slots[offhandSlot]
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundContainerClick,
+		pk.VarInt(0), // Player inventory
+		pk.VarInt(b.containerState.Load()),
+		pk.Short(from),
+		pk.Byte(offhandButton),
+		pk.VarInt(clickModeSwap),
+		pk.VarInt(2), // Changed slots
+		pk.Short(from), encodeSlot(offhand),
+		pk.Short(offhandSlot), encodeSlot(shield),
+		encodeSlot(screen.Slot{}), // Nothing on the cursor
+	)); err != nil {
+		return false, fmt.Errorf("failed to move the shield: %w", err)
+	}
+	b.log.Printf("🛡️ Moved shield from slot %d to the offhand", from)
+	return true, nil
+}
+
+// raiseShield starts blocking with the offhand shield
+func (b *Bot) raiseShield() error {
+	if b.shieldRaised {
+		return nil
+	}
+	b.stateMu.RLock()
+	yaw, pitch := b.yaw, b.pitch
+	b.stateMu.RUnlock()
+
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundUseItem,
+		pk.VarInt(1), // Offhand
+		pk.VarInt(b.sequence.Add(1)),
+		pk.Float(yaw),
+		pk.Float(pitch),
+	)); err != nil {
+		return fmt.Errorf("failed to raise the shield: %w", err)
+	}
+	b.shieldRaised = true
+	return nil
+}
+
+// lowerShield stops blocking, the bot cannot attack with the shield up
+func (b *Bot) lowerShield() error {
+	if !b.shieldRaised {
+		return nil
+	}
+	if err := b.sendDigging(5, 0, 0, 0, faceBottom); err != nil { // Status 5 = release use item
+		return fmt.Errorf("failed to lower the shield: %w", err)
+	}
+	b.shieldRaised = false
+	return nil
+}
+
+// blockBetweenAttacks raises the shield while the weapon recharges, when a mob hit the bot
+// recently and there is time for the shield to come up before the next attack
+func (b *Bot) blockBetweenAttacks(wait time.Duration) error {
+	if b.shieldRaised || !b.recentlyHit() || wait <= (shieldRaiseTicks+1)*tickDuration {
+		return nil
+	}
+	equipped, err := b.equipShield()
+	if err != nil || !equipped {
+		return err
+	}
+	return b.raiseShield()
+}
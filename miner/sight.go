@@ -0,0 +1,180 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+const aimTolerance = 1.0 // Degrees the bot's rotation may be off a target and still count as aimed
+
+// errNoLineOfSight is returned when no face of a target block can be seen from the bot's eyes
+var errNoLineOfSight = errors.New("no line of sight")
+
+// faceCenter returns the point in the middle of a block face
+func faceCenter(pos blockPos, face byte) (x, y, z float64) {
+	x, y, z = float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5
+	switch face {
+	case faceBottom:
+		y -= 0.5
+	case faceTop:
+		y += 0.5
+	case faceNorth:
+		z -= 0.5
+	case faceSouth:
+		z += 0.5
+	case faceWest:
+		x -= 0.5
+	case faceEast:
+		x += 0.5
+	}
+	return x, y, z
+}
+
+// faceNormal returns the direction a block face points to
+func faceNormal(face byte) blockPos {
+	switch face {
+	case faceBottom:
+		return blockPos{Y: -1}
+	case faceTop:
+		return blockPos{Y: 1}
+	case faceNorth:
+		return blockPos{Z: -1}
+	case faceSouth:
+		return blockPos{Z: 1}
+	case faceWest:
+		return blockPos{X: -1}
+	default:
+		return blockPos{X: 1}
+	}
+}
+
+// lineOfSight reports whether a ray from the eye position to a point inside target
+// passes through no other block than target. It walks the blocks along the ray one by one.
+func (w *worldModel) lineOfSight(fromX, fromY, fromZ, toX, toY, toZ float64, target blockPos) bool {
+	dx, dy, dz := toX-fromX, toY-fromY, toZ-fromZ
+	cur := blockPos{X: int(math.Floor(fromX)), Y: int(math.Floor(fromY)), Z: int(math.Floor(fromZ))}
+
+	// Per axis: the direction to step, the ray fraction between block borders and to the first border
+	axis := func(from, d float64, c int) (step int, delta, next float64) {
+		switch {
+		case d > 0:
+			return 1, 1 / d, (float64(c) + 1 - from) / d
+		case d < 0:
+			return -1, -1 / d, (from - float64(c)) / -d
+		default:
+			return 0, math.Inf(1), math.Inf(1)
+		}
+	}
+	sx, ddx, tx := axis(fromX, dx, cur.X)
+	sy, ddy, ty := axis(fromY, dy, cur.Y)
+	sz, ddz, tz := axis(fromZ, dz, cur.Z)
+
+	for cur != target {
+		switch {
+		case tx <= ty && tx <= tz:
+			if tx > 1 {
+				return false
+			}
+			cur.X += sx
+			tx += ddx
+		case ty <= tz:
+			if ty > 1 {
+				return false
+			}
+			cur.Y += sy
+			ty += ddy
+		default:
+			if tz > 1 {
+				return false
+			}
+			cur.Z += sz
+			tz += ddz
+		}
+		if cur != target && !w.passable(cur) {
+			return false
+		}
+	}
+	return true
+}
+
+// visibleFace returns the face of the block at pos the bot should dig from:
+// the one pointing towards its eyes if that is visible, otherwise any exposed face it can see.
+// Blocks in unloaded chunks cannot be checked and get the facing face.
+func (b *Bot) visibleFace(pos blockPos) (byte, bool) {
+	preferred := b.digFace(pos)
+	if _, loaded := b.world.blockAt(pos); !loaded {
+		return preferred, true
+	}
+
+	x, y, z := b.currentPosition()
+	ey := y + eyeHeight
+	faces := []byte{preferred, faceTop, faceBottom, faceNorth, faceSouth, faceWest, faceEast}
+	for _, face := range faces {
+		n := faceNormal(face)
+		fx, fy, fz := faceCenter(pos, face)
+		// The eyes have to be in front of the face
+		if (x-fx)*float64(n.X)+(ey-fy)*float64(n.Y)+(z-fz)*float64(n.Z) <= 0 {
+			continue
+		}
+		if !b.world.passable(blockPos{X: pos.X + n.X, Y: pos.Y + n.Y, Z: pos.Z + n.Z}) {
+			continue
+		}
+		// Aim a hair inside the block so the ray ends in it
+		const inside = 0.01
+		if b.world.lineOfSight(x, ey, z, fx-float64(n.X)*inside, fy-float64(n.Y)*inside, fz-float64(n.Z)*inside, pos) {
+			return face, true
+		}
+	}
+	return 0, false
+}
+
+// aimAt turns the bot towards a block face and makes sure the rotation reached the server
+// at least a tick before returning, as vanilla does before it starts digging
+func (b *Bot) aimAt(ctx context.Context, pos blockPos, face byte) error {
+	x, y, z := b.currentPosition()
+	fx, fy, fz := faceCenter(pos, face)
+	yaw, pitch := lookAngles(x, y+eyeHeight, z, fx, fy, fz)
+
+	// An aim the humanize jitter left off the middle still counts
+	tolerance := float32(aimTolerance)
+	if h := b.cfg.Humanize; h.Enabled {
+		tolerance = max(tolerance, float32(h.LookJitter))
+	}
+	b.stateMu.RLock()
+	aimed := angleDiff(yaw, b.yaw) <= tolerance && angleDiff(pitch, b.pitch) <= tolerance
+	since := time.Since(b.rotatedAt)
+	b.stateMu.RUnlock()
+
+	if !aimed {
+		jy, jp := b.lookJitter()
+		yaw, pitch = yaw+jy, max(-90, min(90, pitch+jp))
+		if err := b.sendRotation(yaw, pitch, true); err != nil {
+			return err
+		}
+		b.setPosition(x, y, z, yaw, pitch)
+		since = 0
+	}
+	if since >= tickDuration {
+		return nil
+	}
+
+	wait := time.NewTimer(tickDuration - since)
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// angleDiff returns the difference between two angles in degrees, accounting for wrap-around
+func angleDiff(a, b float32) float32 {
+	d := math.Mod(float64(a-b), 360)
+	if d < 0 {
+		d += 360
+	}
+	return float32(math.Min(d, 360-d))
+}
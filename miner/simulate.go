@@ -0,0 +1,28 @@
+package miner
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"github.com/coolguycoder/Minecraft-Miner/simulate"
+)
+
+// Simulate runs the bots of configs against a simulated server instead of their servers, to
+// try out mining, pathfinding and commands offline. The bots start afresh and keep nothing:
+// they run without state database, audit log and world cache.
+func Simulate(ctx context.Context, configs []Config, opts simulate.Options) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	go simulate.New(opts).Serve(ln)
+	log.Printf("🧪 Simulated server listening on %s", ln.Addr())
+
+	for i := range configs {
+		configs[i].Server = ln.Addr().String()
+		configs[i].StateDB, configs[i].AuditLog, configs[i].WorldCacheDir = "", "", ""
+	}
+	return NewSwarm(configs).Run(ctx)
+}
@@ -0,0 +1,56 @@
+package miner_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coolguycoder/Minecraft-Miner/miner"
+	"github.com/coolguycoder/Minecraft-Miner/simulate"
+)
+
+// TestSimulatedMine joins a simulated server and checks the task the bot starts with, mining
+// the block in front of it, finished with the block mined
+func TestSimulatedMine(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go simulate.New(simulate.Options{Log: log.New(io.Discard, "", 0)}).Serve(ln)
+
+	cfg := miner.DefaultConfig()
+	cfg.Server = ln.Addr().String()
+	cfg.HTTPAddr, cfg.StateDB, cfg.AuditLog, cfg.WorldCacheDir, cfg.SessionReportsDir = "", "", "", "", ""
+	m := miner.New(cfg)
+	m.Logger().SetOutput(io.Discard)
+
+	finished := make(chan miner.TaskFinished, 8)
+	miner.Subscribe(m, func(e miner.TaskFinished) { finished <- e })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ran := make(chan error, 1)
+	go func() { ran <- m.Run(ctx) }()
+	defer func() {
+		m.Stop()
+		<-ran
+	}()
+
+	select {
+	case e := <-finished:
+		if e.Status != "finished" {
+			t.Fatalf("task %q %s: %s", e.Task.Name, e.Status, e.Error)
+		}
+		if e.BlocksMined != 1 {
+			t.Fatalf("task %q mined %d blocks, want 1", e.Task.Name, e.BlocksMined)
+		}
+	case err := <-ran:
+		t.Fatalf("the bot left before its first task finished: %v", err)
+	case <-ctx.Done():
+		t.Fatal("the first task didn't finish")
+	}
+}
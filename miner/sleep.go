@@ -0,0 +1,170 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	sleepTaskName  = "sleep in bed"
+	bedAnswerWait  = 2 * time.Second // How long the server has to refuse the bed
+	bedRetryDelay  = 5 * time.Second // Wait before trying again when monsters are near the bed
+	bedMessageKey  = "block.minecraft.bed."
+	bedNotSafe     = bedMessageKey + "not_safe"
+	leaveBedAction = 2 // PlayerCommand action
+)
+
+// errBedNotSafe is the server refusing the bed because monsters are near
+var errBedNotSafe = errors.New("monsters are near the bed")
+
+// bedRest remembers the night the bot last went to bed and passes on the server's answers
+type bedRest struct {
+	mu      sync.Mutex
+	night   int64       // Day number of the night the bed was last tried, -1 for none
+	answers chan string // Translation keys of the messages the server refuses the bed with
+}
+
+// registerBedHandler follows the messages the server refuses a bed with
+func (b *Bot) registerBedHandler() {
+	b.bed.night = -1
+	b.bed.answers = make(chan string, 1)
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundSystemChat, F: b.onBedMessage})
+}
+
+// onBedMessage passes on messages like "You may not rest now; there are monsters nearby"
+func (b *Bot) onBedMessage(p pk.Packet) error {
+	var msg chat.Message
+	if err := p.Scan(&msg); err != nil {
+		return nil // handleChatPacket reports it
+	}
+	if !strings.HasPrefix(msg.Translate, bedMessageKey) {
+		return nil
+	}
+	select {
+	case b.bed.answers <- msg.Translate:
+	default:
+	}
+	return nil
+}
+
+// queueSleepIfDue queues going to bed when it is night and surface tasks are held back until
+// the morning, once a night. It reports whether it queued the task.
+func (b *Bot) queueSleepIfDue() bool {
+	bed := b.cfg.Bed
+	if bed == nil || !b.daylightScheduling() || b.world.currentDimension() != overworld {
+		return false // Beds explode outside the overworld
+	}
+	if safe, known := b.surfaceSafe(); !known || safe || !b.tasks.waitingSurface() {
+		return false
+	}
+	night, _ := b.clock.Day()
+	b.bed.mu.Lock()
+	defer b.bed.mu.Unlock()
+	if b.bed.night == night {
+		return false
+	}
+	b.bed.night = night
+	b.log.Printf("🛏️ Surface tasks wait for the morning, going to bed at (%d, %d, %d)", bed.X, bed.Y, bed.Z)
+	b.enqueueTask(sleepTaskName, func(ctx context.Context) error { return b.sleepUntilDawn(ctx, *bed) })
+	return true
+}
+
+// waitingSurface reports whether a surface task is pending
+func (q *taskQueue) waitingSurface() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, t := range q.pending {
+		if t.Exposure == exposureSurface {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepUntilDawn walks to the bed, lies down and waits for the morning. Monsters near the
+// bed keep the bot awake, it tries again until they are gone or the night is over.
+func (b *Bot) sleepUntilDawn(ctx context.Context, bed blockPos) error {
+	if err := b.approachBlock(ctx, bed); err != nil {
+		return fmt.Errorf("failed to reach the bed: %w", err)
+	}
+	for {
+		if safe, _ := b.surfaceSafe(); safe {
+			b.log.Println("🌅 Morning came before the bot got to sleep")
+			return nil
+		}
+		err := b.lieDown(ctx, bed)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errBedNotSafe) {
+			return err
+		}
+		b.log.Println("👾 Monsters are near the bed, trying again shortly")
+		select {
+		case <-time.After(bedRetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	b.log.Println("💤 Sleeping until dawn")
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if safe, _ := b.surfaceSafe(); safe {
+			b.log.Println("🌅 Woke up, back to work")
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			b.leaveBed()
+			return ctx.Err()
+		}
+	}
+}
+
+// lieDown right-clicks the bed and waits for the server to refuse it
+func (b *Bot) lieDown(ctx context.Context, bed blockPos) error {
+	// Forget answers to earlier tries
+	select {
+	case <-b.bed.answers:
+	default:
+	}
+	if err := b.useBlock(ctx, bed); err != nil {
+		return fmt.Errorf("failed to use the bed: %w", err)
+	}
+	select {
+	case key := <-b.bed.answers:
+		if key == bedNotSafe {
+			return errBedNotSafe
+		}
+		return fmt.Errorf("the server refused the bed (%s)", strings.TrimPrefix(key, bedMessageKey))
+	case <-time.After(bedAnswerWait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// leaveBed gets out of bed before the morning
+func (b *Bot) leaveBed() {
+	err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundPlayerCommand,
+		pk.VarInt(b.player.EID),
+		pk.VarInt(leaveBedAction),
+		pk.VarInt(0),
+	))
+	if err != nil {
+		b.log.Printf("⚠️ Failed to leave the bed: %v", err)
+	}
+}
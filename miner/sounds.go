@@ -0,0 +1,230 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	creeperHiss       = "entity.creeper.primed"
+	tntPrimed         = "entity.tnt.primed"
+	creeperHissRadius = 7.0  // A creeper hissing this close blows up on the bot
+	creeperFleeDist   = 10.0 // Blocks the bot sprints away from a hissing creeper
+	tntRadius         = 10.0 // Primed TNT this close is worth running from
+	tntFleeDist       = 12.0
+	activityRadius    = 24.0             // Blocks broken within this distance hint at someone nearby
+	activityBlame     = 6.0              // A player this close to a broken block probably broke it
+	activityQuiet     = 30 * time.Second // Least time between two player activity reports
+	blockBreakEvent   = 2001             // Level event of a block broken by another player
+	fleeTaskName      = "flee"
+	fleeHold          = 5 * time.Second // A creeper explodes 1.5 seconds after hissing, TNT 4 seconds after it was lit
+)
+
+// soundWatch remembers the flight and the activity report in progress
+type soundWatch struct {
+	mu         sync.Mutex
+	fleeUntil  time.Time // Sounds of danger until then don't start another flight
+	reportedAt time.Time // When the last player activity was reported
+}
+
+// registerSoundHandlers listens to the sounds around the bot and the blocks other players break
+func (b *Bot) registerSoundHandlers() {
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundSound, F: b.onSound},
+		bot.PacketHandler{ID: packetid.ClientboundSoundEntity, F: b.onEntitySound},
+		bot.PacketHandler{ID: packetid.ClientboundLevelEvent, F: b.onBlockBreakEvent},
+	)
+}
+
+// soundName reads the sound of a sound packet: a registry ID plus one, or 0 and the sound inline
+func soundName(p pk.Packet) (string, error) {
+	var (
+		id    pk.VarInt
+		name  pk.Identifier
+		fixed pk.Boolean
+		rng   pk.Float
+	)
+	if err := p.Scan(&id); err != nil {
+		return "", err
+	}
+	if id == 0 {
+		if err := p.Scan(&id, &name, &fixed); err != nil {
+			return "", err
+		}
+		if fixed {
+			if err := p.Scan(&id, &name, &fixed, &rng); err != nil {
+				return "", err
+			}
+		}
+		return strings.TrimPrefix(string(name), "minecraft:"), nil
+	}
+	if int(id) > len(registryid.SoundEvent) {
+		return fmt.Sprintf("unknown_%d", id-1), nil
+	}
+	return strings.TrimPrefix(registryid.SoundEvent[id-1], "minecraft:"), nil
+}
+
+// soundFields returns what follows the sound in a sound packet, inline sounds being longer
+func soundFields(p pk.Packet, rest ...pk.FieldDecoder) error {
+	var (
+		id    pk.VarInt
+		name  pk.Identifier
+		fixed pk.Boolean
+		rng   pk.Float
+	)
+	if err := p.Scan(&id); err != nil {
+		return err
+	}
+	head := []pk.FieldDecoder{&id}
+	if id == 0 {
+		if err := p.Scan(&id, &name, &fixed); err != nil {
+			return err
+		}
+		head = append(head, &name, &fixed)
+		if fixed {
+			head = append(head, &rng)
+		}
+	}
+	return p.Scan(append(head, rest...)...)
+}
+
+// onSound reacts to a sound played at a position
+func (b *Bot) onSound(p pk.Packet) error {
+	name, err := soundName(p)
+	if err != nil {
+		return err
+	}
+	var (
+		category pk.VarInt
+		x, y, z  pk.Int // Eighths of a block
+	)
+	if err := soundFields(p, &category, &x, &y, &z); err != nil {
+		return err
+	}
+	b.hearSound(name, float64(x)/8, float64(y)/8, float64(z)/8)
+	return nil
+}
+
+// onEntitySound reacts to a sound an entity made
+func (b *Bot) onEntitySound(p pk.Packet) error {
+	name, err := soundName(p)
+	if err != nil {
+		return err
+	}
+	var category, id pk.VarInt
+	if err := soundFields(p, &category, &id); err != nil {
+		return err
+	}
+	e, ok := b.entities.entity(int32(id))
+	if !ok {
+		return nil
+	}
+	b.hearSound(name, e.X, e.Y, e.Z)
+	return nil
+}
+
+// hearSound runs from a hissing creeper or primed TNT close by, and reports blocks broken or
+// placed near the bot
+func (b *Bot) hearSound(name string, x, y, z float64) {
+	bx, by, bz := b.currentPosition()
+	dist := math.Sqrt((x-bx)*(x-bx) + (y-by)*(y-by) + (z-bz)*(z-bz))
+	switch {
+	case name == creeperHiss && dist <= creeperHissRadius:
+		b.flee(fmt.Sprintf("A creeper is hissing %.0f blocks away", dist), x, z, creeperFleeDist)
+	case name == tntPrimed && dist <= tntRadius:
+		b.flee(fmt.Sprintf("TNT was lit %.0f blocks away", dist), x, z, tntFleeDist)
+	case strings.HasPrefix(name, "block.") && strings.HasSuffix(name, ".break"):
+		b.noteActivity(strings.TrimSuffix(strings.TrimPrefix(name, "block."), ".break")+" broken", x, y, z, dist)
+	case strings.HasPrefix(name, "block.") && strings.HasSuffix(name, ".place"):
+		b.noteActivity(strings.TrimSuffix(strings.TrimPrefix(name, "block."), ".place")+" placed", x, y, z, dist)
+	}
+}
+
+// onBlockBreakEvent reports blocks other players break near the bot, which the server plays
+// the breaking sound of as a level event
+func (b *Bot) onBlockBreakEvent(p pk.Packet) error {
+	var (
+		event, state pk.Int
+		pos          pk.Position
+	)
+	if err := p.Scan(&event, &pos, &state); err != nil {
+		return err
+	}
+	if event != blockBreakEvent {
+		return nil
+	}
+	x, y, z := float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5
+	bx, by, bz := b.currentPosition()
+	dist := math.Sqrt((x-bx)*(x-bx) + (y-by)*(y-by) + (z-bz)*(z-bz))
+	b.noteActivity(strings.TrimPrefix(blockName(block.StateID(state)), "minecraft:")+" broken", x, y, z, dist)
+	return nil
+}
+
+// flee sprints away from a point ahead of every other task, unless the bot just started running
+func (b *Bot) flee(reason string, x, z, dist float64) {
+	b.sounds.mu.Lock()
+	running := time.Now().Before(b.sounds.fleeUntil)
+	if !running {
+		b.sounds.fleeUntil = time.Now().Add(fleeHold)
+	}
+	b.sounds.mu.Unlock()
+	if running {
+		return
+	}
+
+	b.log.Printf("💨 %s, running", reason)
+	b.events.emit(eventDangerHeard, map[string]any{"reason": reason, "x": x, "z": z})
+	b.enqueueUrgentTask(fleeTaskName, func(ctx context.Context) error {
+		if err := b.sprint(true); err != nil {
+			return fmt.Errorf("failed to sprint: %w", err)
+		}
+		defer b.sprint(false)
+		return b.walkAway(ctx, x, z, dist)
+	})
+}
+
+// noteActivity logs a block broken or placed within activityRadius as a sign of another
+// player at work, naming the closest player in view, at most once per activityQuiet. Bots of
+// this process don't count.
+func (b *Bot) noteActivity(what string, x, y, z, dist float64) {
+	if dist > activityRadius {
+		return
+	}
+	player := ""
+	if e, ok := b.entities.nearest(x, y, z, activityBlame, func(e trackedEntity) bool {
+		return entityName(e.Type) == "player"
+	}); ok {
+		player = b.entities.playerName(e.UUID)
+	}
+	if player != "" && b.swarm != nil && b.swarm.has(player) {
+		return
+	}
+
+	b.sounds.mu.Lock()
+	quiet := time.Since(b.sounds.reportedAt) < activityQuiet
+	if !quiet {
+		b.sounds.reportedAt = time.Now()
+	}
+	b.sounds.mu.Unlock()
+	if quiet {
+		return
+	}
+
+	pos := blockPos{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))}
+	who := "someone may be nearby"
+	if player != "" {
+		who = "probably " + player
+	}
+	b.log.Printf("👂 Heard %s %.0f blocks away at (%d, %d, %d), %s", strings.ReplaceAll(what, "_", " "), dist, pos.X, pos.Y, pos.Z, who)
+	b.events.emit(eventPlayerActivity, map[string]any{"sound": what, "pos": pos, "distance": dist, "player": player})
+}
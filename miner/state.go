@@ -0,0 +1,113 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dumpDir is where !dump writes state snapshots
+const dumpDir = "dumps"
+
+// botState is a full snapshot of the bot for debugging and external tools
+type botState struct {
+	Time       time.Time               `json:"time"`
+	Username   string                  `json:"username"`
+	Server     string                  `json:"server"`
+	Connected  bool                    `json:"connected"`
+	Position   positionState           `json:"position"`
+	Health     float32                 `json:"health"`
+	Food       int32                   `json:"food"`
+	Experience experience              `json:"experience"`
+	Effects    map[string]activeEffect `json:"effects"`
+	Inventory  []itemStack             `json:"inventory"`
+	Tasks      tasksState              `json:"tasks"`
+	Freeze     freezeState             `json:"freeze"`
+	World      worldStats              `json:"world"`
+	RecentChat []chatEntry             `json:"recent_chat"`
+	TabList    tabListState            `json:"tablist"`
+	HUD        hudState                `json:"hud"`
+	ConfigHash string                  `json:"config_hash"`
+
+	VersionMismatch []string `json:"version_mismatch,omitempty"` // Problems the protocol self-check found
+	Features        []string `json:"features,omitempty"`         // Feature flags the server enabled, like minecraft:vanilla
+}
+
+type positionState struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Z     float64 `json:"z"`
+	Yaw   float32 `json:"yaw"`
+	Pitch float32 `json:"pitch"`
+}
+
+type healthState struct {
+	Health     float32 `json:"health"`
+	Food       int32   `json:"food"`
+	Saturation float32 `json:"saturation"`
+}
+
+type tasksState struct {
+	Current *taskInfo  `json:"current"`
+	Pending []taskInfo `json:"pending"`
+}
+
+// snapshotState collects the current bot state
+func (b *Bot) snapshotState() botState {
+	b.stateMu.RLock()
+	pos := positionState{X: b.x, Y: b.y, Z: b.z, Yaw: b.yaw, Pitch: b.pitch}
+	health, food, xp := b.health, b.food, b.xp
+	b.stateMu.RUnlock()
+
+	current, pending := b.tasks.snapshot()
+
+	return botState{
+		Time:       time.Now(),
+		Username:   b.cfg.Username,
+		Server:     b.cfg.Server,
+		Connected:  b.connected(),
+		Position:   pos,
+		Health:     health,
+		Food:       food,
+		Experience: xp,
+		Effects:    b.activeEffects(),
+		Inventory:  b.inventorySnapshot(),
+		Tasks:      tasksState{Current: current, Pending: pending},
+		Freeze:     b.frozen(),
+		World:      b.worldSnapshot(),
+		RecentChat: b.chat.recent(),
+		TabList:    b.tablist.snapshot(),
+		HUD:        b.hud.snapshot(),
+		ConfigHash: b.cfg.hash(),
+
+		VersionMismatch: b.drift.mismatches(),
+		Features:        b.serverFeatures(),
+	}
+}
+
+// handleDumpCommand writes the state snapshot to a file and reports where it went
+func (b *Bot) handleDumpCommand(msg string) {
+	b.log.Println("🗂️ Executing !dump command...")
+
+	state := b.snapshotState()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		b.log.Printf("❌ Failed to encode state: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(dumpDir, 0o755); err != nil {
+		b.log.Printf("❌ Failed to create dump directory: %v", err)
+		return
+	}
+	path := filepath.Join(dumpDir, fmt.Sprintf("state-%s.json", state.Time.Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		b.log.Printf("❌ Failed to write state dump: %v", err)
+		return
+	}
+
+	b.log.Printf("✓ State dumped to %s", path)
+	b.reply(msg, fmt.Sprintf("State dumped to %s (%d items, %d tasks queued)", path, len(state.Inventory), len(state.Tasks.Pending)))
+}
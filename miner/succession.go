@@ -0,0 +1,238 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	bucketPresence      = "presence" // When owners were last seen online, keyed by server and player
+	bucketRetired       = "retired"  // Bots that left their server for good, keyed by server and username
+	ownerCheckInterval  = 10 * time.Minute
+	presenceSaveEvery   = 10 * time.Minute // How often the last seen time of an owner who stays online is saved
+	retireTaskName      = "retire"
+	successionDayLength = 24 * time.Hour
+)
+
+// presence is when a player was last seen in the player list
+type presence struct {
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// retirement is why and when a bot left its server for good
+type retirement struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// ownerWatch follows when the owner was last online, which hands control to the backup owner
+// or retires the bot once they are gone too long
+type ownerWatch struct {
+	mu       sync.Mutex
+	lastSeen time.Time
+	savedAt  time.Time
+	online   bool
+	backup   bool // The backup owner is in control
+	retiring bool
+}
+
+// presenceKey is the key of a player of the bot's server in the presence bucket
+func (b *Bot) presenceKey(player string) string {
+	return b.cfg.Server + "/" + strings.ToLower(player)
+}
+
+// loadOwnerPresence restores when the owner was last seen. A bot that never saw its owner
+// counts the absence from now.
+func (b *Bot) loadOwnerPresence() {
+	if !b.cfg.namedOwner() {
+		return
+	}
+	var p presence
+	ok, err := b.db.Get(bucketPresence, b.presenceKey(b.cfg.Owner), &p)
+	if err != nil {
+		b.log.Printf("⚠️ Failed to read when %s was last seen: %v", b.cfg.Owner, err)
+	}
+	if !ok || err != nil {
+		p.LastSeen = time.Now()
+		b.saveOwnerPresence(p.LastSeen)
+	}
+	b.owner.mu.Lock()
+	b.owner.lastSeen = p.LastSeen
+	b.owner.mu.Unlock()
+}
+
+// saveOwnerPresence records when the owner was last seen
+func (b *Bot) saveOwnerPresence(t time.Time) {
+	if err := b.db.Put(bucketPresence, b.presenceKey(b.cfg.Owner), presence{LastSeen: t}); err != nil {
+		b.log.Printf("⚠️ Failed to record when %s was last seen: %v", b.cfg.Owner, err)
+	}
+}
+
+// noteOwnerPresence updates the last seen time of the owner from the player list
+func (b *Bot) noteOwnerPresence(names map[pk.UUID]string) {
+	if !b.cfg.namedOwner() {
+		return
+	}
+	online := false
+	for _, name := range names {
+		if strings.EqualFold(name, b.cfg.Owner) {
+			online = true
+			break
+		}
+	}
+
+	now := time.Now()
+	b.owner.mu.Lock()
+	changed := online != b.owner.online
+	save := changed || online && now.Sub(b.owner.savedAt) >= presenceSaveEvery
+	if online || changed {
+		b.owner.lastSeen = now
+	}
+	if save {
+		b.owner.savedAt = now
+	}
+	back := online && b.owner.backup
+	if back {
+		b.owner.backup = false
+	}
+	b.owner.online = online
+	b.owner.mu.Unlock()
+
+	if save {
+		b.saveOwnerPresence(now)
+	}
+	if back {
+		b.log.Printf("👑 %s is back, %s no longer stands in", b.cfg.Owner, b.cfg.BackupOwner)
+		b.events.emit(eventOwnerChanged, map[string]string{"owner": b.cfg.Owner})
+	}
+}
+
+// ownerAbsence returns how long the owner has been offline, zero while online
+func (b *Bot) ownerAbsence() time.Duration {
+	b.owner.mu.Lock()
+	defer b.owner.mu.Unlock()
+	if b.owner.online || b.owner.lastSeen.IsZero() {
+		return 0
+	}
+	return time.Since(b.owner.lastSeen)
+}
+
+// absentFor reports whether the owner has been offline for the given number of days, never
+// for 0 days
+func (b *Bot) absentFor(days int) bool {
+	return b.cfg.namedOwner() && days > 0 && b.ownerAbsence() >= time.Duration(days)*successionDayLength
+}
+
+// actingOwner returns the player in control of the bot: the owner, or the backup owner while
+// the owner is gone for owner_absence_days
+func (b *Bot) actingOwner() string {
+	if b.cfg.BackupOwner != "" && b.absentFor(b.cfg.OwnerAbsenceDays) {
+		return b.cfg.BackupOwner
+	}
+	if !b.cfg.namedOwner() {
+		return ""
+	}
+	return b.cfg.Owner
+}
+
+// watchOwnerEvery checks for the absence of the owner periodically until ctx is done
+func (b *Bot) watchOwnerEvery(ctx context.Context) {
+	if !b.cfg.namedOwner() {
+		return
+	}
+	ticker := time.NewTicker(ownerCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.checkOwnerAbsence()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkOwnerAbsence hands control to the backup owner, or retires the bot, once the owner was
+// gone long enough
+func (b *Bot) checkOwnerAbsence() {
+	days := int(b.ownerAbsence() / successionDayLength)
+	takeOver := b.actingOwner() != b.cfg.Owner
+	retire := b.absentFor(b.cfg.RetireAfterDays)
+
+	b.owner.mu.Lock()
+	takeOver = takeOver && !b.owner.backup
+	retire = retire && !b.owner.retiring
+	b.owner.backup = b.owner.backup || takeOver
+	b.owner.retiring = b.owner.retiring || retire
+	b.owner.mu.Unlock()
+
+	if takeOver {
+		b.log.Printf("👑 %s has not been online for %d days, %s takes over", b.cfg.Owner, days, b.cfg.BackupOwner)
+		b.events.emit(eventOwnerChanged, map[string]any{"owner": b.cfg.BackupOwner, "absent_days": days})
+		go b.replyTo("owner", b.cfg.BackupOwner, fmt.Sprintf("%s has been away for %d days, you are in charge now", b.cfg.Owner, days))
+	}
+	if retire {
+		b.log.Printf("🪦 %s has not been online for %d days, retiring", b.cfg.Owner, days)
+		b.enqueueTask(retireTaskName, func(ctx context.Context) error { return b.retire(ctx, days) })
+	}
+}
+
+// retire puts the inventory in the home chest and leaves the server for good
+func (b *Bot) retire(ctx context.Context, days int) error {
+	if chest := b.cfg.HomeChest; chest != nil {
+		b.log.Printf("📦 Putting everything in the home chest at (%d, %d, %d)", chest.X, chest.Y, chest.Z)
+		err := b.approachBlock(ctx, *chest)
+		if err == nil {
+			err = b.depositItems(ctx, *chest, b.inventoryCounts())
+		}
+		if err != nil && ctx.Err() != nil {
+			return err
+		}
+		if err != nil {
+			b.log.Printf("⚠️ Failed to empty the inventory, retiring anyway: %v", err)
+		}
+	} else {
+		b.log.Println("⚠️ No home_chest to leave the inventory in")
+	}
+
+	r := retirement{Time: time.Now(), Reason: fmt.Sprintf("%s was away for %d days", b.cfg.Owner, days)}
+	if err := b.db.Put(bucketRetired, b.presenceKey(b.cfg.Username), r); err != nil {
+		return fmt.Errorf("failed to record the retirement: %w", err)
+	}
+	b.events.emit(eventRetired, r)
+	b.sendChatMessage(fmt.Sprintf("%s has been away for %d days, goodbye", b.cfg.Owner, days))
+	time.Sleep(time.Second)
+	b.log.Println("🪦 Retired, the bot won't join this server again")
+	b.stop()
+	return nil
+}
+
+// checkRetired keeps a retired bot off its server while retire_after_days is set. Setting it
+// to 0 brings the bot back.
+func (b *Bot) checkRetired() error {
+	var r retirement
+	ok, err := b.db.Get(bucketRetired, b.presenceKey(b.cfg.Username), &r)
+	if err != nil || !ok {
+		return err
+	}
+	if b.cfg.RetireAfterDays > 0 {
+		return fmt.Errorf("retired from %s on %s because %s, set retire_after_days to 0 to bring the bot back",
+			b.cfg.Server, r.Time.Format(time.DateOnly), r.Reason)
+	}
+	if err := b.db.Delete(bucketRetired, b.presenceKey(b.cfg.Username)); err != nil {
+		return fmt.Errorf("failed to clear the retirement: %w", err)
+	}
+	if b.cfg.namedOwner() {
+		b.saveOwnerPresence(time.Now())
+		b.owner.mu.Lock()
+		b.owner.lastSeen = time.Now()
+		b.owner.mu.Unlock()
+	}
+	b.log.Printf("🌱 Back from retirement on %s", b.cfg.Server)
+	return nil
+}
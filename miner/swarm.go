@@ -0,0 +1,420 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	quarryTaskName   = "quarry"
+	quarryEchoWindow = 3 * time.Second // Time in which every bot of a swarm sees the same chat command
+)
+
+// quarryRegion is the box of blocks a swarm digs out, both corners inclusive
+type quarryRegion struct {
+	Min blockPos `json:"min"`
+	Max blockPos `json:"max"`
+}
+
+// chunkPos is a chunk column of a quarry
+type chunkPos struct {
+	X int `json:"x"`
+	Z int `json:"z"`
+}
+
+// swarm coordinates the bots of one process joining the same server. It splits a quarry
+// into chunk columns, hands each bot its own stripe, moves the work of bots that leave to the
+// ones that stay and counts the blocks mined by everyone.
+type swarm struct {
+	mu       sync.Mutex
+	bots     []*Bot
+	region   *quarryRegion
+	queues   map[*Bot][]chunkPos // Chunks assigned to each bot, next first
+	active   map[*Bot]chunkPos   // Chunk each bot is digging
+	done     int
+	mined    map[string]int // Blocks mined per username
+	stopped  map[string]bool
+	answered time.Time // When a bot last answered a !quarry
+}
+
+// swarmBotStats is the serializable view of one swarm member
+type swarmBotStats struct {
+	Username string    `json:"username"`
+	Online   bool      `json:"online"`
+	Current  *chunkPos `json:"current,omitempty"`
+	Queued   int       `json:"queued"`
+	Mined    int       `json:"mined"`
+}
+
+// swarmStats is the serializable view of the swarm
+type swarmStats struct {
+	Quarry     *quarryRegion   `json:"quarry"`
+	ChunksLeft int             `json:"chunks_left"`
+	ChunksDone int             `json:"chunks_done"`
+	TotalMined int             `json:"total_mined"`
+	Bots       []swarmBotStats `json:"bots"`
+}
+
+// quarryCommand matches "!quarry x1 y1 z1 x2 y2 z2"
+var quarryCommand = regexp.MustCompile(`(?i)!quarry\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)`)
+
+func newSwarm() *swarm {
+	return &swarm{
+		queues:  make(map[*Bot][]chunkPos),
+		active:  make(map[*Bot]chunkPos),
+		mined:   make(map[string]int),
+		stopped: make(map[string]bool),
+	}
+}
+
+// join adds a bot to the swarm and counts the blocks it mines
+func (s *swarm) join(b *Bot) {
+	s.mu.Lock()
+	s.bots = append(s.bots, b)
+	s.mu.Unlock()
+
+	b.swarm = s
+	subscribeTo(b.events, func(BlockMined) {
+		s.mu.Lock()
+		s.mined[b.cfg.Username]++
+		s.mu.Unlock()
+	})
+}
+
+// has reports whether a player is one of the bots of the swarm
+func (s *swarm) has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.bots, func(b *Bot) bool { return strings.EqualFold(b.cfg.Username, name) })
+}
+
+// leave removes a bot that disconnected and spreads its chunks over the remaining bots
+func (s *swarm) leave(b *Bot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bots = slices.DeleteFunc(s.bots, func(o *Bot) bool { return o == b })
+	s.stopped[b.cfg.Username] = true
+
+	orphaned := s.queues[b]
+	if c, ok := s.active[b]; ok {
+		orphaned = append([]chunkPos{c}, orphaned...)
+	}
+	delete(s.queues, b)
+	delete(s.active, b)
+	if len(orphaned) == 0 {
+		return
+	}
+	if len(s.bots) == 0 {
+		log.Printf("🐝 %s left with %d quarry chunk(s) and no bot is left to take them", b.cfg.Username, len(orphaned))
+		return
+	}
+
+	// Hand each orphaned chunk to the bot with the least work left
+	for _, c := range orphaned {
+		least := s.bots[0]
+		for _, o := range s.bots[1:] {
+			if len(s.queues[o]) < len(s.queues[least]) {
+				least = o
+			}
+		}
+		s.queues[least] = append(s.queues[least], c)
+	}
+	log.Printf("🐝 %s left, rebalanced %d quarry chunk(s) over %d bot(s)", b.cfg.Username, len(orphaned), len(s.bots))
+}
+
+// startQuarry splits the region between the bots and queues a quarry task on each.
+// Every bot sees the same chat command, so asking again for the running quarry doesn't start
+// it over; answer reports whether the caller is the first bot to see the command and should
+// reply.
+func (s *swarm) startQuarry(region quarryRegion) (started, answer bool, err error) {
+	s.mu.Lock()
+	if s.region != nil && *s.region == region {
+		defer s.mu.Unlock()
+		if time.Since(s.answered) < quarryEchoWindow {
+			return false, false, nil
+		}
+		s.answered = time.Now()
+		return false, true, nil
+	}
+	if len(s.bots) == 0 {
+		s.mu.Unlock()
+		return false, false, errors.New("no bots online")
+	}
+
+	var chunks []chunkPos
+	for cx := region.Min.X >> 4; cx <= region.Max.X>>4; cx++ {
+		for cz := region.Min.Z >> 4; cz <= region.Max.Z>>4; cz++ {
+			chunks = append(chunks, chunkPos{X: cx, Z: cz})
+		}
+	}
+
+	// Contiguous stripes keep every bot in its own part of the quarry
+	s.region = &region
+	s.done = 0
+	s.answered = time.Now()
+	s.queues = make(map[*Bot][]chunkPos)
+	s.active = make(map[*Bot]chunkPos)
+	bots := slices.Clone(s.bots)
+	for i, b := range bots {
+		from, to := i*len(chunks)/len(bots), (i+1)*len(chunks)/len(bots)
+		s.queues[b] = slices.Clone(chunks[from:to])
+	}
+	s.mu.Unlock()
+
+	log.Printf("🐝 Quarry (%d, %d, %d) to (%d, %d, %d): %d chunk(s) split over %d bot(s)",
+		region.Min.X, region.Min.Y, region.Min.Z, region.Max.X, region.Max.Y, region.Max.Z, len(chunks), len(bots))
+	spec := &taskSpec{Kind: quarryTaskName, Region: &region}
+	for _, b := range bots {
+		b.enqueueResumableTask(quarryTaskName, b.regionExposure(region.Min, region.Max), spec, b.runQuarry)
+	}
+	return true, true, nil
+}
+
+// next returns the chunk the bot should dig next.
+// A bot that finished its own stripe takes work from the bot with the most left.
+func (s *swarm) next(b *Bot) (region quarryRegion, c chunkPos, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.region == nil {
+		return region, c, false
+	}
+
+	from := b
+	if len(s.queues[b]) == 0 {
+		for _, o := range s.bots {
+			if len(s.queues[o]) > len(s.queues[from]) {
+				from = o
+			}
+		}
+		if len(s.queues[from]) == 0 {
+			return region, c, false
+		}
+	}
+
+	queue := s.queues[from]
+	if from == b {
+		c, s.queues[b] = queue[0], queue[1:]
+	} else {
+		// Steal from the far end of the other bot's stripe
+		c, s.queues[from] = queue[len(queue)-1], queue[:len(queue)-1]
+		b.log.Printf("🐝 Took chunk (%d, %d) from %s", c.X, c.Z, from.cfg.Username)
+	}
+	s.active[b] = c
+	return *s.region, c, true
+}
+
+// finish marks the active chunk of the bot as dug, or hands it back when it was interrupted
+func (s *swarm) finish(b *Bot, completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.active[b]
+	if !ok {
+		return
+	}
+	delete(s.active, b)
+	if !completed {
+		s.queues[b] = append([]chunkPos{c}, s.queues[b]...)
+		return
+	}
+
+	s.done++
+	for _, q := range s.queues {
+		if len(q) > 0 {
+			return
+		}
+	}
+	if len(s.active) == 0 {
+		log.Printf("🐝 Quarry finished: %d chunk(s), %d block(s) mined by the swarm", s.done, s.totalMined())
+		s.region = nil
+	}
+}
+
+// activeChunk returns the chunk the bot is digging
+func (s *swarm) activeChunk(b *Bot) (chunkPos, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.active[b]
+	return c, ok
+}
+
+// minedBy returns the blocks a bot mined
+func (s *swarm) minedBy(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mined[name]
+}
+
+// restoreMined carries over the blocks a bot mined before a restart
+func (s *swarm) restoreMined(name string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mined[name] += n
+}
+
+// totalMined sums the blocks mined by every bot. The caller must hold the lock.
+func (s *swarm) totalMined() int {
+	total := 0
+	for _, n := range s.mined {
+		total += n
+	}
+	return total
+}
+
+// snapshot returns the quarry progress and the blocks mined by every bot
+func (s *swarm) snapshot() swarmStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := swarmStats{Quarry: s.region, ChunksDone: s.done, Bots: []swarmBotStats{}}
+	online := make(map[string]bool)
+	for _, b := range s.bots {
+		online[b.cfg.Username] = true
+		member := swarmBotStats{
+			Username: b.cfg.Username,
+			Online:   true,
+			Queued:   len(s.queues[b]),
+			Mined:    s.mined[b.cfg.Username],
+		}
+		if c, ok := s.active[b]; ok {
+			member.Current = &c
+			stats.ChunksLeft++
+		}
+		stats.ChunksLeft += member.Queued
+		stats.Bots = append(stats.Bots, member)
+	}
+	for name := range s.stopped {
+		if !online[name] {
+			stats.Bots = append(stats.Bots, swarmBotStats{Username: name, Mined: s.mined[name]})
+		}
+	}
+	stats.TotalMined = s.totalMined()
+	return stats
+}
+
+// runQuarry digs the chunks the swarm hands to this bot until none are left
+func (b *Bot) runQuarry(ctx context.Context) error {
+	for {
+		region, c, ok := b.swarm.next(b)
+		if !ok {
+			b.log.Println("🐝 No quarry chunks left")
+			return nil
+		}
+
+		err := b.quarryChunk(ctx, region, c)
+		b.swarm.finish(b, err == nil)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// quarryChunk digs out the part of the region inside one chunk column, top layer first
+func (b *Bot) quarryChunk(ctx context.Context, region quarryRegion, c chunkPos) error {
+	minX, maxX := max(region.Min.X, c.X*16), min(region.Max.X, c.X*16+15)
+	minZ, maxZ := max(region.Min.Z, c.Z*16), min(region.Max.Z, c.Z*16+15)
+	b.log.Printf("🐝 Quarrying chunk (%d, %d): x %d..%d, z %d..%d", c.X, c.Z, minX, maxX, minZ, maxZ)
+
+	for y := region.Max.Y; y >= region.Min.Y; y-- {
+		if feet := b.feetBlock(); feet.Y > y+quarryReach {
+			// The layer is out of reach from up here, dig down towards it inside the chunk
+			center := blockPos{X: (minX + maxX) / 2, Y: y, Z: (minZ + maxZ) / 2}
+			if err := b.staircaseDown(ctx, y+1, towards(feet, center)); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				b.log.Printf("⚠️ Can't dig down to layer y=%d: %v", y, err)
+			}
+		}
+		// Each layer is one pipelined line
+		layer := snakeLayer(minX, maxX, minZ, maxZ, y)
+		if _, err := b.mineLine(ctx, layer, func(pos blockPos) bool { return !b.quarryable(pos) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snakeLayer returns the blocks of a rectangle at height y row by row, every other row
+// reversed so consecutive blocks stay adjacent
+func snakeLayer(minX, maxX, minZ, maxZ, y int) []blockPos {
+	var layer []blockPos
+	for x := minX; x <= maxX; x++ {
+		for i := range maxZ - minZ + 1 {
+			z := minZ + i
+			if (x-minX)%2 == 1 {
+				z = maxZ - i
+			}
+			layer = append(layer, blockPos{X: x, Y: y, Z: z})
+		}
+	}
+	return layer
+}
+
+// quarryable reports whether the block at pos is worth digging
+func (b *Bot) quarryable(pos blockPos) bool {
+	state, loaded := b.world.blockAt(pos)
+	if !loaded {
+		// Dug out before a restart, the chunk just isn't loaded again yet
+		return !b.minedBefore(pos)
+	}
+	if block.IsAir(state) {
+		return false
+	}
+	switch name := blockName(state); {
+	case name == "minecraft:water", name == "minecraft:lava":
+		return false
+	case b.checkProtected(pos, name) != nil:
+		return false
+	default:
+		info, ok := registry.BlockInfo(name)
+		return !ok || info.Hardness >= 0
+	}
+}
+
+// handleQuarryCommand starts a swarm quarry from "!quarry x1 y1 z1 x2 y2 z2"
+func (b *Bot) handleQuarryCommand(msg string) {
+	m := quarryCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !quarry x1 y1 z1 x2 y2 z2")
+		return
+	}
+	var v [6]int
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	region := regionFrom(v)
+
+	started, answer, err := b.swarm.startQuarry(region)
+	if err != nil {
+		b.log.Printf("❌ Failed to start quarry: %v", err)
+		return
+	}
+	if !answer {
+		return
+	}
+	stats := b.swarm.snapshot()
+	online := 0
+	for _, m := range stats.Bots {
+		if m.Online {
+			online++
+		}
+	}
+	if started {
+		b.reply(msg, fmt.Sprintf("Quarrying %d chunk(s) with %d bot(s)", stats.ChunksLeft, online))
+		return
+	}
+	b.reply(msg, fmt.Sprintf("Already quarrying: %d chunk(s) done, %d left, %d block(s) mined by %d bot(s)",
+		stats.ChunksDone, stats.ChunksLeft, stats.TotalMined, online))
+}
@@ -0,0 +1,106 @@
+package miner
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// legacyFormatting matches the § color and style codes some servers still put in text
+var legacyFormatting = regexp.MustCompile(`§[0-9a-fk-orx]`)
+
+// tabList keeps the header and footer of the player list and the fields read from them
+type tabList struct {
+	mu       sync.RWMutex
+	patterns map[string]*regexp.Regexp // tablist_fields, compiled
+	header   string
+	footer   string
+	fields   map[string]string
+}
+
+// tabListState is the player list text and its fields in GET /state
+type tabListState struct {
+	Header string            `json:"header,omitempty"`
+	Footer string            `json:"footer,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// compileTabListFields compiles the tablist_fields patterns
+func compileTabListFields(fields map[string]string) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp, len(fields))
+	for name, expr := range fields {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		if re.NumSubexp() > 1 {
+			return nil, fmt.Errorf("field %s: pattern has %d groups, at most one captures the value", name, re.NumSubexp())
+		}
+		patterns[name] = re
+	}
+	return patterns, nil
+}
+
+// registerTabListHandler reads the player list header and footer the server sends
+func (b *Bot) registerTabListHandler() {
+	b.tablist.patterns, _ = compileTabListFields(b.cfg.TabListFields) // Checked when the config was loaded
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundTabList, F: b.onTabList})
+}
+
+// onTabList matches the fields against a new header and footer and emits a server_telemetry
+// event when one of them changed
+func (b *Bot) onTabList(p pk.Packet) error {
+	var header, footer chat.Message
+	if err := p.Scan(&header, &footer); err != nil {
+		return err
+	}
+	h := legacyFormatting.ReplaceAllString(header.ClearString(), "")
+	f := legacyFormatting.ReplaceAllString(footer.ClearString(), "")
+
+	fields := make(map[string]string, len(b.tablist.patterns))
+	for name, re := range b.tablist.patterns {
+		for _, text := range []string{h, f} {
+			if m := re.FindStringSubmatch(text); m != nil {
+				fields[name] = strings.TrimSpace(m[len(m)-1])
+				break
+			}
+		}
+	}
+
+	b.tablist.mu.Lock()
+	changed := !maps.Equal(fields, b.tablist.fields)
+	b.tablist.header, b.tablist.footer, b.tablist.fields = h, f, fields
+	b.tablist.mu.Unlock()
+
+	if changed && len(fields) > 0 {
+		b.events.emit(eventServerTelemetry, fields)
+	}
+	return nil
+}
+
+// snapshot returns the player list text and its fields
+func (t *tabList) snapshot() tabListState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return tabListState{Header: t.header, Footer: t.footer, Fields: maps.Clone(t.fields)}
+}
+
+// numericFields returns the fields that read as numbers, like "19.98", "1,024" or "$5", by name
+func (t *tabList) numericFields() map[string]float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	values := make(map[string]float64)
+	for name, value := range t.fields {
+		if v, ok := parseAmount(value); ok {
+			values[name] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,377 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// taskResultHistory is the number of finished tasks whose result stays available
+const taskResultHistory = 100
+
+// task is a unit of work executed by the task worker, one at a time
+type task struct {
+	ID          int64
+	Name        string
+	Created     time.Time
+	Started     time.Time
+	Exposure    exposure  // Surface tasks are kept to daytime, underground ones preferred at night
+	Constraints []string  // Conditions of task_constraints the task waits for and stops when they end
+	Spec        *taskSpec // How to queue the task again after a restart, nil for tasks that aren't resumed
+	run         func(ctx context.Context) error
+	mined       int           // Blocks mined while the task ran
+	result      *taskResult   // Outcome once the task is over
+	done        chan struct{} // Closed once the task is over
+	requeued    *task         // The copy queued again when the task was interrupted, which carries on its ID
+}
+
+// taskInfo is the serializable view of a task
+type taskInfo struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Created     time.Time  `json:"created"`
+	Started     *time.Time `json:"started,omitempty"`
+	Exposure    string     `json:"exposure,omitempty"`
+	Constraints []string   `json:"constraints,omitempty"`
+}
+
+// taskResult is the data of a task_finished event, and the status of a task for API callers
+type taskResult struct {
+	Task        taskInfo   `json:"task"`
+	Status      string     `json:"status"` // pending or running, then finished, cancelled or failed
+	Finished    *time.Time `json:"finished,omitempty"`
+	BlocksMined int        `json:"blocks_mined"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// taskQueue is a FIFO of pending tasks plus the task currently running
+type taskQueue struct {
+	mu      sync.Mutex
+	nextID  int64
+	pending []*task
+	current *task
+	over    []*task            // Finished tasks, oldest first
+	cancel  context.CancelFunc // Cancels the current task
+	wake    chan struct{}
+}
+
+func newTaskQueue() *taskQueue {
+	return &taskQueue{wake: make(chan struct{}, 1)}
+}
+
+// enqueueTask adds a task to the end of the queue and returns it
+func (b *Bot) enqueueTask(name string, run func(ctx context.Context) error) *task {
+	return b.enqueueExposedTask(name, exposureAny, run)
+}
+
+// enqueueExposedTask adds a task that works on the surface or underground to the queue,
+// so it can be scheduled by the time of day
+func (b *Bot) enqueueExposedTask(name string, e exposure, run func(ctx context.Context) error) *task {
+	return b.enqueueResumableTask(name, e, nil, run)
+}
+
+// enqueueResumableTask adds a task that is saved while it runs, so spec can queue it
+// again when the bot restarts before it is over
+func (b *Bot) enqueueResumableTask(name string, e exposure, spec *taskSpec, run func(ctx context.Context) error) *task {
+	q := b.tasks
+	q.mu.Lock()
+	q.nextID++
+	t := &task{
+		ID:          q.nextID,
+		Name:        name,
+		Created:     time.Now(),
+		Exposure:    e,
+		Constraints: b.constraintsFor(name, e, spec),
+		Spec:        spec,
+		run:         run,
+		done:        make(chan struct{}),
+	}
+	q.pending = append(q.pending, t)
+	q.mu.Unlock()
+
+	b.log.Printf("📋 Queued task #%d: %s", t.ID, t.Name)
+	if safe, known := b.surfaceSafe(); e == exposureSurface && known && !safe && b.daylightScheduling() {
+		b.log.Printf("🌙 Task #%d works on the surface and waits for daylight", t.ID)
+	}
+
+	q.notify()
+	return t
+}
+
+// notify wakes the task worker to look at the queue again
+func (q *taskQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runTasks executes queued tasks one after another until ctx is done
+func (b *Bot) runTasks(ctx context.Context) {
+	q := b.tasks
+	for {
+		t, taskCtx := q.next(ctx, b.scheduleTask)
+		if t == nil {
+			if b.queueSleepIfDue() {
+				continue
+			}
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		b.log.Printf("▶️ Starting task #%d: %s", t.ID, t.Name)
+		b.events.emit(eventTaskStarted, t.info())
+		b.saveTask(t)
+
+		err := t.run(taskCtx)
+		// A task cut short by the bot stopping is resumed on the next start
+		if ctx.Err() == nil && !b.stopping.Load() {
+			b.forgetTask()
+		}
+		// An interrupted task isn't over, its copy in the queue finishes it
+		if q.interrupted(t) {
+			b.log.Printf("🔁 Task #%d (%s) interrupted, queued again", t.ID, t.Name)
+			continue
+		}
+		result := taskResult{Task: t.info(), BlocksMined: q.minedBy(t)}
+		switch {
+		case errors.Is(err, context.Canceled):
+			b.log.Printf("⏹️ Task #%d (%s) cancelled", t.ID, t.Name)
+			result.Status = "cancelled"
+		case err != nil:
+			b.log.Printf("❌ Task #%d (%s) failed: %v", t.ID, t.Name, err)
+			result.Status = "failed"
+			result.Error = err.Error()
+		default:
+			b.log.Printf("✓ Task #%d (%s) finished", t.ID, t.Name)
+			result.Status = "finished"
+		}
+		finished := time.Now()
+		result.Finished = &finished
+		b.events.emit(eventTaskFinished, result)
+
+		q.mu.Lock()
+		q.cancel()
+		q.current = nil
+		q.cancel = nil
+		q.finish(t, result)
+		q.mu.Unlock()
+	}
+}
+
+// next pops the pending task chosen by pick, marks it as current and
+// returns the context it runs under. pick returns -1 when no task may run yet.
+func (q *taskQueue) next(ctx context.Context, pick func([]*task) int) (*task, context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := pick(q.pending)
+	if i < 0 {
+		return nil, nil
+	}
+	t := q.pending[i]
+	q.pending = slices.Delete(q.pending, i, i+1)
+	t.Started = time.Now()
+	q.current = t
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	q.cancel = cancel
+	return t, taskCtx
+}
+
+// interrupted reports whether t was queued again while running and, if so, lets the queue move on
+// to the next task, counting the blocks t mined towards its copy
+func (q *taskQueue) interrupted(t *task) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if t.requeued == nil {
+		return false
+	}
+	t.requeued.mined += t.mined
+	q.cancel()
+	q.current = nil
+	q.cancel = nil
+	return true
+}
+
+// finish stores the result of a task that is over. q.mu must be held.
+func (q *taskQueue) finish(t *task, result taskResult) {
+	t.result = &result
+	close(t.done)
+	q.over = append(q.over, t)
+	if len(q.over) > taskResultHistory {
+		q.over = q.over[len(q.over)-taskResultHistory:]
+	}
+}
+
+// minedBy returns the blocks mined while t ran
+func (q *taskQueue) minedBy(t *task) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return t.mined
+}
+
+// countMined counts the blocks mined towards the running task
+func (q *taskQueue) countMined(BlockMined) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current != nil {
+		q.current.mined++
+	}
+}
+
+// status returns the result of a task, or its progress while it is pending or running, and a
+// channel closed once it is over. ok is false for unknown tasks and those over too long ago.
+func (q *taskQueue) status(id int64) (result taskResult, done <-chan struct{}, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, t := range q.over {
+		if t.ID == id {
+			return *t.result, t.done, true
+		}
+	}
+	if t := q.current; t != nil && t.ID == id {
+		return taskResult{Task: t.info(), Status: "running", BlocksMined: t.mined}, t.done, true
+	}
+	for _, t := range q.pending {
+		if t.ID == id {
+			return taskResult{Task: t.info(), Status: "pending"}, t.done, true
+		}
+	}
+	return taskResult{}, nil, false
+}
+
+// clear drops every pending task and cancels the current one.
+// It returns the number of tasks affected.
+func (q *taskQueue) clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.pending)
+	now := time.Now()
+	for _, t := range q.pending {
+		q.finish(t, taskResult{Task: t.info(), Status: "cancelled", Finished: &now})
+	}
+	q.pending = nil
+	if q.cancel != nil {
+		q.cancel()
+		n++
+	}
+	return n
+}
+
+// cancelCurrent cancels the running task, leaving the queue untouched.
+// It reports whether a task was running.
+func (q *taskQueue) cancelCurrent() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.cancel == nil {
+		return false
+	}
+	q.cancel()
+	return true
+}
+
+// requeueCurrent cancels the running task when keep accepts it and queues it again at the
+// front under the same ID, so it starts over once the scheduler lets it. It returns the
+// requeued task, nil when none was requeued.
+func (q *taskQueue) requeueCurrent(keep func(*task) bool) *task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.current
+	if t == nil || q.cancel == nil || t.requeued != nil || !keep(t) {
+		return nil
+	}
+	again := q.again(t)
+	q.pending = slices.Insert(q.pending, 0, again)
+	q.cancel()
+	return again
+}
+
+// again returns a copy of the running task t to queue again. It keeps the ID and the done
+// channel, so callers waiting on t get the outcome of the copy. q.mu must be held.
+func (q *taskQueue) again(t *task) *task {
+	t.requeued = &task{
+		ID:          t.ID,
+		Name:        t.Name,
+		Created:     t.Created,
+		Exposure:    t.Exposure,
+		Constraints: t.Constraints,
+		Spec:        t.Spec,
+		run:         t.run,
+		done:        t.done,
+	}
+	return t.requeued
+}
+
+// enqueueUrgentTask queues a task ahead of all others and interrupts the running task, which
+// starts over once the urgent one is done
+func (b *Bot) enqueueUrgentTask(name string, run func(ctx context.Context) error) *task {
+	t := b.enqueueTask(name, run)
+	q := b.tasks
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.Index(q.pending, t)
+	if i < 0 {
+		return t // Already running
+	}
+	front := []*task{t}
+	if q.current != nil && q.cancel != nil && q.current.requeued == nil {
+		front = append(front, q.again(q.current))
+		q.cancel()
+	}
+	q.pending = slices.Insert(slices.Delete(q.pending, i, i+1), 0, front...)
+	return t
+}
+
+// snapshot returns the current and pending tasks
+func (q *taskQueue) snapshot() (current *taskInfo, pending []taskInfo) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current != nil {
+		info := q.current.info()
+		current = &info
+	}
+	pending = make([]taskInfo, 0, len(q.pending))
+	for _, t := range q.pending {
+		pending = append(pending, t.info())
+	}
+	return current, pending
+}
+
+// currentKind returns the kind of the running task, empty when the bot is idle
+func (q *taskQueue) currentKind() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current == nil {
+		return ""
+	}
+	return q.current.kind()
+}
+
+// kind is what the task does, without what it does it to: the kind of its spec or else the
+// first word of its name
+func (t *task) kind() string {
+	if t.Spec != nil && t.Spec.Kind != "" {
+		return t.Spec.Kind
+	}
+	kind, _, _ := strings.Cut(t.Name, " ")
+	return kind
+}
+
+func (t *task) info() taskInfo {
+	info := taskInfo{ID: t.ID, Name: t.Name, Created: t.Created, Constraints: t.Constraints}
+	if t.Exposure != exposureAny {
+		info.Exposure = t.Exposure.String()
+	}
+	if !t.Started.IsZero() {
+		started := t.Started
+		info.Started = &started
+	}
+	return info
+}
@@ -0,0 +1,226 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Tnze/go-mc/bot/screen"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+const (
+	durabilityPerBlock = 5         // Wear of one mined block, see minePlanned
+	defaultToolKind    = "pickaxe" // Replacement looked for when mining by hand
+	chestMenus         = 6         // Menu types below this are chests with 1 to 6 rows
+	inventoryStart     = 9         // First main inventory slot of the player inventory window
+)
+
+// toolWorn reports whether the mining item breaks with the next block
+func (b *Bot) toolWorn() bool {
+	return b.itemDurability <= durabilityPerBlock
+}
+
+// replaceTool swaps the worn mining item for a fresh one of the same kind: a spare from
+// the inventory, one crafted from stockpiled materials or one from the home chest
+func (b *Bot) replaceTool(ctx context.Context) error {
+	worn := b.heldTool()
+	material, kind := "", defaultToolKind
+	if worn != "" {
+		material, kind = weaponKind(worn)
+	}
+	b.log.Printf("🔧 %s is about to break, looking for a replacement", nameOr(worn, "Hand"))
+
+	if slot := b.findSpareTool(kind, material); slot >= 0 {
+		return b.equipTool(slot)
+	}
+
+	err := b.craftTool(ctx, kind, material)
+	if err == nil {
+		if slot := b.findSpareTool(kind, material); slot >= 0 {
+			return b.equipTool(slot)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	home := b.cfg.HomeChest
+	if home == nil {
+		return fmt.Errorf("no spare %s: %w", kind, err)
+	}
+	b.log.Printf("🏠 Fetching a %s from the home chest at (%d, %d, %d)", kind, home.X, home.Y, home.Z)
+	if err := b.approachBlock(ctx, *home); err != nil {
+		return fmt.Errorf("failed to reach the home chest: %w", err)
+	}
+	if err := b.takeFromChest(ctx, *home, func(name string) int { return toolScore(name, kind, material) }); err != nil {
+		b.log.Printf("⚠️ No %s in the home chest: %v", kind, err)
+		// A crafting table may stand next to the chest
+		if err := b.craftTool(ctx, kind, material); err != nil {
+			return fmt.Errorf("no spare %s in the inventory or the home chest: %w", kind, err)
+		}
+	}
+	if slot := b.findSpareTool(kind, material); slot >= 0 {
+		return b.equipTool(slot)
+	}
+	return fmt.Errorf("no spare %s arrived in the inventory", kind)
+}
+
+// nameOr returns name, or fallback when it is empty
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// toolScore ranks an item as a replacement for a tool: the same material first,
+// then better tiers. It is -1 for items of another kind.
+func toolScore(name, kind, material string) int {
+	m, k := weaponKind(name)
+	if k != kind {
+		return -1
+	}
+	score := toolTiers[m].tier
+	if m == material {
+		score += 100
+	}
+	return score
+}
+
+// findSpareTool returns the inventory slot of the best tool of a kind other than
+// the one in use, -1 when there is none
+func (b *Bot) findSpareTool(kind, material string) int {
+	if b.screens == nil {
+		return -1
+	}
+	best, bestScore := -1, -1
+	for i := inventoryStart; i < offhandSlot; i++ {
+		s := b.screens.Inventory.Slots[i]
+		if s.Count <= 0 || int32(i) == b.miningItem {
+			continue
+		}
+		if score := toolScore(itemName(int32(s.ID)), kind, material); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// craftTool crafts a tool of a kind from the inventory, trying the material of the worn
+// one first and then the cheap ones
+func (b *Bot) craftTool(ctx context.Context, kind, material string) error {
+	materials := []string{"stone", "wooden"}
+	if material != "" && material != "stone" && material != "wooden" {
+		materials = append([]string{material}, materials...)
+	}
+
+	err := errors.New("no recipe")
+	for _, m := range materials {
+		item := fmt.Sprintf("minecraft:%s_%s", m, kind)
+		if _, err = b.craftBook().Plan(item, 1, b.inventoryCounts()); err != nil {
+			continue
+		}
+		b.log.Printf("🔨 Crafting a replacement %s", item)
+		if err = b.craftItem(ctx, item, 1); err != nil {
+			return err
+		}
+		// The crafted tool reaches the inventory window once the table is closed
+		return sleepCtx(ctx, craftSyncDelay)
+	}
+	return err
+}
+
+// takeFromChest opens the chest at pos and shift-clicks the stack score ranks highest
+// into the inventory. score returns -1 for items not to take.
+func (b *Bot) takeFromChest(ctx context.Context, pos blockPos, score func(name string) int) error {
+	s, err := b.openContainer(ctx, pos, func(typ int32) bool { return typ < chestMenus })
+	if err != nil {
+		return fmt.Errorf("failed to open the chest: %w", err)
+	}
+	defer func() {
+		if err := b.closeWindow(s.ID); err != nil {
+			b.log.Printf("⚠️ Failed to close the chest: %v", err)
+		}
+	}()
+	// Let the window contents arrive
+	if err := sleepCtx(ctx, craftSyncDelay); err != nil {
+		return err
+	}
+
+	stacks := b.windowStacks(s.ID)
+	best, bestScore := -1, -1
+	for i := range min(int(s.Type+1)*9, len(stacks)) {
+		if stacks[i].Count <= 0 {
+			continue
+		}
+		if sc := score(stacks[i].Item); sc > bestScore {
+			best, bestScore = i, sc
+		}
+	}
+	if best < 0 {
+		return errors.New("nothing to take")
+	}
+
+	if err := b.sendClick(s.ID, craft.Click{Slot: best, Button: craft.ButtonLeft, Mode: craft.ModeQuickMove,
+		Changed: map[int]craft.Stack{best: {}}}); err != nil {
+		return fmt.Errorf("failed to take %s: %w", stacks[best].Item, err)
+	}
+	b.log.Printf("📦 Took %d %s from the chest", stacks[best].Count, stacks[best].Item)
+	return nil
+}
+
+// equipTool moves the tool in an inventory slot to the hand and makes it the mining item
+func (b *Bot) equipTool(slot int) error {
+	durability := b.expectedDurability(slot)
+	if slot >= hotbarStart && slot < hotbarStart+hotbarSize {
+		if err := b.selectHotbarSlot(int32(slot - hotbarStart)); err != nil {
+			return err
+		}
+	} else {
+		// Press the number key of the held slot over the tool
+		b.stateMu.RLock()
+		held := b.heldSlot
+		b.stateMu.RUnlock()
+		hotbar := hotbarStart + int(held)
+		inv := &b.screens.Inventory.Slots
+		if err := b.sendClick(0, craft.Click{Slot: slot, Button: int(held), Mode: clickModeSwap,
+			Changed: map[int]craft.Stack{slot: craftStack(inv[hotbar]), hotbar: craftStack(inv[slot])}}); err != nil {
+			return fmt.Errorf("failed to move the tool to the hotbar: %w", err)
+		}
+		tool, toolOK := b.itemDetailsAt(slot)
+		other, otherOK := b.itemDetailsAt(hotbar)
+		b.setSlotDetails(hotbar, tool, toolOK)
+		b.setSlotDetails(slot, other, otherOK)
+		slot = hotbar
+	}
+
+	b.miningItem = int32(slot)
+	b.itemDurability = durability
+	b.log.Printf("🔧 Now mining with %s", b.heldTool())
+	return nil
+}
+
+// applyClick updates the tracked window contents with what a click is predicted to change.
+// The server only sends the slots it disagrees on.
+func (b *Bot) applyClick(window int, c craft.Click) {
+	var slots []screen.Slot
+	if window == 0 {
+		slots = b.screens.Inventory.Slots[:]
+	} else if chest, ok := b.screens.Screens[window].(*screen.Chest); ok {
+		slots = chest.Slots
+	}
+	for i, s := range c.Changed {
+		if i < 0 || i >= len(slots) {
+			continue
+		}
+		id, ok := itemID(s.Item)
+		if s.Count <= 0 || !ok {
+			slots[i] = screen.Slot{}
+			continue
+		}
+		slots[i] = screen.Slot{ID: pk.VarInt(id), Count: pk.VarInt(s.Count)}
+	}
+}
@@ -0,0 +1,117 @@
+package miner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/craft"
+)
+
+const (
+	totemItem          = "minecraft:totem_of_undying"
+	totemPopStatus     = 35  // Entity event status of a totem of undying saving its holder
+	overworldLavaLevel = -54 // Lava fills the caves from here down
+	netherLavaLevel    = 31  // Top of the lava sea
+	lavaLevelMargin    = 4   // Blocks above the lava level that count as at it
+	totemSyncDelay     = 100 * time.Millisecond
+	totemCommand       = "totem" // command_replies key of totem reports
+)
+
+// registerTotemHandler watches the bot's totems of undying pop
+func (b *Bot) registerTotemHandler() {
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundEntityEvent, F: b.onTotemPop})
+}
+
+// onTotemPop tells the owner a totem saved the bot and puts the next one in the offhand
+func (b *Bot) onTotemPop(p pk.Packet) error {
+	var (
+		id     pk.Int
+		status pk.Byte
+	)
+	if err := p.Scan(&id, &status); err != nil {
+		return err
+	}
+	if int32(id) != b.player.EID || status != totemPopStatus {
+		return nil
+	}
+
+	left := b.inventoryCounts()[totemItem] - 1 // The offhand slot empties after the event
+	pos := b.feetBlock()
+	b.log.Printf("💫 A totem of undying saved the bot, %d left", max(left, 0))
+	b.events.emit(eventTotemPopped, map[string]any{"pos": pos, "left": max(left, 0)})
+	go func() {
+		b.replyTo(totemCommand, b.actingOwner(), fmt.Sprintf("A totem saved me at (%d, %d, %d), %d left", pos.X, pos.Y, pos.Z, max(left, 0)))
+		time.Sleep(totemSyncDelay) // Let the emptied offhand arrive
+		if _, err := b.equipTotem(); err != nil {
+			b.log.Printf("⚠️ %v", err)
+		}
+	}()
+	return nil
+}
+
+// atLavaLevel reports whether pos is at or just above the lava level of the dimension
+func (b *Bot) atLavaLevel(pos blockPos) bool {
+	level := overworldLavaLevel
+	if b.world.hot() {
+		level = netherLavaLevel
+	}
+	return pos.Y <= level+lavaLevelMargin
+}
+
+// equipTotem moves a totem of undying from the inventory to the offhand, where it saves the bot
+// from dying. It reports whether the offhand holds a totem afterwards.
+func (b *Bot) equipTotem() (bool, error) {
+	if b.screens == nil {
+		return false, nil
+	}
+	if b.offhandTotem() {
+		return true, nil
+	}
+
+	slots := &b.screens.Inventory.Slots
+	from := -1
+	for i := inventoryStart; i < offhandSlot; i++ {
+		if s := slots[i]; s.Count > 0 && itemName(int32(s.ID)) == totemItem {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return false, nil
+	}
+
+	// Press the offhand key over the totem, whatever the offhand held goes to its slot
+	if err := b.sendClick(0, craft.Click{Slot: from, Button: offhandButton, Mode: clickModeSwap,
+		Changed: map[int]craft.Stack{from: craftStack(slots[offhandSlot]), offhandSlot: craftStack(slots[from])}}); err != nil {
+		return false, fmt.Errorf("failed to move the totem to the offhand: %w", err)
+	}
+	totem, _ := b.itemDetailsAt(from)
+	other, otherOK := b.itemDetailsAt(offhandSlot)
+	b.setSlotDetails(offhandSlot, totem, totem.Item != "")
+	b.setSlotDetails(from, other, otherOK)
+	b.log.Printf("💫 Moved a totem of undying from slot %d to the offhand", from)
+	return true, nil
+}
+
+// holdTotemAt puts a totem in the offhand before digging pos at lava level or next to lava
+func (b *Bot) holdTotemAt(pos blockPos) {
+	if !b.atLavaLevel(pos) && !b.world.nextToLava(pos) {
+		return
+	}
+	if _, err := b.equipTotem(); err != nil {
+		b.log.Printf("⚠️ %v", err)
+	}
+}
+
+// offhandTotem reports whether the offhand holds a totem of undying
+func (b *Bot) offhandTotem() bool {
+	if b.screens == nil {
+		return false
+	}
+	s := b.screens.Inventory.Slots[offhandSlot]
+	return s.Count > 0 && itemName(int32(s.ID)) == totemItem
+}
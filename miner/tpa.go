@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// TeleportConfig answers the teleport requests of tpa plugins like EssentialsX
+type TeleportConfig struct {
+	Enabled bool     `json:"enabled"`
+	Allow   []string `json:"allow,omitempty"` // Players whose requests are accepted, besides the owner
+	// To and Here match the request messages of /tpa (the player comes to the bot) and
+	// /tpahere (the bot goes to the player); the first group is the player
+	To     []string `json:"to"`
+	Here   []string `json:"here"`
+	Accept string   `json:"accept"` // Commands answering a request, {player} is the player
+	Deny   string   `json:"deny"`
+}
+
+// defaultTeleport returns the messages and commands of EssentialsX, which most tpa plugins copy
+func defaultTeleport() TeleportConfig {
+	return TeleportConfig{
+		To:     []string{`^(\w+) has requested to teleport to you`},
+		Here:   []string{`^(\w+) has requested that you teleport to them`},
+		Accept: "tpaccept {player}",
+		Deny:   "tpdeny {player}",
+	}
+}
+
+// teleportRequest is the data of a teleport_request event
+type teleportRequest struct {
+	Player   string `json:"player"`
+	Here     bool   `json:"here"` // The player asked the bot to come to them
+	Accepted bool   `json:"accepted"`
+}
+
+// teleportPatterns are the compiled request messages
+type teleportPatterns struct {
+	to, here []*regexp.Regexp
+}
+
+// compile compiles the request messages
+func (c TeleportConfig) compile() (teleportPatterns, error) {
+	var p teleportPatterns
+	for _, list := range []struct {
+		exprs []string
+		into  *[]*regexp.Regexp
+	}{{c.To, &p.to}, {c.Here, &p.here}} {
+		for _, expr := range list.exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return p, err
+			}
+			if re.NumSubexp() < 1 {
+				return p, fmt.Errorf("pattern %q has no group for the player", expr)
+			}
+			*list.into = append(*list.into, re)
+		}
+	}
+	return p, nil
+}
+
+// registerTeleportHandler answers teleport requests when enabled
+func (b *Bot) registerTeleportHandler() {
+	if !b.cfg.Teleport.Enabled {
+		return
+	}
+	patterns, _ := b.cfg.Teleport.compile() // Checked when the config was loaded
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundSystemChat, F: func(p pk.Packet) error {
+		return b.onTeleportRequest(p, patterns)
+	}})
+}
+
+// onTeleportRequest accepts a teleport request of the owner or an allowed player and denies
+// the others. Only system messages count, players can't fake them in chat.
+func (b *Bot) onTeleportRequest(p pk.Packet, patterns teleportPatterns) error {
+	var msg chat.Message
+	if err := p.Scan(&msg); err != nil {
+		return nil // handleChatPacket reports it
+	}
+	text := legacyFormatting.ReplaceAllString(msg.ClearString(), "")
+	req, ok := matchTeleport(text, patterns)
+	if !ok {
+		return nil
+	}
+	req.Accepted = strings.EqualFold(req.Player, b.actingOwner()) ||
+		slices.ContainsFunc(b.cfg.Teleport.Allow, func(name string) bool { return strings.EqualFold(name, req.Player) })
+
+	command, verb := b.cfg.Teleport.Deny, "Denied"
+	if req.Accepted {
+		command, verb = b.cfg.Teleport.Accept, "Accepted"
+	}
+	what := "to teleport to the bot"
+	if req.Here {
+		what = "the bot to teleport to them"
+	}
+	b.log.Printf("📨 %s the request of %s %s", verb, req.Player, what)
+	b.events.emit(eventTeleportRequest, req)
+	go func() {
+		if err := b.sendCommand(strings.ReplaceAll(command, "{player}", req.Player)); err != nil {
+			b.log.Printf("❌ Failed to answer the teleport request of %s: %v", req.Player, err)
+		}
+	}()
+	return nil
+}
+
+// matchTeleport matches a system message against the request messages
+func matchTeleport(text string, patterns teleportPatterns) (teleportRequest, bool) {
+	for _, re := range patterns.to {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return teleportRequest{Player: m[1]}, true
+		}
+	}
+	for _, re := range patterns.here {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return teleportRequest{Player: m[1], Here: true}, true
+		}
+	}
+	return teleportRequest{}, false
+}
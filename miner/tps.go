@@ -0,0 +1,125 @@
+package miner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	tpsSamples = 11              // Time updates the TPS is estimated over, 10 seconds at one a second
+	tpsMinSpan = 3 * time.Second // Time updates must span this much before the TPS is known
+	fullTPS    = 20              // Ticks per second of a server keeping up
+	lagTPS     = 18              // Below this movement slows down with the server
+	minMoveTPS = 5               // Movement slows down at most as much as for this TPS
+)
+
+// tickRate estimates the server's ticks per second from how far the world age advanced
+// between the time updates it sends every second
+type tickRate struct {
+	mu      sync.Mutex
+	samples []ageSample // Oldest first
+	lagging bool        // Whether movement is slowed down, to log the change
+}
+
+// ageSample is the world age a time update carried and when it arrived
+type ageSample struct {
+	at  time.Time
+	age int64
+}
+
+// add records a time update. A world age going backwards starts over, it comes from
+// another server.
+func (t *tickRate) add(age int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.samples); n > 0 && age < t.samples[n-1].age {
+		t.samples = t.samples[:0]
+	}
+	if len(t.samples) == tpsSamples {
+		t.samples = append(t.samples[:0], t.samples[1:]...)
+	}
+	t.samples = append(t.samples, ageSample{at: time.Now(), age: age})
+}
+
+// reset forgets the samples of the server the bot left
+func (t *tickRate) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = t.samples[:0]
+}
+
+// tps returns the estimated ticks per second, at most 20. known is false until the time
+// updates span a few seconds.
+func (t *tickRate) tps() (tps float64, known bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.samples) < 2 {
+		return 0, false
+	}
+	first, last := t.samples[0], t.samples[len(t.samples)-1]
+	span := last.at.Sub(first.at)
+	if span < tpsMinSpan {
+		return 0, false
+	}
+	return min(float64(last.age-first.age)/span.Seconds(), fullTPS), true
+}
+
+// onTickRateReset forgets the TPS of the server the bot was on before joining
+func (b *Bot) onTickRateReset(pk.Packet) error {
+	b.tps.reset()
+	return nil
+}
+
+// moveInterval returns how long the bot waits between two movement steps. On a lagging server
+// the steps come as slowly as its ticks, so the bot doesn't outrun it and get set back.
+func (b *Bot) moveInterval() time.Duration {
+	tps, known := b.tps.tps()
+	lagging := known && tps < lagTPS
+
+	b.tps.mu.Lock()
+	changed := lagging != b.tps.lagging
+	b.tps.lagging = lagging
+	b.tps.mu.Unlock()
+	if changed {
+		if lagging {
+			b.log.Printf("🐢 Server runs at %.1f TPS, moving slower to keep up", tps)
+		} else {
+			b.log.Println("🐇 Server keeps up again, moving at full speed")
+		}
+	}
+
+	if !lagging {
+		return tickDuration
+	}
+	return time.Duration(float64(tickDuration) * fullTPS / max(tps, minMoveTPS))
+}
+
+// ping returns the bot's latency as the player list shows it, which the server measures with
+// the keep-alives the bot answers
+func (b *Bot) ping() (time.Duration, bool) {
+	b.scoreboard.mu.Lock()
+	defer b.scoreboard.mu.Unlock()
+	for _, p := range b.scoreboard.players {
+		if strings.EqualFold(p.Name, b.cfg.Username) {
+			return time.Duration(p.Latency) * time.Millisecond, true
+		}
+	}
+	return 0, false
+}
+
+// handleTPSCommand reports the server's TPS and the bot's ping
+func (b *Bot) handleTPSCommand(msg string) {
+	tps := "TPS unknown yet"
+	if t, ok := b.tps.tps(); ok {
+		tps = fmt.Sprintf("TPS %.1f", t)
+	}
+	ping := "ping unknown"
+	if p, ok := b.ping(); ok {
+		ping = fmt.Sprintf("ping %d ms", p.Milliseconds())
+	}
+	b.reply(msg, tps+", "+ping)
+}
@@ -0,0 +1,378 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	tradesTaskName    = "trades"
+	tradesRadius      = 32.0            // Distance !trades looks for a villager or wandering trader
+	tradesTimeout     = 5 * time.Second // How long the trade window may take to open
+	tradesShown       = 6               // Offers listed in chat, the event has all of them
+	villagerDataIndex = 18              // Entity data index of the villager type, profession and level
+	professionNone    = 0               // Unemployed, first in the profession registry
+)
+
+// tradeOffer is one trade a villager or wandering trader offers
+type tradeOffer struct {
+	Cost     []string `json:"cost"` // Like "12 emerald"
+	Result   string   `json:"result"`
+	Uses     int      `json:"uses"`
+	MaxUses  int      `json:"max_uses"`
+	Disabled bool     `json:"disabled,omitempty"` // Sold out until the villager restocks
+}
+
+// String returns "12 emerald + book -> bookshelf" for chat
+func (o tradeOffer) String() string {
+	s := strings.Join(o.Cost, " + ") + " -> " + o.Result
+	if o.Disabled {
+		s += " (sold out)"
+	}
+	return s
+}
+
+// traderWatch follows wandering traders and unemployed villagers in view until the owner
+// has been told about them
+type traderWatch struct {
+	mu      sync.Mutex
+	watched map[int32]string // Entities not alerted yet by ID, "wandering trader" or "unemployed villager"
+	alerted map[int32]bool
+	offers  chan []tradeOffer // Offers of the trade window the bot opened
+}
+
+// registerTraderHandlers follows entities that may be traders. The handlers run after those
+// of the entity tracker, which keep the positions.
+func (b *Bot) registerTraderHandlers() {
+	b.traders.watched = make(map[int32]string)
+	b.traders.alerted = make(map[int32]bool)
+	b.traders.offers = make(chan []tradeOffer, 1)
+	b.client.Events.AddListener(
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundLogin, F: b.onTradersReset},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundRespawn, F: b.onTradersReset},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundAddEntity, F: b.onTraderAdded},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundMoveEntityPos, F: b.onTraderMoved},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundMoveEntityPosRot, F: b.onTraderMoved},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundTeleportEntity, F: b.onTraderMoved},
+		bot.PacketHandler{ID: packetid.ClientboundSetEntityData, F: b.onVillagerData},
+		bot.PacketHandler{ID: packetid.ClientboundRemoveEntities, F: b.onTradersRemoved},
+		bot.PacketHandler{ID: packetid.ClientboundMerchantOffers, F: b.onMerchantOffers},
+	)
+}
+
+// onTradersReset forgets the watched entities when joining or changing dimension
+func (b *Bot) onTradersReset(pk.Packet) error {
+	b.traders.mu.Lock()
+	defer b.traders.mu.Unlock()
+	b.traders.watched = make(map[int32]string)
+	return nil
+}
+
+// onTraderAdded starts watching wandering traders and villagers when they appear. Entity data
+// that comes with the villager tells whether it has a job; none comes for an unemployed plains
+// villager, whose data is all default.
+func (b *Bot) onTraderAdded(p pk.Packet) error {
+	var id pk.VarInt
+	if err := p.Scan(&id); err != nil {
+		return err
+	}
+	e, ok := b.entities.entity(int32(id))
+	if !ok {
+		return nil
+	}
+	switch entityName(e.Type) {
+	case "wandering_trader":
+		b.watchTrader(e.ID, "wandering trader")
+		b.checkTrader(e.ID)
+	case "villager":
+		b.watchTrader(e.ID, "unemployed villager")
+	}
+	return nil
+}
+
+// onTraderMoved alerts about watched entities that came close enough
+func (b *Bot) onTraderMoved(p pk.Packet) error {
+	var id pk.VarInt
+	if err := p.Scan(&id); err != nil {
+		return err
+	}
+	b.checkTrader(int32(id))
+	return nil
+}
+
+// onVillagerData watches villagers without a profession
+func (b *Bot) onVillagerData(p pk.Packet) error {
+	r := bytes.NewReader(p.Data)
+	var id pk.VarInt
+	if _, err := id.ReadFrom(r); err != nil {
+		return err
+	}
+	e, ok := b.entities.entity(int32(id))
+	if !ok || entityName(e.Type) != "villager" {
+		return nil
+	}
+	readEntityData(r, func(index uint8, typ int32, r io.Reader) (bool, error) {
+		if index != villagerDataIndex || typ != metaVillagerData {
+			return false, nil
+		}
+		var kind, profession, level pk.VarInt
+		if _, err := (pk.Tuple{&kind, &profession, &level}).ReadFrom(r); err != nil {
+			return true, err
+		}
+		if profession == professionNone {
+			b.watchTrader(e.ID, "unemployed villager")
+			b.checkTrader(e.ID)
+		} else {
+			b.unwatchTrader(e.ID) // Took a job
+		}
+		return true, nil
+	})
+	return nil
+}
+
+// onTradersRemoved stops watching entities that left view
+func (b *Bot) onTradersRemoved(p pk.Packet) error {
+	var ids []pk.VarInt
+	if err := p.Scan(pk.Array(&ids)); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		b.unwatchTrader(int32(id))
+	}
+	return nil
+}
+
+// watchTrader watches an entity until it comes close enough, unless the owner was told already
+func (b *Bot) watchTrader(id int32, kind string) {
+	b.traders.mu.Lock()
+	defer b.traders.mu.Unlock()
+	if !b.traders.alerted[id] {
+		b.traders.watched[id] = kind
+	}
+}
+
+// unwatchTrader stops watching an entity
+func (b *Bot) unwatchTrader(id int32) {
+	b.traders.mu.Lock()
+	defer b.traders.mu.Unlock()
+	delete(b.traders.watched, id)
+}
+
+// checkTrader alerts the owner about a watched entity once it is within trader_radius of the bot
+func (b *Bot) checkTrader(id int32) {
+	radius := float64(b.cfg.TraderRadius)
+	b.traders.mu.Lock()
+	kind, ok := b.traders.watched[id]
+	b.traders.mu.Unlock()
+	e, found := b.entities.entity(id)
+	if !ok || !found || radius <= 0 {
+		return
+	}
+	x, y, z := b.currentPosition()
+	if math.Hypot(math.Hypot(e.X-x, e.Y-y), e.Z-z) > radius {
+		return
+	}
+	b.traders.mu.Lock()
+	delete(b.traders.watched, id)
+	b.traders.alerted[id] = true
+	b.traders.mu.Unlock()
+
+	pos := blockPos{X: int(math.Floor(e.X)), Y: int(math.Floor(e.Y)), Z: int(math.Floor(e.Z))}
+	b.log.Printf("🧳 A %s showed up at (%d, %d, %d)", kind, pos.X, pos.Y, pos.Z)
+	b.events.emit(eventTraderSpotted, map[string]any{"id": id, "kind": kind, "pos": pos})
+	text := fmt.Sprintf("A %s showed up at (%d, %d, %d)", kind, pos.X, pos.Y, pos.Z)
+	if kind == "wandering trader" {
+		text += ", say !trades to see what it sells"
+	}
+	go b.replyTo(tradesTaskName, b.actingOwner(), text)
+}
+
+// onMerchantOffers reads the offers of a trade window the bot opened, up to the first offer
+// it can't read
+func (b *Bot) onMerchantOffers(p pk.Packet) error {
+	r := bytes.NewReader(p.Data)
+	var window, count pk.VarInt
+	if _, err := (pk.Tuple{&window, &count}).ReadFrom(r); err != nil {
+		return err
+	}
+	offers := make([]tradeOffer, 0, int(count))
+	for range int(count) {
+		o, err := readTradeOffer(r)
+		if err != nil {
+			b.log.Printf("⚠️ Read %d of %d trade offers: %v", len(offers), count, err)
+			break
+		}
+		offers = append(offers, o)
+	}
+	select {
+	case b.traders.offers <- offers:
+	default:
+	}
+	return nil
+}
+
+// readTradeOffer reads one offer of a trade window
+func readTradeOffer(r *bytes.Reader) (tradeOffer, error) {
+	var (
+		o                          tradeOffer
+		hasSecond, disabled        pk.Boolean
+		uses, maxUses, xp, special pk.Int
+		priceMultiplier            pk.Float
+		demand                     pk.Int
+	)
+	first, err := readItemCost(r)
+	if err != nil {
+		return o, err
+	}
+	o.Cost = append(o.Cost, first)
+
+	// The result is a whole slot, its count comes before the rest
+	var count pk.VarInt
+	start, _ := r.Seek(0, io.SeekCurrent)
+	if _, err := count.ReadFrom(r); err != nil {
+		return o, err
+	}
+	r.Seek(start, io.SeekStart)
+	result, _, err := readItemDetails(r)
+	if err != nil {
+		return o, err
+	}
+	o.Result = stackLabel(result.Item, int(count))
+
+	if _, err := hasSecond.ReadFrom(r); err != nil {
+		return o, err
+	}
+	if hasSecond {
+		second, err := readItemCost(r)
+		if err != nil {
+			return o, err
+		}
+		o.Cost = append(o.Cost, second)
+	}
+	if _, err := (pk.Tuple{&disabled, &uses, &maxUses, &xp, &special, &priceMultiplier, &demand}).ReadFrom(r); err != nil {
+		return o, err
+	}
+	o.Uses, o.MaxUses, o.Disabled = int(uses), int(maxUses), bool(disabled)
+	return o, nil
+}
+
+// readItemCost reads an item a trade asks for, returning "12 emerald"
+func readItemCost(r io.Reader) (string, error) {
+	var id, count, components pk.VarInt
+	if _, err := (pk.Tuple{&id, &count, &components}).ReadFrom(r); err != nil {
+		return "", err
+	}
+	var d itemDetails
+	for range int(components) {
+		var typ pk.VarInt
+		if _, err := typ.ReadFrom(r); err != nil {
+			return "", err
+		}
+		if err := d.readComponent(r, int32(typ)); err != nil {
+			return "", err
+		}
+	}
+	return stackLabel(itemName(int32(id)), int(count)), nil
+}
+
+// stackLabel returns "12 emerald" for 12 minecraft:emerald, or just the name for one
+func stackLabel(item string, count int) string {
+	name := strings.TrimPrefix(item, "minecraft:")
+	if count == 1 {
+		return name
+	}
+	return fmt.Sprintf("%d %s", count, name)
+}
+
+// inspectTrades opens the trade window of a villager or wandering trader and returns its offers
+func (b *Bot) inspectTrades(ctx context.Context, e trackedEntity) ([]tradeOffer, error) {
+	if err := b.walkWithin(ctx, e.X, e.Y, e.Z, 2); err != nil {
+		return nil, err
+	}
+	if err := b.lookAtEntity(e); err != nil {
+		return nil, err
+	}
+	if err := b.budget.spend(ctx, actionInteract); err != nil {
+		return nil, err
+	}
+	// Forget offers of windows opened before
+	select {
+	case <-b.traders.offers:
+	default:
+	}
+	select {
+	case <-b.screenOpened:
+	default:
+	}
+	if err := b.sendInteract(e.ID); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(tradesTimeout)
+	defer timeout.Stop()
+	var offers []tradeOffer
+	select {
+	case offers = <-b.traders.offers:
+	case <-timeout.C:
+		return nil, fmt.Errorf("the %s didn't open its trades", entityName(e.Type))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case s := <-b.screenOpened:
+		if err := b.closeWindow(s.ID); err != nil {
+			b.log.Printf("⚠️ Failed to close the trade window: %v", err)
+		}
+	case <-time.After(tickDuration):
+	}
+	return offers, nil
+}
+
+// handleTradesCommand inspects the trades of the nearest wandering trader or villager,
+// "!trades"
+func (b *Bot) handleTradesCommand(msg string) {
+	player := senderOf(msg)
+	x, y, z := b.currentPosition()
+	e, ok := b.entities.nearest(x, y, z, tradesRadius, func(e trackedEntity) bool {
+		name := entityName(e.Type)
+		return name == "wandering_trader" || name == "villager"
+	})
+	if !ok {
+		b.reply(msg, "No villager or wandering trader nearby")
+		return
+	}
+	b.enqueueTask(tradesTaskName, func(ctx context.Context) error {
+		offers, err := b.inspectTrades(ctx, e)
+		if err != nil {
+			b.replyTo(tradesTaskName, player, fmt.Sprintf("Couldn't see the trades: %v", err))
+			return err
+		}
+		b.events.emit(eventTradeOffers, map[string]any{"id": e.ID, "kind": entityName(e.Type), "offers": offers})
+		if len(offers) == 0 {
+			b.replyTo(tradesTaskName, player, fmt.Sprintf("The %s has nothing to trade", strings.ReplaceAll(entityName(e.Type), "_", " ")))
+			return nil
+		}
+		parts := make([]string, 0, tradesShown+1)
+		for i, o := range offers {
+			b.log.Printf("🧳 Trade %d: %s (%d/%d used)", i+1, o, o.Uses, o.MaxUses)
+			if i < tradesShown {
+				parts = append(parts, o.String())
+			}
+		}
+		if len(offers) > tradesShown {
+			parts = append(parts, fmt.Sprintf("%d more", len(offers)-tradesShown))
+		}
+		b.replyTo(tradesTaskName, player, "Trades: "+strings.Join(parts, ", "))
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package miner
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	transferDelay   = time.Second // Pause before connecting to the server a transfer names
+	transferRetries = 3           // Attempts to join the new server
+)
+
+// transferError ends the game loop when the server moves the bot to another host
+type transferError struct {
+	addr string
+}
+
+func (e *transferError) Error() string {
+	return "transferred to " + e.addr
+}
+
+// registerTransferHandler follows transfer packets to the server they name
+func (b *Bot) registerTransferHandler() {
+	b.client.Events.AddListener(bot.PacketHandler{ID: packetid.ClientboundTransfer, F: b.onTransfer})
+}
+
+// onTransfer reads the host a transfer packet names and leaves the current server for it
+func (b *Bot) onTransfer(p pk.Packet) error {
+	var (
+		host pk.String
+		port pk.VarInt
+	)
+	if err := p.Scan(&host, &port); err != nil {
+		return fmt.Errorf("failed to parse transfer: %w", err)
+	}
+	addr := net.JoinHostPort(string(host), strconv.Itoa(int(port)))
+	b.log.Printf("🔀 Server transfers the bot to %s", addr)
+	b.transferring.Store(true)
+	return &transferError{addr: addr}
+}
+
+// transfer closes the old connection and joins addr, keeping the task queue, statistics and
+// cookies of the bot. The task that was running starts over once the bot joined. World and
+// entities are reset by the new server's login.
+func (b *Bot) transfer(addr string) error {
+	defer func() {
+		b.transferring.Store(false)
+		b.tasks.notify()
+	}()
+	if t := b.tasks.requeueCurrent(func(*task) bool { return true }); t != nil {
+		b.log.Printf("⏸️ Stopped %s, it starts again as #%d once the bot joined %s", t.Name, t.ID, addr)
+	}
+	if err := b.saveWorldCache(); err != nil {
+		b.log.Printf("⚠️ Failed to save the world cache: %v", err)
+	}
+	if err := b.client.Conn.Close(); err != nil {
+		b.log.Printf("⚠️ Failed to close the old connection: %v", err)
+	}
+	var err error
+	for attempt := 1; attempt <= transferRetries; attempt++ {
+		time.Sleep(transferDelay)
+		if b.stopping.Load() {
+			return nil
+		}
+		if err = b.join(addr); err == nil {
+			return nil
+		}
+		b.log.Printf("⚠️ Transfer attempt %d/%d failed: %v", attempt, transferRetries, err)
+	}
+	return fmt.Errorf("failed to follow the transfer to %s: %w", addr, err)
+}
@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	routeMinTravel      = 8.0 // Routes shorter than this are not rated
+	routePoorEfficiency = 0.6 // Straight-line / traveled ratio below which a route is flagged
+	routeHistorySize    = 20
+)
+
+// routeStats describes the movement of one task
+type routeStats struct {
+	TaskID     int64      `json:"task_id"`
+	Task       string     `json:"task"`
+	Finished   time.Time  `json:"finished"`
+	Traveled   float64    `json:"traveled"`
+	Straight   float64    `json:"straight"`
+	Efficiency float64    `json:"efficiency"`
+	Start      [3]float64 `json:"start"`
+	End        [3]float64 `json:"end"`
+}
+
+// travelStats tracks the distance moved by one bot and rates its routes
+type travelStats struct {
+	mu      sync.Mutex
+	total   float64 // Blocks moved by the bot itself, teleports excluded
+	starts  map[int64]routeMark
+	history []routeStats
+	flagged int
+	trail   []blockPos // Blocks the bot walked through lately, for renders
+}
+
+// routeMark is the travel state when a task started
+type routeMark struct {
+	pos      [3]float64
+	traveled float64
+}
+
+// add accumulates distance moved by the bot
+func (t *travelStats) add(dx, dy, dz float64) {
+	t.mu.Lock()
+	t.total += math.Sqrt(dx*dx + dy*dy + dz*dz)
+	t.mu.Unlock()
+}
+
+// startRoute marks where a task started and how far the bot had traveled then
+func (b *Bot) startRoute(info taskInfo) {
+	t := &b.travel
+	x, y, z := b.currentPosition()
+	t.mu.Lock()
+	if t.starts == nil {
+		t.starts = make(map[int64]routeMark)
+	}
+	t.starts[info.ID] = routeMark{pos: [3]float64{x, y, z}, traveled: t.total}
+	t.mu.Unlock()
+}
+
+// finishRoute rates the route of a task that moved the bot
+func (b *Bot) finishRoute(result taskResult) {
+	t := &b.travel
+	x, y, z := b.currentPosition()
+
+	t.mu.Lock()
+	mark, ok := t.starts[result.Task.ID]
+	delete(t.starts, result.Task.ID)
+	traveled := t.total - mark.traveled
+	t.mu.Unlock()
+	if !ok || traveled < routeMinTravel || result.Finished == nil {
+		return
+	}
+
+	dx, dy, dz := x-mark.pos[0], y-mark.pos[1], z-mark.pos[2]
+	straight := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	route := routeStats{
+		TaskID:     result.Task.ID,
+		Task:       result.Task.Name,
+		Finished:   *result.Finished,
+		Traveled:   traveled,
+		Straight:   straight,
+		Efficiency: straight / traveled,
+		Start:      mark.pos,
+		End:        [3]float64{x, y, z},
+	}
+	b.recordRoute(route)
+}
+
+// recordRoute stores a rated route and flags poor ones
+func (b *Bot) recordRoute(route routeStats) {
+	poor := route.Efficiency < routePoorEfficiency
+
+	t := &b.travel
+	t.mu.Lock()
+	t.history = append(t.history, route)
+	if len(t.history) > routeHistorySize {
+		t.history = t.history[len(t.history)-routeHistorySize:]
+	}
+	if poor {
+		t.flagged++
+	}
+	t.mu.Unlock()
+
+	b.log.Printf("🧭 Task #%d route: traveled %.1f blocks for %.1f straight-line (%.0f%% efficient)",
+		route.TaskID, route.Traveled, route.Straight, route.Efficiency*100)
+	if poor {
+		b.log.Printf("⚠️ Inefficient route for task #%d (%s) from (%.0f, %.0f, %.0f) to (%.0f, %.0f, %.0f), check for obstacles",
+			route.TaskID, route.Task, route.Start[0], route.Start[1], route.Start[2], route.End[0], route.End[1], route.End[2])
+		b.events.emit(eventRouteInefficient, route)
+	}
+}
+
+// restore carries over the distance moved before a restart
+func (t *travelStats) restore(total float64) {
+	t.mu.Lock()
+	t.total += total
+	t.mu.Unlock()
+}
+
+// snapshot returns the total distance, recent routes and number of flagged routes
+func (t *travelStats) snapshot() (total float64, routes []routeStats, flagged int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total, append([]routeStats{}, t.history...), t.flagged
+}
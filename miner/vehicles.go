@@ -0,0 +1,487 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	fastTravelMin     = 64.0            // Goals closer than this are walked to without looking for a vehicle
+	vehicleSearch     = 8               // Blocks around the bot a boat, minecart, water or rail is looked for in
+	vehicleRouteNodes = 20000           // Water or rail blocks a route search explores at most
+	boatSpeed         = 0.35            // Blocks per tick a paddled boat covers on open water
+	boatFloat         = 0.9             // Height of a floating boat above the bottom of its water block
+	mountTimeout      = 2 * time.Second // Time the server has to seat the bot
+	cartStallTime     = 3 * time.Second // A minecart that makes no progress this long is left
+	boatBreakHits     = 5               // Hits the bot tries to break its boat with before leaving it
+	inputForward      = 0x01            // Forward flag of the player input packet
+)
+
+// railBlocks are the blocks a minecart runs on
+var railBlocks = map[string]bool{
+	"minecraft:rail":           true,
+	"minecraft:powered_rail":   true,
+	"minecraft:detector_rail":  true,
+	"minecraft:activator_rail": true,
+}
+
+// vehicleRoute is a way across water by boat or along rails by minecart
+type vehicleRoute struct {
+	Boat bool       // By boat, else by minecart
+	Path []blockPos // Water or rail blocks from the boarding point to the end
+}
+
+// end returns the block the route leaves the vehicle at
+func (r vehicleRoute) end() blockPos {
+	return r.Path[len(r.Path)-1]
+}
+
+// isBoat reports whether an entity type is a boat or raft, with or without a chest
+func isBoat(typ int32) bool {
+	name := entityName(typ)
+	return strings.HasSuffix(name, "_boat") || strings.HasSuffix(name, "_raft")
+}
+
+// isMinecart reports whether an entity type is a rideable minecart
+func isMinecart(typ int32) bool {
+	return entityName(typ) == "minecart"
+}
+
+// boatItems returns the boats in the inventory
+func (b *Bot) boatItems() []string {
+	var boats []string
+	for name := range b.inventoryCounts() {
+		if strings.HasSuffix(name, "_boat") || strings.HasSuffix(name, "_raft") {
+			boats = append(boats, name)
+		}
+	}
+	return boats
+}
+
+// waterSurface reports whether a boat can float at pos: water with air above
+func (w *worldModel) waterSurface(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	above, aboveOK := w.blockAt(blockPos{X: pos.X, Y: pos.Y + 1, Z: pos.Z})
+	return ok && aboveOK && blockName(state) == "minecraft:water" && blockName(above) == "minecraft:air"
+}
+
+// rail reports whether the block at pos is a rail
+func (w *worldModel) rail(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	return ok && railBlocks[blockName(state)]
+}
+
+// routeFrom explores the connected water surface or rails from start and returns the route to
+// the block closest to goal
+func (w *worldModel) routeFrom(start, goal blockPos, boat bool) vehicleRoute {
+	from := map[blockPos]blockPos{start: start}
+	queue := []blockPos{start}
+	closest := start
+	for len(queue) > 0 && len(from) < vehicleRouteNodes {
+		p := queue[0]
+		queue = queue[1:]
+		if distance(p, goal) < distance(closest, goal) {
+			closest = p
+		}
+		for _, d := range horizontalDirs {
+			for _, dy := range []int{0, 1, -1} {
+				n := blockPos{X: p.X + d.X, Y: p.Y + dy, Z: p.Z + d.Z}
+				if boat && dy != 0 {
+					break // Water routes stay on one level
+				}
+				if _, seen := from[n]; seen {
+					break
+				}
+				if (boat && w.waterSurface(n)) || (!boat && w.rail(n)) {
+					from[n] = p
+					queue = append(queue, n)
+					break
+				}
+			}
+		}
+	}
+
+	var path []blockPos
+	for p := closest; p != start; p = from[p] {
+		path = append(path, p)
+	}
+	path = append(path, start)
+	slices.Reverse(path)
+	return vehicleRoute{Boat: boat, Path: path}
+}
+
+// fastRoute looks for a water or rail route near the bot that brings it at least halfway to
+// goal, with a boat or minecart to take it
+func (b *Bot) fastRoute(goal blockPos) (vehicleRoute, bool) {
+	start := b.feetBlock()
+	x, y, z := b.currentPosition()
+	var best vehicleRoute
+	found := false
+	consider := func(boat bool) {
+		entry, ok := b.world.nearestMatch(start, vehicleSearch, func(p blockPos) bool {
+			if boat {
+				return b.world.waterSurface(p)
+			}
+			return b.world.rail(p)
+		})
+		if !ok {
+			return
+		}
+		route := b.world.routeFrom(entry, goal, boat)
+		if distance(route.end(), goal) > distance(start, goal)/2 {
+			return
+		}
+		if found && distance(route.end(), goal) >= distance(best.end(), goal) {
+			return
+		}
+		best, found = route, true
+	}
+
+	_, haveBoat := b.entities.nearest(x, y, z, vehicleSearch, func(e trackedEntity) bool { return isBoat(e.Type) })
+	if haveBoat || len(b.boatItems()) > 0 {
+		consider(true)
+	}
+	_, haveCart := b.entities.nearest(x, y, z, vehicleSearch, func(e trackedEntity) bool { return isMinecart(e.Type) })
+	if haveCart || b.inventoryCounts()["minecraft:minecart"] > 0 {
+		consider(false)
+	}
+	return best, found
+}
+
+// nearestMatch returns the loaded block within radius of center closest to it that matches
+func (w *worldModel) nearestMatch(center blockPos, radius int, match func(blockPos) bool) (blockPos, bool) {
+	var best blockPos
+	found := false
+	for dx := -radius; dx <= radius; dx++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dz := -radius; dz <= radius; dz++ {
+				p := blockPos{X: center.X + dx, Y: center.Y + dy, Z: center.Z + dz}
+				if (!found || distance(center, p) < distance(center, best)) && match(p) {
+					best, found = p, true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// fastTravel rides a boat or minecart towards goal when a route brings the bot at least halfway
+// there. It reports whether it rode; the caller walks the rest.
+func (b *Bot) fastTravel(ctx context.Context, goal blockPos) (bool, error) {
+	if distance(b.feetBlock(), goal) < fastTravelMin {
+		return false, nil
+	}
+	route, ok := b.fastRoute(goal)
+	if !ok {
+		return false, nil
+	}
+	var err error
+	if route.Boat {
+		err = b.rideBoat(ctx, route)
+	} else {
+		err = b.rideMinecart(ctx, route)
+	}
+	if err != nil && ctx.Err() == nil {
+		b.log.Printf("⚠️ Fast travel failed, walking instead: %v", err)
+		return false, b.dismount(ctx)
+	}
+	return err == nil, err
+}
+
+// onSetPassengers notes when the bot gets on or off a vehicle
+func (b *Bot) onSetPassengers(p pk.Packet) error {
+	var (
+		vehicle    pk.VarInt
+		passengers []pk.VarInt
+	)
+	if err := p.Scan(&vehicle, pk.Array(&passengers)); err != nil {
+		return err
+	}
+	seated := slices.Contains(passengers, pk.VarInt(b.player.EID))
+
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	switch {
+	case seated:
+		b.vehicle, b.riding = int32(vehicle), true
+	case b.riding && b.vehicle == int32(vehicle):
+		b.riding = false
+	}
+	return nil
+}
+
+// ridingVehicle returns the entity the bot sits in
+func (b *Bot) ridingVehicle() (int32, bool) {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.vehicle, b.riding
+}
+
+// mount walks up to a vehicle, gets in and waits until the server seats the bot
+func (b *Bot) mount(ctx context.Context, e trackedEntity) error {
+	if err := b.walkWithin(ctx, e.X, e.Y, e.Z, 2); err != nil {
+		return err
+	}
+	if err := b.lookAtEntity(e); err != nil {
+		return err
+	}
+	if err := b.budget.spend(ctx, actionInteract); err != nil {
+		return err
+	}
+	if err := b.sendInteract(e.ID); err != nil {
+		return fmt.Errorf("failed to get in: %w", err)
+	}
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	deadline := time.Now().Add(mountTimeout)
+	for time.Now().Before(deadline) {
+		if id, ok := b.ridingVehicle(); ok && id == e.ID {
+			b.log.Printf("🚣 Got in the %s", entityName(e.Type))
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("the server didn't seat the bot in the %s", entityName(e.Type))
+}
+
+// dismount gets off the vehicle the bot rides, if any, by sneaking. The server puts the bot
+// down next to the vehicle with a teleport.
+func (b *Bot) dismount(ctx context.Context) error {
+	if _, ok := b.ridingVehicle(); !ok {
+		return nil
+	}
+	if err := b.sendSneak(true); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	for range 20 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			b.sendSneak(false)
+			return ctx.Err()
+		}
+		if _, ok := b.ridingVehicle(); !ok {
+			break
+		}
+	}
+	return b.sendSneak(false)
+}
+
+// placeVehicle places a boat or minecart item on the block at pos and returns the entity
+func (b *Bot) placeVehicle(ctx context.Context, items []string, pos blockPos, match func(int32) bool) (trackedEntity, error) {
+	ok, err := b.holdItem(items, flightSlot)
+	if err != nil {
+		return trackedEntity{}, err
+	}
+	if !ok {
+		return trackedEntity{}, fmt.Errorf("no %s to place", strings.Join(items, " or "))
+	}
+	if err := b.walkWithin(ctx, float64(pos.X)+0.5, float64(pos.Y)+1, float64(pos.Z)+0.5, 2); err != nil {
+		return trackedEntity{}, err
+	}
+	if err := b.aimAt(ctx, pos, faceTop); err != nil {
+		return trackedEntity{}, err
+	}
+	if err := b.budget.spend(ctx, actionPlace); err != nil {
+		return trackedEntity{}, err
+	}
+	// Boats are placed by aiming at the water, minecarts by using them on the rail
+	if err := b.sendUseItemOn(pos, faceTop, 0.5, 1, 0.5); err != nil {
+		return trackedEntity{}, err
+	}
+	if err := b.sendUseItem(); err != nil {
+		return trackedEntity{}, err
+	}
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	cx, cy, cz := float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5
+	for range 40 {
+		if e, ok := b.entities.nearest(cx, cy, cz, 2, func(e trackedEntity) bool { return match(e.Type) }); ok {
+			return e, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return trackedEntity{}, ctx.Err()
+		}
+	}
+	return trackedEntity{}, errors.New("the server didn't place the vehicle")
+}
+
+// rideBoat paddles a boat along a water route, placing one from the inventory when none
+// floats nearby, and takes it along at the end
+func (b *Bot) rideBoat(ctx context.Context, route vehicleRoute) error {
+	x, y, z := b.currentPosition()
+	boat, ok := b.entities.nearest(x, y, z, vehicleSearch, func(e trackedEntity) bool { return isBoat(e.Type) })
+	placed := false
+	if !ok {
+		var err error
+		if boat, err = b.placeVehicle(ctx, b.boatItems(), route.Path[0], isBoat); err != nil {
+			return err
+		}
+		placed = true
+	}
+	if err := b.mount(ctx, boat); err != nil {
+		return err
+	}
+	end := route.end()
+	b.log.Printf("🚣 Boating %d blocks to (%d, %d, %d)", len(route.Path), end.X, end.Y, end.Z)
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	bx, by, bz := boat.X, float64(route.Path[0].Y)+boatFloat, boat.Z
+	for _, p := range route.Path {
+		tx, tz := float64(p.X)+0.5, float64(p.Z)+0.5
+		for {
+			dx, dz := tx-bx, tz-bz
+			dist := math.Hypot(dx, dz)
+			if dist < arriveRadius {
+				break
+			}
+			if _, ok := b.ridingVehicle(); !ok {
+				return errors.New("fell out of the boat")
+			}
+			move := math.Min(boatSpeed, dist)
+			bx, bz = bx+dx/dist*move, bz+dz/dist*move
+			yaw, _ := lookAngles(bx, by, bz, tx, by, tz)
+			if err := b.sendVehicleMove(bx, by, bz, yaw, true); err != nil {
+				return err
+			}
+			b.setPosition(bx, by, bz, yaw, 0)
+			ticker.Reset(b.moveInterval())
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if err := b.dismount(ctx); err != nil {
+		return err
+	}
+	if placed {
+		b.breakBoat(ctx, boat.ID)
+	}
+	return nil
+}
+
+// sendVehicleMove moves the boat the bot steers and paddles it like a player holding forward
+func (b *Bot) sendVehicleMove(x, y, z float64, yaw float32, paddling bool) error {
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundMoveVehicle,
+		pk.Double(x), pk.Double(y), pk.Double(z),
+		pk.Float(yaw), pk.Float(0),
+	)); err != nil {
+		return fmt.Errorf("failed to move the boat: %w", err)
+	}
+	if err := b.client.Conn.WritePacket(pk.Marshal(
+		packetid.ServerboundPaddleBoat,
+		pk.Boolean(paddling), pk.Boolean(paddling),
+	)); err != nil {
+		return err
+	}
+	return b.sendRotation(yaw, 0, false)
+}
+
+// breakBoat hits the boat the bot placed until it drops as an item and picks it up
+func (b *Bot) breakBoat(ctx context.Context, id int32) {
+	ticker := time.NewTicker(4 * tickDuration)
+	defer ticker.Stop()
+	for range boatBreakHits {
+		e, ok := b.entities.entity(id)
+		if !ok {
+			break
+		}
+		if err := b.lookAtEntity(e); err != nil {
+			return
+		}
+		if err := b.sendAttack(id); err != nil {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if _, err := b.collectItems(ctx); err != nil {
+		b.log.Printf("⚠️ Failed to pick the boat up: %v", err)
+	}
+}
+
+// rideMinecart rides a minecart along a rail route, holding forward, until it reaches the end
+// of the route or stops
+func (b *Bot) rideMinecart(ctx context.Context, route vehicleRoute) error {
+	x, y, z := b.currentPosition()
+	cart, ok := b.entities.nearest(x, y, z, vehicleSearch, func(e trackedEntity) bool { return isMinecart(e.Type) })
+	if !ok {
+		var err error
+		if cart, err = b.placeVehicle(ctx, []string{"minecraft:minecart"}, route.Path[0], isMinecart); err != nil {
+			return err
+		}
+	}
+	if err := b.mount(ctx, cart); err != nil {
+		return err
+	}
+	end := route.end()
+	b.log.Printf("🛤️ Riding a minecart %d blocks to (%d, %d, %d)", len(route.Path), end.X, end.Y, end.Z)
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	best, progressAt := math.Inf(1), time.Now()
+	for {
+		if _, ok := b.ridingVehicle(); !ok {
+			return errors.New("fell out of the minecart")
+		}
+		e, ok := b.entities.entity(cart.ID)
+		if !ok {
+			return errors.New("lost sight of the minecart")
+		}
+		// The server moves the cart, the bot sits in it
+		b.stateMu.RLock()
+		yaw, pitch := b.yaw, b.pitch
+		b.stateMu.RUnlock()
+		b.setPosition(e.X, e.Y, e.Z, yaw, pitch)
+
+		left := math.Hypot(e.X-float64(end.X)-0.5, e.Z-float64(end.Z)-0.5)
+		if left < 1.5 {
+			break
+		}
+		if left < best-0.5 {
+			best, progressAt = left, time.Now()
+		} else if time.Since(progressAt) > cartStallTime {
+			b.log.Printf("🛤️ The minecart stopped %.0f blocks short", left)
+			break
+		}
+		if err := b.client.Conn.WritePacket(pk.Marshal(packetid.ServerboundPlayerInput, pk.UnsignedByte(inputForward))); err != nil {
+			return err
+		}
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := b.client.Conn.WritePacket(pk.Marshal(packetid.ServerboundPlayerInput, pk.UnsignedByte(0))); err != nil {
+		return err
+	}
+	return b.dismount(ctx)
+}
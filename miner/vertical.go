@@ -0,0 +1,235 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	verticalMaxLeg = 16 // Most blocks one pillar or staircase climbs or descends before the path is tried again
+	quarryReach    = 3  // Blocks above a quarry layer the bot may stand and still dig it
+)
+
+// horizontalDirs are the four directions a staircase can head in
+var horizontalDirs = []blockPos{{X: 1}, {X: -1}, {Z: 1}, {Z: -1}}
+
+// liquid reports whether the block at pos is water or lava
+func (w *worldModel) liquid(pos blockPos) bool {
+	state, ok := w.blockAt(pos)
+	if !ok {
+		return false
+	}
+	return isLava(state) || blockName(state) == "minecraft:water"
+}
+
+// nextToLiquid reports whether water or lava touches pos, which digging pos would let in
+func (w *worldModel) nextToLiquid(pos blockPos) bool {
+	for _, d := range []blockPos{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}} {
+		if w.liquid(blockPos{X: pos.X + d.X, Y: pos.Y + d.Y, Z: pos.Z + d.Z}) {
+			return true
+		}
+	}
+	return false
+}
+
+// pillarUp climbs to targetY by jumping and placing scaffolding under its feet at the top of
+// each jump, digging out what blocks the head room
+func (b *Bot) pillarUp(ctx context.Context, targetY int) error {
+	b.stateMu.RLock()
+	held := b.heldSlot
+	b.stateMu.RUnlock()
+	defer b.selectHotbarSlot(held)
+
+	start := b.feetBlock()
+	b.log.Printf("🪜 Pillaring up from y=%d to y=%d", start.Y, targetY)
+	for feet := start; feet.Y < targetY; feet = b.feetBlock() {
+		support := blockPos{X: feet.X, Y: feet.Y - 1, Z: feet.Z}
+		if !b.world.solid(support) {
+			return fmt.Errorf("nothing to stand on at (%d, %d, %d)", support.X, support.Y, support.Z)
+		}
+		head := blockPos{X: feet.X, Y: feet.Y + 2, Z: feet.Z}
+		if !b.world.passable(head) {
+			if b.world.nextToLiquid(head) {
+				return fmt.Errorf("liquid above (%d, %d, %d)", head.X, head.Y, head.Z)
+			}
+			if err := b.mineWithItem(ctx, head.X, head.Y, head.Z); err != nil {
+				return fmt.Errorf("failed to clear the head room: %w", err)
+			}
+		}
+		if err := b.pillarStep(ctx, feet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pillarStep jumps once from feet and places a block where the bot stood once it is high
+// enough to clear it, landing on top
+func (b *Bot) pillarStep(ctx context.Context, feet blockPos) error {
+	support := blockPos{X: feet.X, Y: feet.Y - 1, Z: feet.Z}
+	if err := b.holdScaffolding(); err != nil {
+		return err
+	}
+	// Look straight down at the block underneath before leaving the ground
+	if err := b.aimAt(ctx, support, faceTop); err != nil {
+		return err
+	}
+	if err := b.budget.spend(ctx, actionPlace); err != nil {
+		return err
+	}
+	updates, stop := b.world.watch(feet)
+	defer stop()
+
+	ticker := time.NewTicker(tickDuration)
+	defer ticker.Stop()
+	x, ground, z := b.currentPosition()
+	y, vy, floor := ground, jumpVelocity, ground
+	for {
+		y += vy
+		vy = (vy - gravity) * verticalDrag
+		landed := vy < 0 && y <= floor
+		if landed {
+			y = floor
+		}
+		if err := b.sendPosition(x, y, z, landed); err != nil {
+			return fmt.Errorf("failed to send position: %w", err)
+		}
+		b.stateMu.Lock()
+		b.y = y
+		b.stateMu.Unlock()
+		if landed {
+			break
+		}
+
+		if floor == ground && y >= ground+1 {
+			// The bot is clear of the block it stood in, fill it
+			if err := b.sendUseItemOn(support, faceTop, 0.5, 1, 0.5); err != nil {
+				return err
+			}
+			if err := b.sendArmSwing(); err != nil {
+				return err
+			}
+			floor = ground + 1
+		}
+
+		ticker.Reset(b.moveInterval())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if floor == ground {
+		return errors.New("jumped too low to place a block")
+	}
+	if err := b.waitPlaced(ctx, updates, feet); err != nil {
+		// Back down to where the bot really stands
+		b.stateMu.Lock()
+		b.y = ground
+		b.stateMu.Unlock()
+		b.sendPosition(x, ground, z, true)
+		return err
+	}
+	return nil
+}
+
+// staircaseDown digs a staircase one block wide and two high down to targetY, heading in
+// dir unless that way is unsafe. It stops rather than dig into liquid or over a drop.
+func (b *Bot) staircaseDown(ctx context.Context, targetY int, dir blockPos) error {
+	start := b.feetBlock()
+	b.log.Printf("🪜 Digging a staircase from y=%d down to y=%d", start.Y, targetY)
+	for feet := start; feet.Y > targetY; feet = b.feetBlock() {
+		var step blockPos
+		var dug []blockPos
+		found := false
+		for _, d := range append([]blockPos{dir}, horizontalDirs...) {
+			step = blockPos{X: feet.X + d.X, Y: feet.Y - 1, Z: feet.Z + d.Z}
+			dug = []blockPos{
+				{X: step.X, Y: feet.Y + 1, Z: step.Z},
+				{X: step.X, Y: feet.Y, Z: step.Z},
+				step,
+			}
+			if b.safeStep(step, dug) {
+				dir, found = d, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no safe way down from (%d, %d, %d)", feet.X, feet.Y, feet.Z)
+		}
+
+		for _, pos := range dug {
+			if b.world.passable(pos) {
+				continue
+			}
+			if err := b.mineWithItem(ctx, pos.X, pos.Y, pos.Z); err != nil {
+				return fmt.Errorf("failed to dig the staircase: %w", err)
+			}
+		}
+		if err := b.walkTo(ctx, float64(step.X)+0.5, float64(step.Y), float64(step.Z)+0.5); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeStep reports whether the blocks of a staircase step can be dug without letting in
+// liquid and the step has a floor to land on
+func (b *Bot) safeStep(step blockPos, dug []blockPos) bool {
+	if !b.world.solid(blockPos{X: step.X, Y: step.Y - 1, Z: step.Z}) {
+		return false
+	}
+	for _, pos := range dug {
+		state, loaded := b.world.blockAt(pos)
+		if !loaded || b.world.liquid(pos) || b.world.nextToLiquid(pos) {
+			return false
+		}
+		if !b.world.passable(pos) && b.checkProtected(pos, blockName(state)) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// towards returns the horizontal direction from one position to another along its longer axis
+func towards(from, to blockPos) blockPos {
+	dx, dz := to.X-from.X, to.Z-from.Z
+	switch {
+	case dx == 0 && dz == 0:
+		return blockPos{X: 1}
+	case dx*dx >= dz*dz && dx > 0:
+		return blockPos{X: 1}
+	case dx*dx >= dz*dz:
+		return blockPos{X: -1}
+	case dz > 0:
+		return blockPos{Z: 1}
+	default:
+		return blockPos{Z: -1}
+	}
+}
+
+// changeElevation pillars up or digs down one leg towards the height of goal, for when no
+// path gets there. It reports whether the bot changed height.
+func (b *Bot) changeElevation(ctx context.Context, goal blockPos) (bool, error) {
+	from := b.feetBlock()
+	var err error
+	switch {
+	case goal.Y > from.Y+1 && b.scaffoldingCount() > 0:
+		err = b.pillarUp(ctx, min(goal.Y, from.Y+verticalMaxLeg))
+	case goal.Y < from.Y-1:
+		err = b.staircaseDown(ctx, max(goal.Y, from.Y-verticalMaxLeg), towards(from, goal))
+	default:
+		return false, nil
+	}
+	moved := b.feetBlock().Y != from.Y
+	if err != nil && (!moved || ctx.Err() != nil) {
+		return moved, err
+	}
+	if err != nil {
+		b.log.Printf("⚠️ Stopped changing height at y=%d: %v", b.feetBlock().Y, err)
+	}
+	return moved, nil
+}
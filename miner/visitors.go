@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const visitorWindow = time.Minute // Window the visitor rate limits count commands in
+
+// contributorCommands are the commands of the loot split, which contributors keep in visitor mode
+var contributorCommands = map[string]bool{
+	"contribute": true,
+	"mine":       true,
+	"shares":     true,
+	"claim":      true,
+	"giveback":   true,
+}
+
+// VisitorConfig opens a few harmless commands to every player on community servers, while
+// the others stay with the owner
+type VisitorConfig struct {
+	Enabled   bool     `json:"enabled"`
+	Commands  []string `json:"commands"`   // Commands visitors may use, without the "!"
+	PerPlayer int      `json:"per_player"` // Commands one visitor may use per minute
+	PerMinute int      `json:"per_minute"` // Visitor commands answered per minute in total
+}
+
+// defaultVisitors returns the visitor commands and limits
+func defaultVisitors() VisitorConfig {
+	return VisitorConfig{Commands: []string{"status", "stats", "pos"}, PerPlayer: 2, PerMinute: 10}
+}
+
+// check checks the visitor commands and limits
+func (c VisitorConfig) check() error {
+	for _, command := range c.Commands {
+		if ownerCommands[strings.ToLower(command)] {
+			return fmt.Errorf("!%s is for the owner only", command)
+		}
+	}
+	if c.PerPlayer < 1 || c.PerMinute < 1 {
+		return fmt.Errorf("per_player and per_minute must be at least 1")
+	}
+	return nil
+}
+
+// visitorLimit counts the visitor commands of the last minute
+type visitorLimit struct {
+	mu      sync.Mutex
+	players map[string][]time.Time // Times of the commands of each visitor
+	all     []time.Time
+}
+
+// admitVisitor decides whether a chat command may run: the owner may use any command and
+// contributors the loot split; other players only get the visitor commands, within the limits.
+// It returns the audit outcome of a command that may not run.
+func (b *Bot) admitVisitor(line chatLine) (string, bool) {
+	v := b.cfg.Visitors
+	if !v.Enabled || strings.EqualFold(line.Sender, b.actingOwner()) {
+		return "", true
+	}
+	if contributorCommands[line.Command] && b.mayContribute(line.Sender) {
+		return "", true
+	}
+	if !slices.ContainsFunc(v.Commands, func(c string) bool { return strings.EqualFold(c, line.Command) }) {
+		return auditDenied, false
+	}
+	if !b.visitors.take(strings.ToLower(line.Sender), v.PerPlayer, v.PerMinute) {
+		return auditLimited, false
+	}
+	return "", true
+}
+
+// take counts a command of player, unless player used perPlayer commands or all visitors
+// perMinute commands in the last minute
+func (l *visitorLimit) take(player string, perPlayer, perMinute int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	recent := func(times []time.Time) []time.Time {
+		return slices.DeleteFunc(times, func(t time.Time) bool { return now.Sub(t) >= visitorWindow })
+	}
+	if l.players == nil {
+		l.players = make(map[string][]time.Time)
+	}
+	for name, times := range l.players {
+		if times = recent(times); len(times) == 0 {
+			delete(l.players, name)
+		} else {
+			l.players[name] = times
+		}
+	}
+	l.all = recent(l.all)
+	if len(l.players[player]) >= perPlayer || len(l.all) >= perMinute {
+		return false
+	}
+	l.players[player] = append(l.players[player], now)
+	l.all = append(l.all, now)
+	return true
+}
+
+// handleStatusCommand replies with what the bot is doing and how it is
+func (b *Bot) handleStatusCommand(msg string) {
+	b.stateMu.RLock()
+	health, food := b.health, b.food
+	b.stateMu.RUnlock()
+	current, pending := b.tasks.snapshot()
+
+	doing := "Idle"
+	if current != nil {
+		doing = "Working on " + current.Name
+	}
+	if b.frozen().Frozen {
+		doing = "Paused"
+	}
+	status := fmt.Sprintf("%s, %d task(s) queued, health %.0f/20, food %d/20", doing, len(pending), health, food)
+	b.reply(msg, status)
+}
+
+// handlePosCommand replies with where the bot is
+func (b *Bot) handlePosCommand(msg string) {
+	pos := b.feetBlock()
+	dim := strings.TrimPrefix(strings.TrimPrefix(b.world.currentDimension(), "minecraft:"), "the_")
+	b.reply(msg, fmt.Sprintf("I'm at %d %d %d in the %s", pos.X, pos.Y, pos.Z, strings.ReplaceAll(dim, "_", " ")))
+}
@@ -0,0 +1,169 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const listWpMaxNames = 20 // Waypoints named in a !listwp answer
+
+// Waypoint commands. Names are case insensitive and may contain dashes, like "mine-entrance".
+var (
+	setWpCommand = regexp.MustCompile(`(?i)!setwp\s+([\w-]{1,32})(?:\s+(-?\d+)\s+(-?\d+)\s+(-?\d+))?`)
+	wpCommand    = regexp.MustCompile(`(?i)!wp\s+([\w-]{1,32})`)
+	delWpCommand = regexp.MustCompile(`(?i)!delwp\s+([\w-]{1,32})`)
+)
+
+// waypoint is a named place on a server
+type waypoint struct {
+	Name  string    `json:"name"`
+	Pos   blockPos  `json:"pos"`
+	SetBy string    `json:"set_by,omitempty"`
+	Set   time.Time `json:"set"`
+}
+
+// waypointKey identifies a waypoint on the server of the bot
+func (b *Bot) waypointKey(name string) string {
+	return b.cfg.Server + "/" + strings.ToLower(name)
+}
+
+// waypoint returns the named waypoint of the server
+func (b *Bot) waypoint(name string) (waypoint, bool) {
+	var wp waypoint
+	ok, err := b.db.Get(bucketWaypoints, b.waypointKey(name), &wp)
+	if err != nil {
+		b.log.Printf("⚠️ Unreadable waypoint %s: %v", name, err)
+		return wp, false
+	}
+	return wp, ok
+}
+
+// waypoints returns the waypoints of the server, by name
+func (b *Bot) waypoints() []waypoint {
+	prefix := b.cfg.Server + "/"
+	wps := []waypoint{}
+	err := b.db.Each(bucketWaypoints, func(key string, value json.RawMessage) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		var wp waypoint
+		if err := json.Unmarshal(value, &wp); err != nil {
+			b.log.Printf("⚠️ Skipping unreadable waypoint %s: %v", key, err)
+			return nil
+		}
+		wps = append(wps, wp)
+		return nil
+	})
+	if err != nil {
+		b.log.Printf("⚠️ Failed to read waypoints: %v", err)
+	}
+	return wps
+}
+
+// handleSetWpCommand saves a waypoint from "!setwp <name> [x y z]", at the sender's
+// feet when no coordinates are given and the sender is in view, else at the bot's
+func (b *Bot) handleSetWpCommand(msg string) {
+	// Replies name the commands and echo back
+	if b.fromSelf(msg) {
+		return
+	}
+	m := setWpCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !setwp <name> [x y z]")
+		return
+	}
+	sender := senderOf(msg)
+	wp := waypoint{Name: strings.ToLower(m[1]), SetBy: sender, Set: time.Now()}
+	switch {
+	case m[2] != "":
+		wp.Pos.X, _ = strconv.Atoi(m[2])
+		wp.Pos.Y, _ = strconv.Atoi(m[3])
+		wp.Pos.Z, _ = strconv.Atoi(m[4])
+	default:
+		if x, y, z, ok := b.entities.playerPosition(sender); sender != "" && ok {
+			wp.Pos = blockPos{X: int(math.Floor(x)), Y: int(math.Floor(y)), Z: int(math.Floor(z))}
+		} else {
+			wp.Pos = b.feetBlock()
+		}
+	}
+
+	if err := b.db.Put(bucketWaypoints, b.waypointKey(wp.Name), wp); err != nil {
+		b.log.Printf("❌ Failed to save waypoint %s: %v", wp.Name, err)
+		b.reply(msg, "Couldn't save the waypoint")
+		return
+	}
+	b.log.Printf("📍 Waypoint %s set at (%d, %d, %d)", wp.Name, wp.Pos.X, wp.Pos.Y, wp.Pos.Z)
+	b.reply(msg, fmt.Sprintf("Waypoint %s set at (%d, %d, %d)", wp.Name, wp.Pos.X, wp.Pos.Y, wp.Pos.Z))
+}
+
+// handleWpCommand queues walking to the waypoint of "!wp <name>"
+func (b *Bot) handleWpCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := wpCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !wp <name>")
+		return
+	}
+	wp, ok := b.waypoint(m[1])
+	if !ok {
+		b.reply(msg, fmt.Sprintf("No waypoint named %s, !listwp shows them", m[1]))
+		return
+	}
+	b.queueGoto(wp.Pos, b.cfg.AvoidMobs, true, senderOf(msg))
+	b.reply(msg, fmt.Sprintf("Heading to %s at (%d, %d, %d)", wp.Name, wp.Pos.X, wp.Pos.Y, wp.Pos.Z))
+}
+
+// handleDelWpCommand deletes the waypoint of "!delwp <name>"
+func (b *Bot) handleDelWpCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	m := delWpCommand.FindStringSubmatch(msg)
+	if m == nil {
+		b.reply(msg, "Usage: !delwp <name>")
+		return
+	}
+	if _, ok := b.waypoint(m[1]); !ok {
+		b.reply(msg, fmt.Sprintf("No waypoint named %s", m[1]))
+		return
+	}
+	if err := b.db.Delete(bucketWaypoints, b.waypointKey(m[1])); err != nil {
+		b.log.Printf("❌ Failed to delete waypoint %s: %v", m[1], err)
+		return
+	}
+	b.reply(msg, fmt.Sprintf("Waypoint %s deleted", strings.ToLower(m[1])))
+}
+
+// handleListWpCommand names the waypoints of the server
+func (b *Bot) handleListWpCommand(msg string) {
+	if b.fromSelf(msg) {
+		return
+	}
+	wps := b.waypoints()
+	if len(wps) == 0 {
+		b.reply(msg, "No waypoints yet, set one with !setwp <name>")
+		return
+	}
+	names := make([]string, 0, min(len(wps), listWpMaxNames))
+	for _, wp := range wps[:min(len(wps), listWpMaxNames)] {
+		names = append(names, wp.Name)
+	}
+	reply := "Waypoints: " + strings.Join(names, ", ")
+	if more := len(wps) - listWpMaxNames; more > 0 {
+		reply += fmt.Sprintf(" and %d more", more)
+	}
+	b.reply(msg, reply)
+}
+
+// handleWaypointsRequest returns the waypoints of the server
+func (b *Bot) handleWaypointsRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.waypoints())
+}
@@ -0,0 +1,352 @@
+package miner
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed static/dashboard.html
+var dashboardHTML []byte
+
+const (
+	mineTaskName = "mine"
+	taskWaitMax  = 5 * time.Minute // Longest ?wait= a task status request holds
+)
+
+// startHTTPServer serves the dashboard and JSON API in the background
+func (b *Bot) startHTTPServer(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handleDashboard)
+	mux.HandleFunc("GET /state", b.publicRead(b.handleStateRequest))
+	mux.HandleFunc("GET /events", b.publicRead(b.handleEventsRequest))
+	mux.HandleFunc("GET /events/stream", b.handleEventStream)
+	mux.HandleFunc("GET /stats", b.publicRead(b.handleStatsRequest))
+	mux.HandleFunc("GET /metrics", b.handleMetricsRequest)
+	mux.HandleFunc("GET /swarm", b.publicRead(b.handleSwarmRequest))
+	mux.HandleFunc("GET /chests", b.publicRead(b.handleChestsRequest))
+	mux.HandleFunc("GET /waypoints", b.publicRead(b.handleWaypointsRequest))
+	mux.HandleFunc("GET /scan", b.publicRead(b.handleScanRequest))
+	mux.HandleFunc("GET /advancements", b.publicRead(b.handleAdvancementsRequest))
+	mux.HandleFunc("GET /players", b.publicRead(b.handlePlayersRequest))
+	mux.HandleFunc("GET /maps", b.publicRead(b.handleMapsRequest))
+	mux.HandleFunc("GET /maps/{file}", b.publicRead(b.handleMapImageRequest))
+	mux.HandleFunc("GET /world.png", b.publicRead(b.handleWorldImageRequest))
+	mux.HandleFunc("GET /tasks/{id}", b.publicRead(b.handleTaskRequest))
+
+	// Control endpoints, off without a token
+	if b.cfg.APIToken == "" {
+		b.log.Printf("⚠️ No api_token set, the control endpoints of the HTTP API are off")
+	}
+	mux.HandleFunc("POST /tasks/mine", b.requireToken(b.handleMineRequest))
+	mux.HandleFunc("POST /tasks/goto", b.requireToken(b.handleGotoRequest))
+	mux.HandleFunc("POST /chat", b.requireToken(b.handleChatRequest))
+	mux.HandleFunc("DELETE /tasks/current", b.requireToken(b.handleCancelTaskRequest))
+	mux.HandleFunc("DELETE /tasks", b.requireToken(b.handleClearTasksRequest))
+	mux.HandleFunc("POST /freeze", b.requireToken(b.handleFreezeRequest))
+	mux.HandleFunc("DELETE /freeze", b.requireToken(b.handleUnfreezeRequest))
+
+	go func() {
+		b.log.Printf("🌐 Dashboard listening on http://%s", addr)
+		if err := http.ListenAndServe(addr, b.checkHost(addr, mux)); err != nil {
+			b.log.Printf("❌ HTTP API stopped: %v", err)
+		}
+	}()
+}
+
+// requireToken rejects requests without the configured bearer token, all of them when there
+// is none, and requests other web pages make the browser send
+func (b *Bot) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const principal = "anonymous"
+		if b.cfg.APIToken == "" {
+			writeError(w, http.StatusForbidden, errors.New("the control endpoints are off, set api_token to turn them on"))
+			b.auditHTTP(r, principal, http.StatusForbidden)
+			return
+		}
+		if status, err := checkControlRequest(r); err != nil {
+			b.log.Printf("⚠️ Rejected %s %s from %s: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+			writeError(w, status, err)
+			b.auditHTTP(r, principal, status)
+			return
+		}
+		if !b.hasToken(r) {
+			b.log.Printf("⚠️ Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			b.auditHTTP(r, principal, http.StatusUnauthorized)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		b.auditHTTP(r, "api_token", rec.status)
+	}
+}
+
+// checkHost rejects requests naming a host the API isn't served under. A page reaches the
+// API through DNS rebinding with a name of its own resolving to the bot's address, which its
+// requests then name. Addresses, localhost, the host of addr and http_hosts are served.
+func (b *Bot) checkHost(addr string, next http.Handler) http.Handler {
+	listen, _, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		served := net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") || strings.EqualFold(host, listen) ||
+			slices.ContainsFunc(b.cfg.HTTPHosts, func(h string) bool { return strings.EqualFold(h, host) })
+		if !served {
+			b.log.Printf("⚠️ Rejected %s %s from %s for host %s, which isn't in http_hosts", r.Method, r.URL.Path, r.RemoteAddr, r.Host)
+			writeError(w, http.StatusMisdirectedRequest, fmt.Errorf("the API isn't served as %s", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkControlRequest rejects control requests coming from the pages of other sites, which
+// browsers send with their Origin, and bodies that aren't JSON, which pages can send without
+// the browser asking first. Clients other than browsers send no Origin.
+func checkControlRequest(r *http.Request) (int, error) {
+	if err := checkOrigin(r); err != nil {
+		return http.StatusForbidden, err
+	}
+	if r.ContentLength != 0 {
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("the request body must be application/json")
+		}
+	}
+	return 0, nil
+}
+
+// checkOrigin rejects requests the pages of other sites make the browser send, which carry
+// their Origin
+func checkOrigin(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return fmt.Errorf("requests from %s aren't allowed", origin)
+		}
+	}
+	return nil
+}
+
+// hasToken reports whether a request carries the API token, false when there is none
+func (b *Bot) hasToken(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && b.cfg.APIToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(b.cfg.APIToken)) == 1
+}
+
+// handleDashboard serves the embedded dashboard page
+func handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardHTML)
+}
+
+// handleStateRequest returns the bot state snapshot
+func (b *Bot) handleStateRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.snapshotState())
+}
+
+// handleEventsRequest returns the recent bot events
+func (b *Bot) handleEventsRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.events.recent())
+}
+
+// handleStatsRequest returns the ore-per-hour rates, travel statistics, money earned and the session's statistics
+func (b *Bot) handleStatsRequest(w http.ResponseWriter, r *http.Request) {
+	traveled, routes, flagged := b.travel.snapshot()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ore_rates":      b.ores.rates(),
+		"traveled":       traveled,
+		"routes":         routes,
+		"routes_flagged": flagged,
+		"economy":        b.economy.snapshot(),
+		"session":        b.sessionSnapshot(),
+	})
+}
+
+// handleSwarmRequest returns the quarry progress and blocks mined across the swarm
+func (b *Bot) handleSwarmRequest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.swarm.snapshot())
+}
+
+// coordsRequest is the body of the mine and goto endpoints
+type coordsRequest struct {
+	X, Y, Z   *int
+	AvoidMobs *float64 `json:"avoid_mobs"` // Goto only, distance to keep from hostile mobs
+}
+
+// pos returns the requested position, the origin when none was given
+func (req coordsRequest) pos() blockPos {
+	if req.X == nil || req.Y == nil || req.Z == nil {
+		return blockPos{}
+	}
+	return blockPos{X: *req.X, Y: *req.Y, Z: *req.Z}
+}
+
+// decodeCoords reads {x,y,z} from the request body.
+// ok is false when the body is empty, which some endpoints allow.
+func decodeCoords(r *http.Request) (req coordsRequest, ok bool, err error) {
+	if err := json.NewDecoder(r.Body).Decode(&req); errors.Is(err, io.EOF) {
+		return req, false, nil
+	} else if err != nil {
+		return req, false, fmt.Errorf("invalid JSON body: %w", err)
+	}
+	if req.X == nil || req.Y == nil || req.Z == nil {
+		return req, false, errors.New("x, y and z are required")
+	}
+	return req, true, nil
+}
+
+// handleMineRequest queues mining a block, or the block in front of the bot when no coordinates are given
+func (b *Bot) handleMineRequest(w http.ResponseWriter, r *http.Request) {
+	req, ok, err := decodeCoords(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pos := req.pos()
+
+	var t *task
+	if !ok {
+		b.log.Println("🌐 Received mine request for the block in front")
+		t = b.enqueueTask("mine block in front", b.mineBlockInFront)
+	} else {
+		b.log.Printf("🌐 Received mine request for (%d, %d, %d)", pos.X, pos.Y, pos.Z)
+		t = b.queueMine(pos)
+	}
+	writeJSON(w, http.StatusAccepted, t.info())
+}
+
+// queueMine queues walking up to the block at pos and mining it
+func (b *Bot) queueMine(pos blockPos) *task {
+	spec := &taskSpec{Kind: mineTaskName, Pos: &pos}
+	name := fmt.Sprintf("%s %d %d %d", mineTaskName, pos.X, pos.Y, pos.Z)
+	return b.enqueueResumableTask(name, b.regionExposure(pos, pos), spec, func(ctx context.Context) error {
+		if err := b.approachBlock(ctx, pos); err != nil {
+			return err
+		}
+		return b.mineWithItem(ctx, pos.X, pos.Y, pos.Z)
+	})
+}
+
+// handleGotoRequest queues walking to a position
+func (b *Bot) handleGotoRequest(w http.ResponseWriter, r *http.Request) {
+	req, ok, err := decodeCoords(r)
+	if err == nil && !ok {
+		err = errors.New("x, y and z are required")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pos, avoid := req.pos(), b.cfg.AvoidMobs
+	if req.AvoidMobs != nil {
+		avoid = *req.AvoidMobs
+	}
+
+	b.log.Printf("🌐 Received goto request for (%d, %d, %d)", pos.X, pos.Y, pos.Z)
+	t := b.queueGoto(pos, avoid, false, "")
+	writeJSON(w, http.StatusAccepted, t.info())
+}
+
+// handleChatRequest sends a chat message as the bot
+func (b *Bot) handleChatRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+	if req.Message == "" || len(req.Message) > 256 {
+		writeError(w, http.StatusBadRequest, errors.New("message must be 1-256 characters"))
+		return
+	}
+
+	b.log.Printf("🌐 Sending chat message from API: %s", req.Message)
+	b.sendChatMessage(req.Message)
+	writeJSON(w, http.StatusOK, map[string]bool{"sent": true})
+}
+
+// handleTaskRequest returns the status of a task, or its result once over. With ?wait= it
+// holds the answer until the task is over or the wait ends.
+func (b *Bot) handleTaskRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("task id must be a number"))
+		return
+	}
+	var wait time.Duration
+	if q := r.URL.Query().Get("wait"); q != "" {
+		if wait, err = time.ParseDuration(q); err != nil || wait < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("wait must be a duration like 30s"))
+			return
+		}
+		wait = min(wait, taskWaitMax)
+	}
+
+	result, done, ok := b.tasks.status(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no task #%d", id))
+		return
+	}
+	if wait > 0 && result.Finished == nil {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-done:
+			if over, _, ok := b.tasks.status(id); ok {
+				result = over
+			}
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleCancelTaskRequest cancels the running task
+func (b *Bot) handleCancelTaskRequest(w http.ResponseWriter, r *http.Request) {
+	if !b.tasks.cancelCurrent() {
+		writeError(w, http.StatusNotFound, errors.New("no task is running"))
+		return
+	}
+	b.log.Println("🌐 Cancelled current task from API")
+	writeJSON(w, http.StatusOK, map[string]bool{"cancelled": true})
+}
+
+// handleClearTasksRequest cancels the current task and drops the queue
+func (b *Bot) handleClearTasksRequest(w http.ResponseWriter, r *http.Request) {
+	n := b.tasks.clear()
+	b.log.Printf("🌐 Cleared %d task(s) from API", n)
+	writeJSON(w, http.StatusOK, map[string]int{"cleared": n})
+}
+
+// writeJSON encodes v as the response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️ Failed to write HTTP response: %v", err)
+	}
+}
+
+// writeError responds with {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
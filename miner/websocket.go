@@ -0,0 +1,202 @@
+package miner
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is the fixed key suffix from RFC 6455
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// wsMaxControlPayload is the largest frame we accept from clients, they only send control frames
+const wsMaxControlPayload = 125
+
+// wsConn is a minimal server side WebSocket connection
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex // Serializes frame writes
+}
+
+// handleEventStream upgrades the request to a WebSocket and streams bot events as JSON text
+// frames. Browsers let any page open a WebSocket, so the pages of other sites are refused, and
+// with an API token only clients sending it are let in.
+func (b *Bot) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if err := checkOrigin(r); err != nil {
+		b.log.Printf("⚠️ Rejected event stream client %s: %v", r.RemoteAddr, err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	if b.cfg.APIToken != "" && !b.hasToken(r) {
+		b.log.Printf("⚠️ Rejected unauthenticated event stream client %s", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+		return
+	}
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer ws.conn.Close()
+
+	b.log.Printf("🔌 Event stream client connected from %s", r.RemoteAddr)
+	defer b.log.Printf("🔌 Event stream client %s disconnected", r.RemoteAddr)
+
+	events, unsubscribe := b.events.stream()
+	defer unsubscribe()
+	private := b.cfg.Privacy.Mode == privacyOff || b.cfg.APIToken != ""
+
+	// The client only talks to us to ping or close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.readControlFrames()
+	}()
+
+	for {
+		select {
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				b.log.Printf("⚠️ Failed to encode event: %v", err)
+				continue
+			}
+			if !private {
+				if data, err = b.cfg.Privacy.json(data); err != nil {
+					b.log.Printf("⚠️ Failed to hide the coordinates of an event: %v", err)
+					continue
+				}
+			}
+			if err := ws.writeFrame(wsOpText, data); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// upgradeWebSocket performs the RFC 6455 opening handshake
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("websocket upgrade required")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection cannot be upgraded")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// headerContains reports whether a comma separated header contains token
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame sends a single unmasked, unfragmented frame
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// readControlFrames answers pings and returns when the client closes or misbehaves
+func (c *wsConn) readControlFrames() {
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+			return
+		}
+		opcode := head[0] & 0x0F
+		masked := head[1]&0x80 != 0
+		length := int(head[1] & 0x7F)
+		if !masked || length > wsMaxControlPayload {
+			// Clients must mask their frames and we never expect large ones
+			c.writeFrame(wsOpClose, []byte{0x03, 0xEA}) // 1002 protocol error
+			return
+		}
+
+		var mask [4]byte
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return
+			}
+		case wsOpClose:
+			c.writeFrame(wsOpClose, payload)
+			return
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package miner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// overworld is the dimension players spawn in
+const overworld = "minecraft:overworld"
+
+// blockPos is the position of a block in the world
+type blockPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// worldStats summarizes the world model
+type worldStats struct {
+	Dimension    string `json:"dimension"`
+	LoadedChunks int    `json:"loaded_chunks"`
+	TimeOfDay    *int64 `json:"time_of_day,omitempty"` // Ticks since sunrise, unset until the server sends the time
+	Weather      string `json:"weather"`               // clear, rain or thunderstorm
+}
+
+// worldModel holds the chunks the server has sent us and keeps them up to date
+// with block updates. Unlike go-mc's world.World it is safe to read from tasks
+// while the packet loop writes to it.
+type worldModel struct {
+	mu        sync.RWMutex
+	columns   map[level.ChunkPos]*level.Chunk
+	minY      int
+	watchers  map[blockPos][]chan block.StateID
+	dimension string                     // Name of the dimension the columns belong to, like minecraft:the_nether
+	ultrawarm bool                       // Water evaporates and lava spreads fast, as in the nether
+	others    map[string]dimensionChunks // Chunks of the dimensions the bot left, by name
+	kept      map[level.ChunkPos]bool    // Explored chunks, kept when the server unloads them
+	keeping   bool                       // Whether chunks loading now are kept
+}
+
+// dimensionChunks are the chunks kept of a dimension the bot is not in
+type dimensionChunks struct {
+	columns map[level.ChunkPos]*level.Chunk
+	kept    map[level.ChunkPos]bool
+	minY    int
+}
+
+// newWorldModel creates an empty world
+func newWorldModel() *worldModel {
+	return &worldModel{
+		columns:  make(map[level.ChunkPos]*level.Chunk),
+		watchers: make(map[blockPos][]chan block.StateID),
+		others:   make(map[string]dimensionChunks),
+		kept:     make(map[level.ChunkPos]bool),
+	}
+}
+
+// registerWorldHandlers makes the world model follow chunk and block packets
+func (b *Bot) registerWorldHandlers() {
+	b.client.Events.AddListener(
+		// After go-mc's handlers, which read the dimension the packets name
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundLogin, F: b.onWorldReset},
+		bot.PacketHandler{Priority: -1, ID: packetid.ClientboundRespawn, F: b.onDimensionChange},
+		bot.PacketHandler{ID: packetid.ClientboundLevelChunkWithLight, F: b.onChunkLoad},
+		bot.PacketHandler{ID: packetid.ClientboundForgetLevelChunk, F: b.onChunkUnload},
+		bot.PacketHandler{ID: packetid.ClientboundBlockUpdate, F: b.onBlockUpdate},
+		bot.PacketHandler{ID: packetid.ClientboundSectionBlocksUpdate, F: b.onSectionBlocksUpdate},
+	)
+}
+
+// onWorldReset drops the chunks of every dimension when joining, possibly another server
+func (b *Bot) onWorldReset(pk.Packet) error {
+	b.world.mu.Lock()
+	b.world.columns = make(map[level.ChunkPos]*level.Chunk)
+	b.world.others = make(map[string]dimensionChunks)
+	b.world.kept = make(map[level.ChunkPos]bool)
+	b.world.dimension = ""
+	b.world.mu.Unlock()
+	return b.onDimensionChange(pk.Packet{})
+}
+
+// onDimensionChange switches the world model to the dimension the bot respawned in. The chunks
+// of the dimension it left are kept for when it comes back, those of the dimension it respawned
+// in again are dropped as the server sends them anew.
+func (b *Bot) onDimensionChange(pk.Packet) error {
+	name := b.player.DimensionName
+	dim := b.client.Registries.DimensionType.GetByID(b.player.DimensionType)
+
+	w := b.world
+	w.mu.Lock()
+	from := w.dimension
+	if from != name && from != "" {
+		w.others[from] = dimensionChunks{columns: w.columns, kept: w.kept, minY: w.minY}
+	}
+	w.columns = make(map[level.ChunkPos]*level.Chunk)
+	w.kept = make(map[level.ChunkPos]bool)
+	other, restored := w.others[name]
+	if restored && from != name {
+		w.columns, w.kept, w.minY = other.columns, other.kept, other.minY
+		delete(w.others, name)
+	}
+	w.dimension = name
+	if dim != nil {
+		w.minY, w.ultrawarm = int(dim.MinY), dim.Ultrawarm
+	}
+	w.mu.Unlock()
+
+	// A dimension entered for the first time since joining may have been seen in an earlier session
+	if from != name && !restored && name != "" && dim != nil && b.cfg.WorldCacheDir != "" {
+		go b.loadWorldCache(name, int(dim.MinY), int(dim.Height)/16)
+	}
+
+	if from != name && from != "" {
+		b.travel.resetTrail()
+		b.log.Printf("🌀 Changed dimension from %s to %s", from, name)
+		b.events.emit(eventDimensionChanged, map[string]string{"from": from, "to": name})
+	}
+	return nil
+}
+
+// currentDimension returns the name of the dimension the world model holds
+func (w *worldModel) currentDimension() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.dimension
+}
+
+// hot reports whether the current dimension is ultrawarm like the nether, where water
+// evaporates and lava flows far and fast
+func (w *worldModel) hot() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.ultrawarm
+}
+
+// onChunkLoad stores a chunk sent by the server
+func (b *Bot) onChunkLoad(p pk.Packet) error {
+	dim := b.client.Registries.DimensionType.GetByID(b.player.DimensionType)
+	if dim == nil {
+		return fmt.Errorf("dimension type %d not found", b.player.DimensionType)
+	}
+
+	var pos level.ChunkPos
+	chunk := level.EmptyChunk(int(dim.Height) / 16)
+	if err := p.Scan(&pos, chunk); err != nil {
+		return err
+	}
+
+	b.world.mu.Lock()
+	defer b.world.mu.Unlock()
+	b.world.columns[pos] = chunk
+	b.world.minY = int(dim.MinY)
+	if b.world.keeping {
+		b.world.kept[pos] = true
+	}
+	return nil
+}
+
+// onChunkUnload forgets a chunk the server stopped tracking, unless it was explored
+func (b *Bot) onChunkUnload(p pk.Packet) error {
+	var pos level.ChunkPos
+	if err := p.Scan(&pos); err != nil {
+		return err
+	}
+
+	b.world.mu.Lock()
+	defer b.world.mu.Unlock()
+	if !b.world.kept[pos] {
+		delete(b.world.columns, pos)
+	}
+	return nil
+}
+
+// onBlockUpdate applies a single block change
+func (b *Bot) onBlockUpdate(p pk.Packet) error {
+	var (
+		pos   pk.Position
+		state pk.VarInt
+	)
+	if err := p.Scan(&pos, &state); err != nil {
+		return err
+	}
+
+	at := blockPos{X: pos.X, Y: pos.Y, Z: pos.Z}
+	if before, ok := b.world.blockAt(at); ok {
+		b.logBlockChange(at, before, block.StateID(state))
+	}
+	b.world.setBlock(at, block.StateID(state))
+	return nil
+}
+
+// onSectionBlocksUpdate applies several block changes within one chunk section
+func (b *Bot) onSectionBlocksUpdate(p pk.Packet) error {
+	var (
+		section pk.Long
+		changes []pk.VarLong
+	)
+	if err := p.Scan(&section, pk.Array(&changes)); err != nil {
+		return err
+	}
+
+	// Section position: X (22 bits) << 42 | Z (22 bits) << 20 | Y (20 bits)
+	sx := int(int64(section) >> 42)
+	sy := int(int64(section) << 44 >> 44)
+	sz := int(int64(section) << 22 >> 42)
+	for _, c := range changes {
+		// Each change: state << 12 | X (4 bits) << 8 | Z (4 bits) << 4 | Y (4 bits)
+		pos := blockPos{
+			X: sx*16 + int(c>>8&0xF),
+			Y: sy*16 + int(c&0xF),
+			Z: sz*16 + int(c>>4&0xF),
+		}
+		if before, ok := b.world.blockAt(pos); ok {
+			b.logBlockChange(pos, before, block.StateID(c>>12))
+		}
+		b.world.setBlock(pos, block.StateID(c>>12))
+	}
+	return nil
+}
+
+// locate returns the section holding pos and the index of pos within it.
+// The caller must hold the lock.
+func (w *worldModel) locate(pos blockPos) (*level.Section, int, bool) {
+	chunk, ok := w.columns[level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)}]
+	if !ok {
+		return nil, 0, false
+	}
+	sec := (pos.Y - w.minY) >> 4
+	if sec < 0 || sec >= len(chunk.Sections) {
+		return nil, 0, false
+	}
+	return &chunk.Sections[sec], (pos.Y&15)<<8 | (pos.Z&15)<<4 | pos.X&15, true
+}
+
+// blockAt returns the block state at pos, ok is false when the chunk is not loaded
+func (w *worldModel) blockAt(pos blockPos) (state block.StateID, ok bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	s, i, ok := w.locate(pos)
+	if !ok {
+		return 0, false
+	}
+	return s.GetBlock(i), true
+}
+
+// setBlock changes the block at pos and wakes up anyone watching it
+func (w *worldModel) setBlock(pos blockPos, state block.StateID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if s, i, ok := w.locate(pos); ok {
+		s.SetBlock(i, state)
+	}
+	for _, ch := range w.watchers[pos] {
+		select {
+		case ch <- state:
+		default:
+		}
+	}
+}
+
+// watch returns a channel receiving every update of the block at pos until stop is called
+func (w *worldModel) watch(pos blockPos) (updates <-chan block.StateID, stop func()) {
+	ch := make(chan block.StateID, 4)
+
+	w.mu.Lock()
+	w.watchers[pos] = append(w.watchers[pos], ch)
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		list := w.watchers[pos]
+		for i, c := range list {
+			if c == ch {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(w.watchers, pos)
+		} else {
+			w.watchers[pos] = list
+		}
+	}
+}
+
+// loadedChunks returns the number of chunks in the model
+func (w *worldModel) loadedChunks() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.columns)
+}
+
+// blockName returns the namespaced name of a block state
+func blockName(state block.StateID) string {
+	if int(state) < 0 || int(state) >= len(block.StateList) {
+		return fmt.Sprintf("unknown:%d", state)
+	}
+	return block.StateList[state].ID()
+}
+
+// worldSnapshot returns statistics about the loaded world
+func (b *Bot) worldSnapshot() worldStats {
+	stats := worldStats{}
+	if b.player != nil {
+		stats.Dimension = b.player.DimensionName
+	}
+	if b.world != nil {
+		stats.LoadedChunks = b.world.loadedChunks()
+	}
+	if t, ok := b.timeOfDay(); ok {
+		stats.TimeOfDay = &t
+	}
+	stats.Weather = string(b.clock.Weather())
+	return stats
+}
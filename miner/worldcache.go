@@ -0,0 +1,231 @@
+package miner
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/level"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	worldCacheDefaultDir = "worldcache"
+	worldCacheMagic      = "MMWC"
+	worldCacheVersion    = 1
+	worldCacheInterval   = 10 * time.Minute // Chunks seen since the last save are lost at most in a crash
+)
+
+// unsafeFileChars are replaced in the server and dimension names of world cache files
+var unsafeFileChars = regexp.MustCompile(`[^\w.-]+`)
+
+// worldCachePath returns the file the chunks of a dimension of the bot's server are cached in
+func (b *Bot) worldCachePath(dimension string) string {
+	name := unsafeFileChars.ReplaceAllString(b.cfg.Server, "_") + "_" +
+		unsafeFileChars.ReplaceAllString(strings.TrimPrefix(dimension, "minecraft:"), "_") + ".chunks.gz"
+	return filepath.Join(b.cfg.WorldCacheDir, name)
+}
+
+// cachedChunk is a chunk as the world cache stores it: its sections in the format of the
+// chunk data packet
+type cachedChunk struct {
+	pos  level.ChunkPos
+	data []byte
+}
+
+// saveWorldCache writes the chunks of every dimension the world model has to their world
+// cache files
+func (b *Bot) saveWorldCache() error {
+	if b.cfg.WorldCacheDir == "" {
+		return nil
+	}
+	w := b.world
+	w.mu.RLock()
+	type dimensionCache struct {
+		name     string
+		minY     int
+		sections int
+		chunks   []cachedChunk
+	}
+	var dims []dimensionCache
+	add := func(name string, columns map[level.ChunkPos]*level.Chunk, minY int) error {
+		d := dimensionCache{name: name, minY: minY, chunks: make([]cachedChunk, 0, len(columns))}
+		for pos, chunk := range columns {
+			data, err := chunk.Data()
+			if err != nil {
+				return fmt.Errorf("failed to encode chunk %d, %d: %w", pos[0], pos[1], err)
+			}
+			d.sections = len(chunk.Sections)
+			d.chunks = append(d.chunks, cachedChunk{pos: pos, data: data})
+		}
+		if name != "" && len(d.chunks) > 0 {
+			dims = append(dims, d)
+		}
+		return nil
+	}
+	err := add(w.dimension, w.columns, w.minY)
+	for name, other := range w.others {
+		if err == nil {
+			err = add(name, other.columns, other.minY)
+		}
+	}
+	w.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range dims {
+		path := b.worldCachePath(d.name)
+		if err := writeWorldCache(path, d.minY, d.sections, d.chunks); err != nil {
+			return err
+		}
+		b.log.Printf("💾 Saved %d chunks of %s to %s", len(d.chunks), d.name, path)
+	}
+	return nil
+}
+
+// writeWorldCache writes chunks to a world cache file: a gzipped header of magic, version,
+// lowest height and sections per chunk, then the chunk count and every chunk position and
+// its section data
+func writeWorldCache(path string, minY, sections int, chunks []cachedChunk) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write next to the file and rename, so a crash never leaves half a file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	buf := bufio.NewWriter(tmp)
+	zw := gzip.NewWriter(buf)
+	_, err = pk.Tuple{
+		pk.String(worldCacheMagic),
+		pk.VarInt(worldCacheVersion),
+		pk.VarInt(minY),
+		pk.VarInt(sections),
+		pk.VarInt(len(chunks)),
+	}.WriteTo(zw)
+	for _, c := range chunks {
+		if err != nil {
+			break
+		}
+		_, err = pk.Tuple{c.pos, pk.ByteArray(c.data)}.WriteTo(zw)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readWorldCache reads the chunks of a world cache file made for a dimension with the given
+// lowest height and sections per chunk
+func readWorldCache(path string, minY, sections int) (map[level.ChunkPos]*level.Chunk, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var (
+		magic                   pk.String
+		ver, low, height, count pk.VarInt
+	)
+	if _, err := (pk.Tuple{&magic, &ver, &low, &height, &count}).ReadFrom(zr); err != nil {
+		return nil, err
+	}
+	switch {
+	case magic != worldCacheMagic:
+		return nil, errors.New("not a world cache file")
+	case ver != worldCacheVersion:
+		return nil, fmt.Errorf("world cache version %d, expected %d", ver, worldCacheVersion)
+	case int(low) != minY || int(height) != sections:
+		return nil, fmt.Errorf("the dimension changed height since the cache was saved, %d sections from y=%d instead of %d from y=%d", height, low, sections, minY)
+	}
+
+	columns := make(map[level.ChunkPos]*level.Chunk, max(int(count), 0))
+	for range int(count) {
+		var (
+			pos  level.ChunkPos
+			data pk.ByteArray
+		)
+		if _, err := (pk.Tuple{&pos, &data}).ReadFrom(zr); err != nil {
+			return nil, err
+		}
+		chunk := level.EmptyChunk(sections)
+		if err := chunk.PutData(data); err != nil {
+			return nil, fmt.Errorf("chunk %d, %d: %w", pos[0], pos[1], err)
+		}
+		columns[pos] = chunk
+	}
+	return columns, nil
+}
+
+// loadWorldCache adds the cached chunks of a dimension the bot entered to the world model,
+// as kept chunks. Chunks the server sent meanwhile are newer and stay.
+func (b *Bot) loadWorldCache(dimension string, minY, sections int) {
+	path := b.worldCachePath(dimension)
+	columns, err := readWorldCache(path, minY, sections)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		b.log.Printf("⚠️ Ignoring the world cache %s: %v", path, err)
+		return
+	}
+
+	w := b.world
+	w.mu.Lock()
+	if w.dimension != dimension {
+		w.mu.Unlock()
+		return
+	}
+	added := 0
+	for pos, chunk := range columns {
+		if _, ok := w.columns[pos]; !ok {
+			w.columns[pos] = chunk
+			added++
+		}
+		w.kept[pos] = true
+	}
+	w.mu.Unlock()
+	b.log.Printf("💾 Loaded %d cached chunks of %s from %s", added, dimension, path)
+}
+
+// saveWorldCacheEvery saves the world cache regularly until ctx is done
+func (b *Bot) saveWorldCacheEvery(ctx context.Context) {
+	ticker := time.NewTicker(worldCacheInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.saveWorldCache(); err != nil {
+				b.log.Printf("⚠️ Failed to save the world cache: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
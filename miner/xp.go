@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/entity"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	xpOrbEntity   = "experience_orb"
+	xpLookRadius  = 16.0             // Distance orbs are walked to, those within 8 blocks fly to the bot anyway
+	xpPickupRange = 1.5              // Distance at which an orb is surely taken in
+	xpCollectTime = 15 * time.Second // Longest detour for orbs before mining goes on
+)
+
+// experience is the level and points of the bot
+type experience struct {
+	Level    int32   `json:"level"`
+	Progress float32 `json:"progress"` // Towards the next level, 0 to 1
+	Total    int32   `json:"total"`
+}
+
+// registerExperienceHandlers follows the experience of the bot and the orbs around it
+func (b *Bot) registerExperienceHandlers() {
+	b.client.Events.AddListener(
+		bot.PacketHandler{ID: packetid.ClientboundSetExperience, F: b.onSetExperience},
+		bot.PacketHandler{ID: packetid.ClientboundAddExperienceOrb, F: b.onAddExperienceOrb},
+	)
+}
+
+// onSetExperience records the experience of the bot
+func (b *Bot) onSetExperience(p pk.Packet) error {
+	var (
+		progress     pk.Float
+		level, total pk.VarInt
+	)
+	if err := p.Scan(&progress, &level, &total); err != nil {
+		return err
+	}
+	b.stateMu.Lock()
+	before := b.xp.Level
+	b.xp = experience{Level: int32(level), Progress: float32(progress), Total: int32(total)}
+	b.stateMu.Unlock()
+	if int32(level) > before {
+		b.log.Printf("✨ Reached experience level %d", level)
+	}
+	return nil
+}
+
+// onAddExperienceOrb tracks an experience orb like any other entity
+func (b *Bot) onAddExperienceOrb(p pk.Packet) error {
+	var (
+		id      pk.VarInt
+		x, y, z pk.Double
+		count   pk.Short
+	)
+	if err := p.Scan(&id, &x, &y, &z, &count); err != nil {
+		return err
+	}
+	b.entities.mu.Lock()
+	defer b.entities.mu.Unlock()
+	b.entities.entities[int32(id)] = &trackedEntity{ID: int32(id), Type: int32(entity.ExperienceOrb.ID), X: float64(x), Y: float64(y), Z: float64(z)}
+	return nil
+}
+
+// mendingNeeded reports whether the mining tool has Mending and lost durability, which
+// experience orbs would repair
+func (b *Bot) mendingNeeded() bool {
+	if b.miningItem < 0 {
+		return false
+	}
+	d, ok := b.itemDetailsAt(int(b.miningItem))
+	return ok && d.Enchantments["mending"] > 0 && d.Damage > 0
+}
+
+// collectXP walks to the experience orbs around the bot while the mining tool needs mending,
+// for at most xpCollectTime
+func (b *Bot) collectXP(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, xpCollectTime)
+	defer cancel()
+	skipped := make(map[int32]bool)
+	announced := false
+	for b.mendingNeeded() {
+		x, y, z := b.currentPosition()
+		e, ok := b.entities.nearest(x, y, z, xpLookRadius, func(e trackedEntity) bool {
+			return entityName(e.Type) == xpOrbEntity && !skipped[e.ID]
+		})
+		if !ok {
+			return nil
+		}
+		if !announced {
+			b.log.Printf("✨ Collecting experience orbs to mend %s", toolLabel(b.heldTool()))
+			announced = true
+		}
+		goal := blockPos{X: int(math.Floor(e.X)), Y: int(math.Floor(e.Y)), Z: int(math.Floor(e.Z))}
+		err := b.walkPath(ctx, goal, xpPickupRange, b.mobAwareness(b.cfg.AvoidMobs))
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return nil // Back to mining, the orbs keep
+		case err != nil && ctx.Err() != nil:
+			return err
+		}
+		skipped[e.ID] = true
+	}
+	return nil
+}
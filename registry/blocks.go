@@ -0,0 +1,82 @@
+// The blocks the bot has needed so far, kept by hand in the layout tools/datagen writes.
+// Running the generator replaces them with every block of the release.
+
+package registry
+
+var blocks = map[string]Block{
+	"minecraft:amethyst_block":             {1.5, "pickaxe", TierWood},
+	"minecraft:ancient_debris":             {30, "pickaxe", TierDiamond},
+	"minecraft:andesite":                   {1.5, "pickaxe", TierWood},
+	"minecraft:basalt":                     {1.25, "pickaxe", TierWood},
+	"minecraft:bedrock":                    {-1, "", TierNone},
+	"minecraft:birch_log":                  {2, "axe", TierNone},
+	"minecraft:blackstone":                 {1.5, "pickaxe", TierWood},
+	"minecraft:budding_amethyst":           {1.5, "", TierNone},
+	"minecraft:calcite":                    {0.75, "pickaxe", TierWood},
+	"minecraft:chest":                      {2.5, "axe", TierNone},
+	"minecraft:clay":                       {0.6, "shovel", TierNone},
+	"minecraft:coal_ore":                   {3, "pickaxe", TierWood},
+	"minecraft:coarse_dirt":                {0.5, "shovel", TierNone},
+	"minecraft:cobbled_deepslate":          {3.5, "pickaxe", TierWood},
+	"minecraft:cobblestone":                {2, "pickaxe", TierWood},
+	"minecraft:cobweb":                     {4, "", TierNone},
+	"minecraft:copper_ore":                 {3, "pickaxe", TierStone},
+	"minecraft:cracked_stone_bricks":       {1.5, "pickaxe", TierWood},
+	"minecraft:crying_obsidian":            {50, "pickaxe", TierDiamond},
+	"minecraft:deepslate":                  {3, "pickaxe", TierWood},
+	"minecraft:deepslate_bricks":           {3.5, "pickaxe", TierWood},
+	"minecraft:deepslate_coal_ore":         {4.5, "pickaxe", TierWood},
+	"minecraft:deepslate_copper_ore":       {4.5, "pickaxe", TierStone},
+	"minecraft:deepslate_diamond_ore":      {4.5, "pickaxe", TierIron},
+	"minecraft:deepslate_emerald_ore":      {4.5, "pickaxe", TierIron},
+	"minecraft:deepslate_gold_ore":         {4.5, "pickaxe", TierIron},
+	"minecraft:deepslate_iron_ore":         {4.5, "pickaxe", TierStone},
+	"minecraft:deepslate_lapis_ore":        {4.5, "pickaxe", TierStone},
+	"minecraft:deepslate_redstone_ore":     {4.5, "pickaxe", TierIron},
+	"minecraft:deepslate_tiles":            {3.5, "pickaxe", TierWood},
+	"minecraft:diamond_ore":                {3, "pickaxe", TierIron},
+	"minecraft:diorite":                    {1.5, "pickaxe", TierWood},
+	"minecraft:dirt":                       {0.5, "shovel", TierNone},
+	"minecraft:dripstone_block":            {1.5, "pickaxe", TierWood},
+	"minecraft:emerald_ore":                {3, "pickaxe", TierIron},
+	"minecraft:end_portal_frame":           {-1, "", TierNone},
+	"minecraft:end_stone":                  {3, "pickaxe", TierWood},
+	"minecraft:glowstone":                  {0.3, "", TierNone},
+	"minecraft:gold_ore":                   {3, "pickaxe", TierIron},
+	"minecraft:granite":                    {1.5, "pickaxe", TierWood},
+	"minecraft:grass_block":                {0.6, "shovel", TierNone},
+	"minecraft:gravel":                     {0.6, "shovel", TierNone},
+	"minecraft:infested_deepslate":         {1.5, "", TierNone},
+	"minecraft:infested_stone":             {0.75, "", TierNone},
+	"minecraft:iron_ore":                   {3, "pickaxe", TierStone},
+	"minecraft:lapis_ore":                  {3, "pickaxe", TierStone},
+	"minecraft:magma_block":                {0.5, "pickaxe", TierWood},
+	"minecraft:mossy_cobblestone":          {2, "pickaxe", TierWood},
+	"minecraft:mossy_stone_bricks":         {1.5, "pickaxe", TierWood},
+	"minecraft:nether_gold_ore":            {3, "pickaxe", TierWood},
+	"minecraft:nether_quartz_ore":          {3, "pickaxe", TierWood},
+	"minecraft:netherrack":                 {0.4, "pickaxe", TierWood},
+	"minecraft:oak_fence":                  {2, "axe", TierNone},
+	"minecraft:oak_log":                    {2, "axe", TierNone},
+	"minecraft:oak_planks":                 {2, "axe", TierNone},
+	"minecraft:obsidian":                   {50, "pickaxe", TierDiamond},
+	"minecraft:polished_blackstone_bricks": {1.5, "pickaxe", TierWood},
+	"minecraft:polished_deepslate":         {3.5, "pickaxe", TierWood},
+	"minecraft:rail":                       {0.7, "pickaxe", TierNone},
+	"minecraft:raw_iron_block":             {5, "pickaxe", TierStone},
+	"minecraft:red_sand":                   {0.5, "shovel", TierNone},
+	"minecraft:redstone_ore":               {3, "pickaxe", TierIron},
+	"minecraft:reinforced_deepslate":       {55, "", TierNone},
+	"minecraft:sand":                       {0.5, "shovel", TierNone},
+	"minecraft:sandstone":                  {0.8, "pickaxe", TierWood},
+	"minecraft:smooth_basalt":              {1.25, "pickaxe", TierWood},
+	"minecraft:soul_sand":                  {0.5, "shovel", TierNone},
+	"minecraft:soul_soil":                  {0.5, "shovel", TierNone},
+	"minecraft:spawner":                    {5, "pickaxe", TierWood},
+	"minecraft:spruce_log":                 {2, "axe", TierNone},
+	"minecraft:stone":                      {1.5, "pickaxe", TierWood},
+	"minecraft:stone_bricks":               {1.5, "pickaxe", TierWood},
+	"minecraft:torch":                      {0, "", TierNone},
+	"minecraft:tuff":                       {1.5, "pickaxe", TierWood},
+	"minecraft:wall_torch":                 {0, "", TierNone},
+}
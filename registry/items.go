@@ -0,0 +1,62 @@
+// The tools and armor the bot has needed so far, kept by hand in the layout tools/datagen
+// writes. Running the generator replaces them with every item of the release.
+
+package registry
+
+var items = map[string]Item{
+	"minecraft:chainmail_boots":      {1, 195},
+	"minecraft:chainmail_chestplate": {1, 240},
+	"minecraft:chainmail_helmet":     {1, 165},
+	"minecraft:chainmail_leggings":   {1, 225},
+	"minecraft:diamond_axe":          {1, 1561},
+	"minecraft:diamond_boots":        {1, 429},
+	"minecraft:diamond_chestplate":   {1, 528},
+	"minecraft:diamond_helmet":       {1, 363},
+	"minecraft:diamond_hoe":          {1, 1561},
+	"minecraft:diamond_leggings":     {1, 495},
+	"minecraft:diamond_pickaxe":      {1, 1561},
+	"minecraft:diamond_shovel":       {1, 1561},
+	"minecraft:diamond_sword":        {1, 1561},
+	"minecraft:golden_axe":           {1, 32},
+	"minecraft:golden_boots":         {1, 91},
+	"minecraft:golden_chestplate":    {1, 112},
+	"minecraft:golden_helmet":        {1, 77},
+	"minecraft:golden_hoe":           {1, 32},
+	"minecraft:golden_leggings":      {1, 105},
+	"minecraft:golden_pickaxe":       {1, 32},
+	"minecraft:golden_shovel":        {1, 32},
+	"minecraft:golden_sword":         {1, 32},
+	"minecraft:iron_axe":             {1, 250},
+	"minecraft:iron_boots":           {1, 195},
+	"minecraft:iron_chestplate":      {1, 240},
+	"minecraft:iron_helmet":          {1, 165},
+	"minecraft:iron_hoe":             {1, 250},
+	"minecraft:iron_leggings":        {1, 225},
+	"minecraft:iron_pickaxe":         {1, 250},
+	"minecraft:iron_shovel":          {1, 250},
+	"minecraft:iron_sword":           {1, 250},
+	"minecraft:leather_boots":        {1, 65},
+	"minecraft:leather_chestplate":   {1, 80},
+	"minecraft:leather_helmet":       {1, 55},
+	"minecraft:leather_leggings":     {1, 75},
+	"minecraft:netherite_axe":        {1, 2031},
+	"minecraft:netherite_boots":      {1, 481},
+	"minecraft:netherite_chestplate": {1, 592},
+	"minecraft:netherite_helmet":     {1, 407},
+	"minecraft:netherite_hoe":        {1, 2031},
+	"minecraft:netherite_leggings":   {1, 555},
+	"minecraft:netherite_pickaxe":    {1, 2031},
+	"minecraft:netherite_shovel":     {1, 2031},
+	"minecraft:netherite_sword":      {1, 2031},
+	"minecraft:stone_axe":            {1, 131},
+	"minecraft:stone_hoe":            {1, 131},
+	"minecraft:stone_pickaxe":        {1, 131},
+	"minecraft:stone_shovel":         {1, 131},
+	"minecraft:stone_sword":          {1, 131},
+	"minecraft:turtle_helmet":        {1, 275},
+	"minecraft:wooden_axe":           {1, 59},
+	"minecraft:wooden_hoe":           {1, 59},
+	"minecraft:wooden_pickaxe":       {1, 59},
+	"minecraft:wooden_shovel":        {1, 59},
+	"minecraft:wooden_sword":         {1, 59},
+}
@@ -0,0 +1,43 @@
+// Package registry holds the block, item and protocol data of the Minecraft release the bot
+// is built for. The bundled tables are a subset kept by hand; to support a new release, or to
+// fill in every block and item, run
+//
+//	go run ./tools/datagen -version <release>
+//
+// instead of editing them.
+package registry
+
+// Tool harvest tiers, matching the vanilla tool materials
+const (
+	TierNone = iota - 1 // Block drops without the right tool
+	TierWood            // Wooden and golden tools
+	TierStone
+	TierIron
+	TierDiamond
+	TierNetherite
+)
+
+// Block is what the break progress formula needs to know about a block
+type Block struct {
+	Hardness float32 // Negative means unbreakable
+	Tool     string  // pickaxe, shovel, axe, hoe or empty when no tool is faster
+	Tier     int     // Minimum tool tier to harvest, TierNone when any tool (or a hand) works
+}
+
+// Item is what the bot needs to know about an item
+type Item struct {
+	StackSize int
+	MaxDamage int // Durability, 0 for items that don't wear out
+}
+
+// BlockInfo returns the data of a block like "minecraft:stone"
+func BlockInfo(name string) (Block, bool) {
+	b, ok := blocks[name]
+	return b, ok
+}
+
+// ItemInfo returns the data of an item like "minecraft:iron_pickaxe"
+func ItemInfo(name string) (Item, bool) {
+	it, ok := items[name]
+	return it, ok
+}
@@ -0,0 +1,10 @@
+// Kept by hand in the layout tools/datagen writes, which replaces it with the release it
+// generates the tables for.
+
+package registry
+
+// Release the tables were generated for
+const (
+	Version  = "1.21.4"
+	Protocol = 768
+)
@@ -0,0 +1,279 @@
+// Package schematic reads the blocks of the structures saved by WorldEdit and Sponge
+// (.schem) and by Litematica (.litematic), gzipped NBT files both.
+package schematic
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// airBlocks are left out of a schematic, they take no building
+var airBlocks = map[string]bool{
+	"minecraft:air":            true,
+	"minecraft:cave_air":       true,
+	"minecraft:void_air":       true,
+	"minecraft:structure_void": true,
+}
+
+// Block is a block of a schematic, at X, Y and Z from its lowest corner
+type Block struct {
+	X, Y, Z int
+	Name    string // Block name like "minecraft:oak_stairs"
+	State   string // Properties like "facing=north,half=bottom", empty for none
+}
+
+// Schematic is the size of a structure and the blocks it is made of, air left out, from the
+// bottom layer up
+type Schematic struct {
+	Width, Height, Length int // Size along X, Y and Z
+	Blocks                []Block
+}
+
+// Materials counts the blocks of each name the schematic needs
+func (s *Schematic) Materials() map[string]int {
+	counts := make(map[string]int)
+	for _, b := range s.Blocks {
+		counts[b.Name]++
+	}
+	return counts
+}
+
+// Load reads a .schem or .litematic file
+func Load(path string) (*Schematic, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := Read(f, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return s, nil
+}
+
+// Read reads a schematic in the format of the file extension ext, ".schem" or ".litematic"
+func Read(r io.Reader, ext string) (*Schematic, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var s *Schematic
+	switch strings.ToLower(ext) {
+	case ".schem":
+		var f spongeFile
+		if _, err := nbt.NewDecoder(zr).Decode(&f); err != nil {
+			return nil, err
+		}
+		s, err = f.schematic()
+	case ".litematic":
+		var f litematicFile
+		if _, err := nbt.NewDecoder(zr).Decode(&f); err != nil {
+			return nil, err
+		}
+		s, err = f.schematic()
+	default:
+		return nil, fmt.Errorf("unknown schematic format %q, expected .schem or .litematic", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(s.Blocks, func(i, j int) bool { return s.Blocks[i].Y < s.Blocks[j].Y })
+	return s, nil
+}
+
+// splitState splits a block state like "minecraft:oak_stairs[facing=north]" into its name
+// and properties
+func splitState(state string) (name, props string) {
+	name, props, _ = strings.Cut(state, "[")
+	if !strings.Contains(name, ":") {
+		name = "minecraft:" + name
+	}
+	return name, strings.TrimSuffix(props, "]")
+}
+
+// spongeFile is a Sponge schematic. Version 2 keeps the palette and block data at the root,
+// version 3 in Blocks under a Schematic compound.
+type spongeFile struct {
+	Version   int32
+	Width     uint16
+	Height    uint16
+	Length    uint16
+	Palette   map[string]int32
+	BlockData []byte
+	Blocks    *spongeBlocks
+	Schematic *spongeFile
+}
+
+// spongeBlocks is the block container of a version 3 Sponge schematic
+type spongeBlocks struct {
+	Palette map[string]int32
+	Data    []byte
+}
+
+// schematic decodes the blocks of a Sponge schematic, stored as varint palette indexes with
+// X changing fastest, then Z, then Y
+func (f *spongeFile) schematic() (*Schematic, error) {
+	if f.Schematic != nil {
+		return f.Schematic.schematic()
+	}
+	palette, data := f.Palette, f.BlockData
+	if f.Blocks != nil {
+		palette, data = f.Blocks.Palette, f.Blocks.Data
+	}
+	if len(palette) == 0 {
+		return nil, errors.New("schematic has no block palette")
+	}
+	names := make(map[int32]string, len(palette))
+	for state, id := range palette {
+		names[id] = state
+	}
+
+	s := &Schematic{Width: int(f.Width), Height: int(f.Height), Length: int(f.Length)}
+	volume := s.Width * s.Height * s.Length
+	for i := 0; i < volume; i++ {
+		var id, shift int32
+		for {
+			if len(data) == 0 {
+				return nil, fmt.Errorf("block data ends after %d of %d blocks", i, volume)
+			}
+			c := data[0]
+			data = data[1:]
+			id |= int32(c&0x7f) << shift
+			if c&0x80 == 0 {
+				break
+			}
+			if shift += 7; shift > 28 {
+				return nil, errors.New("bad varint in block data")
+			}
+		}
+		state, ok := names[id]
+		if !ok {
+			return nil, fmt.Errorf("block %d has palette index %d, which isn't in the palette", i, id)
+		}
+		name, props := splitState(state)
+		if airBlocks[name] {
+			continue
+		}
+		x, z, y := i%s.Width, i/s.Width%s.Length, i/(s.Width*s.Length)
+		s.Blocks = append(s.Blocks, Block{X: x, Y: y, Z: z, Name: name, State: props})
+	}
+	return s, nil
+}
+
+// litematicFile is a Litematica schematic, made of regions
+type litematicFile struct {
+	Version int32
+	Regions map[string]litematicRegion
+}
+
+// litematicRegion is a box of blocks placed relative to the schematic. Size is negative along
+// the axes the box extends from Position towards lower coordinates.
+type litematicRegion struct {
+	Position          litematicVec
+	Size              litematicVec
+	BlockStatePalette []litematicState
+	BlockStates       []int64
+}
+
+type litematicVec struct {
+	X int32 `nbt:"x"`
+	Y int32 `nbt:"y"`
+	Z int32 `nbt:"z"`
+}
+
+type litematicState struct {
+	Name       string
+	Properties map[string]string
+}
+
+// String writes the properties of a state like a Sponge palette does
+func (s litematicState) String() string {
+	props := make([]string, 0, len(s.Properties))
+	for k, v := range s.Properties {
+		props = append(props, k+"="+v)
+	}
+	sort.Strings(props)
+	return strings.Join(props, ",")
+}
+
+// span returns the lowest coordinate of a region along an axis and its size
+func span(pos, size int32) (low, n int) {
+	if size < 0 {
+		return int(pos + size + 1), int(-size)
+	}
+	return int(pos), int(size)
+}
+
+// schematic decodes the blocks of every region, moving them so the lowest corner of all the
+// regions is at 0, 0, 0
+func (f *litematicFile) schematic() (*Schematic, error) {
+	if len(f.Regions) == 0 {
+		return nil, errors.New("schematic has no regions")
+	}
+	var blocks []Block
+	minX, minY, minZ, maxX, maxY, maxZ := 0, 0, 0, 0, 0, 0
+	first := true
+	for _, name := range slices.Sorted(maps.Keys(f.Regions)) {
+		r := f.Regions[name]
+		x0, sx := span(r.Position.X, r.Size.X)
+		y0, sy := span(r.Position.Y, r.Size.Y)
+		z0, sz := span(r.Position.Z, r.Size.Z)
+		if first {
+			minX, minY, minZ, maxX, maxY, maxZ = x0, y0, z0, x0+sx, y0+sy, z0+sz
+			first = false
+		}
+		minX, minY, minZ = min(minX, x0), min(minY, y0), min(minZ, z0)
+		maxX, maxY, maxZ = max(maxX, x0+sx), max(maxY, y0+sy), max(maxZ, z0+sz)
+
+		if len(r.BlockStatePalette) == 0 {
+			return nil, fmt.Errorf("region %q has no block palette", name)
+		}
+		// Palette indexes are packed tightly, an index may start in one long and end in the next
+		width := max(bits.Len(uint(len(r.BlockStatePalette)-1)), 2)
+		mask := uint64(1)<<width - 1
+		volume := sx * sy * sz
+		if need := (volume*width + 63) / 64; len(r.BlockStates) < need {
+			return nil, fmt.Errorf("region %q has %d longs of block states, it needs %d", name, len(r.BlockStates), need)
+		}
+		for i := 0; i < volume; i++ {
+			bit := i * width
+			start, end, offset := bit/64, (bit+width-1)/64, bit%64
+			v := uint64(r.BlockStates[start]) >> offset
+			if end != start {
+				v |= uint64(r.BlockStates[end]) << (64 - offset)
+			}
+			v &= mask
+			if int(v) >= len(r.BlockStatePalette) {
+				return nil, fmt.Errorf("region %q has palette index %d, which isn't in the palette", name, v)
+			}
+			state := r.BlockStatePalette[v]
+			blockName, _ := splitState(state.Name)
+			if airBlocks[blockName] {
+				continue
+			}
+			x, z, y := i%sx, i/sx%sz, i/(sx*sz)
+			blocks = append(blocks, Block{X: x0 + x, Y: y0 + y, Z: z0 + z, Name: blockName, State: state.String()})
+		}
+	}
+
+	for i := range blocks {
+		blocks[i].X -= minX
+		blocks[i].Y -= minY
+		blocks[i].Z -= minZ
+	}
+	return &Schematic{Width: maxX - minX, Height: maxY - minY, Length: maxZ - minZ, Blocks: blocks}, nil
+}
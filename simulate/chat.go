@@ -0,0 +1,44 @@
+package simulate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ChatLine is a line of a chat script: a player saying something after a wait
+type ChatLine struct {
+	Wait   time.Duration // Since the line before, or since the first player joined
+	Player string        // Needn't be online
+	Text   string
+}
+
+// ParseChat reads a chat script, a line per chat line of the wait, the player and the text:
+//
+//	# Mine a little and check on the bot
+//	5s Steve !goto 12 64 5
+//	1m Steve !status
+//
+// Empty lines and lines starting with # are skipped.
+func ParseChat(r io.Reader) ([]ChatLine, error) {
+	var lines []ChatLine
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, " ", 3)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected a wait, a player and the text", n)
+		}
+		wait, err := time.ParseDuration(fields[0])
+		if err != nil || wait < 0 {
+			return nil, fmt.Errorf("line %d: %q is not a wait like 5s or 1m", n, fields[0])
+		}
+		lines = append(lines, ChatLine{Wait: wait, Player: fields[1], Text: strings.TrimSpace(fields[2])})
+	}
+	return lines, sc.Err()
+}
@@ -0,0 +1,106 @@
+package simulate
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"time"
+)
+
+// delayed is data on its way through a latencyConn
+type delayed struct {
+	at   time.Time // When it arrives
+	data []byte
+}
+
+// latencyConn delays the data a connection reads and writes, like a slow network would
+type latencyConn struct {
+	net.Conn
+	delay time.Duration
+
+	in      chan delayed
+	readErr error  // Why reading stopped, set before in is closed
+	pending []byte // Arrived data Read hasn't returned yet
+
+	out       chan delayed
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newLatencyConn delays the data of conn by delay each way
+func newLatencyConn(conn net.Conn, delay time.Duration) net.Conn {
+	c := &latencyConn{
+		Conn:  conn,
+		delay: delay,
+		in:    make(chan delayed, 256),
+		out:   make(chan delayed, 256),
+		done:  make(chan struct{}),
+	}
+	go c.readLoop()
+	go c.writeLoop()
+	return c
+}
+
+func (c *latencyConn) readLoop() {
+	defer close(c.in)
+	for {
+		buf := make([]byte, 32<<10)
+		n, err := c.Conn.Read(buf)
+		if n > 0 {
+			select {
+			case c.in <- delayed{at: time.Now().Add(c.delay), data: buf[:n]}:
+			case <-c.done:
+				c.readErr = net.ErrClosed
+				return
+			}
+		}
+		if err != nil {
+			c.readErr = err
+			return
+		}
+	}
+}
+
+func (c *latencyConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		d, ok := <-c.in
+		if !ok {
+			return 0, c.readErr
+		}
+		time.Sleep(time.Until(d.at))
+		c.pending = d.data
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *latencyConn) writeLoop() {
+	for {
+		select {
+		case d := <-c.out:
+			time.Sleep(time.Until(d.at))
+			if _, err := c.Conn.Write(d.data); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Write queues the data, a failure to send it closes the connection later
+func (c *latencyConn) Write(p []byte) (int, error) {
+	select {
+	case c.out <- delayed{at: time.Now().Add(c.delay), data: bytes.Clone(p)}:
+		return len(p), nil
+	case <-c.done:
+		return 0, net.ErrClosed
+	}
+}
+
+func (c *latencyConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,462 @@
+package simulate
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	viewDistance  = 4  // Chunks sent around a player in every direction
+	inventorySize = 46 // Slots of the player inventory window
+	hotbarStart   = 36 // Window slot of the first hotbar slot
+	stackSize     = 64
+
+	digStart  = 0 // PlayerAction statuses
+	digFinish = 2
+	dropStack = 3
+	dropItem  = 4
+)
+
+// kit is what players join with, by window slot
+var kit = map[int]stack{
+	hotbarStart:     {"minecraft:diamond_pickaxe", 1},
+	hotbarStart + 1: {"minecraft:diamond_shovel", 1},
+	hotbarStart + 2: {"minecraft:diamond_axe", 1},
+	hotbarStart + 3: {"minecraft:bread", 16},
+	hotbarStart + 8: {"minecraft:cobblestone", stackSize},
+}
+
+// faces are the offsets of the block faces of a PlayerAction or UseItemOn
+var faces = [6][3]int{{0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}, {-1, 0, 0}, {1, 0, 0}}
+
+// stack is an inventory slot
+type stack struct {
+	item  string
+	count int
+}
+
+func (st stack) WriteTo(w io.Writer) (int64, error) {
+	if st.count <= 0 {
+		return pk.VarInt(0).WriteTo(w)
+	}
+	id, _ := itemOf(st.item)
+	return pk.Tuple{
+		pk.VarInt(st.count),
+		pk.VarInt(id),
+		pk.VarInt(0), pk.VarInt(0), // No components added or removed
+	}.WriteTo(w)
+}
+
+// player is a connection in play
+type player struct {
+	s    *Server
+	conn *mcnet.Conn
+	name string
+	uuid pk.UUID
+	eid  int32
+
+	writeMu sync.Mutex // Guards writing to conn
+
+	mu        sync.Mutex // Guards the fields below
+	x, y, z   float64
+	center    level.ChunkPos
+	loaded    map[level.ChunkPos]bool
+	inventory [inventorySize]stack
+	held      int // Selected hotbar slot, 0-8
+	stateID   int32
+	teleport  int32
+}
+
+func newPlayer(s *Server, conn *mcnet.Conn, name string, uuid pk.UUID, eid int32) *player {
+	p := &player{s: s, conn: conn, name: name, uuid: uuid, eid: eid, x: 0.5, y: SurfaceY, z: 0.5, loaded: make(map[level.ChunkPos]bool)}
+	for slot, st := range kit {
+		p.inventory[slot] = st
+	}
+	return p
+}
+
+// write sends a packet to the player
+func (p *player) write(packet pk.Packet) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return p.conn.WritePacket(packet)
+}
+
+// play runs the player in game until the connection ends
+func (p *player) play() error {
+	if err := p.join(); err != nil {
+		return err
+	}
+	p.s.script.Do(func() { go p.s.playScript() })
+
+	done := make(chan struct{})
+	defer close(done)
+	go p.tick(done)
+	for {
+		var packet pk.Packet
+		if err := p.conn.ReadPacket(&packet); err != nil {
+			return err
+		}
+		if err := p.handle(packet); err != nil {
+			return fmt.Errorf("packet 0x%02x: %w", packet.ID, err)
+		}
+	}
+}
+
+// join sends what a player gets when entering the game: the world, the inventory, the
+// chunks around the spawn and the position
+func (p *player) join() error {
+	err := p.write(pk.Marshal(
+		packetid.ClientboundLogin,
+		pk.Int(p.eid),
+		pk.Boolean(false), // Hardcore
+		pk.Array([]pk.Identifier{dimension}),
+		pk.VarInt(maxPlayers),
+		pk.VarInt(viewDistance),
+		pk.VarInt(viewDistance), // Simulation distance
+		pk.Boolean(false),       // Reduced debug info
+		pk.Boolean(true),        // Respawn screen
+		pk.Boolean(false),       // Limited crafting
+		pk.VarInt(0),            // Dimension type
+		pk.Identifier(dimension),
+		pk.Long(0),         // Hashed seed
+		pk.UnsignedByte(0), // Survival
+		pk.Byte(-1),        // No previous game mode
+		pk.Boolean(false),  // Debug world
+		pk.Boolean(true),   // Flat world
+		pk.Boolean(false),  // No death location
+		pk.VarInt(0),       // Portal cooldown
+		pk.VarInt(SurfaceY-1),
+		pk.Boolean(false), // Secure chat enforced
+	))
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.stateID++
+	slots := make([]pk.FieldEncoder, inventorySize)
+	for i, st := range p.inventory {
+		slots[i] = st
+	}
+	content := pk.Marshal(packetid.ClientboundContainerSetContent, pk.VarInt(0), pk.VarInt(p.stateID), pk.Array(slots), stack{})
+	p.mu.Unlock()
+	if err := p.write(content); err != nil {
+		return err
+	}
+	if err := p.moveTo(p.x, p.y, p.z, true); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.teleport++
+	teleport := pk.Marshal(packetid.ClientboundPlayerPosition,
+		pk.Double(p.x), pk.Double(p.y), pk.Double(p.z),
+		pk.Float(0), pk.Float(0),
+		pk.Byte(0), // Absolute
+		pk.VarInt(p.teleport),
+	)
+	p.mu.Unlock()
+	for _, packet := range []pk.Packet{
+		teleport,
+		pk.Marshal(packetid.ClientboundSetHealth, pk.Float(20), pk.VarInt(20), pk.Float(5)),
+		p.timePacket(),
+	} {
+		if err := p.write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timePacket returns the time update of the server
+func (p *player) timePacket() pk.Packet {
+	t := p.s.dayTime()
+	return pk.Marshal(packetid.ClientboundSetTime, pk.Long(t), pk.Long(t), pk.Boolean(true))
+}
+
+// tick sends keep-alives and the time until done is closed
+func (p *player) tick(done <-chan struct{}) {
+	keepAlive := time.NewTicker(keepAliveEvery)
+	defer keepAlive.Stop()
+	clock := time.NewTicker(timeEvery)
+	defer clock.Stop()
+	for {
+		select {
+		case <-keepAlive.C:
+			p.write(pk.Marshal(packetid.ClientboundKeepAlive, pk.Long(time.Now().UnixMilli())))
+		case <-clock.C:
+			p.write(p.timePacket())
+		case <-done:
+			return
+		}
+	}
+}
+
+// handle reacts to a packet of the player. Packets the simulation has no use for are ignored.
+func (p *player) handle(packet pk.Packet) error {
+	switch packetid.ServerboundPacketID(packet.ID) {
+	case packetid.ServerboundMovePlayerPos, packetid.ServerboundMovePlayerPosRot:
+		var x, y, z pk.Double
+		if err := packet.Scan(&x, &y, &z); err != nil {
+			return err
+		}
+		return p.moveTo(float64(x), float64(y), float64(z), false)
+
+	case packetid.ServerboundPlayerAction:
+		var (
+			status, seq pk.VarInt
+			pos         pk.Position
+			face        pk.Byte
+		)
+		if err := packet.Scan(&status, &pos, &face, &seq); err != nil {
+			return err
+		}
+		return p.action(int(status), pos, int32(seq))
+
+	case packetid.ServerboundUseItemOn:
+		var (
+			hand, face, seq    pk.VarInt
+			pos                pk.Position
+			cx, cy, cz         pk.Float
+			inside, borderHits pk.Boolean
+		)
+		if err := packet.Scan(&hand, &pos, &face, &cx, &cy, &cz, &inside, &borderHits, &seq); err != nil {
+			return err
+		}
+		return p.place(pos, int(face), int32(seq))
+
+	case packetid.ServerboundSetCarriedItem:
+		var slot pk.Short
+		if err := packet.Scan(&slot); err != nil {
+			return err
+		}
+		if slot >= 0 && slot < 9 {
+			p.mu.Lock()
+			p.held = int(slot)
+			p.mu.Unlock()
+		}
+
+	case packetid.ServerboundChat:
+		var msg pk.String
+		if err := packet.Scan(&msg); err != nil {
+			return err
+		}
+		p.s.Chat(p.name, string(msg))
+
+	case packetid.ServerboundChatCommand:
+		var command pk.String
+		if err := packet.Scan(&command); err != nil {
+			return err
+		}
+		p.s.log.Printf("🧪 %s ran /%s", p.name, command)
+		return p.write(systemChat("Unknown or incomplete command, see below for error"))
+	}
+	return nil
+}
+
+// moveTo moves the player, sending the chunks that come into view and unloading those out
+// of it when the player changes chunks
+func (p *player) moveTo(x, y, z float64, force bool) error {
+	p.mu.Lock()
+	p.x, p.y, p.z = x, y, z
+	center := level.ChunkPos{int32(math.Floor(x)) >> 4, int32(math.Floor(z)) >> 4}
+	if center == p.center && !force {
+		p.mu.Unlock()
+		return nil
+	}
+	p.center = center
+	var load, unload []level.ChunkPos
+	for pos := range p.loaded {
+		if chunkDistance(pos, center) > viewDistance+1 {
+			unload = append(unload, pos)
+			delete(p.loaded, pos)
+		}
+	}
+	for dx := int32(-viewDistance); dx <= viewDistance; dx++ {
+		for dz := int32(-viewDistance); dz <= viewDistance; dz++ {
+			pos := level.ChunkPos{center[0] + dx, center[1] + dz}
+			if !p.loaded[pos] {
+				load = append(load, pos)
+				p.loaded[pos] = true
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	// The chunks nearest the player first
+	slices.SortFunc(load, func(a, b level.ChunkPos) int {
+		return cmp.Compare(chunkDistance(a, center), chunkDistance(b, center))
+	})
+	if err := p.write(pk.Marshal(packetid.ClientboundSetChunkCacheCenter, pk.VarInt(center[0]), pk.VarInt(center[1]))); err != nil {
+		return err
+	}
+	for _, pos := range unload {
+		if err := p.write(pk.Marshal(packetid.ClientboundForgetLevelChunk, pos)); err != nil {
+			return err
+		}
+	}
+	for _, pos := range load {
+		packet, err := p.s.world.chunkPacket(pos)
+		if err != nil {
+			return err
+		}
+		if err := p.write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkDistance returns how many chunks apart two chunks are, the larger of the axes
+func chunkDistance(a, b level.ChunkPos) int32 {
+	return max(abs(a[0]-b[0]), abs(a[1]-b[1]))
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// action handles digging and dropping. Blocks break as soon as the player starts on those that
+// break instantly and when it finishes on the others, without checking the time it took.
+func (p *player) action(status int, pos pk.Position, seq int32) error {
+	switch status {
+	case digStart, digFinish:
+		name := nameOf(p.s.world.block(pos.X, pos.Y, pos.Z))
+		h := hardness(name)
+		switch {
+		case h < 0 && status == digFinish:
+			p.s.log.Printf("🧪 %s can't break %s at (%d, %d, %d)", p.name, name, pos.X, pos.Y, pos.Z)
+			if err := p.blockUpdate(pos); err != nil {
+				return err
+			}
+		case h == 0 || status == digFinish && h > 0:
+			p.breakBlock(pos, name)
+		}
+	case dropStack, dropItem:
+		p.mu.Lock()
+		slot := hotbarStart + p.held
+		if status == dropStack {
+			p.inventory[slot].count = 0
+		} else {
+			p.inventory[slot].count--
+		}
+		p.mu.Unlock()
+		if err := p.slotUpdate(slot); err != nil {
+			return err
+		}
+	}
+	return p.write(pk.Marshal(packetid.ClientboundBlockChangedAck, pk.VarInt(seq)))
+}
+
+// breakBlock breaks a block for everyone and puts its drop into the player's inventory
+func (p *player) breakBlock(pos pk.Position, name string) {
+	if name == "" || name == "minecraft:air" || !p.s.world.setBlock(pos.X, pos.Y, pos.Z, 0) {
+		return
+	}
+	p.s.sendAll(pk.Marshal(packetid.ClientboundBlockUpdate, pos, pk.VarInt(0)))
+	if drop := dropOf(name); drop != "" {
+		p.give(drop)
+	}
+}
+
+// give adds one item to the inventory, like picking it up: onto a stack of it that has room,
+// else into the first empty slot, the hotbar before the rest
+func (p *player) give(item string) {
+	order := make([]int, 0, 36)
+	for i := range 9 {
+		order = append(order, hotbarStart+i)
+	}
+	for i := 9; i < hotbarStart; i++ {
+		order = append(order, i)
+	}
+
+	p.mu.Lock()
+	slot := -1
+	for _, i := range order {
+		if st := p.inventory[i]; st.item == item && st.count > 0 && st.count < stackSize {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		for _, i := range order {
+			if p.inventory[i].count <= 0 {
+				slot = i
+				break
+			}
+		}
+	}
+	if slot >= 0 {
+		if p.inventory[slot].count <= 0 {
+			p.inventory[slot] = stack{item: item}
+		}
+		p.inventory[slot].count++
+	}
+	p.mu.Unlock()
+	if slot >= 0 {
+		p.slotUpdate(slot)
+	}
+}
+
+// place places the held block against a face of a block
+func (p *player) place(against pk.Position, face int, seq int32) error {
+	if face < 0 || face >= len(faces) {
+		return fmt.Errorf("unknown face %d", face)
+	}
+	pos := pk.Position{X: against.X + faces[face][0], Y: against.Y + faces[face][1], Z: against.Z + faces[face][2]}
+
+	p.mu.Lock()
+	slot := hotbarStart + p.held
+	held := p.inventory[slot]
+	state, isBlock := stateOf(held.item)
+	placed := false
+	if held.count > 0 && isBlock && block.IsAir(p.s.world.block(pos.X, pos.Y, pos.Z)) {
+		placed = p.s.world.setBlock(pos.X, pos.Y, pos.Z, state)
+		if placed {
+			p.inventory[slot].count--
+		}
+	}
+	p.mu.Unlock()
+
+	if placed {
+		p.s.sendAll(pk.Marshal(packetid.ClientboundBlockUpdate, pos, pk.VarInt(state)))
+		if err := p.slotUpdate(slot); err != nil {
+			return err
+		}
+	} else if err := p.blockUpdate(pos); err != nil {
+		// The client may have predicted the block, set it back
+		return err
+	}
+	return p.write(pk.Marshal(packetid.ClientboundBlockChangedAck, pk.VarInt(seq)))
+}
+
+// blockUpdate sends the player the block the world has at a position
+func (p *player) blockUpdate(pos pk.Position) error {
+	return p.write(pk.Marshal(packetid.ClientboundBlockUpdate, pos, pk.VarInt(p.s.world.block(pos.X, pos.Y, pos.Z))))
+}
+
+// slotUpdate sends the player a slot of the inventory
+func (p *player) slotUpdate(slot int) error {
+	p.mu.Lock()
+	if p.inventory[slot].count <= 0 {
+		p.inventory[slot] = stack{}
+	}
+	p.stateID++
+	packet := pk.Marshal(packetid.ClientboundContainerSetSlot, pk.VarInt(0), pk.VarInt(p.stateID), pk.Short(slot), p.inventory[slot])
+	p.mu.Unlock()
+	return p.write(packet)
+}
@@ -0,0 +1,299 @@
+// Package simulate is a small Minecraft server to try the bots out without a real one: a flat
+// world with ores scattered through the stone, chat played from a script and latency added to
+// every packet. It speaks the protocol the bots are built for in offline mode and simulates
+// what mining, pathfinding and commands rely on: chunks loading as players move, digging with
+// drops into the inventory, placing blocks, chat, the time of day and keep-alives. There are
+// no mobs, no physics, no containers or crafting, and nothing checks what players do.
+package simulate
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	dimension      = "minecraft:overworld"
+	maxPlayers     = 20
+	keepAliveEvery = 10 * time.Second
+	timeEvery      = time.Second
+	startTime      = 1000 // Time of day players join at, early morning
+)
+
+// Options configure a simulated server
+type Options struct {
+	Chat    []ChatLine    // Played once, after the first player joined
+	Latency time.Duration // Round trip added to every connection, half of it each way
+	Log     *log.Logger   // Where the server logs, the standard logger when nil
+}
+
+// Server is a simulated Minecraft server. Its players share one world.
+type Server struct {
+	opts    Options
+	log     *log.Logger
+	world   *world
+	started time.Time
+	script  sync.Once
+
+	mu      sync.Mutex // Guards players and nextEID
+	players map[*player]bool
+	nextEID int32
+}
+
+// New returns a simulated server, Serve makes it accept players
+func New(opts Options) *Server {
+	s := &Server{opts: opts, log: opts.Log, world: newWorld(), started: time.Now(), players: make(map[*player]bool), nextEID: 1}
+	if s.log == nil {
+		s.log = log.Default()
+	}
+	return s
+}
+
+// Serve accepts players from ln until it is closed
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if s.opts.Latency > 0 {
+			conn = newLatencyConn(conn, s.opts.Latency/2)
+		}
+		go s.handle(conn)
+	}
+}
+
+// Chat sends a chat line of a player, who needn't be online, to every player
+func (s *Server) Chat(player, text string) {
+	s.Broadcast(fmt.Sprintf("<%s> %s", player, text))
+}
+
+// Broadcast sends a system message to every player
+func (s *Server) Broadcast(text string) {
+	s.log.Printf("🧪 Chat: %s", text)
+	s.sendAll(systemChat(text))
+}
+
+// systemChat returns a system chat packet of plain text. The text is sent as a string
+// component, which go-mc reads like vanilla does but can't write.
+func systemChat(text string) pk.Packet {
+	return pk.Marshal(packetid.ClientboundSystemChat, pk.NBT(text), pk.Boolean(false))
+}
+
+// sendAll sends a packet to every player
+func (s *Server) sendAll(p pk.Packet) {
+	s.mu.Lock()
+	players := make([]*player, 0, len(s.players))
+	for pl := range s.players {
+		players = append(players, pl)
+	}
+	s.mu.Unlock()
+	for _, pl := range players {
+		pl.write(p) // A failed write ends the connection, its reader notices
+	}
+}
+
+// handle runs a connection until the player leaves
+func (s *Server) handle(conn net.Conn) {
+	c := mcnet.WrapConn(conn)
+	defer c.Close()
+	p, err := s.login(c)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.log.Printf("🧪 Connection from %s failed: %v", conn.RemoteAddr(), err)
+		}
+		return
+	}
+	if p == nil {
+		return // A server list ping
+	}
+
+	s.mu.Lock()
+	s.players[p] = true
+	s.mu.Unlock()
+	s.log.Printf("🧪 %s joined", p.name)
+	err = p.play()
+	s.mu.Lock()
+	delete(s.players, p)
+	s.mu.Unlock()
+	s.log.Printf("🧪 %s left: %v", p.name, err)
+}
+
+// login takes a connection through the handshake, login and configuration. It returns no
+// player for a server list ping.
+func (s *Server) login(c *mcnet.Conn) (*player, error) {
+	var (
+		p              pk.Packet
+		protocol, next pk.VarInt
+		host           pk.String
+		port           pk.UnsignedShort
+	)
+	if err := c.ReadPacket(&p); err != nil {
+		return nil, err
+	}
+	if err := p.Scan(&protocol, &host, &port, &next); err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+	switch next {
+	case 1:
+		return nil, s.status(c)
+	case 2, 3: // Joining, or joining on a transfer
+	default:
+		return nil, fmt.Errorf("handshake for unknown state %d", next)
+	}
+
+	var (
+		name pk.String
+		uuid pk.UUID
+	)
+	if err := c.ReadPacket(&p); err != nil {
+		return nil, err
+	}
+	if err := p.Scan(&name, &uuid); err != nil {
+		return nil, fmt.Errorf("login start: %w", err)
+	}
+	if protocol != registry.Protocol {
+		reason, _ := json.Marshal(chat.Text(fmt.Sprintf("The simulated server speaks protocol %d (Minecraft %s)", registry.Protocol, registry.Version)))
+		c.WritePacket(pk.Marshal(packetid.ClientboundLoginLoginDisconnect, pk.String(reason)))
+		return nil, fmt.Errorf("%s speaks protocol %d", name, protocol)
+	}
+
+	// Offline mode players get the UUID a vanilla server in offline mode gives them
+	uuid = md5.Sum([]byte("OfflinePlayer:" + name))
+	uuid[6] = uuid[6]&0x0f | 0x30
+	uuid[8] = uuid[8]&0x3f | 0x80
+	if err := c.WritePacket(pk.Marshal(packetid.ClientboundLoginGameProfile, uuid, name, pk.VarInt(0))); err != nil {
+		return nil, err
+	}
+	if err := readUntil(c, int32(packetid.ServerboundLoginLoginAcknowledged)); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	if err := c.WritePacket(pk.Marshal(
+		packetid.ClientboundConfigRegistryData,
+		pk.Identifier("minecraft:dimension_type"),
+		pk.VarInt(1),
+		pk.Identifier(dimension), pk.Boolean(true), pk.NBT(overworld),
+	)); err != nil {
+		return nil, err
+	}
+	if err := c.WritePacket(pk.Marshal(packetid.ClientboundConfigFinishConfiguration)); err != nil {
+		return nil, err
+	}
+	if err := readUntil(c, int32(packetid.ServerboundConfigFinishConfiguration)); err != nil {
+		return nil, fmt.Errorf("configuration: %w", err)
+	}
+
+	s.mu.Lock()
+	eid := s.nextEID
+	s.nextEID++
+	s.mu.Unlock()
+	return newPlayer(s, c, string(name), uuid, eid), nil
+}
+
+// overworld is the dimension type of the world
+var overworld = struct {
+	HasSkylight                 bool    `nbt:"has_skylight"`
+	HasCeiling                  bool    `nbt:"has_ceiling"`
+	Ultrawarm                   bool    `nbt:"ultrawarm"`
+	Natural                     bool    `nbt:"natural"`
+	CoordinateScale             float64 `nbt:"coordinate_scale"`
+	BedWorks                    bool    `nbt:"bed_works"`
+	RespawnAnchorWorks          byte    `nbt:"respawn_anchor_works"`
+	MinY                        int32   `nbt:"min_y"`
+	Height                      int32   `nbt:"height"`
+	LogicalHeight               int32   `nbt:"logical_height"`
+	InfiniteBurn                string  `nbt:"infiniburn"`
+	Effects                     string  `nbt:"effects"`
+	AmbientLight                float64 `nbt:"ambient_light"`
+	PiglinSafe                  byte    `nbt:"piglin_safe"`
+	HasRaids                    byte    `nbt:"has_raids"`
+	MonsterSpawnLightLevel      int32   `nbt:"monster_spawn_light_level"`
+	MonsterSpawnBlockLightLimit int32   `nbt:"monster_spawn_block_light_limit"`
+}{
+	HasSkylight:     true,
+	Natural:         true,
+	CoordinateScale: 1,
+	BedWorks:        true,
+	MinY:            MinY,
+	Height:          Height,
+	LogicalHeight:   Height,
+	InfiniteBurn:    "#minecraft:infiniburn_overworld",
+	Effects:         dimension,
+	HasRaids:        1,
+}
+
+// readUntil reads packets until one with the given ID, skipping the others
+func readUntil(c *mcnet.Conn, id int32) error {
+	for {
+		var p pk.Packet
+		if err := c.ReadPacket(&p); err != nil {
+			return err
+		}
+		if p.ID == id {
+			return nil
+		}
+	}
+}
+
+// status answers a server list ping
+func (s *Server) status(c *mcnet.Conn) error {
+	for {
+		var p pk.Packet
+		if err := c.ReadPacket(&p); err != nil {
+			return err
+		}
+		switch packetid.ServerboundPacketID(p.ID) {
+		case packetid.ServerboundStatusStatusRequest:
+			s.mu.Lock()
+			online := len(s.players)
+			s.mu.Unlock()
+			resp, err := json.Marshal(map[string]any{
+				"version":     map[string]any{"name": registry.Version, "protocol": registry.Protocol},
+				"players":     map[string]any{"max": maxPlayers, "online": online},
+				"description": chat.Text("Simulated server"),
+			})
+			if err != nil {
+				return err
+			}
+			if err := c.WritePacket(pk.Marshal(packetid.ClientboundStatusStatusResponse, pk.String(resp))); err != nil {
+				return err
+			}
+		case packetid.ServerboundStatusPingRequest:
+			return c.WritePacket(pk.Packet{ID: int32(packetid.ClientboundStatusPongResponse), Data: p.Data})
+		}
+	}
+}
+
+// playScript plays the chat script
+func (s *Server) playScript() {
+	if len(s.opts.Chat) == 0 {
+		return
+	}
+	for _, line := range s.opts.Chat {
+		time.Sleep(line.Wait)
+		s.Chat(line.Player, line.Text)
+	}
+	s.log.Printf("🧪 Chat script done, %d lines played", len(s.opts.Chat))
+}
+
+// dayTime returns the time of day in ticks
+func (s *Server) dayTime() int64 {
+	return startTime + int64(time.Since(s.started)/(50*time.Millisecond))
+}
@@ -0,0 +1,217 @@
+package simulate
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/Tnze/go-mc/data/item"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	pk "github.com/Tnze/go-mc/net/packet"
+
+	"github.com/coolguycoder/Minecraft-Miner/registry"
+)
+
+const (
+	MinY     = -64 // Lowest block of the world, bedrock
+	Height   = 384 // Blocks from the bottom to the top of the world
+	SurfaceY = 64  // Height the players stand at, on grass
+
+	sections = Height / 16
+)
+
+// Layers of the flat world from the bottom up, each up to the height before the next. Ores are
+// scattered through the stone.
+var layers = []struct {
+	top   int
+	block string
+}{
+	{MinY, "minecraft:bedrock"},
+	{SurfaceY - 5, "minecraft:stone"},
+	{SurfaceY - 2, "minecraft:dirt"},
+	{SurfaceY - 1, "minecraft:grass_block"},
+}
+
+// drops are the items blocks drop that differ from the block itself
+var drops = map[string]string{
+	"minecraft:stone":       "minecraft:cobblestone",
+	"minecraft:grass_block": "minecraft:dirt",
+	"minecraft:coal_ore":    "minecraft:coal",
+	"minecraft:iron_ore":    "minecraft:raw_iron",
+	"minecraft:diamond_ore": "minecraft:diamond",
+}
+
+var (
+	lookupOnce sync.Once
+	states     map[string]block.StateID // First state of every block by name
+	items      map[string]int32         // Item IDs by name
+)
+
+func lookups() {
+	lookupOnce.Do(func() {
+		states = make(map[string]block.StateID)
+		for i, b := range block.StateList {
+			if _, ok := states[b.ID()]; !ok {
+				states[b.ID()] = block.StateID(i)
+			}
+		}
+		items = make(map[string]int32, len(item.ByID))
+		for id, it := range item.ByID {
+			items["minecraft:"+it.Name] = int32(id)
+		}
+	})
+}
+
+// stateOf returns the state a block is placed in, and whether there is such a block
+func stateOf(name string) (block.StateID, bool) {
+	lookups()
+	s, ok := states[name]
+	return s, ok
+}
+
+// itemOf returns the ID of an item, and whether there is such an item
+func itemOf(name string) (int32, bool) {
+	lookups()
+	id, ok := items[name]
+	return id, ok
+}
+
+// nameOf returns the name of the block of a state
+func nameOf(s block.StateID) string {
+	if int(s) < 0 || int(s) >= len(block.StateList) {
+		return ""
+	}
+	return block.StateList[s].ID()
+}
+
+// generated returns the block the flat world has at a position before anyone changed it
+func generated(x, y, z int) string {
+	if x == 0 && y == SurfaceY && z == 1 {
+		return "minecraft:cobblestone" // In front of the spawn, for the block the bots mine first
+	}
+	if y < MinY || y >= SurfaceY {
+		return "minecraft:air"
+	}
+	name := layers[len(layers)-1].block
+	for _, l := range layers {
+		if y <= l.top {
+			name = l.block
+			break
+		}
+	}
+	if name != "minecraft:stone" {
+		return name
+	}
+	h := uint32(x*73856093) ^ uint32(y*19349663) ^ uint32(z*83492791)
+	h ^= h >> 13
+	h *= 0x5bd1e995
+	h ^= h >> 15
+	switch {
+	case y < -48 && h%400 == 0:
+		return "minecraft:diamond_ore"
+	case y < 40 && h%150 == 0:
+		return "minecraft:iron_ore"
+	case h%90 == 0:
+		return "minecraft:coal_ore"
+	}
+	return name
+}
+
+// world is the flat world the players share, generated chunk by chunk as they come near
+type world struct {
+	mu     sync.Mutex
+	chunks map[level.ChunkPos]*level.Chunk
+}
+
+func newWorld() *world {
+	return &world{chunks: make(map[level.ChunkPos]*level.Chunk)}
+}
+
+// chunk returns a chunk, generating it the first time. The caller holds mu.
+func (w *world) chunk(pos level.ChunkPos) *level.Chunk {
+	if c, ok := w.chunks[pos]; ok {
+		return c
+	}
+	c := level.EmptyChunk(sections)
+	for s := range c.Sections {
+		for i := range 16 * 16 * 16 {
+			x, y, z := int(pos[0])*16+i&15, MinY+s*16+i>>8, int(pos[1])*16+i>>4&15
+			if name := generated(x, y, z); name != "minecraft:air" {
+				state, _ := stateOf(name)
+				c.Sections[s].SetBlock(i, level.BlocksState(state))
+			}
+		}
+	}
+	w.chunks[pos] = c
+	return c
+}
+
+// block returns the state at a position
+func (w *world) block(x, y, z int) block.StateID {
+	if y < MinY || y >= MinY+Height {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := w.chunk(level.ChunkPos{int32(x >> 4), int32(z >> 4)})
+	return block.StateID(c.Sections[(y-MinY)>>4].GetBlock((y-MinY)&15<<8 | (z&15)<<4 | x&15))
+}
+
+// setBlock changes the state at a position and reports whether it is inside the world
+func (w *world) setBlock(x, y, z int, state block.StateID) bool {
+	if y < MinY || y >= MinY+Height {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := w.chunk(level.ChunkPos{int32(x >> 4), int32(z >> 4)})
+	c.Sections[(y-MinY)>>4].SetBlock((y-MinY)&15<<8|(z&15)<<4|x&15, level.BlocksState(state))
+	return true
+}
+
+// chunkPacket returns the chunk data packet of a chunk, without light
+func (w *world) chunkPacket(pos level.ChunkPos) (pk.Packet, error) {
+	w.mu.Lock()
+	c := w.chunk(pos)
+	data, err := c.Data()
+	heightmaps := struct {
+		MotionBlocking []uint64 `nbt:"MOTION_BLOCKING"`
+		WorldSurface   []uint64 `nbt:"WORLD_SURFACE"`
+	}{c.HeightMaps.MotionBlocking.Raw(), c.HeightMaps.WorldSurface.Raw()}
+	w.mu.Unlock()
+	if err != nil {
+		return pk.Packet{}, err
+	}
+	var buf bytes.Buffer
+	_, err = pk.Tuple{
+		pos,
+		pk.NBT(heightmaps),
+		pk.ByteArray(data),
+		pk.VarInt(0),             // Block entities
+		pk.BitSet{}, pk.BitSet{}, // Sky and block light masks
+		pk.BitSet{}, pk.BitSet{}, // Empty sky and block light masks
+		pk.VarInt(0), pk.VarInt(0), // Sky and block light arrays
+	}.WriteTo(&buf)
+	return pk.Packet{ID: int32(packetid.ClientboundLevelChunkWithLight), Data: buf.Bytes()}, err
+}
+
+// hardness returns how hard a block is to break, negative for unbreakable
+func hardness(name string) float32 {
+	if b, ok := registry.BlockInfo(name); ok {
+		return b.Hardness
+	}
+	return 1
+}
+
+// dropOf returns the item a broken block drops, empty for none
+func dropOf(name string) string {
+	if d, ok := drops[name]; ok {
+		return d
+	}
+	if _, ok := itemOf(name); ok && !strings.HasSuffix(name, "air") {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Backend is where the bots keep what they learned. DB and SQL implement it.
+type Backend interface {
+	// Put stores v as JSON under key in a bucket
+	Put(bucket, key string, v any) error
+	// Get decodes the value under key in a bucket into v and reports whether there was one
+	Get(bucket, key string, v any) (bool, error)
+	// Has reports whether a bucket holds key
+	Has(bucket, key string) bool
+	// Delete removes key from a bucket. Deleting a missing key is not an error.
+	Delete(bucket, key string) error
+	// Each calls fn with every key of a bucket and its JSON value, in key order, stopping at
+	// the first error
+	Each(bucket string, fn func(key string, value json.RawMessage) error) error
+	// Len returns the number of keys in a bucket
+	Len(bucket string) int
+	// Close releases the database
+	Close() error
+}
+
+var (
+	_ Backend = (*DB)(nil)
+	_ Backend = (*SQL)(nil)
+)
+
+// Location prefixes naming a database that isn't a file of this package
+const (
+	sqlitePrefix = "sqlite:"
+)
+
+var postgresPrefixes = []string{"postgres://", "postgresql://"}
+
+// Connect opens the database a location names: a PostgreSQL connection URL like
+// "postgres://miner@db.example.com/miner", "sqlite:<file>" for an SQLite file, or the path
+// of a file of this package, empty for memory only
+func Connect(location string) (Backend, error) {
+	for _, p := range postgresPrefixes {
+		if strings.HasPrefix(location, p) {
+			return OpenSQL(postgresDriver, location)
+		}
+	}
+	if file, ok := strings.CutPrefix(location, sqlitePrefix); ok {
+		return OpenSQL(sqliteDriver, file)
+	}
+	return Open(location)
+}
+
+// LocalFile returns the file a location keeps the database in, false for a database on a
+// server or in memory
+func LocalFile(location string) (string, bool) {
+	for _, p := range postgresPrefixes {
+		if strings.HasPrefix(location, p) {
+			return "", false
+		}
+	}
+	file := strings.TrimPrefix(location, sqlitePrefix)
+	return file, file != ""
+}
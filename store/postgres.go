@@ -0,0 +1,6 @@
+//go:build postgres
+
+package store
+
+// The PostgreSQL driver, built in with -tags postgres.
+import _ "github.com/jackc/pgx/v5/stdlib"
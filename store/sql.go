@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Drivers of database/sql the SQL backend uses, registered by building with the sqlite or
+// postgres tag
+const (
+	sqliteDriver   = "sqlite"
+	postgresDriver = "pgx"
+)
+
+const sqlTable = "miner_state"
+
+// SQL is a database kept in a table of an SQL database. Unlike DB nothing is cached, every
+// call goes to the database, so the bots of several processes or machines can share it. It
+// is safe for concurrent use.
+type SQL struct {
+	db       *sql.DB
+	numbered bool // Whether placeholders are $1, $2... (PostgreSQL) rather than ?
+}
+
+// OpenSQL connects to a database through a database/sql driver and creates the table of
+// the buckets unless it exists
+func OpenSQL(driver, dsn string) (*SQL, error) {
+	if !slices.Contains(sql.Drivers(), driver) {
+		return nil, fmt.Errorf("this build has no %s driver, build with -tags %s", driver, map[string]string{sqliteDriver: "sqlite", postgresDriver: "postgres"}[driver])
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s database: %w", driver, err)
+	}
+	if driver == sqliteDriver {
+		db.SetMaxOpenConns(1) // SQLite takes one writer at a time
+	}
+	s := &SQL{db: db, numbered: driver == postgresDriver}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS ` + sqlTable + ` (
+		bucket TEXT NOT NULL,
+		name   TEXT NOT NULL,
+		value  TEXT NOT NULL,
+		PRIMARY KEY (bucket, name)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the %s table: %w", sqlTable, err)
+	}
+	return s, nil
+}
+
+// query writes the placeholders of a query the way the database expects them
+func (s *SQL) query(q string) string {
+	if !s.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Put stores v as JSON under key in a bucket
+func (s *SQL) Put(bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", bucket, key, err)
+	}
+	_, err = s.db.Exec(s.query(`INSERT INTO `+sqlTable+` (bucket, name, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, name) DO UPDATE SET value = excluded.value`), bucket, key, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Get decodes the value under key in a bucket into v and reports whether there was one
+func (s *SQL) Get(bucket, key string, v any) (bool, error) {
+	var data string
+	err := s.db.QueryRow(s.query(`SELECT value FROM `+sqlTable+` WHERE bucket = ? AND name = ?`), bucket, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s/%s: %w", bucket, key, err)
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return true, fmt.Errorf("failed to decode %s/%s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// Has reports whether a bucket holds key, false when the database can't be reached
+func (s *SQL) Has(bucket, key string) bool {
+	var one int
+	err := s.db.QueryRow(s.query(`SELECT 1 FROM `+sqlTable+` WHERE bucket = ? AND name = ?`), bucket, key).Scan(&one)
+	return err == nil
+}
+
+// Delete removes key from a bucket. Deleting a missing key is not an error.
+func (s *SQL) Delete(bucket, key string) error {
+	if _, err := s.db.Exec(s.query(`DELETE FROM `+sqlTable+` WHERE bucket = ? AND name = ?`), bucket, key); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Each calls fn with every key of a bucket and its JSON value, in key order, stopping at
+// the first error. The rows are read before fn is called, so fn may use the database.
+func (s *SQL) Each(bucket string, fn func(key string, value json.RawMessage) error) error {
+	rows, err := s.db.Query(s.query(`SELECT name, value FROM `+sqlTable+` WHERE bucket = ? ORDER BY name`), bucket)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", bucket, err)
+	}
+	var keys []string
+	var values []json.RawMessage
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read %s: %w", bucket, err)
+		}
+		keys = append(keys, key)
+		values = append(values, json.RawMessage(value))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", bucket, err)
+	}
+
+	for i, k := range keys {
+		if err := fn(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of keys in a bucket, 0 when the database can't be reached
+func (s *SQL) Len(bucket string) int {
+	var n int
+	if err := s.db.QueryRow(s.query(`SELECT COUNT(*) FROM `+sqlTable+` WHERE bucket = ?`), bucket).Scan(&n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// Close closes the connections to the database
+func (s *SQL) Close() error {
+	return s.db.Close()
+}
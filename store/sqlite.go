@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package store
+
+// The SQLite driver, pure Go, built in with -tags sqlite.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,282 @@
+// Package store is a small key-value database. Values are JSON documents grouped in
+// buckets. DB keeps them in memory and persists them to a single file as a log of changes
+// that is compacted when it grows mostly stale; SQL keeps them in a table of an SQLite or
+// PostgreSQL database that the bots of many machines can share.
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+)
+
+const (
+	compactMin   = 1024     // Stale records tolerated before compacting, whatever the live count
+	maxRecordLen = 16 << 20 // Longest record read back
+)
+
+// record is one line of the log: a value put in a bucket, or a key deleted from it
+type record struct {
+	Bucket  string          `json:"b"`
+	Key     string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+}
+
+// DB is an open database. It is safe for concurrent use.
+type DB struct {
+	mu      sync.Mutex
+	path    string   // Empty for a database kept in memory only
+	f       *os.File // Log the changes are appended to
+	buckets map[string]map[string]json.RawMessage
+	live    int // Records in the buckets
+	stale   int // Records in the log that were overwritten or deleted since
+}
+
+// Open loads the database at path, creating the file when it does not exist. An empty path
+// opens a database that is not persisted. A record cut short by a crash is dropped.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path, buckets: make(map[string]map[string]json.RawMessage)}
+	if path == "" {
+		return db, nil
+	}
+
+	valid, err := db.replay()
+	if err != nil {
+		return nil, err
+	}
+	db.f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// Later records are appended after the last complete one
+	if err := db.f.Truncate(valid); err != nil {
+		db.f.Close()
+		return nil, fmt.Errorf("failed to truncate %s: %w", path, err)
+	}
+	if _, err := db.f.Seek(valid, io.SeekStart); err != nil {
+		db.f.Close()
+		return nil, fmt.Errorf("failed to seek %s: %w", path, err)
+	}
+	if err := db.compactIfStale(); err != nil {
+		db.f.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// replay applies the log to the buckets and returns the length of its complete records
+func (db *DB) replay() (valid int64, err error) {
+	f, err := os.Open(db.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", db.path, err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// Long records don't fit the reader's buffer, collect them piece by piece
+			long := slices.Clone(line)
+			for errors.Is(err, bufio.ErrBufferFull) && len(long) < maxRecordLen {
+				line, err = r.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if err != nil {
+			// A last line without its newline was being written when the process ended
+			return valid, nil
+		}
+		var rec record
+		if json.Unmarshal(bytes.TrimSpace(line), &rec) != nil {
+			return valid, nil
+		}
+		db.apply(rec)
+		valid += int64(len(line))
+	}
+}
+
+// apply changes the buckets as a record says. The caller must hold the lock or own db.
+func (db *DB) apply(rec record) {
+	b := db.buckets[rec.Bucket]
+	_, existed := b[rec.Key]
+	if existed {
+		db.stale++
+	}
+	if rec.Deleted {
+		if existed {
+			delete(b, rec.Key)
+			db.live--
+		}
+		// The deletion itself is stale once applied
+		db.stale++
+		return
+	}
+	if b == nil {
+		b = make(map[string]json.RawMessage)
+		db.buckets[rec.Bucket] = b
+	}
+	b[rec.Key] = rec.Value
+	if !existed {
+		db.live++
+	}
+}
+
+// write appends a record to the log and applies it. The caller must hold the lock.
+func (db *DB) write(rec record) error {
+	if db.f != nil {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		// One write per record, so a crash loses at most the record being written
+		if _, err := db.f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write %s: %w", db.path, err)
+		}
+	}
+	db.apply(rec)
+	return db.compactIfStale()
+}
+
+// compactIfStale rewrites the log with the live records only once most of it is stale.
+// The caller must hold the lock or own db.
+func (db *DB) compactIfStale() error {
+	if db.f == nil || db.stale < compactMin || db.stale < db.live {
+		return nil
+	}
+
+	tmp := db.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to compact %s: %w", db.path, err)
+	}
+	w := bufio.NewWriter(f)
+	for _, bucket := range slices.Sorted(maps.Keys(db.buckets)) {
+		for _, key := range slices.Sorted(maps.Keys(db.buckets[bucket])) {
+			line, err := json.Marshal(record{Bucket: bucket, Key: key, Value: db.buckets[bucket][key]})
+			if err == nil {
+				_, err = w.Write(append(line, '\n'))
+			}
+			if err != nil {
+				f.Close()
+				os.Remove(tmp)
+				return fmt.Errorf("failed to compact %s: %w", db.path, err)
+			}
+		}
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		// The old log stays complete until the new one replaces it
+		err = os.Rename(tmp, db.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact %s: %w", db.path, err)
+	}
+
+	db.f.Close()
+	db.f = f
+	db.stale = 0
+	return nil
+}
+
+// Put stores v as JSON under key in a bucket
+func (db *DB) Put(bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", bucket, key, err)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.write(record{Bucket: bucket, Key: key, Value: data})
+}
+
+// Get decodes the value under key in a bucket into v and reports whether there was one
+func (db *DB) Get(bucket, key string, v any) (bool, error) {
+	db.mu.Lock()
+	data, ok := db.buckets[bucket][key]
+	db.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("failed to decode %s/%s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// Has reports whether a bucket holds key
+func (db *DB) Has(bucket, key string) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, ok := db.buckets[bucket][key]
+	return ok
+}
+
+// Delete removes key from a bucket. Deleting a missing key is not an error.
+func (db *DB) Delete(bucket, key string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.buckets[bucket][key]; !ok {
+		return nil
+	}
+	return db.write(record{Bucket: bucket, Key: key, Deleted: true})
+}
+
+// Each calls fn with every key of a bucket and its JSON value, in key order,
+// stopping at the first error
+func (db *DB) Each(bucket string, fn func(key string, value json.RawMessage) error) error {
+	db.mu.Lock()
+	b := db.buckets[bucket]
+	keys := slices.Sorted(maps.Keys(b))
+	values := make([]json.RawMessage, len(keys))
+	for i, k := range keys {
+		values[i] = b[k]
+	}
+	db.mu.Unlock()
+
+	for i, k := range keys {
+		if err := fn(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of keys in a bucket
+func (db *DB) Len(bucket string) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.buckets[bucket])
+}
+
+// Close flushes the log to disk and closes it. The database stays readable in memory.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.f == nil {
+		return nil
+	}
+	err := db.f.Sync()
+	if cerr := db.f.Close(); err == nil {
+		err = cerr
+	}
+	db.f = nil
+	return err
+}
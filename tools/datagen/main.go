@@ -0,0 +1,549 @@
+// Command datagen regenerates the tables of the registry package from the PrismarineJS
+// minecraft-data project for one Minecraft release:
+//
+//	go run ./tools/datagen -version 1.21.4
+//
+// -data points at a local checkout of the project's data directory instead of GitHub, and
+// -packets writes the release's packets report for the bot's protocols_dir instead, named as
+// the vanilla data generator names them, for the releases before it reported packets.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultData = "https://raw.githubusercontent.com/PrismarineJS/minecraft-data/master/data"
+
+// tiers ranks tool materials by the blocks they can harvest, like registry.Tier*
+var tiers = map[string]string{
+	"wooden":    "TierWood",
+	"golden":    "TierWood",
+	"stone":     "TierStone",
+	"iron":      "TierIron",
+	"diamond":   "TierDiamond",
+	"netherite": "TierNetherite",
+}
+
+// tierOrder sorts the tier constants from lowest to highest
+var tierOrder = []string{"TierNone", "TierWood", "TierStone", "TierIron", "TierDiamond", "TierNetherite"}
+
+// versionInfo is the version.json of a release
+type versionInfo struct {
+	Minecraft string `json:"minecraftVersion"`
+	Protocol  int    `json:"version"`
+}
+
+// blockData is an entry of blocks.json
+type blockData struct {
+	Name         string          `json:"name"`
+	Hardness     *float64        `json:"hardness"`
+	Diggable     bool            `json:"diggable"`
+	Material     string          `json:"material"`
+	HarvestTools map[string]bool `json:"harvestTools"`
+}
+
+// itemData is an entry of items.json
+type itemData struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	StackSize     int    `json:"stackSize"`
+	MaxDurability int    `json:"maxDurability"`
+}
+
+// Block and Item are table rows as written to the registry package
+type (
+	Block struct {
+		Name     string
+		Hardness float64
+		Tool     string
+		Tier     string
+	}
+	Item struct {
+		Name      string
+		StackSize int
+		MaxDamage int
+	}
+)
+
+// source reads the files of the data directory from a URL or a local path
+type source string
+
+// read decodes the JSON file at path below the data directory
+func (s source) read(path string, v any) error {
+	var r io.ReadCloser
+	if strings.HasPrefix(string(s), "http://") || strings.HasPrefix(string(s), "https://") {
+		client := http.Client{Timeout: time.Minute}
+		resp, err := client.Get(string(s) + "/" + path)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("%s: %s", path, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(filepath.Join(string(s), filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// dataDirs returns the directory of every kind of data of a release, by kind
+func dataDirs(src source, version string) (map[string]string, error) {
+	var paths struct {
+		PC map[string]map[string]string `json:"pc"`
+	}
+	if err := src.read("dataPaths.json", &paths); err != nil {
+		return nil, err
+	}
+	dirs, ok := paths.PC[version]
+	if !ok {
+		return nil, fmt.Errorf("minecraft-data has no release %s", version)
+	}
+	return dirs, nil
+}
+
+// readData decodes the file of a kind of data of a release
+func readData(src source, dirs map[string]string, version, file string, v any) error {
+	dir, ok := dirs[file]
+	if !ok {
+		return fmt.Errorf("release %s has no %s data", version, file)
+	}
+	return src.read(dir+"/"+file+".json", v)
+}
+
+// load reads the tables of a release
+func load(src source, version string) (versionInfo, []Block, []Item, error) {
+	dirs, err := dataDirs(src, version)
+	if err != nil {
+		return versionInfo{}, nil, nil, err
+	}
+
+	var v versionInfo
+	var rawBlocks []blockData
+	var rawItems []itemData
+	for file, dst := range map[string]any{"version": &v, "blocks": &rawBlocks, "items": &rawItems} {
+		if err := readData(src, dirs, version, file, dst); err != nil {
+			return versionInfo{}, nil, nil, err
+		}
+	}
+
+	itemNames := make(map[string]string, len(rawItems))
+	items := make([]Item, 0, len(rawItems))
+	for _, it := range rawItems {
+		itemNames[fmt.Sprint(it.ID)] = it.Name
+		items = append(items, Item{Name: "minecraft:" + it.Name, StackSize: it.StackSize, MaxDamage: it.MaxDurability})
+	}
+	blocks := make([]Block, 0, len(rawBlocks))
+	for _, b := range rawBlocks {
+		blocks = append(blocks, convertBlock(b, itemNames))
+	}
+	return v, blocks, items, nil
+}
+
+// convertBlock works out the tool and tier of a block from its material and harvest tools
+func convertBlock(b blockData, itemNames map[string]string) Block {
+	out := Block{Name: "minecraft:" + b.Name, Hardness: -1, Tier: "TierNone"}
+	if b.Hardness != nil && b.Diggable {
+		out.Hardness = *b.Hardness
+	}
+	for _, m := range strings.Split(b.Material, ";") {
+		if tool, ok := strings.CutPrefix(m, "mineable/"); ok {
+			out.Tool = tool
+			break
+		}
+	}
+	// The lowest material among the tools that harvest the block is the tier it needs
+	for id := range b.HarvestTools {
+		material, _, _ := strings.Cut(itemNames[id], "_")
+		tier, ok := tiers[material]
+		if !ok {
+			continue
+		}
+		if out.Tier == "TierNone" || slices.Index(tierOrder, tier) < slices.Index(tierOrder, out.Tier) {
+			out.Tier = tier
+		}
+	}
+	return out
+}
+
+var (
+	versionTmpl = template.Must(template.New("version").Parse(`// Code generated by tools/datagen. DO NOT EDIT.
+
+package registry
+
+// Release the tables were generated for
+const (
+	Version  = "{{.Minecraft}}"
+	Protocol = {{.Protocol}}
+)
+`))
+	blocksTmpl = template.Must(template.New("blocks").Parse(`// Code generated by tools/datagen. DO NOT EDIT.
+
+package registry
+
+var blocks = map[string]Block{
+{{- range .}}
+	"{{.Name}}": { {{- .Hardness}}, "{{.Tool}}", {{.Tier -}} },
+{{- end}}
+}
+`))
+	itemsTmpl = template.Must(template.New("items").Parse(`// Code generated by tools/datagen. DO NOT EDIT.
+
+package registry
+
+var items = map[string]Item{
+{{- range .}}
+	"{{.Name}}": { {{- .StackSize}}, {{.MaxDamage -}} },
+{{- end}}
+}
+`))
+)
+
+// writeTables writes the version, block and item tables to the registry package in dir
+func writeTables(dir string, v versionInfo, blocks []Block, items []Item) error {
+	slices.SortFunc(blocks, func(a, b Block) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(items, func(a, b Item) int { return cmp.Compare(a.Name, b.Name) })
+	for _, f := range []struct {
+		name string
+		tmpl *template.Template
+		data any
+	}{
+		{"version.go", versionTmpl, v},
+		{"blocks.go", blocksTmpl, blocks},
+		{"items.go", itemsTmpl, items},
+	} {
+		var buf bytes.Buffer
+		if err := f.tmpl.Execute(&buf, f.data); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.name), src, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packetNames are the vanilla names of the play packets minecraft-data names otherwise, by
+// direction, for the releases before the vanilla data generator reported packets
+var packetNames = map[string]map[string]string{
+	"clientbound": {
+		"spawn_entity":                "add_entity",
+		"spawn_entity_experience_orb": "add_experience_orb",
+		"spawn_entity_living":         "add_mob",
+		"spawn_entity_painting":       "add_painting",
+		"named_entity_spawn":          "add_player",
+		"sculk_vibration_signal":      "add_vibration_signal",
+		"animation":                   "animate",
+		"statistics":                  "award_stats",
+		"block_break_animation":       "block_destruction",
+		"tile_entity_data":            "block_entity_data",
+		"block_action":                "block_event",
+		"block_change":                "block_update",
+		"boss_bar":                    "boss_event",
+		"difficulty":                  "change_difficulty",
+		"chat_preview":                "chat_preview",
+		"tab_complete":                "command_suggestions",
+		"declare_commands":            "commands",
+		"transaction":                 "container_ack",
+		"close_window":                "container_close",
+		"window_items":                "container_set_content",
+		"craft_progress_bar":          "container_set_data",
+		"set_slot":                    "container_set_slot",
+		"set_cooldown":                "cooldown",
+		"chat_suggestions":            "custom_chat_completions",
+		"named_sound_effect":          "custom_sound",
+		"hide_message":                "delete_chat",
+		"kick_disconnect":             "disconnect",
+		"profileless_chat":            "disguised_chat",
+		"entity_status":               "entity_event",
+		"explosion":                   "explode",
+		"unload_chunk":                "forget_level_chunk",
+		"game_state_change":           "game_event",
+		"open_horse_window":           "horse_screen_open",
+		"initialize_world_border":     "initialize_border",
+		"world_event":                 "level_event",
+		"world_particles":             "level_particles",
+		"update_light":                "light_update",
+		"map":                         "map_item_data",
+		"trade_list":                  "merchant_offers",
+		"rel_entity_move":             "move_entity_pos",
+		"entity_move_look":            "move_entity_pos_rot",
+		"entity_look":                 "move_entity_rot",
+		"entity":                      "move_entity",
+		"vehicle_move":                "move_vehicle",
+		"open_window":                 "open_screen",
+		"open_sign_entity":            "open_sign_editor",
+		"craft_recipe_response":       "place_ghost_recipe",
+		"abilities":                   "player_abilities",
+		"message_header":              "player_chat_header",
+		"combat_event":                "player_combat",
+		"end_combat_event":            "player_combat_end",
+		"enter_combat_event":          "player_combat_enter",
+		"death_combat_event":          "player_combat_kill",
+		"player_remove":               "player_info_remove",
+		"face_player":                 "player_look_at",
+		"position":                    "player_position",
+		"unlock_recipes":              "recipe",
+		"remove_entity_effect":        "remove_mob_effect",
+		"resource_pack_send":          "resource_pack",
+		"entity_head_rotation":        "rotate_head",
+		"multi_block_change":          "section_blocks_update",
+		"select_advancement_tab":      "select_advancements_tab",
+		"action_bar":                  "set_action_bar_text",
+		"world_border":                "set_border",
+		"world_border_center":         "set_border_center",
+		"world_border_lerp_size":      "set_border_lerp_size",
+		"world_border_size":           "set_border_size",
+		"world_border_warning_delay":  "set_border_warning_delay",
+		"world_border_warning_reach":  "set_border_warning_distance",
+		"camera":                      "set_camera",
+		"held_item_slot":              "set_carried_item",
+		"update_view_position":        "set_chunk_cache_center",
+		"update_view_distance":        "set_chunk_cache_radius",
+		"spawn_position":              "set_default_spawn_position",
+		"should_display_chat_preview": "set_display_chat_preview",
+		"display_scoreboard":          "set_display_objective",
+		"entity_metadata":             "set_entity_data",
+		"attach_entity":               "set_entity_link",
+		"entity_velocity":             "set_entity_motion",
+		"entity_equipment":            "set_equipment",
+		"experience":                  "set_experience",
+		"update_health":               "set_health",
+		"scoreboard_objective":        "set_objective",
+		"teams":                       "set_player_team",
+		"scoreboard_score":            "set_score",
+		"simulation_distance":         "set_simulation_distance",
+		"set_title_subtitle":          "set_subtitle_text",
+		"title":                       "set_titles",
+		"set_title_time":              "set_titles_animation",
+		"update_time":                 "set_time",
+		"entity_sound_effect":         "sound_entity",
+		"sound_effect":                "sound",
+		"playerlist_header":           "tab_list",
+		"nbt_query_response":          "tag_query",
+		"collect":                     "take_item_entity",
+		"entity_teleport":             "teleport_entity",
+		"advancements":                "update_advancements",
+		"entity_update_attributes":    "update_attributes",
+		"feature_flags":               "update_enabled_features",
+		"entity_effect":               "update_mob_effect",
+		"declare_recipes":             "update_recipes",
+		"tags":                        "update_tags",
+	},
+	"serverbound": {
+		"teleport_confirm":              "accept_teleportation",
+		"query_block_nbt":               "block_entity_tag_query",
+		"set_difficulty":                "change_difficulty",
+		"message_acknowledgement":       "chat_ack",
+		"chat_message":                  "chat",
+		"settings":                      "client_information",
+		"tab_complete":                  "command_suggestion",
+		"transaction":                   "container_ack",
+		"enchant_item":                  "container_button_click",
+		"window_click":                  "container_click",
+		"close_window":                  "container_close",
+		"query_entity_nbt":              "entity_tag_query",
+		"use_entity":                    "interact",
+		"generate_structure":            "jigsaw_generate",
+		"position":                      "move_player_pos",
+		"position_look":                 "move_player_pos_rot",
+		"look":                          "move_player_rot",
+		"flying":                        "move_player_status_only",
+		"steer_boat":                    "paddle_boat",
+		"craft_recipe_request":          "place_recipe",
+		"abilities":                     "player_abilities",
+		"block_dig":                     "player_action",
+		"entity_action":                 "player_command",
+		"steer_vehicle":                 "player_input",
+		"recipe_book":                   "recipe_book_change_settings",
+		"displayed_recipe":              "recipe_book_seen_recipe",
+		"name_item":                     "rename_item",
+		"resource_pack_receive":         "resource_pack",
+		"advancement_tab":               "seen_advancements",
+		"set_beacon_effect":             "set_beacon",
+		"held_item_slot":                "set_carried_item",
+		"update_command_block":          "set_command_block",
+		"update_command_block_minecart": "set_command_minecart",
+		"set_creative_slot":             "set_creative_mode_slot",
+		"update_jigsaw_block":           "set_jigsaw_block",
+		"update_structure_block":        "set_structure_block",
+		"update_sign":                   "sign_update",
+		"arm_animation":                 "swing",
+		"spectate":                      "teleport_to_entity",
+		"block_place":                   "use_item_on",
+	},
+}
+
+// vanillaPacketName returns the vanilla name of a play packet of protocol, for the names
+// minecraft-data kept while the vanilla one changed
+func vanillaPacketName(direction, name string, protocol int) string {
+	switch {
+	case direction == "serverbound":
+	case name == "map_chunk" && protocol < 757:
+		return "level_chunk"
+	case name == "map_chunk":
+		return "level_chunk_with_light"
+	case name == "entity_destroy" && protocol == 755:
+		return "remove_entity"
+	case name == "entity_destroy":
+		return "remove_entities"
+	case name == "acknowledge_player_digging" && protocol >= 759:
+		return "block_changed_ack"
+	case name == "acknowledge_player_digging":
+		return "block_break_ack"
+	case name == "player_info" && protocol >= 761:
+		return "player_info_update"
+	}
+	if vanilla, ok := packetNames[direction][name]; ok {
+		return vanilla
+	}
+	return name
+}
+
+// protocolData is the part of protocol.json numbering the packets of a state: the name of each
+// packet ID, in the mapper of the packet's name field
+type protocolData map[string]map[string]struct {
+	Types struct {
+		Packet []json.RawMessage `json:"packet"`
+	} `json:"types"`
+}
+
+// packetReport is the packets report of the vanilla data generator, by state, direction and
+// name
+type packetReport map[string]map[string]map[string]packetEntry
+
+// packetEntry is a packet of the packets report
+type packetEntry struct {
+	ID int `json:"protocol_id"`
+}
+
+// loadPackets reads the play packets of a release as the vanilla packets report lists them
+func loadPackets(src source, version string) (versionInfo, packetReport, error) {
+	dirs, err := dataDirs(src, version)
+	if err != nil {
+		return versionInfo{}, nil, err
+	}
+	var v versionInfo
+	var data protocolData
+	for file, dst := range map[string]any{"version": &v, "protocol": &data} {
+		if err := readData(src, dirs, version, file, dst); err != nil {
+			return versionInfo{}, nil, err
+		}
+	}
+	report := packetReport{"play": {}}
+	for from, direction := range map[string]string{"toClient": "clientbound", "toServer": "serverbound"} {
+		packet := data["play"][from].Types.Packet
+		if len(packet) != 2 {
+			return versionInfo{}, nil, fmt.Errorf("the play packets %s have no container", from)
+		}
+		var fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		}
+		if err := json.Unmarshal(packet[1], &fields); err != nil {
+			return versionInfo{}, nil, fmt.Errorf("the play packets %s: %w", from, err)
+		}
+		var mapper struct {
+			Mappings map[string]string `json:"mappings"`
+		}
+		for _, f := range fields {
+			var typ []json.RawMessage // Like ["mapper", {"mappings": ...}]
+			if f.Name != "name" || json.Unmarshal(f.Type, &typ) != nil || len(typ) != 2 {
+				continue
+			}
+			if err := json.Unmarshal(typ[1], &mapper); err != nil {
+				return versionInfo{}, nil, fmt.Errorf("the play packets %s: %w", from, err)
+			}
+		}
+		if len(mapper.Mappings) == 0 {
+			return versionInfo{}, nil, fmt.Errorf("the play packets %s have no names", from)
+		}
+		packets := make(map[string]packetEntry, len(mapper.Mappings))
+		for hex, name := range mapper.Mappings {
+			var id int
+			if _, err := fmt.Sscanf(hex, "0x%x", &id); err != nil {
+				return versionInfo{}, nil, fmt.Errorf("invalid packet ID %s: %w", hex, err)
+			}
+			packets["minecraft:"+vanillaPacketName(direction, name, v.Protocol)] = packetEntry{id}
+		}
+		report["play"][direction] = packets
+	}
+	return v, report, nil
+}
+
+// writePackets writes the packets report of a release to dir, named by its protocol as the
+// bot's protocols_dir expects
+func writePackets(dir string, protocol int, report packetReport) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d.json", protocol))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func main() {
+	version := flag.String("version", "", "Minecraft release to generate the tables for, like 1.21.4")
+	data := flag.String("data", defaultData, "URL or local path of the minecraft-data data directory")
+	out := flag.String("out", "registry", "directory of the registry package")
+	packets := flag.String("packets", "", "directory to write the packets report of the release to instead of the tables, like protocols")
+	flag.Parse()
+	if *version == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *packets != "" {
+		log.Printf("📥 Loading the Minecraft %s packets from %s", *version, *data)
+		v, report, err := loadPackets(source(*data), *version)
+		if err != nil {
+			log.Fatalf("❌ Failed to load the packets: %v", err)
+		}
+		path, err := writePackets(*packets, v.Protocol, report)
+		if err != nil {
+			log.Fatalf("❌ Failed to write the packets report: %v", err)
+		}
+		log.Printf("✅ Wrote the packets of Minecraft %s (protocol %d) to %s", v.Minecraft, v.Protocol, path)
+		return
+	}
+
+	log.Printf("📥 Loading Minecraft %s data from %s", *version, *data)
+	v, blocks, items, err := load(source(*data), *version)
+	if err != nil {
+		log.Fatalf("❌ Failed to load the data: %v", err)
+	}
+	if err := writeTables(*out, v, blocks, items); err != nil {
+		log.Fatalf("❌ Failed to write the tables: %v", err)
+	}
+	log.Printf("✅ Wrote %d blocks and %d items for Minecraft %s (protocol %d) to %s", len(blocks), len(items), v.Minecraft, v.Protocol, *out)
+}
@@ -0,0 +1,117 @@
+// Package world follows the time of day and the weather the server announces, extrapolating
+// the time between its updates.
+package world
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	TicksPerDay    = 24000
+	TicksPerSecond = 20
+	NightStart     = 12542 // Sky light gets low enough for hostile mobs to spawn in clear weather
+	NightEnd       = 23460 // Sky light is back up
+
+	rainThreshold    = 0.2 // Rain level above which it rains, as the vanilla client decides
+	thunderThreshold = 0.9 // Thunder level, scaled by the rain level, above which it thunders
+)
+
+// Weather is the state of the sky
+type Weather string
+
+const (
+	Clear        Weather = "clear"
+	Rain         Weather = "rain" // Snow in cold biomes
+	Thunderstorm Weather = "thunderstorm"
+)
+
+// Clock keeps the time of day and the weather. It is safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	known   bool
+	dayTime int64     // Ticks since the world was created, modulo a day is the time of day
+	at      time.Time // When dayTime was received
+	ticking bool      // False when the daylight cycle is frozen (doDaylightCycle false)
+	rain    float32   // Rain level, 0 to 1
+	thunder float32   // Thunder level, 0 to 1
+}
+
+// SetTime records a time update of the server
+func (c *Clock) SetTime(dayTime int64, ticking bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.known = true
+	c.dayTime = dayTime
+	c.at = time.Now()
+	c.ticking = ticking
+}
+
+// SetRainLevel records a rain level change, which the server sends every tick while the
+// rain starts or stops
+func (c *Clock) SetRainLevel(level float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rain = level
+}
+
+// SetThunderLevel records a thunder level change
+func (c *Clock) SetThunderLevel(level float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.thunder = level
+}
+
+// ClearWeather forgets the weather, for a new world or dimension where the server sends it again
+func (c *Clock) ClearWeather() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rain, c.thunder = 0, 0
+}
+
+// TimeOfDay returns the current time of day in ticks (0 is sunrise, 6000 noon),
+// extrapolated from the last time update
+func (c *Clock) TimeOfDay() (ticks int64, known bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.known {
+		return 0, false
+	}
+	t := c.now()
+	return (t%TicksPerDay + TicksPerDay) % TicksPerDay, true
+}
+
+// Day returns the number of days since the world was created
+func (c *Clock) Day() (day int64, known bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now() / TicksPerDay, c.known
+}
+
+// now returns the extrapolated day time. c.mu must be held.
+func (c *Clock) now() int64 {
+	t := c.dayTime
+	if c.ticking {
+		t += int64(time.Since(c.at) / (time.Second / TicksPerSecond))
+	}
+	return t
+}
+
+// Night reports whether it is night, when hostile mobs spawn under the open sky
+func (c *Clock) Night() (night, known bool) {
+	t, known := c.TimeOfDay()
+	return t >= NightStart && t < NightEnd, known
+}
+
+// Weather returns the current weather
+func (c *Clock) Weather() Weather {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.thunder*c.rain > thunderThreshold:
+		return Thunderstorm
+	case c.rain > rainThreshold:
+		return Rain
+	}
+	return Clear
+}